@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/annotate"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/github"
+	"github.com/spf13/cobra"
+)
+
+func newAnnotateCmd() *cobra.Command {
+	var (
+		base     string
+		head     string
+		empty    string
+		include  []string
+		exclude  []string
+		maxChurn int
+		maxGen   int
+		level    string
+		checkRun string
+		repo     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "annotate [rev-range] [flags] [-- pathspec...]",
+		Short: "Annotate files over churn thresholds for GitHub reviews",
+		Long: `Analyze a range like the main command and emit a GitHub annotation for
+each file whose churn exceeds --max-churn, and for each generated file whose
+churn exceeds --max-generated, so reviewers see the warnings next to the
+files in a pull request.
+
+By default the annotations are printed as workflow commands, which GitHub
+Actions picks up from the job log. With --check-run, they are attached to a
+new check run of that name on the head commit through the GitHub API instead;
+a token is read from GITHUB_TOKEN and needs the checks:write permission.
+
+A negative threshold disables its check. Annotations never change the exit
+code; use 'differ check' to fail a build.
+
+Examples:
+  differ annotate main...HEAD
+  differ annotate --max-churn 300 --max-generated -1
+  differ annotate main...HEAD --check-run "differ churn"`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(annotate.Levels, level) {
+				fmt.Fprintf(stderr, "Error: --level must be one of %s, got %q\n", strings.Join(annotate.Levels, ", "), level)
+				os.Exit(exitInvalidConfig)
+			}
+			runner := gitdiff.DefaultRunner
+			opts := runOpts{
+				base:    base,
+				head:    head,
+				empty:   empty,
+				format:  "text",
+				include: include,
+				exclude: exclude,
+				sort:    "churn",
+				runner:  runner,
+			}
+			validateOpts(opts)
+
+			revArgs, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				revArgs, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(revArgs) > 1 {
+				fmt.Fprintln(stderr, "Error: at most one positional rev-range argument allowed")
+				os.Exit(exitRuntimeError)
+			}
+			var revRange string
+			if len(revArgs) == 1 {
+				revRange = revArgs[0]
+			}
+
+			summary, _ := analyze(opts, revRange, pathspecs)
+			anns := annotate.Find(summary, annotate.Thresholds{Churn: maxChurn, Generated: maxGen}, level)
+
+			if checkRun == "" {
+				if err := annotate.WriteCommands(stdout, anns); err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				return nil
+			}
+
+			if repo == "" {
+				repo = detectGitHubRepo(runner)
+			}
+			if repo == "" {
+				fmt.Fprintln(stderr, "Error: cannot determine GitHub repository; pass --repo owner/name")
+				os.Exit(exitRuntimeError)
+			}
+			headSHA := ""
+			if p := summary.Meta.Provenance; p != nil {
+				headSHA = p.HeadCommit
+			}
+			if headSHA == "" {
+				sha, err := gitdiff.ResolveCommit(runner, "HEAD")
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				headSHA = sha
+			}
+
+			out := github.CheckRunOutput{
+				Title:   fmt.Sprintf("%d %s over churn thresholds", len(anns), plural(len(anns), "file")),
+				Summary: fmt.Sprintf("%d lines of churn (+%d -%d) in %d %s.", summary.Totals.Churn, summary.Totals.Added, summary.Totals.Deleted, summary.Totals.FileCount, plural(summary.Totals.FileCount, "file")),
+			}
+			for _, a := range anns {
+				out.Annotations = append(out.Annotations, github.CheckAnnotation{
+					Path:      a.Path,
+					StartLine: 1,
+					EndLine:   1,
+					Level:     checkLevel(a.Level),
+					Title:     a.Title,
+					Message:   a.Message,
+				})
+			}
+			conclusion := "success"
+			if len(anns) > 0 {
+				conclusion = "neutral"
+			}
+			client := github.NewClient(os.Getenv("GITHUB_TOKEN"))
+			if err := client.CreateCheckRun(repo, checkRun, headSHA, conclusion, out); err != nil {
+				fmt.Fprintf(stderr, "Error: creating check run: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			fmt.Fprintf(stdout, "Created check run %q on %.7s with %d %s\n", checkRun, headSHA, len(anns), plural(len(anns), "annotation"))
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&base, "base", "", "base ref")
+	flags.StringVar(&head, "head", "", "head ref")
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.IntVar(&maxChurn, "max-churn", 500, "annotate files with more churn than `N` lines (negative to disable)")
+	flags.IntVar(&maxGen, "max-generated", 0, "annotate generated files with more churn than `N` lines (negative to disable)")
+	flags.StringVar(&level, "level", "warning", "annotation level ("+strings.Join(annotate.Levels, "|")+")")
+	flags.StringVar(&checkRun, "check-run", "", "create a check run with this `name` through the GitHub API instead of printing workflow commands")
+	flags.StringVar(&repo, "repo", "", "GitHub repository as owner/name (default: GITHUB_REPOSITORY or origin remote)")
+
+	return cmd
+}
+
+// checkLevel maps a workflow command level to a check run annotation level.
+func checkLevel(level string) string {
+	if level == "error" {
+		return "failure"
+	}
+	return level
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/authors"
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/filter"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/spf13/cobra"
+)
+
+func newAuthorsCmd() *cobra.Command {
+	var (
+		empty          string
+		format         string
+		groupBy        string
+		matrix         bool
+		firstParent    bool
+		ignoreRevsFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "authors [range] [-- pathspec...]",
+		Short: "Aggregate the churn of a range of commits by author or company",
+		Long: `Walk the non-merge commits in a range and sum their churn per author, or,
+with --group-by domain, per author email domain. Domains are mapped to
+organizations with the 'organizations:' config section; a mapping for a
+domain also covers its subdomains, and unmapped domains are reported as is.
+
+With --matrix, churn is broken down by category: a table with a row per
+author or organization and a column per category, showing who writes the
+docs, tests, and source.
+
+With --first-parent, only the commits on the first-parent chain are walked,
+as mainline history reads: each merge counts the changes it brought in once,
+credited to its author, instead of the commits of the branch it merged.
+
+Commits listed in an --ignore-revs-file, in git blame's format, are left
+out, so bulk reformats and renames do not dominate the results.
+
+A single ref is treated as "<ref>..HEAD". With no range, the base is
+auto-detected the same way as the main command. Include and exclude globs
+from the config apply.
+
+Example .differ.yml:
+  organizations:
+    google.com: Google
+    redhat.com: Red Hat
+    users.noreply.github.com: Independent
+
+Examples:
+  differ authors v1.2.0
+  differ authors v1.2.0..v1.3.0 --group-by domain --format json
+  differ authors main...HEAD --matrix`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if empty != "include" && empty != "exclude" {
+				fmt.Fprintf(stderr, "Error: --empty must be 'include' or 'exclude', got %q\n", empty)
+				os.Exit(exitInvalidConfig)
+			}
+			if format != "text" && format != "json" {
+				fmt.Fprintf(stderr, "Error: --format must be 'text' or 'json', got %q\n", format)
+				os.Exit(exitInvalidConfig)
+			}
+			if groupBy != authors.GroupByAuthor && groupBy != authors.GroupByDomain {
+				fmt.Fprintf(stderr, "Error: --group-by must be %s, got %q\n", strings.Join(authors.GroupByValues, " or "), groupBy)
+				os.Exit(exitInvalidConfig)
+			}
+
+			revArgs, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				revArgs, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(revArgs) > 1 {
+				fmt.Fprintln(stderr, "Error: at most one positional rev-range argument allowed")
+				os.Exit(exitRuntimeError)
+			}
+			var revRange string
+			if len(revArgs) == 1 {
+				revRange = revArgs[0]
+			}
+
+			cfg, err := config.Load(topLevel(gitdiff.DefaultRunner), config.Config{Empty: empty})
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+
+			runner := gitdiff.DefaultRunner
+			if revRange == "" {
+				revRange, err = gitdiff.ResolveRefs(runner, "", "", "")
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+			}
+
+			commits, err := history.Log(runner, history.LogRange(revRange), pathspecs, history.Options{
+				Empty:       cfg.Empty,
+				NoMerges:    !firstParent,
+				FirstParent: firstParent,
+				IgnoreRevs:  ignoredRevs(ignoreRevsFile),
+			})
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			categoryOf := pathCategory(cfg)
+			filterCfg := filter.FilterConfig{Include: cfg.Include, Exclude: cfg.Exclude}
+			for i := range commits {
+				commits[i].Files = filter.Filter(commits[i].Files, filterCfg, categoryOf)
+			}
+
+			var classifyPath authors.Classifier
+			if matrix {
+				classifyPath = categoryOf
+			}
+			groups, err := authors.Aggregate(commits, groupBy, cfg.Organizations, classifyPath)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			if matrix {
+				if format == "json" {
+					if err := authors.RenderMatrixJSON(stdout, groupBy, groups); err != nil {
+						fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+						os.Exit(exitRuntimeError)
+					}
+					return nil
+				}
+				authors.RenderMatrixText(stdout, groups)
+				return nil
+			}
+			if format == "json" {
+				if err := authors.RenderJSON(stdout, groupBy, groups); err != nil {
+					fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				return nil
+			}
+			authors.RenderText(stdout, groups)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringVar(&format, "format", "text", "output format (text|json)")
+	flags.StringVar(&groupBy, "group-by", authors.GroupByAuthor, "aggregate by "+strings.Join(authors.GroupByValues, " or "))
+	flags.BoolVar(&matrix, "matrix", false, "show churn per category in a table with a row per group")
+	flags.BoolVar(&firstParent, "first-parent", false, "follow only the first parent of merges, counting each merge's changes once")
+	flags.StringVar(&ignoreRevsFile, "ignore-revs-file", "", "skip the commits listed in `file`, as git blame does (e.g. .git-blame-ignore-revs)")
+
+	return cmd
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jbonatakis/differ/internal/badge"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/spf13/cobra"
+)
+
+func newBadgeCmd() *cobra.Command {
+	var (
+		release string
+		db      string
+		repo    string
+		format  string
+		out     string
+		label   string
+		empty   string
+		include []string
+		exclude []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "badge --release <tag>",
+		Short: "Render a size badge for a release",
+		Long: `Render a badge giving the size of a release: a t-shirt size (XS to XXL)
+and the churn since the previous release. The previous release is the
+nearest tag reachable from the release's parent; a first release is measured
+from the empty tree.
+
+With --db, release churn is kept in the history ledger written by --record:
+a release already recorded for the repository is read back rather than
+recomputed, and a new one is computed and recorded under its tag, so CI can
+stamp every release with a badge. Release records are left out of 'differ
+site' and of percentile limits in 'differ check', which describe ordinary
+runs.
+
+--format svg writes a flat badge image; --format json writes a shields.io
+endpoint description, to be published and used with
+https://img.shields.io/endpoint?url=<published URL>.
+
+Examples:
+  differ badge --release v1.3.0 -o size.svg
+  differ badge --release "$(git describe --tags --abbrev=0)" --db churn.db --format json -o badge.json`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if release == "" {
+				fmt.Fprintln(stderr, "Error: --release is required")
+				os.Exit(exitRuntimeError)
+			}
+			if format != "svg" && format != "json" {
+				fmt.Fprintf(stderr, "Error: --format must be 'svg' or 'json', got %q\n", format)
+				os.Exit(exitInvalidConfig)
+			}
+
+			runner := gitdiff.DefaultRunner
+			if repo == "" {
+				repo = repoName(runner)
+			}
+
+			var records []ledger.Record
+			if db != "" {
+				var err error
+				records, err = ledger.Read(db)
+				if err != nil && !os.IsNotExist(err) {
+					fmt.Fprintf(stderr, "Error: reading history: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+			}
+			rec, ok := ledger.FindRelease(records, repo, release)
+			if !ok {
+				revRange, err := gitdiff.ReleaseRange(runner, release)
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				opts := runOpts{
+					empty:   empty,
+					format:  "text",
+					include: include,
+					exclude: exclude,
+					sort:    "churn",
+					runner:  runner,
+				}
+				validateOpts(opts)
+				summary, _ := analyze(opts, revRange, nil)
+
+				rec = newRecord(runner, summary)
+				rec.Repo = repo
+				rec.Release = release
+				if db != "" {
+					if err := ledger.Append(db, rec); err != nil {
+						fmt.Fprintf(stderr, "Error: recording release: %v\n", err)
+						os.Exit(exitRuntimeError)
+					}
+				}
+			}
+
+			w := io.Writer(stdout)
+			if out != "" {
+				f, err := os.Create(out)
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				defer f.Close()
+				w = f
+			}
+			b := badge.ForChurn(label, rec.Snapshot.Total.Churn)
+			write := badge.WriteSVG
+			if format == "json" {
+				write = badge.WriteEndpoint
+			}
+			if err := write(w, b); err != nil {
+				fmt.Fprintf(stderr, "Error: writing badge: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&release, "release", "", "release `tag` to measure")
+	flags.StringVar(&db, "db", "", "history ledger `file` to read and record release churn in")
+	flags.StringVar(&repo, "repo", "", "repository name in the ledger (default: working tree directory name)")
+	flags.StringVar(&format, "format", "svg", "badge format (svg|json)")
+	flags.StringVarP(&out, "output", "o", "", "write the badge to `file` instead of stdout")
+	flags.StringVar(&label, "label", "release size", "badge label")
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+
+	return cmd
+}
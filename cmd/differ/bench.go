@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/bench"
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/parser"
+	"github.com/jbonatakis/differ/pkg/differ"
+	"github.com/spf13/cobra"
+)
+
+func newBenchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "bench",
+		Short:  "Measure pipeline performance",
+		Hidden: true,
+	}
+	cmd.AddCommand(newBenchGenCmd())
+	return cmd
+}
+
+func newBenchGenCmd() *cobra.Command {
+	var (
+		shape bench.Shape
+		exts  []string
+		runs  int
+		out   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gen",
+		Short: "Generate a synthetic diff and time the pipeline on it",
+		Long: `Generate a synthetic unified diff of the given size and shape and time each
+pipeline stage on it, reporting the fastest of --runs runs:
+
+  parse      counting added and deleted lines
+  moves      counting with --detect-moves
+  summarize  classifying, filtering, and aggregating the parsed files
+
+With --out, the diff is written to a file ("-" for stdout) instead, for use
+with other tools. The same flags always generate the same diff.
+
+Examples:
+  differ bench gen --files 10000 --hunks 5
+  differ bench gen --distribution skewed --ext .go,.ts --out big.diff`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shape.Extensions = exts
+			if err := shape.Validate(); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+			if runs < 1 {
+				fmt.Fprintf(stderr, "Error: --runs must be at least 1, got %d\n", runs)
+				os.Exit(exitInvalidConfig)
+			}
+
+			if out != "" {
+				w := stdout
+				if out != "-" {
+					f, err := os.Create(out)
+					if err != nil {
+						fmt.Fprintf(stderr, "Error: %v\n", err)
+						os.Exit(exitRuntimeError)
+					}
+					defer f.Close()
+					w = f
+				}
+				if _, err := bench.Generate(w, shape); err != nil {
+					fmt.Fprintf(stderr, "Error: writing diff: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				return nil
+			}
+
+			var diff bytes.Buffer
+			start := time.Now()
+			st, err := bench.Generate(&diff, shape)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: generating diff: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			fmt.Fprintf(stdout, "Synthetic diff: %d files, %d hunks, +%d -%d, %s (generated in %s)\n",
+				st.Files, st.Hunks, st.Added, st.Deleted, formatBytes(st.Bytes), roundDuration(time.Since(start)))
+
+			if err := runBench(stdout, diff.Bytes(), st, runs); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&shape.Files, "files", 1000, "number of changed files")
+	flags.IntVar(&shape.Hunks, "hunks", 4, "mean number of hunks per file")
+	flags.IntVar(&shape.Lines, "lines", 5, "lines added and deleted per hunk")
+	flags.StringSliceVar(&exts, "ext", []string{".go", ".ts", ".py", ".md", ".yaml"}, "file extensions, assigned round-robin")
+	flags.StringVar(&shape.Distribution, "distribution", bench.Uniform, "how hunks are spread over files ("+bench.Uniform+"|"+bench.Skewed+")")
+	flags.Uint64Var(&shape.Seed, "seed", 1, "random seed")
+	flags.IntVar(&runs, "runs", 3, "timed runs per stage; the fastest is reported")
+	flags.StringVar(&out, "out", "", "write the diff to `file` (\"-\" for stdout) instead of timing it")
+
+	return cmd
+}
+
+// runBench times each pipeline stage over diff and prints one row per stage.
+func runBench(w io.Writer, diff []byte, st bench.Stats, runs int) error {
+	var parsed []parser.FileStat
+	stages := []struct {
+		name string
+		run  func() error
+	}{
+		{"parse", func() (err error) {
+			parsed, err = parser.ParseWithOptions(bytes.NewReader(diff), parser.ParseOptions{})
+			return err
+		}},
+		{"moves", func() error {
+			_, err := parser.ParseWithOptions(bytes.NewReader(diff), parser.ParseOptions{DetectMoves: true})
+			return err
+		}},
+		{"summarize", func() error {
+			differ.Summarize(noRepoRunner{}, parsed, config.Config{}, nil, nil)
+			return nil
+		}},
+	}
+
+	lines := float64(st.Added + st.Deleted)
+	fmt.Fprintf(w, "%-10s %10s %14s\n", "stage", "time", "lines/s")
+	for _, stage := range stages {
+		best := time.Duration(0)
+		for i := 0; i < runs; i++ {
+			start := time.Now()
+			if err := stage.run(); err != nil {
+				return fmt.Errorf("%s: %w", stage.name, err)
+			}
+			if d := time.Since(start); best == 0 || d < best {
+				best = d
+			}
+		}
+		rate := lines / best.Seconds()
+		fmt.Fprintf(w, "%-10s %10s %14.0f\n", stage.name, roundDuration(best), rate)
+	}
+	return nil
+}
+
+// noRepoRunner is the CommandRunner for benchmarks, which run outside any
+// repository: every git call fails, so optional lookups such as
+// gitattributes are skipped as they would be without git.
+type noRepoRunner struct{}
+
+var errNoRepo = errors.New("benchmarks do not run git")
+
+func (noRepoRunner) Run(name string, args ...string) ([]byte, error) {
+	return nil, errNoRepo
+}
+
+func (noRepoRunner) Start(name string, args ...string) (io.ReadCloser, *exec.Cmd, error) {
+	return nil, nil, errNoRepo
+}
+
+// roundDuration rounds d for display to two decimals in its largest unit.
+func roundDuration(d time.Duration) time.Duration {
+	for unit := time.Second; unit >= time.Nanosecond; unit /= 1000 {
+		if d >= unit {
+			return d.Round(unit / 100)
+		}
+	}
+	return d
+}
+
+// formatBytes renders n with a binary unit, such as "2.3 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), strings.ToUpper("kmgtpe")[exp])
+}
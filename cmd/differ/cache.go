@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/cache"
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// useCache is cleared by --no-cache.
+var useCache = true
+
+// cacheKey returns the key under which the summary of the range from base
+// to head is cached, or false when the result cannot be cached: only ranges
+// between two commits are, since the index and working tree change without
+// notice. cfg is the effective config, after per-directory scopes.
+func cacheKey(opts runOpts, cfg config.Config, base, head, autoBase string, pathspecs []string) (string, bool) {
+	// Recursing into submodules reads their config from disk.
+	if !useCache || reproduction != nil || base == "" || head == "" || opts.subMode == "recurse" {
+		return "", false
+	}
+	// The reference change shown in auto mode depends on where the base
+	// branch is now, not just on the merge base.
+	var autoBaseCommit string
+	if autoBase != "" {
+		sha, err := gitdiff.ResolveCommit(opts.runner, autoBase)
+		if err != nil {
+			return "", false
+		}
+		autoBaseCommit = sha
+	}
+	attrs, err := attributesDigest(opts.runner)
+	if err != nil {
+		return "", false
+	}
+	gitVersion, _ := gitdiff.Version(opts.runner)
+	return cache.Key(
+		version(),
+		gitVersion,
+		fmt.Sprintf("%T", opts.runner),
+		strings.Join(gitdiff.GitArgs, "\x00"),
+		base,
+		head,
+		autoBaseCommit,
+		strings.Join(pathspecs, "\x00"),
+		configHash(cfg),
+		gitdiff.EffectiveAlgorithm(opts.runner, cfg.DiffAlgorithm),
+		attrs,
+		strings.Join(opts.category, "\x00"),
+		strconv.FormatBool(opts.moves),
+		strconv.FormatBool(opts.apiChurn),
+		strconv.FormatBool(opts.schemas),
+		strconv.FormatBool(opts.shebang),
+		strconv.FormatBool(opts.byTeam),
+		strconv.FormatBool(opts.commits),
+		strconv.FormatBool(opts.fileAge),
+		opts.count,
+	), true
+}
+
+// attributesDigest captures the .gitattributes files of the working tree,
+// which refine classification but are read from disk rather than from the
+// compared commits.
+func attributesDigest(runner gitdiff.CommandRunner) (string, error) {
+	top, err := gitdiff.TopLevel(runner)
+	if err != nil {
+		return "", err
+	}
+	files, err := gitdiff.ListFiles(runner, "**/.gitattributes")
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, 2*len(files))
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(top, f))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		parts = append(parts, f, string(data))
+	}
+	return cache.Key(parts...), nil
+}
+
+// cachedSummary returns the summary cached under key, if any.
+func cachedSummary(key string) (output.Summary, bool) {
+	dir, err := cache.Dir()
+	if err != nil {
+		return output.Summary{}, false
+	}
+	return cache.Get(dir, key)
+}
+
+// storeSummary caches summary under key. The cache is only an
+// optimization, so failures to write it are ignored.
+func storeSummary(key string, summary output.Summary) {
+	if dir, err := cache.Dir(); err == nil {
+		_ = cache.Put(dir, key, summary)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jbonatakis/differ/internal/changelog"
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/filter"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/spf13/cobra"
+)
+
+func newChangelogCmd() *cobra.Command {
+	var (
+		empty          string
+		title          string
+		firstParent    bool
+		ignoreRevsFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "changelog [range] [-- pathspec...]",
+		Short: "Emit a markdown changelog skeleton from conventional commits",
+		Long: `Walk the commits in a range, group them by conventional-commit type
+(features, fixes, refactors, ...), and annotate each with its churn and the
+areas it touched. Areas come from the 'areas:' config section, falling back to
+each file's top-level directory.
+
+With --first-parent, only the commits on the first-parent chain are walked,
+as mainline history reads: a merge is listed once, with the changes it
+brought in, instead of the commits of the branch it merged.
+
+Commits listed in an --ignore-revs-file, in git blame's format, such as
+bulk reformats, are left out of the changelog.
+
+A single ref is treated as "<ref>..HEAD". With no range, the base is
+auto-detected the same way as the main command.
+
+Examples:
+  differ changelog v1.2.0
+  differ changelog v1.2.0..v1.3.0 > CHANGELOG-draft.md`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if empty != "include" && empty != "exclude" {
+				fmt.Fprintf(stderr, "Error: --empty must be 'include' or 'exclude', got %q\n", empty)
+				os.Exit(exitInvalidConfig)
+			}
+
+			revArgs, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				revArgs, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(revArgs) > 1 {
+				fmt.Fprintln(stderr, "Error: at most one positional rev-range argument allowed")
+				os.Exit(exitRuntimeError)
+			}
+			var revRange string
+			if len(revArgs) == 1 {
+				revRange = revArgs[0]
+			}
+
+			cfg, err := config.Load(topLevel(gitdiff.DefaultRunner), config.Config{Empty: empty})
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+
+			runner := gitdiff.DefaultRunner
+			if revRange == "" {
+				revRange, err = gitdiff.ResolveRefs(runner, "", "", "")
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+			}
+			logRange := history.LogRange(revRange)
+
+			commits, err := history.Log(runner, logRange, pathspecs, history.Options{
+				Empty:       cfg.Empty,
+				NoMerges:    !firstParent,
+				FirstParent: firstParent,
+				IgnoreRevs:  ignoredRevs(ignoreRevsFile),
+			})
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			categoryOf := pathCategory(cfg)
+			filterCfg := filter.FilterConfig{Include: cfg.Include, Exclude: cfg.Exclude}
+			for i := range commits {
+				commits[i].Files = filter.Filter(commits[i].Files, filterCfg, categoryOf)
+			}
+
+			if title == "" {
+				title = "Changes in " + logRange
+			}
+			changelog.RenderMarkdown(stdout, title, changelog.Build(commits, cfg.Areas))
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringVar(&title, "title", "", "heading for the changelog (default \"Changes in <range>\")")
+	flags.BoolVar(&firstParent, "first-parent", false, "follow only the first parent of merges, counting each merge's changes once")
+	flags.StringVar(&ignoreRevsFile, "ignore-revs-file", "", "skip the commits listed in `file`, as git blame does (e.g. .git-blame-ignore-revs)")
+
+	return cmd
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jbonatakis/differ/internal/gate"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+func newCheckCmd() *cobra.Command {
+	var (
+		base       string
+		head       string
+		empty      string
+		include    []string
+		exclude    []string
+		limits     []string
+		db         string
+		repo       string
+		minSamples int
+		minDocs    float64
+		quiet      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check [rev-range] --max <limit>... [-- pathspec...]",
+		Short: "Fail when churn exceeds fixed or historical limits",
+		Long: `Analyze a range like the main command and check its churn against limits,
+exiting with code 1 if any is exceeded.
+
+A limit is "[metric=]max", where metric is "total" (the default) or a
+category name, and max is either a line count or a percentile of the
+repository's recorded history (see --record), such as p95. Percentile limits
+adapt to each repository: "p95" fails a change larger than 95% of the runs
+recorded for it. They are skipped while fewer than --min-samples runs exist.
+
+--min-docs-ratio requires docs churn of at least that fraction of source
+churn, for teams that expect documentation to change with features. It is
+skipped when no source changed.
+
+Examples:
+  differ check --max 1000 --max generated=0
+  differ check main...HEAD --max p95 --max source=p99 --db churn.db
+  differ check --min-docs-ratio 0.1`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runner := gitdiff.DefaultRunner
+			opts := runOpts{
+				base:    base,
+				head:    head,
+				empty:   empty,
+				format:  "text",
+				include: include,
+				exclude: exclude,
+				sort:    "churn",
+				runner:  runner,
+			}
+			validateOpts(opts)
+
+			checkDocs := cmd.Flags().Changed("min-docs-ratio")
+			if len(limits) == 0 && !checkDocs {
+				fmt.Fprintln(stderr, "Error: at least one --max limit or --min-docs-ratio is required")
+				os.Exit(exitRuntimeError)
+			}
+			if minDocs < 0 {
+				fmt.Fprintf(stderr, "Error: --min-docs-ratio must not be negative, got %g\n", minDocs)
+				os.Exit(exitRuntimeError)
+			}
+			parsed := make([]gate.Limit, 0, len(limits))
+			needHistory := false
+			for _, s := range limits {
+				l, err := gate.ParseLimit(s)
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				needHistory = needHistory || l.Percentile > 0
+				parsed = append(parsed, l)
+			}
+			if needHistory && db == "" {
+				fmt.Fprintln(stderr, "Error: percentile limits require --db")
+				os.Exit(exitRuntimeError)
+			}
+
+			revArgs, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				revArgs, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(revArgs) > 1 {
+				fmt.Fprintln(stderr, "Error: at most one positional rev-range argument allowed")
+				os.Exit(exitRuntimeError)
+			}
+			var revRange string
+			if len(revArgs) == 1 {
+				revRange = revArgs[0]
+			}
+
+			summary, _ := analyze(opts, revRange, pathspecs)
+
+			var history []snapshot.Snapshot
+			if needHistory {
+				records, err := ledger.Read(db)
+				if err != nil && !os.IsNotExist(err) {
+					fmt.Fprintf(stderr, "Error: reading history: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				if repo == "" {
+					repo = repoName(runner)
+				}
+				for _, rec := range ledger.Runs(records) {
+					if rec.Repo == repo {
+						history = append(history, rec.Snapshot)
+					}
+				}
+			}
+
+			// With --quiet only the tally is printed, to stderr.
+			w, tally := stdout, stdout
+			if quiet {
+				w, tally = io.Discard, stderr
+			}
+			var passed, failed, skipped int
+			for _, r := range gate.Evaluate(parsed, summary, history, minSamples) {
+				label := r.Limit.Metric + " churn"
+				switch {
+				case r.Skipped != "":
+					skipped++
+					fmt.Fprintf(w, "SKIP %s: %s\n", label, r.Skipped)
+				case r.Passed():
+					passed++
+					fmt.Fprintf(w, "PASS %s %d <= %d%s\n", label, r.Actual, r.Max, limitSource(r))
+				default:
+					failed++
+					fmt.Fprintf(w, "FAIL %s %d > %d%s\n", label, r.Actual, r.Max, limitSource(r))
+				}
+			}
+			if checkDocs {
+				ratio, ok := output.DocsRatio(summary)
+				switch {
+				case !ok:
+					skipped++
+					fmt.Fprintln(w, "SKIP docs ratio: no source churn")
+				case ratio >= minDocs:
+					passed++
+					fmt.Fprintf(w, "PASS docs ratio %.2f >= %.2f\n", ratio, minDocs)
+				default:
+					failed++
+					fmt.Fprintf(w, "FAIL docs ratio %.2f < %.2f\n", ratio, minDocs)
+				}
+			}
+			fmt.Fprintf(tally, "%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+			if failed > 0 {
+				os.Exit(exitRuntimeError)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&base, "base", "", "base ref")
+	flags.StringVar(&head, "head", "", "head ref")
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&limits, "max", nil, "churn `limit` as [metric=]lines or [metric=]pNN (repeatable)")
+	flags.StringVar(&db, "db", "", "history ledger `file` for percentile limits")
+	flags.StringVar(&repo, "repo", "", "repository name in the ledger (default: working tree directory name)")
+	flags.IntVar(&minSamples, "min-samples", 10, "recorded runs required before percentile limits apply")
+	flags.Float64Var(&minDocs, "min-docs-ratio", 0, "fail when docs churn is less than this `ratio` of source churn, e.g. 0.1")
+	flags.BoolVarP(&quiet, "quiet", "q", false, "print only the tally of limits, to stderr; the exit code reports failures")
+
+	return cmd
+}
+
+// limitSource describes where a percentile limit came from.
+func limitSource(r gate.Result) string {
+	if r.Limit.Percentile == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (p%g of %d recorded runs)", r.Limit.Percentile, r.Samples)
+}
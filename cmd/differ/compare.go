@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+func newCompareCmd() *cobra.Command {
+	var (
+		base     string
+		head     string
+		empty    string
+		list     bool
+		format   string
+		include  []string
+		exclude  []string
+		category []string
+		color    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "compare <baseline.json> [other.json] [-- pathspec...]",
+		Short: "Show churn deltas between a saved baseline and another run",
+		Long: `Compare a snapshot saved with --save-baseline against either a second
+snapshot or a fresh analysis of the current repository.
+
+Examples:
+  differ --save-baseline before.json            # save a baseline
+  differ compare before.json                    # compare baseline to current auto-detected range
+  differ compare before.json after.json         # compare two saved snapshots
+  differ compare before.json --base main --head feature -l`,
+		Args:          cobra.MinimumNArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := runOpts{
+				base:     base,
+				head:     head,
+				empty:    empty,
+				list:     list,
+				format:   format,
+				include:  include,
+				exclude:  exclude,
+				category: category,
+				sort:     "churn",
+				color:    color,
+				runner:   gitdiff.DefaultRunner,
+			}
+			validateOpts(opts)
+
+			files := args
+			var pathspecs []string
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				files, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(files) < 1 || len(files) > 2 {
+				fmt.Fprintln(stderr, "Error: compare expects one or two snapshot files")
+				os.Exit(exitRuntimeError)
+			}
+
+			before := readSnapshot(files[0])
+			var after output.Summary
+			if len(files) == 2 {
+				after = readSnapshot(files[1])
+			} else {
+				requireGit()
+				after, _ = analyze(opts, "", pathspecs)
+			}
+
+			delta := output.Compare(before, after)
+			if format == "json" {
+				if err := output.RenderDeltaJSON(stdout, delta); err != nil {
+					fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				return nil
+			}
+			output.RenderDeltaText(stdout, delta, output.Options{List: list, NoColor: !output.Colorize(color, os.Stdout)})
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&base, "base", "", "base ref for the current run")
+	flags.StringVar(&head, "head", "", "head ref for the current run")
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.BoolVarP(&list, "list", "l", false, "also list files whose churn changed")
+	flags.StringVar(&format, "format", "text", "output format (text|json)")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&category, "category", nil, "restrict to category (docs|tests|source|ci|i18n|generated|other, repeatable)")
+	addColorFlag(cmd, &color)
+
+	return cmd
+}
+
+// readSnapshot loads a saved baseline, exiting with exitRuntimeError if it
+// cannot be read.
+func readSnapshot(path string) output.Summary {
+	snap, err := snapshot.Read(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: reading snapshot %s: %v\n", path, err)
+		os.Exit(exitRuntimeError)
+	}
+	return snap.Summary()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/spf13/cobra"
+)
+
+func newCompareRefsCmd() *cobra.Command {
+	var (
+		empty    string
+		format   string
+		include  []string
+		exclude  []string
+		category []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "compare-refs <rev-range> <rev-range>... [flags] [-- pathspec...]",
+		Short: "Compare the churn profiles of two or more ranges side by side",
+		Long: `Analyze each range and show their churn side by side: per category, the
+churn and its share of the range's total, then each range's totals. Useful
+for choosing between alternative implementations of the same change.
+
+Examples:
+  differ compare-refs main...feature-a main...feature-b
+  differ compare-refs main...a main...b main...c --format markdown
+  differ compare-refs main...a main...b -- internal/`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := runOpts{
+				empty:    empty,
+				format:   format,
+				include:  include,
+				exclude:  exclude,
+				category: category,
+				sort:     "churn",
+				runner:   gitdiff.DefaultRunner,
+			}
+			validateOpts(opts)
+			if format != "text" && format != "json" && format != "markdown" {
+				fmt.Fprintf(stderr, "Error: compare-refs supports text, json, and markdown output, got %q\n", format)
+				os.Exit(exitInvalidConfig)
+			}
+
+			ranges, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				ranges, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(ranges) < 2 {
+				fmt.Fprintln(stderr, "Error: compare-refs needs at least two ranges")
+				os.Exit(exitRuntimeError)
+			}
+
+			layers := make([]output.Layer, 0, len(ranges))
+			for _, r := range ranges {
+				summary, _ := analyze(opts, r, pathspecs)
+				layers = append(layers, output.Layer{Name: r, Summary: summary})
+			}
+
+			switch format {
+			case "json":
+				if err := output.RenderRangesJSON(stdout, layers); err != nil {
+					fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+			case "markdown":
+				output.RenderRangesMarkdown(stdout, layers)
+			default:
+				output.RenderRangesText(stdout, layers)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringVar(&format, "format", "text", "output format (text|json|markdown)")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&category, "category", nil, "restrict to category (docs|tests|source|ci|i18n|generated|other, repeatable)")
+
+	return cmd
+}
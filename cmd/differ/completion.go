@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/classify"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/spf13/cobra"
+)
+
+// addCompletions registers dynamic completions on cmd and its subcommands:
+// --base and --head complete refs, and --category the categories.
+func addCompletions(cmd *cobra.Command) {
+	for _, name := range []string{"base", "head"} {
+		if cmd.Flags().Lookup(name) != nil {
+			_ = cmd.RegisterFlagCompletionFunc(name, completeRefs)
+		}
+	}
+	if cmd.Flags().Lookup("category") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("category", completeCategories)
+	}
+	for _, sub := range cmd.Commands() {
+		addCompletions(sub)
+	}
+}
+
+// completeRefs completes a ref with HEAD and the repository's branches,
+// remote-tracking branches, and tags.
+func completeRefs(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return matchingRefs("", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRevRange completes the rev-range argument: a ref, or after ".."
+// or "...", the second ref of a range. Anything after it is a pathspec and
+// completes as a file.
+func completeRevRange(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Cobra parses a trailing -- while completing, so ArgsLenAtDash cannot
+	// tell a pathspec from the rev-range here.
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	prefix, rest := "", toComplete
+	if i := strings.LastIndex(toComplete, ".."); i >= 0 {
+		prefix, rest = toComplete[:i+2], toComplete[i+2:]
+		if strings.HasPrefix(rest, ".") {
+			prefix, rest = prefix+".", rest[1:]
+		}
+	}
+	return matchingRefs(prefix, rest), cobra.ShellCompDirectiveNoFileComp
+}
+
+// matchingRefs returns HEAD and the repository's refs that start with
+// toComplete, each preceded by prefix. Outside a repository there are none.
+func matchingRefs(prefix, toComplete string) []string {
+	refs, err := gitdiff.ListRefs(gitdiff.DefaultRunner)
+	if err != nil {
+		return nil
+	}
+	var matches []string
+	for _, ref := range append([]string{"HEAD"}, refs...) {
+		if strings.HasPrefix(ref, toComplete) {
+			matches = append(matches, prefix+ref)
+		}
+	}
+	return matches
+}
+
+// completeCategories completes --category with the built-in categories,
+// the only ones a .differ.yml can configure rules for.
+func completeCategories(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return classify.Categories, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jbonatakis/differ/internal/classify"
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/pkg/differ"
+	"github.com/spf13/cobra"
+)
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and validate differ configuration",
+	}
+	cmd.AddCommand(newConfigTestCmd())
+	cmd.AddCommand(newConfigSchemaCmd())
+	return cmd
+}
+
+func newConfigSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for config files",
+		Long: `Print the JSON Schema that .differ.yml and the global config are checked
+against, for editors to validate and complete config files.
+
+Example:
+  differ config schema > .differ.schema.json
+  # then start .differ.yml with:
+  # yaml-language-server: $schema=.differ.schema.json`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := stdout.Write(config.Schema)
+			return err
+		},
+	}
+}
+
+func newConfigTestCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "test",
+		Short: "Verify classification against the expectations section of the config",
+		Long: `Classify every path listed under 'expectations:' in the merged config and
+report any path whose category differs from the expected one.
+
+Example .differ.yml:
+  expectations:
+    internal/gen/api.pb.go: generated
+    handbook/intro.md: docs
+    scripts/release.sh: source`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(topLevel(gitdiff.DefaultRunner), config.Config{})
+			if err == nil {
+				cfg, err = differ.WithScopes(gitdiff.DefaultRunner, cfg)
+			}
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+			if len(cfg.Expectations) == 0 {
+				fmt.Fprintln(stderr, "Error: no expectations defined in config")
+				os.Exit(exitInvalidConfig)
+			}
+
+			mismatches := classify.New(cfg).Verify(cfg.Expectations)
+			for _, m := range mismatches {
+				fmt.Fprintf(stdout, "FAIL %s: expected %s, got %s\n", m.Path, m.Expected, m.Actual)
+			}
+			passed := len(cfg.Expectations) - len(mismatches)
+			fmt.Fprintf(stdout, "%d passed, %d failed\n", passed, len(mismatches))
+			if len(mismatches) > 0 {
+				os.Exit(exitRuntimeError)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jbonatakis/differ/internal/daemon"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/pkg/differ"
+	"github.com/spf13/cobra"
+)
+
+func newDaemonCmd() *cobra.Command {
+	var (
+		configPath string
+		once       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "daemon --config <daemon.yml>",
+		Short: "Periodically fetch repositories and record their churn",
+		Long: `Run continuous churn tracking without cron: on every interval, fetch each
+configured repository, analyze each configured branch against its base,
+append the result to the history ledger, post a webhook notification when a
+threshold is exceeded, and push the latest churn to a Prometheus Pushgateway.
+
+Branches whose head has not moved since they were last recorded are skipped.
+Each repository's own .differ.yml is honored.
+
+Example daemon.yml:
+  interval: 1h
+  db: /var/lib/differ/churn.db
+  webhook: https://hooks.slack.com/services/...
+  pushgateway: http://pushgateway:9091
+  thresholds:
+    churn: 2000
+    categories:
+      generated: 500
+  repos:
+    - name: api
+      path: /srv/repos/api
+      base: main
+      branches: [develop, release]`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configPath == "" {
+				fmt.Fprintln(stderr, "Error: --config is required")
+				os.Exit(exitRuntimeError)
+			}
+			cfg, err := daemon.LoadConfig(configPath)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: loading daemon config: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+
+			d := &daemon.Daemon{Config: cfg, Analyze: analyzeRepo, Log: stderr}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if once {
+				err = d.RunOnce(ctx)
+			} else {
+				err = d.Run(ctx)
+			}
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&configPath, "config", "", "daemon configuration `file`")
+	flags.BoolVar(&once, "once", false, "run a single cycle and exit")
+
+	return cmd
+}
+
+// analyzeRepo runs the diff pipeline for refRange in the repository at dir
+// using that repository's config. Unlike analyze, it reports errors instead
+// of exiting so one failing repository does not stop the daemon.
+func analyzeRepo(dir, refRange string, pathspecs []string) (output.Summary, error) {
+	return differ.Analyze(differ.Options{Dir: dir, RevRange: refRange, Pathspecs: pathspecs})
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jbonatakis/differ/internal/authors"
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/filter"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/spf13/cobra"
+)
+
+func newLeaderboardCmd() *cobra.Command {
+	var (
+		empty          string
+		format         string
+		since          string
+		limit          int
+		includeBots    bool
+		firstParent    bool
+		ignoreRevsFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "leaderboard [rev] [-- pathspec...]",
+		Short: "Rank contributors by churn over a time window",
+		Long: `Rank the authors of the non-merge commits reachable from rev (HEAD by
+default) within a time window by churn, broken down by category. Author
+identities are resolved through .mailmap, so one person committing under
+several names or emails is counted once. Commits by bots such as
+dependabot[bot] or renovate are left out unless --include-bots is set.
+
+With --first-parent, only the commits on the first-parent chain are walked,
+as mainline history reads: each merge counts the changes it brought in once,
+credited to its author, instead of the commits of the branch it merged.
+
+--ignore-revs-file skips the commits it lists, in git blame's format, so
+whoever ran a bulk reformat or rename does not top the board.
+
+--since takes a short age (90d, 6w, 3m, 1y) or any date git log --since
+accepts. Include and exclude globs and custom categories from the config
+apply. --format markdown writes a table that can be pasted into release
+notes or a README; it lists contributors by name only.
+
+Examples:
+  differ leaderboard
+  differ leaderboard --since 90d --format markdown
+  differ leaderboard main --since 2024-01-01 --limit 0 -- src/`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if empty != "include" && empty != "exclude" {
+				fmt.Fprintf(stderr, "Error: --empty must be 'include' or 'exclude', got %q\n", empty)
+				os.Exit(exitInvalidConfig)
+			}
+			if format != "text" && format != "markdown" && format != "json" {
+				fmt.Fprintf(stderr, "Error: --format must be 'text', 'markdown', or 'json', got %q\n", format)
+				os.Exit(exitInvalidConfig)
+			}
+			if limit < 0 {
+				fmt.Fprintf(stderr, "Error: --limit must not be negative, got %d\n", limit)
+				os.Exit(exitInvalidConfig)
+			}
+
+			revArgs, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				revArgs, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(revArgs) > 1 {
+				fmt.Fprintln(stderr, "Error: at most one positional rev argument allowed")
+				os.Exit(exitRuntimeError)
+			}
+			rev := "HEAD"
+			if len(revArgs) == 1 {
+				rev = revArgs[0]
+			}
+
+			cfg, err := config.Load(topLevel(gitdiff.DefaultRunner), config.Config{Empty: empty})
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+
+			runner := gitdiff.DefaultRunner
+			commits, err := history.Log(runner, rev, pathspecs, history.Options{
+				Empty:       cfg.Empty,
+				NoMerges:    !firstParent,
+				FirstParent: firstParent,
+				IgnoreRevs:  ignoredRevs(ignoreRevsFile),
+				Since:       history.SinceDate(since),
+			})
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			if !includeBots {
+				commits = authors.WithoutBots(commits)
+			}
+			categoryOf := pathCategory(cfg)
+			filterCfg := filter.FilterConfig{Include: cfg.Include, Exclude: cfg.Exclude}
+			for i := range commits {
+				commits[i].Files = filter.Filter(commits[i].Files, filterCfg, categoryOf)
+			}
+
+			groups, err := authors.Aggregate(commits, authors.GroupByAuthor, nil, categoryOf)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			if limit > 0 && len(groups) > limit {
+				groups = groups[:limit]
+			}
+
+			switch format {
+			case "json":
+				if err := authors.RenderJSON(stdout, authors.GroupByAuthor, groups); err != nil {
+					fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+			case "markdown":
+				authors.RenderMarkdown(stdout, "Top contributors since "+since, groups)
+			default:
+				authors.RenderText(stdout, groups)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringVar(&format, "format", "text", "output format (text|markdown|json)")
+	flags.StringVar(&since, "since", "90d", "only count commits newer than this age (90d, 6w, 3m, 1y) or date")
+	flags.IntVar(&limit, "limit", 10, "show at most this many contributors (0 for all)")
+	flags.BoolVar(&includeBots, "include-bots", false, "count commits by bots such as dependabot[bot]")
+	flags.BoolVar(&firstParent, "first-parent", false, "follow only the first parent of merges, counting each merge's changes once")
+	flags.StringVar(&ignoreRevsFile, "ignore-revs-file", "", "skip the commits listed in `file`, as git blame does (e.g. .git-blame-ignore-revs)")
+
+	return cmd
+}
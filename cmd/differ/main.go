@@ -1,17 +1,35 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jbonatakis/differ/internal/classify"
 	"github.com/jbonatakis/differ/internal/config"
-	"github.com/jbonatakis/differ/internal/filter"
+	"github.com/jbonatakis/differ/internal/deps"
+	"github.com/jbonatakis/differ/internal/gate"
 	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/goapi"
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/jbonatakis/differ/internal/infra"
+	"github.com/jbonatakis/differ/internal/migrate"
 	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/owners"
 	"github.com/jbonatakis/differ/internal/parser"
+	"github.com/jbonatakis/differ/internal/progress"
+	"github.com/jbonatakis/differ/internal/schema"
+	"github.com/jbonatakis/differ/internal/snapshot"
+	"github.com/jbonatakis/differ/pkg/differ"
 	"github.com/spf13/cobra"
 )
 
@@ -25,16 +43,44 @@ const (
 	exitInvalidConfig = 2
 )
 
+// stdout and stderr receive all command output; --ascii wraps them so that
+// only ASCII is written.
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
 func main() {
 	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		if errors.As(err, new(flagError)) {
+			os.Exit(exitInvalidConfig)
+		}
 		os.Exit(exitRuntimeError)
 	}
 }
 
+// flagError is a command line that does not parse, such as one with an
+// unknown flag.
+type flagError struct{ error }
+
+// flagErrorFunc marks err as a flagError. Subcommands inherit it from the
+// root command, and since only the root command reads diffs through a
+// backend, their error for --backend says so.
+func flagErrorFunc(cmd *cobra.Command, err error) error {
+	if cmd != cmd.Root() && strings.Contains(err.Error(), "--backend") {
+		err = fmt.Errorf("%w; --backend applies to differ itself, not to %s", err, cmd.CommandPath())
+	}
+	return flagError{err}
+}
+
 func newRootCmd() *cobra.Command {
 	var (
 		base     string
 		head     string
+		since    string
+		until    string
+		threeDot bool
 		empty    string
 		list     bool
 		listOnly bool
@@ -43,14 +89,56 @@ func newRootCmd() *cobra.Command {
 		exclude  []string
 		category []string
 		sort     string
-		noColor  bool
+		net      bool
+		top      int
+		topGlob  bool
+		groupBy  string
+		color    string
+		saveBase string
+		record   string
+		staged   bool
+		unstaged bool
+		backend  string
+		ignoreWS bool
+		diffAlgo string
+		moves    bool
+		apiChurn bool
+		schemas  bool
+		subMode  string
+		shebang  bool
+		linkTmpl string
+		wtA      string
+		wtB      string
+		patch    string
+		repro    string
+		ascii    bool
+		gitBin   string
+		gitArgs  []string
+		noCache  bool
+		noPager  bool
+		fast     bool
+		relative bool
+		relTo    string
+		relOnly  bool
+		failOn   []string
+		quiet    bool
+		worktree string
+		byTeam   bool
+		count    string
+		jsonCats string
+		compact  bool
+		commits  bool
+		fileAge  bool
+		print0   bool
+		noHeader bool
+		icons    bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "differ [rev-range] [flags] [-- pathspec...]",
 		Short: "Git-aware line-of-code churn reporting",
 		Long: `differ is a Git-aware CLI tool that reports line-of-code churn between two refs,
-grouped into practical categories (docs, tests, source, generated, other).
+grouped into practical categories (docs, tests, source, ci, generated, other).
 
 Examples:
   differ                                          # auto-detect base ref
@@ -59,14 +147,51 @@ Examples:
   differ --empty include -l                       # include empty lines, show file list
   differ --format json --exclude 'vendor/**'      # JSON output, exclude vendor
   differ -- docs/ internal/                       # restrict to pathspecs`,
-		Args: cobra.ArbitraryArgs,
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completeRevRange,
 		// Silence default Cobra error/usage printing so we control exit codes.
 		SilenceErrors: true,
 		SilenceUsage:  true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if ascii {
+				stdout = output.NewASCIIWriter(os.Stdout)
+				stderr = output.NewASCIIWriter(os.Stderr)
+			}
+			asciiOnly = ascii
+			gitdiff.GitBin, gitdiff.GitArgs = gitBin, gitArgs
+			traceGit()
+			useCache = !noCache
+			usePager = !noPager
+			if cmd.Parent() == cmd.Root() && slices.Contains(gitCommands, cmd.Name()) {
+				requireGit()
+			}
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return run(cmd, args, runOpts{
+			if repro != "" {
+				if len(args) > 0 || cmd.Flags().NFlag() > 1 {
+					fmt.Fprintln(stderr, "Error: --reproduce takes its arguments from the report and cannot be combined with others")
+					os.Exit(exitRuntimeError)
+				}
+				reproduceReport(repro)
+				return nil
+			}
+			runner, err := gitdiff.NewBackend(backend)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: --backend: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+			if backend != "git" && (cmd.Flags().Changed("git-bin") || cmd.Flags().Changed("git-arg")) {
+				fmt.Fprintf(stderr, "Error: --git-bin and --git-arg apply to the git backend, not %s\n", backend)
+				os.Exit(exitInvalidConfig)
+			}
+			if patch == "" && backend == "git" {
+				requireGit()
+			}
+			opts := runOpts{
 				base:     base,
 				head:     head,
+				since:    since,
+				until:    until,
 				empty:    empty,
 				list:     list,
 				listOnly: listOnly,
@@ -75,31 +200,220 @@ Examples:
 				exclude:  exclude,
 				category: category,
 				sort:     sort,
-				noColor:  noColor,
-				runner:   gitdiff.DefaultRunner,
-			})
+				net:      net,
+				top:      top,
+				topGlob:  topGlob,
+				groupBy:  groupBy,
+				color:    color,
+				saveBase: saveBase,
+				record:   record,
+				staged:   staged,
+				unstaged: unstaged,
+				diffAlgo: diffAlgo,
+				moves:    moves,
+				apiChurn: apiChurn,
+				schemas:  schemas,
+				subMode:  subMode,
+				shebang:  shebang,
+				linkTmpl: linkTmpl,
+				wtA:      wtA,
+				wtB:      wtB,
+				patch:    patch,
+				relative: relative,
+				relTo:    relTo,
+				relOnly:  relOnly,
+				quiet:    quiet,
+				worktree: worktree,
+				byTeam:   byTeam,
+				count:    count,
+				jsonCats: jsonCats,
+				compact:  compact,
+				commits:  commits,
+				fileAge:  fileAge,
+				print0:   print0,
+				noHeader: noHeader,
+				icons:    icons,
+				runner:   runner,
+				backend:  backend,
+			}
+			if cmd.Flags().Changed("ignore-whitespace") {
+				opts.ignoreWS = &ignoreWS
+			}
+			for _, s := range failOn {
+				c, err := gate.ParseCondition(s)
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: --fail-on: %v\n", err)
+					os.Exit(exitInvalidConfig)
+				}
+				if c.Metric != gate.TotalMetric && !slices.Contains(classify.Categories, c.Metric) {
+					fmt.Fprintf(stderr, "Error: --fail-on %s: metric must be total or a category (%s)\n", s, strings.Join(classify.Categories, ", "))
+					os.Exit(exitInvalidConfig)
+				}
+				opts.failOn = append(opts.failOn, c)
+			}
+			configDefaults(cmd, args, &opts)
+			if cmd.Flags().Changed("merge-base") && (opts.base == "" || opts.head == "") {
+				fmt.Fprintln(stderr, "Error: --merge-base needs both --base and --head")
+				os.Exit(exitRuntimeError)
+			}
+			opts.twoDot = !threeDot
+			if fast {
+				if cmd.Flags().Changed("empty") && empty != "include" {
+					fmt.Fprintln(stderr, "Error: --fast counts every changed line and cannot be combined with --empty exclude")
+					os.Exit(exitRuntimeError)
+				}
+				if moves || patch != "" || count == output.CountMeaningful {
+					fmt.Fprintln(stderr, "Error: --fast reads git's per-file counts and cannot be combined with --detect-moves, --count meaningful, or --patch-file")
+					os.Exit(exitRuntimeError)
+				}
+				opts.empty = "include"
+			}
+			return run(cmd, args, opts)
 		},
 	}
 
+	cmd.AddCommand(newShowCmd())
+	cmd.AddCommand(newCompareCmd())
+	cmd.AddCommand(newCompareRefsCmd())
+	cmd.AddCommand(newConfigCmd())
+	cmd.AddCommand(newRulesCmd())
+	cmd.AddCommand(newChangelogCmd())
+	cmd.AddCommand(newAuthorsCmd())
+	cmd.AddCommand(newMergesCmd())
+	cmd.AddCommand(newLeaderboardCmd())
+	cmd.AddCommand(newReviewersCmd())
+	cmd.AddCommand(newStackCmd())
+	cmd.AddCommand(newMultiCmd())
+	cmd.AddCommand(newSiteCmd())
+	cmd.AddCommand(newTrendCmd())
+	cmd.AddCommand(newBadgeCmd())
+	cmd.AddCommand(newDaemonCmd())
+	cmd.AddCommand(newServeCmd())
+	cmd.AddCommand(newCheckCmd())
+	cmd.AddCommand(newAnnotateCmd())
+	cmd.AddCommand(newNotifyCmd())
+	cmd.AddCommand(newSetupCmd())
+	cmd.AddCommand(newBenchCmd())
+	cmd.SetFlagErrorFunc(flagErrorFunc)
+
+	cmd.PersistentFlags().BoolVar(&ascii, "ascii", false, "write only ASCII: plain punctuation, no box-drawing characters or emoji")
+	cmd.PersistentFlags().StringVar(&gitBin, "git-bin", "git", "git executable to run, by name or `path`")
+	cmd.PersistentFlags().StringArrayVar(&gitArgs, "git-arg", nil, "argument passed to every git invocation before the subcommand (repeatable, e.g. --git-arg=-c --git-arg=diff.algorithm=histogram)")
+	cmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "always analyze, neither reading nor writing the result cache")
+	cmd.PersistentFlags().BoolVar(&noPager, "no-pager", false, "write to the terminal directly instead of through $PAGER")
+	cmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "log git commands and stage timings to stderr; -vv adds output sizes and git's error output")
+
 	flags := cmd.Flags()
 	flags.StringVar(&base, "base", "", "base ref")
 	flags.StringVar(&head, "head", "", "head ref")
+	flags.BoolVar(&threeDot, "merge-base", true, "compare --base and --head from their merge base (base...head); --merge-base=false compares them directly (base..head)")
+	flags.StringVar(&since, "since", "", "compare from the last commit before this age (2w, 30d, 3m, 1y) or date; on the current branch or --head")
+	flags.StringVar(&until, "until", "", "compare up to the last commit before this age or date, with --since or alone")
 	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.BoolVar(&fast, "fast", false, "read git's per-file line counts instead of parsing the patch; implies --empty include")
 	flags.BoolVarP(&list, "list", "l", false, "show summary plus per-file list")
 	flags.BoolVarP(&listOnly, "list-only", "L", false, "show per-file list only")
-	flags.StringVar(&format, "format", "text", "output format (text|json)")
-	flags.StringArrayVar(&include, "include", nil, "include path glob (repeatable)")
-	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob (repeatable)")
-	flags.StringArrayVar(&category, "category", nil, "restrict to category (docs|tests|source|generated|other, repeatable)")
-	flags.StringVar(&sort, "sort", "churn", "file list ordering (churn|path)")
-	flags.BoolVar(&noColor, "no-color", false, "disable colorized text output")
+	flags.BoolVarP(&print0, "print0", "z", false, "write only the paths of the file list, each followed by a NUL byte, for xargs -0")
+	flags.StringVar(&format, "format", "text", "output format ("+strings.Join(output.Formats(), "|")+")")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&category, "category", nil, "restrict to category (docs|tests|source|ci|i18n|generated|other, repeatable)")
+	flags.StringVar(&sort, "sort", "churn", "file list ordering ("+strings.Join(output.SortModes, "|")+")")
+	flags.BoolVar(&net, "net", false, "show net lines (added minus deleted) per category and file")
+	flags.IntVar(&top, "top", 0, "list only the `N` highest-churn files per group (implies -l)")
+	flags.BoolVar(&topGlob, "top-global", false, "apply --top to the whole file list instead of per group")
+	flags.StringVar(&groupBy, "group-by", "category", "file list grouping ("+strings.Join(output.GroupModes, "|")+")")
+	flags.StringVar(&jsonCats, "json-categories", output.JSONCategoriesMap, "how JSON output writes by_category ("+strings.Join(output.JSONCategoryModes, "|")+"): array lists categories in report order")
+	flags.BoolVar(&compact, "compact", false, "write JSON on a single line, without indentation")
+	flags.BoolVar(&noHeader, "no-header", false, "leave the header row out of table and csv output")
+	flags.BoolVar(&icons, "icons", false, "show an icon before each category in text and markdown output")
+	addColorFlag(cmd, &color)
+	flags.BoolVar(&staged, "staged", false, "diff the index against HEAD (or --base) instead of commits or the worktree")
+	flags.BoolVar(&staged, "cached", false, "alias for --staged")
+	flags.BoolVar(&unstaged, "unstaged", false, "diff the worktree against the index (changes not yet staged)")
+	flags.BoolVarP(&ignoreWS, "ignore-whitespace", "w", false, "ignore whitespace when comparing lines (git diff -w)")
+	flags.StringVar(&diffAlgo, "diff-algorithm", "", "git diff algorithm ("+strings.Join(gitdiff.DiffAlgorithms, "|")+"); default: git's diff.algorithm setting")
+	flags.BoolVar(&moves, "detect-moves", false, "report lines that moved between files separately instead of as churn")
+	flags.StringVar(&count, "count", output.CountLines, "changed lines to count ("+strings.Join(output.CountModes, "|")+"): meaningful leaves out comment-only and blank lines")
+	flags.BoolVar(&apiChurn, "api-churn", false, "report changed lines that touch exported Go declarations separately")
+	flags.BoolVar(&commits, "commit-counts", false, "count the commits in the range that changed each file, following renames")
+	flags.BoolVar(&fileAge, "file-age", false, "add when each file was created and last changed before the range to JSON output")
+	flags.BoolVar(&schemas, "schema-changes", false, "compare changed OpenAPI, GraphQL, and protobuf schemas structurally")
+	flags.BoolVar(&byTeam, "by-team", false, "roll churn up per team, using the path globs of the 'teams:' config section")
+	flags.StringVar(&worktree, "worktree", "auto", "compare against the working tree ("+strings.Join(worktreeModes, "|")+"): auto includes local edits only without refs and when the tree is dirty")
+	flags.StringVar(&subMode, "submodules", "pointer", "submodule handling ("+strings.Join(submoduleModes, "|")+"): list moved pointers, or also analyze each submodule's own range")
+	flags.BoolVar(&shebang, "shebang", false, "detect the language of extensionless files from their #! line")
+	flags.BoolVar(&relative, "relative", false, "show paths relative to the current directory instead of the repository root")
+	flags.StringVar(&relTo, "relative-to", "", "show paths relative to `dir`, given from the repository root, instead of the root")
+	flags.BoolVar(&relOnly, "relative-only", false, "with --relative-to, leave out files outside its directory")
+	flags.StringArrayVar(&failOn, "fail-on", nil, "exit with code 1 when a `condition` such as 'generated.churn>0' or 'total.files>=50' holds (repeatable)")
+	flags.BoolVarP(&quiet, "quiet", "q", false, "print no report, only a one-line summary to stderr; the exit code reports --fail-on")
+	flags.StringVar(&linkTmpl, "link-template", "", "URL `template` for per-file links in JSON, markdown, and HTML output ({path}, {base}, {head})")
+	flags.StringVar(&wtA, "worktree-a", "", "`path` of the worktree to compare from, including uncommitted changes (requires --worktree-b)")
+	flags.StringVar(&wtB, "worktree-b", "", "`path` of the worktree to compare to, including uncommitted changes (requires --worktree-a)")
+	flags.StringVar(&patch, "patch-file", "", "summarize the git-format patch in `file` (- for stdin) instead of running git diff")
+	flags.StringVar(&backend, "backend", "git", "diff backend ("+strings.Join(gitdiff.Backends(), "|")+")")
+	flags.StringVar(&saveBase, "save-baseline", "", "write a snapshot of this run to `file` for later use with 'differ compare'")
+	flags.StringVar(&repro, "reproduce", "", "re-run the analysis recorded in the provenance of JSON `report` and compare the totals")
+	flags.StringVar(&record, "record", "", "append this run to the history ledger `file` (see 'differ site'); sqlite://path appends to a SQLite database")
+	_ = cmd.RegisterFlagCompletionFunc("format", completeFormats)
+	addCompletions(cmd)
 
 	return cmd
 }
 
+// categoryIcons returns the icons to show before categories if on is set:
+// the built-in ones, with those configured in their place. It returns nil
+// otherwise.
+func categoryIcons(on bool, configured map[string]string) map[string]string {
+	if !on {
+		return nil
+	}
+	icons := maps.Clone(output.DefaultIcons)
+	maps.Copy(icons, configured)
+	return icons
+}
+
+// addColorFlag registers --color on cmd, along with --no-color, which it
+// replaced, as a hidden alias for --color never.
+func addColorFlag(cmd *cobra.Command, color *string) {
+	flags := cmd.Flags()
+	flags.StringVar(color, "color", "auto", "colorize text output ("+strings.Join(output.ColorModes, "|")+"); auto colors terminals unless NO_COLOR is set")
+	flags.Var(noColorFlag{color}, "no-color", "same as --color never")
+	flags.Lookup("no-color").NoOptDefVal = "true"
+	_ = flags.MarkHidden("no-color")
+	_ = cmd.RegisterFlagCompletionFunc("color", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return output.ColorModes, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// noColorFlag is the --no-color flag; setting it sets --color to never.
+type noColorFlag struct{ color *string }
+
+func (f noColorFlag) String() string {
+	return strconv.FormatBool(f.color != nil && *f.color == "never")
+}
+func (f noColorFlag) Type() string { return "bool" }
+
+func (f noColorFlag) Set(s string) error {
+	on, err := strconv.ParseBool(s)
+	if err == nil && on {
+		*f.color = "never"
+	}
+	return err
+}
+
+// completeFormats completes --format with the registered output formats.
+func completeFormats(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return output.Formats(), cobra.ShellCompDirectiveNoFileComp
+}
+
 type runOpts struct {
 	base     string
 	head     string
+	twoDot   bool   // compare base and head directly (base..head), not from their merge base
+	since    string // with until, a date range on head's history; see gitdiff.DateRange
+	until    string
 	empty    string
 	list     bool
 	listOnly bool
@@ -108,28 +422,104 @@ type runOpts struct {
 	exclude  []string
 	category []string
 	sort     string
-	noColor  bool
+	net      bool
+	top      int
+	topGlob  bool
+	groupBy  string
+	color    string // one of output.ColorModes
+	saveBase string
+	record   string
+	staged   bool
+	unstaged bool
+	ignoreWS *bool // nil when not set on the command line
+	diffAlgo string
+	moves    bool
+	apiChurn bool
+	schemas  bool
+	subMode  string // one of submoduleModes
+	shebang  bool
+	linkTmpl string
+	wtA      string
+	wtB      string
+	patch    string // patch file to read instead of running git diff; - for stdin
+	relative bool
+	relTo    string           // root-relative directory to show paths relative to
+	relOnly  bool             // restrict the diff to relTo
+	failOn   []gate.Condition // exit with exitRuntimeError when any holds
+	quiet    bool             // print only a one-line summary, to stderr
+	worktree string           // one of worktreeModes; empty means auto
+	byTeam   bool             // roll churn up per team from the teams config
+	count    string           // one of output.CountModes; empty means lines
+	jsonCats string           // one of output.JSONCategoryModes
+	compact  bool             // write JSON on one line
+	commits  bool             // count the commits that changed each file
+	fileAge  bool             // look up when each file was created and last changed
+	print0   bool             // write only NUL-terminated paths
+	noHeader bool             // leave out the header row of table and csv output
+	icons    bool             // show category icons in text and markdown output
 	runner   gitdiff.CommandRunner
+	backend  string // name of the backend runner comes from, for errors
 }
 
-func run(cmd *cobra.Command, args []string, opts runOpts) error {
-	// Validate --empty flag value.
-	if opts.empty != "include" && opts.empty != "exclude" {
-		fmt.Fprintf(os.Stderr, "Error: --empty must be 'include' or 'exclude', got %q\n", opts.empty)
+// configDefaults fills in the options not given on the command line from
+// the repo and global config, so that, for example, a repository can make
+// JSON its default format. Configured refs apply only when no refs, range,
+// or other source of changes is given.
+func configDefaults(cmd *cobra.Command, args []string, opts *runOpts) {
+	cfg, err := config.Load(topLevel(opts.runner), config.Config{})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
 		os.Exit(exitInvalidConfig)
 	}
-
-	// Validate --format flag value.
-	if opts.format != "text" && opts.format != "json" {
-		fmt.Fprintf(os.Stderr, "Error: --format must be 'text' or 'json', got %q\n", opts.format)
-		os.Exit(exitInvalidConfig)
+	flags := cmd.Flags()
+	if !flags.Changed("empty") {
+		if cfg.Empty != "include" && cfg.Empty != "exclude" {
+			fmt.Fprintf(stderr, "Error: loading config: empty must be 'include' or 'exclude', got %q\n", cfg.Empty)
+			os.Exit(exitInvalidConfig)
+		}
+		opts.empty = cfg.Empty
+	}
+	if !flags.Changed("sort") {
+		if !slices.Contains(output.SortModes, cfg.Sort) {
+			fmt.Fprintf(stderr, "Error: loading config: sort must be one of %s, got %q\n", strings.Join(output.SortModes, ", "), cfg.Sort)
+			os.Exit(exitInvalidConfig)
+		}
+		opts.sort = cfg.Sort
+	}
+	if !flags.Changed("format") && cfg.Format != "" {
+		if _, err := output.LookupRenderer(cfg.Format); err != nil {
+			fmt.Fprintf(stderr, "Error: loading config: format: %v\n", err)
+			os.Exit(exitInvalidConfig)
+		}
+		opts.format = cfg.Format
+	}
+	if !flags.Changed("list") && cfg.List != nil {
+		opts.list = *cfg.List
+	}
+	if !flags.Changed("color") && !flags.Changed("no-color") && cfg.Color != "" {
+		opts.color = cfg.Color
+	}
+	if !flags.Changed("icons") && cfg.Icons != nil {
+		opts.icons = *cfg.Icons
+	}
+	if !flags.Changed("category") && len(cfg.Category) > 0 {
+		opts.category = cfg.Category
 	}
 
-	// Validate --sort flag value.
-	if opts.sort != "churn" && opts.sort != "path" {
-		fmt.Fprintf(os.Stderr, "Error: --sort must be 'churn' or 'path', got %q\n", opts.sort)
-		os.Exit(exitInvalidConfig)
+	revArgs := len(args)
+	if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+		revArgs = dashIdx
+	}
+	explicitRefs := opts.base != "" || opts.head != "" || opts.since != "" || opts.until != "" || revArgs > 0 ||
+		opts.staged || opts.unstaged || opts.wtA != "" || opts.wtB != "" || opts.patch != ""
+	if !explicitRefs {
+		opts.base, opts.head = cfg.Base, cfg.Head
 	}
+}
+
+func run(cmd *cobra.Command, args []string, opts runOpts) error {
+	validateOpts(opts)
+	checkBackend(opts)
 
 	// Split args into rev-range (before --) and pathspecs (after --).
 	var revRange string
@@ -137,7 +527,7 @@ func run(cmd *cobra.Command, args []string, opts runOpts) error {
 	dashIdx := cmd.ArgsLenAtDash()
 	if dashIdx >= 0 {
 		if dashIdx > 1 {
-			fmt.Fprintln(os.Stderr, "Error: at most one positional rev-range argument allowed")
+			fmt.Fprintln(stderr, "Error: at most one positional rev-range argument allowed")
 			os.Exit(exitRuntimeError)
 		}
 		if dashIdx == 1 {
@@ -146,7 +536,7 @@ func run(cmd *cobra.Command, args []string, opts runOpts) error {
 		pathspecs = args[dashIdx:]
 	} else {
 		if len(args) > 1 {
-			fmt.Fprintln(os.Stderr, "Error: at most one positional rev-range argument allowed")
+			fmt.Fprintln(stderr, "Error: at most one positional rev-range argument allowed")
 			os.Exit(exitRuntimeError)
 		}
 		if len(args) == 1 {
@@ -154,156 +544,1163 @@ func run(cmd *cobra.Command, args []string, opts runOpts) error {
 		}
 	}
 
+	if opts.staged && (opts.head != "" || revRange != "") {
+		fmt.Fprintln(stderr, "Error: --staged cannot be combined with --head or a rev-range")
+		os.Exit(exitRuntimeError)
+	}
+	if opts.unstaged && (opts.staged || opts.base != "" || opts.head != "" || revRange != "") {
+		fmt.Fprintln(stderr, "Error: --unstaged cannot be combined with --staged, --base, --head, or a rev-range")
+		os.Exit(exitRuntimeError)
+	}
+
+	if (opts.wtA == "") != (opts.wtB == "") {
+		fmt.Fprintln(stderr, "Error: --worktree-a and --worktree-b must be used together")
+		os.Exit(exitRuntimeError)
+	}
+	if opts.wtA != "" && (opts.staged || opts.unstaged || opts.base != "" || opts.head != "" || revRange != "") {
+		fmt.Fprintln(stderr, "Error: --worktree-a/--worktree-b cannot be combined with refs, --staged, or --unstaged")
+		os.Exit(exitRuntimeError)
+	}
+
+	if opts.patch != "" && (opts.staged || opts.unstaged || opts.wtA != "" || opts.base != "" || opts.head != "" || revRange != "") {
+		fmt.Fprintln(stderr, "Error: --patch-file cannot be combined with refs, --staged, --unstaged, or worktrees")
+		os.Exit(exitRuntimeError)
+	}
+	if opts.patch != "" && (opts.apiChurn || opts.schemas || opts.shebang || opts.subMode == "recurse" || opts.ignoreWS != nil || opts.diffAlgo != "") {
+		fmt.Fprintln(stderr, "Error: --api-churn, --schema-changes, --shebang, --submodules recurse, --ignore-whitespace, and --diff-algorithm need git and cannot be combined with --patch-file")
+		os.Exit(exitRuntimeError)
+	}
+	if opts.commits && (opts.staged || opts.unstaged || opts.wtA != "" || opts.patch != "") {
+		fmt.Fprintln(stderr, "Error: --commit-counts needs a range of commits and cannot be combined with --staged, --unstaged, worktrees, or --patch-file")
+		os.Exit(exitRuntimeError)
+	}
+	if opts.fileAge && (opts.unstaged || opts.wtA != "" || opts.patch != "") {
+		fmt.Fprintln(stderr, "Error: --file-age needs the history before the change and cannot be combined with --unstaged, worktrees, or --patch-file")
+		os.Exit(exitRuntimeError)
+	}
+	if opts.worktree != "" && opts.worktree != "auto" && (opts.staged || opts.unstaged || opts.wtA != "" || opts.patch != "") {
+		fmt.Fprintln(stderr, "Error: --worktree include and exclude cannot be combined with --staged, --unstaged, worktrees, or --patch-file")
+		os.Exit(exitRuntimeError)
+	}
+	if opts.patch != "" && len(pathspecs) > 0 {
+		fmt.Fprintln(stderr, "Error: --patch-file does not take pathspecs; use --include and --exclude")
+		os.Exit(exitRuntimeError)
+	}
+	// --relative-only is a pathspec for the --relative-to directory, from
+	// the root wherever differ runs. Pathspecs add up rather than narrow
+	// each other, so it cannot join the user's own.
+	if opts.relOnly {
+		if opts.patch != "" || len(pathspecs) > 0 {
+			fmt.Fprintln(stderr, "Error: --relative-only cannot be combined with pathspecs or --patch-file; use --include and --exclude")
+			os.Exit(exitRuntimeError)
+		}
+		pathspecs = []string{":(top)" + path.Clean(filepath.ToSlash(opts.relTo))}
+	}
+
+	if opts.since != "" || opts.until != "" {
+		if opts.base != "" || revRange != "" || opts.staged || opts.unstaged || opts.wtA != "" || opts.patch != "" {
+			fmt.Fprintln(stderr, "Error: --since and --until cannot be combined with --base, a rev-range, --staged, --unstaged, worktrees, or --patch-file")
+			os.Exit(exitRuntimeError)
+		}
+		dateRange, err := gitdiff.DateRange(opts.runner, history.SinceDate(opts.since), history.SinceDate(opts.until), opts.head)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+		opts.head, revRange = "", dateRange
+	}
+
+	// A reproduction compares the commits the report recorded, wherever
+	// its refs point now.
+	if reproduction != nil && reproduction.provenance.BaseCommit != "" && reproduction.provenance.HeadCommit != "" {
+		opts.base, opts.head = "", ""
+		revRange = reproduction.provenance.BaseCommit + ".." + reproduction.provenance.HeadCommit
+	}
+
+	var summary output.Summary
+	var cfg config.Config
+	if opts.patch != "" {
+		summary, cfg = analyzePatch(opts)
+	} else {
+		summary, cfg = analyze(opts, revRange, pathspecs)
+	}
+
+	if opts.saveBase != "" {
+		if err := snapshot.Write(opts.saveBase, summary); err != nil {
+			fmt.Fprintf(stderr, "Error: saving baseline: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+	}
+	if opts.record != "" {
+		if err := recordRun(opts.record, opts.runner, summary); err != nil {
+			fmt.Fprintf(stderr, "Error: recording run: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+	}
+
+	// Saved baselines and records keep paths relative to the repository
+	// root; only what is shown here follows --relative and --relative-to.
+	if opts.relative {
+		prefix, err := gitdiff.Prefix(opts.runner)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: --relative: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+		output.Relativize(&summary, prefix)
+	}
+	output.Relativize(&summary, opts.relTo)
+
+	var failed []string
+	for _, c := range opts.failOn {
+		if c.Holds(summary) {
+			failed = append(failed, fmt.Sprintf("FAIL %s (actual %d)", c, c.Actual(summary)))
+		}
+	}
+	if opts.quiet {
+		line := output.SummaryLine(summary)
+		if len(failed) > 0 {
+			line += "; " + strings.Join(failed, "; ")
+		}
+		fmt.Fprintln(stderr, line)
+		if len(failed) > 0 {
+			os.Exit(exitRuntimeError)
+		}
+		return nil
+	}
+
+	// 8. Render output. Bare paths are meant for other programs, not a
+	// pager.
+	renderer, _ := output.LookupRenderer(opts.format)
+	stopPager := func() {}
+	if opts.print0 {
+		renderer = output.RendererFunc(output.RenderPaths)
+	} else {
+		stopPager = startPager(cfg.Pager)
+	}
+	err := renderer.Render(stdout, summary, output.Options{
+		List:           opts.list || opts.top > 0,
+		ListOnly:       opts.listOnly,
+		Sort:           cfg.Sort,
+		NoColor:        !output.Colorize(opts.color, os.Stdout),
+		Net:            opts.net || cfg.Sort == "net",
+		Top:            opts.top,
+		TopGlobal:      opts.topGlob,
+		GroupBy:        opts.groupBy,
+		JSONCategories: opts.jsonCats,
+		Compact:        opts.compact,
+		NoHeader:       opts.noHeader,
+		Icons:          categoryIcons(opts.icons, cfg.CategoryIcons),
+	})
+	stopPager()
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: rendering %s: %v\n", opts.format, err)
+		os.Exit(exitRuntimeError)
+	}
+	if reproduction != nil {
+		checkReproduction(summary)
+	}
+	for _, f := range failed {
+		fmt.Fprintln(stderr, f)
+	}
+	if len(failed) > 0 {
+		os.Exit(exitRuntimeError)
+	}
+
+	return nil
+}
+
+// analyze runs the diff pipeline (config, ref resolution, git diff, parse,
+// classify, filter) and returns the resulting summary along with the effective
+// config. Errors are reported to stderr and terminate the process with the
+// appropriate exit code.
+func analyze(opts runOpts, revRange string, pathspecs []string) (output.Summary, config.Config) {
 	autoRefMode := opts.base == "" && opts.head == "" && revRange == ""
 	worktreeMode := false
+	lap := stageTimer()
 
 	// 1. Load config with CLI overrides.
 	cliOverrides := config.Config{
-		Include: opts.include,
-		Exclude: opts.exclude,
-		Empty:   opts.empty,
-		Sort:    opts.sort,
+		Include:          opts.include,
+		Exclude:          opts.exclude,
+		Empty:            opts.empty,
+		Sort:             opts.sort,
+		IgnoreWhitespace: opts.ignoreWS,
+		DiffAlgorithm:    opts.diffAlgo,
+		LinkTemplate:     opts.linkTmpl,
 	}
 
-	// Determine repo root for config loading.
-	repoRoot, _ := os.Getwd()
-	cfg, err := config.Load(repoRoot, cliOverrides)
+	cfg, err := config.Load(topLevel(opts.runner), cliOverrides)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: loading config: %v\n", err)
+		fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
 		os.Exit(exitInvalidConfig)
 	}
+	if cfg.DiffAlgorithm != "" && !slices.Contains(gitdiff.DiffAlgorithms, cfg.DiffAlgorithm) {
+		fmt.Fprintf(stderr, "Error: loading config: diff_algorithm must be one of %s, got %q\n", strings.Join(gitdiff.DiffAlgorithms, ", "), cfg.DiffAlgorithm)
+		os.Exit(exitInvalidConfig)
+	}
+	if opts.byTeam && len(cfg.Teams) == 0 {
+		fmt.Fprintln(stderr, "Error: --by-team needs a 'teams:' section in the config")
+		os.Exit(exitInvalidConfig)
+	}
+	for _, key := range []struct {
+		name    string
+		feature gitdiff.Feature
+		set     bool
+	}{
+		{"ignore_whitespace", gitdiff.FeatureIgnoreWhitespace, cfg.IgnoreWhitespace != nil && *cfg.IgnoreWhitespace},
+		{"diff_algorithm", gitdiff.FeatureDiffAlgorithm, cfg.DiffAlgorithm != ""},
+	} {
+		if key.set && !gitdiff.Supports(opts.runner, key.feature) {
+			fmt.Fprintf(stderr, "Error: loading config: the %s backend does not support %s; unset it or use --backend git\n", opts.backend, key.name)
+			os.Exit(exitInvalidConfig)
+		}
+	}
+
+	lap("load config")
 
 	// 2. Resolve refs.
-	refRange, err := gitdiff.ResolveRefs(opts.runner, opts.base, opts.head, revRange)
+	var refRange string
+	switch {
+	case opts.wtA != "":
+		// Each worktree's full state is snapshotted to a tree object and the
+		// two trees are diffed inside worktree B.
+		refRange = worktreeRange(opts.wtA, opts.wtB)
+		opts.runner = gitdiff.DirRunner{Dir: opts.wtB}
+	case opts.unstaged:
+		// The worktree is compared against the index; no ref is needed.
+	case opts.staged:
+		// The index is compared against --base, defaulting to HEAD.
+		refRange = opts.base
+		if refRange == "" {
+			refRange = "HEAD"
+		}
+	case opts.twoDot && opts.base != "" && opts.head != "":
+		refRange = opts.base + ".." + opts.head
+	default:
+		refRange, err = gitdiff.ResolveRefs(opts.runner, opts.base, opts.head, revRange)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+	}
+
+	// In auto mode the change is compared against the latest change merged
+	// into the detected base branch.
+	var autoBase string
+	if autoRefMode && !opts.staged && !opts.unstaged && opts.wtA == "" {
+		autoBase, _ = differ.ParseRefRange(refRange)
+	}
+
+	// Local edits are included by diffing from the base (the merge base, for
+	// base...head) to the current worktree: with --worktree include always,
+	// and by default in auto mode when the working tree is dirty.
+	var wtMode string
+	if !opts.staged && !opts.unstaged && opts.wtA == "" {
+		wtMode = opts.worktree
+		if wtMode == "" {
+			wtMode = "auto"
+		}
+		switch wtMode {
+		case "include":
+			base, err := worktreeBase(opts.runner, refRange)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: --worktree include: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			refRange, worktreeMode = base, true
+		case "auto":
+			if !autoRefMode {
+				break
+			}
+			if dirty, err := gitdiff.WorktreeDirty(opts.runner); err == nil && dirty {
+				if !gitdiff.Supports(opts.runner, gitdiff.FeatureWorktree) {
+					fmt.Fprintf(stderr, "Error: the working tree has local changes, which the %s backend cannot compare; commit them, or pass a rev-range or --worktree exclude\n", opts.backend)
+					os.Exit(exitRuntimeError)
+				}
+				if base, err := worktreeBase(opts.runner, refRange); err == nil {
+					refRange, worktreeMode = base, true
+				}
+			}
+		}
+	}
+
+	logf(1, "comparing %s", refRange)
+	lap("resolve refs")
+
+	// Per-directory .differ.yml files refine the root config below them.
+	cfg, err = differ.WithScopes(opts.runner, cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
+		os.Exit(exitInvalidConfig)
+	}
+	lap("load per-directory config")
+
+	diffOpts := gitdiff.DiffOptions{
+		Cached:           opts.staged,
+		IgnoreWhitespace: cfg.IgnoreWhitespace != nil && *cfg.IgnoreWhitespace,
+		Algorithm:        cfg.DiffAlgorithm,
+	}
+
+	// Ranges between two commits are cached; a hit skips the diff and every
+	// step below.
+	var baseCommit, headCommit string
+	var mergeBase *bool
+	if !worktreeMode && !opts.staged && !opts.unstaged && opts.wtA == "" {
+		baseCommit, headCommit = pinCommits(opts.runner, refRange)
+		mergeBase = differ.RangeMergeBase(refRange)
+	}
+	key, cacheable := cacheKey(opts, cfg, baseCommit, headCommit, autoBase, pathspecs)
+	if cacheable {
+		if summary, ok := cachedSummary(key); ok {
+			logf(1, "using the cached result for %s..%s", baseCommit, headCommit)
+			// The same commits may have been named differently.
+			summary.Meta.Base, summary.Meta.Head = differ.ParseRefRange(refRange)
+			summary.Meta.MergeBase = mergeBase
+			summary.Meta.Worktree = wtMode
+			summary.Meta.Timestamp = output.Now().Format(time.RFC3339)
+			summary.Meta.Provenance = newProvenance(opts.runner, cfg, summary.Meta.DiffAlgorithm, baseCommit, headCommit)
+			return summary, cfg
+		}
+	}
+
+	// Non-fatal issues are collected here and reported after the results.
+	var warnings []output.Warning
+	warn := func(severity, rule, format string, args ...any) {
+		warnings = append(warnings, output.Warning{Severity: severity, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+	warnings = append(warnings, differ.ConfigWarnings(cfg)...)
+	for _, c := range unknownCategories(opts.category) {
+		warn(output.SeverityWarning, "unknown-category", "--category %s matches no files; categories are %s", c, strings.Join(classify.Categories, ", "))
+	}
+
+	// Partial clones and sparse checkouts hold only part of the repository
+	// locally: fetch the blobs the diff needs in one go, and note any limits.
+	clone := gitdiff.DetectClone(opts.runner)
+	if clone.Partial() {
+		if _, err := gitdiff.PrefetchBlobs(opts.runner, clone.PromisorRemote, refRange, pathspecs, diffOpts); err != nil {
+			warn(output.SeverityInfo, "partial-clone", "could not prefetch changed blobs (%v); missing content is fetched lazily", err)
+		}
+	}
+	if clone.Sparse && (worktreeMode || opts.unstaged || opts.wtA != "") {
+		warn(output.SeverityInfo, "sparse-checkout", "working-tree changes are only detected inside the sparse checkout")
+	}
+
+	// From here on a run can take a while: on a terminal, a progress line
+	// shows it has not hung.
+	prog := startProgress()
+	defer prog.Stop()
+
+	// 3-6. Run git diff and parse its output, classifying, filtering, and
+	// aggregating files as they stream by, so only the kept files are held
+	// (and the parsed submodules, for step 12). Risky statements in
+	// migrations are flagged as the added lines stream by.
+	var risky []migrate.Warning
+	var firstLine differ.LineReader
+	if opts.shebang {
+		firstLine = differ.HeadFirstLine(opts.runner, headRevision(opts, refRange, worktreeMode))
+	}
+	summarizer := differ.NewSummarizer(opts.runner, cfg, opts.category, firstLine)
+	var parsedSubs []parser.FileStat
+	err = streamStats(progressRunner{opts.runner, prog}, refRange, pathspecs, diffOpts, parser.ParseOptions{
+		Empty:       cfg.Empty,
+		DetectMoves: opts.moves,
+		AddedLine: func(path string, line int, content string) {
+			risky = append(risky, migrate.Check(path, line, content)...)
+		},
+		Comments: commentSyntax(opts.count, cfg),
+	}, func(fs parser.FileStat) error {
+		prog.AddFile()
+		if fs.Status == parser.StatusSubmodule {
+			parsedSubs = append(parsedSubs, fs)
+		}
+		return summarizer.Add(fs)
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		prog.Stop()
+		fmt.Fprintf(stderr, "Error: %v\n", err)
 		os.Exit(exitRuntimeError)
 	}
+	summary := summarizer.Summary()
+	lap("diff and summarize")
 
-	// In auto mode, prefer showing local edits when the working tree is dirty by
-	// diffing from merge-base to the current worktree.
-	if autoRefMode {
-		if dirty, err := gitdiff.WorktreeDirty(opts.runner); err == nil && dirty {
-			baseRef, headRef := parseRefRange(refRange)
-			if baseRef != "" && headRef != "" {
-				if mergeBase, err := gitdiff.MergeBase(opts.runner, baseRef, headRef); err == nil {
-					refRange = mergeBase
-					worktreeMode = true
+	// 7. Fill in meta. Base and head are parsed from refRange.
+	metaBase, metaHead := differ.ParseRefRange(refRange)
+	if worktreeMode {
+		metaHead = "WORKTREE"
+	}
+	if opts.staged {
+		metaHead = "INDEX"
+	}
+	if opts.unstaged {
+		metaBase, metaHead = "INDEX", "WORKTREE"
+	}
+	if opts.wtA != "" {
+		metaBase, metaHead = "WORKTREE:"+opts.wtA, "WORKTREE:"+opts.wtB
+	}
+
+	summary.Meta = output.Meta{
+		Base:             metaBase,
+		Head:             metaHead,
+		Empty:            cfg.Empty,
+		IgnoreWhitespace: cfg.IgnoreWhitespace != nil && *cfg.IgnoreWhitespace,
+		DiffAlgorithm:    gitdiff.EffectiveAlgorithm(opts.runner, cfg.DiffAlgorithm),
+		DetectMoves:      opts.moves,
+		Count:            meaningfulCount(opts.count),
+		Pathspecs:        pathspecs,
+		Timestamp:        output.Now().Format(time.RFC3339),
+		MergeBase:        mergeBase,
+		Worktree:         wtMode,
+	}
+	output.SetLinks(&summary, cfg.LinkTemplate)
+
+	// 8. Tag changes that only touch dependency manifests and lockfiles.
+	if dep, err := dependencyUpdate(opts.runner, refRange, summary, diffOpts); err != nil {
+		warn(output.SeverityInfo, "dependency-files", "could not inspect dependency files: %v", err)
+	} else {
+		summary.Meta.DependencyUpdate = dep
+	}
+
+	// 9. Optionally report churn in exported Go declarations.
+	if opts.apiChurn {
+		api, err := apiChurn(opts.runner, refRange, summary, diffOpts)
+		if err != nil {
+			prog.Stop()
+			fmt.Fprintf(stderr, "Error: detecting API churn: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+		summary.Meta.APIChurn = api
+	}
+
+	// 10. Summarize infrastructure changes by resource.
+	if kinds, err := infrastructure(opts.runner, refRange, summary, diffOpts); err != nil {
+		warn(output.SeverityInfo, "infrastructure", "could not summarize infrastructure changes: %v", err)
+	} else {
+		summary.Meta.Infrastructure = kinds
+	}
+
+	// 11. Optionally compare API schema files structurally.
+	if opts.schemas {
+		changes, err := schemaChanges(opts.runner, refRange, summary, diffOpts)
+		if err != nil {
+			prog.Stop()
+			fmt.Fprintf(stderr, "Error: comparing schemas: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+		summary.Meta.SchemaChanges = changes
+	}
+
+	// 12. List moved submodules, optionally analyzing each one's range.
+	subs, subWarnings := submodules(topLevel(opts.runner), parsedSubs, summary, cfg, opts.category, opts.subMode == "recurse")
+	summary.Meta.Submodules = subs
+	warnings = append(warnings, subWarnings...)
+
+	// 13. Split a comparison against the working tree into what is
+	// committed, staged, and unstaged.
+	if worktreeMode {
+		wb, err := worktreeBreakdown(opts.runner, refRange, pathspecs, diffOpts, cfg, opts.category)
+		if err != nil {
+			warn(output.SeverityInfo, "worktree-breakdown", "could not split the working-tree changes: %v", err)
+		} else {
+			summary.Meta.WorktreeBreakdown = wb
+		}
+	}
+
+	// 14. In auto mode, summarize the latest change merged into the base
+	// branch the same way, for scale. Finding it walks history, which not
+	// every backend can.
+	if autoBase != "" && gitdiff.Supports(opts.runner, gitdiff.FeatureHistory) {
+		ref, err := referenceChange(opts.runner, autoBase, pathspecs, diffOpts, cfg, opts.category, opts.shebang)
+		if err != nil {
+			warn(output.SeverityInfo, "reference", "could not summarize the last merge into %s: %v", autoBase, err)
+		} else {
+			summary.Meta.Reference = ref
+		}
+	}
+
+	// 15. Optionally roll churn up by team.
+	if opts.byTeam {
+		summary.Meta.Teams = owners.RollupTeams(summary.FileStats, cfg.Teams)
+	}
+
+	// 16. Optionally count the commits in the range that changed each file,
+	// all in one git log.
+	if opts.commits {
+		counts, err := history.TouchCounts(opts.runner, history.LogRange(refRange), pathspecs)
+		if err != nil {
+			prog.Stop()
+			fmt.Fprintf(stderr, "Error: counting commits: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+		for i := range summary.FileStats {
+			summary.FileStats[i].Commits = counts[summary.FileStats[i].Path]
+		}
+	}
+
+	// 17. Optionally look up when each file was created and last changed
+	// before the range, all in one git log of the history up to its base.
+	if opts.fileAge {
+		if err := setFileAges(opts.runner, refRange, summary.FileStats); err != nil {
+			prog.Stop()
+			fmt.Fprintf(stderr, "Error: looking up file ages: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+	}
+
+	summary.Meta.Provenance = newProvenance(opts.runner, cfg, summary.Meta.DiffAlgorithm, baseCommit, headCommit)
+
+	summary.Meta.Warnings = append(differ.MigrationWarnings(risky, summary), warnings...)
+	if cacheable {
+		storeSummary(key, summary)
+	}
+	lap("extras")
+	return summary, cfg
+}
+
+// progressDelay is how long an analysis runs before its progress is shown.
+const progressDelay = time.Second
+
+// startProgress starts a progress line on stderr if it is a terminal and
+// not taken by --verbose logs; it returns nil, which does nothing, otherwise.
+func startProgress() *progress.Line {
+	if verbosity > 0 || !output.IsTerminal(os.Stderr) {
+		return nil
+	}
+	return progress.Start(stderr, progressDelay, asciiOnly)
+}
+
+// progressRunner counts the output of the commands it starts, such as git
+// diff, as bytes read on a progress line.
+type progressRunner struct {
+	gitdiff.CommandRunner
+	prog *progress.Line
+}
+
+func (r progressRunner) Start(name string, args ...string) (io.ReadCloser, *exec.Cmd, error) {
+	out, cmd, err := r.CommandRunner.Start(name, args...)
+	if err != nil {
+		return out, cmd, err
+	}
+	return r.prog.ReadCloser(out), cmd, nil
+}
+
+// diffStats runs git diff for refRange and parses it, as streamStats does.
+func diffStats(runner gitdiff.CommandRunner, refRange string, pathspecs []string, diffOpts gitdiff.DiffOptions, parseOpts parser.ParseOptions) ([]parser.FileStat, error) {
+	var parsed []parser.FileStat
+	err := streamStats(runner, refRange, pathspecs, diffOpts, parseOpts, func(fs parser.FileStat) error {
+		parsed = append(parsed, fs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// streamStats runs git diff for refRange and calls emit with each file as it
+// is parsed. When every changed line counts and moved lines are not needed,
+// it reads git's per-file counts instead of the patch, which is much faster
+// on large ranges; then only migration files are diffed in full, for
+// parseOpts.AddedLine to check. If git's counts cannot be read, for example
+// with a backend that does not support them, the whole patch is parsed.
+func streamStats(runner gitdiff.CommandRunner, refRange string, pathspecs []string, diffOpts gitdiff.DiffOptions, parseOpts parser.ParseOptions, emit func(parser.FileStat) error) error {
+	if parseOpts.Empty == "include" && !parseOpts.DetectMoves && parseOpts.Comments == nil {
+		var migrations []string
+		emitted := false
+		err := differ.StreamNumstat(runner, refRange, pathspecs, diffOpts, func(fs parser.FileStat) error {
+			if parseOpts.AddedLine != nil && migrate.IsMigrationFile(fs.Path) {
+				migrations = append(migrations, ":(top,literal)"+fs.Path)
+				// With the old path, git still pairs a renamed
+				// migration instead of showing all of it as added.
+				if fs.OldPath != "" {
+					migrations = append(migrations, ":(top,literal)"+fs.OldPath)
 				}
 			}
+			emitted = true
+			return emit(fs)
+		})
+		switch {
+		case err == nil:
+			if len(migrations) == 0 {
+				return nil
+			}
+			_, err := differ.DiffStats(runner, refRange, migrations, diffOpts, parseOpts)
+			return err
+		case emitted:
+			// Files already passed on cannot be parsed again.
+			return err
 		}
 	}
+	return differ.StreamStats(runner, refRange, pathspecs, diffOpts, parseOpts, emit)
+}
 
-	// 3. Run git diff.
-	diffResult, err := gitdiff.RunDiff(opts.runner, refRange, pathspecs)
+// analyzePatch summarizes a patch read from a file or stdin rather than from
+// git, so it also works where git is not installed. Only the root config
+// applies, and the git-only extras (scopes, dependency and infrastructure
+// summaries, the reference change) are skipped.
+func analyzePatch(opts runOpts) (output.Summary, config.Config) {
+	cfg, err := config.Load(topLevel(opts.runner), config.Config{
+		Include:      opts.include,
+		Exclude:      opts.exclude,
+		Empty:        opts.empty,
+		Sort:         opts.sort,
+		LinkTemplate: opts.linkTmpl,
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: running git diff: %v\n", err)
-		os.Exit(exitRuntimeError)
+		fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
+		os.Exit(exitInvalidConfig)
 	}
 
-	// 4. Parse diff output.
-	parsed, err := parser.Parse(diffResult.Stdout, cfg.Empty)
+	in, name := io.Reader(os.Stdin), "stdin"
+	if opts.patch != "-" {
+		f, err := os.Open(opts.patch)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			os.Exit(exitRuntimeError)
+		}
+		defer f.Close()
+		in, name = f, opts.patch
+	}
+
+	var risky []migrate.Warning
+	parsed, err := parser.ParseWithOptions(in, parser.ParseOptions{
+		Empty:       cfg.Empty,
+		DetectMoves: opts.moves,
+		AddedLine: func(path string, line int, content string) {
+			risky = append(risky, migrate.Check(path, line, content)...)
+		},
+		Comments: commentSyntax(opts.count, cfg),
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: parsing diff: %v\n", err)
+		fmt.Fprintf(stderr, "Error: parsing %s: %v\n", name, err)
 		os.Exit(exitRuntimeError)
 	}
 
-	if err := diffResult.Wait(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(exitRuntimeError)
+	summary := differ.Summarize(opts.runner, parsed, cfg, opts.category, nil)
+	summary.Meta = output.Meta{
+		Base:        "PATCH",
+		Head:        name,
+		Empty:       cfg.Empty,
+		DetectMoves: opts.moves,
+		Count:       meaningfulCount(opts.count),
+		Timestamp:   output.Now().Format(time.RFC3339),
+	}
+	output.SetLinks(&summary, cfg.LinkTemplate)
+	summary.Meta.Submodules, _ = submodules("", parsed, summary, cfg, opts.category, false)
+
+	var warnings []output.Warning
+	warnings = append(warnings, differ.ConfigWarnings(cfg)...)
+	for _, c := range unknownCategories(opts.category) {
+		warnings = append(warnings, output.Warning{
+			Severity: output.SeverityWarning,
+			Rule:     "unknown-category",
+			Message:  fmt.Sprintf("--category %s matches no files; categories are %s", c, strings.Join(classify.Categories, ", ")),
+		})
+	}
+	summary.Meta.Provenance = newProvenance(opts.runner, cfg, "", "", "")
+	summary.Meta.Warnings = append(differ.MigrationWarnings(risky, summary), warnings...)
+	return summary, cfg
+}
+
+// topLevel returns the root of the working tree differ runs in, where
+// .differ.yml and CODEOWNERS live, or the current directory outside a
+// repository.
+func topLevel(runner gitdiff.CommandRunner) string {
+	if top, err := gitdiff.TopLevel(runner); err == nil {
+		return top
+	}
+	dir, _ := os.Getwd()
+	return dir
+}
+
+// commentSyntax returns the parser.ParseOptions.Comments for a --count mode:
+// the comment syntax of each file's language when only meaningful lines
+// count, and nil when every line does.
+func commentSyntax(count string, cfg config.Config) func(path string) parser.CommentSyntax {
+	if count != output.CountMeaningful {
+		return nil
+	}
+	classifier := classify.New(cfg)
+	return func(path string) parser.CommentSyntax {
+		_, language := classifier.Classify(path)
+		return classify.CommentSyntax(language)
+	}
+}
+
+// meaningfulCount returns the Meta.Count of a --count mode, which is only
+// recorded when it is not the default.
+func meaningfulCount(count string) string {
+	if count == output.CountMeaningful {
+		return count
+	}
+	return ""
+}
+
+// ignoredRevs reads the commits listed in an --ignore-revs-file, exiting if
+// the file cannot be read. An empty path ignores none.
+func ignoredRevs(path string) map[string]bool {
+	if path == "" {
+		return nil
+	}
+	revs, err := history.ReadIgnoreRevs(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: reading ignore-revs file: %v\n", err)
+		os.Exit(exitInvalidConfig)
 	}
+	return revs
+}
 
-	// 5. Classify files.
+// pathCategory returns a function that classifies a path by cfg's rules,
+// for the history commands, which read no file contents.
+func pathCategory(cfg config.Config) func(path string) string {
 	classifier := classify.New(cfg)
+	return func(path string) string {
+		category, _ := classifier.Classify(path)
+		return category
+	}
+}
 
-	// 6. Filter.
-	filterCfg := filter.FilterConfig{
-		Include:    cfg.Include,
-		Exclude:    cfg.Exclude,
-		Categories: opts.category,
+// unknownCategories returns the names in categories that are not built-in
+// categories, in order.
+func unknownCategories(categories []string) []string {
+	var unknown []string
+	for _, c := range categories {
+		if !slices.Contains(classify.Categories, c) {
+			unknown = append(unknown, c)
+		}
+	}
+	return unknown
+}
+
+// referenceChange summarizes the latest change merged into base, the diff
+// between the latest merge on its first-parent history and that merge's
+// first parent, with the same pathspecs, filters, and categories as the
+// current change. Without merges, as when changes are squashed or rebased
+// in, base's last commit stands in. It returns nil if that commit has no
+// parent.
+func referenceChange(runner gitdiff.CommandRunner, base string, pathspecs []string, diffOpts gitdiff.DiffOptions, cfg config.Config, categories []string, shebang bool) (*output.Reference, error) {
+	tip, err := gitdiff.LastMerge(runner, base)
+	if err != nil {
+		return nil, err
+	}
+	kind := output.ReferenceMerge
+	if tip == "" {
+		if tip, err = gitdiff.ResolveCommit(runner, base); err != nil {
+			return nil, err
+		}
+		kind = output.ReferenceCommit
+	}
+	parent, err := gitdiff.ResolveCommit(runner, tip+"^1")
+	if err != nil {
+		return nil, nil
+	}
+
+	diffOpts.Cached = false
+	parsed, err := diffStats(runner, parent+"..."+tip, pathspecs, diffOpts, parser.ParseOptions{Empty: cfg.Empty})
+	if err != nil {
+		return nil, err
+	}
+	var firstLine differ.LineReader
+	if shebang {
+		firstLine = differ.HeadFirstLine(runner, tip)
+	}
+	summary := differ.Summarize(runner, parsed, cfg, categories, firstLine)
+
+	ref := &output.Reference{
+		Base:       base,
+		Commit:     tip,
+		Kind:       kind,
+		Churn:      summary.Totals.Churn,
+		Files:      summary.Totals.FileCount,
+		ByCategory: make(map[string]int, len(summary.CategoryTotals)),
+	}
+	for cat, ct := range summary.CategoryTotals {
+		ref.ByCategory[cat] = ct.Churn
 	}
-	filtered := filter.Filter(parsed, filterCfg, func(path string) string {
-		cat, _ := classifier.Classify(path)
-		return cat
+	return ref, nil
+}
+
+// worktreeBreakdown diffs base to HEAD, HEAD to the index, and the index to
+// the working tree separately and summarizes each the same way as the
+// comparison of base to the working tree.
+func worktreeBreakdown(runner gitdiff.CommandRunner, base string, pathspecs []string, diffOpts gitdiff.DiffOptions, cfg config.Config, categories []string) (*output.WorktreeBreakdown, error) {
+	bucket := func(refRange string, cached bool) (output.WorktreeBucket, error) {
+		diffOpts.Cached = cached
+		parsed, err := diffStats(runner, refRange, pathspecs, diffOpts, parser.ParseOptions{Empty: cfg.Empty})
+		if err != nil {
+			return output.WorktreeBucket{}, err
+		}
+		summary := differ.Summarize(runner, parsed, cfg, categories, nil)
+		return output.WorktreeBucket{Totals: summary.Totals, CategoryTotals: summary.CategoryTotals}, nil
+	}
+
+	var wb output.WorktreeBreakdown
+	var err error
+	if wb.Committed, err = bucket(base+"..HEAD", false); err != nil {
+		return nil, err
+	}
+	if wb.Staged, err = bucket("HEAD", true); err != nil {
+		return nil, err
+	}
+	if wb.Unstaged, err = bucket("", false); err != nil {
+		return nil, err
+	}
+	return &wb, nil
+}
+
+// apiChurn finds the changed lines in summary's Go files that touch exported
+// declarations.
+func apiChurn(runner gitdiff.CommandRunner, refRange string, summary output.Summary, diffOpts gitdiff.DiffOptions) (*output.APIChurn, error) {
+	var report goapi.Report
+	err := fullDiff(runner, refRange, summary, diffOpts, goapi.IsAPIFile, func(r io.Reader) (err error) {
+		report, err = goapi.Analyze(r)
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	// 7. Build summary.
-	// Parse base and head from refRange for meta.
-	metaBase, metaHead := parseRefRange(refRange)
-	if worktreeMode {
-		metaHead = "WORKTREE"
+	result := &output.APIChurn{Lines: report.Lines, Symbols: []output.APISymbol{}}
+	for _, sym := range report.Symbols {
+		result.Symbols = append(result.Symbols, output.APISymbol{
+			Path:   sym.Path,
+			Kind:   sym.Kind,
+			Name:   sym.Name,
+			Change: sym.Change,
+			Lines:  sym.Lines,
+		})
 	}
+	return result, nil
+}
 
-	fileStats := make([]output.FileStat, 0, len(filtered))
-	catTotals := make(map[string]output.CategoryTotal)
+// schemaChanges compares both versions of summary's OpenAPI, GraphQL, and
+// protobuf files.
+func schemaChanges(runner gitdiff.CommandRunner, refRange string, summary output.Summary, diffOpts gitdiff.DiffOptions) ([]output.SchemaChange, error) {
+	var changes []schema.Change
+	err := fullDiff(runner, refRange, summary, diffOpts, schema.IsCandidate, func(r io.Reader) (err error) {
+		changes, err = schema.Analyze(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	var totalAdded, totalDeleted, totalFiles int
-	for _, fs := range filtered {
-		cat, lang := classifier.Classify(fs.Path)
-		fileStats = append(fileStats, output.FileStat{
-			Path:     fs.Path,
-			Added:    fs.Added,
-			Deleted:  fs.Deleted,
-			Churn:    fs.Churn,
-			Category: cat,
-			Language: lang,
+	var result []output.SchemaChange
+	for _, c := range changes {
+		result = append(result, output.SchemaChange{
+			Path:    c.Path,
+			Format:  c.Format,
+			Added:   c.Added,
+			Removed: c.Removed,
+			Changed: c.Changed,
 		})
+	}
+	return result, nil
+}
 
-		ct := catTotals[cat]
-		ct.Added += fs.Added
-		ct.Deleted += fs.Deleted
-		ct.Churn += fs.Churn
-		ct.FileCount++
-		catTotals[cat] = ct
+// infrastructure counts the Terraform resources and Kubernetes objects that
+// summary's files add, change, or remove.
+func infrastructure(runner gitdiff.CommandRunner, refRange string, summary output.Summary, diffOpts gitdiff.DiffOptions) ([]output.InfraKind, error) {
+	var counts []infra.KindCount
+	err := fullDiff(runner, refRange, summary, diffOpts, infra.IsCandidate, func(r io.Reader) (err error) {
+		counts, err = infra.Analyze(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		totalAdded += fs.Added
-		totalDeleted += fs.Deleted
-		totalFiles++
+	var result []output.InfraKind
+	for _, c := range counts {
+		result = append(result, output.InfraKind{Kind: c.Kind, Added: c.Added, Changed: c.Changed, Removed: c.Removed})
 	}
+	return result, nil
+}
 
-	summary := output.Summary{
-		Totals: output.CategoryTotal{
-			Added:     totalAdded,
-			Deleted:   totalDeleted,
-			Churn:     totalAdded + totalDeleted,
-			FileCount: totalFiles,
-		},
-		CategoryTotals: catTotals,
-		FileStats:      fileStats,
-		Meta: output.Meta{
-			Base:      metaBase,
-			Head:      metaHead,
-			Empty:     cfg.Empty,
-			Pathspecs: pathspecs,
-			Timestamp: time.Now().UTC().Format(time.RFC3339),
-		},
+// fullDiff runs a full-context diff of the summary's files accepted by match
+// and hands it to read, so both versions of each file can be rebuilt the same
+// way for commits, the index, and the worktree. read is not called when no
+// file matches.
+func fullDiff(runner gitdiff.CommandRunner, refRange string, summary output.Summary, diffOpts gitdiff.DiffOptions, match func(string) bool, read func(io.Reader) error) error {
+	var paths []string
+	for _, f := range summary.FileStats {
+		if match(f.Path) {
+			paths = append(paths, ":(top,literal)"+f.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
 	}
 
-	// 8. Render output.
-	if opts.format == "json" {
-		if err := output.RenderJSON(os.Stdout, summary); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: rendering JSON: %v\n", err)
-			os.Exit(exitRuntimeError)
+	diffOpts.FullContext = true
+	diffResult, err := gitdiff.RunDiffWithOptions(runner, refRange, paths, diffOpts)
+	if err != nil {
+		return err
+	}
+	if err := read(diffResult.Stdout); err != nil {
+		return err
+	}
+	return diffResult.Wait()
+}
+
+// dependencyUpdate reports the packages bumped by summary when every changed
+// file is a dependency manifest or lockfile, and nil otherwise.
+func dependencyUpdate(runner gitdiff.CommandRunner, refRange string, summary output.Summary, diffOpts gitdiff.DiffOptions) (*output.DependencyUpdate, error) {
+	if len(summary.FileStats) == 0 {
+		return nil, nil
+	}
+	paths := make([]string, 0, len(summary.FileStats))
+	for _, f := range summary.FileStats {
+		if !deps.IsDependencyFile(f.Path) {
+			return nil, nil
 		}
-	} else {
-		output.RenderText(os.Stdout, summary, output.OutputOpts{
-			List:     opts.list,
-			ListOnly: opts.listOnly,
-			Sort:     cfg.Sort,
-			NoColor:  opts.noColor,
-		})
+		paths = append(paths, ":(top,literal)"+f.Path)
 	}
 
+	diffResult, err := gitdiff.RunDiffWithOptions(runner, refRange, paths, diffOpts)
+	if err != nil {
+		return nil, err
+	}
+	packages, err := deps.Packages(diffResult.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	if err := diffResult.Wait(); err != nil {
+		return nil, err
+	}
+	return &output.DependencyUpdate{Bumped: len(packages), Packages: packages}, nil
+}
+
+// headRevision names where the head side of the diff lives for reading
+// file contents: a revision, "" for the index, or "WORKTREE".
+func headRevision(opts runOpts, refRange string, worktreeMode bool) string {
+	switch {
+	case opts.unstaged || worktreeMode || opts.wtA != "":
+		return differ.Worktree
+	case opts.staged:
+		return ""
+	}
+	_, head := differ.ParseRefRange(refRange)
+	if head == "" {
+		head = "HEAD"
+	}
+	return head
+}
+
+// worktreeModes lists the accepted --worktree values: auto compares against
+// the working tree only in auto mode with a dirty tree, include always does,
+// and exclude never does.
+var worktreeModes = []string{"auto", "include", "exclude"}
+
+// worktreeBase returns the commit that refRange's changes start from, to
+// compare the working tree against: the merge base of a base...head range,
+// or the base of a base..head one.
+func worktreeBase(runner gitdiff.CommandRunner, refRange string) (string, error) {
+	base, head := differ.ParseRefRange(refRange)
+	if base == "" || head == "" {
+		return "", fmt.Errorf("cannot find the base of %q", refRange)
+	}
+	if !strings.Contains(refRange, "...") {
+		return base, nil
+	}
+	return gitdiff.MergeBase(runner, base, head)
+}
+
+// setFileAges sets the creation and last change, before refRange, of each
+// file that existed at its base, found under its old path if renamed.
+func setFileAges(runner gitdiff.CommandRunner, refRange string, files []output.FileStat) error {
+	base := refRange
+	if strings.Contains(refRange, "..") {
+		var err error
+		if base, err = worktreeBase(runner, refRange); err != nil {
+			return err
+		}
+	}
+	ages, err := history.FileAges(runner, base)
+	if err != nil {
+		return err
+	}
+	for i := range files {
+		path := files[i].Path
+		if files[i].OldPath != "" {
+			path = files[i].OldPath
+		}
+		if age, ok := ages[path]; ok {
+			files[i].Created, files[i].LastCommit, files[i].LastModified = age.Created, age.LastCommit, age.LastModified
+		}
+	}
 	return nil
 }
 
-// parseRefRange splits "base...head" into base and head parts.
-func parseRefRange(refRange string) (string, string) {
-	if parts := strings.SplitN(refRange, "...", 2); len(parts) == 2 {
-		return parts[0], parts[1]
+// worktreeRange snapshots two worktrees of the same repository and returns a
+// tree range comparing them, exiting with exitRuntimeError on failure.
+func worktreeRange(a, b string) string {
+	commonA, err := gitdiff.CommonDir(a)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		os.Exit(exitRuntimeError)
+	}
+	commonB, err := gitdiff.CommonDir(b)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		os.Exit(exitRuntimeError)
+	}
+	if commonA != commonB {
+		fmt.Fprintf(stderr, "Error: %s and %s are not worktrees of the same repository\n", a, b)
+		os.Exit(exitRuntimeError)
+	}
+
+	treeA, err := gitdiff.SnapshotWorktree(a)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		os.Exit(exitRuntimeError)
+	}
+	treeB, err := gitdiff.SnapshotWorktree(b)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		os.Exit(exitRuntimeError)
+	}
+	return treeA + ".." + treeB
+}
+
+// gitCommands names the subcommands that cannot do anything without git.
+// The root command, compare, and notify check for git themselves, since they
+// can also run from a patch file or saved snapshots.
+var gitCommands = []string{"annotate", "authors", "badge", "changelog", "check", "compare-refs", "daemon", "leaderboard", "multi", "reviewers", "serve", "setup", "show", "stack"}
+
+// noGitHelp lists what still works when git is not installed.
+const noGitHelp = `differ reads refs, history, and the working tree through git. Without it,
+these still work:
+  differ --patch-file <file|->      summarize a git-format patch (- for stdin)
+  differ --backend gogit <range>    compare two commits, reading them with go-git
+  differ compare <a.json> <b.json>  compare two saved snapshots
+  differ notify --from <a.json>     post a saved snapshot to a webhook
+  differ site --db <ledger> ...     build the history site from a ledger
+  differ rules export               export the classification rules
+  differ config test                check .differ.yml
+Install git or add it to PATH for everything else.`
+
+// requireGit exits with exitRuntimeError and a list of the features that
+// remain available when the git binary cannot be found. A missing --git-bin
+// is a configuration error instead.
+func requireGit() {
+	if _, err := exec.LookPath(gitdiff.GitBin); err == nil {
+		return
+	}
+	if gitdiff.GitBin != "git" {
+		fmt.Fprintf(stderr, "Error: --git-bin: %s not found\n", gitdiff.GitBin)
+		os.Exit(exitInvalidConfig)
+	}
+	fmt.Fprintln(stderr, "Error: git not found in PATH")
+	fmt.Fprintln(stderr, noGitHelp)
+	os.Exit(exitRuntimeError)
+}
+
+// checkBackend rejects flags that need a feature the backend lacks, before
+// anything runs, rather than failing midway on a git command the backend
+// cannot answer.
+func checkBackend(opts runOpts) {
+	var unsupported []string
+	need := func(f gitdiff.Feature, flag string, set bool) {
+		if set && !gitdiff.Supports(opts.runner, f) {
+			unsupported = append(unsupported, flag)
+		}
+	}
+	need(gitdiff.FeatureWorktree, "--staged", opts.staged)
+	need(gitdiff.FeatureWorktree, "--unstaged", opts.unstaged)
+	need(gitdiff.FeatureWorktree, "--worktree-a/--worktree-b", opts.wtA != "" || opts.wtB != "")
+	need(gitdiff.FeatureWorktree, "--worktree include", opts.worktree == "include")
+	need(gitdiff.FeatureHistory, "--since", opts.since != "")
+	need(gitdiff.FeatureHistory, "--until", opts.until != "")
+	need(gitdiff.FeatureHistory, "--commit-counts", opts.commits)
+	need(gitdiff.FeatureHistory, "--file-age", opts.fileAge)
+	need(gitdiff.FeatureIgnoreWhitespace, "--ignore-whitespace", opts.ignoreWS != nil && *opts.ignoreWS)
+	need(gitdiff.FeatureDiffAlgorithm, "--diff-algorithm", opts.diffAlgo != "")
+	if len(unsupported) > 0 {
+		fmt.Fprintf(stderr, "Error: the %s backend does not support %s; use --backend git\n", opts.backend, strings.Join(unsupported, ", "))
+		os.Exit(exitInvalidConfig)
+	}
+}
+
+// validateOpts checks flag values shared by every command that runs the
+// analysis pipeline, exiting with exitInvalidConfig on bad input.
+func validateOpts(opts runOpts) {
+	// Validate --empty flag value.
+	if opts.empty != "include" && opts.empty != "exclude" {
+		fmt.Fprintf(stderr, "Error: --empty must be 'include' or 'exclude', got %q\n", opts.empty)
+		os.Exit(exitInvalidConfig)
+	}
+
+	// Validate --format flag value.
+	if _, err := output.LookupRenderer(opts.format); err != nil {
+		fmt.Fprintf(stderr, "Error: --format: %v\n", err)
+		os.Exit(exitInvalidConfig)
+	}
+
+	if opts.color != "" && !slices.Contains(output.ColorModes, opts.color) {
+		fmt.Fprintf(stderr, "Error: --color must be one of %s, got %q\n", strings.Join(output.ColorModes, ", "), opts.color)
+		os.Exit(exitInvalidConfig)
+	}
+
+	// Validate --sort flag value.
+	if !slices.Contains(output.SortModes, opts.sort) {
+		fmt.Fprintf(stderr, "Error: --sort must be one of %s, got %q\n", strings.Join(output.SortModes, ", "), opts.sort)
+		os.Exit(exitInvalidConfig)
 	}
-	if parts := strings.SplitN(refRange, "..", 2); len(parts) == 2 {
-		return parts[0], parts[1]
+
+	// Validate --diff-algorithm flag value.
+	if opts.diffAlgo != "" && !slices.Contains(gitdiff.DiffAlgorithms, opts.diffAlgo) {
+		fmt.Fprintf(stderr, "Error: --diff-algorithm must be one of %s, got %q\n", strings.Join(gitdiff.DiffAlgorithms, ", "), opts.diffAlgo)
+		os.Exit(exitInvalidConfig)
+	}
+
+	if opts.worktree != "" && !slices.Contains(worktreeModes, opts.worktree) {
+		fmt.Fprintf(stderr, "Error: --worktree must be one of %s, got %q\n", strings.Join(worktreeModes, ", "), opts.worktree)
+		os.Exit(exitInvalidConfig)
+	}
+	if opts.count != "" && !slices.Contains(output.CountModes, opts.count) {
+		fmt.Fprintf(stderr, "Error: --count must be one of %s, got %q\n", strings.Join(output.CountModes, ", "), opts.count)
+		os.Exit(exitInvalidConfig)
+	}
+	if opts.subMode != "" && !slices.Contains(submoduleModes, opts.subMode) {
+		fmt.Fprintf(stderr, "Error: --submodules must be one of %s, got %q\n", strings.Join(submoduleModes, ", "), opts.subMode)
+		os.Exit(exitInvalidConfig)
+	}
+
+	// Validate --group-by flag value.
+	if opts.groupBy != "" && !slices.Contains(output.GroupModes, opts.groupBy) {
+		fmt.Fprintf(stderr, "Error: --group-by must be one of %s, got %q\n", strings.Join(output.GroupModes, ", "), opts.groupBy)
+		os.Exit(exitInvalidConfig)
+	}
+
+	if opts.jsonCats != "" && !slices.Contains(output.JSONCategoryModes, opts.jsonCats) {
+		fmt.Fprintf(stderr, "Error: --json-categories must be one of %s, got %q\n", strings.Join(output.JSONCategoryModes, ", "), opts.jsonCats)
+		os.Exit(exitInvalidConfig)
+	}
+	if opts.relTo != "" {
+		if opts.relative {
+			fmt.Fprintln(stderr, "Error: --relative and --relative-to cannot be combined")
+			os.Exit(exitInvalidConfig)
+		}
+		if dir := path.Clean(filepath.ToSlash(opts.relTo)); filepath.IsAbs(opts.relTo) || path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+			fmt.Fprintf(stderr, "Error: --relative-to must be a directory inside the repository, given from its root, got %q\n", opts.relTo)
+			os.Exit(exitInvalidConfig)
+		}
+	} else if opts.relOnly {
+		fmt.Fprintln(stderr, "Error: --relative-only requires --relative-to")
+		os.Exit(exitInvalidConfig)
+	}
+	if opts.compact && opts.format != "json" {
+		fmt.Fprintf(stderr, "Error: --compact requires --format json, got %q\n", opts.format)
+		os.Exit(exitInvalidConfig)
+	}
+
+	// Validate --top and --top-global.
+	if opts.top < 0 {
+		fmt.Fprintf(stderr, "Error: --top must not be negative, got %d\n", opts.top)
+		os.Exit(exitInvalidConfig)
+	}
+	if opts.topGlob && opts.top == 0 {
+		fmt.Fprintln(stderr, "Error: --top-global requires --top")
+		os.Exit(exitInvalidConfig)
 	}
-	return refRange, ""
 }
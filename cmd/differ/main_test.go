@@ -2,12 +2,26 @@ package main
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/classify"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/output"
 )
 
 // setupTestRepo creates a temp git repo with two commits and returns:
@@ -101,6 +115,10 @@ func runDiffer(t *testing.T, bin, dir string, args ...string) (stdout, stderr st
 	t.Helper()
 	cmd := exec.Command(bin, args...)
 	cmd.Dir = dir
+	// Each test binary gets its own result cache rather than the user's.
+	if os.Getenv("DIFFER_CACHE_DIR") == "" {
+		cmd.Env = append(os.Environ(), "DIFFER_CACHE_DIR="+filepath.Join(filepath.Dir(bin), "cache"))
+	}
 	var outBuf, errBuf bytes.Buffer
 	cmd.Stdout = &outBuf
 	cmd.Stderr = &errBuf
@@ -254,6 +272,39 @@ func TestE2E_JSONOutput(t *testing.T) {
 	}
 }
 
+func TestE2E_JSONLinkTemplate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--base", baseRef, "--head", headRef, "--format", "json",
+		"--link-template", "https://example.com/blob/{head}/{path}")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result struct {
+		ByFile []struct {
+			Path string `json:"path"`
+			Link string `json:"link"`
+		} `json:"by_file"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	if len(result.ByFile) == 0 {
+		t.Fatal("expected non-empty by_file array")
+	}
+	for _, f := range result.ByFile {
+		if want := "https://example.com/blob/" + headRef + "/" + f.Path; f.Link != want {
+			t.Errorf("%s: link = %q, want %q", f.Path, f.Link, want)
+		}
+	}
+}
+
 func TestE2E_CategoryFilter(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping e2e test in short mode")
@@ -429,22 +480,4159 @@ func TestE2E_DefaultIncludesDirtyWorktree(t *testing.T) {
 	}
 }
 
-func TestE2E_ParseRefRange(t *testing.T) {
+func TestE2E_WorktreeMode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	rng := baseRef + ".." + headRef
+	// Leave a local change to a tracked file.
+	writeFile(t, filepath.Join(dir, "README.md"), "# Test\n\nA description.\n\nMore.\n")
+
+	type result struct {
+		Meta struct {
+			Head     string `json:"head"`
+			Worktree string `json:"worktree"`
+		} `json:"meta"`
+		ByFile []struct {
+			Path  string `json:"path"`
+			Churn int    `json:"churn"`
+		} `json:"by_file"`
+	}
+	analyze := func(args ...string) result {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append([]string{"--format", "json", "--sort", "path"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d\nstderr: %s", args, exitCode, stderr)
+		}
+		var r result
+		if err := json.Unmarshal([]byte(stdout), &r); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+		}
+		return r
+	}
+
 	tests := []struct {
-		input    string
-		wantBase string
-		wantHead string
+		args         []string
+		wantHead     string
+		wantWorktree string
+		wantFiles    int
 	}{
-		{"main...HEAD", "main", "HEAD"},
-		{"abc123..def456", "abc123", "def456"},
-		{"single-ref", "single-ref", ""},
+		// Without refs a dirty tree is compared by default, showing only the
+		// local edit since main is checked out.
+		{nil, "WORKTREE", "auto", 1},
+		{[]string{"--worktree", "exclude"}, "HEAD", "exclude", 0},
+		// Explicit refs compare commits unless the worktree is included.
+		{[]string{rng}, headRef, "auto", 4},
+		{[]string{rng, "--worktree", "include"}, "WORKTREE", "include", 4},
 	}
-
 	for _, tt := range tests {
-		base, head := parseRefRange(tt.input)
-		if base != tt.wantBase || head != tt.wantHead {
-			t.Errorf("parseRefRange(%q) = (%q, %q), want (%q, %q)",
-				tt.input, base, head, tt.wantBase, tt.wantHead)
+		r := analyze(tt.args...)
+		if r.Meta.Head != tt.wantHead || r.Meta.Worktree != tt.wantWorktree {
+			t.Errorf("%v: meta head %q, worktree %q; want %q, %q", tt.args, r.Meta.Head, r.Meta.Worktree, tt.wantHead, tt.wantWorktree)
+		}
+		if len(r.ByFile) != tt.wantFiles {
+			t.Errorf("%v: %d files, want %d", tt.args, len(r.ByFile), tt.wantFiles)
+		}
+	}
+
+	// The local edit adds to the committed README.md change.
+	committed, included := analyze(rng), analyze(rng, "--worktree", "include")
+	if committed.ByFile[0].Path != "README.md" || included.ByFile[0].Churn <= committed.ByFile[0].Churn {
+		t.Errorf("README.md churn: committed %+v, with worktree %+v; want more with the worktree", committed.ByFile[0], included.ByFile[0])
+	}
+
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "--staged", "--worktree", "include"); exitCode != 1 || !strings.Contains(stderr, "cannot be combined") {
+		t.Errorf("expected --worktree include with --staged to fail, got %d: %s", exitCode, stderr)
+	}
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "--worktree", "always"); exitCode != 2 {
+		t.Errorf("expected an unknown --worktree mode to exit 2, got %d: %s", exitCode, stderr)
+	}
+}
+
+func TestE2E_WorktreeBreakdown(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	// Stage a new file and leave an unstaged edit to a tracked one.
+	writeFile(t, filepath.Join(dir, "staged.go"), "package main\nfunc staged() {}\n")
+	cmd := exec.Command("git", "add", "staged.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	writeFile(t, filepath.Join(dir, "README.md"), "# Test\n\nA description.\nMore.\n")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--format", "json", "--worktree", "include", baseRef+".."+headRef)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	type bucket struct {
+		Total struct {
+			Added int `json:"added"`
+			Files int `json:"files"`
+		} `json:"total"`
+		ByCategory map[string]json.RawMessage `json:"by_category"`
+	}
+	var result struct {
+		Meta struct {
+			Breakdown *struct {
+				Committed bucket `json:"committed"`
+				Staged    bucket `json:"staged"`
+				Unstaged  bucket `json:"unstaged"`
+			} `json:"worktree_breakdown"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	wb := result.Meta.Breakdown
+	if wb == nil {
+		t.Fatalf("expected meta.worktree_breakdown, got:\n%s", stdout)
+	}
+	if wb.Committed.Total.Files != 4 {
+		t.Errorf("committed: %d files, want 4", wb.Committed.Total.Files)
+	}
+	if wb.Staged.Total.Files != 1 || wb.Staged.Total.Added != 2 || wb.Staged.ByCategory["source"] == nil {
+		t.Errorf("staged: %+v, want staged.go's 2 source lines", wb.Staged)
+	}
+	if wb.Unstaged.Total.Files != 1 || wb.Unstaged.Total.Added != 1 || wb.Unstaged.ByCategory["docs"] == nil {
+		t.Errorf("unstaged: %+v, want README.md's 1 docs line", wb.Unstaged)
+	}
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "--worktree", "include", baseRef+".."+headRef)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "Worktree: committed ") || !strings.Contains(stdout, ", staged +2 -0 (2), unstaged +1 -0 (1)") {
+		t.Errorf("expected the worktree breakdown line, got:\n%s", stdout)
+	}
+
+	// Commit ranges have no breakdown.
+	stdout, _, _ = runDiffer(t, bin, dir, "--format", "json", baseRef+".."+headRef)
+	if strings.Contains(stdout, "worktree_breakdown") {
+		t.Errorf("expected no worktree_breakdown for a commit range, got:\n%s", stdout)
+	}
+}
+func TestE2E_SaveBaselineAndCompare(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	_, stderr, exitCode := runDiffer(t, bin, dir, baseRef+"..."+baseRef, "--save-baseline", baseline, "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+	}
+	if _, err := os.Stat(baseline); err != nil {
+		t.Fatalf("expected baseline file to be written: %v", err)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "compare", baseline, "--base", baseRef, "--head", headRef, "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	total := result["total"].(map[string]interface{})
+	if total["churn_delta"].(float64) <= 0 {
+		t.Errorf("expected positive churn delta, got %v", total["churn_delta"])
+	}
+}
+
+func TestE2E_ConfigTest(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir := t.TempDir()
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), `
+expectations:
+  main.go: source
+  README.md: docs
+`)
+	stdout, _, exitCode := runDiffer(t, bin, dir, "config", "test")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d:\n%s", exitCode, stdout)
+	}
+	if !strings.Contains(stdout, "2 passed, 0 failed") {
+		t.Errorf("expected pass summary, got:\n%s", stdout)
+	}
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), `
+expectations:
+  main.go: docs
+`)
+	stdout, _, exitCode = runDiffer(t, bin, dir, "config", "test")
+	if exitCode != 1 {
+		t.Fatalf("expected exit code 1, got %d:\n%s", exitCode, stdout)
+	}
+	if !strings.Contains(stdout, "FAIL main.go: expected docs, got source") {
+		t.Errorf("expected mismatch report, got:\n%s", stdout)
+	}
+}
+
+func TestE2E_ConfigSchema(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir := t.TempDir()
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "config", "schema")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Enum []string `json:"enum"`
+		} `json:"properties"`
+		Defs map[string]struct {
+			Enum []string `json:"enum"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &schema); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	// The schema's lists of values must follow the code's.
+	for _, c := range []struct {
+		name      string
+		got, want []string
+	}{
+		{"sort", schema.Properties["sort"].Enum, output.SortModes},
+		{"diff_algorithm", schema.Properties["diff_algorithm"].Enum, gitdiff.DiffAlgorithms},
+		{"category", schema.Defs["category"].Enum, classify.Categories},
+	} {
+		if !slices.Equal(c.got, c.want) {
+			t.Errorf("schema %s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	// Loaded config is checked against the schema, with locations.
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "categories:\n  docs:\n    pattern: [\"handbook/**\"]\n")
+	_, stderr, exitCode = runDiffer(t, bin, dir, "config", "test")
+	if exitCode != 2 || !strings.Contains(stderr, ".differ.yml: line 3, column 5: categories.docs.pattern: unknown key") {
+		t.Errorf("expected exit 2 with the location of the unknown key, got %d: %s", exitCode, stderr)
+	}
+}
+
+func TestE2E_RulesExport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".differ.yml"), `
+categories:
+  docs:
+    patterns: ["handbook/"]
+`)
+
+	stdout, _, exitCode := runDiffer(t, bin, dir, "rules", "export")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", exitCode)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	categories := result["categories"].(map[string]interface{})
+	docs := categories["docs"].(map[string]interface{})
+	custom, ok := docs["custom"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected custom docs rules, got %v", docs)
+	}
+	if custom["patterns"].([]interface{})[0] != "handbook/" {
+		t.Errorf("custom.patterns = %v, want [handbook/]", custom["patterns"])
+	}
+
+	// In a repository, per-directory config and .gitattributes are listed.
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	writeFile(t, filepath.Join(dir, "api", ".differ.yml"), "languages:\n  \"*.inc\": PHP\n")
+	writeFile(t, filepath.Join(dir, "api", ".gitattributes"), "gen/** linguist-generated\n")
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "rules", "export")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0 in a repository, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var rules classify.RuleSet
+	if err := json.Unmarshal([]byte(stdout), &rules); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(rules.Scopes) != 1 || rules.Scopes[0].Dir != "api" || rules.Scopes[0].Languages["*.inc"] != "PHP" {
+		t.Errorf("scopes = %+v, want api with *.inc: PHP", rules.Scopes)
+	}
+	want := []classify.AttributeRule{{File: "api/.gitattributes", Pattern: "gen/**", Attribute: "linguist-generated", Value: "set", Category: classify.Generated}}
+	if !reflect.DeepEqual(rules.Attributes, want) {
+		t.Errorf("attributes = %+v, want %+v", rules.Attributes, want)
+	}
+	if rules.MinifiedLineLength != classify.DefaultMinifiedLineLength || rules.ShebangLanguages["python"] != "Python" {
+		t.Errorf("minified_line_length = %d, shebang_languages = %v", rules.MinifiedLineLength, rules.ShebangLanguages)
+	}
+}
+
+func TestE2E_StagedOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "staged.go"), "package main\n\nfunc staged() {}\n")
+	cmd := exec.Command("git", "add", "staged.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	writeFile(t, filepath.Join(dir, "unstaged.go"), "package main\n")
+
+	stdout, _, exitCode := runDiffer(t, bin, dir, "--staged", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", exitCode)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	meta := result["meta"].(map[string]interface{})
+	if meta["base"] != "HEAD" || meta["head"] != "INDEX" {
+		t.Errorf("meta = %v, want base HEAD head INDEX", meta)
+	}
+	byFile := result["by_file"].([]interface{})
+	if len(byFile) != 1 || byFile[0].(map[string]interface{})["path"] != "staged.go" {
+		t.Errorf("expected only staged.go, got %v", byFile)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "--staged", "main...HEAD")
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for --staged with rev-range, got %d", exitCode)
+	}
+}
+
+func TestE2E_ASCIIOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "internal", "api", "api.go"), "package api\n\nfunc New() {}\n")
+	cmd := exec.Command("git", "add", "-A")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	cmd = exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "feat(api): add constructor ✨")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "changelog", baseRef, "--ascii")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+	}
+	for i, c := range []byte(stdout) {
+		if c >= 0x80 {
+			t.Fatalf("non-ASCII byte at offset %d:\n%s", i, stdout)
+		}
+	}
+	if !strings.Contains(stdout, `add constructor \u2728 (`) || !strings.Contains(stdout, ") - +") {
+		t.Errorf("expected escaped emoji and ASCII dash in changelog:\n%s", stdout)
+	}
+}
+
+func TestE2E_Changelog(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, _ := setupTestRepo(t)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test",
+			"GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test",
+			"GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	writeFile(t, filepath.Join(dir, "internal", "api", "api.go"), "package api\n\nfunc New() {}\n")
+	git("add", "-A")
+	git("commit", "-m", "feat(api): add constructor")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "changelog", baseRef)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+	}
+	for _, want := range []string{
+		"## Changes in " + baseRef + "..HEAD",
+		"### Features",
+		"**api**: add constructor",
+		"in internal",
+		"### Other",
+		"add features",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in changelog:\n%s", want, stdout)
 		}
 	}
 }
+
+func TestE2E_UnstagedOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "staged.go"), "package main\n\nfunc staged() {}\n")
+	cmd := exec.Command("git", "add", "staged.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {\n\tprintln(\"unstaged\")\n}\n")
+
+	stdout, _, exitCode := runDiffer(t, bin, dir, "--unstaged", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", exitCode)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	meta := result["meta"].(map[string]interface{})
+	if meta["base"] != "INDEX" || meta["head"] != "WORKTREE" {
+		t.Errorf("meta = %v, want base INDEX head WORKTREE", meta)
+	}
+	byFile := result["by_file"].([]interface{})
+	if len(byFile) != 1 || byFile[0].(map[string]interface{})["path"] != "main.go" {
+		t.Errorf("expected only main.go, got %v", byFile)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "--unstaged", "--staged")
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for --unstaged with --staged, got %d", exitCode)
+	}
+}
+
+func TestE2E_SingleCommitShorthand(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, _, exitCode := runDiffer(t, bin, dir, "HEAD", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", exitCode)
+	}
+	var single map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &single); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+
+	stdout, _, exitCode = runDiffer(t, bin, dir, baseRef+".."+headRef, "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", exitCode)
+	}
+	var explicit map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &explicit); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+
+	if single["total"].(map[string]interface{})["churn"] != explicit["total"].(map[string]interface{})["churn"] {
+		t.Errorf("HEAD churn %v != explicit range churn %v", single["total"], explicit["total"])
+	}
+	if meta := single["meta"].(map[string]interface{}); meta["base"] != "HEAD^" || meta["head"] != "HEAD" {
+		t.Errorf("meta = %v, want base HEAD^ head HEAD", meta)
+	}
+
+	// The root commit is compared against the empty tree.
+	stdout, _, exitCode = runDiffer(t, bin, dir, baseRef, "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0 for root commit, got %d", exitCode)
+	}
+	if err := json.Unmarshal([]byte(stdout), &single); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if single["total"].(map[string]interface{})["churn"].(float64) <= 0 {
+		t.Errorf("expected positive churn for root commit, got %v", single["total"])
+	}
+}
+
+func TestE2E_Reviewers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	writeFile(t, filepath.Join(dir, "CODEOWNERS"), "*.go @gopher\n*.md @writer\n")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "reviewers", "--base", baseRef, "--head", headRef, "--blame", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+	}
+
+	var result struct {
+		Reviewers []struct {
+			Reviewer string   `json:"reviewer"`
+			Lines    float64  `json:"lines"`
+			Sources  []string `json:"sources"`
+		} `json:"reviewers"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(result.Reviewers) == 0 || result.Reviewers[0].Reviewer != "@gopher" {
+		t.Fatalf("expected @gopher first, got %+v", result.Reviewers)
+	}
+	found := map[string]bool{}
+	for _, r := range result.Reviewers {
+		found[r.Reviewer] = true
+	}
+	if !found["@writer"] || !found["test@test.com"] {
+		t.Errorf("expected @writer and blame author test@test.com, got %+v", result.Reviewers)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "reviewers", "--request")
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for --request without --github-pr, got %d", exitCode)
+	}
+
+	// For base...head, lines are blamed at the merge base, not at a base
+	// that has since been rewritten by someone else.
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test",
+			"GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test",
+			"GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("checkout", "-q", "-b", "feature")
+	writeFile(t, filepath.Join(dir, "README.md"), "# Test\n")
+	git("commit", "-q", "-am", "trim readme")
+	git("checkout", "-q", "main")
+	writeFile(t, filepath.Join(dir, "README.md"), "# Rewritten\n\nBy someone else.\n")
+	git("commit", "-q", "-am", "rewrite readme", "--author", "Other <other@test.com>")
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "reviewers", "main...feature", "--blame", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+	}
+	result.Reviewers = nil
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	found = map[string]bool{}
+	for _, r := range result.Reviewers {
+		found[r.Reviewer] = true
+	}
+	if !found["test@test.com"] || found["other@test.com"] {
+		t.Errorf("expected blame author test@test.com from the merge base only, got %+v", result.Reviewers)
+	}
+
+	// A renamed file is blamed under its old path.
+	writeFile(t, filepath.Join(dir, "guide.txt"), strings.Repeat("a line of the guide\n", 10))
+	git("add", "guide.txt")
+	git("commit", "-q", "-m", "add guide", "--author", "Writer <writer@test.com>")
+	git("checkout", "-q", "-b", "moved")
+	git("mv", "guide.txt", "handbook.txt")
+	writeFile(t, filepath.Join(dir, "handbook.txt"), strings.Repeat("a line of the guide\n", 9))
+	git("commit", "-q", "-am", "move guide")
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "reviewers", "main...moved", "--blame", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+	}
+	result.Reviewers = nil
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(result.Reviewers) != 1 || result.Reviewers[0].Reviewer != "writer@test.com" {
+		t.Errorf("expected blame author writer@test.com of the renamed file, got %+v", result.Reviewers)
+	}
+}
+
+func TestE2E_UnknownBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	_, stderr, exitCode := runDiffer(t, bin, dir, "--backend", "nope")
+	if exitCode != 2 {
+		t.Fatalf("expected exit code 2, got %d", exitCode)
+	}
+	if !strings.Contains(stderr, "available: git, gogit") {
+		t.Errorf("expected available backends in error, got: %s", stderr)
+	}
+}
+
+func TestE2E_GoGitBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	want, stderr, exitCode := runDiffer(t, bin, dir, baseRef+"..."+headRef, "-l", "--color", "never")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+
+	// Without git on PATH, only the gogit backend can read the repository.
+	cmd := exec.Command(bin, "--backend", "gogit", baseRef+"..."+headRef, "-l", "--color", "never")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PATH="+t.TempDir(), "DIFFER_CACHE_DIR="+t.TempDir())
+	got, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("differ --backend gogit: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("gogit backend:\n%s\ngit backend:\n%s", got, want)
+	}
+
+	// What gogit cannot do is rejected before anything runs.
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{baseRef + "..." + headRef, "--commit-counts", "--diff-algorithm", "patience"}, "the gogit backend does not support --commit-counts, --diff-algorithm"},
+		{[]string{"--staged"}, "does not support --staged"},
+		{[]string{"--since", "1y"}, "does not support --since"},
+		{[]string{baseRef + "..." + headRef, "--git-bin", "git"}, "--git-bin and --git-arg apply to the git backend"},
+		{[]string{"authors"}, "--backend applies to differ itself, not to differ authors"},
+	} {
+		_, stderr, exitCode := runDiffer(t, bin, dir, append([]string{"--backend", "gogit"}, tc.args...)...)
+		if exitCode != 2 || !strings.Contains(stderr, tc.want) {
+			t.Errorf("%v: exit code %d, stderr %q; want 2 and %q", tc.args, exitCode, stderr, tc.want)
+		}
+	}
+}
+
+func TestE2E_FutureConfigVersion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, base, head := setupTestRepo(t)
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "version: 99\n")
+
+	_, stderr, exitCode := runDiffer(t, bin, dir, base+".."+head)
+	if exitCode != 2 {
+		t.Fatalf("expected exit code 2, got %d", exitCode)
+	}
+	if !strings.Contains(stderr, "version 99 is newer than this differ supports") {
+		t.Errorf("expected version error, got: %s", stderr)
+	}
+}
+
+func TestE2E_Stack(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test",
+			"GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test",
+			"GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	git("checkout", "-b", "feat-a")
+	writeFile(t, filepath.Join(dir, "a.go"), "package main\n\nfunc a() {}\n")
+	git("add", "-A")
+	git("commit", "-m", "layer a")
+	git("checkout", "-b", "feat-b")
+	writeFile(t, filepath.Join(dir, "b.go"), "package main\n\nfunc b() {}\n\nfunc b2() {}\n")
+	git("add", "-A")
+	git("commit", "-m", "layer b")
+	git("branch", "--set-upstream-to=feat-a", "feat-b")
+	git("branch", "--set-upstream-to=main", "feat-a")
+
+	type stackResult struct {
+		Layers []struct {
+			Name   string `json:"name"`
+			ByFile []struct {
+				Path string `json:"path"`
+			} `json:"by_file"`
+		} `json:"layers"`
+	}
+	check := func(args ...string) {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, args...)
+		if exitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+		}
+		var result stackResult
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+		}
+		if len(result.Layers) != 2 {
+			t.Fatalf("expected 2 layers, got %+v", result.Layers)
+		}
+		a, b := result.Layers[0], result.Layers[1]
+		if a.Name != "feat-a" || len(a.ByFile) != 1 || a.ByFile[0].Path != "a.go" {
+			t.Errorf("layer a = %+v", a)
+		}
+		if b.Name != "feat-b" || len(b.ByFile) != 1 || b.ByFile[0].Path != "b.go" {
+			t.Errorf("layer b = %+v", b)
+		}
+	}
+
+	check("stack", "main", "feat-a", "feat-b", "--format", "json")
+	check("stack", "--format", "json")
+}
+
+func TestE2E_IgnoreWhitespace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	// Re-indent main.go without changing any tokens.
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nimport \"fmt\"\n\nfunc main() {\n        fmt.Println(\"hello\")\n}\n")
+
+	churn := func(args ...string) float64 {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append([]string{"--unstaged", "--format", "json"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+		}
+		return result["total"].(map[string]interface{})["churn"].(float64)
+	}
+
+	if got := churn(); got != 2 {
+		t.Errorf("expected churn 2 for re-indent without -w, got %v", got)
+	}
+	if got := churn("--ignore-whitespace"); got != 0 {
+		t.Errorf("expected churn 0 with --ignore-whitespace, got %v", got)
+	}
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "ignore_whitespace: true\n")
+	if got := churn(); got != 0 {
+		t.Errorf("expected churn 0 with ignore_whitespace config, got %v", got)
+	}
+	if got := churn("--ignore-whitespace=false"); got != 2 {
+		t.Errorf("expected CLI false to override config, got churn %v", got)
+	}
+}
+
+func TestE2E_DiffAlgorithm(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	// myers and histogram align this edit differently.
+	writeFile(t, filepath.Join(dir, "main.go"), "}\na\ny\n")
+	git := exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qam", "shape")
+	git.Dir = dir
+	if out, err := git.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+	writeFile(t, filepath.Join(dir, "main.go"), "b\ny\na\n}\nb\ny\ny\n}\n")
+
+	run := func(args ...string) (churn float64, algorithm string) {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append([]string{"--unstaged", "--format", "json"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+		}
+		meta := result["meta"].(map[string]interface{})
+		return result["total"].(map[string]interface{})["churn"].(float64), meta["diff_algorithm"].(string)
+	}
+
+	if churn, algorithm := run(); churn != 7 || algorithm != "myers" {
+		t.Errorf("default: churn %v, algorithm %q; want 7 and myers", churn, algorithm)
+	}
+	if churn, algorithm := run("--diff-algorithm", "histogram"); churn != 9 || algorithm != "histogram" {
+		t.Errorf("--diff-algorithm histogram: churn %v, algorithm %q; want 9 and histogram", churn, algorithm)
+	}
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "diff_algorithm: histogram\n")
+	if churn, _ := run(); churn != 9 {
+		t.Errorf("expected diff_algorithm config to apply, got churn %v", churn)
+	}
+	if _, algorithm := run("--diff-algorithm", "myers"); algorithm != "myers" {
+		t.Errorf("expected the flag to override config, got %q", algorithm)
+	}
+
+	if _, _, exitCode := runDiffer(t, bin, dir, "--diff-algorithm", "fastest"); exitCode != 2 {
+		t.Errorf("expected exit code 2 for an unknown algorithm, got %d", exitCode)
+	}
+}
+
+func TestE2E_ConfigFlagDefaults(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	writeFile(t, filepath.Join(dir, ".differ.yml"), fmt.Sprintf("format: json\nempty: include\ncategory: [source]\nbase: %s\nhead: %s\n", baseRef, headRef))
+
+	run := func(args ...string) map[string]interface{} {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, args...)
+		if exitCode != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", exitCode, stderr)
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("expected JSON from format: json, got %v\n%s", err, stdout)
+		}
+		return result
+	}
+
+	// The configured refs, format, empty mode, and category apply. The
+	// uncommitted .differ.yml is not counted, since the refs are commits.
+	result := run()
+	meta := result["meta"].(map[string]interface{})
+	if meta["base"] != baseRef || meta["head"] != headRef || meta["empty"] != "include" {
+		t.Errorf("meta = %v, want configured refs and empty include", meta)
+	}
+	categories := result["by_category"].(map[string]interface{})
+	if _, ok := categories["source"]; !ok || len(categories) != 1 {
+		t.Errorf("by_category = %v, want only source", categories)
+	}
+	if got := result["total"].(map[string]interface{})["churn"].(float64); got != 6 {
+		t.Errorf("churn = %v, want 6 with the empty line counted", got)
+	}
+
+	// Flags override the config, and a rev-range replaces configured refs.
+	result = run("--empty", "exclude", "--category", "docs", "HEAD~1..HEAD")
+	meta = result["meta"].(map[string]interface{})
+	if meta["base"] != "HEAD~1" || meta["empty"] != "exclude" {
+		t.Errorf("meta = %v, want the rev-range and empty exclude", meta)
+	}
+	if _, ok := result["by_category"].(map[string]interface{})["docs"]; !ok {
+		t.Errorf("by_category = %v, want docs", result["by_category"])
+	}
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--format", "text")
+	if exitCode != 0 || !strings.Contains(stdout, "Source:") {
+		t.Errorf("--format text: exit %d, stdout %q, stderr %q", exitCode, stdout, stderr)
+	}
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "format: xml\n")
+	_, stderr, exitCode = runDiffer(t, bin, dir)
+	if exitCode != 2 || !strings.Contains(stderr, "loading config: format") {
+		t.Errorf("unknown configured format: exit %d, stderr %q; want 2", exitCode, stderr)
+	}
+}
+
+func TestE2E_Color(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	colored := func(env map[string]string, args ...string) bool {
+		t.Helper()
+		for k, v := range map[string]string{"NO_COLOR": "", "CLICOLOR_FORCE": ""} {
+			t.Setenv(k, v)
+		}
+		for k, v := range env {
+			t.Setenv(k, v)
+		}
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append([]string{baseRef + ".." + headRef}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d: %s", args, exitCode, stderr)
+		}
+		return strings.Contains(stdout, "\033[")
+	}
+
+	force := map[string]string{"CLICOLOR_FORCE": "1"}
+	for _, c := range []struct {
+		env  map[string]string
+		args []string
+		want bool
+	}{
+		{nil, nil, false}, // piped output is not a terminal
+		{nil, []string{"--color", "always"}, true},
+		{force, nil, true},
+		{map[string]string{"CLICOLOR_FORCE": "1", "NO_COLOR": "1"}, nil, false},
+		{force, []string{"--color", "never"}, false},
+		{force, []string{"--no-color"}, false},
+		{map[string]string{"NO_COLOR": "1"}, []string{"--color=always"}, true},
+	} {
+		if got := colored(c.env, c.args...); got != c.want {
+			t.Errorf("env %v, args %v: colored = %v, want %v", c.env, c.args, got, c.want)
+		}
+	}
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "color: always\n")
+	if !colored(nil) {
+		t.Error("expected color: always in config to color piped output")
+	}
+	if colored(nil, "--color", "auto") {
+		t.Error("expected --color auto to override the config")
+	}
+
+	_, stderr, exitCode := runDiffer(t, bin, dir, "--color", "sometimes")
+	if exitCode != 2 || !strings.Contains(stderr, "--color must be one of auto, always, never") {
+		t.Errorf("--color sometimes: exit %d, stderr %q; want 2", exitCode, stderr)
+	}
+}
+
+func TestE2E_Pager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+	if _, err := exec.LookPath("script"); err != nil || runtime.GOOS != "linux" {
+		t.Skip("needs util-linux script to run differ on a terminal")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	// script gives differ a terminal for stdout.
+	onTerminal := func(env []string, args ...string) string {
+		t.Helper()
+		command := fmt.Sprintf("%q %s..%s --color never %s", bin, baseRef, headRef, strings.Join(args, " "))
+		cmd := exec.Command("script", "-qec", command, "/dev/null")
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "DIFFER_CACHE_DIR="+filepath.Join(filepath.Dir(bin), "cache"))
+		cmd.Env = append(cmd.Env, env...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("script %s: %v\n%s", command, err, out)
+		}
+		return strings.ReplaceAll(string(out), "\r", "")
+	}
+	prefix := "sed 's/^/paged: /'"
+
+	if out := onTerminal([]string{"PAGER=" + prefix}); !strings.Contains(out, "paged: Source:") {
+		t.Errorf("expected output through $PAGER, got:\n%s", out)
+	}
+	if out := onTerminal([]string{"PAGER=" + prefix}, "--no-pager"); strings.Contains(out, "paged:") || !strings.Contains(out, "Source:") {
+		t.Errorf("expected --no-pager to write directly, got:\n%s", out)
+	}
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "pager: \"\"\n")
+	if out := onTerminal([]string{"PAGER=" + prefix}); strings.Contains(out, "paged:") {
+		t.Errorf("expected pager: \"\" in config to turn paging off, got:\n%s", out)
+	}
+	if out := onTerminal([]string{"DIFFER_PAGER=" + prefix}); !strings.Contains(out, "paged: Source:") {
+		t.Errorf("expected DIFFER_PAGER to override the config, got:\n%s", out)
+	}
+
+	// Piped output is never paged.
+	t.Setenv("DIFFER_PAGER", prefix)
+	if stdout, _, _ := runDiffer(t, bin, dir, baseRef+".."+headRef); strings.Contains(stdout, "paged:") {
+		t.Errorf("expected piped output not to be paged, got:\n%s", stdout)
+	}
+}
+
+func TestE2E_WorktreeComparison(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	other := filepath.Join(t.TempDir(), "feature")
+	cmd := exec.Command("git", "worktree", "add", "-b", "feature", other)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git worktree add failed: %v\n%s", err, out)
+	}
+
+	// Uncommitted edits on both sides are part of the comparison.
+	writeFile(t, filepath.Join(dir, "README.md"), "# Test\n\nA description.\nMain only.\n")
+	writeFile(t, filepath.Join(other, "extra.go"), "package main\n\nfunc extra() {}\n")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, other, "--worktree-a", dir, "--worktree-b", other, "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	got := map[string]bool{}
+	for _, f := range result["by_file"].([]interface{}) {
+		got[f.(map[string]interface{})["path"].(string)] = true
+	}
+	if len(got) != 2 || !got["README.md"] || !got["extra.go"] {
+		t.Errorf("expected README.md and extra.go, got %v", got)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, other, "--worktree-a", dir)
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for --worktree-a alone, got %d", exitCode)
+	}
+	_, _, exitCode = runDiffer(t, bin, other, "--worktree-a", dir, "--worktree-b", t.TempDir())
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for a non-repository worktree, got %d", exitCode)
+	}
+}
+
+func TestE2E_PartialCloneAndSparseCheckout(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	src, baseRef, headRef := setupTestRepo(t)
+
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git(src, "config", "uploadpack.allowFilter", "true")
+	git(src, "config", "uploadpack.allowAnySHA1InWant", "true")
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	git(src, "clone", "--quiet", "--filter=blob:none", "file://"+src, clone)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, clone, baseRef+".."+headRef, "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	total := result["total"].(map[string]interface{})
+	if total["files"].(float64) != 4 {
+		t.Errorf("expected 4 files in the partial clone, got %v", total["files"])
+	}
+	if _, ok := result["meta"].(map[string]interface{})["warnings"]; ok {
+		t.Errorf("expected no warnings after a successful prefetch, got %v", result["meta"])
+	}
+
+	// Local edits in a sparse checkout are annotated.
+	git(clone, "sparse-checkout", "set", "--no-cone", "/*.go")
+	writeFile(t, filepath.Join(clone, "main.go"), "package main\n")
+	stdout, _, exitCode = runDiffer(t, bin, clone, "--unstaged", "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", exitCode)
+	}
+	if !strings.Contains(stdout, "info: working-tree changes are only detected inside the sparse checkout (sparse-checkout)") {
+		t.Errorf("expected sparse checkout warning, got:\n%s", stdout)
+	}
+}
+
+func TestE2E_RecordAndSite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	db := filepath.Join(t.TempDir(), "churn.db")
+
+	for i := 0; i < 2; i++ {
+		_, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--record", db, "--no-color")
+		if exitCode != 0 {
+			t.Fatalf("record run %d: exit code %d\nstderr: %s", i, exitCode, stderr)
+		}
+	}
+
+	data, err := os.ReadFile(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 ledger lines, got %d", len(lines))
+	}
+	var rec map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("invalid ledger line: %v", err)
+	}
+	if rec["repo"] != filepath.Base(dir) || rec["head_sha"] != headRef {
+		t.Errorf("record = repo %v head %v, want %s %s", rec["repo"], rec["head_sha"], filepath.Base(dir), headRef)
+	}
+
+	out := filepath.Join(t.TempDir(), "site")
+	_, stderr, exitCode := runDiffer(t, bin, dir, "site", "--db", db, "--out", out)
+	if exitCode != 0 {
+		t.Fatalf("site: exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	index, err := os.ReadFile(filepath.Join(out, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), "2 recorded runs across 1 repository") {
+		t.Errorf("unexpected index page:\n%s", index)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "site", "--db", filepath.Join(t.TempDir(), "missing.db"), "--out", out)
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for a missing ledger, got %d", exitCode)
+	}
+}
+
+func TestE2E_RecordSQLite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	db := filepath.Join(t.TempDir(), "churn.sqlite")
+
+	for i := 0; i < 2; i++ {
+		_, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--record", "sqlite://"+db)
+		if exitCode != 0 {
+			t.Fatalf("record run %d: exit code %d\nstderr: %s", i, exitCode, stderr)
+		}
+	}
+
+	conn, err := sql.Open("sqlite", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var runs, churn, files int
+	var headSHA string
+	if err := conn.QueryRow("SELECT count(*), min(head_sha), sum(churn) FROM runs").Scan(&runs, &headSHA, &churn); err != nil {
+		t.Fatalf("querying the database: %v", err)
+	}
+	if err := conn.QueryRow("SELECT count(*) FROM files WHERE path = ?", "main.go").Scan(&files); err != nil {
+		t.Fatalf("querying the database: %v", err)
+	}
+	stdout, _, _ := runDiffer(t, bin, dir, baseRef+".."+headRef, "--format", "json")
+	var report struct {
+		Total struct{ Churn int } `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 2 || headSHA != headRef || churn != 2*report.Total.Churn || files != 2 {
+		t.Errorf("query = %d runs, head %s, churn %d, %d main.go rows; want 2, %s, %d, 2", runs, headSHA, churn, files, headRef, 2*report.Total.Churn)
+	}
+
+	site := filepath.Join(t.TempDir(), "site")
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "site", "--db", "sqlite://"+db, "--out", site); exitCode != 0 {
+		t.Fatalf("site from SQLite: exit code %d\nstderr: %s", exitCode, stderr)
+	}
+}
+
+func TestE2E_Trend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	db := filepath.Join(t.TempDir(), "churn.db")
+
+	for i := 0; i < 2; i++ {
+		if _, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--record", db); exitCode != 0 {
+			t.Fatalf("record run %d: exit code %d\nstderr: %s", i, exitCode, stderr)
+		}
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "trend", "--db", db, "--period", "run")
+	if exitCode != 0 {
+		t.Fatalf("trend: exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	lines := strings.Split(stdout, "\n")
+	if len(lines) < 3 || !strings.Contains(lines[0], "Source") || !strings.Contains(lines[1], headRef[:7]) || !strings.Contains(lines[1], "SSS") {
+		t.Errorf("expected a header and a charted row per run, got:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "S Source") {
+		t.Errorf("expected a legend, got:\n%s", stdout)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "trend", "--db", db, "--format", "json")
+	var result struct {
+		Repo   string `json:"repo"`
+		Points []struct {
+			Runs int `json:"runs"`
+		} `json:"points"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.Repo != filepath.Base(dir) || len(result.Points) != 1 || result.Points[0].Runs != 2 {
+		t.Errorf("expected both runs in this week for %s, got %s", filepath.Base(dir), stdout)
+	}
+
+	if _, _, exitCode := runDiffer(t, bin, dir, "trend", "--db", db, "--repo", "elsewhere"); exitCode != 1 {
+		t.Errorf("expected exit code 1 for a repository without runs, got %d", exitCode)
+	}
+	if _, _, exitCode := runDiffer(t, bin, dir, "trend", "--db", db, "--period", "year"); exitCode != 2 {
+		t.Errorf("expected exit code 2 for an unknown period, got %d", exitCode)
+	}
+}
+
+func TestE2E_DetectMoves(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	// Move greet from main.go into its own file and change main itself.
+	body := "func greet(name string) string {\n\treturn \"hello, \" + name\n}\n"
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\n"+body+"\nfunc main() {}\n")
+	cmd := exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qam", "add greet")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() { println(greet(\"x\")) }\n")
+	writeFile(t, filepath.Join(dir, "greet.go"), "package main\n\n"+body)
+
+	cmd = exec.Command("git", "add", "greet.go", "main.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--staged", "--detect-moves", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	total := result["total"].(map[string]interface{})
+	// The three greet lines moved out of main.go and into greet.go.
+	if total["moved"] != float64(6) {
+		t.Errorf("total.moved = %v, want 6", total["moved"])
+	}
+	if result["meta"].(map[string]interface{})["detect_moves"] != true {
+		t.Errorf("meta.detect_moves not set: %v", result["meta"])
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "--staged", "--format", "json")
+	result = nil
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if _, ok := result["total"].(map[string]interface{})["moved"]; ok {
+		t.Error("moved should be omitted without --detect-moves")
+	}
+}
+
+func TestE2E_CountMeaningful(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	// Prepend a license header to main.go and change one line of code.
+	writeFile(t, filepath.Join(dir, "main.go"), "// Copyright 2024 Test\n// SPDX-License-Identifier: MIT\n\npackage main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello, world\")\n}\n")
+
+	type total struct {
+		Added      int `json:"added"`
+		Deleted    int `json:"deleted"`
+		RawAdded   int `json:"raw_added"`
+		RawDeleted int `json:"raw_deleted"`
+	}
+	var result struct {
+		Meta struct {
+			Count string `json:"count"`
+		} `json:"meta"`
+		Total total `json:"total"`
+	}
+	cmd := exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qam", "add license header")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, headRef+"..HEAD", "--count", "meaningful", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("--count meaningful exited %d: %s", exitCode, stderr)
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	// The two comment lines count only in the raw numbers.
+	if result.Meta.Count != "meaningful" || result.Total.Added != 1 || result.Total.Deleted != 1 ||
+		result.Total.RawAdded != 3 || result.Total.RawDeleted != 1 {
+		t.Errorf("expected +1 -1 with raw +3 -1, got %s", stdout)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, baseRef+".."+headRef, "--count", "meaningful")
+	if !strings.Contains(stdout, "Meaningful lines only:") {
+		t.Errorf("expected the meaningful lines note, got:\n%s", stdout)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "--count", "statements")
+	if exitCode != 2 {
+		t.Errorf("expected exit 2 for an unknown --count, got %d", exitCode)
+	}
+}
+
+func TestE2E_MinifiedGenerated(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, headRef := setupTestRepo(t)
+
+	// A bundle written on one line, past bufio's default token size, next
+	// to a hand-written script.
+	writeFile(t, filepath.Join(dir, "static", "app.js"), strings.Repeat("var a=1;", 10000)+"\n")
+	writeFile(t, filepath.Join(dir, "static", "menu.js"), "function open() {\n  return true;\n}\n")
+	for _, args := range [][]string{{"add", "-A"}, {"-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "add assets"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	var result struct {
+		ByFile []struct {
+			Path     string `json:"path"`
+			Category string `json:"category"`
+		} `json:"by_file"`
+	}
+	categories := func() map[string]string {
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, headRef+"..HEAD", "--format", "json")
+		if exitCode != 0 {
+			t.Fatalf("differ exited %d: %s", exitCode, stderr)
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+		}
+		cats := make(map[string]string)
+		for _, f := range result.ByFile {
+			cats[f.Path] = f.Category
+		}
+		return cats
+	}
+
+	if cats := categories(); cats["static/app.js"] != "generated" || cats["static/menu.js"] != "source" {
+		t.Errorf("expected app.js generated and menu.js source, got %v", cats)
+	}
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "minified_line_length: 0\n")
+	if cats := categories(); cats["static/app.js"] != "source" {
+		t.Errorf("expected app.js source with the check off, got %v", cats)
+	}
+}
+
+func TestE2E_CategoryScopedExclude(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, headRef := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "tests", "fixtures", "payload.json"), "{\n  \"id\": 1\n}\n")
+	writeFile(t, filepath.Join(dir, "internal", "fixtures", "load.go"), "package fixtures\n\nfunc Load() {}\n")
+	for _, args := range [][]string{{"add", "-A"}, {"-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "add fixtures"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, headRef+"..HEAD", "--exclude", "tests:**/fixtures/**", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+	var result struct {
+		ByFile []struct {
+			Path string `json:"path"`
+		} `json:"by_file"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(result.ByFile) != 1 || result.ByFile[0].Path != "internal/fixtures/load.go" {
+		t.Errorf("expected only the source fixture loader, got %s", stdout)
+	}
+}
+
+func TestE2E_JSONCategoriesArray(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--format", "json", "--json-categories", "array")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+	var result struct {
+		SchemaVersion int `json:"schema_version"`
+		ByCategory    []struct {
+			Category string `json:"category"`
+		} `json:"by_category"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if result.SchemaVersion != 1 {
+		t.Errorf("expected schema_version 1, got %d", result.SchemaVersion)
+	}
+	var got []string
+	for _, c := range result.ByCategory {
+		got = append(got, c.Category)
+	}
+	if want := "docs,tests,source,generated"; strings.Join(got, ",") != want {
+		t.Errorf("expected by_category in report order %s, got %v", want, got)
+	}
+
+	_, stderr, exitCode = runDiffer(t, bin, dir, baseRef+".."+headRef, "--format", "json", "--json-categories", "list")
+	if exitCode != 2 {
+		t.Errorf("expected exit 2 for an unknown --json-categories, got %d: %s", exitCode, stderr)
+	}
+}
+
+func TestE2E_CompactJSONLines(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "show", baseRef, headRef, "--format", "json", "--compact")
+	if exitCode != 0 {
+		t.Fatalf("differ show exited %d: %s", exitCode, stderr)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per commit, got %d:\n%s", len(lines), stdout)
+	}
+	for i, line := range lines {
+		var doc struct {
+			Meta struct {
+				Head string `json:"head"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal([]byte(line), &doc); err != nil {
+			t.Fatalf("line %d is not a JSON document: %v\n%s", i+1, err, line)
+		}
+		if doc.Meta.Head == "" {
+			t.Errorf("line %d has no meta.head: %s", i+1, line)
+		}
+	}
+
+	_, stderr, exitCode = runDiffer(t, bin, dir, baseRef+".."+headRef, "--compact")
+	if exitCode != 2 || !strings.Contains(stderr, "--compact requires --format json") {
+		t.Errorf("expected exit 2 for --compact with text output, got %d: %s", exitCode, stderr)
+	}
+}
+
+func TestE2E_CommitCounts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, headRef := setupTestRepo(t)
+
+	commit := func(msg string, args ...[]string) {
+		t.Helper()
+		for _, a := range append(args, []string{"add", "-A"}, []string{"-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", msg}) {
+			cmd := exec.Command("git", a...)
+			cmd.Dir = dir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", a, err, out)
+			}
+		}
+	}
+	writeFile(t, filepath.Join(dir, "README.md"), "# Test\n\nA description.\n\nUsage notes.\n")
+	commit("expand readme")
+	commit("move readme", []string{"mv", "README.md", "GUIDE.md"})
+	writeFile(t, filepath.Join(dir, "GUIDE.md"), "# Test\n\nA description.\n\nUsage notes, revised.\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() { println(1) }\n")
+	commit("revise guide")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, headRef+"..HEAD", "--commit-counts", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+	var result struct {
+		ByFile []struct {
+			Path    string `json:"path"`
+			Commits int    `json:"commits"`
+		} `json:"by_file"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	got := make(map[string]int)
+	for _, f := range result.ByFile {
+		got[f.Path] = f.Commits
+	}
+	// The guide's commits include the one made before it was renamed.
+	if got["GUIDE.md"] != 3 || got["main.go"] != 1 {
+		t.Errorf("expected 3 commits for GUIDE.md and 1 for main.go, got %v", got)
+	}
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, headRef+"..HEAD", "--commit-counts", "-L")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "GUIDE.md (3 commits)") || !strings.Contains(stdout, "main.go (1 commit)") {
+		t.Errorf("expected commit counts in the file list:\n%s", stdout)
+	}
+
+	_, stderr, exitCode = runDiffer(t, bin, dir, "--staged", "--commit-counts")
+	if exitCode == 0 || !strings.Contains(stderr, "--commit-counts needs a range of commits") {
+		t.Errorf("expected --commit-counts to be rejected with --staged, got %d: %s", exitCode, stderr)
+	}
+}
+
+func TestE2E_FileAge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(dir, "util.go"), "package main\n\nfunc util() {}\n")
+	for _, args := range [][]string{{"add", "-A"}, {"-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "simplify"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	cmd := exec.Command("git", "show", "-s", "--format=%aI", baseRef)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, headRef+"..HEAD", "--file-age", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+	var result struct {
+		ByFile []struct {
+			Path         string `json:"path"`
+			Created      string `json:"created"`
+			LastCommit   string `json:"last_commit"`
+			LastModified string `json:"last_modified"`
+		} `json:"by_file"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(result.ByFile) != 2 {
+		t.Fatalf("expected main.go and util.go, got %s", stdout)
+	}
+	for _, f := range result.ByFile {
+		switch f.Path {
+		case "main.go":
+			got, err := time.Parse(time.RFC3339, f.Created)
+			if err != nil || !got.Equal(created) || f.LastCommit != headRef || f.LastModified == "" {
+				t.Errorf("main.go: expected created %s and last changed in %s, got %+v", created, headRef, f)
+			}
+		case "util.go":
+			if f.Created != "" || f.LastCommit != "" || f.LastModified != "" {
+				t.Errorf("util.go is new and should have no age, got %+v", f)
+			}
+		}
+	}
+}
+
+func TestE2E_Print0(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, headRef := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "docs", "user guide.md"), "# Guide\n\nStart here.\n")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {\n\tprintln(1)\n\tprintln(2)\n\tprintln(3)\n}\n")
+	for _, args := range [][]string{{"add", "-A"}, {"-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "guide"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, headRef+"..HEAD", "-z", "--sort", "path")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+	if want := "docs/user guide.md\x00main.go\x00"; stdout != want {
+		t.Errorf("expected NUL-terminated paths %q, got %q", want, stdout)
+	}
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, headRef+"..HEAD", "--print0", "--top", "1")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+	if want := "main.go\x00"; stdout != want {
+		t.Errorf("expected only the file with the most churn, got %q", stdout)
+	}
+}
+
+func TestE2E_TableFormat(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--format", "table", "--sort", "path")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+	want := "" +
+		"Added  Deleted  Churn  Category       Language  Path\n" +
+		"    1        0      1  Documentation  -         README.md\n" +
+		"    1        0      1  Generated      -         go.sum\n" +
+		"    4        1      5  Source         Go        main.go\n" +
+		"    2        0      2  Tests          Go        main_test.go\n"
+	if stdout != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout, want)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, baseRef+".."+headRef, "--format", "table", "--sort", "path", "--no-header")
+	if !strings.HasPrefix(stdout, "1  0  1  Documentation  -   README.md\n") {
+		t.Errorf("expected no header row:\n%s", stdout)
+	}
+}
+
+func TestE2E_CategoryIcons(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--icons", "--format", "markdown")
+	if exitCode != 0 {
+		t.Fatalf("differ exited %d: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "| 📝 Documentation | +1 | -0 | 1 | 1 |\n") || !strings.Contains(stdout, "| 💻 Source |") {
+		t.Errorf("expected the built-in icons:\n%s", stdout)
+	}
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "icons: true\ncategory_icons:\n  docs: \"📚\"\n  source: \"\"\n")
+	stdout, _, _ = runDiffer(t, bin, dir, baseRef+".."+headRef, "--color", "never")
+	if !strings.HasPrefix(stdout, "📚 Documentation: ") || !strings.Contains(stdout, "\nSource:") || !strings.Contains(stdout, "\n🧪 Tests:") {
+		t.Errorf("expected configured icons:\n%s", stdout)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, baseRef+".."+headRef, "--icons=false")
+	if strings.Contains(stdout, "📚") {
+		t.Errorf("--icons=false should override the config:\n%s", stdout)
+	}
+}
+
+func TestE2E_DaemonOnce(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	src, _, _ := setupTestRepo(t)
+	cmd := exec.Command("git", "branch", "feature", "main")
+	cmd.Dir = src
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git branch failed: %v\n%s", err, out)
+	}
+	cmd = exec.Command("git", "reset", "-q", "--hard", "HEAD~1")
+	cmd.Dir = src
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git reset failed: %v\n%s", err, out)
+	}
+
+	work := t.TempDir()
+	clone := filepath.Join(work, "clone")
+	cmd = exec.Command("git", "clone", "-q", src, clone)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v\n%s", err, out)
+	}
+
+	db := filepath.Join(work, "churn.db")
+	cfgPath := filepath.Join(work, "daemon.yml")
+	writeFile(t, cfgPath, "db: "+db+"\nrepos:\n  - name: svc\n    path: "+clone+"\n    branches: [feature]\n")
+
+	_, stderr, exitCode := runDiffer(t, bin, work, "daemon", "--config", cfgPath, "--once")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	data, err := os.ReadFile(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec struct {
+		Repo     string
+		Snapshot struct {
+			Meta  map[string]interface{}
+			Total map[string]float64
+		}
+	}
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatalf("invalid ledger: %v\n%s", err, data)
+	}
+	if rec.Repo != "svc" || rec.Snapshot.Meta["head"] != "origin/feature" || rec.Snapshot.Total["files"] != 4 {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+
+	writeFile(t, cfgPath, "repos: []\n")
+	_, _, exitCode = runDiffer(t, bin, work, "daemon", "--config", cfgPath, "--once")
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2 for an invalid daemon config, got %d", exitCode)
+	}
+}
+
+func TestE2E_CheckPercentileLimits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	rng := baseRef + ".." + headRef
+	db := filepath.Join(t.TempDir(), "churn.db")
+
+	// History of small changes: README.md only (2 lines of churn each).
+	for i := 0; i < 3; i++ {
+		if _, stderr, code := runDiffer(t, bin, dir, rng, "--record", db, "--", "README.md"); code != 0 {
+			t.Fatalf("record: exit %d: %s", code, stderr)
+		}
+	}
+
+	stdout, _, exitCode := runDiffer(t, bin, dir, "check", rng, "--max", "p95", "--max", "generated=5", "--db", db, "--min-samples", "3")
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d\n%s", exitCode, stdout)
+	}
+	if !strings.Contains(stdout, "FAIL total churn") || !strings.Contains(stdout, "(p95 of 3 recorded runs)") {
+		t.Errorf("expected percentile failure, got:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "PASS generated churn 1 <= 5") {
+		t.Errorf("expected fixed limit to pass, got:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "1 passed, 1 failed, 0 skipped") {
+		t.Errorf("unexpected tally:\n%s", stdout)
+	}
+
+	// Not enough history: the percentile limit is skipped.
+	stdout, _, exitCode = runDiffer(t, bin, dir, "check", rng, "--max", "p95", "--db", db)
+	if exitCode != 0 || !strings.Contains(stdout, "SKIP total churn: only 3 recorded runs, need 10") {
+		t.Errorf("expected skipped check with exit 0, got %d:\n%s", exitCode, stdout)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "check", rng, "--max", "p95")
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1 for a percentile limit without --db, got %d", exitCode)
+	}
+}
+
+func TestE2E_CheckDocsRatio(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	rng := baseRef + ".." + headRef
+
+	// README.md changes 1 counted line of docs to main.go's 5 of source.
+	stdout, _, exitCode := runDiffer(t, bin, dir, "check", rng, "--min-docs-ratio", "0.1")
+	if exitCode != 0 || !strings.Contains(stdout, "PASS docs ratio 0.20 >= 0.10") {
+		t.Errorf("expected the ratio to pass, got %d:\n%s", exitCode, stdout)
+	}
+
+	stdout, _, exitCode = runDiffer(t, bin, dir, "check", rng, "--min-docs-ratio", "0.5", "--max", "1000")
+	if exitCode != 1 || !strings.Contains(stdout, "FAIL docs ratio 0.20 < 0.50") || !strings.Contains(stdout, "1 passed, 1 failed, 0 skipped") {
+		t.Errorf("expected the ratio to fail, got %d:\n%s", exitCode, stdout)
+	}
+
+	// Without source changes the ratio is skipped.
+	stdout, _, exitCode = runDiffer(t, bin, dir, "check", rng, "--min-docs-ratio", "0.5", "--", "README.md")
+	if exitCode != 0 || !strings.Contains(stdout, "SKIP docs ratio: no source churn") {
+		t.Errorf("expected the ratio to be skipped, got %d:\n%s", exitCode, stdout)
+	}
+}
+
+func TestE2E_FailOnQuiet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	rng := baseRef + ".." + headRef
+
+	// Without --quiet the report is written and failed conditions follow on
+	// stderr.
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, rng, "--fail-on", "generated.churn>0", "--fail-on", "total.files>100")
+	if exitCode != 1 {
+		t.Errorf("expected exit code 1, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "Total:") {
+		t.Errorf("expected the report on stdout, got:\n%s", stdout)
+	}
+	if stderr != "FAIL generated.churn>0 (actual 1)\n" {
+		t.Errorf("stderr = %q, want only the generated condition", stderr)
+	}
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "-q", rng, "--fail-on", "generated.churn > 0")
+	if exitCode != 1 || stdout != "" {
+		t.Errorf("expected exit code 1 and no stdout, got %d:\n%s", exitCode, stdout)
+	}
+	if lines := strings.Split(strings.TrimSpace(stderr), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], "Total: +") || !strings.HasSuffix(lines[0], "; FAIL generated.churn>0 (actual 1)") {
+		t.Errorf("expected a one-line summary with the failure, got %q", stderr)
+	}
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "--quiet", rng, "--fail-on", "docs.churn>100")
+	if exitCode != 0 || stdout != "" || !strings.HasPrefix(stderr, "Total: +") {
+		t.Errorf("expected exit code 0, no stdout, and a summary; got %d, %q, %q", exitCode, stdout, stderr)
+	}
+
+	if _, stderr, exitCode := runDiffer(t, bin, dir, rng, "--fail-on", "sources.churn>0"); exitCode != 2 || !strings.Contains(stderr, "metric must be total or a category") {
+		t.Errorf("expected an unknown metric to be rejected with exit code 2, got %d: %s", exitCode, stderr)
+	}
+	if _, stderr, exitCode := runDiffer(t, bin, dir, rng, "--fail-on", "generated.churn"); exitCode != 2 || !strings.Contains(stderr, "--fail-on") {
+		t.Errorf("expected a malformed condition to be rejected with exit code 2, got %d: %s", exitCode, stderr)
+	}
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "check", "-q", rng, "--max", "generated=0")
+	if exitCode != 1 || stdout != "" || stderr != "0 passed, 1 failed, 0 skipped\n" {
+		t.Errorf("check -q: got %d, %q, %q; want exit 1 with only the tally on stderr", exitCode, stdout, stderr)
+	}
+}
+
+func TestE2E_DependencyUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "go.mod"), "module example.com/test\n\ngo 1.25\n\nrequire (\n\tgithub.com/spf13/cobra v1.10.1\n\tgopkg.in/yaml.v3 v3.0.1\n)\n")
+	writeFile(t, filepath.Join(dir, "go.sum"), "github.com/spf13/cobra v1.10.1 h1:abc=\ngithub.com/spf13/cobra v1.10.1/go.mod h1:def=\n")
+	cmd := exec.Command("git", "add", "go.mod", "go.sum")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--staged", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	dep, ok := result["meta"].(map[string]interface{})["dependency_update"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected meta.dependency_update, got %v", result["meta"])
+	}
+	if dep["bumped"] != float64(2) {
+		t.Errorf("dependency_update.bumped = %v, want 2", dep["bumped"])
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "--staged")
+	if !strings.Contains(stdout, "Dependency update: 2 packages bumped (github.com/spf13/cobra, gopkg.in/yaml.v3)") {
+		t.Errorf("expected dependency update line, got:\n%s", stdout)
+	}
+
+	// Any other change means the run is not a pure dependency update.
+	writeFile(t, filepath.Join(dir, "extra.go"), "package main\n")
+	cmd = exec.Command("git", "add", "extra.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	stdout, _, _ = runDiffer(t, bin, dir, "--staged")
+	if strings.Contains(stdout, "Dependency update") {
+		t.Errorf("mixed change should not be tagged, got:\n%s", stdout)
+	}
+}
+
+func TestE2E_LinguistGeneratedAttribute(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, ".gitattributes"), "api/*.go linguist-generated=true\n")
+	writeFile(t, filepath.Join(dir, "api", "client.go"), "package api\n\nfunc Call() {}\n")
+	cmd := exec.Command("git", "add", ".gitattributes", "api/client.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+
+	// Run from a subdirectory to check paths are resolved from the root.
+	sub := filepath.Join(dir, "api")
+	stdout, stderr, exitCode := runDiffer(t, bin, sub, "--staged", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	found := false
+	for _, f := range result["by_file"].([]interface{}) {
+		file := f.(map[string]interface{})
+		if file["path"] == "api/client.go" {
+			found = true
+			if file["category"] != "generated" {
+				t.Errorf("api/client.go category = %v, want generated", file["category"])
+			}
+		}
+	}
+	if !found {
+		t.Errorf("api/client.go missing from by_file: %s", stdout)
+	}
+}
+
+func TestE2E_APIChurn(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "lib", "lib.go"), "package lib\n\nfunc Parse(s string) int {\n\treturn len(s)\n}\n")
+	cmd := exec.Command("git", "add", "lib/lib.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	cmd = exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "add lib")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+
+	// A body-only edit is churn but not API churn.
+	writeFile(t, filepath.Join(dir, "lib", "lib.go"), "package lib\n\nfunc Parse(s string) int {\n\treturn len(s) + 1\n}\n")
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--unstaged", "--api-churn")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "Public API churn: none") {
+		t.Errorf("expected no API churn for a body edit, got:\n%s", stdout)
+	}
+
+	writeFile(t, filepath.Join(dir, "lib", "lib.go"), "package lib\n\nfunc Parse(s string, base int) int {\n\treturn len(s) + base\n}\n")
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "--unstaged", "--api-churn", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	api, ok := result["meta"].(map[string]interface{})["api_churn"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected meta.api_churn, got %v", result["meta"])
+	}
+	if api["lines"] != float64(2) {
+		t.Errorf("api_churn.lines = %v, want 2", api["lines"])
+	}
+	symbols := api["symbols"].([]interface{})
+	if len(symbols) != 1 || symbols[0].(map[string]interface{})["name"] != "Parse" {
+		t.Errorf("api_churn.symbols = %v, want Parse", symbols)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "--unstaged", "--format", "json")
+	if strings.Contains(stdout, "api_churn") {
+		t.Error("api_churn should be omitted without --api-churn")
+	}
+}
+
+func TestE2E_SchemaChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "api.proto"), "syntax = \"proto3\";\n\nmessage User {\n  string id = 1;\n  string email = 2;\n}\n")
+	cmd := exec.Command("git", "add", "api.proto")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	cmd = exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "add proto")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+
+	writeFile(t, filepath.Join(dir, "api.proto"), "syntax = \"proto3\";\n\nmessage User {\n  string id = 1;\n  reserved 2;\n  string name = 3;\n}\n")
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--unstaged", "--schema-changes")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	for _, want := range []string{"api.proto (proto): 1 added, 1 removed, 0 changed", "+ message User.name", "- message User.email"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "--unstaged")
+	if strings.Contains(stdout, "Schema changes") {
+		t.Errorf("schema changes should only be reported with --schema-changes:\n%s", stdout)
+	}
+}
+
+func TestE2E_MigrationWarnings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "db", "002_cleanup.sql"), "-- cleanup\nDROP TABLE legacy;\nCREATE INDEX users_email ON users (email);\n")
+	cmd := exec.Command("git", "add", "db/002_cleanup.sql")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--staged")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	for _, want := range []string{"Warnings:", "db/002_cleanup.sql:2:", "(drop-table)", "db/002_cleanup.sql:3:", "(index-without-concurrently)"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+
+	// Warnings follow the file filters.
+	stdout, _, _ = runDiffer(t, bin, dir, "--staged", "--exclude", "db/**")
+	if strings.Contains(stdout, "Warnings:") {
+		t.Errorf("excluded files should not produce warnings:\n%s", stdout)
+	}
+
+	// Unknown categories are reported with the results rather than failing.
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "--staged", "--category", "sorce", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if stderr != "" {
+		t.Errorf("expected nothing on stderr, got %q", stderr)
+	}
+	if !strings.Contains(stdout, `"rule": "unknown-category"`) {
+		t.Errorf("expected unknown-category warning in JSON:\n%s", stdout)
+	}
+}
+
+func TestE2E_InfrastructureSummary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "infra", "main.tf"), "resource \"aws_s3_bucket\" \"logs\" {\n  bucket = \"logs\"\n}\n\nresource \"aws_s3_bucket\" \"assets\" {\n  bucket = \"assets\"\n}\n")
+	writeFile(t, filepath.Join(dir, "k8s", "web.yaml"), "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 2\n")
+	cmd := exec.Command("git", "add", "infra", "k8s")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	cmd = exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "add infra")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+
+	writeFile(t, filepath.Join(dir, "infra", "main.tf"), "resource \"aws_s3_bucket\" \"logs\" {\n  bucket = \"logs-v2\"\n}\n\nresource \"aws_s3_bucket\" \"assets\" {\n  bucket = \"assets\"\n}\n\nresource \"aws_sqs_queue\" \"jobs\" {}\n")
+	writeFile(t, filepath.Join(dir, "k8s", "web.yaml"), "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 3\n")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--unstaged")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	for _, want := range []string{
+		"Infrastructure: 1 to add, 2 to change, 0 to destroy",
+		"Deployment:    1 changed",
+		"aws_s3_bucket: 1 changed",
+		"aws_sqs_queue: 1 added",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestE2E_I18nLocales(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "locales", "fr.json"), "{\n  \"hello\": \"bonjour\",\n  \"bye\": \"au revoir\"\n}\n")
+	writeFile(t, filepath.Join(dir, "po", "de.po"), "msgid \"hello\"\nmsgstr \"hallo\"\n")
+	cmd := exec.Command("git", "add", "locales", "po")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--staged", "--category", "i18n", "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	for _, want := range []string{"Localization", "Locales: fr +4 -0, de +2 -0"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestE2E_NestedConfig(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "packages", "web", ".differ.yml"), "exclude:\n  - \"dist/**\"\ncategories:\n  tests:\n    patterns:\n      - \"e2e/**\"\n")
+	writeFile(t, filepath.Join(dir, "packages", "web", "e2e", "login.ts"), "export const a = 1;\n")
+	writeFile(t, filepath.Join(dir, "packages", "web", "dist", "app.js"), "var a = 1;\n")
+	writeFile(t, filepath.Join(dir, "dist", "app.js"), "var a = 1;\n")
+	cmd := exec.Command("git", "add", "packages", "dist")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--staged", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result struct {
+		ByFile []struct {
+			Path     string `json:"path"`
+			Category string `json:"category"`
+		} `json:"by_file"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	got := make(map[string]string)
+	for _, f := range result.ByFile {
+		got[f.Path] = f.Category
+	}
+	want := map[string]string{
+		"packages/web/e2e/login.ts": "tests",
+		"packages/web/.differ.yml":  "source",
+		"dist/app.js":               "generated",
+	}
+	if len(got) != len(want) {
+		t.Errorf("by_file = %v, want %v", got, want)
+	}
+	for path, cat := range want {
+		if got[path] != cat {
+			t.Errorf("%s: category %q, want %q", path, got[path], cat)
+		}
+	}
+}
+
+func TestE2E_AutoModeReference(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, headRef := setupTestRepo(t)
+
+	cmd := exec.Command("git", "checkout", "-qb", "feature")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git checkout failed: %v\n%s", err, out)
+	}
+	writeFile(t, filepath.Join(dir, "lib.go"), "package main\n\nfunc lib() {}\n")
+	cmd = exec.Command("git", "add", "lib.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	cmd = exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", "add lib")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	// Without merges on main, auto mode compares against its last commit.
+	for _, want := range []string{
+		"Source:",
+		"last commit",
+		"Last commit: " + headRef[:7] + " on main",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "--format", "json")
+	var result struct {
+		Meta struct {
+			Reference *struct {
+				Base   string `json:"base"`
+				Commit string `json:"commit"`
+				Kind   string `json:"kind"`
+				Churn  int    `json:"churn"`
+			} `json:"reference"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	if ref := result.Meta.Reference; ref == nil || ref.Base != "main" || ref.Commit != headRef || ref.Kind != "commit" || ref.Churn == 0 {
+		t.Errorf("meta.reference = %+v, want the tip of main", ref)
+	}
+
+	// Once main has a merge, it is the reference, even with plain commits
+	// after it.
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("checkout", "-q", "-b", "side", "main")
+	writeFile(t, filepath.Join(dir, "side.go"), "package main\n\nfunc side() {}\n")
+	git("add", "side.go")
+	git("commit", "-qm", "add side")
+	git("checkout", "-q", "main")
+	git("merge", "-q", "--no-ff", "-m", "merge side", "side")
+	merge := git("rev-parse", "HEAD")
+	writeFile(t, filepath.Join(dir, "README.md"), "# Test\n\nAfter the merge.\n")
+	git("commit", "-qam", "plain commit")
+	git("checkout", "-q", "feature")
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if want := "Last merge: " + merge[:7] + " into main, 2 lines in 1 file"; !strings.Contains(stdout, want) {
+		t.Errorf("expected %q in output:\n%s", want, stdout)
+	}
+
+	// Explicit refs skip the reference.
+	stdout, _, _ = runDiffer(t, bin, dir, "main...feature")
+	if strings.Contains(stdout, "last merge") {
+		t.Errorf("unexpected reference with explicit refs:\n%s", stdout)
+	}
+}
+
+func TestE2E_Shebang(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "bin", "deploy"), "#!/usr/bin/env bash\nset -e\necho deploying\n")
+	cmd := exec.Command("git", "add", "bin")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+
+	fileOf := func(stdout, path string) (category, language string) {
+		t.Helper()
+		var result struct {
+			ByFile []struct {
+				Path     string `json:"path"`
+				Category string `json:"category"`
+				Language string `json:"language"`
+			} `json:"by_file"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+		}
+		for _, f := range result.ByFile {
+			if f.Path == path {
+				return f.Category, f.Language
+			}
+		}
+		t.Fatalf("%s missing from output:\n%s", path, stdout)
+		return "", ""
+	}
+
+	stdout, _, _ := runDiffer(t, bin, dir, "--staged", "--format", "json")
+	if cat, _ := fileOf(stdout, "bin/deploy"); cat != "other" {
+		t.Errorf("without --shebang: category %q, want other", cat)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--staged", "--format", "json", "--shebang")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if cat, lang := fileOf(stdout, "bin/deploy"); cat != "source" || lang != "Shell" {
+		t.Errorf("staged: got %q/%q, want source/Shell", cat, lang)
+	}
+
+	// Unstaged changes are read from the worktree.
+	writeFile(t, filepath.Join(dir, "bin", "deploy"), "#!/usr/bin/env python3\nprint('deploying')\n")
+	stdout, _, _ = runDiffer(t, bin, dir, "--unstaged", "--format", "json", "--shebang")
+	if cat, lang := fileOf(stdout, "bin/deploy"); cat != "source" || lang != "Python" {
+		t.Errorf("unstaged: got %q/%q, want source/Python", cat, lang)
+	}
+}
+
+func TestE2E_SymlinkTypeChange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "scripts", "deploy"), "#!/usr/bin/env python3\nprint('deploying')\n")
+	writeFile(t, filepath.Join(dir, "bin", "tool"), "placeholder\n")
+	cmd := exec.Command("git", "add", "scripts", "bin")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+
+	// Replacing a file with a symlink is diffed as a deletion and an
+	// addition of the same path.
+	tool := filepath.Join(dir, "bin", "tool")
+	if err := os.Remove(tool); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../scripts/deploy", tool); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--unstaged", "--format", "json", "--shebang")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result struct {
+		Total struct {
+			Files int `json:"files"`
+		} `json:"total"`
+		ByFile []struct {
+			Path     string `json:"path"`
+			Category string `json:"category"`
+			Added    int    `json:"added"`
+			Deleted  int    `json:"deleted"`
+		} `json:"by_file"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+
+	if len(result.ByFile) != 1 || result.Total.Files != 1 {
+		t.Fatalf("expected bin/tool listed once, got %d entries, %d files\n%s", len(result.ByFile), result.Total.Files, stdout)
+	}
+	// The symlink is not read through to its target's shebang.
+	if f := result.ByFile[0]; f.Path != "bin/tool" || f.Category != "other" || f.Added != 1 || f.Deleted != 1 {
+		t.Errorf("got %+v, want bin/tool as other with +1 -1", f)
+	}
+}
+
+func TestE2E_ModeAndSymlinkChanges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com", "-c", "core.fileMode=true"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	writeFile(t, filepath.Join(dir, "run.sh"), "echo hi\n")
+	link := filepath.Join(dir, "current")
+	if err := os.Symlink("main.go", link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	git("add", "-A")
+	git("commit", "-qm", "script and link")
+
+	// Only the executable bit and the link target change.
+	git("update-index", "--chmod=+x", "run.sh")
+	if err := os.Remove(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("README.md", link); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "current")
+	git("commit", "-qm", "chmod and retarget")
+
+	type file struct {
+		Path    string `json:"path"`
+		Churn   int    `json:"churn"`
+		OldMode string `json:"old_mode"`
+		NewMode string `json:"new_mode"`
+		Status  string `json:"status"`
+	}
+	want := []file{
+		{Path: "current", Churn: 2, Status: "symlink"},
+		{Path: "run.sh", OldMode: "100644", NewMode: "100755", Status: "mode"},
+	}
+	for _, args := range [][]string{nil, {"--fast"}} {
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append([]string{"HEAD~1..HEAD", "--format", "json", "--sort", "path"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d\nstderr: %s", args, exitCode, stderr)
+		}
+		var result struct {
+			ByFile []file `json:"by_file"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+		}
+		if !reflect.DeepEqual(result.ByFile, want) {
+			t.Errorf("%v: by_file = %+v, want %+v", args, result.ByFile, want)
+		}
+	}
+
+	stdout, _, _ := runDiffer(t, bin, dir, "HEAD~1..HEAD", "-l", "--color", "never")
+	for _, line := range []string{"Source:", "+0 -0 run.sh (mode 100644 -> 100755)", "+1 -1 current (symlink)"} {
+		if !strings.Contains(stdout, line) {
+			t.Errorf("expected %q in output:\n%s", line, stdout)
+		}
+	}
+}
+
+func TestE2E_Submodules(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+	lib := t.TempDir()
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com", "-c", "protocol.file.allow=always"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	git(lib, "init", "-q")
+	writeFile(t, filepath.Join(lib, "lib.go"), "package lib\n")
+	git(lib, "add", "-A")
+	git(lib, "commit", "-qm", "lib")
+	git(dir, "submodule", "--quiet", "add", lib, "lib")
+	git(dir, "commit", "-qm", "add lib")
+	oldCommit := git(dir, "rev-parse", "HEAD:lib")
+
+	// The submodule gains three source lines and a doc.
+	sub := filepath.Join(dir, "lib")
+	writeFile(t, filepath.Join(sub, "lib.go"), "package lib\n\nfunc A() {}\nfunc B() {}\nfunc C() {}\n")
+	writeFile(t, filepath.Join(sub, "README.md"), "# lib\n")
+	git(sub, "add", "-A")
+	git(sub, "commit", "-qm", "grow")
+	git(dir, "add", "lib")
+	git(dir, "commit", "-qm", "bump lib")
+	newCommit := git(dir, "rev-parse", "HEAD:lib")
+
+	type total struct {
+		Added int `json:"added"`
+		Files int `json:"files"`
+	}
+	type submodule struct {
+		Path       string           `json:"path"`
+		OldCommit  string           `json:"old_commit"`
+		NewCommit  string           `json:"new_commit"`
+		Total      *total           `json:"total"`
+		ByCategory map[string]total `json:"by_category"`
+	}
+	type report struct {
+		Meta struct {
+			Submodules []submodule `json:"submodules"`
+			Warnings   []struct {
+				Rule string `json:"rule"`
+			} `json:"warnings"`
+		} `json:"meta"`
+		Total  total `json:"total"`
+		ByFile []struct {
+			Path   string `json:"path"`
+			Churn  int    `json:"churn"`
+			Status string `json:"status"`
+		} `json:"by_file"`
+	}
+	analyze := func(args ...string) report {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append([]string{"HEAD~1..HEAD", "--format", "json"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d\nstderr: %s", args, exitCode, stderr)
+		}
+		var result report
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+		}
+		return result
+	}
+
+	// By default only the pointer is reported, and it is not churn.
+	for _, args := range [][]string{nil, {"--fast"}} {
+		result := analyze(args...)
+		if result.Total.Added != 0 || len(result.ByFile) != 1 || result.ByFile[0].Status != "submodule" || result.ByFile[0].Churn != 0 {
+			t.Errorf("%v: total = %+v, by_file = %+v, want one submodule without churn", args, result.Total, result.ByFile)
+		}
+		want := []submodule{{Path: "lib", OldCommit: oldCommit, NewCommit: newCommit}}
+		if !reflect.DeepEqual(result.Meta.Submodules, want) {
+			t.Errorf("%v: submodules = %+v, want %+v", args, result.Meta.Submodules, want)
+		}
+	}
+
+	// Recursing analyzes the submodule's own range.
+	for _, args := range [][]string{{"--submodules", "recurse"}, {"--submodules", "recurse", "--fast"}} {
+		result := analyze(args...)
+		want := []submodule{{
+			Path:       "lib",
+			OldCommit:  oldCommit,
+			NewCommit:  newCommit,
+			Total:      &total{Added: 5, Files: 2},
+			ByCategory: map[string]total{"source": {Added: 4, Files: 1}, "docs": {Added: 1, Files: 1}},
+		}}
+		if args[len(args)-1] != "--fast" {
+			// Empty lines are not counted by default.
+			want[0].Total.Added, want[0].ByCategory["source"] = 4, total{Added: 3, Files: 1}
+		}
+		if !reflect.DeepEqual(result.Meta.Submodules, want) {
+			t.Errorf("%v: submodules = %+v, want %+v", args, result.Meta.Submodules, want)
+		}
+	}
+
+	stdout, _, _ := runDiffer(t, bin, dir, "HEAD~1..HEAD", "-l", "--color", "never", "--submodules", "recurse")
+	for _, line := range []string{"Submodules:", "lib: " + oldCommit[:7] + ".." + newCommit[:7] + " +4 -0 (4) [2 files]", "+0 -0 lib (submodule)"} {
+		if !strings.Contains(stdout, line) {
+			t.Errorf("expected %q in output:\n%s", line, stdout)
+		}
+	}
+
+	// A submodule that is not checked out is listed with a note.
+	git(dir, "submodule", "--quiet", "deinit", "-f", "lib")
+	result := analyze("--submodules", "recurse")
+	if len(result.Meta.Submodules) != 1 || result.Meta.Submodules[0].Total != nil {
+		t.Errorf("submodules = %+v, want lib without churn", result.Meta.Submodules)
+	}
+	if len(result.Meta.Warnings) != 1 || result.Meta.Warnings[0].Rule != "submodule" {
+		t.Errorf("warnings = %+v, want a submodule note", result.Meta.Warnings)
+	}
+
+	_, stderr, exitCode := runDiffer(t, bin, dir, "--submodules", "all")
+	if exitCode != 2 || !strings.Contains(stderr, "--submodules must be one of pointer, recurse") {
+		t.Errorf("exit code %d, stderr %q; want exit 2 and the accepted values", exitCode, stderr)
+	}
+}
+
+func TestE2E_Multi(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	api, apiBase, apiHead := setupTestRepo(t)
+	web, webBase, _ := setupTestRepo(t)
+	work := t.TempDir()
+
+	type total struct {
+		Churn int `json:"churn"`
+		Files int `json:"files"`
+	}
+	type result struct {
+		Repos []struct {
+			Name  string `json:"name"`
+			Total total  `json:"total"`
+		} `json:"repos"`
+		Total      total            `json:"total"`
+		ByCategory map[string]total `json:"by_category"`
+	}
+	multi := func(args ...string) result {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, work, append([]string{"multi", "--format", "json"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d\nstderr: %s", args, exitCode, stderr)
+		}
+		var r result
+		if err := json.Unmarshal([]byte(stdout), &r); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+		}
+		return r
+	}
+
+	// Each repository is analyzed on its own, and the totals add up.
+	single, _, _ := runDiffer(t, bin, api, apiBase+".."+apiHead, "--format", "json")
+	var one struct {
+		Total total `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(single), &one); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, single)
+	}
+	got := multi("HEAD~1..HEAD", "--repo", api, "--repo", web)
+	if len(got.Repos) != 2 || got.Repos[0].Total != one.Total || got.Repos[1].Total != one.Total {
+		t.Errorf("repos = %+v, want both with %+v", got.Repos, one.Total)
+	}
+	if got.Total.Churn != 2*one.Total.Churn || got.Total.Files != 2*one.Total.Files {
+		t.Errorf("total = %+v, want twice %+v", got.Total, one.Total)
+	}
+	if got.ByCategory["source"].Files != 2 {
+		t.Errorf("by_category = %+v, want source in both repositories", got.ByCategory)
+	}
+
+	// A workspace names repositories and ranges relative to itself.
+	rel, err := filepath.Rel(work, web)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(work, "fleet.yml"), "repos:\n  - path: "+api+"\n    name: api\n  - path: "+rel+"\n    name: web\n    range: "+webBase+"..HEAD\n    pathspecs: [README.md]\n")
+	got = multi("HEAD~1..HEAD", "--workspace", "fleet.yml")
+	if len(got.Repos) != 2 || got.Repos[0].Name != "api" || got.Repos[1].Name != "web" || got.Repos[1].Total.Files != 1 {
+		t.Errorf("repos = %+v, want api and web with only README.md", got.Repos)
+	}
+
+	stdout, _, _ := runDiffer(t, bin, work, "multi", "--workspace", "fleet.yml", "--color", "never")
+	for _, want := range []string{"== api (", "== web (", "== Total (2 repositories) =="} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+
+	writeFile(t, filepath.Join(work, "bad.yml"), "repos:\n  - dir: api\n")
+	for _, args := range [][]string{{"multi"}, {"multi", "--workspace", "bad.yml"}} {
+		if _, stderr, exitCode := runDiffer(t, bin, work, args...); exitCode != 2 {
+			t.Errorf("%v: exit code %d, want 2\nstderr: %s", args, exitCode, stderr)
+		}
+	}
+	if _, stderr, exitCode := runDiffer(t, bin, work, "multi", "--repo", work); exitCode != 1 || !strings.Contains(stderr, "not inside a git working tree") {
+		t.Errorf("exit code %d, stderr %q; want exit 1 for a directory that is not a repository", exitCode, stderr)
+	}
+}
+
+func TestE2E_CompareRefs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	// Two alternative branches: one all source, one with docs.
+	git("checkout", "-qb", "feature-a")
+	writeFile(t, filepath.Join(dir, "a.go"), "package main\n\nfunc a() {}\nfunc a2() {}\n")
+	git("add", "-A")
+	git("commit", "-qm", "a")
+	git("checkout", "-q", "main")
+	git("checkout", "-qb", "feature-b")
+	writeFile(t, filepath.Join(dir, "b.go"), "package main\n")
+	writeFile(t, filepath.Join(dir, "docs", "b.md"), "# B\n")
+	git("add", "-A")
+	git("commit", "-qm", "b")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "compare-refs", "main...feature-a", "main...feature-b", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result struct {
+		Ranges []struct {
+			Range string `json:"range"`
+			Total struct {
+				Churn int `json:"churn"`
+				Files int `json:"files"`
+			} `json:"total"`
+			ByCategory map[string]struct {
+				Share float64 `json:"share"`
+			} `json:"by_category"`
+		} `json:"ranges"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	if len(result.Ranges) != 2 || result.Ranges[0].Range != "main...feature-a" || result.Ranges[0].Total.Churn != 3 || result.Ranges[1].Total.Files != 2 {
+		t.Errorf("ranges = %+v", result.Ranges)
+	}
+	if got := result.Ranges[1].ByCategory["docs"].Share; got != 0.5 {
+		t.Errorf("feature-b docs share = %v, want 0.5", got)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "compare-refs", "main...feature-a", "main...feature-b")
+	for _, want := range []string{"main...feature-a  main...feature-b", "Documentation", "0 (0%)", "1 (50%)", "Files"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in output:\n%s", want, stdout)
+		}
+	}
+
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "compare-refs", "main...feature-a"); exitCode != 1 || !strings.Contains(stderr, "at least two ranges") {
+		t.Errorf("exit code %d, stderr %q; want exit 1 for a single range", exitCode, stderr)
+	}
+}
+
+func TestE2E_SinceUntil(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir := t.TempDir()
+	git := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git(nil, "init", "-q", "-b", "main")
+	// One commit 60, 20, and 1 days ago, each adding a file.
+	for _, age := range []int{60, 20, 1} {
+		name := fmt.Sprintf("day%d.go", age)
+		writeFile(t, filepath.Join(dir, name), "package main\n")
+		date := time.Now().AddDate(0, 0, -age).Format(time.RFC3339)
+		git(nil, "add", name)
+		git([]string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}, "commit", "-qm", name)
+	}
+
+	paths := func(args ...string) []string {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append([]string{"--format", "json", "--sort", "path"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d\nstderr: %s", args, exitCode, stderr)
+		}
+		var result struct {
+			ByFile []struct {
+				Path string `json:"path"`
+			} `json:"by_file"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+		}
+		var got []string
+		for _, f := range result.ByFile {
+			got = append(got, f.Path)
+		}
+		return got
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--since", "2w"}, []string{"day1.go"}},
+		{[]string{"--since", "30d", "--until", "1w"}, []string{"day20.go"}},
+		{[]string{"--since", "1y"}, []string{"day1.go", "day20.go", "day60.go"}},
+		{[]string{"--until", "30d"}, []string{"day60.go"}},
+		{[]string{"--since", "2w", "--head", "HEAD~1"}, nil},
+	}
+	for _, tt := range tests {
+		if got := paths(tt.args...); !slices.Equal(got, tt.want) {
+			t.Errorf("%v: files = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "--since", "2w", "HEAD~2..HEAD"); exitCode != 1 || !strings.Contains(stderr, "cannot be combined") {
+		t.Errorf("exit code %d, stderr %q; want exit 1 with a rev-range", exitCode, stderr)
+	}
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "--until", "1y"); exitCode != 1 || !strings.Contains(stderr, "no commits") {
+		t.Errorf("exit code %d, stderr %q; want exit 1 before the first commit", exitCode, stderr)
+	}
+}
+
+func TestE2E_MergeBase(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@test.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	// main and feature diverge, each adding a file of its own.
+	git("checkout", "-q", "-b", "feature")
+	writeFile(t, filepath.Join(dir, "feature.go"), "package main\n")
+	git("add", "feature.go")
+	git("commit", "-qm", "feature")
+	git("checkout", "-q", "main")
+	writeFile(t, filepath.Join(dir, "NOTES.md"), "# Notes\n\nRelease notes.\n")
+	git("add", "NOTES.md")
+	git("commit", "-qm", "mainline")
+
+	type result struct {
+		Meta struct {
+			MergeBase *bool `json:"merge_base"`
+		} `json:"meta"`
+		ByFile []struct {
+			Path string `json:"path"`
+		} `json:"by_file"`
+	}
+	analyze := func(args ...string) result {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append([]string{"--format", "json", "--sort", "path", "--base", "main", "--head", "feature"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d\nstderr: %s", args, exitCode, stderr)
+		}
+		var r result
+		if err := json.Unmarshal([]byte(stdout), &r); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+		}
+		return r
+	}
+	paths := func(r result) []string {
+		var got []string
+		for _, f := range r.ByFile {
+			got = append(got, f.Path)
+		}
+		return got
+	}
+
+	tests := []struct {
+		args          []string
+		wantMergeBase bool
+		wantPaths     []string
+	}{
+		{nil, true, []string{"feature.go"}},
+		{[]string{"--merge-base"}, true, []string{"feature.go"}},
+		// Compared directly, main's own change shows up as a deletion.
+		{[]string{"--merge-base=false"}, false, []string{"NOTES.md", "feature.go"}},
+	}
+	for _, tt := range tests {
+		r := analyze(tt.args...)
+		if r.Meta.MergeBase == nil || *r.Meta.MergeBase != tt.wantMergeBase {
+			t.Errorf("%v: meta.merge_base = %v, want %v", tt.args, r.Meta.MergeBase, tt.wantMergeBase)
+		}
+		if got := paths(r); !slices.Equal(got, tt.wantPaths) {
+			t.Errorf("%v: files = %v, want %v", tt.args, got, tt.wantPaths)
+		}
+	}
+
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "--merge-base=false", "main..feature"); exitCode != 1 || !strings.Contains(stderr, "--base and --head") {
+		t.Errorf("exit code %d, stderr %q; want exit 1 without --base and --head", exitCode, stderr)
+	}
+}
+
+func TestE2E_BenchGen(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir := t.TempDir()
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "bench", "gen", "--files", "20", "--runs", "1")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	for _, want := range []string{"Synthetic diff: 20 files, 80 hunks", "parse", "moves", "summarize"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output missing %q:\n%s", want, stdout)
+		}
+	}
+
+	out := filepath.Join(dir, "synthetic.diff")
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "bench", "gen", "--files", "3", "--out", out); exitCode != 0 {
+		t.Fatalf("--out: expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "diff --git "); got != 3 {
+		t.Errorf("--out wrote %d files, want 3", got)
+	}
+
+	if _, _, exitCode := runDiffer(t, bin, dir, "bench", "gen", "--distribution", "bimodal"); exitCode != 2 {
+		t.Errorf("bad distribution: exit code %d, want 2", exitCode)
+	}
+}
+
+func TestE2E_CICategory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, ".github", "workflows", "ci.yml"), "on: push\njobs: {}\n")
+	writeFile(t, filepath.Join(dir, "Dockerfile"), "FROM golang:1.25\nRUN go build ./...\n")
+	cmd := exec.Command("git", "add", "-A")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--staged", "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if !regexp.MustCompile(`CI & Build:\s+\+4 -0 \(4\) \[2 files\]`).MatchString(stdout) {
+		t.Errorf("expected CI & Build row, got:\n%s", stdout)
+	}
+}
+
+func TestE2E_Setup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+	writeFile(t, filepath.Join(dir, "api", "gen", "client.go"), "package gen\n")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "setup", "--dry-run")
+	if exitCode != 0 {
+		t.Fatalf("setup --dry-run exited %d: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "Languages: Go (2)") || !strings.Contains(stdout, "Default branch: main") {
+		t.Errorf("expected repository report, got:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, `- "api/gen/" # 1 file`) {
+		t.Errorf("expected api/gen/ suggestion, got:\n%s", stdout)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".differ.yml")); err == nil {
+		t.Fatal("--dry-run wrote .differ.yml")
+	}
+
+	_, stderr, exitCode = runDiffer(t, bin, dir, "setup", "--yes", "--hook")
+	if exitCode != 0 {
+		t.Fatalf("setup --yes exited %d: %s", exitCode, stderr)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".differ.yml"))
+	if err != nil || !strings.Contains(string(data), "api/gen/") {
+		t.Errorf("expected .differ.yml with api/gen/, got %q (%v)", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, ".git", "hooks", "pre-push")); err != nil || info.Mode()&0o111 == 0 {
+		t.Errorf("expected an executable pre-push hook (%v)", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".github", "workflows", "differ.yml")); err == nil {
+		t.Error("workflow installed without --ci")
+	}
+}
+
+func TestE2E_Show(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "show", "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("show exited %d: %s", exitCode, stderr)
+	}
+	if !strings.HasPrefix(stdout, "commit "+headRef+" add features\n") {
+		t.Errorf("expected commit header for HEAD, got:\n%s", stdout)
+	}
+	rangeOut, _, _ := runDiffer(t, bin, dir, baseRef+".."+headRef, "--no-color")
+	if !strings.HasSuffix(stdout, rangeOut) {
+		t.Errorf("show HEAD differs from the explicit range:\n%s\nwant:\n%s", stdout, rangeOut)
+	}
+
+	// Commits piped from git log, one JSON document per commit.
+	cmd := exec.Command(bin, "show", "--stdin", "--format", "json", "--no-cache")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(headRef + " add features\n" + baseRef + " initial\n")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("show --stdin failed: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	var heads []string
+	for dec.More() {
+		var doc struct {
+			Meta struct {
+				Head string `json:"head"`
+			} `json:"meta"`
+		}
+		if err := dec.Decode(&doc); err != nil {
+			t.Fatalf("invalid JSON stream: %v\n%s", err, out)
+		}
+		heads = append(heads, doc.Meta.Head)
+	}
+	if len(heads) != 2 || heads[0] != headRef || heads[1] != baseRef {
+		t.Errorf("meta.head values = %v, want [%s %s]", heads, headRef, baseRef)
+	}
+
+	_, stderr, exitCode = runDiffer(t, bin, dir, "show", "no-such-rev")
+	if exitCode != 1 || !strings.Contains(stderr, "no-such-rev is not a commit") {
+		t.Errorf("expected exit 1 for an unknown commit, got %d: %s", exitCode, stderr)
+	}
+}
+
+func TestE2E_AuthorsGroupByDomain(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, _ := setupTestRepo(t)
+
+	writeFile(t, filepath.Join(dir, "lib.go"), "package main\n\nfunc lib() {}\n")
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", "add lib"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Ann", "GIT_AUTHOR_EMAIL=ann@eng.acme.io",
+			"GIT_COMMITTER_NAME=Ann", "GIT_COMMITTER_EMAIL=ann@eng.acme.io",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "organizations:\n  acme.io: Acme\n")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "authors", baseRef, "--group-by", "domain", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("authors exited %d: %s", exitCode, stderr)
+	}
+	var result struct {
+		Groups []struct {
+			Name    string `json:"name"`
+			Commits int    `json:"commits"`
+			Churn   int    `json:"churn"`
+		} `json:"groups"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	names := map[string]int{}
+	for _, g := range result.Groups {
+		names[g.Name] = g.Commits
+	}
+	if names["Acme"] != 1 || names["test.com"] != 1 || len(names) != 2 {
+		t.Errorf("groups = %+v, want Acme and test.com with one commit each", result.Groups)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "authors", baseRef)
+	if !strings.Contains(stdout, "Ann <ann@eng.acme.io>") || !strings.Contains(stdout, "Test <test@test.com>") {
+		t.Errorf("expected per-author lines, got:\n%s", stdout)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "authors", "--group-by", "team")
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2 for an invalid --group-by, got %d", exitCode)
+	}
+}
+
+func TestE2E_AuthorsMatrix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, _ := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "authors", baseRef, "--matrix")
+	if exitCode != 0 {
+		t.Fatalf("authors --matrix exited %d: %s", exitCode, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], "Documentation") || !strings.Contains(lines[0], "Tests") ||
+		!strings.HasPrefix(lines[1], "Test <test@test.com>") || !strings.HasPrefix(lines[2], "Total") {
+		t.Errorf("expected a header, one author row, and totals, got:\n%s", stdout)
+	}
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "authors", baseRef, "--matrix", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("authors --matrix --format json exited %d: %s", exitCode, stderr)
+	}
+	var result struct {
+		Categories []string `json:"categories"`
+		Groups     []struct {
+			Churn      int            `json:"churn"`
+			ByCategory map[string]int `json:"by_category"`
+		} `json:"groups"`
+		Totals map[string]int `json:"totals"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(result.Groups) != 1 || result.Groups[0].ByCategory["docs"] == 0 || result.Groups[0].ByCategory["tests"] == 0 {
+		t.Fatalf("expected docs and tests churn for the one author, got %s", stdout)
+	}
+	sum := 0
+	for _, cat := range result.Categories {
+		sum += result.Totals[cat]
+	}
+	if sum != result.Groups[0].Churn {
+		t.Errorf("category totals sum to %d, want the author's churn %d", sum, result.Groups[0].Churn)
+	}
+}
+
+func TestE2E_AuthorsFirstParent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, _ := setupTestRepo(t)
+
+	git := func(author string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME="+author, "GIT_AUTHOR_EMAIL="+strings.ToLower(author)+"@test.com",
+			"GIT_COMMITTER_NAME="+author, "GIT_COMMITTER_EMAIL="+strings.ToLower(author)+"@test.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	// Ann writes lib.go in two commits on a branch; Test merges it.
+	git("Ann", "checkout", "-q", "-b", "feature")
+	writeFile(t, filepath.Join(dir, "lib.go"), "package main\n\nfunc lib() {}\n")
+	git("Ann", "add", "-A")
+	git("Ann", "commit", "-q", "-m", "add lib")
+	writeFile(t, filepath.Join(dir, "lib.go"), "package main\n\nfunc lib() { println() }\n")
+	git("Ann", "commit", "-q", "-am", "fix lib")
+	git("Test", "checkout", "-q", "-")
+	git("Test", "merge", "-q", "--no-ff", "feature", "-m", "Merge branch 'feature'")
+
+	churn := func(args ...string) map[string]int {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append([]string{"authors", baseRef, "--format", "json"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("authors %v exited %d: %s", args, exitCode, stderr)
+		}
+		var result struct {
+			Groups []struct {
+				Name  string `json:"name"`
+				Churn int    `json:"churn"`
+			} `json:"groups"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+		}
+		byName := map[string]int{}
+		for _, g := range result.Groups {
+			byName[g.Name] = g.Churn
+		}
+		return byName
+	}
+
+	all := churn()
+	if all["Ann"] != 4 {
+		t.Errorf("without --first-parent, Ann's churn = %d, want 4 over both commits", all["Ann"])
+	}
+	mainline := churn("--first-parent")
+	if _, ok := mainline["Ann"]; ok || mainline["Test"] != all["Test"]+2 {
+		t.Errorf("with --first-parent, churn = %v, want the merge's 2 lines credited to Test (%d before)", mainline, all["Test"])
+	}
+}
+
+func TestE2E_IgnoreRevsFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, _ := setupTestRepo(t)
+
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Ann", "GIT_AUTHOR_EMAIL=ann@test.com",
+			"GIT_COMMITTER_NAME=Ann", "GIT_COMMITTER_EMAIL=ann@test.com",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nimport \"fmt\"\n\nfunc main() {\n    fmt.Println(\"hello\")\n}\n")
+	git("commit", "-q", "-am", "style: reindent")
+	reformat := git("rev-parse", "HEAD")
+	writeFile(t, filepath.Join(dir, ".git-blame-ignore-revs"), "# Reindent\n"+reformat+"\n")
+
+	stdout, _, _ := runDiffer(t, bin, dir, "authors", baseRef)
+	if !strings.Contains(stdout, "Ann <ann@test.com>") {
+		t.Fatalf("expected the reformat to count without an ignore file, got:\n%s", stdout)
+	}
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "authors", baseRef, "--ignore-revs-file", ".git-blame-ignore-revs")
+	if exitCode != 0 {
+		t.Fatalf("authors --ignore-revs-file exited %d: %s", exitCode, stderr)
+	}
+	if strings.Contains(stdout, "Ann") || !strings.Contains(stdout, "Test <test@test.com>") {
+		t.Errorf("expected only Test's commits, got:\n%s", stdout)
+	}
+
+	writeFile(t, filepath.Join(dir, "bad-revs"), "abc1234\n")
+	_, stderr, exitCode = runDiffer(t, bin, dir, "leaderboard", "--ignore-revs-file", "bad-revs")
+	if exitCode != 2 || !strings.Contains(stderr, "invalid object name") {
+		t.Errorf("expected exit 2 for an abbreviated SHA, got %d: %s", exitCode, stderr)
+	}
+}
+func TestE2E_Merges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, headRef := setupTestRepo(t)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	git("checkout", "-q", "-b", "feature")
+	writeFile(t, filepath.Join(dir, "feature.go"), "package main\n\nfunc feature() {}\n")
+	writeFile(t, filepath.Join(dir, "docs", "feature.md"), "# Feature\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "add feature")
+	git("checkout", "-q", "-")
+	writeFile(t, filepath.Join(dir, "direct.go"), "package main\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "direct commit")
+	git("merge", "-q", "--no-ff", "feature", "-m", "Merge pull request #42 from octo/feature")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "merges", headRef, "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("merges exited %d: %s", exitCode, stderr)
+	}
+	var result struct {
+		Merges []struct {
+			Subject    string         `json:"subject"`
+			PR         int            `json:"pr"`
+			Branch     string         `json:"branch"`
+			Head       string         `json:"head"`
+			Files      int            `json:"files"`
+			ByCategory map[string]int `json:"by_category"`
+		} `json:"merges"`
+		Totals struct {
+			Merges int `json:"merges"`
+			Churn  int `json:"churn"`
+		} `json:"totals"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if len(result.Merges) != 1 {
+		t.Fatalf("expected one merge, got %s", stdout)
+	}
+	m := result.Merges[0]
+	if m.PR != 42 || m.Branch != "octo/feature" || m.Head == "" {
+		t.Errorf("merge = %+v, want PR 42 from octo/feature with its head", m)
+	}
+	// The direct commit on the first-parent chain is not part of the merge.
+	if m.Files != 2 || m.ByCategory["source"] != 2 || m.ByCategory["docs"] != 1 || result.Totals.Churn != 3 {
+		t.Errorf("expected feature.go and docs/feature.md only, got %s", stdout)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "merges", headRef)
+	if !strings.Contains(stdout, "#42 octo/feature") || !strings.Contains(stdout, "Total (1 merge)") {
+		t.Errorf("expected the merge and a total row, got:\n%s", stdout)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "merges", "--sort", "size")
+	if exitCode != 2 {
+		t.Errorf("expected exit 2 for an unknown --sort, got %d", exitCode)
+	}
+}
+func TestE2E_Leaderboard(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	commitAs := func(name, email, file, content string) {
+		t.Helper()
+		writeFile(t, filepath.Join(dir, file), content)
+		for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", "update " + file}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(),
+				"GIT_AUTHOR_NAME="+name, "GIT_AUTHOR_EMAIL="+email,
+				"GIT_COMMITTER_NAME="+name, "GIT_COMMITTER_EMAIL="+email,
+			)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, out)
+			}
+		}
+	}
+	commitAs("Ann", "ann@example.com", "lib.go", "package main\n\nfunc lib() {}\n")
+	commitAs("ann", "ann@old.example.com", "lib_test.go", "package main\n")
+	commitAs("dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", "go.sum", "a\nb\nc\nd\ne\nf\ng\nh\n")
+	commitAs("Ann", "ann@example.com", ".mailmap", "Ann <ann@example.com> <ann@old.example.com>\n")
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "leaderboard", "--since", "1y", "--format", "markdown")
+	if exitCode != 0 {
+		t.Fatalf("leaderboard exited %d: %s", exitCode, stderr)
+	}
+	if !strings.HasPrefix(stdout, "## Top contributors since 1y\n") {
+		t.Errorf("expected a markdown heading, got:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "| Ann | 3 |") {
+		t.Errorf("expected Ann's identities merged via .mailmap into one row, got:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "dependabot") {
+		t.Errorf("expected bot commits to be skipped, got:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "| Tests |") {
+		t.Errorf("expected a per-category column, got:\n%s", stdout)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "leaderboard", "--include-bots")
+	if !strings.Contains(stdout, "dependabot[bot]") {
+		t.Errorf("expected bot commits with --include-bots, got:\n%s", stdout)
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "leaderboard", "--limit", "1")
+	if strings.Count(stdout, "\n") != 1 {
+		t.Errorf("expected only the top contributor with --limit 1, got:\n%s", stdout)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "leaderboard", "--format", "html")
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2 for an invalid --format, got %d", exitCode)
+	}
+}
+
+func TestE2E_BadgeRelease(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	for _, args := range [][]string{{"tag", "v1.0.0", baseRef}, {"tag", "v1.1.0", headRef}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	db := filepath.Join(t.TempDir(), "churn.db")
+
+	stdout, _, _ := runDiffer(t, bin, dir, "v1.0.0..v1.1.0", "--format", "json")
+	var summary struct {
+		Total struct {
+			Churn int `json:"churn"`
+		} `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &summary); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+
+	for i := 0; i < 2; i++ {
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, "badge", "--release", "v1.1.0", "--db", db, "--format", "json")
+		if exitCode != 0 {
+			t.Fatalf("badge run %d: exit code %d\nstderr: %s", i, exitCode, stderr)
+		}
+		var endpoint struct {
+			Label   string `json:"label"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &endpoint); err != nil {
+			t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+		}
+		want := fmt.Sprintf("XS · %d lines", summary.Total.Churn)
+		if endpoint.Label != "release size" || endpoint.Message != want {
+			t.Errorf("run %d: endpoint = %+v, want message %q", i, endpoint, want)
+		}
+	}
+
+	data, err := os.ReadFile(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"release":"v1.1.0"`) {
+		t.Errorf("expected the release recorded once, got:\n%s", data)
+	}
+
+	svg := filepath.Join(t.TempDir(), "size.svg")
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "badge", "--release", "v1.0.0", "-o", svg); exitCode != 0 {
+		t.Fatalf("first release badge: exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if data, err := os.ReadFile(svg); err != nil || !strings.Contains(string(data), "XS · 4 lines") {
+		t.Errorf("expected an XS badge for the first release, got %s (%v)", data, err)
+	}
+
+	if _, _, exitCode := runDiffer(t, bin, dir, "badge", "--release", "v9.9.9"); exitCode != 1 {
+		t.Errorf("expected exit code 1 for an unknown tag, got %d", exitCode)
+	}
+}
+
+func TestE2E_Top(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--top", "1", "--top-global", "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "main.go\n") || strings.Contains(stdout, "README.md") {
+		t.Errorf("expected only the highest-churn file listed, got:\n%s", stdout)
+	}
+	if !strings.HasSuffix(stdout, "… and 3 more files\n") {
+		t.Errorf("expected a note about the omitted files, got:\n%s", stdout)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, baseRef+".."+headRef, "--top-global")
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2 for --top-global without --top, got %d", exitCode)
+	}
+}
+
+func TestE2E_GroupBy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "-L", "--group-by", "language", "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.HasPrefix(stdout, "[Go] ") || !strings.Contains(stdout, "[2 files]\n") {
+		t.Errorf("expected a Go section with a subtotal, got:\n%s", stdout)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, baseRef+".."+headRef, "--group-by", "owner")
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2 for an invalid --group-by, got %d", exitCode)
+	}
+}
+
+func TestE2E_ReproducibleJSON(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	first, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(first, `"timestamp": "2023-11-14T22:13:20Z"`) {
+		t.Errorf("expected the SOURCE_DATE_EPOCH timestamp, got:\n%s", first)
+	}
+	for i := 0; i < 3; i++ {
+		if again, _, _ := runDiffer(t, bin, dir, baseRef+".."+headRef, "--format", "json"); again != first {
+			t.Fatalf("JSON output differs between runs:\n%s\nthen:\n%s", first, again)
+		}
+	}
+}
+
+func TestE2E_PatchFileWithoutGit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	want, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "-L", "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	cmd := exec.Command("git", "diff", baseRef+".."+headRef)
+	cmd.Dir = dir
+	patch, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git diff: %v", err)
+	}
+	patchFile := filepath.Join(t.TempDir(), "change.patch")
+	writeFile(t, patchFile, string(patch))
+
+	// With no git on PATH, a patch file still gives the same file list.
+	t.Setenv("PATH", t.TempDir())
+	got, stderr, exitCode := runDiffer(t, bin, t.TempDir(), "--patch-file", patchFile, "-L", "--no-color")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if got != want {
+		t.Errorf("--patch-file output differs from the git run:\n%s\nwant:\n%s", got, want)
+	}
+
+	for _, args := range [][]string{nil, {"show"}} {
+		_, stderr, exitCode = runDiffer(t, bin, dir, args...)
+		if exitCode != 1 {
+			t.Errorf("differ %v: expected exit code 1 without git, got %d", args, exitCode)
+		}
+		if !strings.Contains(stderr, "Error: git not found in PATH") || !strings.Contains(stderr, "--patch-file") {
+			t.Errorf("differ %v: expected a note on what works without git, got:\n%s", args, stderr)
+		}
+	}
+}
+
+func TestE2E_GitBinAndArgs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	tools := t.TempDir()
+	logFile := filepath.Join(tools, "calls.log")
+	wrapper := filepath.Join(tools, "git-wrapper")
+	writeFile(t, wrapper, "#!/bin/sh\necho \"$@\" >> "+logFile+"\nexec git \"$@\"\n")
+	if err := os.Chmod(wrapper, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--git-bin", wrapper, "--git-arg=-c", "--git-arg=diff.algorithm=histogram")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	calls, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("reading wrapper log: %v", err)
+	}
+	for _, call := range strings.Split(strings.TrimSpace(string(calls)), "\n") {
+		if !strings.HasPrefix(call, "-c diff.algorithm=histogram ") {
+			t.Errorf("git invoked without --git-arg: %q", call)
+		}
+	}
+	if !strings.Contains(string(calls), "-c diff.algorithm=histogram diff ") {
+		t.Errorf("expected git diff to go through the wrapper, got:\n%s", calls)
+	}
+
+	_, _, exitCode = runDiffer(t, bin, dir, "--git-bin", filepath.Join(tools, "missing"))
+	if exitCode != 2 {
+		t.Errorf("expected exit code 2 for a missing --git-bin, got %d", exitCode)
+	}
+}
+
+func TestE2E_Reproduce(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	report, stderr, exitCode := runDiffer(t, bin, dir, "HEAD~1..HEAD", "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	var result struct {
+		Meta struct {
+			Provenance struct {
+				Version    string   `json:"version"`
+				GitVersion string   `json:"git_version"`
+				ConfigHash string   `json:"config_hash"`
+				Args       []string `json:"args"`
+				BaseCommit string   `json:"base_commit"`
+				HeadCommit string   `json:"head_commit"`
+			} `json:"provenance"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal([]byte(report), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, report)
+	}
+	p := result.Meta.Provenance
+	if p.Version == "" || p.GitVersion == "" || !strings.HasPrefix(p.ConfigHash, "sha256:") {
+		t.Errorf("incomplete provenance: %+v", p)
+	}
+	if strings.Join(p.Args, " ") != "HEAD~1..HEAD --format json" || p.BaseCommit != baseRef || p.HeadCommit != headRef {
+		t.Errorf("unexpected provenance: %+v", p)
+	}
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	writeFile(t, reportFile, report)
+
+	// Moving HEAD does not change what the report is reproduced against.
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	git := exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qam", "later")
+	git.Dir = dir
+	if out, err := git.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--reproduce", reportFile)
+	if exitCode != 0 {
+		t.Fatalf("expected the report to reproduce, got exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stderr, "totals match") || !strings.Contains(stdout, `"base": "`+baseRef+`"`) {
+		t.Errorf("unexpected reproduction:\n%s\nstderr: %s", stdout, stderr)
+	}
+
+	writeFile(t, reportFile, strings.Replace(report, `"churn": `, `"churn": 1`, 1))
+	if _, stderr, exitCode = runDiffer(t, bin, dir, "--reproduce", reportFile); exitCode != 1 || !strings.Contains(stderr, "totals differ") {
+		t.Errorf("expected a mismatch to exit 1, got %d\nstderr: %s", exitCode, stderr)
+	}
+}
+
+func TestE2E_Annotate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "annotate", baseRef+".."+headRef, "--max-churn", "3")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	want := "::warning file=go.sum,line=1,title=Generated file changed::1 line of churn (+1 -0) in a generated file, over the limit of 0. Review the change to its source or generator instead.\n" +
+		"::warning file=main.go,line=1,title=Large change::5 lines of churn (+4 -1), over the limit of 3. Consider splitting this change.\n"
+	if stdout != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout, want)
+	}
+
+	var received struct {
+		HeadSHA string `json:"head_sha"`
+		Output  struct {
+			Annotations []struct {
+				Path  string `json:"path"`
+				Level string `json:"annotation_level"`
+			} `json:"annotations"`
+		} `json:"output"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/o/r/check-runs" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	stdout, stderr, exitCode = runDiffer(t, bin, dir, "annotate", baseRef+".."+headRef, "--max-churn", "4", "--max-generated", "-1", "--level", "error", "--check-run", "churn", "--repo", "o/r")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if received.HeadSHA != headRef || len(received.Output.Annotations) != 1 ||
+		received.Output.Annotations[0].Path != "main.go" || received.Output.Annotations[0].Level != "failure" {
+		t.Errorf("unexpected check run: %+v", received)
+	}
+	if !strings.Contains(stdout, "with 1 annotation") {
+		t.Errorf("stdout = %q", stdout)
+	}
+}
+
+func TestE2E_Notify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r.URL.Path+" "+string(body))
+	}))
+	defer srv.Close()
+
+	_, stderr, exitCode := runDiffer(t, bin, dir, "notify", baseRef+".."+headRef, "--webhook", srv.URL+"/hook", "--repo", "demo",
+		"--template", "{{.Repo}}: {{.Total.Churn}} {{plural .Total.Churn \"line\"}}, largest {{(index .Files 0).Path}}")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if len(received) != 1 || !strings.HasPrefix(received[0], `/hook {"base":"`+baseRef+`","categories":`) ||
+		!strings.Contains(received[0], `"text":"demo: 9 lines, largest main.go"`) {
+		t.Errorf("received = %q", received)
+	}
+
+	// A saved snapshot can be posted without refs; --dry-run prints the
+	// payload instead.
+	snap := filepath.Join(t.TempDir(), "snap.json")
+	if _, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--save-baseline", snap); exitCode != 0 {
+		t.Fatalf("save-baseline: exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "notify", "--from", snap, "--format", "slack", "--dry-run")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, `"blocks":[`) || !strings.Contains(stdout, "9 lines of churn") {
+		t.Errorf("stdout = %q", stdout)
+	}
+
+	_, stderr, exitCode = runDiffer(t, bin, dir, "notify", "--from", snap)
+	if exitCode != exitInvalidConfig || !strings.Contains(stderr, "--webhook or DIFFER_WEBHOOK_URL is required") {
+		t.Errorf("missing webhook: exit code %d, stderr %q", exitCode, stderr)
+	}
+	_, stderr, exitCode = runDiffer(t, bin, dir, "notify", "--dry-run", "--template", "{{.Total")
+	if exitCode != exitInvalidConfig || !strings.Contains(stderr, "Error: --template:") {
+		t.Errorf("bad template: exit code %d, stderr %q", exitCode, stderr)
+	}
+}
+
+func TestE2E_ResultCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	t.Setenv("DIFFER_CACHE_DIR", cacheDir)
+	tools := t.TempDir()
+	logFile := filepath.Join(tools, "calls.log")
+	wrapper := filepath.Join(tools, "git-wrapper")
+	writeFile(t, wrapper, "#!/bin/sh\necho \"$@\" >> "+logFile+"\nexec git \"$@\"\n")
+	if err := os.Chmod(wrapper, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// diffCalls runs differ through the wrapper and reports whether it ran
+	// git diff.
+	diffCalls := func(args ...string) (string, bool) {
+		t.Helper()
+		os.Remove(logFile)
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, append(args, "--git-bin", wrapper, "--format", "json")...)
+		if exitCode != 0 {
+			t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+		}
+		calls, _ := os.ReadFile(logFile)
+		return stdout, regexp.MustCompile(`(?m)^diff `).Match(calls)
+	}
+	totals := func(stdout string) string {
+		var doc struct{ Total json.RawMessage }
+		json.Unmarshal([]byte(stdout), &doc)
+		return string(doc.Total)
+	}
+
+	first, ran := diffCalls(baseRef + ".." + headRef)
+	if !ran {
+		t.Fatal("first run did not run git diff")
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 1 {
+		t.Fatalf("expected one cache entry, got %d", len(entries))
+	}
+	second, ran := diffCalls(baseRef + ".." + headRef)
+	if ran {
+		t.Error("repeat run did not use the cache")
+	}
+	if totals(first) != totals(second) {
+		t.Errorf("cached totals %s differ from %s", totals(second), totals(first))
+	}
+
+	// Different settings, a changed config, and --no-cache all miss.
+	if _, ran := diffCalls(baseRef+".."+headRef, "--empty", "include"); !ran {
+		t.Error("--empty include reused the cached result")
+	}
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "exclude:\n  - go.sum\n")
+	if _, ran := diffCalls(baseRef + ".." + headRef); !ran {
+		t.Error("a changed config reused the cached result")
+	}
+	if _, ran := diffCalls(baseRef+".."+headRef, "--no-cache"); !ran {
+		t.Error("--no-cache used the cache")
+	}
+}
+
+func TestE2E_Fast(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+
+	// Counts from git's numstat match a parsed patch with every line
+	// counted; --detect-moves needs the patch, so it forces a full parse.
+	fast, stderr, exitCode := runDiffer(t, bin, dir, baseRef+".."+headRef, "--fast", "--format", "json", "--no-cache")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	full, _, _ := runDiffer(t, bin, dir, baseRef+".."+headRef, "--empty", "include", "--detect-moves", "--format", "json", "--no-cache")
+	var a, b struct {
+		Total  map[string]int
+		ByFile []map[string]any `json:"by_file"`
+	}
+	json.Unmarshal([]byte(fast), &a)
+	json.Unmarshal([]byte(full), &b)
+	if a.Total["churn"] == 0 || fmt.Sprint(a.Total) != fmt.Sprint(b.Total) || fmt.Sprint(a.ByFile) != fmt.Sprint(b.ByFile) {
+		t.Errorf("--fast:\n%s\nfull parse:\n%s", fast, full)
+	}
+
+	// Migrations are still checked line by line, also from a subdirectory.
+	writeFile(t, filepath.Join(dir, "db", "002_cleanup.sql"), "-- cleanup\nDROP TABLE legacy;\n")
+	cmd := exec.Command("git", "add", "db/002_cleanup.sql")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add failed: %v\n%s", err, out)
+	}
+	stdout, stderr, exitCode := runDiffer(t, bin, filepath.Join(dir, "db"), "--staged", "--fast")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "db/002_cleanup.sql:2:") || !strings.Contains(stdout, "(drop-table)") {
+		t.Errorf("expected the migration warning with --fast:\n%s", stdout)
+	}
+
+	for _, args := range [][]string{{"--fast", "--empty", "exclude"}, {"--fast", "--detect-moves"}} {
+		if _, stderr, exitCode := runDiffer(t, bin, dir, args...); exitCode != 1 || !strings.Contains(stderr, "--fast") {
+			t.Errorf("%v: exit code %d, stderr %q", args, exitCode, stderr)
+		}
+	}
+}
+
+func TestE2E_Subdirectory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "exclude:\n  - go.sum\n")
+	sub := filepath.Join(dir, "pkg", "util")
+	writeFile(t, filepath.Join(sub, "util.go"), "package util\n")
+
+	paths := func(args ...string) (string, []string) {
+		t.Helper()
+		stdout, stderr, exitCode := runDiffer(t, bin, sub, append([]string{baseRef + ".." + headRef, "--format", "json"}, args...)...)
+		if exitCode != 0 {
+			t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+		}
+		var doc struct {
+			Meta   struct{ Relative string }
+			ByFile []struct{ Path string } `json:"by_file"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &doc); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, f := range doc.ByFile {
+			got = append(got, f.Path)
+		}
+		return doc.Meta.Relative, got
+	}
+
+	// The root .differ.yml applies, and paths stay relative to the root.
+	if rel, got := paths(); rel != "" || strings.Join(got, " ") != "main.go main_test.go README.md" {
+		t.Errorf("from a subdirectory: relative %q, paths %v", rel, got)
+	}
+	if rel, got := paths("--relative"); rel != "pkg/util" || strings.Join(got, " ") != "../../main.go ../../main_test.go ../../README.md" {
+		t.Errorf("--relative: relative %q, paths %v", rel, got)
+	}
+	// --relative-to names its directory from the root, wherever differ runs.
+	if rel, got := paths("--relative-to", "./pkg/"); rel != "pkg" || strings.Join(got, " ") != "../main.go ../main_test.go ../README.md" {
+		t.Errorf("--relative-to: relative %q, paths %v", rel, got)
+	}
+	for _, args := range [][]string{{"--relative", "--relative-to", "pkg"}, {"--relative-to", "../elsewhere"}} {
+		if _, stderr, exitCode := runDiffer(t, bin, sub, append([]string{baseRef + ".." + headRef}, args...)...); exitCode != 2 {
+			t.Errorf("%v: expected exit code 2, got %d: %s", args, exitCode, stderr)
+		}
+	}
+}
+
+func TestE2E_RelativeOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, _ := setupTestRepo(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test",
+			"GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test",
+			"GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	writeFile(t, filepath.Join(dir, "services", "api", "api.go"), "package api\n")
+	writeFile(t, filepath.Join(dir, "services", "web", "web.go"), "package web\n")
+	git("add", "-A")
+	git("commit", "-m", "add services")
+
+	// From a sibling directory too, the subtree is named from the root.
+	sub := filepath.Join(dir, "services", "web")
+	stdout, stderr, exitCode := runDiffer(t, bin, sub, baseRef+"..HEAD", "--format", "json", "--relative-to", "services/api", "--relative-only")
+	if exitCode != 0 {
+		t.Fatalf("exit code %d\nstderr: %s", exitCode, stderr)
+	}
+	var doc struct {
+		Meta   struct{ Relative string }
+		ByFile []struct{ Path string } `json:"by_file"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Meta.Relative != "services/api" || len(doc.ByFile) != 1 || doc.ByFile[0].Path != "api.go" {
+		t.Errorf("relative %q, files %+v; want only api.go", doc.Meta.Relative, doc.ByFile)
+	}
+
+	for _, args := range [][]string{{"--relative-only"}, {"--relative", "--relative-only"}} {
+		if _, stderr, exitCode := runDiffer(t, bin, dir, append([]string{baseRef + "..HEAD"}, args...)...); exitCode != 2 {
+			t.Errorf("%v: expected exit code 2, got %d: %s", args, exitCode, stderr)
+		}
+	}
+	if _, stderr, exitCode := runDiffer(t, bin, dir, baseRef+"..HEAD", "--relative-to", "services/api", "--relative-only", "--", "main.go"); exitCode != 1 {
+		t.Errorf("with pathspecs: expected exit code 1, got %d: %s", exitCode, stderr)
+	}
+}
+
+func TestE2E_Verbose(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	rng := baseRef + ".." + headRef
+
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--no-cache", "-v", rng)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	if !strings.Contains(stdout, "Total:") {
+		t.Errorf("expected the report on stdout, got:\n%s", stdout)
+	}
+	for _, want := range []string{
+		"differ: comparing " + rng + "\n",
+		"differ: git diff --no-color",
+		"differ: stage resolve refs: ",
+		"differ: stage diff and summarize: ",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("expected %q in stderr:\n%s", want, stderr)
+		}
+	}
+	if strings.Contains(stderr, " B)") {
+		t.Errorf("expected no output sizes without -vv:\n%s", stderr)
+	}
+
+	_, stderr, _ = runDiffer(t, bin, dir, "--no-cache", "-vv", rng)
+	if !strings.Contains(stderr, "differ: git rev-parse --show-toplevel (") || !strings.Contains(stderr, " B") {
+		t.Errorf("expected git commands with output sizes with -vv:\n%s", stderr)
+	}
+
+	// Without -v nothing is logged.
+	if _, stderr, _ := runDiffer(t, bin, dir, rng); strings.Contains(stderr, "differ: ") {
+		t.Errorf("expected no logs without -v:\n%s", stderr)
+	}
+}
+
+func TestE2E_Completion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, _, _ := setupTestRepo(t)
+
+	cases := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"__complete", "--base", ""}, []string{"HEAD", "main"}},
+		{[]string{"__complete", "check", "--head", "ma"}, []string{"main"}},
+		{[]string{"__complete", "main.."}, []string{"main..HEAD", "main..main"}},
+		{[]string{"__complete", "main...ma"}, []string{"main...main"}},
+		{[]string{"__complete", "--category", ""}, []string{"docs", "tests", "source", "generated"}},
+	}
+	for _, tc := range cases {
+		stdout, stderr, exitCode := runDiffer(t, bin, dir, tc.args...)
+		if exitCode != 0 {
+			t.Fatalf("%v: expected exit code 0, got %d\nstderr: %s", tc.args, exitCode, stderr)
+		}
+		lines := strings.Split(stdout, "\n")
+		for _, want := range tc.want {
+			if !slices.Contains(lines, want) {
+				t.Errorf("%v: expected completion %q, got:\n%s", tc.args, want, stdout)
+			}
+		}
+	}
+}
+
+func TestE2E_ByTeam(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	bin := buildBinary(t)
+	dir, baseRef, headRef := setupTestRepo(t)
+	rng := baseRef + ".." + headRef
+
+	// Without a teams section there is nothing to roll up by.
+	if _, stderr, exitCode := runDiffer(t, bin, dir, "--by-team", rng); exitCode != 2 || !strings.Contains(stderr, "teams:") {
+		t.Errorf("expected exit code 2 without teams config, got %d\nstderr: %s", exitCode, stderr)
+	}
+
+	writeFile(t, filepath.Join(dir, ".differ.yml"), "teams:\n  core: [\"*.go\"]\n")
+	stdout, stderr, exitCode := runDiffer(t, bin, dir, "--by-team", "--format", "json", rng)
+	if exitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d\nstderr: %s", exitCode, stderr)
+	}
+	var result struct {
+		Meta struct {
+			Teams []struct {
+				Name       string                         `json:"name"`
+				Total      struct{ Files int }            `json:"total"`
+				ByCategory map[string]struct{ Files int } `json:"by_category"`
+			} `json:"teams"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	teams := result.Meta.Teams
+	if len(teams) != 2 || teams[0].Name != "core" || teams[1].Name != "unassigned" {
+		t.Fatalf("expected core and unassigned teams, got %+v", teams)
+	}
+	if teams[0].Total.Files != 2 || teams[0].ByCategory["tests"].Files != 1 {
+		t.Errorf("expected main.go and main_test.go for core, got %+v", teams[0])
+	}
+
+	stdout, _, _ = runDiffer(t, bin, dir, "--by-team", rng)
+	if !strings.Contains(stdout, "Teams:\n  core:") {
+		t.Errorf("expected a Teams section, got:\n%s", stdout)
+	}
+	if stdout, _, _ = runDiffer(t, bin, dir, rng); strings.Contains(stdout, "Teams:") {
+		t.Errorf("expected no Teams section without --by-team, got:\n%s", stdout)
+	}
+}
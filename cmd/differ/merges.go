@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/filter"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/jbonatakis/differ/internal/merges"
+	"github.com/spf13/cobra"
+)
+
+func newMergesCmd() *cobra.Command {
+	var (
+		empty          string
+		format         string
+		sortBy         string
+		ignoreRevsFile string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "merges [range] [-- pathspec...]",
+		Short: "Break the churn of a range down by merged pull request or branch",
+		Long: `Walk the merge commits on the first-parent chain of a range and report the
+churn each brought in, diffed against its first parent, with its SHAs and
+churn per category. Pull request numbers and branch names are read from the
+merge subjects GitHub, GitLab, Bitbucket, and git write. Merges are listed
+by churn, largest first, or with --sort date, newest first.
+
+Commits made directly on the first-parent chain, without a merge, are not
+counted.
+
+Merges listed in an --ignore-revs-file, in git blame's format, are left
+out, for example a bulk reformat landed as its own pull request.
+
+A single ref is treated as "<ref>..HEAD". With no range, the base is
+auto-detected the same way as the main command. Include and exclude globs
+from the config apply.
+
+Examples:
+  differ merges v1.2.0..v1.3.0
+  differ merges v1.2.0 --sort date --format json`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if empty != "include" && empty != "exclude" {
+				fmt.Fprintf(stderr, "Error: --empty must be 'include' or 'exclude', got %q\n", empty)
+				os.Exit(exitInvalidConfig)
+			}
+			if format != "text" && format != "json" {
+				fmt.Fprintf(stderr, "Error: --format must be 'text' or 'json', got %q\n", format)
+				os.Exit(exitInvalidConfig)
+			}
+			if sortBy != merges.SortChurn && sortBy != merges.SortDate {
+				fmt.Fprintf(stderr, "Error: --sort must be %s, got %q\n", strings.Join(merges.SortValues, " or "), sortBy)
+				os.Exit(exitInvalidConfig)
+			}
+
+			revArgs, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				revArgs, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(revArgs) > 1 {
+				fmt.Fprintln(stderr, "Error: at most one positional rev-range argument allowed")
+				os.Exit(exitRuntimeError)
+			}
+			var revRange string
+			if len(revArgs) == 1 {
+				revRange = revArgs[0]
+			}
+
+			cfg, err := config.Load(topLevel(gitdiff.DefaultRunner), config.Config{Empty: empty})
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+
+			runner := gitdiff.DefaultRunner
+			if revRange == "" {
+				revRange, err = gitdiff.ResolveRefs(runner, "", "", "")
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+			}
+			logRange := history.LogRange(revRange)
+
+			commits, err := history.Log(runner, logRange, pathspecs, history.Options{
+				Empty:             cfg.Empty,
+				FirstParentMerges: true,
+				IgnoreRevs:        ignoredRevs(ignoreRevsFile),
+			})
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			categoryOf := pathCategory(cfg)
+			filterCfg := filter.FilterConfig{Include: cfg.Include, Exclude: cfg.Exclude}
+			for i := range commits {
+				commits[i].Files = filter.Filter(commits[i].Files, filterCfg, categoryOf)
+			}
+
+			result, err := merges.Build(commits, categoryOf, sortBy)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			if format == "json" {
+				if err := merges.RenderJSON(stdout, logRange, result); err != nil {
+					fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				return nil
+			}
+			merges.RenderText(stdout, result)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringVar(&format, "format", "text", "output format (text|json)")
+	flags.StringVar(&sortBy, "sort", merges.SortChurn, "order merges by "+strings.Join(merges.SortValues, " or "))
+	flags.StringVar(&ignoreRevsFile, "ignore-revs-file", "", "skip the commits listed in `file`, as git blame does (e.g. .git-blame-ignore-revs)")
+
+	return cmd
+}
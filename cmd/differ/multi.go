@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// workspace is a file listing the repositories of a multi-repository
+// report.
+type workspace struct {
+	Repos []workspaceRepo `yaml:"repos"`
+}
+
+// workspaceRepo is one repository of a workspace. Path is relative to the
+// workspace file; Name defaults to the directory name, and Range and
+// Pathspecs to the command's.
+type workspaceRepo struct {
+	Path      string   `yaml:"path"`
+	Name      string   `yaml:"name"`
+	Range     string   `yaml:"range"`
+	Pathspecs []string `yaml:"pathspecs"`
+}
+
+func newMultiCmd() *cobra.Command {
+	var (
+		repos     []string
+		workspace string
+		empty     string
+		list      bool
+		format    string
+		include   []string
+		exclude   []string
+		category  []string
+		sort      string
+		color     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "multi [rev-range] (--repo path... | --workspace file) [flags] [-- pathspec...]",
+		Short: "Report churn across several repositories with a grand total",
+		Long: `Run the analysis in each of several repositories and report them together,
+followed by the grand total over all of them, overall and per category.
+
+Repositories are given with --repo, or listed in a workspace file:
+
+  repos:
+    - path: services/api
+    - path: ../billing
+      name: billing
+      range: release...main
+      pathspecs: [src/]
+
+Workspace paths are relative to the file. A repository without a range uses
+rev-range, or, without one, its auto-detected base, as the main command does;
+one without pathspecs uses those given after --. Each repository's own
+.differ.yml applies.
+
+Examples:
+  differ multi --repo ../api --repo ../web
+  differ multi main...HEAD --repo ../api --repo ../web
+  differ multi --workspace fleet.yml --format json`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := runOpts{
+				empty:    empty,
+				list:     list,
+				format:   format,
+				include:  include,
+				exclude:  exclude,
+				category: category,
+				sort:     sort,
+				color:    color,
+			}
+			validateOpts(opts)
+			if format != "text" && format != "json" {
+				fmt.Fprintf(stderr, "Error: multi supports text and json output, got %q\n", format)
+				os.Exit(exitInvalidConfig)
+			}
+
+			positional, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				positional, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(positional) > 1 {
+				fmt.Fprintln(stderr, "Error: multi takes at most one rev-range")
+				os.Exit(exitRuntimeError)
+			}
+			var revRange string
+			if len(positional) == 1 {
+				revRange = positional[0]
+			}
+			targets := multiRepos(repos, workspace, revRange, pathspecs)
+
+			layers := make([]output.Layer, 0, len(targets))
+			var cfgSort string
+			for _, r := range targets {
+				repoOpts := opts
+				repoOpts.runner = gitdiff.DirRunner{Dir: r.Path}
+				if _, err := gitdiff.TopLevel(repoOpts.runner); err != nil {
+					fmt.Fprintf(stderr, "Error: repository %s: %v\n", r.Path, err)
+					os.Exit(exitRuntimeError)
+				}
+				summary, cfg := analyze(repoOpts, r.Range, r.Pathspecs)
+				cfgSort = cfg.Sort
+				layers = append(layers, output.Layer{Name: r.Name, Summary: summary})
+			}
+
+			if format == "json" {
+				if err := output.RenderMultiJSON(stdout, layers); err != nil {
+					fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				return nil
+			}
+			output.RenderMultiText(stdout, layers, output.Options{
+				List:    list,
+				Sort:    cfgSort,
+				NoColor: !output.Colorize(color, os.Stdout),
+				Net:     cfgSort == "net",
+			})
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringArrayVar(&repos, "repo", nil, "`path` of a repository to include (repeatable)")
+	flags.StringVar(&workspace, "workspace", "", "YAML `file` listing the repositories, with optional names, ranges, and pathspecs")
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.BoolVarP(&list, "list", "l", false, "show summary plus per-file list for each repository")
+	flags.StringVar(&format, "format", "text", "output format (text|json)")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&category, "category", nil, "restrict to category (docs|tests|source|ci|i18n|generated|other, repeatable)")
+	flags.StringVar(&sort, "sort", "churn", "file list ordering ("+strings.Join(output.SortModes, "|")+")")
+	addColorFlag(cmd, &color)
+
+	return cmd
+}
+
+// multiRepos returns the repositories named by --repo and the workspace
+// file, with absolute paths, unique names, and revRange and pathspecs
+// filled in where they are not set. It exits with exitInvalidConfig if
+// there are none or the workspace cannot be read.
+func multiRepos(repos []string, workspaceFile, revRange string, pathspecs []string) []workspaceRepo {
+	var targets []workspaceRepo
+	for _, p := range repos {
+		targets = append(targets, workspaceRepo{Path: p})
+	}
+	if workspaceFile != "" {
+		ws, err := loadWorkspace(workspaceFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: loading workspace: %v\n", err)
+			os.Exit(exitInvalidConfig)
+		}
+		targets = append(targets, ws...)
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(stderr, "Error: multi needs at least one --repo or a --workspace")
+		os.Exit(exitInvalidConfig)
+	}
+
+	seen := make(map[string]int)
+	for i := range targets {
+		r := &targets[i]
+		given := r.Path
+		abs, err := filepath.Abs(r.Path)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: repository %s: %v\n", r.Path, err)
+			os.Exit(exitRuntimeError)
+		}
+		r.Path = abs
+		if r.Name == "" {
+			r.Name = filepath.Base(abs)
+			// Two repositories in directories of the same name are told
+			// apart by their paths as given.
+			if seen[r.Name] > 0 {
+				r.Name = given
+			}
+		}
+		seen[r.Name]++
+		if r.Range == "" {
+			r.Range = revRange
+		}
+		if len(r.Pathspecs) == 0 {
+			r.Pathspecs = pathspecs
+		}
+	}
+	return targets
+}
+
+// loadWorkspace reads the repositories listed in the workspace file at
+// path, resolving their paths against its directory.
+func loadWorkspace(path string) ([]workspaceRepo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var ws workspace
+	if err := dec.Decode(&ws); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(ws.Repos) == 0 {
+		return nil, fmt.Errorf("%s: at least one repo is required", path)
+	}
+	for i, r := range ws.Repos {
+		if r.Path == "" {
+			return nil, fmt.Errorf("%s: repos[%d]: path is required", path, i)
+		}
+		if !filepath.IsAbs(r.Path) {
+			ws.Repos[i].Path = filepath.Join(filepath.Dir(path), r.Path)
+		}
+	}
+	return ws.Repos, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/notify"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/spf13/cobra"
+)
+
+func newNotifyCmd() *cobra.Command {
+	var (
+		base         string
+		head         string
+		empty        string
+		include      []string
+		exclude      []string
+		category     []string
+		webhook      string
+		format       string
+		templateText string
+		templateFile string
+		from         string
+		repo         string
+		dryRun       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "notify [rev-range] --webhook <url> [flags] [-- pathspec...]",
+		Short: "Post a churn summary to a webhook or Slack channel",
+		Long: `Analyze a range like the main command, or read a snapshot saved with
+--save-baseline, and post the summary to a webhook.
+
+--format json posts a compact JSON object with the message, totals, and
+per-category totals. --format slack posts a Slack Block Kit message with the
+churn per category and the largest files. Both carry the message in a "text"
+field, so either works with Slack-compatible incoming webhooks.
+
+The message is a Go text/template executed with .Repo, .Base, .Head, .Total,
+.Categories, and .Files (largest churn first); totals have .Added, .Deleted,
+.Churn, and .Files. The webhook URL can also be set with DIFFER_WEBHOOK_URL,
+which keeps it out of shell history and process listings.
+
+Examples:
+  differ notify main...HEAD --webhook https://hooks.slack.com/services/... --format slack
+  differ notify --from nightly.json --template 'Nightly: {{.Total.Churn}} lines changed'
+  differ notify --dry-run --format slack`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(notify.Formats, format) {
+				fmt.Fprintf(stderr, "Error: --format must be one of %s, got %q\n", strings.Join(notify.Formats, ", "), format)
+				os.Exit(exitInvalidConfig)
+			}
+			if templateText != "" && templateFile != "" {
+				fmt.Fprintln(stderr, "Error: --template and --template-file are mutually exclusive")
+				os.Exit(exitInvalidConfig)
+			}
+			if templateFile != "" {
+				data, err := os.ReadFile(templateFile)
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitInvalidConfig)
+				}
+				templateText = string(data)
+			}
+			if templateText == "" {
+				templateText = notify.DefaultTemplate
+			}
+			tmpl, err := notify.ParseTemplate(templateText)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: --template: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+			if webhook == "" {
+				webhook = os.Getenv("DIFFER_WEBHOOK_URL")
+			}
+			if webhook == "" && !dryRun {
+				fmt.Fprintln(stderr, "Error: --webhook or DIFFER_WEBHOOK_URL is required (or pass --dry-run)")
+				os.Exit(exitInvalidConfig)
+			}
+
+			runner := gitdiff.DefaultRunner
+			opts := runOpts{
+				base:     base,
+				head:     head,
+				empty:    empty,
+				format:   "text",
+				include:  include,
+				exclude:  exclude,
+				category: category,
+				sort:     "churn",
+				runner:   runner,
+			}
+			validateOpts(opts)
+
+			revArgs, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				revArgs, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(revArgs) > 1 {
+				fmt.Fprintln(stderr, "Error: at most one positional rev-range argument allowed")
+				os.Exit(exitRuntimeError)
+			}
+
+			var summary output.Summary
+			if from != "" {
+				if len(revArgs) > 0 || len(pathspecs) > 0 || base != "" || head != "" || len(include) > 0 || len(exclude) > 0 || len(category) > 0 {
+					fmt.Fprintln(stderr, "Error: --from cannot be combined with refs, pathspecs, or filters")
+					os.Exit(exitInvalidConfig)
+				}
+				summary = readSnapshot(from)
+			} else {
+				requireGit()
+				var revRange string
+				if len(revArgs) == 1 {
+					revRange = revArgs[0]
+				}
+				summary, _ = analyze(opts, revRange, pathspecs)
+			}
+			if repo == "" {
+				repo = repoName(runner)
+			}
+
+			payload, err := notify.Payload(format, tmpl, notify.NewData(repo, summary))
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: --template: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			if dryRun {
+				fmt.Fprintf(stdout, "%s\n", payload)
+				return nil
+			}
+			if err := notify.Post(context.Background(), nil, webhook, payload); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&base, "base", "", "base ref")
+	flags.StringVar(&head, "head", "", "head ref")
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&category, "category", nil, "only include files in this category (repeatable)")
+	flags.StringVar(&webhook, "webhook", "", "webhook `url` to post to (default: DIFFER_WEBHOOK_URL)")
+	flags.StringVar(&format, "format", "json", "payload format ("+strings.Join(notify.Formats, "|")+")")
+	flags.StringVar(&templateText, "template", "", "message `template` in Go text/template syntax")
+	flags.StringVar(&templateFile, "template-file", "", "read the message template from `file`")
+	flags.StringVar(&from, "from", "", "post a snapshot saved with --save-baseline instead of analyzing")
+	flags.StringVar(&repo, "repo", "", "repository name for the message (default: working tree directory name)")
+	flags.BoolVar(&dryRun, "dry-run", false, "print the payload instead of posting it")
+
+	return cmd
+}
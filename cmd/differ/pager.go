@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// usePager is cleared by --no-pager; asciiOnly is set by --ascii.
+var (
+	usePager  = true
+	asciiOnly bool
+)
+
+// startPager pipes stdout through a pager when stdout is a terminal, as git
+// does, and returns a function that waits for the pager to exit. configured
+// is the pager config key, nil if unset. less is run with LESS=FRX unless
+// LESS is set, so output that fits on one screen is printed directly and
+// colors pass through.
+func startPager(configured *string) (stop func()) {
+	stop = func() {}
+	if !usePager || !output.IsTerminal(os.Stdout) {
+		return stop
+	}
+	command := pagerCommand(configured)
+	if command == "" || command == "cat" {
+		return stop
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if _, ok := os.LookupEnv("LV"); !ok {
+		cmd.Env = append(cmd.Env, "LV=-c")
+	}
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return stop
+	}
+	if err := cmd.Start(); err != nil {
+		return stop
+	}
+	// The pager handles ^C itself; differ waits for it to exit.
+	signal.Ignore(os.Interrupt)
+
+	saved := stdout
+	stdout = pagerWriter{in}
+	if asciiOnly {
+		stdout = output.NewASCIIWriter(stdout)
+	}
+	return func() {
+		in.Close()
+		_ = cmd.Wait()
+		signal.Reset(os.Interrupt)
+		stdout = saved
+	}
+}
+
+// pagerCommand returns the pager to run: DIFFER_PAGER, the pager config
+// key, PAGER, or less, in that order. Empty or "cat" means no pager.
+func pagerCommand(configured *string) string {
+	if p, ok := os.LookupEnv("DIFFER_PAGER"); ok {
+		return p
+	}
+	if configured != nil {
+		return *configured
+	}
+	if p, ok := os.LookupEnv("PAGER"); ok {
+		return p
+	}
+	return "less"
+}
+
+// pagerWriter discards output once the pager has been quit, rather than
+// failing the command.
+type pagerWriter struct{ w io.Writer }
+
+func (p pagerWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed) {
+		return len(b), nil
+	}
+	return n, err
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+	"github.com/jbonatakis/differ/pkg/differ"
+)
+
+// invocation is the command line recorded in report provenance. A
+// reproduction replaces it with the arguments of the report it re-runs.
+var invocation = os.Args[1:]
+
+// reproduction is set while --reproduce re-runs a report.
+var reproduction *reproduceState
+
+type reproduceState struct {
+	path       string
+	provenance output.Provenance
+	total      snapshot.Totals
+	differs    bool
+}
+
+// version returns the differ version: the -ldflags value, else the module
+// version recorded by go install, else "dev".
+func version() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// configHash fingerprints the effective config, including CLI overrides and
+// per-directory configs, so reports made under different rules can be told
+// apart.
+func configHash(cfg config.Config) string {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// newProvenance describes the current run. algorithm is the diff algorithm
+// git used, or empty for runs that never invoked git, such as --patch-file.
+func newProvenance(runner gitdiff.CommandRunner, cfg config.Config, algorithm, baseCommit, headCommit string) *output.Provenance {
+	p := &output.Provenance{
+		Version:       version(),
+		DiffAlgorithm: algorithm,
+		ConfigHash:    configHash(cfg),
+		Args:          append([]string{}, invocation...),
+		BaseCommit:    baseCommit,
+		HeadCommit:    headCommit,
+	}
+	if algorithm != "" {
+		p.GitVersion, _ = gitdiff.Version(runner)
+	}
+	return p
+}
+
+// pinCommits resolves the two ends of refRange to commits; for a three-dot
+// range the base is the merge base. It returns empty strings unless both
+// ends are commits.
+func pinCommits(runner gitdiff.CommandRunner, refRange string) (base, head string) {
+	baseRef, headRef := differ.ParseRefRange(refRange)
+	if baseRef == "" || headRef == "" {
+		return "", ""
+	}
+	if strings.Contains(refRange, "...") {
+		mb, err := gitdiff.MergeBase(runner, baseRef, headRef)
+		if err != nil {
+			return "", ""
+		}
+		baseRef = mb
+	}
+	base, err := gitdiff.ResolveCommit(runner, baseRef)
+	if err != nil {
+		return "", ""
+	}
+	head, err = gitdiff.ResolveCommit(runner, headRef)
+	if err != nil {
+		return "", ""
+	}
+	return base, head
+}
+
+// reproduceReport re-runs the analysis recorded in the provenance of the
+// report at path with the same arguments and, when they were recorded, the
+// same commits. The re-run is rendered as the original was, and
+// checkReproduction then compares its totals with the report's.
+func reproduceReport(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		os.Exit(exitRuntimeError)
+	}
+	var report struct {
+		Meta struct {
+			Provenance *output.Provenance `json:"provenance"`
+		} `json:"meta"`
+		Total snapshot.Totals `json:"total"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		fmt.Fprintf(stderr, "Error: reading %s: %v\n", path, err)
+		os.Exit(exitRuntimeError)
+	}
+	prov := report.Meta.Provenance
+	if prov == nil {
+		fmt.Fprintf(stderr, "Error: %s has no provenance to reproduce from\n", path)
+		os.Exit(exitRuntimeError)
+	}
+
+	root := newRootCmd()
+	if sub, _, err := root.Find(prov.Args); err != nil || sub != root || slices.Contains(prov.Args, "--reproduce") {
+		fmt.Fprintf(stderr, "Error: %s was not produced by the main differ command and cannot be reproduced\n", path)
+		os.Exit(exitRuntimeError)
+	}
+
+	reproduction = &reproduceState{path: path, provenance: *prov, total: report.Total}
+	invocation = prov.Args
+	root.SetArgs(prov.Args)
+	if err := root.Execute(); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		os.Exit(exitRuntimeError)
+	}
+	if reproduction.differs {
+		os.Exit(exitRuntimeError)
+	}
+}
+
+// checkReproduction reports, on stderr, how a re-run differs from the
+// report being reproduced: first the environment, then the totals.
+func checkReproduction(summary output.Summary) {
+	want, got := reproduction.provenance, summary.Meta.Provenance
+	if got != nil {
+		for _, c := range []struct{ name, want, got string }{
+			{"differ version", want.Version, got.Version},
+			{"git version", want.GitVersion, got.GitVersion},
+			{"diff algorithm", want.DiffAlgorithm, got.DiffAlgorithm},
+			{"config", want.ConfigHash, got.ConfigHash},
+		} {
+			if c.want != c.got {
+				fmt.Fprintf(stderr, "note: %s differs from the report (%s, now %s)\n", c.name, orNone(c.want), orNone(c.got))
+			}
+		}
+	}
+
+	t := summary.Totals
+	now := snapshot.Totals{Added: t.Added, Deleted: t.Deleted, Churn: t.Churn, Moved: t.Moved, Files: t.FileCount}
+	if now == reproduction.total {
+		fmt.Fprintf(stderr, "Reproduced %s: totals match (+%d -%d, %d %s)\n", reproduction.path, now.Added, now.Deleted, now.Files, plural(now.Files, "file"))
+		return
+	}
+	was := reproduction.total
+	fmt.Fprintf(stderr, "Error: totals differ from %s: +%d -%d, %d %s (report: +%d -%d, %d %s)\n",
+		reproduction.path, now.Added, now.Deleted, now.Files, plural(now.Files, "file"), was.Added, was.Deleted, was.Files, plural(was.Files, "file"))
+	reproduction.differs = true
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
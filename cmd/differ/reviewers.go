@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/github"
+	"github.com/jbonatakis/differ/internal/owners"
+	"github.com/spf13/cobra"
+)
+
+func newReviewersCmd() *cobra.Command {
+	var (
+		base     string
+		head     string
+		empty    string
+		format   string
+		include  []string
+		exclude  []string
+		blame    bool
+		top      int
+		skip     []string
+		prNumber int
+		request  bool
+		repo     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reviewers [rev-range] [flags] [-- pathspec...]",
+		Short: "Suggest reviewers weighted by ownership of the changed code",
+		Long: `Suggest reviewers for a change by combining CODEOWNERS with the diff.
+Each CODEOWNERS owner of a changed file is credited with that file's churn;
+with --blame, authors of the file at the base ref, or at the merge base of a
+base...head range, are also credited in proportion to the lines they own.
+
+With --github-pr, the pull request's base and head SHAs are used as the range
+(unless --base/--head are given) and the PR author is excluded, by handle
+and by the emails of their commits in the pull request. Adding
+--request asks the top suggestions to review through the GitHub API; only
+@user and @org/team handles can be requested. A token is read from
+GITHUB_TOKEN.
+
+Examples:
+  differ reviewers
+  differ reviewers main...HEAD --blame --top 3
+  differ reviewers --github-pr 42 --request`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "text" && format != "json" {
+				fmt.Fprintf(stderr, "Error: --format must be 'text' or 'json', got %q\n", format)
+				os.Exit(exitInvalidConfig)
+			}
+			if request && prNumber == 0 {
+				fmt.Fprintln(stderr, "Error: --request requires --github-pr")
+				os.Exit(exitRuntimeError)
+			}
+
+			revArgs, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				revArgs, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(revArgs) > 1 {
+				fmt.Fprintln(stderr, "Error: at most one positional rev-range argument allowed")
+				os.Exit(exitRuntimeError)
+			}
+			var revRange string
+			if len(revArgs) == 1 {
+				revRange = revArgs[0]
+			}
+
+			runner := gitdiff.DefaultRunner
+			var client *github.Client
+			if prNumber > 0 {
+				client = github.NewClient(os.Getenv("GITHUB_TOKEN"))
+				if repo == "" {
+					repo = detectGitHubRepo(runner)
+				}
+				if repo == "" {
+					fmt.Fprintln(stderr, "Error: cannot determine GitHub repository; pass --repo owner/name")
+					os.Exit(exitRuntimeError)
+				}
+				pr, err := client.PullRequest(repo, prNumber)
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: fetching pull request: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				if base == "" && head == "" && revRange == "" {
+					base, head = pr.Base.SHA, pr.Head.SHA
+				}
+				// The author is skipped by handle and by the emails their
+				// commits carry, which is how blame names them.
+				commits, err := client.PullRequestCommits(repo, prNumber)
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: fetching pull request commits: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				skip = append(skip, "@"+pr.User.Login)
+				skip = append(skip, github.AuthorEmails(commits, pr.User.Login)...)
+			}
+
+			opts := runOpts{
+				base:    base,
+				head:    head,
+				empty:   empty,
+				format:  format,
+				include: include,
+				exclude: exclude,
+				sort:    "churn",
+				runner:  runner,
+			}
+			validateOpts(opts)
+			summary, _ := analyze(opts, revRange, pathspecs)
+
+			co, err := owners.LoadCodeowners(topLevel(runner))
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: reading CODEOWNERS: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+
+			// Blame where the diff starts, which for base...head is their
+			// merge base rather than the base ref.
+			blameRev := summary.Meta.Base
+			if blame && summary.Meta.MergeBase != nil && *summary.Meta.MergeBase {
+				if blameRev, err = gitdiff.MergeBase(runner, summary.Meta.Base, summary.Meta.Head); err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+			}
+
+			files := make([]owners.ChangedFile, 0, len(summary.FileStats))
+			blameCounts := make(map[string]map[string]int)
+			for _, f := range summary.FileStats {
+				files = append(files, owners.ChangedFile{Path: f.Path, Churn: f.Churn})
+				if !blame || f.Deleted == 0 {
+					continue
+				}
+				// A renamed file is blamed under its old path, and files that
+				// did not exist at the base ref cannot be blamed.
+				blamePath := f.Path
+				if f.OldPath != "" {
+					blamePath = f.OldPath
+				}
+				if counts, err := owners.Blame(runner, blameRev, blamePath); err == nil {
+					blameCounts[f.Path] = counts
+				}
+			}
+
+			suggestions := owners.Suggest(files, co, blameCounts, skip)
+			if top > 0 && len(suggestions) > top {
+				suggestions = suggestions[:top]
+			}
+
+			if format == "json" {
+				renderReviewersJSON(suggestions)
+			} else {
+				renderReviewersText(suggestions, co == nil)
+			}
+
+			if request {
+				handles := make([]string, 0, len(suggestions))
+				for _, s := range suggestions {
+					handles = append(handles, s.Reviewer)
+				}
+				users, teams, skipped := github.SplitReviewers(handles)
+				if len(skipped) > 0 {
+					fmt.Fprintf(stderr, "Note: cannot request non-GitHub reviewers: %s\n", strings.Join(skipped, ", "))
+				}
+				if len(users)+len(teams) == 0 {
+					fmt.Fprintln(stderr, "Note: no requestable reviewers")
+					return nil
+				}
+				if err := client.RequestReviewers(repo, prNumber, users, teams); err != nil {
+					fmt.Fprintf(stderr, "Error: requesting reviewers: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&base, "base", "", "base ref")
+	flags.StringVar(&head, "head", "", "head ref")
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.StringVar(&format, "format", "text", "output format (text|json)")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.BoolVar(&blame, "blame", false, "also weight by git blame ownership at the base ref")
+	flags.IntVar(&top, "top", 5, "number of reviewers to suggest (0 for all)")
+	flags.StringArrayVar(&skip, "skip", nil, "reviewer handle or email to exclude (repeatable)")
+	flags.IntVar(&prNumber, "github-pr", 0, "GitHub pull request number to analyze")
+	flags.BoolVar(&request, "request", false, "request the suggested reviewers on the pull request")
+	flags.StringVar(&repo, "repo", "", "GitHub repository as owner/name (default: GITHUB_REPOSITORY or origin remote)")
+
+	return cmd
+}
+
+// detectGitHubRepo returns "owner/name" from GITHUB_REPOSITORY or the origin
+// remote URL, or "" if neither identifies a GitHub repository.
+func detectGitHubRepo(runner gitdiff.CommandRunner) string {
+	if repo := os.Getenv("GITHUB_REPOSITORY"); repo != "" {
+		return repo
+	}
+	out, err := runner.Run("git", "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	return github.RepoFromRemote(string(out))
+}
+
+func renderReviewersText(suggestions []owners.Suggestion, noCodeowners bool) {
+	if len(suggestions) == 0 {
+		if noCodeowners {
+			fmt.Fprintln(stdout, "No reviewers found (no CODEOWNERS file; try --blame).")
+		} else {
+			fmt.Fprintln(stdout, "No reviewers found.")
+		}
+		return
+	}
+	width := 0
+	for _, s := range suggestions {
+		if len(s.Reviewer) > width {
+			width = len(s.Reviewer)
+		}
+	}
+	for _, s := range suggestions {
+		fmt.Fprintf(stdout, "%-*s %6.0f lines (%3.0f%%) [%s]\n",
+			width, s.Reviewer, s.Score, s.Share*100, strings.Join(s.Sources, ", "))
+	}
+}
+
+type jsonReviewer struct {
+	Reviewer string   `json:"reviewer"`
+	Lines    float64  `json:"lines"`
+	Share    float64  `json:"share"`
+	Sources  []string `json:"sources"`
+}
+
+func renderReviewersJSON(suggestions []owners.Suggestion) {
+	out := make([]jsonReviewer, 0, len(suggestions))
+	for _, s := range suggestions {
+		out = append(out, jsonReviewer{Reviewer: s.Reviewer, Lines: s.Score, Share: s.Share, Sources: s.Sources})
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(map[string]interface{}{"reviewers": out}); err != nil {
+		fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+		os.Exit(exitRuntimeError)
+	}
+}
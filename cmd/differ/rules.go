@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jbonatakis/differ/internal/classify"
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/pkg/differ"
+	"github.com/spf13/cobra"
+)
+
+func newRulesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Inspect classification rules",
+	}
+	cmd.AddCommand(newRulesExportCmd())
+	return cmd
+}
+
+func newRulesExportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export",
+		Short: "Print the effective classification rules as JSON",
+		Long: `Print the fully merged classification rule set (built-in heuristics plus
+custom categories from global, repo, and per-directory config, and the
+linguist attributes set in .gitattributes files) as JSON, so other tools can
+replicate differ's classification exactly.`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.Load(topLevel(gitdiff.DefaultRunner), config.Config{})
+			if err == nil {
+				cfg, err = differ.WithScopes(gitdiff.DefaultRunner, cfg)
+			}
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: loading config: %v\n", err)
+				os.Exit(exitInvalidConfig)
+			}
+			classifier := classify.New(cfg)
+			attrs, err := attributeRules(gitdiff.DefaultRunner)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: reading .gitattributes: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			classifier.SetAttributeRules(attrs)
+
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(classifier.Rules()); err != nil {
+				fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			return nil
+		},
+	}
+}
+
+// attributeRules returns the linguist attributes set in the repository's
+// .gitattributes files. Outside a git repository there are none.
+func attributeRules(runner gitdiff.CommandRunner) ([]classify.AttributeRule, error) {
+	paths, err := gitdiff.ListFiles(runner, "**/.gitattributes")
+	if err != nil {
+		return nil, nil
+	}
+	top, err := gitdiff.TopLevel(runner)
+	if err != nil {
+		return nil, nil
+	}
+	var rules []classify.AttributeRule
+	for _, p := range paths {
+		data, err := os.ReadFile(filepath.Join(top, filepath.FromSlash(p)))
+		if err != nil {
+			// Listed files deleted from the working tree set nothing.
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		rules = append(rules, classify.ParseAttributeRules(p, data)...)
+	}
+	return rules, nil
+}
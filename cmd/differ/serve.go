@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/serve"
+	"github.com/jbonatakis/differ/pkg/differ"
+	"github.com/spf13/cobra"
+)
+
+func newServeCmd() *cobra.Command {
+	var addr string
+
+	cmd := &cobra.Command{
+		Use:   "serve [--addr :8080]",
+		Short: "Serve churn summaries of the current repository over HTTP",
+		Long: `Serve a JSON API and a minimal HTML dashboard for the repository in the
+current directory. Each request runs the analysis on demand, so dashboards
+can query a checkout without invoking the CLI.
+
+Endpoints:
+  GET /         dashboard with a form for base and head
+  GET /summary  JSON summary, as with --format json
+  GET /report   HTML report with the file list
+
+/summary and /report take base, head, range, path, include, exclude,
+category (the last four repeatable), and empty as query parameters.
+
+Examples:
+  differ serve --addr :8080
+  curl 'localhost:8080/summary?base=main&head=HEAD'
+  curl 'localhost:8080/summary?range=v1.2.0..v1.3.0&category=source'`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := os.Getwd()
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			s := &serve.Server{
+				Analyze: func(q serve.Query) (output.Summary, error) {
+					return differ.Analyze(differ.Options{
+						Dir:        dir,
+						Base:       q.Base,
+						Head:       q.Head,
+						RevRange:   q.RevRange,
+						Pathspecs:  q.Pathspecs,
+						Include:    q.Include,
+						Exclude:    q.Exclude,
+						Categories: q.Categories,
+						Empty:      q.Empty,
+					})
+				},
+				Log: stderr,
+			}
+			srv := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_ = srv.Shutdown(shutdown)
+			}()
+
+			fmt.Fprintf(stderr, "Serving %s on %s\n", dir, addr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", ":8080", "`address` to listen on")
+
+	return cmd
+}
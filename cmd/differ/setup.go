@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/setup"
+	"github.com/spf13/cobra"
+)
+
+func newSetupCmd() *cobra.Command {
+	var (
+		yes    bool
+		dryRun bool
+		hook   bool
+		ci     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "setup",
+		Short: "Inspect the repository and create a tailored .differ.yml",
+		Long: `Inspect the repository's files (languages, test conventions, directories of
+generated code or tests the built-in rules miss, and the default branch),
+then walk through a proposed .differ.yml and optionally install a pre-push
+hook and a GitHub Actions workflow that report churn.
+
+Each step asks for confirmation. With --yes, every suggestion is accepted
+without asking and .differ.yml is written unless one exists; the hook and
+workflow are installed only with --hook and --ci. Existing hooks and
+workflows are never overwritten.
+
+Examples:
+  differ setup
+  differ setup --dry-run
+  differ setup --yes --hook --ci`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runner := gitdiff.DefaultRunner
+			top, err := gitdiff.TopLevel(runner)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			files, err := gitdiff.ListFiles(runner, "**")
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			report := setup.Inspect(files)
+			branch := gitdiff.DefaultBranch(runner)
+			printReport(stdout, report, branch)
+
+			p := prompter{in: bufio.NewReader(cmd.InOrStdin()), out: stdout, yes: yes || dryRun}
+			var accepted []setup.Suggestion
+			for _, s := range report.Suggestions {
+				q := fmt.Sprintf("Classify %s (%d %s) as %s?", s.Pattern, s.Files, plural(s.Files, "file"), s.Category)
+				if p.confirm(q, true) {
+					accepted = append(accepted, s)
+				}
+			}
+
+			var proposal bytes.Buffer
+			if err := setup.WriteConfig(&proposal, accepted); err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			fmt.Fprintf(stdout, "\nProposed .differ.yml:\n\n%s\n", proposal.String())
+			if dryRun {
+				return nil
+			}
+
+			configPath := filepath.Join(top, ".differ.yml")
+			_, statErr := os.Stat(configPath)
+			exists := statErr == nil
+			write := !exists && p.confirm("Write .differ.yml?", true)
+			if exists && !yes {
+				write = p.confirm(".differ.yml exists. Overwrite it?", false)
+			}
+			if write {
+				if err := os.WriteFile(configPath, proposal.Bytes(), 0o644); err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				fmt.Fprintln(stdout, "Wrote .differ.yml")
+			} else if exists {
+				fmt.Fprintln(stdout, "Kept the existing .differ.yml")
+			}
+
+			if hook || (!yes && p.confirm("Install a pre-push hook that prints the churn of pushed branches?", false)) {
+				hooksDir, err := hooksPath(runner, top)
+				if err == nil {
+					err = installFile(filepath.Join(hooksDir, "pre-push"), setup.HookScript(branch), 0o755)
+				}
+				reportInstall(stdout, "pre-push hook", err)
+			}
+			if ci || (!yes && p.confirm("Add a GitHub Actions workflow that reports the churn of pull requests?", false)) {
+				workflow := filepath.Join(top, ".github", "workflows", "differ.yml")
+				_, name, _ := strings.Cut(branch, "/")
+				if name == "" {
+					name = branch
+				}
+				reportInstall(stdout, ".github/workflows/differ.yml", installFile(workflow, setup.GitHubWorkflow(name), 0o644))
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&yes, "yes", "y", false, "accept all suggestions without prompting")
+	flags.BoolVar(&dryRun, "dry-run", false, "print the proposed .differ.yml without writing anything")
+	flags.BoolVar(&hook, "hook", false, "install the pre-push hook without prompting")
+	flags.BoolVar(&ci, "ci", false, "add the GitHub Actions workflow without prompting")
+
+	return cmd
+}
+
+// printReport prints what setup found in the repository.
+func printReport(w io.Writer, r setup.Report, branch string) {
+	fmt.Fprintf(w, "Inspected %d %s.\n", r.Files, plural(r.Files, "file"))
+	if len(r.Languages) > 0 {
+		fmt.Fprintf(w, "Languages: %s\n", formatCounts(r.Languages, 5))
+	}
+	if len(r.TestConventions) > 0 {
+		fmt.Fprintf(w, "Test conventions: %s\n", formatCounts(r.TestConventions, 5))
+	}
+	if branch == "" {
+		branch = "none found"
+	}
+	fmt.Fprintf(w, "Default branch: %s\n", branch)
+	if len(r.Suggestions) == 0 {
+		fmt.Fprintln(w, "The built-in rules cover this repository; no category patterns are needed.")
+	}
+}
+
+// formatCounts lists up to max counts as "Go (12), Python (3)".
+func formatCounts(counts []setup.Count, max int) string {
+	parts := make([]string, 0, max+1)
+	for i, c := range counts {
+		if i == max {
+			parts = append(parts, fmt.Sprintf("and %d more", len(counts)-max))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d)", c.Name, c.Files))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// hooksPath returns the directory git runs hooks from, honoring
+// core.hooksPath.
+func hooksPath(runner gitdiff.CommandRunner, top string) (string, error) {
+	out, err := runner.Run("git", "-C", top, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", fmt.Errorf("locating hooks directory: %w", err)
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(top, dir)
+	}
+	return dir, nil
+}
+
+var errFileExists = errors.New("already exists; left unchanged")
+
+// installFile writes content to path, creating its directory, unless path
+// already exists.
+func installFile(path, content string, perm os.FileMode) error {
+	if _, err := os.Lstat(path); err == nil {
+		return errFileExists
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), perm)
+}
+
+// reportInstall prints the outcome of installing name. Failures are not
+// fatal: the config has already been written.
+func reportInstall(w io.Writer, name string, err error) {
+	switch {
+	case errors.Is(err, errFileExists):
+		fmt.Fprintf(w, "Skipped %s: %v\n", name, err)
+	case err != nil:
+		fmt.Fprintf(stderr, "Error: installing %s: %v\n", name, err)
+	default:
+		fmt.Fprintf(w, "Installed %s\n", name)
+	}
+}
+
+// prompter asks yes/no questions. When yes is set, or input has ended, it
+// takes the default answer without asking.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	yes bool
+}
+
+func (p prompter) confirm(question string, def bool) bool {
+	if p.yes {
+		return def
+	}
+	choices := "[y/N]"
+	if def {
+		choices = "[Y/n]"
+	}
+	fmt.Fprintf(p.out, "%s %s ", question, choices)
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(p.out)
+		return def
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	}
+	return def
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
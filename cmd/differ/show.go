@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/spf13/cobra"
+)
+
+func newShowCmd() *cobra.Command {
+	var (
+		empty     string
+		list      bool
+		listOnly  bool
+		format    string
+		include   []string
+		exclude   []string
+		category  []string
+		sort      string
+		net       bool
+		top       int
+		topGlob   bool
+		groupBy   string
+		color     string
+		ignoreWS  bool
+		diffAlgo  string
+		moves     bool
+		shebang   bool
+		fromStdin bool
+		jsonCats  string
+		compact   bool
+		noHeader  bool
+		icons     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "show [commit...] [flags] [-- pathspec...]",
+		Short: "Report the churn of individual commits",
+		Long: `Report the churn each commit introduced, like git show: the commit is
+compared against its first parent, so a merge shows what it brought into the
+branch, and a root commit is compared against the empty tree. With no
+commits, HEAD is shown.
+
+Each commit is reported separately. Text output starts each report with a
+"commit <sha> <subject>" line; other formats write one document per commit,
+with the full SHA in meta.head. With --stdin, commits are read one per line
+from standard input (only the first word of each line is used), so the
+output of git log or git rev-list can be piped in.
+
+Examples:
+  differ show
+  differ show v1.2.0 -l
+  git rev-list --no-merges main..HEAD | differ show --stdin --format json`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runner := gitdiff.DefaultRunner
+			opts := runOpts{
+				empty:    empty,
+				list:     list,
+				listOnly: listOnly,
+				format:   format,
+				include:  include,
+				exclude:  exclude,
+				category: category,
+				sort:     sort,
+				top:      top,
+				topGlob:  topGlob,
+				groupBy:  groupBy,
+				color:    color,
+				diffAlgo: diffAlgo,
+				moves:    moves,
+				shebang:  shebang,
+				jsonCats: jsonCats,
+				compact:  compact,
+				noHeader: noHeader,
+				runner:   runner,
+			}
+			if cmd.Flags().Changed("ignore-whitespace") {
+				opts.ignoreWS = &ignoreWS
+			}
+			validateOpts(opts)
+
+			revs, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				revs, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if fromStdin {
+				if len(revs) > 0 {
+					fmt.Fprintln(stderr, "Error: --stdin cannot be combined with commit arguments")
+					os.Exit(exitRuntimeError)
+				}
+				var err error
+				if revs, err = readRevs(cmd.InOrStdin()); err != nil {
+					fmt.Fprintf(stderr, "Error: reading commits: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+			} else if len(revs) == 0 {
+				revs = []string{"HEAD"}
+			}
+
+			renderer, _ := output.LookupRenderer(format)
+			for i, rev := range revs {
+				sha, err := gitdiff.ResolveCommit(runner, rev)
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				summary, cfg := analyze(opts, sha, pathspecs)
+
+				if format == "text" {
+					if i > 0 {
+						fmt.Fprintln(stdout)
+					}
+					fmt.Fprintf(stdout, "commit %s %s\n", sha, commitSubject(runner, sha))
+				}
+				err = renderer.Render(stdout, summary, output.Options{
+					List:           list || top > 0,
+					ListOnly:       listOnly,
+					Sort:           cfg.Sort,
+					NoColor:        !output.Colorize(color, os.Stdout),
+					Net:            net || cfg.Sort == "net",
+					Top:            top,
+					TopGlobal:      topGlob,
+					GroupBy:        groupBy,
+					JSONCategories: jsonCats,
+					Compact:        compact,
+					NoHeader:       noHeader,
+					Icons:          categoryIcons(icons, cfg.CategoryIcons),
+				})
+				if err != nil {
+					fmt.Fprintf(stderr, "Error: rendering %s: %v\n", format, err)
+					os.Exit(exitRuntimeError)
+				}
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.BoolVarP(&list, "list", "l", false, "show summary plus per-file list")
+	flags.BoolVarP(&listOnly, "list-only", "L", false, "show per-file list only")
+	flags.StringVar(&format, "format", "text", "output format ("+strings.Join(output.Formats(), "|")+")")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&category, "category", nil, "restrict to category (docs|tests|source|ci|i18n|generated|other, repeatable)")
+	flags.StringVar(&sort, "sort", "churn", "file list ordering ("+strings.Join(output.SortModes, "|")+")")
+	flags.BoolVar(&net, "net", false, "show net lines (added minus deleted) per category and file")
+	flags.IntVar(&top, "top", 0, "list only the `N` highest-churn files per group (implies -l)")
+	flags.BoolVar(&topGlob, "top-global", false, "apply --top to the whole file list instead of per group")
+	flags.StringVar(&groupBy, "group-by", "category", "file list grouping ("+strings.Join(output.GroupModes, "|")+")")
+	flags.StringVar(&jsonCats, "json-categories", output.JSONCategoriesMap, "how JSON output writes by_category ("+strings.Join(output.JSONCategoryModes, "|")+"): array lists categories in report order")
+	flags.BoolVar(&compact, "compact", false, "write each JSON document on a single line (JSON Lines)")
+	flags.BoolVar(&noHeader, "no-header", false, "leave the header row out of table and csv output")
+	flags.BoolVar(&icons, "icons", false, "show an icon before each category in text and markdown output")
+	addColorFlag(cmd, &color)
+	flags.BoolVarP(&ignoreWS, "ignore-whitespace", "w", false, "ignore whitespace when comparing lines (git diff -w)")
+	flags.StringVar(&diffAlgo, "diff-algorithm", "", "git diff algorithm ("+strings.Join(gitdiff.DiffAlgorithms, "|")+"); default: git's diff.algorithm setting")
+	flags.BoolVar(&moves, "detect-moves", false, "report lines that moved between files separately instead of as churn")
+	flags.BoolVar(&shebang, "shebang", false, "detect the language of extensionless files from their #! line")
+	flags.BoolVar(&fromStdin, "stdin", false, "read commits from standard input, one per line")
+	_ = cmd.RegisterFlagCompletionFunc("format", completeFormats)
+
+	return cmd
+}
+
+// readRevs returns the first word of each non-blank line of r.
+func readRevs(r io.Reader) ([]string, error) {
+	var revs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			revs = append(revs, fields[0])
+		}
+	}
+	return revs, scanner.Err()
+}
+
+// commitSubject returns the subject line of commit sha, or "" if git cannot
+// read it.
+func commitSubject(runner gitdiff.CommandRunner, sha string) string {
+	out, err := runner.Run("git", "log", "-1", "--format=%s", sha)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/site"
+	"github.com/jbonatakis/differ/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+func newSiteCmd() *cobra.Command {
+	var (
+		db    string
+		out   string
+		title string
+		top   int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "site --db <ledger> --out <dir>",
+		Short: "Render recorded history as a static HTML dashboard",
+		Long: `Render the runs recorded with --record into a static HTML site: a
+repository leaderboard and fleet-wide hotspots on the index page, and a page
+per repository with its churn trend, category breakdown, and hotspots. The
+output needs no server and can be published as-is, e.g. to GitHub Pages.
+
+Examples:
+  differ main...HEAD --record churn.db
+  differ site --db churn.db --out site/`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if db == "" || out == "" {
+				fmt.Fprintln(stderr, "Error: --db and --out are required")
+				os.Exit(exitRuntimeError)
+			}
+			records, err := ledger.Read(db)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: reading history: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			records = ledger.Runs(records)
+			if len(records) == 0 {
+				fmt.Fprintf(stderr, "Error: %s has no recorded runs\n", db)
+				os.Exit(exitRuntimeError)
+			}
+			if err := site.Build(out, records, site.Options{Title: title, Top: top}); err != nil {
+				fmt.Fprintf(stderr, "Error: building site: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&db, "db", "", "history `file` written by --record")
+	flags.StringVar(&out, "out", "", "output `dir`ectory for the generated site")
+	flags.StringVar(&title, "title", "", "site title (default \"Churn dashboard\")")
+	flags.IntVar(&top, "top", 20, "number of hotspot rows per table")
+
+	return cmd
+}
+
+// recordRun appends summary to the history ledger at path, tagged with the
+// repository name (the working tree's directory name) and the head commit.
+func recordRun(path string, runner gitdiff.CommandRunner, summary output.Summary) error {
+	return ledger.Append(path, newRecord(runner, summary))
+}
+
+// newRecord builds the ledger record of summary for the current repository.
+func newRecord(runner gitdiff.CommandRunner, summary output.Summary) ledger.Record {
+	rec := ledger.Record{
+		RecordedAt: time.Now().UTC(),
+		Snapshot:   snapshot.FromSummary(summary),
+	}
+	rec.Repo = repoName(runner)
+	// Worktree and index heads have no commit; fall back to HEAD.
+	if sha, err := gitdiff.ResolveCommit(runner, summary.Meta.Head); err == nil {
+		rec.HeadSHA = sha
+	} else if sha, err := gitdiff.ResolveCommit(runner, "HEAD"); err == nil {
+		rec.HeadSHA = sha
+	}
+	return rec
+}
+
+// repoName identifies the current repository in the ledger by its working
+// tree's directory name.
+func repoName(runner gitdiff.CommandRunner) string {
+	top, err := gitdiff.TopLevel(runner)
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(top)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/pkg/differ"
+	"github.com/spf13/cobra"
+)
+
+func newStackCmd() *cobra.Command {
+	var (
+		empty    string
+		list     bool
+		format   string
+		include  []string
+		exclude  []string
+		category []string
+		sort     string
+		color    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "stack [base branch...] [flags] [-- pathspec...]",
+		Short: "Report incremental churn for each layer of a stacked branch chain",
+		Long: `Report churn per layer of a stack of branches, where each layer is
+compared against the branch below it (three-dot, so only that layer's own
+commits count) instead of against the base.
+
+Branches are given bottom to top, starting with the base. With no branches,
+the stack is inferred from the current branch by following upstream tracking
+branches that point at local branches (as set by 'git branch -u parent child'),
+ending at the first remote or untracked base; if the current branch has no
+local upstream, the auto-detected base is used.
+
+Examples:
+  differ stack main feat-a feat-b feat-c
+  differ stack -l
+  differ stack --format json`,
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runner := gitdiff.DefaultRunner
+			opts := runOpts{
+				empty:    empty,
+				list:     list,
+				format:   format,
+				include:  include,
+				exclude:  exclude,
+				category: category,
+				sort:     sort,
+				color:    color,
+				runner:   runner,
+			}
+			validateOpts(opts)
+
+			branches, pathspecs := args, []string(nil)
+			if dashIdx := cmd.ArgsLenAtDash(); dashIdx >= 0 {
+				branches, pathspecs = args[:dashIdx], args[dashIdx:]
+			}
+			if len(branches) == 1 {
+				fmt.Fprintln(stderr, "Error: stack needs a base and at least one branch")
+				os.Exit(exitRuntimeError)
+			}
+			if len(branches) == 0 {
+				branches = inferStack(runner)
+			}
+
+			layers := make([]output.Layer, 0, len(branches)-1)
+			var cfgSort string
+			for i := 1; i < len(branches); i++ {
+				layerOpts := opts
+				layerOpts.base, layerOpts.head = branches[i-1], branches[i]
+				summary, cfg := analyze(layerOpts, "", pathspecs)
+				cfgSort = cfg.Sort
+				layers = append(layers, output.Layer{Name: branches[i], Summary: summary})
+			}
+
+			if format == "json" {
+				if err := output.RenderLayersJSON(stdout, layers); err != nil {
+					fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				return nil
+			}
+			output.RenderLayersText(stdout, layers, output.Options{
+				List:    list,
+				Sort:    cfgSort,
+				NoColor: !output.Colorize(color, os.Stdout),
+				Net:     cfgSort == "net",
+			})
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&empty, "empty", "exclude", "count empty/whitespace-only changed lines (include|exclude)")
+	flags.BoolVarP(&list, "list", "l", false, "show summary plus per-file list for each layer")
+	flags.StringVar(&format, "format", "text", "output format (text|json)")
+	flags.StringArrayVar(&include, "include", nil, "include path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&exclude, "exclude", nil, "exclude path glob, or category:glob to apply it to one category (repeatable)")
+	flags.StringArrayVar(&category, "category", nil, "restrict to category (docs|tests|source|ci|i18n|generated|other, repeatable)")
+	flags.StringVar(&sort, "sort", "churn", "file list ordering ("+strings.Join(output.SortModes, "|")+")")
+	addColorFlag(cmd, &color)
+
+	return cmd
+}
+
+// inferStack builds the branch chain for the current branch from upstream
+// tracking configuration, exiting with exitRuntimeError if it cannot.
+func inferStack(runner gitdiff.CommandRunner) []string {
+	branch, err := gitdiff.CurrentBranch(runner)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v; pass the stack branches explicitly\n", err)
+		os.Exit(exitRuntimeError)
+	}
+	chain := gitdiff.UpstreamChain(runner, branch)
+	if len(chain) > 1 {
+		return chain
+	}
+	refRange, err := gitdiff.ResolveRefs(runner, "", "", "")
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		os.Exit(exitRuntimeError)
+	}
+	base, _ := differ.ParseRefRange(refRange)
+	return []string{base, branch}
+}
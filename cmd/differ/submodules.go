@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/parser"
+	"github.com/jbonatakis/differ/pkg/differ"
+)
+
+// submoduleModes lists the accepted --submodules values: pointer only
+// reports which submodules moved, recurse also analyzes each one.
+var submoduleModes = []string{"pointer", "recurse"}
+
+// submodules lists the submodules in summary whose pointer moved, with the
+// commits from parsed. With recurse, each one checked out under top is
+// analyzed for the range between its commits, with its own config and the
+// same empty mode, whitespace, algorithm, and categories; those that cannot
+// be are returned as info warnings and listed without churn.
+func submodules(top string, parsed []parser.FileStat, summary output.Summary, cfg config.Config, categories []string, recurse bool) ([]output.Submodule, []output.Warning) {
+	kept := make(map[string]bool)
+	for _, f := range summary.FileStats {
+		if f.Status == output.StatusSubmodule {
+			kept[f.Path] = true
+		}
+	}
+
+	var subs []output.Submodule
+	var warnings []output.Warning
+	for _, fs := range parsed {
+		// A submodule with only local changes has not moved.
+		if !kept[fs.Path] || fs.OldCommit == fs.NewCommit {
+			continue
+		}
+		sub := output.Submodule{Path: fs.Path, OldCommit: fs.OldCommit, NewCommit: fs.NewCommit}
+		if recurse && fs.OldCommit != "" && fs.NewCommit != "" {
+			if err := analyzeSubmodule(&sub, filepath.Join(top, filepath.FromSlash(fs.Path)), cfg, categories); err != nil {
+				warnings = append(warnings, output.Warning{
+					Severity: output.SeverityInfo,
+					Rule:     "submodule",
+					Message:  fmt.Sprintf("could not analyze submodule %s: %v", fs.Path, err),
+				})
+			}
+		}
+		subs = append(subs, sub)
+	}
+	return subs, warnings
+}
+
+// analyzeSubmodule fills in sub's churn from the submodule checked out in
+// dir. Submodules nested inside it are not followed.
+func analyzeSubmodule(sub *output.Submodule, dir string, cfg config.Config, categories []string) error {
+	runner := gitdiff.DirRunner{Dir: dir}
+	// An uninitialized submodule is an empty directory inside the parent
+	// repository.
+	if prefix, err := gitdiff.Prefix(runner); err != nil || prefix != "" {
+		return fmt.Errorf("not checked out")
+	}
+
+	subCfg, err := config.Load(dir, config.Config{
+		Empty:            cfg.Empty,
+		IgnoreWhitespace: cfg.IgnoreWhitespace,
+		DiffAlgorithm:    cfg.DiffAlgorithm,
+	})
+	if err != nil {
+		return err
+	}
+	subCfg, err = differ.WithScopes(runner, subCfg)
+	if err != nil {
+		return err
+	}
+
+	diffOpts := gitdiff.DiffOptions{
+		IgnoreWhitespace: subCfg.IgnoreWhitespace != nil && *subCfg.IgnoreWhitespace,
+		Algorithm:        subCfg.DiffAlgorithm,
+	}
+	parsed, err := diffStats(runner, sub.OldCommit+".."+sub.NewCommit, nil, diffOpts, parser.ParseOptions{Empty: subCfg.Empty})
+	if err != nil {
+		return err
+	}
+	summary := differ.Summarize(runner, parsed, subCfg, categories, nil)
+	sub.Totals = &summary.Totals
+	sub.CategoryTotals = summary.CategoryTotals
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/jbonatakis/differ/internal/trend"
+	"github.com/spf13/cobra"
+)
+
+func newTrendCmd() *cobra.Command {
+	var (
+		db     string
+		repo   string
+		period string
+		last   int
+		width  int
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "trend --db <ledger>",
+		Short: "Chart recorded churn per category over time",
+		Long: `Read the runs recorded with --record and print the churn of each category
+per week, with a bar chart of the totals drawn in plain ASCII. Each category
+is drawn with its own letter, listed in a legend below the chart.
+
+--period groups runs by day, week, or month instead, or lists every run on
+its own. Periods without recorded runs are shown empty, so gaps stand out.
+Runs are those of the current repository unless --repo names another;
+outside a repository, those of every repository in the ledger are combined.
+Release records written by 'differ badge --db' are left out.
+
+Examples:
+  differ trend --db churn.db
+  differ trend --db sqlite://churn.sqlite --period month --last 0
+  differ trend --db churn.db --repo api --format json`,
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if db == "" {
+				fmt.Fprintln(stderr, "Error: --db is required")
+				os.Exit(exitRuntimeError)
+			}
+			if !slices.Contains(trend.Periods, period) {
+				fmt.Fprintf(stderr, "Error: --period must be one of %s, got %q\n", strings.Join(trend.Periods, ", "), period)
+				os.Exit(exitInvalidConfig)
+			}
+			if format != "text" && format != "json" {
+				fmt.Fprintf(stderr, "Error: --format must be 'text' or 'json', got %q\n", format)
+				os.Exit(exitInvalidConfig)
+			}
+			if last < 0 || width < 1 {
+				fmt.Fprintln(stderr, "Error: --last must be at least 0 and --width at least 1")
+				os.Exit(exitInvalidConfig)
+			}
+
+			records, err := ledger.Read(db)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: reading history: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			if repo == "" {
+				repo = repoName(gitdiff.DefaultRunner)
+			}
+			var runs []ledger.Record
+			for _, rec := range ledger.Runs(records) {
+				if repo == "" || rec.Repo == repo {
+					runs = append(runs, rec)
+				}
+			}
+			if len(runs) == 0 {
+				if repo != "" {
+					fmt.Fprintf(stderr, "Error: %s has no recorded runs for %s\n", db, repo)
+				} else {
+					fmt.Fprintf(stderr, "Error: %s has no recorded runs\n", db)
+				}
+				os.Exit(exitRuntimeError)
+			}
+
+			points, err := trend.Build(runs, period)
+			if err != nil {
+				fmt.Fprintf(stderr, "Error: %v\n", err)
+				os.Exit(exitRuntimeError)
+			}
+			if last > 0 && len(points) > last {
+				points = points[len(points)-last:]
+			}
+
+			if format == "json" {
+				if err := trend.RenderJSON(stdout, repo, period, points); err != nil {
+					fmt.Fprintf(stderr, "Error: rendering JSON: %v\n", err)
+					os.Exit(exitRuntimeError)
+				}
+				return nil
+			}
+			trend.RenderText(stdout, points, width)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&db, "db", "", "history `file` written by --record (sqlite://path for a SQLite database)")
+	flags.StringVar(&repo, "repo", "", "repository to chart, as named in the ledger (default: the current repository)")
+	flags.StringVar(&period, "period", trend.PeriodWeek, "group runs by "+strings.Join(trend.Periods, ", "))
+	flags.IntVar(&last, "last", 12, "show only the latest N periods (0 for all)")
+	flags.IntVar(&width, "width", 40, "width of the longest bar, in characters")
+	flags.StringVar(&format, "format", "text", "output format (text|json)")
+
+	return cmd
+}
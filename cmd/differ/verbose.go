@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+)
+
+// verbosity is the number of -v flags: 1 logs each git command with its
+// duration and the time spent in each pipeline stage, 2 also the commands'
+// directories, output sizes, and error output.
+var verbosity int
+
+// logMu keeps log lines whole when git runs concurrently.
+var logMu sync.Mutex
+
+// logf writes a log line to stderr when verbosity is at least level.
+func logf(level int, format string, args ...any) {
+	if verbosity < level {
+		return
+	}
+	logMu.Lock()
+	defer logMu.Unlock()
+	fmt.Fprintf(stderr, "differ: "+format+"\n", args...)
+}
+
+// traceGit logs every git invocation when verbosity is set.
+func traceGit() {
+	if verbosity == 0 {
+		gitdiff.Trace = nil
+		return
+	}
+	gitdiff.Trace = func(cmd *exec.Cmd) func(int64, error) {
+		start := time.Now()
+		return func(n int64, err error) {
+			details := []string{roundDuration(time.Since(start)).String()}
+			if verbosity >= 2 {
+				details = append(details, formatBytes(n))
+				if cmd.Dir != "" {
+					details = append(details, "in "+cmd.Dir)
+				}
+			}
+			if err != nil {
+				details = append(details, err.Error())
+			}
+			logf(1, "%s (%s)", commandLine(cmd.Args), strings.Join(details, ", "))
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				logf(2, "  %s", strings.TrimSpace(string(exitErr.Stderr)))
+			}
+		}
+	}
+}
+
+// stageTimer returns a function that logs the time since it was last
+// called, or since stageTimer was, as the duration of the named stage.
+func stageTimer() func(stage string) {
+	last := time.Now()
+	return func(stage string) {
+		now := time.Now()
+		logf(1, "stage %s: %s", stage, roundDuration(now.Sub(last)))
+		last = now
+	}
+}
+
+// commandLine formats args for the log, quoting those a shell would split.
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\n'\"\\$*?[]{}()<>|&;`~#") {
+			a = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+		quoted[i] = a
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,92 @@
+// Package annotate flags changed files that exceed churn thresholds as
+// GitHub annotations, written either as workflow commands to an Actions log
+// or as the annotations of a check run.
+package annotate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// Levels are the accepted annotation levels, in GitHub workflow command
+// terms.
+var Levels = []string{"notice", "warning", "error"}
+
+// Thresholds select the files to annotate. A negative threshold disables
+// its check.
+type Thresholds struct {
+	// Churn is the most churn any file may have.
+	Churn int
+	// Generated is the most churn a generated file may have.
+	Generated int
+}
+
+// Annotation is a note on one file.
+type Annotation struct {
+	Path    string
+	Level   string
+	Title   string
+	Message string
+}
+
+// Find returns an annotation at level for each file in summary over a
+// threshold, ordered by path. A generated file over both thresholds gets
+// only the generated-file annotation.
+func Find(summary output.Summary, t Thresholds, level string) []Annotation {
+	var anns []Annotation
+	for _, f := range summary.FileStats {
+		switch {
+		case t.Generated >= 0 && f.Category == "generated" && f.Churn > t.Generated:
+			anns = append(anns, Annotation{
+				Path:    f.Path,
+				Level:   level,
+				Title:   "Generated file changed",
+				Message: fmt.Sprintf("%s of churn (+%d -%d) in a generated file, over the limit of %d. Review the change to its source or generator instead.", lines(f.Churn), f.Added, f.Deleted, t.Generated),
+			})
+		case t.Churn >= 0 && f.Churn > t.Churn:
+			anns = append(anns, Annotation{
+				Path:    f.Path,
+				Level:   level,
+				Title:   "Large change",
+				Message: fmt.Sprintf("%s of churn (+%d -%d), over the limit of %d. Consider splitting this change.", lines(f.Churn), f.Added, f.Deleted, t.Churn),
+			})
+		}
+	}
+	sort.Slice(anns, func(i, j int) bool { return anns[i].Path < anns[j].Path })
+	return anns
+}
+
+func lines(n int) string {
+	if n == 1 {
+		return "1 line"
+	}
+	return fmt.Sprintf("%d lines", n)
+}
+
+// WriteCommands writes anns as GitHub Actions workflow commands, which
+// the runner turns into annotations on the workflow run and on the pull
+// request's changed files.
+func WriteCommands(w io.Writer, anns []Annotation) error {
+	for _, a := range anns {
+		_, err := fmt.Fprintf(w, "::%s file=%s,line=1,title=%s::%s\n",
+			a.Level, escapeProperty(a.Path), escapeProperty(a.Title), escapeData(a.Message))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeData escapes a workflow command message.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
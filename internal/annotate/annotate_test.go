@@ -0,0 +1,59 @@
+package annotate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+func TestFind(t *testing.T) {
+	summary := output.Summary{FileStats: []output.FileStat{
+		{Path: "small.go", Category: "source", Churn: 10},
+		{Path: "zz/big.go", Category: "source", Added: 550, Deleted: 50, Churn: 600},
+		{Path: "api.pb.go", Category: "generated", Added: 3, Churn: 3},
+		{Path: "huge.pb.go", Category: "generated", Added: 900, Churn: 900},
+	}}
+
+	anns := Find(summary, Thresholds{Churn: 500, Generated: 0}, "warning")
+	var got []string
+	for _, a := range anns {
+		got = append(got, a.Path+" "+a.Title)
+	}
+	want := []string{"api.pb.go Generated file changed", "huge.pb.go Generated file changed", "zz/big.go Large change"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("annotation %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	// Disabling the generated check leaves large generated files to the
+	// churn threshold.
+	anns = Find(summary, Thresholds{Churn: 500, Generated: -1}, "notice")
+	if len(anns) != 2 || anns[0].Path != "huge.pb.go" || anns[0].Title != "Large change" || anns[0].Level != "notice" {
+		t.Errorf("with the generated check disabled: %+v", anns)
+	}
+	if anns := Find(summary, Thresholds{Churn: -1, Generated: -1}, "warning"); len(anns) != 0 {
+		t.Errorf("with both checks disabled: %+v", anns)
+	}
+}
+
+func TestWriteCommands(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCommands(&buf, []Annotation{{
+		Path:    "dir/a,b:c.go",
+		Level:   "warning",
+		Title:   "Large change",
+		Message: "100% of\nthe file",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "::warning file=dir/a%2Cb%3Ac.go,line=1,title=Large change::100%25 of%0Athe file\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
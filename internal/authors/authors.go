@@ -0,0 +1,229 @@
+// Package authors aggregates the churn of a range of commits by who wrote
+// them: per author, or per email domain with domains mapped to the
+// organizations behind them.
+package authors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// Ways to group commits.
+const (
+	GroupByAuthor = "author"
+	GroupByDomain = "domain"
+)
+
+// GroupByValues lists the accepted grouping modes.
+var GroupByValues = []string{GroupByAuthor, GroupByDomain}
+
+// Group is the churn of the commits attributed to one author or
+// organization.
+type Group struct {
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"` // grouped by author only
+	Commits int    `json:"commits"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Churn   int    `json:"churn"`
+	// ByCategory is the churn per category, when Aggregate is given a
+	// Classifier.
+	ByCategory map[string]int `json:"by_category,omitempty"`
+}
+
+// A Classifier returns the category of a path.
+type Classifier func(path string) string
+
+// Aggregate sums commits per group, ordered by churn and then name. By
+// author, commits are grouped by email and named after the newest commit.
+// By domain, they are grouped by the organization that Organization maps
+// the email domain to. If classify is not nil, churn is also broken down
+// by category. Commits without files are skipped.
+func Aggregate(commits []history.Commit, groupBy string, orgs map[string]string, classify Classifier) ([]Group, error) {
+	if groupBy != GroupByAuthor && groupBy != GroupByDomain {
+		return nil, fmt.Errorf("unknown grouping %q (want %s)", groupBy, strings.Join(GroupByValues, " or "))
+	}
+	index := make(map[string]int)
+	var groups []Group
+	for _, c := range commits {
+		if len(c.Files) == 0 {
+			continue
+		}
+		key := strings.ToLower(c.AuthorEmail)
+		group := Group{Name: c.AuthorName, Email: c.AuthorEmail}
+		if groupBy == GroupByDomain {
+			key = Organization(Domain(key), orgs)
+			group = Group{Name: key}
+		}
+		i, ok := index[key]
+		if !ok {
+			// Commits arrive newest first, so the first name seen is the
+			// current one.
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, group)
+		}
+		g := &groups[i]
+		g.Commits++
+		for _, f := range c.Files {
+			g.Added += f.Added
+			g.Deleted += f.Deleted
+			g.Churn += f.Added + f.Deleted
+			if classify != nil {
+				if g.ByCategory == nil {
+					g.ByCategory = make(map[string]int)
+				}
+				g.ByCategory[classify(f.Path)] += f.Added + f.Deleted
+			}
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Churn != groups[j].Churn {
+			return groups[i].Churn > groups[j].Churn
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups, nil
+}
+
+// Domain returns the lowercase domain of an email address, or "unknown"
+// if it has none.
+func Domain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return "unknown"
+	}
+	return strings.ToLower(email[at+1:])
+}
+
+// Organization returns the organization orgs maps domain to. A mapping for
+// a parent domain covers its subdomains, so "google.com" also maps
+// "corp.google.com"; the most specific mapping wins. Domains match
+// case-insensitively; if several keys differ only in case, the first in
+// sorted order wins. Unmapped domains are returned as is.
+func Organization(domain string, orgs map[string]string) string {
+	keys := make([]string, 0, len(orgs))
+	for k := range orgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for d := domain; d != ""; {
+		for _, k := range keys {
+			if strings.EqualFold(k, d) {
+				return orgs[k]
+			}
+		}
+		_, parent, ok := strings.Cut(d, ".")
+		if !ok {
+			break
+		}
+		d = parent
+	}
+	return domain
+}
+
+// RenderText writes one aligned line per group.
+func RenderText(w io.Writer, groups []Group) {
+	if len(groups) == 0 {
+		fmt.Fprintln(w, "No commits.")
+		return
+	}
+	nameWidth, addWidth, delWidth := 0, 0, 0
+	for _, g := range groups {
+		nameWidth = max(nameWidth, len(g.label()))
+		addWidth = max(addWidth, len(fmt.Sprint(g.Added)))
+		delWidth = max(delWidth, len(fmt.Sprint(g.Deleted)))
+	}
+	for _, g := range groups {
+		fmt.Fprintf(w, "%-*s  +%-*d -%-*d (%d) [%d %s]\n", nameWidth, g.label(), addWidth, g.Added, delWidth, g.Deleted, g.Churn, g.Commits, commitWord(g.Commits))
+	}
+}
+
+// RenderMarkdown writes groups as a ranked markdown table under a title,
+// with a churn column for each category any group has churn in. Authors
+// are listed by name only, so the table can be posted publicly.
+func RenderMarkdown(w io.Writer, title string, groups []Group) {
+	fmt.Fprintf(w, "## %s\n\n", title)
+	if len(groups) == 0 {
+		fmt.Fprintln(w, "No commits.")
+		return
+	}
+
+	categories := Categories(groups)
+	header := "| # | Contributor | Commits | Churn |"
+	align := "|--:|:--|--:|--:|"
+	for _, key := range categories {
+		header += " " + output.DisplayName(key) + " |"
+		align += "--:|"
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, align)
+	for i, g := range groups {
+		row := fmt.Sprintf("| %d | %s | %d | %d |", i+1, escapeCell(g.Name), g.Commits, g.Churn)
+		for _, key := range categories {
+			row += fmt.Sprintf(" %d |", g.ByCategory[key])
+		}
+		fmt.Fprintln(w, row)
+	}
+}
+
+// escapeCell escapes the characters that would break a markdown table cell.
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// botNameRe matches the author names of well-known bots.
+var botNameRe = regexp.MustCompile(`(?i)(\[bot\]$|^(dependabot|renovate|github-actions|greenkeeper|snyk-bot|pre-commit-ci|mergify|imgbot|allcontributors)\b)`)
+
+// IsBot reports whether an author looks like an automated account, such as
+// "dependabot[bot]" or "renovate-bot".
+func IsBot(name, email string) bool {
+	return botNameRe.MatchString(name) || strings.Contains(strings.ToLower(email), "[bot]@")
+}
+
+// WithoutBots returns the commits not authored by bots.
+func WithoutBots(commits []history.Commit) []history.Commit {
+	var kept []history.Commit
+	for _, c := range commits {
+		if !IsBot(c.AuthorName, c.AuthorEmail) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// label is how text output names the group.
+func (g Group) label() string {
+	if g.Email == "" {
+		return g.Name
+	}
+	return fmt.Sprintf("%s <%s>", g.Name, g.Email)
+}
+
+// RenderJSON writes the groups as a JSON object with a "groups" array.
+func RenderJSON(w io.Writer, groupBy string, groups []Group) error {
+	if groups == nil {
+		groups = []Group{}
+	}
+	out := struct {
+		GroupBy string  `json:"group_by"`
+		Groups  []Group `json:"groups"`
+	}{groupBy, groups}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func commitWord(n int) string {
+	if n == 1 {
+		return "commit"
+	}
+	return "commits"
+}
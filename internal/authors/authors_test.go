@@ -0,0 +1,184 @@
+package authors
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+func commit(name, email string, added, deleted int) history.Commit {
+	return history.Commit{AuthorName: name, AuthorEmail: email, Files: []parser.FileStat{{Path: "a.go", Added: added, Deleted: deleted}}}
+}
+
+func testCommits() []history.Commit {
+	return []history.Commit{
+		commit("Ada L", "ada@corp.example.com", 10, 2),
+		commit("Bob", "bob@other.org", 3, 3),
+		commit("Ada", "Ada@corp.example.com", 5, 0),
+		commit("Cy", "cy@example.com", 1, 0),
+		{AuthorName: "Empty", AuthorEmail: "empty@other.org"}, // no files
+	}
+}
+
+func TestAggregateByAuthor(t *testing.T) {
+	got, err := Aggregate(testCommits(), GroupByAuthor, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Group{
+		{Name: "Ada L", Email: "ada@corp.example.com", Commits: 2, Added: 15, Deleted: 2, Churn: 17},
+		{Name: "Bob", Email: "bob@other.org", Commits: 1, Added: 3, Deleted: 3, Churn: 6},
+		{Name: "Cy", Email: "cy@example.com", Commits: 1, Added: 1, Churn: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestAggregateByDomain(t *testing.T) {
+	got, err := Aggregate(testCommits(), GroupByDomain, map[string]string{"example.com": "Example Inc"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Group{
+		{Name: "Example Inc", Commits: 3, Added: 16, Deleted: 2, Churn: 18},
+		{Name: "other.org", Commits: 1, Added: 3, Deleted: 3, Churn: 6},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	if _, err := Aggregate(nil, "team", nil, nil); err == nil {
+		t.Error("expected an error for an unknown grouping")
+	}
+}
+
+func TestOrganization(t *testing.T) {
+	orgs := map[string]string{"google.com": "Google", "cloud.google.com": "Google Cloud", "Redhat.com": "Red Hat"}
+	tests := map[string]string{
+		"google.com":           "Google",
+		"corp.google.com":      "Google",
+		"eu.cloud.google.com":  "Google Cloud",
+		"redhat.com":           "Red Hat",
+		"notgoogle.com":        "notgoogle.com",
+		"unknown":              "unknown",
+		"users.noreply.github": "users.noreply.github",
+	}
+	for domain, want := range tests {
+		if got := Organization(domain, orgs); got != want {
+			t.Errorf("Organization(%q) = %q, want %q", domain, got, want)
+		}
+	}
+
+	// Keys that differ only in case resolve the same way every time.
+	orgs = map[string]string{"Acme.io": "Acme (old)", "acme.io": "Acme", "ACME.IO": "ACME"}
+	for i := 0; i < 20; i++ {
+		if got := Organization("acme.io", orgs); got != "ACME" {
+			t.Fatalf("Organization with case-colliding keys = %q, want ACME", got)
+		}
+	}
+}
+
+func TestDomain(t *testing.T) {
+	for email, want := range map[string]string{"a@Example.COM": "example.com", "nobody": "unknown", "x@": "unknown"} {
+		if got := Domain(email); got != want {
+			t.Errorf("Domain(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	groups, _ := Aggregate(testCommits(), GroupByDomain, nil, nil)
+	var buf bytes.Buffer
+	RenderText(&buf, groups)
+	want := "corp.example.com  +15 -2 (17) [2 commits]\n" +
+		"other.org         +3  -3 (6) [1 commit]\n" +
+		"example.com       +1  -0 (1) [1 commit]\n"
+	if buf.String() != want {
+		t.Errorf("text:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := RenderJSON(&buf, GroupByDomain, nil); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		GroupBy string  `json:"group_by"`
+		Groups  []Group `json:"groups"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil || out.GroupBy != "domain" || out.Groups == nil {
+		t.Errorf("json = %s (%v)", buf.String(), err)
+	}
+	if !strings.Contains(buf.String(), `"groups": []`) {
+		t.Errorf("expected an empty groups array, got %s", buf.String())
+	}
+}
+
+func TestAggregateByCategory(t *testing.T) {
+	commits := []history.Commit{{
+		AuthorName:  "Ada",
+		AuthorEmail: "ada@example.com",
+		Files: []parser.FileStat{
+			{Path: "main.go", Added: 10, Deleted: 2},
+			{Path: "main_test.go", Added: 4},
+			{Path: "README.md", Added: 1, Deleted: 1},
+		},
+	}}
+	classify := func(path string) string {
+		switch {
+		case strings.HasSuffix(path, "_test.go"):
+			return "tests"
+		case strings.HasSuffix(path, ".md"):
+			return "docs"
+		}
+		return "source"
+	}
+	got, err := Aggregate(commits, GroupByAuthor, nil, classify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"source": 12, "tests": 4, "docs": 2}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].ByCategory, want) || got[0].Churn != 18 {
+		t.Errorf("got %+v, want churn 18 by category %v", got, want)
+	}
+
+	var buf bytes.Buffer
+	RenderMarkdown(&buf, "Top contributors", append(got, Group{Name: "Bo|b", Commits: 1, Churn: 3, ByCategory: map[string]int{"source": 3}}))
+	wantMD := "## Top contributors\n\n" +
+		"| # | Contributor | Commits | Churn | Documentation | Tests | Source |\n" +
+		"|--:|:--|--:|--:|--:|--:|--:|\n" +
+		"| 1 | Ada | 1 | 18 | 2 | 4 | 12 |\n" +
+		"| 2 | Bo\\|b | 1 | 3 | 0 | 0 | 3 |\n"
+	if buf.String() != wantMD {
+		t.Errorf("markdown:\n%s\nwant:\n%s", buf.String(), wantMD)
+	}
+}
+
+func TestIsBot(t *testing.T) {
+	tests := []struct {
+		name, email string
+		want        bool
+	}{
+		{"dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", true},
+		{"renovate-bot", "bot@renovateapp.com", true},
+		{"github-actions", "41898282+github-actions[bot]@users.noreply.github.com", true},
+		{"Some Tool", "12345+some-tool[bot]@users.noreply.github.com", true},
+		{"Ada", "ada@example.com", false},
+		{"Robot Roberts", "robot@example.com", false},
+	}
+	for _, tt := range tests {
+		if got := IsBot(tt.name, tt.email); got != tt.want {
+			t.Errorf("IsBot(%q, %q) = %v, want %v", tt.name, tt.email, got, tt.want)
+		}
+	}
+
+	kept := WithoutBots([]history.Commit{commit("dependabot[bot]", "x[bot]@github.com", 1, 0), commit("Ada", "ada@example.com", 1, 0)})
+	if len(kept) != 1 || kept[0].AuthorName != "Ada" {
+		t.Errorf("WithoutBots kept %+v", kept)
+	}
+}
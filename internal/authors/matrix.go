@@ -0,0 +1,108 @@
+package authors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// Categories returns the categories any group has churn in, in report
+// order.
+func Categories(groups []Group) []string {
+	var categories []string
+	for _, key := range output.DisplayOrder() {
+		for _, g := range groups {
+			if g.ByCategory[key] > 0 {
+				categories = append(categories, key)
+				break
+			}
+		}
+	}
+	return categories
+}
+
+// matrixTable lays out groups against categories: a header row of category
+// names, one row per group with its churn in each category and in total,
+// then the totals per category. The first cell of each row is its label.
+func matrixTable(groups []Group, categories []string) [][]string {
+	header := []string{""}
+	for _, key := range categories {
+		header = append(header, output.DisplayName(key))
+	}
+	rows := [][]string{append(header, "Total")}
+
+	totals := make(map[string]int, len(categories))
+	churn := 0
+	for _, g := range groups {
+		row := []string{g.label()}
+		for _, key := range categories {
+			row = append(row, fmt.Sprint(g.ByCategory[key]))
+			totals[key] += g.ByCategory[key]
+		}
+		rows = append(rows, append(row, fmt.Sprint(g.Churn)))
+		churn += g.Churn
+	}
+
+	row := []string{"Total"}
+	for _, key := range categories {
+		row = append(row, fmt.Sprint(totals[key]))
+	}
+	return append(rows, append(row, fmt.Sprint(churn)))
+}
+
+// RenderMatrixText writes the churn of each group in each category as a
+// table, one row per group and a column per category any group has churn
+// in, with totals. Groups need a by-category breakdown from Aggregate.
+func RenderMatrixText(w io.Writer, groups []Group) {
+	if len(groups) == 0 {
+		fmt.Fprintln(w, "No commits.")
+		return
+	}
+	rows := matrixTable(groups, Categories(groups))
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	for _, row := range rows {
+		cells := []string{fmt.Sprintf("%-*s", widths[0], row[0])}
+		for i, cell := range row[1:] {
+			cells = append(cells, fmt.Sprintf("%*s", widths[i+1], cell))
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+}
+
+// RenderMatrixJSON writes the groups as RenderJSON does, with the matrix's
+// categories listed and every group's by_category holding each of them,
+// zero or not, followed by the churn per category over all groups.
+func RenderMatrixJSON(w io.Writer, groupBy string, groups []Group) error {
+	categories := Categories(groups)
+	if categories == nil {
+		categories = []string{}
+	}
+	rows := make([]Group, len(groups))
+	totals := make(map[string]int, len(categories))
+	for i, g := range groups {
+		byCategory := make(map[string]int, len(categories))
+		for _, key := range categories {
+			byCategory[key] = g.ByCategory[key]
+			totals[key] += g.ByCategory[key]
+		}
+		g.ByCategory = byCategory
+		rows[i] = g
+	}
+	out := struct {
+		GroupBy    string         `json:"group_by"`
+		Categories []string       `json:"categories"`
+		Groups     []Group        `json:"groups"`
+		Totals     map[string]int `json:"totals"`
+	}{groupBy, categories, rows, totals}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,57 @@
+package authors
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRenderMatrix(t *testing.T) {
+	groups := []Group{
+		{Name: "Ada", Email: "ada@example.com", Commits: 2, Churn: 18, ByCategory: map[string]int{"source": 12, "tests": 4, "docs": 2}},
+		{Name: "Bob", Email: "bob@example.com", Commits: 1, Churn: 3, ByCategory: map[string]int{"docs": 3}},
+	}
+
+	var buf bytes.Buffer
+	RenderMatrixText(&buf, groups)
+	want := "                       Documentation  Tests  Source  Total\n" +
+		"Ada <ada@example.com>              2      4      12     18\n" +
+		"Bob <bob@example.com>              3      0       0      3\n" +
+		"Total                              5      4      12     21\n"
+	if buf.String() != want {
+		t.Errorf("text:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := RenderMatrixJSON(&buf, GroupByAuthor, groups); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Categories []string       `json:"categories"`
+		Groups     []Group        `json:"groups"`
+		Totals     map[string]int `json:"totals"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"docs", "tests", "source"}; !reflect.DeepEqual(out.Categories, want) {
+		t.Errorf("categories = %v, want %v", out.Categories, want)
+	}
+	if want := map[string]int{"docs": 3, "tests": 0, "source": 0}; !reflect.DeepEqual(out.Groups[1].ByCategory, want) {
+		t.Errorf("second group by_category = %v, want %v", out.Groups[1].ByCategory, want)
+	}
+	if want := map[string]int{"docs": 5, "tests": 4, "source": 12}; !reflect.DeepEqual(out.Totals, want) {
+		t.Errorf("totals = %v, want %v", out.Totals, want)
+	}
+	// The caller's groups are left as they were.
+	if len(groups[1].ByCategory) != 1 {
+		t.Errorf("RenderMatrixJSON changed its input: %v", groups[1].ByCategory)
+	}
+
+	buf.Reset()
+	RenderMatrixText(&buf, nil)
+	if buf.String() != "No commits.\n" {
+		t.Errorf("empty text = %q", buf.String())
+	}
+}
@@ -0,0 +1,103 @@
+// Package badge renders release size badges: a t-shirt size derived from a
+// release's churn, drawn as a flat SVG or described as a shields.io endpoint.
+package badge
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+)
+
+// Sizes maps churn to t-shirt sizes. A release is the first size whose Max
+// its churn does not exceed; the last size has no upper bound.
+var Sizes = []struct {
+	Name  string
+	Max   int
+	Color string
+}{
+	{"XS", 10, "brightgreen"},
+	{"S", 100, "green"},
+	{"M", 500, "yellowgreen"},
+	{"L", 1000, "yellow"},
+	{"XL", 5000, "orange"},
+	{"XXL", -1, "red"},
+}
+
+// colors are the hex values of the named shields.io colors used by Sizes.
+var colors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+}
+
+// Badge is a two-part label/message badge.
+type Badge struct {
+	Label   string
+	Message string
+	Color   string // a shields.io color name
+}
+
+// ForChurn returns a badge labelled label whose message gives the size and
+// churn, such as "M · 312 lines".
+func ForChurn(label string, churn int) Badge {
+	name, color := Size(churn)
+	return Badge{Label: label, Message: fmt.Sprintf("%s · %d lines", name, churn), Color: color}
+}
+
+// Size returns the t-shirt size for churn and its color.
+func Size(churn int) (name, color string) {
+	for _, s := range Sizes {
+		if s.Max < 0 || churn <= s.Max {
+			return s.Name, s.Color
+		}
+	}
+	last := Sizes[len(Sizes)-1]
+	return last.Name, last.Color
+}
+
+// WriteEndpoint writes b in the shields.io endpoint JSON schema, so a
+// published file can back a https://img.shields.io/endpoint?url=... badge.
+func WriteEndpoint(w io.Writer, b Badge) error {
+	out := struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Label         string `json:"label"`
+		Message       string `json:"message"`
+		Color         string `json:"color"`
+	}{1, b.Label, b.Message, b.Color}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// charWidth approximates the width of a character of 11px Verdana, which
+// badges are conventionally set in.
+const charWidth = 7
+
+// padding is the horizontal space around each half's text.
+const padding = 10
+
+// WriteSVG writes b as a flat SVG badge.
+func WriteSVG(w io.Writer, b Badge) error {
+	lw := len([]rune(b.Label))*charWidth + padding
+	mw := len([]rune(b.Message))*charWidth + padding
+	color, ok := colors[b.Color]
+	if !ok {
+		color = colors["red"]
+	}
+	label, msg := html.EscapeString(b.Label), html.EscapeString(b.Message)
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">
+<title>%[4]s: %[5]s</title>
+<rect width="%[2]d" height="20" fill="#555"/>
+<rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="14">%[4]s</text>
+<text x="%[8]d" y="14">%[5]s</text>
+</g>
+</svg>
+`, lw+mw, lw, mw, label, msg, color, lw/2, lw+mw/2)
+	return err
+}
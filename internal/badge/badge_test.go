@@ -0,0 +1,44 @@
+package badge
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSize(t *testing.T) {
+	tests := map[int]string{0: "XS", 10: "XS", 11: "S", 100: "S", 500: "M", 999: "L", 5000: "XL", 5001: "XXL", 1 << 30: "XXL"}
+	for churn, want := range tests {
+		if got, _ := Size(churn); got != want {
+			t.Errorf("Size(%d) = %q, want %q", churn, got, want)
+		}
+	}
+}
+
+func TestWriteEndpoint(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteEndpoint(&buf, ForChurn("release size", 312)); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["schemaVersion"] != float64(1) || got["label"] != "release size" || got["message"] != "M · 312 lines" || got["color"] != "yellowgreen" {
+		t.Errorf("endpoint = %v", got)
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, Badge{Label: "a<b", Message: "XS · 3 lines", Color: "brightgreen"}); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	for _, want := range []string{`<svg xmlns="http://www.w3.org/2000/svg"`, "a&lt;b", "XS · 3 lines", `fill="#4c1"`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG missing %q:\n%s", want, svg)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package bench generates synthetic unified diffs for benchmarking the
+// analysis pipeline on arbitrary hardware.
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
+)
+
+// Hunk distributions.
+const (
+	// Uniform gives every file the same number of hunks.
+	Uniform = "uniform"
+	// Skewed gives files hunks in proportion to 1/rank, so a few files hold
+	// most of the churn as in typical history.
+	Skewed = "skewed"
+)
+
+// Shape describes the synthetic diff to generate.
+type Shape struct {
+	Files int
+	// Extensions are assigned to files round-robin, such as ".go".
+	Extensions []string
+	// Hunks is the mean number of hunks per file.
+	Hunks int
+	// Lines is the number of lines added and deleted per hunk.
+	Lines        int
+	Distribution string
+	Seed         uint64
+}
+
+// Stats summarizes a generated diff.
+type Stats struct {
+	Files   int
+	Hunks   int
+	Added   int
+	Deleted int
+	Bytes   int64
+}
+
+// contextLines is the number of unchanged lines around each hunk, as in
+// git's default.
+const contextLines = 3
+
+// Validate reports whether s can be generated.
+func (s Shape) Validate() error {
+	switch {
+	case s.Files < 1:
+		return fmt.Errorf("files must be at least 1, got %d", s.Files)
+	case s.Hunks < 1:
+		return fmt.Errorf("hunks must be at least 1, got %d", s.Hunks)
+	case s.Lines < 1:
+		return fmt.Errorf("lines must be at least 1, got %d", s.Lines)
+	case len(s.Extensions) == 0:
+		return fmt.Errorf("at least one extension is required")
+	case s.Distribution != Uniform && s.Distribution != Skewed:
+		return fmt.Errorf("unknown distribution %q (want %s or %s)", s.Distribution, Uniform, Skewed)
+	}
+	return nil
+}
+
+// Generate writes a unified diff with the given shape to w, in the format
+// git diff produces. The same shape always generates the same diff.
+func Generate(w io.Writer, s Shape) (Stats, error) {
+	if err := s.Validate(); err != nil {
+		return Stats{}, err
+	}
+	rng := rand.New(rand.NewPCG(s.Seed, s.Seed^0x9e3779b97f4a7c15))
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	hunks := hunkCounts(s)
+
+	var st Stats
+	for i := 0; i < s.Files; i++ {
+		ext := s.Extensions[i%len(s.Extensions)]
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		path := fmt.Sprintf("pkg%03d/file%05d%s", i%100, i, ext)
+		fmt.Fprintf(cw, "diff --git a/%s b/%s\nindex %07x..%07x 100644\n--- a/%s\n+++ b/%s\n",
+			path, path, rng.Uint32()>>4, rng.Uint32()>>4, path, path)
+
+		// Hunks are spaced so their context never overlaps.
+		line := 1
+		for h := 0; h < hunks[i]; h++ {
+			line += contextLines + rng.IntN(40)
+			span := 2*contextLines + s.Lines
+			fmt.Fprintf(cw, "@@ -%d,%d +%d,%d @@\n", line, span, line, span)
+			for c := 0; c < contextLines; c++ {
+				fmt.Fprintf(cw, " %s\n", sourceLine(rng))
+			}
+			for l := 0; l < s.Lines; l++ {
+				fmt.Fprintf(cw, "-%s\n", sourceLine(rng))
+			}
+			for l := 0; l < s.Lines; l++ {
+				fmt.Fprintf(cw, "+%s\n", sourceLine(rng))
+			}
+			for c := 0; c < contextLines; c++ {
+				fmt.Fprintf(cw, " %s\n", sourceLine(rng))
+			}
+			line += span
+		}
+		st.Files++
+		st.Hunks += hunks[i]
+		st.Added += hunks[i] * s.Lines
+		st.Deleted += hunks[i] * s.Lines
+	}
+
+	if err := cw.w.Flush(); err != nil {
+		return Stats{}, err
+	}
+	if cw.err != nil {
+		return Stats{}, cw.err
+	}
+	st.Bytes = cw.n
+	return st, nil
+}
+
+// hunkCounts returns the number of hunks for each file. Both distributions
+// total Files*Hunks and give every file at least one hunk.
+func hunkCounts(s Shape) []int {
+	counts := make([]int, s.Files)
+	total := s.Files * s.Hunks
+	if s.Distribution == Uniform {
+		for i := range counts {
+			counts[i] = s.Hunks
+		}
+		return counts
+	}
+
+	var harmonic float64
+	for i := 1; i <= s.Files; i++ {
+		harmonic += 1 / float64(i)
+	}
+	// Every file keeps one hunk; the rest are shared out by rank.
+	extra := total - s.Files
+	assigned := 0
+	for i := range counts {
+		counts[i] = 1 + int(float64(extra)/(float64(i+1)*harmonic))
+		assigned += counts[i]
+	}
+	counts[0] += total - assigned
+	return counts
+}
+
+var (
+	identifiers = []string{"value", "count", "result", "config", "index", "buffer", "client", "request", "offset", "name"}
+	callees     = []string{"compute", "lookup", "render", "parse", "validate", "encode", "fetch", "merge"}
+)
+
+// sourceLine returns a plausible line of code.
+func sourceLine(rng *rand.Rand) string {
+	return fmt.Sprintf("\t%s%d := %s(%s, %d)",
+		identifiers[rng.IntN(len(identifiers))], rng.IntN(100),
+		callees[rng.IntN(len(callees))], identifiers[rng.IntN(len(identifiers))], rng.IntN(10000))
+}
+
+// countingWriter counts the bytes written through it and keeps the first
+// error, so Generate can check once at the end.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
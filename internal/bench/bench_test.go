@@ -0,0 +1,112 @@
+package bench
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+func testShape() Shape {
+	return Shape{
+		Files:        20,
+		Extensions:   []string{".go", "py", ".md"},
+		Hunks:        3,
+		Lines:        4,
+		Distribution: Uniform,
+		Seed:         1,
+	}
+}
+
+func TestGenerateParses(t *testing.T) {
+	var buf bytes.Buffer
+	st, err := Generate(&buf, testShape())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Files != 20 || st.Hunks != 60 || st.Added != 240 || st.Deleted != 240 {
+		t.Errorf("stats = %+v", st)
+	}
+	if st.Bytes != int64(buf.Len()) {
+		t.Errorf("Bytes = %d, want %d", st.Bytes, buf.Len())
+	}
+
+	stats, err := parser.Parse(bytes.NewReader(buf.Bytes()), "exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != st.Files {
+		t.Fatalf("parsed %d files, want %d", len(stats), st.Files)
+	}
+	added, deleted := 0, 0
+	for _, fs := range stats {
+		added += fs.Added
+		deleted += fs.Deleted
+	}
+	if added != st.Added || deleted != st.Deleted {
+		t.Errorf("parsed +%d -%d, want +%d -%d", added, deleted, st.Added, st.Deleted)
+	}
+	if !strings.HasSuffix(stats[1].Path, ".py") {
+		t.Errorf("stats[1].Path = %q, want a .py file", stats[1].Path)
+	}
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	var a, b bytes.Buffer
+	if _, err := Generate(&a, testShape()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Generate(&b, testShape()); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != b.String() {
+		t.Error("same shape generated different diffs")
+	}
+
+	other := testShape()
+	other.Seed = 2
+	var c bytes.Buffer
+	if _, err := Generate(&c, other); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() == c.String() {
+		t.Error("different seeds generated the same diff")
+	}
+}
+
+func TestSkewedDistribution(t *testing.T) {
+	s := testShape()
+	s.Distribution = Skewed
+	counts := hunkCounts(s)
+
+	total := 0
+	for i, n := range counts {
+		if n < 1 {
+			t.Errorf("file %d has %d hunks, want at least 1", i, n)
+		}
+		if i > 0 && n > counts[i-1] {
+			t.Errorf("file %d has more hunks than file %d", i, i-1)
+		}
+		total += n
+	}
+	if total != s.Files*s.Hunks {
+		t.Errorf("total hunks = %d, want %d", total, s.Files*s.Hunks)
+	}
+	if counts[0] <= s.Hunks {
+		t.Errorf("first file has %d hunks, want more than the mean %d", counts[0], s.Hunks)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := testShape()
+	s.Distribution = "bimodal"
+	if _, err := Generate(&bytes.Buffer{}, s); err == nil {
+		t.Error("expected error for unknown distribution")
+	}
+	s = testShape()
+	s.Files = 0
+	if err := s.Validate(); err == nil {
+		t.Error("expected error for zero files")
+	}
+}
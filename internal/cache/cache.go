@@ -0,0 +1,112 @@
+// Package cache keeps computed churn summaries on disk so repeated analyses
+// of the same commits under the same settings return instantly.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// formatVersion is mixed into every key, so entries written by an
+// incompatible release are never read.
+const formatVersion = "3"
+
+// MaxAge is how long an unused entry is kept.
+const MaxAge = 30 * 24 * time.Hour
+
+// Dir returns the cache directory: DIFFER_CACHE_DIR if set, else "differ"
+// under the user cache directory (~/.cache/differ on Linux).
+func Dir() (string, error) {
+	if dir := os.Getenv("DIFFER_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "differ"), nil
+}
+
+// Key derives a cache key from everything that determines a summary.
+// Parts are length-prefixed, so no two different lists share a key.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range append([]string{formatVersion}, parts...) {
+		fmt.Fprintf(h, "%d:%s", len(p), p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the summary stored under key. A missing or unreadable entry
+// is a miss. A hit refreshes the entry's age.
+func Get(dir, key string) (output.Summary, bool) {
+	path := filepath.Join(dir, key+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return output.Summary{}, false
+	}
+	var s output.Summary
+	if err := json.Unmarshal(data, &s); err != nil {
+		return output.Summary{}, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return s, true
+}
+
+// Put stores s under key, then removes entries unused for MaxAge. The entry
+// is written to a temporary file and renamed into place, so concurrent runs
+// never read a partial entry.
+func Put(dir, key string, s output.Summary) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, key+".json")); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	prune(dir, time.Now().Add(-MaxAge))
+	return nil
+}
+
+// prune removes entries, and temporary files left by interrupted writes,
+// last used before cutoff.
+func prune(dir string, cutoff time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !(strings.HasSuffix(e.Name(), ".json") || strings.HasSuffix(e.Name(), ".tmp")) {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
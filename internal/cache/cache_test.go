@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+func TestKey(t *testing.T) {
+	if Key("a", "bc") == Key("ab", "c") {
+		t.Error("keys of different part lists collide")
+	}
+	if Key("a", "b") != Key("a", "b") {
+		t.Error("key is not deterministic")
+	}
+}
+
+func TestPutGet(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	key := Key("base", "head")
+	if _, ok := Get(dir, key); ok {
+		t.Fatal("hit in an empty cache")
+	}
+
+	want := output.Summary{
+		Totals:         output.CategoryTotal{Added: 3, Deleted: 1, Churn: 4, FileCount: 1},
+		CategoryTotals: map[string]output.CategoryTotal{"source": {Added: 3, Deleted: 1, Churn: 4, FileCount: 1}},
+		FileStats:      []output.FileStat{{Path: "main.go", Added: 3, Deleted: 1, Churn: 4, Category: "source", Language: "Go"}},
+		Meta:           output.Meta{Base: "main", Head: "HEAD", Empty: "exclude", Pathspecs: []string{"cmd/"}},
+	}
+	if err := Put(dir, key, want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := Get(dir, key)
+	if !ok {
+		t.Fatal("miss after Put")
+	}
+	if got.Totals != want.Totals || len(got.FileStats) != 1 || got.FileStats[0] != want.FileStats[0] ||
+		got.Meta.Base != "main" || len(got.Meta.Pathspecs) != 1 {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// A corrupt entry is a miss.
+	if err := os.WriteFile(filepath.Join(dir, key+".json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Get(dir, key); ok {
+		t.Error("hit on a corrupt entry")
+	}
+}
+
+func TestPutPrunesStaleEntries(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, Key("old")+".json")
+	leftover := filepath.Join(dir, "x.123.tmp")
+	for _, path := range []string{stale, leftover} {
+		if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		old := time.Now().Add(-MaxAge - time.Hour)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Put(dir, Key("new"), output.Summary{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{stale, leftover} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was not pruned", filepath.Base(path))
+		}
+	}
+	if _, ok := Get(dir, Key("new")); !ok {
+		t.Error("new entry missing after prune")
+	}
+}
@@ -0,0 +1,172 @@
+package changelog
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/jbonatakis/differ/internal/history"
+)
+
+// ConventionalCommit is a parsed conventional-commit subject line.
+type ConventionalCommit struct {
+	Type        string
+	Scope       string
+	Breaking    bool
+	Description string
+}
+
+var conventionalRe = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// ParseSubject parses a conventional-commit subject ("type(scope)!: desc").
+// Subjects that do not follow the convention return ok == false.
+func ParseSubject(subject string) (cc ConventionalCommit, ok bool) {
+	m := conventionalRe.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return ConventionalCommit{Description: strings.TrimSpace(subject)}, false
+	}
+	return ConventionalCommit{
+		Type:        strings.ToLower(m[1]),
+		Scope:       m[2],
+		Breaking:    m[3] == "!",
+		Description: m[4],
+	}, true
+}
+
+// Section groups entries under a heading.
+type Section struct {
+	Title   string
+	Entries []Entry
+}
+
+// Entry is a single changelog line derived from a commit.
+type Entry struct {
+	SHA         string
+	Scope       string
+	Description string
+	Areas       []string
+	Added       int
+	Deleted     int
+}
+
+// sectionOrder lists section titles in output order; commit types not listed
+// in typeSections land in "Other".
+var sectionOrder = []string{
+	"Breaking Changes",
+	"Features",
+	"Fixes",
+	"Performance",
+	"Refactors",
+	"Documentation",
+	"Other",
+}
+
+var typeSections = map[string]string{
+	"feat":     "Features",
+	"feature":  "Features",
+	"fix":      "Fixes",
+	"bugfix":   "Fixes",
+	"perf":     "Performance",
+	"refactor": "Refactors",
+	"docs":     "Documentation",
+}
+
+// Build groups commits into changelog sections. areas maps area names to path
+// globs; files matching no area fall back to their top-level directory.
+func Build(commits []history.Commit, areas map[string][]string) []Section {
+	bySection := make(map[string][]Entry)
+	for _, c := range commits {
+		cc, _ := ParseSubject(c.Subject)
+		title, ok := typeSections[cc.Type]
+		if !ok {
+			title = "Other"
+		}
+		if cc.Breaking {
+			title = "Breaking Changes"
+		}
+
+		areaSet := make(map[string]bool)
+		for _, f := range c.Files {
+			areaSet[AreaFor(f.Path, areas)] = true
+		}
+		areaList := make([]string, 0, len(areaSet))
+		for a := range areaSet {
+			areaList = append(areaList, a)
+		}
+		sort.Strings(areaList)
+
+		bySection[title] = append(bySection[title], Entry{
+			SHA:         c.SHA,
+			Scope:       cc.Scope,
+			Description: cc.Description,
+			Areas:       areaList,
+			Added:       c.Added(),
+			Deleted:     c.Deleted(),
+		})
+	}
+
+	var sections []Section
+	for _, title := range sectionOrder {
+		if entries := bySection[title]; len(entries) > 0 {
+			sections = append(sections, Section{Title: title, Entries: entries})
+		}
+	}
+	return sections
+}
+
+// AreaFor returns the configured area whose globs match p, or the path's
+// top-level directory ("." for root files) when none match. Area names are
+// checked in sorted order so the result is deterministic.
+func AreaFor(p string, areas map[string][]string) string {
+	names := make([]string, 0, len(areas))
+	for name := range areas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, glob := range areas[name] {
+			if matched, _ := doublestar.Match(glob, p); matched {
+				return name
+			}
+		}
+	}
+	dir := path.Dir(p)
+	if dir == "." {
+		return "."
+	}
+	return strings.SplitN(dir, "/", 2)[0]
+}
+
+// RenderMarkdown writes sections as a markdown changelog skeleton.
+func RenderMarkdown(w io.Writer, title string, sections []Section) {
+	fmt.Fprintf(w, "## %s\n", title)
+	if len(sections) == 0 {
+		fmt.Fprintln(w, "\nNo changes.")
+		return
+	}
+	for _, s := range sections {
+		fmt.Fprintf(w, "\n### %s\n\n", s.Title)
+		for _, e := range s.Entries {
+			desc := e.Description
+			if e.Scope != "" {
+				desc = fmt.Sprintf("**%s**: %s", e.Scope, desc)
+			}
+			fmt.Fprintf(w, "- %s (%s) — +%d -%d", desc, shortSHA(e.SHA), e.Added, e.Deleted)
+			if len(e.Areas) > 0 {
+				fmt.Fprintf(w, " in %s", strings.Join(e.Areas, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
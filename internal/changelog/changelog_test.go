@@ -0,0 +1,91 @@
+package changelog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+func TestParseSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    ConventionalCommit
+		ok      bool
+	}{
+		{"feat: add compare", ConventionalCommit{Type: "feat", Description: "add compare"}, true},
+		{"fix(parser): handle renames", ConventionalCommit{Type: "fix", Scope: "parser", Description: "handle renames"}, true},
+		{"refactor(api)!: drop v1", ConventionalCommit{Type: "refactor", Scope: "api", Breaking: true, Description: "drop v1"}, true},
+		{"Feat: Upper type", ConventionalCommit{Type: "feat", Description: "Upper type"}, true},
+		{"Update README", ConventionalCommit{Description: "Update README"}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseSubject(tt.subject)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseSubject(%q) = %+v, %v; want %+v, %v", tt.subject, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAreaFor(t *testing.T) {
+	areas := map[string][]string{
+		"cli":  {"cmd/**"},
+		"core": {"internal/**"},
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"cmd/differ/main.go", "cli"},
+		{"internal/output/output.go", "core"},
+		{"docs/usage.md", "docs"},
+		{"README.md", "."},
+	}
+	for _, tt := range tests {
+		if got := AreaFor(tt.path, areas); got != tt.want {
+			t.Errorf("AreaFor(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestBuildAndRender(t *testing.T) {
+	commits := []history.Commit{
+		{SHA: "1111111aaaa", Subject: "feat(output): add compare", Files: []parser.FileStat{
+			{Path: "internal/output/compare.go", Added: 100, Deleted: 0},
+			{Path: "cmd/differ/compare.go", Added: 20, Deleted: 2},
+		}},
+		{SHA: "2222222bbbb", Subject: "fix: off by one", Files: []parser.FileStat{
+			{Path: "internal/parser/parser.go", Added: 1, Deleted: 1},
+		}},
+		{SHA: "3333333cccc", Subject: "feat!: new config format", Files: []parser.FileStat{
+			{Path: "internal/config/config.go", Added: 5, Deleted: 5},
+		}},
+		{SHA: "4444444dddd", Subject: "bump version"},
+	}
+
+	sections := Build(commits, nil)
+	var titles []string
+	for _, s := range sections {
+		titles = append(titles, s.Title)
+	}
+	if got := strings.Join(titles, ","); got != "Breaking Changes,Features,Fixes,Other" {
+		t.Errorf("section order = %s", got)
+	}
+
+	var buf bytes.Buffer
+	RenderMarkdown(&buf, "Changelog", sections)
+	got := buf.String()
+	for _, want := range []string{
+		"## Changelog",
+		"### Features",
+		"- **output**: add compare (1111111) — +120 -2 in cmd, internal",
+		"- off by one (2222222) — +1 -1 in internal",
+		"- bump version (4444444) — +0 -0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
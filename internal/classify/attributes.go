@@ -0,0 +1,97 @@
+package classify
+
+import (
+	"bufio"
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// AttributeRule is a gitattributes line setting one of Attributes, as
+// listed by Rules.
+type AttributeRule struct {
+	// File is the .gitattributes file the line is in, relative to the
+	// repository root; Pattern is relative to its directory.
+	File    string `json:"file"`
+	Pattern string `json:"pattern"`
+	// Attribute is one of Attributes, and Value what git check-attr
+	// reports for it: "set", "unset", "unspecified", or the assigned value.
+	Attribute string `json:"attribute"`
+	Value     string `json:"value"`
+	// Category is the category a true value puts matching paths in; a
+	// false one turns off its built-in heuristics instead.
+	Category string `json:"category"`
+}
+
+// attributeCategories maps Attributes to the category they select.
+var attributeCategories = map[string]string{
+	"linguist-generated":     Generated,
+	"linguist-vendored":      Generated,
+	"linguist-documentation": Docs,
+}
+
+// ParseAttributeRules returns the lines of the gitattributes file data,
+// named file, that set one of Attributes. Macro definitions are skipped.
+func ParseAttributeRules(file string, data []byte) []AttributeRule {
+	var rules []AttributeRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[attr]") {
+			continue
+		}
+		pattern, rest := attributePattern(line)
+		if pattern == "" {
+			continue
+		}
+		for _, field := range strings.Fields(rest) {
+			name, value := field, "set"
+			switch {
+			case strings.HasPrefix(field, "-"):
+				name, value = field[1:], "unset"
+			case strings.HasPrefix(field, "!"):
+				name, value = field[1:], "unspecified"
+			default:
+				if n, v, ok := strings.Cut(field, "="); ok {
+					name, value = n, v
+				}
+			}
+			if !slices.Contains(Attributes, name) {
+				continue
+			}
+			rules = append(rules, AttributeRule{
+				File:      file,
+				Pattern:   pattern,
+				Attribute: name,
+				Value:     value,
+				Category:  attributeCategories[name],
+			})
+		}
+	}
+	return rules
+}
+
+// attributePattern splits a gitattributes line into its pattern, which may
+// be quoted as a C string, and the attributes after it.
+func attributePattern(line string) (pattern, rest string) {
+	if strings.HasPrefix(line, `"`) {
+		if quoted, err := strconv.QuotedPrefix(line); err == nil {
+			if p, err := strconv.Unquote(quoted); err == nil {
+				return p, line[len(quoted):]
+			}
+		}
+	}
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+	return line[:i], line[i:]
+}
+
+// SetAttributeRules supplies the gitattributes lines that Rules lists, as
+// returned by ParseAttributeRules. Classification itself uses the values
+// supplied with SetAttributes.
+func (c *Classifier) SetAttributeRules(rules []AttributeRule) {
+	c.attributeRules = rules
+}
@@ -0,0 +1,27 @@
+package classify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAttributeRules(t *testing.T) {
+	data := []byte(`# comment
+[attr]binary -diff -merge -text
+*.pb.go linguist-generated=true -diff
+vendor/** linguist-vendored
+	docs/api/**	-linguist-documentation
+"with space/*" !linguist-generated
+*.go text eol=lf
+`)
+	got := ParseAttributeRules("sub/.gitattributes", data)
+	want := []AttributeRule{
+		{File: "sub/.gitattributes", Pattern: "*.pb.go", Attribute: "linguist-generated", Value: "true", Category: Generated},
+		{File: "sub/.gitattributes", Pattern: "vendor/**", Attribute: "linguist-vendored", Value: "set", Category: Generated},
+		{File: "sub/.gitattributes", Pattern: "docs/api/**", Attribute: "linguist-documentation", Value: "unset", Category: Docs},
+		{File: "sub/.gitattributes", Pattern: "with space/*", Attribute: "linguist-generated", Value: "unspecified", Category: Generated},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAttributeRules =\n%+v\nwant\n%+v", got, want)
+	}
+}
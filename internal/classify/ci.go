@@ -0,0 +1,103 @@
+package classify
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CI, container, and build tooling file names, matched case-insensitively.
+var ciFilenames = map[string]bool{
+	// CI services
+	".gitlab-ci.yml":          true,
+	".travis.yml":             true,
+	"jenkinsfile":             true,
+	"azure-pipelines.yml":     true,
+	"bitbucket-pipelines.yml": true,
+	"appveyor.yml":            true,
+	".drone.yml":              true,
+	"cloudbuild.yaml":         true,
+	"codecov.yml":             true,
+	"dependabot.yml":          true,
+	"renovate.json":           true,
+	".pre-commit-config.yaml": true,
+	".goreleaser.yml":         true,
+	".goreleaser.yaml":        true,
+	// Containers
+	"dockerfile":          true,
+	"containerfile":       true,
+	".dockerignore":       true,
+	"docker-compose.yml":  true,
+	"docker-compose.yaml": true,
+	"compose.yml":         true,
+	"compose.yaml":        true,
+	"skaffold.yaml":       true,
+	"tiltfile":            true,
+	// Helm
+	"chart.yaml": true,
+	// Task runners
+	"makefile":     true,
+	"gnumakefile":  true,
+	"justfile":     true,
+	"taskfile.yml": true,
+}
+
+// CI file name patterns, lowercase globs matched against the lowercase base
+// name.
+var ciFilePatterns = []string{
+	"dockerfile.*",
+	"*.dockerfile",
+	"containerfile.*",
+	"docker-compose.*.yml",
+	"docker-compose.*.yaml",
+	"jenkinsfile.*",
+}
+
+// CI directories: workflow definitions and Helm charts.
+var ciDirs = []string{
+	".github/workflows/",
+	".github/actions/",
+	".circleci/",
+	".buildkite/",
+	".gitlab/ci/",
+	"charts/",
+	"helm/",
+}
+
+// Extensions of pipeline definitions, templates, and scripts recognized
+// inside ciDirs, so application code under a charts/ directory stays source.
+var ciDirExtensions = map[string]bool{
+	".yml":  true,
+	".yaml": true,
+	".tpl":  true,
+	".sh":   true,
+}
+
+func (c *Classifier) isCI(normalized, base, ext string) bool {
+	if cc, rel, ok := c.custom(CI, normalized); ok {
+		switch matchesCustom(rel, base, cc) {
+		case customMatched:
+			return true
+		case customExcluded:
+			return false
+		}
+	}
+
+	lower := strings.ToLower(base)
+	if ciFilenames[lower] {
+		return true
+	}
+	for _, p := range ciFilePatterns {
+		if matched, _ := filepath.Match(p, lower); matched {
+			return true
+		}
+	}
+
+	if ciDirExtensions[ext] {
+		for _, dir := range ciDirs {
+			if strings.HasPrefix(normalized, dir) || strings.Contains(normalized, "/"+dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
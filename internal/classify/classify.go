@@ -2,62 +2,213 @@ package classify
 
 import (
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/jbonatakis/differ/internal/config"
 )
 
 // Category constants.
 const (
 	Generated = "generated"
+	I18n      = "i18n"
 	Docs      = "docs"
 	Tests     = "tests"
+	CI        = "ci"
 	Source    = "source"
 	Other     = "other"
 )
 
+// Categories lists the built-in categories in first-match priority order.
+var Categories = []string{Generated, I18n, Docs, Tests, CI, Source, Other}
+
 // Classifier assigns a category and language to file paths.
 type Classifier struct {
 	customCategories map[string]config.CategoryConfig
+	scopes           []config.Scope
+	attributes       map[string]map[string]string
+	attributeRules   []AttributeRule
+	shebangs         map[string]string
+	minified         map[string]bool
+	minifiedLength   int
+	languages        map[string]string
+	// generatedDirs are the built-in generatedDirs with those the config
+	// adds or turns off applied; scriptDirs, the ones among them matched as
+	// scriptDirs describes.
+	generatedDirs []string
+	scriptDirs    map[string]bool
+}
+
+// Attributes lists the gitattributes that influence classification, for use
+// with SetAttributes.
+var Attributes = []string{"linguist-generated", "linguist-vendored", "linguist-documentation"}
+
+// SetAttributes supplies gitattributes values per path, as returned by
+// gitdiff.CheckAttr for Attributes, so classification agrees with GitHub
+// Linguist: linguist-generated and linguist-vendored paths are generated,
+// and linguist-documentation paths are docs. Setting an attribute to false
+// turns off the matching built-in heuristics for that path.
+func (c *Classifier) SetAttributes(attrs map[string]map[string]string) {
+	c.attributes = attrs
 }
 
 // New creates a Classifier with optional custom category overrides from config.
 func New(cfg config.Config) *Classifier {
-	return &Classifier{
+	c := &Classifier{
 		customCategories: cfg.Categories,
+		scopes:           cfg.Scopes,
+		languages:        cfg.Languages,
+		minifiedLength:   MinifiedLineLength(cfg),
 	}
+	c.generatedDirs, c.scriptDirs = effectiveGeneratedDirs(cfg.GeneratedDirs)
+	return c
+}
+
+// custom returns the custom rules for category that apply to path, and the
+// path they are matched against: a category defined in the innermost
+// per-directory config containing path replaces the root one, with its
+// patterns relative to that directory.
+func (c *Classifier) custom(category, normalized string) (cc config.CategoryConfig, rel string, ok bool) {
+	if s, rel, ok := config.ScopeFor(c.scopes, normalized, func(s config.Scope) bool {
+		_, ok := s.Categories[category]
+		return ok
+	}); ok {
+		return s.Categories[category], rel, true
+	}
+	cc, ok = c.customCategories[category]
+	return cc, normalized, ok
 }
 
 // Classify returns the category and detected language for a file path.
 // Categories are evaluated in first-match priority order:
-// generated > docs > tests > source > other.
+// generated > i18n > docs > tests > ci > source > other. For i18n files the
+// language is the locale (see Locale), since they hold no code.
 func (c *Classifier) Classify(path string) (category string, language string) {
 	// Normalize path separators.
 	normalized := filepath.ToSlash(path)
 	base := filepath.Base(path)
 	ext := strings.ToLower(filepath.Ext(base))
 
-	if c.isGenerated(normalized, base) {
-		return Generated, detectLanguage(ext)
+	lang := c.configuredLanguage(normalized, base)
+	if lang == "" {
+		lang = detectLanguage(base, ext)
+	}
+	if lang == "" && ext == "" {
+		lang = c.shebangs[normalized]
+	}
+
+	if c.isGenerated(normalized, base, ext, lang, c.attributes[normalized]) {
+		return Generated, lang
 	}
-	if c.isDocs(normalized, ext) {
-		return Docs, detectLanguage(ext)
+	if c.isI18n(normalized, base, ext) {
+		return I18n, Locale(normalized)
+	}
+	if c.isDocs(normalized, ext, c.attributes[normalized]) {
+		return Docs, lang
 	}
 	if c.isTests(normalized, base) {
-		return Tests, detectLanguage(ext)
+		return Tests, lang
+	}
+	if c.isCI(normalized, base, ext) {
+		return CI, lang
+	}
+	if c.isSource(normalized, ext) || lang != "" {
+		return Source, lang
+	}
+	return Other, lang
+}
+
+// configuredLanguage returns the language the languages config sets for
+// path, or "" if none does. Patterns match as custom category patterns do;
+// those of the innermost per-directory config, relative to its directory,
+// are tried before the root ones, and the longest matching pattern wins.
+func (c *Classifier) configuredLanguage(normalized, base string) string {
+	if s, rel, ok := config.ScopeFor(c.scopes, normalized, func(s config.Scope) bool {
+		return len(s.Languages) > 0
+	}); ok {
+		if lang := matchLanguage(s.Languages, rel, base); lang != "" {
+			return lang
+		}
 	}
-	if c.isSource(ext) {
-		return Source, detectLanguage(ext)
+	return matchLanguage(c.languages, normalized, base)
+}
+
+// matchLanguage returns the language of the longest pattern in languages
+// matching path, or "" if none does.
+func matchLanguage(languages map[string]string, normalized, base string) string {
+	var best, lang string
+	for pattern, l := range languages {
+		p := filepath.ToSlash(pattern)
+		if !matchesPattern(normalized, base, p) {
+			continue
+		}
+		// Ties go to the lexically first pattern, so the result does not
+		// depend on map order.
+		if len(p) > len(best) || (len(p) == len(best) && p < best) {
+			best, lang = p, l
+		}
 	}
-	return Other, detectLanguage(ext)
+	return lang
 }
 
-// Generated directories that indicate generated/vendored content.
+// Generated directories that indicate generated/vendored content: vendored
+// dependencies, package manager caches, and build and coverage output.
 var generatedDirs = []string{
 	"vendor/",
+	"third_party/",
 	"node_modules/",
+	"Pods/",
+	".yarn/",
 	"dist/",
 	"build/",
+	"target/",
+	"bin/",
+	"obj/",
+	"coverage/",
+	"__pycache__/",
+}
+
+// scriptDirs are generated directories that also commonly hold hand-written
+// scripts. Only files there with an extension but no detected language,
+// such as compiled .dll or .pdb output, are generated.
+var scriptDirs = map[string]bool{"bin/": true}
+
+// effectiveGeneratedDirs applies the generated_dirs config to the built-in
+// generatedDirs: directories set to true are added, and matched in full,
+// and those set to false are left out.
+func effectiveGeneratedDirs(overrides map[string]bool) ([]string, map[string]bool) {
+	set := make(map[string]bool, len(overrides))
+	for dir, on := range overrides {
+		set[normalizeDir(dir)] = on
+	}
+	var dirs []string
+	scripts := make(map[string]bool)
+	for _, dir := range generatedDirs {
+		on, configured := set[dir]
+		if configured && !on {
+			continue
+		}
+		dirs = append(dirs, dir)
+		if scriptDirs[dir] && !configured {
+			scripts[dir] = true
+		}
+	}
+	var added []string
+	for dir, on := range set {
+		if on && !slices.Contains(generatedDirs, dir) {
+			added = append(added, dir)
+		}
+	}
+	sort.Strings(added)
+	return append(dirs, added...), scripts
+}
+
+// normalizeDir returns dir, as written in the generated_dirs config, in the
+// form of generatedDirs: slash-separated with a trailing slash.
+func normalizeDir(dir string) string {
+	return strings.Trim(filepath.ToSlash(dir), "/") + "/"
 }
 
 // Lockfiles considered generated.
@@ -75,27 +226,60 @@ var lockfiles = map[string]bool{
 	"flake.lock":        true,
 }
 
-func (c *Classifier) isGenerated(normalized, base string) bool {
+func (c *Classifier) isGenerated(normalized, base, ext, lang string, attrs map[string]string) bool {
 	// Check custom generated patterns first.
-	if cc, ok := c.customCategories[Generated]; ok {
-		if matchesCustom(normalized, base, cc) {
+	if cc, rel, ok := c.custom(Generated, normalized); ok {
+		switch matchesCustom(rel, base, cc) {
+		case customMatched:
 			return true
+		case customExcluded:
+			return false
 		}
 	}
 
+	// Then explicit gitattributes, which override the heuristics below.
+	gen, genSet := attrs["linguist-generated"]
+	vendored, vendoredSet := attrs["linguist-vendored"]
+	if (genSet && attrTrue(gen)) || (vendoredSet && attrTrue(vendored)) {
+		return true
+	}
+	if genSet || vendoredSet {
+		return false
+	}
+
 	// Check generated directories.
-	for _, dir := range generatedDirs {
+	for _, dir := range c.generatedDirs {
 		if strings.HasPrefix(normalized, dir) || strings.Contains(normalized, "/"+dir) {
+			if c.scriptDirs[dir] && (ext == "" || lang != "") {
+				continue
+			}
 			return true
 		}
 	}
 
 	// Check lockfiles (case-insensitive).
-	if lockfiles[strings.ToLower(base)] {
+	lower := strings.ToLower(base)
+	if lockfiles[lower] {
 		return true
 	}
 
-	return false
+	// Terraform state is written by terraform itself.
+	for _, suffix := range generatedSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+
+	// Minified bundles and compiled assets that no path rule names.
+	return c.minified[normalized]
+}
+
+// Generated file name suffixes, matched case-insensitively.
+var generatedSuffixes = []string{".tfstate", ".tfstate.backup"}
+
+// attrTrue reports whether a check-attr value turns a boolean attribute on.
+func attrTrue(v string) bool {
+	return v == "set" || v == "true"
 }
 
 // Doc extensions.
@@ -114,14 +298,22 @@ var docDirs = []string{
 	"documentation/",
 }
 
-func (c *Classifier) isDocs(normalized, ext string) bool {
-	if cc, ok := c.customCategories[Docs]; ok {
-		if matchesCustom(normalized, filepath.Base(normalized), cc) {
+func (c *Classifier) isDocs(normalized, ext string, attrs map[string]string) bool {
+	if cc, rel, ok := c.custom(Docs, normalized); ok {
+		switch matchesCustom(rel, filepath.Base(normalized), cc) {
+		case customMatched:
 			return true
+		case customExcluded:
+			return false
 		}
 	}
 
-	if docExtensions[ext] {
+	if v, ok := attrs["linguist-documentation"]; ok {
+		return attrTrue(v)
+	}
+
+	// CMakeLists.txt and friends are build files, not prose.
+	if docExtensions[ext] && filenameLanguage(filepath.Base(normalized)) == "" {
 		return true
 	}
 
@@ -143,10 +335,37 @@ var testDirs = []string{
 	"__tests__/",
 }
 
+// Test filename patterns, matched against the base name. Patterns are
+// lowercase and matched case-insensitively unless caseSensitive is set.
+var testFilePatterns = []struct {
+	pattern       string
+	caseSensitive bool
+}{
+	// Generic: *.test.*, *.spec.*
+	{"*.test.*", false},
+	{"*.spec.*", false},
+	// Go
+	{"*_test.go", false},
+	// Python
+	{"test_*.py", false},
+	{"*_test.py", false},
+	// Java
+	{"*Test.java", true},
+	{"*Tests.java", true},
+	// Kotlin
+	{"*Test.kt", true},
+	// Ruby
+	{"*_spec.rb", false},
+	{"test_*.rb", false},
+}
+
 func (c *Classifier) isTests(normalized, base string) bool {
-	if cc, ok := c.customCategories[Tests]; ok {
-		if matchesCustom(normalized, base, cc) {
+	if cc, rel, ok := c.custom(Tests, normalized); ok {
+		switch matchesCustom(rel, base, cc) {
+		case customMatched:
 			return true
+		case customExcluded:
+			return false
 		}
 	}
 
@@ -159,39 +378,12 @@ func (c *Classifier) isTests(normalized, base string) bool {
 
 	// Check filename patterns.
 	lower := strings.ToLower(base)
-
-	// Generic patterns: *.test.*, *.spec.*
-	if strings.Contains(lower, ".test.") || strings.Contains(lower, ".spec.") {
-		return true
-	}
-
-	// Go: *_test.go
-	if strings.HasSuffix(lower, "_test.go") {
-		return true
-	}
-
-	// Python: test_*.py, *_test.py
-	if strings.HasSuffix(lower, ".py") {
-		name := strings.TrimSuffix(lower, ".py")
-		if strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test") {
-			return true
+	for _, tp := range testFilePatterns {
+		name := lower
+		if tp.caseSensitive {
+			name = base
 		}
-	}
-
-	// Java: *Test.java, *Tests.java
-	if strings.HasSuffix(base, "Test.java") || strings.HasSuffix(base, "Tests.java") {
-		return true
-	}
-
-	// Kotlin: *Test.kt
-	if strings.HasSuffix(base, "Test.kt") {
-		return true
-	}
-
-	// Ruby: *_spec.rb, test_*.rb
-	if strings.HasSuffix(lower, ".rb") {
-		name := strings.TrimSuffix(lower, ".rb")
-		if strings.HasSuffix(name, "_spec") || strings.HasPrefix(name, "test_") {
+		if matched, _ := filepath.Match(tp.pattern, name); matched {
 			return true
 		}
 	}
@@ -199,94 +391,8 @@ func (c *Classifier) isTests(normalized, base string) bool {
 	return false
 }
 
-// Source code extensions mapped to language names.
-var sourceExtensions = map[string]string{
-	// Go
-	".go": "Go",
-	// Rust
-	".rs": "Rust",
-	// Python
-	".py": "Python", ".pyi": "Python", ".pyw": "Python",
-	// JavaScript
-	".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
-	// TypeScript
-	".ts": "TypeScript", ".mts": "TypeScript", ".cts": "TypeScript",
-	// JSX/TSX
-	".jsx": "JSX", ".tsx": "TSX",
-	// Java
-	".java": "Java",
-	// Kotlin
-	".kt": "Kotlin", ".kts": "Kotlin",
-	// C
-	".c": "C", ".h": "C",
-	// C++
-	".cpp": "C++", ".cc": "C++", ".cxx": "C++", ".hpp": "C++", ".hxx": "C++", ".hh": "C++",
-	// C#
-	".cs": "C#",
-	// PHP
-	".php": "PHP",
-	// Ruby
-	".rb": "Ruby", ".rake": "Ruby",
-	// Swift
-	".swift": "Swift",
-	// Scala
-	".scala": "Scala", ".sc": "Scala",
-	// Shell
-	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell",
-	// Lua
-	".lua": "Lua",
-	// Perl
-	".pl": "Perl", ".pm": "Perl",
-	// R
-	".r": "R",
-	// Dart
-	".dart": "Dart",
-	// Elixir
-	".ex": "Elixir", ".exs": "Elixir",
-	// Erlang
-	".erl": "Erlang", ".hrl": "Erlang",
-	// Haskell
-	".hs": "Haskell", ".lhs": "Haskell",
-	// OCaml
-	".ml": "OCaml", ".mli": "OCaml",
-	// Clojure
-	".clj": "Clojure", ".cljs": "Clojure", ".cljc": "Clojure",
-	// Groovy
-	".groovy": "Groovy",
-	// Zig
-	".zig": "Zig",
-	// Nim
-	".nim": "Nim",
-	// V
-	".v": "V",
-	// SQL
-	".sql": "SQL",
-	// HTML
-	".html": "HTML", ".htm": "HTML",
-	// CSS
-	".css": "CSS", ".scss": "CSS", ".sass": "CSS", ".less": "CSS",
-	// Vue
-	".vue": "Vue",
-	// Svelte
-	".svelte": "Svelte",
-	// YAML
-	".yaml": "YAML", ".yml": "YAML",
-	// TOML
-	".toml": "TOML",
-	// JSON
-	".json": "JSON",
-	// XML
-	".xml": "XML",
-	// Protobuf
-	".proto": "Protobuf",
-	// GraphQL
-	".graphql": "GraphQL", ".gql": "GraphQL",
-	// Terraform
-	".tf": "Terraform", ".tfvars": "Terraform",
-}
-
-func (c *Classifier) isSource(ext string) bool {
-	if cc, ok := c.customCategories[Source]; ok {
+func (c *Classifier) isSource(normalized, ext string) bool {
+	if cc, _, ok := c.custom(Source, normalized); ok {
 		for _, e := range cc.Extensions {
 			cmpExt := strings.ToLower(e)
 			if !strings.HasPrefix(cmpExt, ".") {
@@ -301,35 +407,32 @@ func (c *Classifier) isSource(ext string) bool {
 	return ok
 }
 
-// detectLanguage returns the language name for a given extension.
-func detectLanguage(ext string) string {
-	if lang, ok := sourceExtensions[ext]; ok {
+// detectLanguage returns the language name for a file's base name and
+// lowercase extension. Well-known file names such as Dockerfile take
+// precedence over the extension.
+func detectLanguage(base, ext string) string {
+	if lang := filenameLanguage(base); lang != "" {
 		return lang
 	}
-	return ""
+	return sourceExtensions[ext]
 }
 
-// matchesCustom checks if a file matches custom category patterns or extensions.
-func matchesCustom(normalized, base string, cc config.CategoryConfig) bool {
-	for _, pattern := range cc.Patterns {
-		p := filepath.ToSlash(pattern)
-		// Support glob patterns.
-		if matched, _ := filepath.Match(p, base); matched {
-			return true
-		}
-		// Support directory prefix patterns.
-		if strings.HasSuffix(p, "/") {
-			if strings.HasPrefix(normalized, p) || strings.Contains(normalized, "/"+p) {
-				return true
-			}
-		}
-		// Support substring matching for non-glob, non-directory patterns.
-		if !strings.ContainsAny(p, "*?[") && !strings.HasSuffix(p, "/") {
-			if strings.Contains(normalized, p) {
-				return true
-			}
-		}
-	}
+// customMatch is the outcome of matching a path against a custom category.
+type customMatch int
+
+const (
+	customNone     customMatch = iota // no pattern or extension matched
+	customMatched                     // the path belongs to the category
+	customExcluded                    // a "!" pattern removed the path
+)
+
+// matchesCustom checks if a file matches custom category patterns or
+// extensions. Patterns are evaluated in order after extensions, and a
+// pattern prefixed with "!" negates earlier matches, so
+// ["test/**", "!test/fixtures/**"] leaves fixtures out of the category.
+// An excluded path is also kept out of the category's built-in heuristics.
+func matchesCustom(normalized, base string, cc config.CategoryConfig) customMatch {
+	result := customNone
 	ext := strings.ToLower(filepath.Ext(base))
 	for _, e := range cc.Extensions {
 		cmpExt := strings.ToLower(e)
@@ -337,6 +440,47 @@ func matchesCustom(normalized, base string, cc config.CategoryConfig) bool {
 			cmpExt = "." + cmpExt
 		}
 		if ext == cmpExt {
+			result = customMatched
+		}
+	}
+	for _, pattern := range cc.Patterns {
+		p := filepath.ToSlash(pattern)
+		negated := strings.HasPrefix(p, "!")
+		if negated {
+			p = p[1:]
+		}
+		if !matchesPattern(normalized, base, p) {
+			continue
+		}
+		if negated {
+			result = customExcluded
+		} else {
+			result = customMatched
+		}
+	}
+	return result
+}
+
+// matchesPattern reports whether a single custom category pattern matches.
+func matchesPattern(normalized, base, p string) bool {
+	// Support glob patterns, against the base name or the full path.
+	if matched, _ := filepath.Match(p, base); matched {
+		return true
+	}
+	if strings.Contains(p, "/") {
+		if matched, _ := doublestar.Match(p, normalized); matched {
+			return true
+		}
+	}
+	// Support directory prefix patterns.
+	if strings.HasSuffix(p, "/") {
+		if strings.HasPrefix(normalized, p) || strings.Contains(normalized, "/"+p) {
+			return true
+		}
+	}
+	// Support substring matching for non-glob, non-directory patterns.
+	if !strings.ContainsAny(p, "*?[") && !strings.HasSuffix(p, "/") {
+		if strings.Contains(normalized, p) {
 			return true
 		}
 	}
@@ -43,6 +43,14 @@ func TestGeneratedDirectories(t *testing.T) {
 		"frontend/node_modules/react/index.js",
 		"out/dist/app.js",
 		"project/build/main.o",
+		"third_party/zlib/zlib.h",
+		"target/release/app.d",
+		"ios/Pods/Alamofire/Source/Request.swift",
+		".yarn/releases/yarn-4.1.0.cjs",
+		"src/App/obj/Debug/App.AssemblyInfo.cs",
+		"src/App/bin/Debug/App.dll",
+		"coverage/lcov.info",
+		"pkg/__pycache__/util.cpython-312.pyc",
 	}
 	for _, p := range paths {
 		cat, _ := c.Classify(p)
@@ -52,6 +60,47 @@ func TestGeneratedDirectories(t *testing.T) {
 	}
 }
 
+func TestBinScripts(t *testing.T) {
+	c := defaultClassifier()
+	c.SetShebangs(map[string]string{"bin/deploy": "Shell"})
+	tests := []struct {
+		path, want string
+	}{
+		{"bin/deploy", Source},
+		{"bin/setup", Other},
+		{"bin/release.sh", Source},
+		{"bin/Release/App.pdb", Generated},
+	}
+	for _, tt := range tests {
+		if got, _ := c.Classify(tt.path); got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestConfiguredGeneratedDirs(t *testing.T) {
+	c := New(config.Config{GeneratedDirs: map[string]bool{
+		"gen":       true,
+		"/out/":     true,
+		"coverage/": false,
+		"bin":       true,
+	}})
+	tests := []struct {
+		path, want string
+	}{
+		{"gen/api.go", Generated},
+		{"web/out/index.js", Generated},
+		{"coverage/report.js", Source},
+		{"bin/deploy", Generated}, // listed in config, so every file matches
+		{"vendor/x/y.go", Generated},
+	}
+	for _, tt := range tests {
+		if got, _ := c.Classify(tt.path); got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
 func TestGeneratedLockfiles(t *testing.T) {
 	c := defaultClassifier()
 	lockfileNames := []string{
@@ -243,9 +292,7 @@ func TestSourceExtensions(t *testing.T) {
 func TestOtherCategory(t *testing.T) {
 	c := defaultClassifier()
 	paths := []string{
-		"Makefile",
 		".gitignore",
-		"Dockerfile",
 		"LICENSE",
 		"file.bin",
 		"image.png",
@@ -261,9 +308,118 @@ func TestOtherCategory(t *testing.T) {
 
 func TestOtherLanguageIsEmpty(t *testing.T) {
 	c := defaultClassifier()
-	_, lang := c.Classify("Makefile")
+	_, lang := c.Classify("LICENSE")
 	if lang != "" {
-		t.Errorf("Classify(\"Makefile\") language = %q, want empty", lang)
+		t.Errorf("Classify(\"LICENSE\") language = %q, want empty", lang)
+	}
+}
+
+func TestFilenameLanguages(t *testing.T) {
+	c := defaultClassifier()
+	tests := []struct {
+		path string
+		lang string
+	}{
+		{"Dockerfile", "Dockerfile"},
+		{"deploy/Dockerfile.prod", "Dockerfile"},
+		{"Containerfile", "Dockerfile"},
+		{"Makefile", "Makefile"},
+		{"GNUmakefile", "Makefile"},
+		{"CMakeLists.txt", "CMake"},
+		{"src/CMakeLists.txt", "CMake"},
+		{"Jenkinsfile", "Groovy"},
+		{"BUILD.bazel", "Starlark"},
+		{"pkg/BUILD", "Starlark"},
+		{"Gemfile", "Ruby"},
+		{"Rakefile", "Ruby"},
+		{"Vagrantfile", "Ruby"},
+		{".bashrc", "Shell"},
+	}
+	for _, tt := range tests {
+		cat, lang := c.Classify(tt.path)
+		if (cat != Source && cat != CI) || lang != tt.lang {
+			t.Errorf("Classify(%q) = (%q, %q), want source or ci with %q", tt.path, cat, lang, tt.lang)
+		}
+	}
+}
+
+func TestCICategory(t *testing.T) {
+	c := defaultClassifier()
+	tests := []struct {
+		path string
+		want string
+	}{
+		{".github/workflows/ci.yml", CI},
+		{".github/actions/setup/action.yaml", CI},
+		{".github/dependabot.yml", CI},
+		{".gitlab-ci.yml", CI},
+		{".circleci/config.yml", CI},
+		{"Dockerfile", CI},
+		{"services/api/Dockerfile.dev", CI},
+		{"docker/api.dockerfile", CI},
+		{"docker-compose.override.yaml", CI},
+		{"Makefile", CI},
+		{"Jenkinsfile", CI},
+		{"deploy/charts/api/Chart.yaml", CI},
+		{"deploy/charts/api/templates/deployment.yaml", CI},
+		{"deploy/charts/api/templates/_helpers.tpl", CI},
+		// Application code under a charts/ directory stays source.
+		{"web/src/charts/LineChart.tsx", Source},
+		// Earlier categories win.
+		{"tests/Dockerfile", Tests},
+		{"docs/Makefile", Docs},
+		{"CMakeLists.txt", Source},
+	}
+	for _, tt := range tests {
+		if cat, _ := c.Classify(tt.path); cat != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.path, cat, tt.want)
+		}
+	}
+}
+
+func TestCICustomExclusion(t *testing.T) {
+	c := newClassifier(map[string]config.CategoryConfig{
+		CI: {Patterns: []string{"!Makefile"}},
+	})
+	if cat, lang := c.Classify("Makefile"); cat != Source || lang != "Makefile" {
+		t.Errorf("Classify(\"Makefile\") = (%q, %q), want (%q, \"Makefile\")", cat, lang, Source)
+	}
+}
+
+func TestExpandedExtensions(t *testing.T) {
+	c := defaultClassifier()
+	tests := []struct {
+		path string
+		lang string
+	}{
+		{"solver.f90", "Fortran"},
+		{"model.jl", "Julia"},
+		{"AppDelegate.m", "Objective-C"},
+		{"Bridge.mm", "Objective-C++"},
+		{"boot.asm", "Assembly"},
+		{"install.ps1", "PowerShell"},
+		{"build.bat", "Batchfile"},
+		{"Program.fs", "F#"},
+		{"kernel.cu", "Cuda"},
+		{"Token.sol", "Solidity"},
+		{"defs.bzl", "Starlark"},
+		{"flake.nix", "Nix"},
+		{"cmake/deps.cmake", "CMake"},
+	}
+	for _, tt := range tests {
+		cat, lang := c.Classify(tt.path)
+		if cat != Source || lang != tt.lang {
+			t.Errorf("Classify(%q) = (%q, %q), want (%q, %q)", tt.path, cat, lang, Source, tt.lang)
+		}
+	}
+}
+
+func TestTerraformStateIsGenerated(t *testing.T) {
+	c := defaultClassifier()
+	for _, p := range []string{"infra/terraform.tfstate", "infra/terraform.tfstate.backup"} {
+		if cat, _ := c.Classify(p); cat != Generated {
+			t.Errorf("Classify(%q) = %q, want %q", p, cat, Generated)
+		}
 	}
 }
 
@@ -408,6 +564,102 @@ func TestCustomTestsPatterns(t *testing.T) {
 	}
 }
 
+func TestCustomPatternsNegation(t *testing.T) {
+	c := newClassifier(map[string]config.CategoryConfig{
+		Tests: {
+			Patterns: []string{"test/**", "!test/fixtures/**"},
+		},
+		Docs: {
+			Patterns: []string{"handbook/**"},
+		},
+	})
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"test/api/client.go", Tests},
+		// Negated out of tests, including the built-in test heuristics.
+		{"test/fixtures/sample_test.go", Source},
+		{"test/fixtures/data.bin", Other},
+		// Full-path globs match below the top directory.
+		{"handbook/guide/intro.txt", Docs},
+	}
+	for _, tt := range tests {
+		if cat, _ := c.Classify(tt.path); cat != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.path, cat, tt.want)
+		}
+	}
+}
+
+func TestScopedCategories(t *testing.T) {
+	c := New(config.Config{
+		Categories: map[string]config.CategoryConfig{
+			Docs: {Patterns: []string{"packages/web/guide/**"}},
+		},
+		Scopes: []config.Scope{
+			{Dir: "packages/web", Config: config.Config{Categories: map[string]config.CategoryConfig{
+				Tests:     {Patterns: []string{"e2e/**"}},
+				Generated: {Patterns: []string{"!dist/**"}},
+			}}},
+		},
+	})
+	tests := []struct {
+		path string
+		want string
+	}{
+		// Scope patterns are relative to the scope directory.
+		{"packages/web/e2e/login.ts", Tests},
+		{"e2e/login.ts", Source},
+		// A negated scope pattern overrides the built-in heuristics there.
+		{"packages/web/dist/app.js", Source},
+		{"dist/app.js", Generated},
+		// Categories the scope does not set fall back to the root config.
+		{"packages/web/guide/intro.cfg", Docs},
+	}
+	for _, tt := range tests {
+		if cat, _ := c.Classify(tt.path); cat != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.path, cat, tt.want)
+		}
+	}
+}
+
+func TestConfiguredLanguages(t *testing.T) {
+	c := New(config.Config{
+		Languages: map[string]string{
+			"*.inc":          "PHP",
+			"scripts/*":      "Shell",
+			"scripts/*.py":   "Starlark",
+			"legacy/**/*.js": "JScript",
+		},
+		Scopes: []config.Scope{
+			{Dir: "tools", Config: config.Config{Languages: map[string]string{"*.inc": "Pascal"}}},
+		},
+	})
+	tests := []struct {
+		path, cat, lang string
+	}{
+		{"lib/header.inc", Source, "PHP"},
+		{"scripts/deploy", Source, "Shell"},
+		// The longest matching pattern wins.
+		{"scripts/build.py", Source, "Starlark"},
+		// Overrides replace the language detected from the extension, but
+		// not the category of an earlier match.
+		{"legacy/app/main.js", Source, "JScript"},
+		{"legacy/app/main.test.js", Tests, "JScript"},
+		// A per-directory config's patterns apply under its directory, and
+		// the root ones elsewhere.
+		{"tools/x.inc", Source, "Pascal"},
+		{"tools/run", Other, ""},
+		{"app/main.js", Source, "JavaScript"},
+	}
+	for _, tt := range tests {
+		cat, lang := c.Classify(tt.path)
+		if cat != tt.cat || lang != tt.lang {
+			t.Errorf("Classify(%q) = %q, %q; want %q, %q", tt.path, cat, lang, tt.cat, tt.lang)
+		}
+	}
+}
+
 func TestCustomSourceExtensions(t *testing.T) {
 	c := newClassifier(map[string]config.CategoryConfig{
 		Source: {
@@ -453,9 +705,9 @@ func TestEdgeCaseEmptyPath(t *testing.T) {
 
 func TestEdgeCaseNoExtension(t *testing.T) {
 	c := defaultClassifier()
-	cat, _ := c.Classify("Makefile")
+	cat, _ := c.Classify("LICENSE")
 	if cat != Other {
-		t.Errorf("Classify(\"Makefile\") = %q, want %q", cat, Other)
+		t.Errorf("Classify(\"LICENSE\") = %q, want %q", cat, Other)
 	}
 }
 
@@ -504,3 +756,86 @@ func TestCustomCategorySubstringPattern(t *testing.T) {
 		t.Errorf("Classify(\"api.generated.go\") = %q, want %q", cat, Generated)
 	}
 }
+
+func TestLinguistGeneratedAttribute(t *testing.T) {
+	c := defaultClassifier()
+	c.SetAttributes(map[string]map[string]string{
+		"api/service.pb.go": {"linguist-generated": "true"},
+		"schema.sql":        {"linguist-generated": "set"},
+		"dist/app.js":       {"linguist-generated": "false"},
+		"docs/ref.md":       {"linguist-generated": "unset"},
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"api/service.pb.go", Generated},
+		{"schema.sql", Generated},
+		{"dist/app.js", Source},
+		{"docs/ref.md", Docs},
+		{"dist/other.js", Generated},
+	}
+	for _, tt := range tests {
+		if got, _ := c.Classify(tt.path); got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLinguistVendoredAndDocumentationAttributes(t *testing.T) {
+	c := defaultClassifier()
+	c.SetAttributes(map[string]map[string]string{
+		"third_party/lib.c": {"linguist-vendored": "set"},
+		"vendor/ours/x.go":  {"linguist-vendored": "false"},
+		"guides/intro.html": {"linguist-documentation": "true"},
+		"docs/build.go":     {"linguist-documentation": "false"},
+		"docs/gen/api.md":   {"linguist-documentation": "set", "linguist-generated": "set"},
+		"vendor/mixed/y.go": {"linguist-vendored": "false", "linguist-generated": "true"},
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"third_party/lib.c", Generated},
+		{"vendor/ours/x.go", Source},
+		{"guides/intro.html", Docs},
+		{"docs/build.go", Source},
+		{"docs/gen/api.md", Generated},
+		{"vendor/mixed/y.go", Generated},
+	}
+	for _, tt := range tests {
+		if got, _ := c.Classify(tt.path); got != tt.want {
+			t.Errorf("Classify(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestI18nCategory(t *testing.T) {
+	c := defaultClassifier()
+	tests := []struct {
+		path, category, locale string
+	}{
+		{"locales/fr.json", I18n, "fr"},
+		{"web/src/locales/pt_BR/common.json", I18n, "pt-BR"},
+		{"po/de.po", I18n, "de"},
+		{"po/messages.pot", I18n, ""},
+		{"app/src/main/res/values-es/strings.xml", I18n, "es"},
+		{"app/src/main/res/values-pt-rBR/strings.xml", I18n, "pt-BR"},
+		{"app/src/main/res/values/strings.xml", I18n, ""},
+		{"ios/zh-Hant.lproj/Localizable.strings", I18n, "zh-Hant"},
+		{"i18n/app/en.yml", I18n, "en"},
+		{"lib/l10n/app_ja.arb", I18n, "ja"},
+		{"src/main/resources/i18n/messages_pt_BR.properties", I18n, "pt-BR"},
+		{"locales/README.md", Docs, ""},
+		{"src/locale.go", Source, "Go"},
+		{"config/app.json", Source, "JSON"},
+	}
+	for _, tt := range tests {
+		cat, lang := c.Classify(tt.path)
+		if cat != tt.category || lang != tt.locale {
+			t.Errorf("Classify(%q) = (%q, %q), want (%q, %q)", tt.path, cat, lang, tt.category, tt.locale)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package classify
+
+import "github.com/jbonatakis/differ/internal/parser"
+
+// Comment syntaxes shared by several languages.
+var (
+	cBlock     = [][2]string{{"/*", "*/"}}
+	mlBlock    = [][2]string{{"(*", "*)"}}
+	haskBlock  = [][2]string{{"{-", "-}"}}
+	cStyle     = parser.CommentSyntax{Line: []string{"//"}, Block: cBlock}
+	slashes    = parser.CommentSyntax{Line: []string{"//"}}
+	hashes     = parser.CommentSyntax{Line: []string{"#"}}
+	hashBlocks = parser.CommentSyntax{Line: []string{"#", "//"}, Block: cBlock}
+	dashes     = parser.CommentSyntax{Line: []string{"--"}}
+	semicolons = parser.CommentSyntax{Line: []string{";"}}
+	apostrophe = parser.CommentSyntax{Line: []string{"'"}}
+	markup     = parser.CommentSyntax{Block: [][2]string{{"<!--", "-->"}}}
+)
+
+// commentSyntaxes maps language names, as the tables in languages.go report
+// them, to how the language writes comments. Template languages, whose
+// comments depend on what they embed, are left out.
+var commentSyntaxes = map[string]parser.CommentSyntax{
+	// C family
+	"C": cStyle, "C++": cStyle, "C#": cStyle, "Objective-C": cStyle, "Objective-C++": cStyle,
+	"Cuda": cStyle, "Go": cStyle, "Rust": cStyle, "Java": cStyle, "Kotlin": cStyle,
+	"Scala": cStyle, "Groovy": cStyle, "Swift": cStyle, "Dart": cStyle, "V": cStyle,
+	"Odin": cStyle, "Haxe": cStyle, "Solidity": cStyle, "QML": cStyle, "ReScript": cStyle,
+	"SystemVerilog": cStyle, "Protobuf": cStyle, "CSS": cStyle,
+	"GLSL": cStyle, "HLSL": cStyle, "WGSL": cStyle, "Metal": cStyle,
+	"JavaScript": cStyle, "TypeScript": cStyle, "JSX": cStyle, "TSX": cStyle,
+	"D":   {Line: []string{"//"}, Block: [][2]string{{"/*", "*/"}, {"/+", "+/"}}},
+	"PHP": {Line: []string{"//", "#"}, Block: cBlock},
+	"Zig": slashes, "Prisma": slashes, "CUE": slashes, "Gleam": slashes,
+	// # and C-style
+	"Thrift": hashBlocks, "Jsonnet": hashBlocks, "Terraform": hashBlocks, "HCL": hashBlocks,
+	// Scripting and configuration
+	"Python": hashes, "Cython": hashes, "Mojo": hashes, "Ruby": hashes, "Crystal": hashes,
+	"Perl": hashes, "Raku": hashes, "Shell": hashes, "fish": hashes, "Nushell": hashes,
+	"Tcl": hashes, "Awk": hashes, "R": hashes, "Elixir": hashes, "Nim": hashes,
+	"Starlark": hashes, "Makefile": hashes, "CMake": hashes, "Dockerfile": hashes,
+	"Just": hashes, "Meson": hashes, "YAML": hashes, "TOML": hashes, "GraphQL": hashes,
+	"Open Policy Agent": hashes,
+	"CoffeeScript":      {Line: []string{"#"}, Block: [][2]string{{"###", "###"}}},
+	"Julia":             {Line: []string{"#"}, Block: [][2]string{{"#=", "=#"}}},
+	"PowerShell":        {Line: []string{"#"}, Block: [][2]string{{"<#", "#>"}}},
+	"Nix":               {Line: []string{"#"}, Block: cBlock},
+	"INI":               {Line: []string{";", "#"}},
+	// -- comments
+	"Ada": dashes, "VHDL": dashes,
+	"SQL":        {Line: []string{"--"}, Block: cBlock},
+	"Lua":        {Line: []string{"--"}, Block: [][2]string{{"--[[", "]]"}}},
+	"Haskell":    {Line: []string{"--"}, Block: haskBlock},
+	"PureScript": {Line: []string{"--"}, Block: haskBlock},
+	"Elm":        {Line: []string{"--"}, Block: haskBlock},
+	// Lisps and assembly
+	"Common Lisp": semicolons, "Scheme": semicolons, "Racket": semicolons,
+	"Clojure": semicolons, "Emacs Lisp": semicolons, "LLVM": semicolons,
+	"Assembly":    {Line: []string{";", "#", "//"}},
+	"WebAssembly": {Line: []string{";;"}},
+	// ML family and Pascal
+	"OCaml":  {Block: mlBlock},
+	"F#":     {Line: []string{"//"}, Block: mlBlock},
+	"Pascal": {Line: []string{"//"}, Block: [][2]string{{"{", "}"}, {"(*", "*)"}}},
+	// Others
+	"Erlang": {Line: []string{"%"}}, "Fortran": {Line: []string{"!"}},
+	"Vim Script": {Line: []string{`"`}}, "COBOL": {Line: []string{"*>"}},
+	"Visual Basic .NET": apostrophe, "VBScript": apostrophe,
+	"Batchfile": {Line: []string{"::", "REM ", "rem "}},
+	// Markup
+	"HTML": markup, "XML": markup, "Vue": markup, "Svelte": markup,
+}
+
+// CommentSyntax returns how language, a name reported by Classify, writes
+// comments, or the zero syntax if it is unknown or has none.
+func CommentSyntax(language string) parser.CommentSyntax {
+	return commentSyntaxes[language]
+}
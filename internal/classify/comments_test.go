@@ -0,0 +1,28 @@
+package classify
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/config"
+)
+
+func TestCommentSyntax(t *testing.T) {
+	c := New(config.Config{})
+	for path, marker := range map[string]string{
+		"main.go":        "//",
+		"app/models.py":  "#",
+		"Dockerfile":     "#",
+		"db/schema.sql":  "--",
+		"src/lib.rs":     "//",
+		"scripts/run.sh": "#",
+	} {
+		_, lang := c.Classify(path)
+		if syntax := CommentSyntax(lang); !slices.Contains(syntax.Line, marker) {
+			t.Errorf("CommentSyntax(%q) for %s = %+v, want a %q line comment", lang, path, syntax, marker)
+		}
+	}
+	if syntax := CommentSyntax(""); syntax.Line != nil || syntax.Block != nil {
+		t.Errorf("CommentSyntax of no language = %+v, want none", syntax)
+	}
+}
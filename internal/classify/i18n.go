@@ -0,0 +1,150 @@
+package classify
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Localization file extensions (gettext, XLIFF, Apple, Flutter).
+var i18nExtensions = map[string]bool{
+	".po":          true,
+	".pot":         true,
+	".xlf":         true,
+	".xliff":       true,
+	".strings":     true,
+	".stringsdict": true,
+	".arb":         true,
+}
+
+// Localization file names (Android string resources).
+var i18nFilenames = map[string]bool{
+	"strings.xml": true,
+	"plurals.xml": true,
+}
+
+// Localization directories; message catalogs inside them (JSON, YAML,
+// properties) are i18n resources.
+var i18nDirs = []string{
+	"locales/",
+	"locale/",
+	"i18n/",
+	"l10n/",
+	"translations/",
+}
+
+// Extensions of message catalogs recognized inside i18nDirs.
+var i18nCatalogExtensions = map[string]bool{
+	".json":       true,
+	".yaml":       true,
+	".yml":        true,
+	".properties": true,
+	".toml":       true,
+}
+
+func (c *Classifier) isI18n(normalized, base, ext string) bool {
+	if cc, rel, ok := c.custom(I18n, normalized); ok {
+		switch matchesCustom(rel, base, cc) {
+		case customMatched:
+			return true
+		case customExcluded:
+			return false
+		}
+	}
+
+	if i18nExtensions[ext] {
+		return true
+	}
+
+	// Android string resources: res/values-fr/strings.xml.
+	if i18nFilenames[strings.ToLower(base)] {
+		return true
+	}
+
+	if i18nCatalogExtensions[ext] {
+		for _, dir := range i18nDirs {
+			if strings.HasPrefix(normalized, dir) || strings.Contains(normalized, "/"+dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Locale returns the locale of a localization file, such as "fr" or "pt-BR",
+// read from the file name or the nearest directory that names one
+// (fr.json, app_pt_BR.arb, locales/fr/common.json,
+// fr.lproj/Localizable.strings, values-pt-rBR/strings.xml). It returns ""
+// when no locale is recognized, e.g. for gettext templates and default
+// Android resources.
+func Locale(path string) string {
+	normalized := filepath.ToSlash(path)
+	segments := strings.Split(normalized, "/")
+	last := len(segments) - 1
+	name := strings.TrimSuffix(segments[last], filepath.Ext(segments[last]))
+
+	// The file name may carry the locale as a suffix: messages_fr, app.de.
+	for i := 0; i < len(name); i++ {
+		if i == 0 || name[i-1] == '_' || name[i-1] == '.' {
+			if loc, ok := parseLocale(name[i:]); ok {
+				return loc
+			}
+		}
+	}
+	for i := last - 1; i >= 0; i-- {
+		seg := strings.TrimSuffix(segments[i], ".lproj")
+		if rest, ok := strings.CutPrefix(seg, "values-"); ok {
+			seg = strings.Replace(rest, "-r", "-", 1)
+		}
+		if loc, ok := parseLocale(seg); ok {
+			return loc
+		}
+	}
+	return ""
+}
+
+// parseLocale recognizes tags such as "fr", "pt_BR", "pt-BR", and
+// "zh-Hant", normalizing the separator to "-". The language must be a known
+// ISO 639-1 code so that names like "app" or "ui" are not mistaken for
+// locales.
+func parseLocale(s string) (string, bool) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 0 || len(parts) > 3 || !isoLanguages[strings.ToLower(parts[0])] || len(parts[0]) != 2 {
+		return "", false
+	}
+	tag := strings.ToLower(parts[0])
+	for _, p := range parts[1:] {
+		switch {
+		case len(p) == 2 && isLetters(p):
+			tag += "-" + strings.ToUpper(p)
+		case len(p) == 4 && isLetters(p):
+			tag += "-" + strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		default:
+			return "", false
+		}
+	}
+	return tag, true
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// isoLanguages is the set of ISO 639-1 language codes.
+var isoLanguages = func() map[string]bool {
+	codes := strings.Fields(`aa ab ae af ak am an ar as av ay az ba be bg bh bi bm bn bo br bs ca ce ch
+co cr cs cu cv cy da de dv dz ee el en eo es et eu fa ff fi fj fo fr fy ga gd gl gn gu gv ha he
+hi ho hr ht hu hy hz ia id ie ig ii ik io is it iu ja jv ka kg ki kj kk kl km kn ko kr ks ku kv
+kw ky la lb lg li ln lo lt lu lv mg mh mi mk ml mn mr ms mt my na nb nd ne ng nl nn no nr nv ny
+oc oj om or os pa pi pl ps pt qu rm rn ro ru rw sa sc sd se sg si sk sl sm sn so sq sr ss st su
+sv sw ta te tg th ti tk tl tn to tr ts tt tw ty ug uk ur uz ve vi vo wa wo xh yi yo za zh zu`)
+	m := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		m[c] = true
+	}
+	return m
+}()
@@ -0,0 +1,280 @@
+package classify
+
+import "strings"
+
+// The tables below follow the language names and file associations of
+// GitHub Linguist's languages.yml, trimmed to languages that appear in
+// source trees and to extensions that are not ambiguous with data or
+// documentation formats.
+
+// Source code extensions mapped to language names.
+var sourceExtensions = map[string]string{
+	// Go
+	".go": "Go",
+	// Rust
+	".rs": "Rust",
+	// Python
+	".py": "Python", ".pyi": "Python", ".pyw": "Python",
+	// Cython
+	".pyx": "Cython", ".pxd": "Cython",
+	// JavaScript
+	".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	// TypeScript
+	".ts": "TypeScript", ".mts": "TypeScript", ".cts": "TypeScript",
+	// JSX/TSX
+	".jsx": "JSX", ".tsx": "TSX",
+	// CoffeeScript
+	".coffee": "CoffeeScript",
+	// Java
+	".java": "Java",
+	// Kotlin
+	".kt": "Kotlin", ".kts": "Kotlin",
+	// C
+	".c": "C", ".h": "C",
+	// C++
+	".cpp": "C++", ".cc": "C++", ".cxx": "C++", ".c++": "C++",
+	".hpp": "C++", ".hxx": "C++", ".hh": "C++", ".h++": "C++",
+	".ipp": "C++", ".tpp": "C++", ".inl": "C++", ".ino": "C++",
+	// Objective-C (MATLAB also uses .m; Linguist tells them apart by content)
+	".m": "Objective-C",
+	// Objective-C++
+	".mm": "Objective-C++",
+	// CUDA
+	".cu": "Cuda", ".cuh": "Cuda",
+	// C#
+	".cs": "C#", ".csx": "C#",
+	// F#
+	".fs": "F#", ".fsi": "F#", ".fsx": "F#",
+	// Visual Basic
+	".vb": "Visual Basic .NET", ".vbs": "VBScript",
+	// Razor
+	".cshtml": "HTML+Razor", ".razor": "HTML+Razor",
+	// PHP
+	".php": "PHP",
+	// Ruby
+	".rb": "Ruby", ".rake": "Ruby", ".gemspec": "Ruby", ".ru": "Ruby", ".podspec": "Ruby",
+	// Crystal
+	".cr": "Crystal",
+	// Swift
+	".swift": "Swift",
+	// Scala
+	".scala": "Scala", ".sc": "Scala", ".sbt": "Scala",
+	// Shell
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".ksh": "Shell",
+	// Fish
+	".fish": "fish",
+	// PowerShell
+	".ps1": "PowerShell", ".psm1": "PowerShell", ".psd1": "PowerShell",
+	// Batchfile
+	".bat": "Batchfile", ".cmd": "Batchfile",
+	// Nushell
+	".nu": "Nushell",
+	// Lua
+	".lua": "Lua",
+	// Perl
+	".pl": "Perl", ".pm": "Perl",
+	// Raku
+	".raku": "Raku", ".rakumod": "Raku",
+	// Tcl
+	".tcl": "Tcl",
+	// Awk
+	".awk": "Awk",
+	// Vim Script
+	".vim": "Vim Script",
+	// Emacs Lisp
+	".el": "Emacs Lisp",
+	// R
+	".r": "R",
+	// Julia
+	".jl": "Julia",
+	// Fortran
+	".f": "Fortran", ".for": "Fortran", ".f77": "Fortran",
+	".f90": "Fortran", ".f95": "Fortran", ".f03": "Fortran", ".f08": "Fortran",
+	// Dart
+	".dart": "Dart",
+	// Elixir
+	".ex": "Elixir", ".exs": "Elixir",
+	// Erlang
+	".erl": "Erlang", ".hrl": "Erlang",
+	// Gleam
+	".gleam": "Gleam",
+	// Haskell
+	".hs": "Haskell", ".lhs": "Haskell",
+	// PureScript
+	".purs": "PureScript",
+	// Elm
+	".elm": "Elm",
+	// OCaml
+	".ml": "OCaml", ".mli": "OCaml",
+	// ReScript
+	".res": "ReScript", ".resi": "ReScript",
+	// Clojure
+	".clj": "Clojure", ".cljs": "Clojure", ".cljc": "Clojure",
+	// Lisp dialects
+	".lisp": "Common Lisp", ".lsp": "Common Lisp",
+	".scm": "Scheme", ".ss": "Scheme",
+	".rkt": "Racket",
+	// Groovy
+	".groovy": "Groovy", ".gradle": "Groovy", ".gvy": "Groovy",
+	// Zig
+	".zig": "Zig",
+	// Nim
+	".nim": "Nim",
+	// V
+	".v": "V",
+	// D
+	".d": "D",
+	// Odin
+	".odin": "Odin",
+	// Mojo
+	".mojo": "Mojo",
+	// Haxe
+	".hx": "Haxe",
+	// Pascal
+	".pas": "Pascal", ".dpr": "Pascal",
+	// Ada
+	".adb": "Ada", ".ads": "Ada",
+	// COBOL
+	".cob": "COBOL", ".cbl": "COBOL", ".cpy": "COBOL",
+	// Assembly
+	".asm": "Assembly", ".s": "Assembly", ".nasm": "Assembly",
+	// LLVM
+	".ll": "LLVM",
+	// WebAssembly
+	".wat": "WebAssembly", ".wast": "WebAssembly",
+	// Hardware description
+	".vhd": "VHDL", ".vhdl": "VHDL",
+	".sv": "SystemVerilog", ".svh": "SystemVerilog",
+	// Shaders
+	".glsl": "GLSL", ".vert": "GLSL", ".frag": "GLSL",
+	".hlsl": "HLSL", ".wgsl": "WGSL", ".metal": "Metal",
+	// Solidity
+	".sol": "Solidity",
+	// SQL
+	".sql": "SQL",
+	// HTML
+	".html": "HTML", ".htm": "HTML",
+	// Templates
+	".erb": "HTML+ERB", ".ejs": "EJS", ".pug": "Pug", ".twig": "Twig", ".liquid": "Liquid",
+	".hbs": "Handlebars", ".handlebars": "Handlebars",
+	// CSS
+	".css": "CSS", ".scss": "CSS", ".sass": "CSS", ".less": "CSS", ".styl": "CSS",
+	// Vue
+	".vue": "Vue",
+	// Svelte
+	".svelte": "Svelte",
+	// Astro
+	".astro": "Astro",
+	// QML
+	".qml": "QML",
+	// YAML
+	".yaml": "YAML", ".yml": "YAML",
+	// TOML
+	".toml": "TOML",
+	// JSON
+	".json": "JSON", ".jsonc": "JSON", ".json5": "JSON",
+	// Jsonnet
+	".jsonnet": "Jsonnet", ".libsonnet": "Jsonnet",
+	// CUE
+	".cue": "CUE",
+	// INI
+	".ini": "INI",
+	// XML
+	".xml": "XML", ".xsd": "XML", ".xsl": "XML", ".xslt": "XML",
+	// Protobuf
+	".proto": "Protobuf",
+	// Thrift
+	".thrift": "Thrift",
+	// GraphQL
+	".graphql": "GraphQL", ".gql": "GraphQL", ".graphqls": "GraphQL",
+	// Prisma
+	".prisma": "Prisma",
+	// Terraform
+	".tf": "Terraform", ".tfvars": "Terraform",
+	// HCL
+	".hcl": "HCL",
+	// Nix
+	".nix": "Nix",
+	// Starlark
+	".bzl": "Starlark", ".star": "Starlark",
+	// Rego
+	".rego": "Open Policy Agent",
+	// Build files
+	".cmake": "CMake", ".mk": "Makefile", ".mak": "Makefile", ".dockerfile": "Dockerfile",
+	// Jupyter
+	".ipynb": "Jupyter Notebook",
+}
+
+// Source file names mapped to language names. Keys are lowercase base names
+// and are matched case-insensitively.
+var sourceFilenames = map[string]string{
+	// Containers
+	"dockerfile":    "Dockerfile",
+	"containerfile": "Dockerfile",
+	// Make and friends
+	"makefile":       "Makefile",
+	"gnumakefile":    "Makefile",
+	"bsdmakefile":    "Makefile",
+	"cmakelists.txt": "CMake",
+	"justfile":       "Just",
+	"meson.build":    "Meson",
+	// Groovy
+	"jenkinsfile": "Groovy",
+	// Starlark
+	"build":           "Starlark",
+	"build.bazel":     "Starlark",
+	"workspace":       "Starlark",
+	"workspace.bazel": "Starlark",
+	"module.bazel":    "Starlark",
+	"tiltfile":        "Starlark",
+	// Ruby
+	"gemfile":     "Ruby",
+	"rakefile":    "Ruby",
+	"guardfile":   "Ruby",
+	"podfile":     "Ruby",
+	"fastfile":    "Ruby",
+	"appfile":     "Ruby",
+	"vagrantfile": "Ruby",
+	"brewfile":    "Ruby",
+	"capfile":     "Ruby",
+	"berksfile":   "Ruby",
+	"dangerfile":  "Ruby",
+	// Python
+	"snakefile":  "Python",
+	"sconstruct": "Python",
+	"sconscript": "Python",
+	// Shell
+	".bashrc":       "Shell",
+	".bash_profile": "Shell",
+	".profile":      "Shell",
+	".zshrc":        "Shell",
+	".zshenv":       "Shell",
+	".zprofile":     "Shell",
+}
+
+// filenamePrefixes maps lowercase base-name prefixes to language names, for
+// conventions like Dockerfile.dev.
+var filenamePrefixes = []struct {
+	prefix   string
+	language string
+}{
+	{"dockerfile.", "Dockerfile"},
+	{"containerfile.", "Dockerfile"},
+	{"makefile.", "Makefile"},
+	{"jenkinsfile.", "Groovy"},
+}
+
+// filenameLanguage returns the language of a well-known file name such as
+// Dockerfile or CMakeLists.txt, or "" if base is not one.
+func filenameLanguage(base string) string {
+	lower := strings.ToLower(base)
+	if lang, ok := sourceFilenames[lower]; ok {
+		return lang
+	}
+	for _, fp := range filenamePrefixes {
+		if strings.HasPrefix(lower, fp.prefix) {
+			return fp.language
+		}
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package classify
+
+import "github.com/jbonatakis/differ/internal/config"
+
+// DefaultMinifiedLineLength is the changed-line length, in bytes, past
+// which a file is taken to be minified when the config sets none.
+const DefaultMinifiedLineLength = 2000
+
+// MinifiedLineLength returns the threshold for Minified that cfg sets, or
+// DefaultMinifiedLineLength if it sets none.
+func MinifiedLineLength(cfg config.Config) int {
+	if cfg.MinifiedLineLength != nil {
+		return *cfg.MinifiedLineLength
+	}
+	return DefaultMinifiedLineLength
+}
+
+// Minified reports whether a file's changes look like a minified or
+// compiled asset rather than code written by hand: a changed line longer
+// than threshold, or a single line on each side of the change at least a
+// quarter that long, as when a one-line bundle is rebuilt. longestLine is
+// the length of the longest changed line and added and deleted count them.
+// A threshold of 0 turns the check off.
+func Minified(longestLine, added, deleted, threshold int) bool {
+	if threshold <= 0 {
+		return false
+	}
+	if longestLine > threshold {
+		return true
+	}
+	return added <= 1 && deleted <= 1 && added+deleted > 0 && 4*longestLine >= threshold
+}
+
+// SetMinified supplies the paths whose changes look minified (see
+// Minified). Such files are generated unless a custom generated rule or a
+// linguist-generated or linguist-vendored attribute says otherwise.
+func (c *Classifier) SetMinified(paths map[string]bool) {
+	c.minified = paths
+}
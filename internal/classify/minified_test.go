@@ -0,0 +1,48 @@
+package classify
+
+import "testing"
+
+func TestMinified(t *testing.T) {
+	tests := []struct {
+		name                    string
+		longest, added, deleted int
+		threshold               int
+		want                    bool
+	}{
+		{"short lines", 120, 40, 12, 2000, false},
+		{"over threshold", 2001, 30, 2, 2000, true},
+		{"at threshold", 2000, 30, 2, 2000, false},
+		{"single-line bundle", 600, 1, 1, 2000, true},
+		{"new single-line bundle", 500, 1, 0, 2000, true},
+		{"single short line", 499, 1, 1, 2000, false},
+		{"no lines", 600, 0, 0, 2000, false},
+		{"two long lines", 600, 2, 0, 2000, false},
+		{"turned off", 50000, 1, 0, 0, false},
+	}
+	for _, tt := range tests {
+		if got := Minified(tt.longest, tt.added, tt.deleted, tt.threshold); got != tt.want {
+			t.Errorf("%s: Minified(%d, %d, %d, %d) = %v, want %v", tt.name, tt.longest, tt.added, tt.deleted, tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyMinified(t *testing.T) {
+	c := defaultClassifier()
+	c.SetMinified(map[string]bool{"static/app.js": true, "static/site.css": true, "docs/guide.md": true})
+	c.SetAttributes(map[string]map[string]string{
+		"static/site.css": {"linguist-generated": "false"},
+	})
+	tests := []struct {
+		path, cat string
+	}{
+		{"static/app.js", Generated},
+		{"static/site.css", Source}, // gitattributes win
+		{"docs/guide.md", Generated},
+		{"static/other.js", Source},
+	}
+	for _, tt := range tests {
+		if cat, _ := c.Classify(tt.path); cat != tt.cat {
+			t.Errorf("Classify(%q) = %q, want %q", tt.path, cat, tt.cat)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package classify
+
+import (
+	"sort"
+
+	"github.com/jbonatakis/differ/internal/config"
+)
+
+// RuleSet is a serializable description of every rule the Classifier applies,
+// in a form other tools can use to replicate its behavior.
+type RuleSet struct {
+	// Priority lists categories in first-match evaluation order.
+	Priority   []string                 `json:"priority"`
+	Categories map[string]CategoryRules `json:"categories"`
+	// Languages maps lowercase file extensions to language names.
+	Languages map[string]string `json:"languages"`
+	// FilenameLanguages maps lowercase base names to language names. They
+	// take precedence over Languages.
+	FilenameLanguages map[string]string `json:"filename_languages"`
+	// LanguageOverrides maps the path globs of the languages config to
+	// language names. They take precedence over FilenameLanguages.
+	LanguageOverrides map[string]string `json:"language_overrides,omitempty"`
+	// ShebangLanguages maps the interpreters of "#!" lines, lowercase and
+	// without version suffixes, to language names. With --shebang they set
+	// the language of extensionless files that nothing above names.
+	ShebangLanguages map[string]string `json:"shebang_languages"`
+	// Attributes are the gitattributes lines setting linguist-generated,
+	// linguist-vendored, or linguist-documentation. They take precedence
+	// over the built-in rules of their category, but not over custom ones.
+	Attributes []AttributeRule `json:"attributes,omitempty"`
+	// Scopes are the per-directory .differ.yml files, ordered by directory.
+	// For paths under a scope's directory, the innermost scope defining a
+	// category or languages replaces the root ones.
+	Scopes []ScopeRules `json:"scopes,omitempty"`
+	// MinifiedLineLength is the changed-line length past which a file is
+	// generated, as are files whose change is a single line on each side
+	// at least a quarter this long; custom generated rules and attributes
+	// take precedence. 0 turns the check off.
+	MinifiedLineLength int `json:"minified_line_length"`
+}
+
+// ScopeRules lists the classification rules of a per-directory .differ.yml.
+// Its patterns are relative to Dir.
+type ScopeRules struct {
+	Dir        string                           `json:"dir"`
+	Categories map[string]config.CategoryConfig `json:"categories,omitempty"`
+	Languages  map[string]string                `json:"languages,omitempty"`
+}
+
+// CategoryRules lists the built-in and configured rules for one category.
+// Custom rules are evaluated before built-in ones.
+type CategoryRules struct {
+	Custom *config.CategoryConfig `json:"custom,omitempty"`
+	// Directories match when the path starts with or contains "/<dir>".
+	Directories []string `json:"directories,omitempty"`
+	// Filenames match the base name case-insensitively.
+	Filenames []string `json:"filenames,omitempty"`
+	// FilenamePatterns are globs matched against the base name.
+	FilenamePatterns []FilenamePattern `json:"filename_patterns,omitempty"`
+	// Extensions match the lowercase file extension.
+	Extensions []string `json:"extensions,omitempty"`
+	// DirectoryExtensions, when set, restricts Directories matches to files
+	// with these extensions.
+	DirectoryExtensions []string `json:"directory_extensions,omitempty"`
+	// ScriptDirectories are Directories that match only files with an
+	// extension and no detected language, since they also hold scripts.
+	ScriptDirectories []string `json:"script_directories,omitempty"`
+}
+
+// FilenamePattern is a base-name glob with its case sensitivity.
+type FilenamePattern struct {
+	Pattern       string `json:"pattern"`
+	CaseSensitive bool   `json:"case_sensitive"`
+}
+
+// Rules returns the effective rule set, merging built-in heuristics with the
+// custom categories the Classifier was configured with.
+func (c *Classifier) Rules() RuleSet {
+	rs := RuleSet{
+		Priority:   append([]string(nil), Categories...),
+		Categories: make(map[string]CategoryRules),
+		Languages:  make(map[string]string, len(sourceExtensions)),
+	}
+	rs.FilenameLanguages = make(map[string]string, len(sourceFilenames))
+	for ext, lang := range sourceExtensions {
+		rs.Languages[ext] = lang
+	}
+	for name, lang := range sourceFilenames {
+		rs.FilenameLanguages[name] = lang
+	}
+	rs.ShebangLanguages = make(map[string]string, len(shebangInterpreters))
+	for name, lang := range shebangInterpreters {
+		rs.ShebangLanguages[name] = lang
+	}
+	rs.Attributes = append([]AttributeRule(nil), c.attributeRules...)
+	rs.MinifiedLineLength = c.minifiedLength
+
+	generated := CategoryRules{
+		Directories:       append([]string(nil), c.generatedDirs...),
+		Filenames:         sortedKeys(lockfiles),
+		ScriptDirectories: sortedKeys(c.scriptDirs),
+	}
+	for _, suffix := range generatedSuffixes {
+		generated.FilenamePatterns = append(generated.FilenamePatterns, FilenamePattern{Pattern: "*" + suffix})
+	}
+	rs.Categories[Generated] = generated
+	rs.Categories[I18n] = CategoryRules{
+		Directories:         append([]string(nil), i18nDirs...),
+		Filenames:           sortedKeys(i18nFilenames),
+		Extensions:          sortedKeys(i18nExtensions),
+		DirectoryExtensions: sortedKeys(i18nCatalogExtensions),
+	}
+	rs.Categories[Docs] = CategoryRules{
+		Directories: append([]string(nil), docDirs...),
+		Extensions:  sortedKeys(docExtensions),
+	}
+	tests := CategoryRules{Directories: append([]string(nil), testDirs...)}
+	for _, tp := range testFilePatterns {
+		tests.FilenamePatterns = append(tests.FilenamePatterns, FilenamePattern{Pattern: tp.pattern, CaseSensitive: tp.caseSensitive})
+	}
+	rs.Categories[Tests] = tests
+	ci := CategoryRules{
+		Directories:         append([]string(nil), ciDirs...),
+		Filenames:           sortedKeys(ciFilenames),
+		DirectoryExtensions: sortedKeys(ciDirExtensions),
+	}
+	for _, p := range ciFilePatterns {
+		ci.FilenamePatterns = append(ci.FilenamePatterns, FilenamePattern{Pattern: p})
+	}
+	rs.Categories[CI] = ci
+	srcExts := make([]string, 0, len(sourceExtensions))
+	for ext := range sourceExtensions {
+		srcExts = append(srcExts, ext)
+	}
+	sort.Strings(srcExts)
+	source := CategoryRules{Extensions: srcExts}
+	for name := range sourceFilenames {
+		source.Filenames = append(source.Filenames, name)
+	}
+	sort.Strings(source.Filenames)
+	for _, fp := range filenamePrefixes {
+		source.FilenamePatterns = append(source.FilenamePatterns, FilenamePattern{Pattern: fp.prefix + "*"})
+	}
+	rs.Categories[Source] = source
+
+	if len(c.languages) > 0 {
+		rs.LanguageOverrides = make(map[string]string, len(c.languages))
+		for pattern, lang := range c.languages {
+			rs.LanguageOverrides[pattern] = lang
+		}
+	}
+
+	for name, cc := range c.customCategories {
+		cr := rs.Categories[name]
+		custom := cc
+		cr.Custom = &custom
+		rs.Categories[name] = cr
+	}
+
+	for _, scope := range c.scopes {
+		if len(scope.Categories) == 0 && len(scope.Languages) == 0 {
+			continue
+		}
+		rs.Scopes = append(rs.Scopes, ScopeRules{
+			Dir:        scope.Dir,
+			Categories: scope.Categories,
+			Languages:  scope.Languages,
+		})
+	}
+
+	return rs
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
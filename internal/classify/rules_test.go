@@ -0,0 +1,117 @@
+package classify
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/config"
+)
+
+func TestRulesBuiltins(t *testing.T) {
+	rs := defaultClassifier().Rules()
+
+	want := []string{Generated, I18n, Docs, Tests, CI, Source, Other}
+	if len(rs.Priority) != len(want) {
+		t.Fatalf("Priority = %v, want %v", rs.Priority, want)
+	}
+	for i := range want {
+		if rs.Priority[i] != want[i] {
+			t.Errorf("Priority[%d] = %q, want %q", i, rs.Priority[i], want[i])
+		}
+	}
+	if rs.Languages[".go"] != "Go" {
+		t.Errorf("Languages[.go] = %q, want Go", rs.Languages[".go"])
+	}
+	if rs.FilenameLanguages["dockerfile"] != "Dockerfile" {
+		t.Errorf("FilenameLanguages[dockerfile] = %q, want Dockerfile", rs.FilenameLanguages["dockerfile"])
+	}
+	if len(rs.Categories[Generated].Filenames) == 0 {
+		t.Error("expected built-in lockfile names for generated")
+	}
+	if len(rs.Categories[Tests].FilenamePatterns) == 0 {
+		t.Error("expected built-in filename patterns for tests")
+	}
+	if rs.Categories[Docs].Custom != nil {
+		t.Error("expected no custom docs rules without config")
+	}
+}
+
+func TestRulesIncludesCustomCategories(t *testing.T) {
+	c := New(config.Config{Categories: map[string]config.CategoryConfig{
+		Docs: {Patterns: []string{"handbook/"}},
+	}})
+	rs := c.Rules()
+
+	custom := rs.Categories[Docs].Custom
+	if custom == nil || len(custom.Patterns) != 1 || custom.Patterns[0] != "handbook/" {
+		t.Errorf("Docs.Custom = %+v, want handbook/ pattern", custom)
+	}
+	if len(rs.Categories[Docs].Extensions) == 0 {
+		t.Error("custom rules should not replace built-in docs extensions")
+	}
+}
+
+func TestRulesGeneratedDirs(t *testing.T) {
+	rs := New(config.Config{GeneratedDirs: map[string]bool{"gen": true, "dist/": false}}).Rules()
+	dirs := rs.Categories[Generated].Directories
+	if !slices.Contains(dirs, "gen/") || slices.Contains(dirs, "dist/") || !slices.Contains(dirs, "vendor/") {
+		t.Errorf("generated directories = %v, want gen/ added and dist/ left out", dirs)
+	}
+	if got := rs.Categories[Generated].ScriptDirectories; !slices.Equal(got, []string{"bin/"}) {
+		t.Errorf("ScriptDirectories = %v, want [bin/]", got)
+	}
+}
+
+func TestRulesJSONIsDeterministic(t *testing.T) {
+	c := defaultClassifier()
+	first, err := json.Marshal(c.Rules())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		again, _ := json.Marshal(c.Rules())
+		if string(again) != string(first) {
+			t.Fatal("rules JSON differs between calls")
+		}
+	}
+}
+
+func TestRulesClassifiers(t *testing.T) {
+	length := 500
+	c := New(config.Config{
+		MinifiedLineLength: &length,
+		Scopes: []config.Scope{
+			{Dir: "services/api", Config: config.Config{
+				Categories: map[string]config.CategoryConfig{Docs: {Patterns: []string{"guides/"}}},
+			}},
+			{Dir: "services/web", Config: config.Config{Include: []string{"src/"}}},
+			{Dir: "tools", Config: config.Config{Languages: map[string]string{"*.inc": "PHP"}}},
+		},
+	})
+	c.SetAttributeRules(ParseAttributeRules(".gitattributes", []byte("gen/** linguist-generated\n")))
+	rs := c.Rules()
+
+	if rs.MinifiedLineLength != 500 {
+		t.Errorf("MinifiedLineLength = %d, want 500", rs.MinifiedLineLength)
+	}
+	if rs.ShebangLanguages["python"] != "Python" || rs.ShebangLanguages["bash"] != "Shell" {
+		t.Errorf("ShebangLanguages = %v, want python and bash", rs.ShebangLanguages)
+	}
+	if len(rs.Attributes) != 1 || rs.Attributes[0].Pattern != "gen/**" || rs.Attributes[0].Category != Generated {
+		t.Errorf("Attributes = %+v, want gen/** generated", rs.Attributes)
+	}
+	if len(rs.Scopes) != 2 || rs.Scopes[0].Dir != "services/api" || rs.Scopes[1].Dir != "tools" {
+		t.Fatalf("Scopes = %+v, want services/api and tools", rs.Scopes)
+	}
+	if got := rs.Scopes[0].Categories[Docs].Patterns; !slices.Equal(got, []string{"guides/"}) {
+		t.Errorf("services/api docs patterns = %v, want [guides/]", got)
+	}
+	if rs.Scopes[1].Languages["*.inc"] != "PHP" {
+		t.Errorf("tools languages = %v, want *.inc: PHP", rs.Scopes[1].Languages)
+	}
+
+	if got := defaultClassifier().Rules().MinifiedLineLength; got != DefaultMinifiedLineLength {
+		t.Errorf("default MinifiedLineLength = %d, want %d", got, DefaultMinifiedLineLength)
+	}
+}
@@ -0,0 +1,65 @@
+package classify
+
+import (
+	"path"
+	"strings"
+)
+
+// shebangInterpreters maps interpreter names, without version suffixes, to
+// languages.
+var shebangInterpreters = map[string]string{
+	"python": "Python", "pypy": "Python",
+	"sh": "Shell", "bash": "Shell", "zsh": "Shell", "ksh": "Shell", "dash": "Shell", "ash": "Shell", "fish": "Shell",
+	"node": "JavaScript", "nodejs": "JavaScript", "deno": "JavaScript", "bun": "JavaScript",
+	"ts-node": "TypeScript", "tsx": "TypeScript",
+	"ruby": "Ruby", "jruby": "Ruby",
+	"perl": "Perl",
+	"php":  "PHP",
+	"lua":  "Lua", "luajit": "Lua",
+	"rscript": "R",
+	"elixir":  "Elixir",
+	"escript": "Erlang",
+	"runghc":  "Haskell", "runhaskell": "Haskell", "stack": "Haskell",
+	"ocaml":  "OCaml",
+	"groovy": "Groovy",
+	"scala":  "Scala",
+	"kotlin": "Kotlin",
+	"swift":  "Swift",
+	"dart":   "Dart",
+}
+
+// ShebangLanguage returns the language of a script's "#!" line, such as
+// "#!/usr/bin/env python3" or "#!/bin/bash -e", or "" if the line is not a
+// shebang or names an unknown interpreter.
+func ShebangLanguage(line string) string {
+	rest, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return ""
+	}
+	name := path.Base(fields[0])
+	if name == "env" {
+		// Skip env's options and variable assignments (env -S, FOO=1).
+		name = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				name = path.Base(f)
+				break
+			}
+		}
+	}
+	// python3.12 and python3 are python.
+	name = strings.TrimRight(strings.ToLower(name), "0123456789.")
+	return shebangInterpreters[name]
+}
+
+// SetShebangs supplies the languages of extensionless files detected from
+// their "#!" lines (see ShebangLanguage), keyed by path. Such files are
+// classified as source with that language unless an earlier category, such
+// as tests, matches.
+func (c *Classifier) SetShebangs(langs map[string]string) {
+	c.shebangs = langs
+}
@@ -0,0 +1,52 @@
+package classify
+
+import "testing"
+
+func TestShebangLanguage(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"#!/usr/bin/env python3", "Python"},
+		{"#!/usr/bin/python3.12 -u", "Python"},
+		{"#!/bin/bash -e", "Shell"},
+		{"#!/bin/sh", "Shell"},
+		{"#! /usr/bin/env node", "JavaScript"},
+		{"#!/usr/bin/env -S deno run --allow-net", "JavaScript"},
+		{"#!/usr/bin/env NODE_ENV=production node", "JavaScript"},
+		{"#!/usr/bin/ruby", "Ruby"},
+		{"#!/usr/bin/env Rscript", "R"},
+		{"#!/usr/bin/env unknown-tool", ""},
+		{"#!", ""},
+		{"# just a comment", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ShebangLanguage(tt.line); got != tt.want {
+			t.Errorf("ShebangLanguage(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyShebangs(t *testing.T) {
+	c := defaultClassifier()
+	c.SetShebangs(map[string]string{
+		"bin/deploy":  "Shell",
+		"tests/run":   "Python",
+		"bin/tool.sh": "Python", // extensions take precedence
+	})
+	tests := []struct {
+		path, cat, lang string
+	}{
+		{"bin/deploy", Source, "Shell"},
+		{"tests/run", Tests, "Python"},
+		{"bin/tool.sh", Source, "Shell"},
+		{"bin/other", Other, ""},
+	}
+	for _, tt := range tests {
+		cat, lang := c.Classify(tt.path)
+		if cat != tt.cat || lang != tt.lang {
+			t.Errorf("Classify(%q) = %q, %q; want %q, %q", tt.path, cat, lang, tt.cat, tt.lang)
+		}
+	}
+}
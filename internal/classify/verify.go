@@ -0,0 +1,29 @@
+package classify
+
+import "sort"
+
+// Mismatch describes a path whose classification differs from expectations.
+type Mismatch struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+// Verify classifies each path in expectations (path → expected category) and
+// returns the paths whose actual category differs, sorted by path.
+func (c *Classifier) Verify(expectations map[string]string) []Mismatch {
+	paths := make([]string, 0, len(expectations))
+	for p := range expectations {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var mismatches []Mismatch
+	for _, p := range paths {
+		actual, _ := c.Classify(p)
+		if actual != expectations[p] {
+			mismatches = append(mismatches, Mismatch{Path: p, Expected: expectations[p], Actual: actual})
+		}
+	}
+	return mismatches
+}
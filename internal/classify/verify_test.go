@@ -0,0 +1,39 @@
+package classify
+
+import (
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/config"
+)
+
+func TestVerifyAllMatch(t *testing.T) {
+	c := defaultClassifier()
+	mismatches := c.Verify(map[string]string{
+		"main.go":      Source,
+		"main_test.go": Tests,
+		"README.md":    Docs,
+		"go.sum":       Generated,
+		"LICENSE":      Other,
+	})
+	if len(mismatches) != 0 {
+		t.Errorf("expected no mismatches, got %+v", mismatches)
+	}
+}
+
+func TestVerifyReportsMismatchesSorted(t *testing.T) {
+	c := New(config.Config{})
+	mismatches := c.Verify(map[string]string{
+		"z/handbook.txt": Source,
+		"a/main.go":      Docs,
+		"b/main.go":      Source,
+	})
+	if len(mismatches) != 2 {
+		t.Fatalf("expected 2 mismatches, got %+v", mismatches)
+	}
+	if mismatches[0].Path != "a/main.go" || mismatches[0].Expected != Docs || mismatches[0].Actual != Source {
+		t.Errorf("mismatches[0] = %+v", mismatches[0])
+	}
+	if mismatches[1].Path != "z/handbook.txt" || mismatches[1].Actual != Docs {
+		t.Errorf("mismatches[1] = %+v", mismatches[1])
+	}
+}
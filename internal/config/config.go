@@ -4,31 +4,109 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // CategoryConfig defines custom patterns and extensions for a category.
 type CategoryConfig struct {
-	Patterns   []string `yaml:"patterns"`
-	Extensions []string `yaml:"extensions"`
+	Patterns   []string `yaml:"patterns" json:"patterns,omitempty"`
+	Extensions []string `yaml:"extensions" json:"extensions,omitempty"`
 }
 
+// CurrentVersion is the config schema version this build of differ reads
+// natively. Files declaring an older version are upgraded when loaded;
+// files declaring a newer one are rejected.
+const CurrentVersion = 1
+
 // Config holds all configuration fields for differ.
 type Config struct {
+	// Version is the schema version the file was written for. Files
+	// without one are read as version 1, the schema that predates the key.
+	Version    int                       `yaml:"version"`
 	Include    []string                  `yaml:"include"`
 	Exclude    []string                  `yaml:"exclude"`
 	Categories map[string]CategoryConfig `yaml:"categories"`
 	Empty      string                    `yaml:"empty"`
 	Sort       string                    `yaml:"sort"`
+	// IgnoreWhitespace passes -w to git diff so whitespace-only changes are
+	// not counted. nil means unset.
+	IgnoreWhitespace *bool `yaml:"ignore_whitespace"`
+	// DiffAlgorithm is passed to git diff as --diff-algorithm (myers,
+	// minimal, patience, or histogram). Empty leaves git's own setting.
+	DiffAlgorithm string `yaml:"diff_algorithm"`
+	// Expectations maps paths to the category they are expected to classify
+	// as; checked by `differ config test`.
+	Expectations map[string]string `yaml:"expectations"`
+	// Areas maps area names to path globs, used to group changes by
+	// product area (e.g. in `differ changelog`).
+	Areas map[string][]string `yaml:"areas"`
+	// Teams maps team names to the path globs they own, used by
+	// `differ --by-team` to roll churn up per team.
+	Teams map[string][]string `yaml:"teams"`
+	// Organizations maps email domains to the organization they belong to,
+	// used by `differ authors --group-by domain`.
+	Organizations map[string]string `yaml:"organizations"`
+	// Languages maps path globs to language names, overriding the language
+	// detected from the extension or file name (e.g. "*.inc": PHP).
+	Languages map[string]string `yaml:"languages"`
+	// GeneratedDirs adds directories, set to true, to the built-in ones
+	// whose files are generated, or turns built-in ones off, set to false.
+	GeneratedDirs map[string]bool `yaml:"generated_dirs"`
+	// MinifiedLineLength is the length in bytes past which a changed line
+	// marks its file as minified, and so generated; 0 turns the check off.
+	// nil means unset, for the default.
+	MinifiedLineLength *int `yaml:"minified_line_length"`
+	// LinkTemplate is a URL template for per-file links in JSON output, with
+	// {path}, {base}, and {head} placeholders.
+	LinkTemplate string `yaml:"link_template"`
+	// Format, List, Color, Category, Base, and Head are defaults for the
+	// flags of the same names, used when the flag is not given. nil means
+	// unset.
+	Format string `yaml:"format"`
+	List   *bool  `yaml:"list"`
+	Color  string `yaml:"color"`
+	// NoColor is the deprecated no_color key, which color replaced; true
+	// reads as color: never and false as color: auto.
+	NoColor  *bool    `yaml:"no_color"`
+	Category []string `yaml:"category"`
+	Base     string   `yaml:"base"`
+	Head     string   `yaml:"head"`
+	// Pager is the command that terminal output is paged through; empty or
+	// "cat" turns paging off. nil means unset.
+	Pager *string `yaml:"pager"`
+	// Icons is the default for --icons. nil means unset.
+	Icons *bool `yaml:"icons"`
+	// CategoryIcons maps category keys to the icon shown before them when
+	// icons are on, replacing the built-in one; an empty icon shows none.
+	CategoryIcons map[string]string `yaml:"category_icons"`
+
+	// Scopes are the .differ.yml files found in subdirectories; see
+	// LoadScopes.
+	Scopes []Scope `yaml:"-"`
+	// Notices is upgrade guidance for files written for an older schema
+	// version, collected as they are loaded.
+	Notices []string `yaml:"-"`
+}
+
+// Scope is a .differ.yml in a subdirectory of the repository. Its
+// categories, languages, include, and exclude apply to paths under Dir, with
+// patterns relative to Dir.
+type Scope struct {
+	Dir string // slash-separated, relative to the repository root
+	Config
 }
 
 // defaults returns the built-in default configuration.
 func defaults() Config {
 	return Config{
-		Empty: "exclude",
-		Sort:  "churn",
+		Version: CurrentVersion,
+		Empty:   "exclude",
+		Sort:    "churn",
 	}
 }
 
@@ -57,6 +135,7 @@ func load(globalPath, repoRoot string, cliOverrides Config) (Config, error) {
 			return Config{}, fmt.Errorf("global config %s: %w", globalPath, err)
 		}
 		if global != nil {
+			global.Notices = prefixNotices(globalPath, global.Notices)
 			cfg = merge(cfg, *global)
 		}
 	}
@@ -69,6 +148,7 @@ func load(globalPath, repoRoot string, cliOverrides Config) (Config, error) {
 			return Config{}, fmt.Errorf("repo config %s: %w", repoPath, err)
 		}
 		if repo != nil {
+			repo.Notices = prefixNotices(".differ.yml", repo.Notices)
 			cfg = merge(cfg, *repo)
 		}
 	}
@@ -79,6 +159,49 @@ func load(globalPath, repoRoot string, cliOverrides Config) (Config, error) {
 	return cfg, nil
 }
 
+// LoadScopes reads the .differ.yml files at the given paths, relative to
+// repoRoot, as scopes for their directories. The root .differ.yml, already
+// read by Load, is skipped. Scopes are returned ordered by directory.
+func LoadScopes(repoRoot string, paths []string) ([]Scope, error) {
+	var scopes []Scope
+	for _, p := range paths {
+		dir := path.Dir(filepath.ToSlash(p))
+		if path.Base(p) != ".differ.yml" || dir == "." {
+			continue
+		}
+		cfg, err := loadFile(filepath.Join(repoRoot, filepath.FromSlash(p)))
+		if err != nil {
+			return nil, fmt.Errorf("config %s: %w", p, err)
+		}
+		if cfg != nil {
+			cfg.Notices = prefixNotices(p, cfg.Notices)
+			scopes = append(scopes, Scope{Dir: dir, Config: *cfg})
+		}
+	}
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i].Dir < scopes[j].Dir })
+	return scopes, nil
+}
+
+// ScopeFor returns the innermost scope containing filePath for which keep
+// returns true, and filePath relative to that scope's directory. Scopes
+// that keep rejects are skipped in favor of enclosing ones, so a nested
+// config that only sets categories can leave include/exclude to its
+// parents. ok is false, and rel is filePath, if no scope matches.
+func ScopeFor(scopes []Scope, filePath string, keep func(Scope) bool) (scope Scope, rel string, ok bool) {
+	best := -1
+	for i, s := range scopes {
+		if strings.HasPrefix(filePath, s.Dir+"/") && keep(s) {
+			if best < 0 || len(s.Dir) > len(scopes[best].Dir) {
+				best = i
+			}
+		}
+	}
+	if best < 0 {
+		return Scope{}, filePath, false
+	}
+	return scopes[best], strings.TrimPrefix(filePath, scopes[best].Dir+"/"), true
+}
+
 // globalConfigPath returns the path to ~/.config/differ/config.yml.
 func globalConfigPath() (string, error) {
 	home, err := os.UserHomeDir()
@@ -99,13 +222,87 @@ func loadFile(path string) (*Config, error) {
 		return nil, err
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
 		return nil, fmt.Errorf("malformed YAML: %w", err)
 	}
+	if err := validate(&node); err != nil {
+		// A file written for a newer differ may use keys this one does not
+		// know; asking for an upgrade is more useful than listing them.
+		var v struct {
+			Version int `yaml:"version"`
+		}
+		if node.Decode(&v) == nil && v.Version > CurrentVersion {
+			return nil, upgrade(&Config{Version: v.Version}, migrations)
+		}
+		return nil, err
+	}
+	var cfg Config
+	if node.Kind != 0 {
+		if err := node.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("malformed YAML: %w", err)
+		}
+	}
+	if err := upgrade(&cfg, migrations); err != nil {
+		return nil, err
+	}
+	if cfg.NoColor != nil && cfg.Color == "" {
+		cfg.Color = "auto"
+		if *cfg.NoColor {
+			cfg.Color = "never"
+		}
+	}
 	return &cfg, nil
 }
 
+// A migration rewrites a config from one schema version to the next, in
+// place, and returns guidance for the user on how to update the file, or ""
+// if the file means the same under the new version.
+type migration func(*Config) string
+
+// migrations[i] upgrades a config from version i+1 to version i+2, so there
+// is one per version before CurrentVersion.
+var migrations []migration
+
+// upgrade brings cfg to the version after the last of steps, collecting
+// guidance for each step that changed its meaning in cfg.Notices.
+func upgrade(cfg *Config, steps []migration) error {
+	target := len(steps) + 1
+	if cfg.Version == 0 {
+		cfg.Version = 1
+	}
+	switch {
+	case cfg.Version < 0:
+		return fmt.Errorf("invalid version %d", cfg.Version)
+	case cfg.Version > target:
+		return fmt.Errorf("version %d is newer than this differ supports (%d); upgrade differ", cfg.Version, target)
+	}
+	for v := cfg.Version; v < target; v++ {
+		if guidance := steps[v-1](cfg); guidance != "" {
+			cfg.Notices = append(cfg.Notices, fmt.Sprintf("version %d config: %s; set version: %d once updated", v, guidance, target))
+		}
+		cfg.Version = v + 1
+	}
+	return nil
+}
+
+// prefixNotices labels each notice with the file it came from.
+func prefixNotices(path string, notices []string) []string {
+	for i, n := range notices {
+		notices[i] = path + ": " + n
+	}
+	return notices
+}
+
+// UpgradeNotices returns the upgrade guidance for c and its scopes.
+func (c Config) UpgradeNotices() []string {
+	notices := append([]string(nil), c.Notices...)
+	for _, s := range c.Scopes {
+		notices = append(notices, s.Notices...)
+	}
+	return notices
+}
+
 // merge returns a new Config where non-zero fields in override replace the
 // corresponding fields in base.
 func merge(base, override Config) Config {
@@ -123,6 +320,47 @@ func merge(base, override Config) Config {
 	if override.Sort != "" {
 		result.Sort = override.Sort
 	}
+	if override.IgnoreWhitespace != nil {
+		result.IgnoreWhitespace = override.IgnoreWhitespace
+	}
+	if override.DiffAlgorithm != "" {
+		result.DiffAlgorithm = override.DiffAlgorithm
+	}
+	if len(override.Notices) > 0 {
+		result.Notices = append(append([]string(nil), base.Notices...), override.Notices...)
+	}
+	if override.MinifiedLineLength != nil {
+		result.MinifiedLineLength = override.MinifiedLineLength
+	}
+	if override.LinkTemplate != "" {
+		result.LinkTemplate = override.LinkTemplate
+	}
+	if override.Format != "" {
+		result.Format = override.Format
+	}
+	if override.List != nil {
+		result.List = override.List
+	}
+	if override.Color != "" {
+		result.Color = override.Color
+	}
+	if override.NoColor != nil {
+		result.NoColor = override.NoColor
+	}
+	if len(override.Category) > 0 {
+		result.Category = override.Category
+	}
+	if override.Pager != nil {
+		result.Pager = override.Pager
+	}
+	if override.Icons != nil {
+		result.Icons = override.Icons
+	}
+	// Base and head name one range together, so a file that sets either
+	// replaces both.
+	if override.Base != "" || override.Head != "" {
+		result.Base, result.Head = override.Base, override.Head
+	}
 	if len(override.Categories) > 0 {
 		result.Categories = make(map[string]CategoryConfig, len(override.Categories))
 		// Start with base categories if any.
@@ -134,6 +372,69 @@ func merge(base, override Config) Config {
 			result.Categories[k] = v
 		}
 	}
+	if len(override.Expectations) > 0 {
+		result.Expectations = make(map[string]string, len(base.Expectations)+len(override.Expectations))
+		for k, v := range base.Expectations {
+			result.Expectations[k] = v
+		}
+		for k, v := range override.Expectations {
+			result.Expectations[k] = v
+		}
+	}
+	if len(override.Organizations) > 0 {
+		result.Organizations = make(map[string]string, len(base.Organizations)+len(override.Organizations))
+		for k, v := range base.Organizations {
+			result.Organizations[k] = v
+		}
+		for k, v := range override.Organizations {
+			result.Organizations[k] = v
+		}
+	}
+	if len(override.CategoryIcons) > 0 {
+		result.CategoryIcons = make(map[string]string, len(base.CategoryIcons)+len(override.CategoryIcons))
+		for k, v := range base.CategoryIcons {
+			result.CategoryIcons[k] = v
+		}
+		for k, v := range override.CategoryIcons {
+			result.CategoryIcons[k] = v
+		}
+	}
+	if len(override.Languages) > 0 {
+		result.Languages = make(map[string]string, len(base.Languages)+len(override.Languages))
+		for k, v := range base.Languages {
+			result.Languages[k] = v
+		}
+		for k, v := range override.Languages {
+			result.Languages[k] = v
+		}
+	}
+	if len(override.GeneratedDirs) > 0 {
+		result.GeneratedDirs = make(map[string]bool, len(base.GeneratedDirs)+len(override.GeneratedDirs))
+		for k, v := range base.GeneratedDirs {
+			result.GeneratedDirs[k] = v
+		}
+		for k, v := range override.GeneratedDirs {
+			result.GeneratedDirs[k] = v
+		}
+	}
+	if len(override.Areas) > 0 {
+		result.Areas = make(map[string][]string, len(base.Areas)+len(override.Areas))
+		for k, v := range base.Areas {
+			result.Areas[k] = v
+		}
+		for k, v := range override.Areas {
+			result.Areas[k] = v
+		}
+	}
+	if len(override.Teams) > 0 {
+		result.Teams = make(map[string][]string, len(base.Teams)+len(override.Teams))
+		for k, v := range base.Teams {
+			result.Teams[k] = v
+		}
+		for k, v := range override.Teams {
+			result.Teams[k] = v
+		}
+	}
 
 	return result
 }
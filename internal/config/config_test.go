@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -276,3 +277,420 @@ func assertSlice(t *testing.T, name string, got, want []string) {
 		}
 	}
 }
+
+func TestLoadExpectationsMerged(t *testing.T) {
+	tmp := t.TempDir()
+
+	globalFile := filepath.Join(tmp, "global.yml")
+	writeYAML(t, globalFile, `
+expectations:
+  docs/guide.md: docs
+  main.go: other
+`)
+
+	repoDir := filepath.Join(tmp, "repo")
+	os.MkdirAll(repoDir, 0o755)
+	writeYAML(t, filepath.Join(repoDir, ".differ.yml"), `
+expectations:
+  main.go: source
+  main_test.go: tests
+`)
+
+	cfg, err := load(globalFile, repoDir, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"docs/guide.md": "docs",
+		"main.go":       "source",
+		"main_test.go":  "tests",
+	}
+	if len(cfg.Expectations) != len(want) {
+		t.Fatalf("Expectations = %v, want %v", cfg.Expectations, want)
+	}
+	for k, v := range want {
+		if cfg.Expectations[k] != v {
+			t.Errorf("Expectations[%q] = %q, want %q", k, cfg.Expectations[k], v)
+		}
+	}
+}
+
+func TestLoadOrganizationsMerged(t *testing.T) {
+	tmp := t.TempDir()
+
+	globalFile := filepath.Join(tmp, "global.yml")
+	writeYAML(t, globalFile, `
+organizations:
+  google.com: Google
+  example.com: Example
+`)
+
+	repoDir := filepath.Join(tmp, "repo")
+	os.MkdirAll(repoDir, 0o755)
+	writeYAML(t, filepath.Join(repoDir, ".differ.yml"), `
+organizations:
+  example.com: Example Inc
+`)
+
+	cfg, err := load(globalFile, repoDir, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Organizations) != 2 || cfg.Organizations["google.com"] != "Google" || cfg.Organizations["example.com"] != "Example Inc" {
+		t.Errorf("Organizations = %v, want google.com from global and example.com from repo", cfg.Organizations)
+	}
+}
+
+func TestLoadTeamsMerged(t *testing.T) {
+	tmp := t.TempDir()
+
+	globalFile := filepath.Join(tmp, "global.yml")
+	writeYAML(t, globalFile, `
+teams:
+  platform: ["infra/**"]
+  payments: ["billing/**"]
+`)
+
+	repoDir := filepath.Join(tmp, "repo")
+	os.MkdirAll(repoDir, 0o755)
+	writeYAML(t, filepath.Join(repoDir, ".differ.yml"), `
+teams:
+  payments: ["services/payments/**", "billing/**"]
+`)
+
+	cfg, err := load(globalFile, repoDir, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Teams) != 2 || len(cfg.Teams["platform"]) != 1 || len(cfg.Teams["payments"]) != 2 {
+		t.Errorf("Teams = %v, want platform from global and payments from repo", cfg.Teams)
+	}
+}
+
+func TestLoadGeneratedDirsMerged(t *testing.T) {
+	tmp := t.TempDir()
+	globalPath := filepath.Join(tmp, "global.yml")
+	writeYAML(t, globalPath, `
+generated_dirs:
+  gen: true
+  bin: false
+`)
+	repoDir := filepath.Join(tmp, "repo")
+	writeYAML(t, filepath.Join(repoDir, ".differ.yml"), `
+generated_dirs:
+  bin: true
+`)
+
+	cfg, err := load(globalPath, repoDir, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.GeneratedDirs) != 2 || !cfg.GeneratedDirs["gen"] || !cfg.GeneratedDirs["bin"] {
+		t.Errorf("GeneratedDirs = %v, want gen from global and bin turned on by repo", cfg.GeneratedDirs)
+	}
+}
+
+func TestLoadLanguagesMerged(t *testing.T) {
+	tmp := t.TempDir()
+
+	globalFile := filepath.Join(tmp, "global.yml")
+	writeYAML(t, globalFile, `
+languages:
+  "*.inc": PHP
+  "scripts/*": Shell
+`)
+
+	repoDir := filepath.Join(tmp, "repo")
+	os.MkdirAll(repoDir, 0o755)
+	writeYAML(t, filepath.Join(repoDir, ".differ.yml"), `
+languages:
+  "*.inc": Pascal
+`)
+
+	cfg, err := load(globalFile, repoDir, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Languages) != 2 || cfg.Languages["scripts/*"] != "Shell" || cfg.Languages["*.inc"] != "Pascal" {
+		t.Errorf("Languages = %v, want scripts/* from global and *.inc from repo", cfg.Languages)
+	}
+}
+
+func TestIgnoreWhitespaceOverride(t *testing.T) {
+	tmp := t.TempDir()
+	writeYAML(t, filepath.Join(tmp, ".differ.yml"), `
+ignore_whitespace: true
+`)
+
+	cfg, err := load("", tmp, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.IgnoreWhitespace == nil || !*cfg.IgnoreWhitespace {
+		t.Errorf("IgnoreWhitespace = %v, want true from repo config", cfg.IgnoreWhitespace)
+	}
+
+	// An explicit CLI false overrides config true.
+	off := false
+	cfg, err = load("", tmp, Config{IgnoreWhitespace: &off})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.IgnoreWhitespace == nil || *cfg.IgnoreWhitespace {
+		t.Errorf("IgnoreWhitespace = %v, want false from CLI override", cfg.IgnoreWhitespace)
+	}
+}
+
+func TestCategoryIconsMerge(t *testing.T) {
+	tmp := t.TempDir()
+	globalFile := filepath.Join(tmp, "global.yml")
+	writeYAML(t, globalFile, `
+icons: true
+category_icons:
+  docs: "📚"
+  tests: "✅"
+`)
+
+	repoDir := filepath.Join(tmp, "repo")
+	os.MkdirAll(repoDir, 0o755)
+	writeYAML(t, filepath.Join(repoDir, ".differ.yml"), `
+category_icons:
+  tests: ""
+`)
+
+	cfg, err := load(globalFile, repoDir, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Icons == nil || !*cfg.Icons {
+		t.Errorf("Icons = %v, want true from global config", cfg.Icons)
+	}
+	if len(cfg.CategoryIcons) != 2 || cfg.CategoryIcons["docs"] != "📚" || cfg.CategoryIcons["tests"] != "" {
+		t.Errorf("CategoryIcons = %v, want docs from global and an empty tests from repo", cfg.CategoryIcons)
+	}
+}
+
+func TestLinkTemplateOverride(t *testing.T) {
+	tmp := t.TempDir()
+	writeYAML(t, filepath.Join(tmp, ".differ.yml"), `
+link_template: "https://example.com/blob/{head}/{path}"
+`)
+
+	cfg, err := load("", tmp, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LinkTemplate != "https://example.com/blob/{head}/{path}" {
+		t.Errorf("LinkTemplate = %q, want repo config value", cfg.LinkTemplate)
+	}
+
+	cfg, err = load("", tmp, Config{LinkTemplate: "https://cli/{path}"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LinkTemplate != "https://cli/{path}" {
+		t.Errorf("LinkTemplate = %q, want CLI override", cfg.LinkTemplate)
+	}
+}
+
+func TestMinifiedLineLengthOverride(t *testing.T) {
+	tmp := t.TempDir()
+	writeYAML(t, filepath.Join(tmp, ".differ.yml"), `
+minified_line_length: 0
+`)
+
+	cfg, err := load("", tmp, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MinifiedLineLength == nil || *cfg.MinifiedLineLength != 0 {
+		t.Errorf("MinifiedLineLength = %v, want 0 from repo config", cfg.MinifiedLineLength)
+	}
+
+	cfg, err = load("", "", Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MinifiedLineLength != nil {
+		t.Errorf("MinifiedLineLength = %v, want unset", *cfg.MinifiedLineLength)
+	}
+}
+
+func TestLoadScopes(t *testing.T) {
+	tmp := t.TempDir()
+	writeYAML(t, filepath.Join(tmp, "packages", "web", ".differ.yml"), `
+exclude:
+  - "dist/**"
+categories:
+  tests:
+    patterns:
+      - "e2e/**"
+`)
+	writeYAML(t, filepath.Join(tmp, "packages", "api", ".differ.yml"), `
+include:
+  - "**/*.go"
+`)
+
+	scopes, err := LoadScopes(tmp, []string{".differ.yml", "packages/web/.differ.yml", "packages/api/.differ.yml", "packages/gone/.differ.yml"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scopes) != 2 || scopes[0].Dir != "packages/api" || scopes[1].Dir != "packages/web" {
+		t.Fatalf("scopes = %+v, want packages/api and packages/web", scopes)
+	}
+	assertSlice(t, "web.Exclude", scopes[1].Exclude, []string{"dist/**"})
+	assertSlice(t, "web.tests.Patterns", scopes[1].Categories["tests"].Patterns, []string{"e2e/**"})
+
+	writeYAML(t, filepath.Join(tmp, "bad", ".differ.yml"), "include: [")
+	if _, err := LoadScopes(tmp, []string{"bad/.differ.yml"}); err == nil {
+		t.Error("expected error for malformed scope config")
+	}
+}
+
+func TestScopeFor(t *testing.T) {
+	scopes := []Scope{
+		{Dir: "packages", Config: Config{Exclude: []string{"tmp/**"}}},
+		{Dir: "packages/web", Config: Config{Include: []string{"src/**"}}},
+	}
+	any := func(Scope) bool { return true }
+	withExclude := func(s Scope) bool { return len(s.Exclude) > 0 }
+
+	if s, rel, ok := ScopeFor(scopes, "packages/web/src/a.ts", any); !ok || s.Dir != "packages/web" || rel != "src/a.ts" {
+		t.Errorf("innermost: got %q, %q, %v", s.Dir, rel, ok)
+	}
+	if s, rel, ok := ScopeFor(scopes, "packages/web/src/a.ts", withExclude); !ok || s.Dir != "packages" || rel != "web/src/a.ts" {
+		t.Errorf("enclosing: got %q, %q, %v", s.Dir, rel, ok)
+	}
+	if _, rel, ok := ScopeFor(scopes, "packagesx/a.go", any); ok || rel != "packagesx/a.go" {
+		t.Errorf("outside: got %q, %v", rel, ok)
+	}
+}
+
+func TestLoadVersion(t *testing.T) {
+	tmp := t.TempDir()
+	writeYAML(t, filepath.Join(tmp, ".differ.yml"), "version: 1\nsort: path\n")
+	cfg, err := load("", tmp, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Version != CurrentVersion || cfg.Sort != "path" || len(cfg.Notices) != 0 {
+		t.Errorf("cfg = %+v, want version %d, sort path, no notices", cfg, CurrentVersion)
+	}
+
+	// Files without a version key are read as version 1.
+	writeYAML(t, filepath.Join(tmp, ".differ.yml"), "sort: path\n")
+	if _, err := load("", tmp, Config{}); err != nil {
+		t.Errorf("unversioned config: unexpected error: %v", err)
+	}
+
+	writeYAML(t, filepath.Join(tmp, ".differ.yml"), "version: 99\n")
+	if _, err := load("", tmp, Config{}); err == nil || !strings.Contains(err.Error(), "upgrade differ") {
+		t.Errorf("future version: err = %v, want upgrade guidance", err)
+	}
+	writeYAML(t, filepath.Join(tmp, ".differ.yml"), "version: -1\n")
+	if _, err := load("", tmp, Config{}); err == nil {
+		t.Error("negative version: expected error")
+	}
+}
+
+func TestMigrationsCoverEveryVersion(t *testing.T) {
+	if len(migrations) != CurrentVersion-1 {
+		t.Errorf("len(migrations) = %d, want %d", len(migrations), CurrentVersion-1)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	steps := []migration{
+		// 1 -> 2: a change that leaves most configs alone.
+		func(cfg *Config) string { return "" },
+		// 2 -> 3: sort "size" was renamed to "churn".
+		func(cfg *Config) string {
+			if cfg.Sort != "size" {
+				return ""
+			}
+			cfg.Sort = "churn"
+			return `sort "size" is now "churn"`
+		},
+	}
+
+	cfg := Config{Sort: "size"}
+	if err := upgrade(&cfg, steps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Version != 3 || cfg.Sort != "churn" {
+		t.Errorf("cfg = %+v, want version 3 with sort churn", cfg)
+	}
+	want := []string{`version 2 config: sort "size" is now "churn"; set version: 3 once updated`}
+	assertSlice(t, "Notices", cfg.Notices, want)
+
+	cfg = Config{Version: 3, Sort: "size"}
+	if err := upgrade(&cfg, steps); err != nil || cfg.Sort != "size" || cfg.Notices != nil {
+		t.Errorf("current config changed: cfg = %+v, err = %v", cfg, err)
+	}
+}
+
+func TestUpgradeNoticesIncludeScopes(t *testing.T) {
+	cfg := merge(defaults(), Config{Notices: prefixNotices(".differ.yml", []string{"root"})})
+	cfg.Scopes = []Scope{{Dir: "web", Config: Config{Notices: []string{"web/.differ.yml: nested"}}}}
+	assertSlice(t, "UpgradeNotices", cfg.UpgradeNotices(), []string{".differ.yml: root", "web/.differ.yml: nested"})
+}
+
+func TestLoadFlagDefaults(t *testing.T) {
+	tmp := t.TempDir()
+	globalFile := filepath.Join(tmp, "global.yml")
+	writeYAML(t, globalFile, `
+format: markdown
+color: never
+base: develop
+`)
+	repoDir := filepath.Join(tmp, "repo")
+	writeYAML(t, filepath.Join(repoDir, ".differ.yml"), `
+format: json
+list: false
+category: [source, tests]
+head: feature
+`)
+
+	cfg, err := load(globalFile, repoDir, Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Format != "json" {
+		t.Errorf("Format = %q, want json from repo config", cfg.Format)
+	}
+	if cfg.List == nil || *cfg.List {
+		t.Errorf("List = %v, want false from repo config", cfg.List)
+	}
+	if cfg.Color != "never" {
+		t.Errorf("Color = %q, want never from global config", cfg.Color)
+	}
+	assertSlice(t, "Category", cfg.Category, []string{"source", "tests"})
+	// The repo's head does not pair with the global base.
+	if cfg.Base != "" || cfg.Head != "feature" {
+		t.Errorf("Base, Head = %q, %q; want \"\", feature", cfg.Base, cfg.Head)
+	}
+}
+
+func TestLoadDeprecatedNoColor(t *testing.T) {
+	tmp := t.TempDir()
+	globalFile := filepath.Join(tmp, "global.yml")
+	tests := []struct {
+		yaml string
+		want string
+	}{
+		{"no_color: true\n", "never"},
+		{"no_color: false\n", "auto"},
+		// color wins over the deprecated no_color in the same file.
+		{"color: always\nno_color: true\n", "always"},
+	}
+	for _, tt := range tests {
+		writeYAML(t, globalFile, tt.yaml)
+		cfg, err := load(globalFile, "", Config{})
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.yaml, err)
+		}
+		if cfg.Color != tt.want {
+			t.Errorf("%q: Color = %q, want %q", tt.yaml, cfg.Color, tt.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the JSON Schema for config files, for editors to validate and
+// complete them. Loaded files are checked against it.
+//
+//go:embed schema.json
+var Schema []byte
+
+// SchemaError is a config value that does not match Schema.
+type SchemaError struct {
+	Line, Column int
+	// Path locates the value, e.g. categories.docs.patterns[0]; empty for
+	// the whole file.
+	Path    string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// schemaNode is the part of JSON Schema that Schema uses.
+type schemaNode struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Enum                 []string               `json:"enum"`
+	Minimum              *int                   `json:"minimum"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	AdditionalProperties *additional            `json:"additionalProperties"`
+	Items                *schemaNode            `json:"items"`
+	Defs                 map[string]*schemaNode `json:"$defs"`
+}
+
+// additional is an additionalProperties value: false, or a schema for the
+// values of keys not listed in properties.
+type additional struct {
+	forbidden bool
+	schema    *schemaNode
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		a.forbidden = !allowed
+		return nil
+	}
+	return json.Unmarshal(data, &a.schema)
+}
+
+var rootSchema = sync.OnceValue(func() *schemaNode {
+	var s schemaNode
+	if err := json.Unmarshal(Schema, &s); err != nil {
+		panic("config: invalid embedded schema: " + err.Error())
+	}
+	return &s
+})
+
+// validate checks the parsed YAML document doc against Schema, returning
+// every mismatch, each with its location.
+func validate(doc *yaml.Node) error {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+		doc = doc.Content[0]
+	}
+	if doc.Kind == 0 {
+		return nil
+	}
+	root := rootSchema()
+	var errs []error
+	root.check(root, doc, "", &errs)
+	return errors.Join(errs...)
+}
+
+// check appends to errs every way n, found at path, does not match s.
+// References are resolved against root.
+func (s *schemaNode) check(root *schemaNode, n *yaml.Node, path string, errs *[]error) {
+	for s.Ref != "" {
+		s = root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+	}
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, &SchemaError{Line: n.Line, Column: n.Column, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != "" && kindOf(n) != s.Type {
+		fail("must be %s, got %s", article(s.Type), describe(n))
+		return
+	}
+	if len(s.Enum) > 0 {
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!str" || !slices.Contains(s.Enum, n.Value) {
+			fail("must be one of %s, got %s", strings.Join(s.Enum, ", "), describe(n))
+		}
+		return
+	}
+	if s.Minimum != nil {
+		if v, err := strconv.Atoi(n.Value); err == nil && v < *s.Minimum {
+			fail("must be at least %d, got %d", *s.Minimum, v)
+		}
+	}
+
+	switch n.Kind {
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for i, item := range n.Content {
+				s.Items.check(root, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Tag == "!!merge" {
+				// << merges another mapping, or a list of them, into this one.
+				merged := []*yaml.Node{value}
+				if value.Kind == yaml.SequenceNode {
+					merged = value.Content
+				}
+				for _, m := range merged {
+					s.check(root, m, path, errs)
+				}
+				continue
+			}
+			child := key.Value
+			if path != "" {
+				child = path + "." + key.Value
+			}
+			if prop, ok := s.Properties[key.Value]; ok {
+				prop.check(root, value, child, errs)
+				continue
+			}
+			switch a := s.AdditionalProperties; {
+			case a == nil:
+			case a.forbidden:
+				*errs = append(*errs, &SchemaError{Line: key.Line, Column: key.Column, Path: child, Message: "unknown key"})
+			default:
+				a.schema.check(root, value, child, errs)
+			}
+		}
+	}
+}
+
+// kindOf returns the JSON Schema type of n.
+func kindOf(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch n.Tag {
+	case "!!str":
+		return "string"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return n.Tag
+}
+
+// article names a JSON Schema type for messages.
+func article(kind string) string {
+	switch kind {
+	case "object":
+		return "a mapping"
+	case "array":
+		return "a list"
+	case "integer":
+		return "an integer"
+	case "null":
+		return "empty"
+	}
+	return "a " + kind
+}
+
+// describe names the value n for messages.
+func describe(n *yaml.Node) string {
+	switch kind := kindOf(n); kind {
+	case "object", "array", "null":
+		return article(kind)
+	case "string":
+		return strconv.Quote(n.Value)
+	default:
+		return kind + " " + n.Value
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSchemaResolves(t *testing.T) {
+	root := rootSchema()
+	var walk func(s *schemaNode)
+	walk = func(s *schemaNode) {
+		if s == nil {
+			return
+		}
+		if s.Ref != "" {
+			if root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")] == nil {
+				t.Errorf("unresolved $ref %q", s.Ref)
+			}
+		}
+		for _, p := range s.Properties {
+			walk(p)
+		}
+		for _, d := range s.Defs {
+			walk(d)
+		}
+		if s.AdditionalProperties != nil {
+			walk(s.AdditionalProperties.schema)
+		}
+		walk(s.Items)
+	}
+	walk(root)
+}
+
+func TestSchemaCoversConfig(t *testing.T) {
+	// Every key Config reads is described, so valid files are not rejected.
+	var node yaml.Node
+	if err := node.Encode(Config{Categories: map[string]CategoryConfig{"docs": {}}}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if _, ok := rootSchema().Properties[node.Content[i].Value]; !ok {
+			t.Errorf("config key %q is missing from the schema", node.Content[i].Value)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{"empty file", "", nil},
+		{"comments only", "# nothing yet\n", nil},
+		{"valid", `
+version: 1
+empty: include
+sort: path
+ignore_whitespace: true
+include: ["**/*.go"]
+categories:
+  docs:
+    patterns: ["handbook/**"]
+expectations:
+  handbook/intro.md: docs
+areas:
+  api: ["api/**"]
+teams:
+  payments: ["services/payments/**"]
+category: [source]
+list: true
+minified_line_length: 4000
+generated_dirs:
+  gen: true
+  bin: false
+`, nil},
+		{"merge key", `
+defaults: &defaults
+  patterns: ["x/**"]
+categories:
+  docs:
+    <<: *defaults
+`, []string{"line 2, column 1: defaults: unknown key"}},
+		{"unknown key", "sorts: path\n", []string{"line 1, column 1: sorts: unknown key"}},
+		{"bad enum", "sort: size\n", []string{`line 1, column 7: sort: must be one of churn, path, net, added, deleted, language, category, got "size"`}},
+		{"wrong type", "include: vendor/**\n", []string{`line 1, column 10: include: must be a list, got "vendor/**"`}},
+		{"nested", `
+categories:
+  docs:
+    patterns:
+      - handbook/
+      - 3
+  vendor:
+    patterns: []
+`, []string{
+			"line 6, column 9: categories.docs.patterns[1]: must be a string, got integer 3",
+			"line 7, column 3: categories.vendor: unknown key",
+		}},
+		{"bad expectation", "expectations:\n  a.md: doc\n", []string{`line 2, column 9: expectations.a.md: must be one of generated, i18n, docs, tests, ci, source, other, got "doc"`}},
+		{"minimum", "version: 0\n", []string{"line 1, column 10: version: must be at least 1, got 0"}},
+		{"negative length", "minified_line_length: -1\n", []string{"line 1, column 23: minified_line_length: must be at least 0, got -1"}},
+		{"null", "list:\n", []string{"line 1, column 6: list: must be a boolean, got empty"}},
+		{"not a mapping", "- a\n", []string{"line 1, column 1: must be a mapping, got a list"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.yaml), &node); err != nil {
+				t.Fatal(err)
+			}
+			err := validate(&node)
+			var got []string
+			if err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got errors\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLoadReportsSchemaErrors(t *testing.T) {
+	tmp := t.TempDir()
+	writeYAML(t, filepath.Join(tmp, ".differ.yml"), "empty: include\nsort: size\n")
+
+	_, err := load("", tmp, Config{})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Line != 2 || schemaErr.Path != "sort" {
+		t.Fatalf("err = %v, want a SchemaError for sort on line 2", err)
+	}
+	if !strings.Contains(err.Error(), ".differ.yml: line 2, column 7: sort:") {
+		t.Errorf("err = %q, want the file and location", err)
+	}
+
+	// A newer file's unknown keys are reported as a version problem.
+	writeYAML(t, filepath.Join(tmp, ".differ.yml"), "version: 99\nfuture_key: true\n")
+	if _, err := load("", tmp, Config{}); err == nil || !strings.Contains(err.Error(), "upgrade differ") {
+		t.Errorf("err = %v, want an upgrade message", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package daemon runs churn analysis on a schedule: it fetches configured
+// repositories, records each branch's churn to the history ledger, and
+// reports threshold breaches and metrics.
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultInterval is how often repositories are analyzed when the config
+// does not say.
+const DefaultInterval = time.Hour
+
+// Config is the daemon configuration file.
+type Config struct {
+	Interval    time.Duration `yaml:"interval"`
+	DB          string        `yaml:"db"`
+	Webhook     string        `yaml:"webhook"`
+	Pushgateway string        `yaml:"pushgateway"`
+	Thresholds  Thresholds    `yaml:"thresholds"`
+	Repos       []Repo        `yaml:"repos"`
+}
+
+// Repo is one repository to analyze. Each branch is compared against Base
+// (three-dot, using the remote-tracking refs after fetching).
+type Repo struct {
+	Name      string   `yaml:"name"`
+	Path      string   `yaml:"path"`
+	Remote    string   `yaml:"remote"`
+	Base      string   `yaml:"base"`
+	Branches  []string `yaml:"branches"`
+	Pathspecs []string `yaml:"pathspecs"`
+}
+
+// Thresholds are churn limits that trigger a notification when exceeded.
+// Zero means no limit.
+type Thresholds struct {
+	Churn      int            `yaml:"churn"`
+	Categories map[string]int `yaml:"categories"`
+}
+
+// LoadConfig reads and validates the daemon config at path, filling in
+// defaults.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if cfg.Interval == 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Interval < 0 {
+		return Config{}, fmt.Errorf("%s: interval must be positive", path)
+	}
+	if cfg.DB == "" {
+		return Config{}, fmt.Errorf("%s: db is required", path)
+	}
+	if len(cfg.Repos) == 0 {
+		return Config{}, fmt.Errorf("%s: at least one repo is required", path)
+	}
+
+	seen := make(map[string]bool)
+	for i := range cfg.Repos {
+		r := &cfg.Repos[i]
+		if r.Path == "" {
+			return Config{}, fmt.Errorf("%s: repos[%d]: path is required", path, i)
+		}
+		if r.Name == "" {
+			return Config{}, fmt.Errorf("%s: repos[%d]: name is required", path, i)
+		}
+		if seen[r.Name] {
+			return Config{}, fmt.Errorf("%s: duplicate repo name %q", path, r.Name)
+		}
+		seen[r.Name] = true
+		if len(r.Branches) == 0 {
+			return Config{}, fmt.Errorf("%s: repo %q: at least one branch is required", path, r.Name)
+		}
+		if r.Remote == "" {
+			r.Remote = "origin"
+		}
+		if r.Base == "" {
+			r.Base = "main"
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "daemon.yml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, `
+db: churn.db
+repos:
+  - name: api
+    path: /srv/api
+    branches: [develop]
+`))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Interval != DefaultInterval {
+		t.Errorf("Interval = %v, want %v", cfg.Interval, DefaultInterval)
+	}
+	r := cfg.Repos[0]
+	if r.Remote != "origin" || r.Base != "main" {
+		t.Errorf("repo defaults = remote %q base %q, want origin main", r.Remote, r.Base)
+	}
+}
+
+func TestLoadConfigFull(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, `
+interval: 15m
+db: churn.db
+webhook: https://hooks.example.com/x
+pushgateway: http://pushgateway:9091
+thresholds:
+  churn: 2000
+  categories:
+    generated: 500
+repos:
+  - name: api
+    path: /srv/api
+    remote: upstream
+    base: trunk
+    branches: [release, develop]
+    pathspecs: [src/]
+`))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Interval != 15*time.Minute {
+		t.Errorf("Interval = %v, want 15m", cfg.Interval)
+	}
+	if cfg.Thresholds.Churn != 2000 || cfg.Thresholds.Categories["generated"] != 500 {
+		t.Errorf("Thresholds = %+v", cfg.Thresholds)
+	}
+	r := cfg.Repos[0]
+	if r.Remote != "upstream" || r.Base != "trunk" || len(r.Branches) != 2 || r.Pathspecs[0] != "src/" {
+		t.Errorf("repo = %+v", r)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	cases := map[string]string{
+		"db is required":            "repos: [{name: a, path: /a, branches: [x]}]",
+		"at least one repo":         "db: x.db",
+		"path is required":          "db: x.db\nrepos: [{name: a, branches: [x]}]",
+		"name is required":          "db: x.db\nrepos: [{path: /a, branches: [x]}]",
+		"duplicate repo name":       "db: x.db\nrepos: [{name: a, path: /a, branches: [x]}, {name: a, path: /b, branches: [y]}]",
+		"at least one branch":       "db: x.db\nrepos: [{name: a, path: /a}]",
+		"interval must be positive": "db: x.db\ninterval: -1m\nrepos: [{name: a, path: /a, branches: [x]}]",
+		"field bogus not found":     "db: x.db\nbogus: 1\nrepos: [{name: a, path: /a, branches: [x]}]",
+	}
+	for want, content := range cases {
+		_, err := LoadConfig(writeConfig(t, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("config %q: got error %v, want one containing %q", content, err, want)
+		}
+	}
+}
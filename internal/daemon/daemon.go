@@ -0,0 +1,274 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+)
+
+// AnalyzeFunc computes the churn summary for refRange in the repository at
+// dir.
+type AnalyzeFunc func(dir, refRange string, pathspecs []string) (output.Summary, error)
+
+// Daemon periodically analyzes the configured repositories.
+type Daemon struct {
+	Config  Config
+	Analyze AnalyzeFunc
+	Log     io.Writer    // progress and per-repo errors; defaults to os.Stderr
+	HTTP    *http.Client // for webhook and Pushgateway requests
+
+	// latest holds the most recent record per branch, keyed by branchKey;
+	// it deduplicates unchanged heads and feeds the metrics.
+	latest map[string]ledger.Record
+}
+
+// Breach is a threshold exceeded by an analysis.
+type Breach struct {
+	Metric string `json:"metric"` // "churn" or "<category>.churn"
+	Value  int    `json:"value"`
+	Limit  int    `json:"limit"`
+}
+
+// Run analyzes all repositories immediately and then every Config.Interval
+// until ctx is cancelled.
+func (d *Daemon) Run(ctx context.Context) error {
+	if err := d.RunOnce(ctx); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(d.Config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := d.RunOnce(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// RunOnce performs a single analysis cycle. Failures in individual
+// repositories are logged and do not stop the cycle; only an unusable
+// ledger is returned as an error.
+func (d *Daemon) RunOnce(ctx context.Context) error {
+	if d.latest == nil {
+		if err := d.loadLatest(); err != nil {
+			return err
+		}
+	}
+
+	for _, repo := range d.Config.Repos {
+		if ctx.Err() != nil {
+			return nil
+		}
+		runner := gitdiff.DirRunner{Dir: repo.Path}
+		if _, err := runner.Run("git", "fetch", "--quiet", "--prune", repo.Remote); err != nil {
+			d.logf("%s: fetch %s failed: %v", repo.Name, repo.Remote, err)
+			continue
+		}
+		for _, branch := range repo.Branches {
+			d.analyzeBranch(ctx, runner, repo, branch)
+		}
+	}
+
+	if d.Config.Pushgateway != "" {
+		if err := d.pushMetrics(ctx); err != nil {
+			d.logf("pushing metrics: %v", err)
+		}
+	}
+	return nil
+}
+
+func (d *Daemon) analyzeBranch(ctx context.Context, runner gitdiff.CommandRunner, repo Repo, branch string) {
+	base := repo.Remote + "/" + repo.Base
+	head := repo.Remote + "/" + branch
+	key := branchKey(repo.Name, head)
+
+	sha, err := gitdiff.ResolveCommit(runner, head)
+	if err != nil {
+		d.logf("%s: %v", repo.Name, err)
+		return
+	}
+	if prev, ok := d.latest[key]; ok && prev.HeadSHA == sha {
+		return
+	}
+
+	summary, err := d.Analyze(repo.Path, base+"..."+head, repo.Pathspecs)
+	if err != nil {
+		d.logf("%s: analyzing %s...%s: %v", repo.Name, base, head, err)
+		return
+	}
+
+	rec := ledger.Record{
+		Repo:       repo.Name,
+		RecordedAt: time.Now().UTC(),
+		HeadSHA:    sha,
+		Snapshot:   snapshot.FromSummary(summary),
+	}
+	if err := ledger.Append(d.Config.DB, rec); err != nil {
+		d.logf("%s: recording %s: %v", repo.Name, head, err)
+		return
+	}
+	d.latest[key] = rec
+	d.logf("%s: recorded %s at %.7s (churn %d)", repo.Name, head, sha, summary.Totals.Churn)
+
+	breaches := CheckThresholds(d.Config.Thresholds, summary)
+	if len(breaches) > 0 && d.Config.Webhook != "" {
+		if err := d.notify(ctx, rec, breaches); err != nil {
+			d.logf("%s: notifying: %v", repo.Name, err)
+		}
+	}
+}
+
+// loadLatest seeds the per-branch state from the ledger so a restarted
+// daemon does not re-record unchanged branches.
+func (d *Daemon) loadLatest() error {
+	d.latest = make(map[string]ledger.Record)
+	records, err := ledger.Read(d.Config.DB)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		d.latest[branchKey(rec.Repo, rec.Snapshot.Meta.Head)] = rec
+	}
+	return nil
+}
+
+// CheckThresholds returns the thresholds summary exceeds, total churn first
+// and then categories in name order.
+func CheckThresholds(t Thresholds, summary output.Summary) []Breach {
+	var breaches []Breach
+	if t.Churn > 0 && summary.Totals.Churn > t.Churn {
+		breaches = append(breaches, Breach{Metric: "churn", Value: summary.Totals.Churn, Limit: t.Churn})
+	}
+	cats := make([]string, 0, len(t.Categories))
+	for cat := range t.Categories {
+		cats = append(cats, cat)
+	}
+	sort.Strings(cats)
+	for _, cat := range cats {
+		limit := t.Categories[cat]
+		if churn := summary.CategoryTotals[cat].Churn; limit > 0 && churn > limit {
+			breaches = append(breaches, Breach{Metric: cat + ".churn", Value: churn, Limit: limit})
+		}
+	}
+	return breaches
+}
+
+// notify posts a breach report to the webhook. The "text" field makes the
+// payload usable as-is with Slack-compatible incoming webhooks.
+func (d *Daemon) notify(ctx context.Context, rec ledger.Record, breaches []Breach) error {
+	parts := make([]string, 0, len(breaches))
+	for _, b := range breaches {
+		parts = append(parts, fmt.Sprintf("%s %d > %d", b.Metric, b.Value, b.Limit))
+	}
+	payload := map[string]any{
+		"text":     fmt.Sprintf("differ: %s %s...%s exceeded thresholds: %s", rec.Repo, rec.Snapshot.Meta.Base, rec.Snapshot.Meta.Head, strings.Join(parts, ", ")),
+		"repo":     rec.Repo,
+		"base":     rec.Snapshot.Meta.Base,
+		"head":     rec.Snapshot.Meta.Head,
+		"head_sha": rec.HeadSHA,
+		"total":    rec.Snapshot.Total,
+		"breaches": breaches,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return d.send(ctx, http.MethodPost, d.Config.Webhook, "application/json", body)
+}
+
+// pushMetrics replaces the daemon's metric group on the Prometheus
+// Pushgateway with the latest churn of every branch.
+func (d *Daemon) pushMetrics(ctx context.Context) error {
+	url := strings.TrimSuffix(d.Config.Pushgateway, "/") + "/metrics/job/differ"
+	return d.send(ctx, http.MethodPut, url, "text/plain; version=0.0.4", FormatMetrics(d.latest))
+}
+
+// FormatMetrics renders records in the Prometheus text exposition format,
+// one series per repository, branch, and category (plus "total").
+func FormatMetrics(latest map[string]ledger.Record) []byte {
+	keys := make([]string, 0, len(latest))
+	for k := range latest {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	b.WriteString("# HELP differ_churn_lines Lines of churn in the latest recorded analysis of a branch.\n")
+	b.WriteString("# TYPE differ_churn_lines gauge\n")
+	for _, k := range keys {
+		rec := latest[k]
+		writeSeries(&b, "differ_churn_lines", rec, "total", rec.Snapshot.Total.Churn)
+		cats := make([]string, 0, len(rec.Snapshot.Categories))
+		for cat := range rec.Snapshot.Categories {
+			cats = append(cats, cat)
+		}
+		sort.Strings(cats)
+		for _, cat := range cats {
+			writeSeries(&b, "differ_churn_lines", rec, cat, rec.Snapshot.Categories[cat].Churn)
+		}
+	}
+	b.WriteString("# HELP differ_recorded_timestamp_seconds When the latest analysis of a branch was recorded.\n")
+	b.WriteString("# TYPE differ_recorded_timestamp_seconds gauge\n")
+	for _, k := range keys {
+		rec := latest[k]
+		fmt.Fprintf(&b, "differ_recorded_timestamp_seconds{repo=%q,branch=%q} %d\n", rec.Repo, rec.Snapshot.Meta.Head, rec.RecordedAt.Unix())
+	}
+	return b.Bytes()
+}
+
+func writeSeries(b *bytes.Buffer, name string, rec ledger.Record, category string, value int) {
+	fmt.Fprintf(b, "%s{repo=%q,branch=%q,category=%q} %d\n", name, rec.Repo, rec.Snapshot.Meta.Head, category, value)
+}
+
+func (d *Daemon) send(ctx context.Context, method, url, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	client := d.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func (d *Daemon) logf(format string, args ...any) {
+	w := d.Log
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "%s "+format+"\n", append([]any{time.Now().UTC().Format(time.RFC3339)}, args...)...)
+}
+
+func branchKey(repo, head string) string {
+	return repo + "\x00" + head
+}
@@ -0,0 +1,186 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+)
+
+func TestCheckThresholds(t *testing.T) {
+	summary := output.Summary{
+		Totals: output.CategoryTotal{Churn: 300},
+		CategoryTotals: map[string]output.CategoryTotal{
+			"generated": {Churn: 200},
+			"source":    {Churn: 100},
+		},
+	}
+	got := CheckThresholds(Thresholds{
+		Churn:      250,
+		Categories: map[string]int{"source": 100, "generated": 150, "docs": 1},
+	}, summary)
+	want := []Breach{
+		{Metric: "churn", Value: 300, Limit: 250},
+		{Metric: "generated.churn", Value: 200, Limit: 150},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("CheckThresholds = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("breach %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := CheckThresholds(Thresholds{}, summary); len(got) != 0 {
+		t.Errorf("zero thresholds should never breach, got %+v", got)
+	}
+}
+
+func TestFormatMetrics(t *testing.T) {
+	rec := ledger.Record{
+		Repo:       "api",
+		RecordedAt: time.Unix(1700000000, 0),
+		Snapshot: snapshot.Snapshot{
+			Meta:       output.Meta{Head: "origin/develop"},
+			Total:      snapshot.Totals{Churn: 30},
+			Categories: map[string]snapshot.Totals{"tests": {Churn: 10}, "source": {Churn: 20}},
+		},
+	}
+	got := string(FormatMetrics(map[string]ledger.Record{branchKey("api", "origin/develop"): rec}))
+	for _, line := range []string{
+		`differ_churn_lines{repo="api",branch="origin/develop",category="total"} 30`,
+		`differ_churn_lines{repo="api",branch="origin/develop",category="source"} 20`,
+		`differ_churn_lines{repo="api",branch="origin/develop",category="tests"} 10`,
+		`differ_recorded_timestamp_seconds{repo="api",branch="origin/develop"} 1700000000`,
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("metrics missing %q:\n%s", line, got)
+		}
+	}
+	if strings.Index(got, `category="source"`) > strings.Index(got, `category="tests"`) {
+		t.Errorf("categories should be sorted:\n%s", got)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestIntegration_RunOnce(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmp := t.TempDir()
+	upstream := filepath.Join(tmp, "upstream")
+	os.MkdirAll(upstream, 0o755)
+	git(t, upstream, "init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(upstream, "a.txt"), []byte("a\n"), 0o644)
+	git(t, upstream, "add", "-A")
+	git(t, upstream, "commit", "-qm", "initial")
+	git(t, upstream, "checkout", "-qb", "develop")
+	os.WriteFile(filepath.Join(upstream, "b.txt"), []byte("b\n"), 0o644)
+	git(t, upstream, "add", "-A")
+	git(t, upstream, "commit", "-qm", "develop")
+
+	clone := filepath.Join(tmp, "clone")
+	git(t, tmp, "clone", "-q", upstream, clone)
+
+	var (
+		mu       sync.Mutex
+		requests = map[string][]string{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path] = append(requests[r.Method+" "+r.URL.Path], string(body))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	var analyzed []string
+	d := &Daemon{
+		Config: Config{
+			DB:          filepath.Join(tmp, "churn.db"),
+			Webhook:     srv.URL + "/hook",
+			Pushgateway: srv.URL,
+			Thresholds:  Thresholds{Churn: 5},
+			Repos: []Repo{
+				{Name: "api", Path: clone, Remote: "origin", Base: "main", Branches: []string{"develop", "missing"}},
+				{Name: "broken", Path: filepath.Join(tmp, "nope"), Remote: "origin", Base: "main", Branches: []string{"x"}},
+			},
+		},
+		Analyze: func(dir, refRange string, pathspecs []string) (output.Summary, error) {
+			analyzed = append(analyzed, refRange)
+			base, head, _ := strings.Cut(refRange, "...")
+			return output.Summary{
+				Totals:         output.CategoryTotal{Added: 10, Churn: 10, FileCount: 1},
+				CategoryTotals: map[string]output.CategoryTotal{"other": {Added: 10, Churn: 10, FileCount: 1}},
+				Meta:           output.Meta{Base: base, Head: head},
+			}, nil
+		},
+		Log: io.Discard,
+	}
+
+	if err := d.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if len(analyzed) != 1 || analyzed[0] != "origin/main...origin/develop" {
+		t.Errorf("analyzed = %v, want only origin/main...origin/develop", analyzed)
+	}
+	records, err := ledger.Read(d.Config.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Repo != "api" || records[0].HeadSHA != git(t, upstream, "rev-parse", "develop") {
+		t.Errorf("records = %+v", records)
+	}
+	if hooks := requests["POST /hook"]; len(hooks) != 1 || !strings.Contains(hooks[0], `"text":"differ: api origin/main...origin/develop exceeded thresholds: churn 10 \u003e 5"`) {
+		t.Errorf("webhook requests = %v", hooks)
+	}
+	if pushes := requests["PUT /metrics/job/differ"]; len(pushes) != 1 || !strings.Contains(pushes[0], `category="total"} 10`) {
+		t.Errorf("pushgateway requests = %v", pushes)
+	}
+
+	// A restarted daemon skips the unchanged branch but still pushes metrics.
+	d2 := &Daemon{Config: d.Config, Analyze: d.Analyze, Log: io.Discard}
+	if err := d2.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if len(analyzed) != 1 {
+		t.Errorf("unchanged branch was re-analyzed: %v", analyzed)
+	}
+	if len(requests["POST /hook"]) != 1 || len(requests["PUT /metrics/job/differ"]) != 2 {
+		t.Errorf("unexpected requests after second cycle: %v", requests)
+	}
+
+	var payload map[string]any
+	json.Unmarshal([]byte(requests["POST /hook"][0]), &payload)
+	if payload["head_sha"] != records[0].HeadSHA {
+		t.Errorf("webhook head_sha = %v, want %s", payload["head_sha"], records[0].HeadSHA)
+	}
+}
@@ -0,0 +1,178 @@
+// Package deps recognizes dependency manifests and lockfiles and extracts the
+// packages touched by a diff of them.
+package deps
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// lineParser returns the package named on an added manifest line, or "".
+type lineParser func(line string) string
+
+// manifests maps dependency file base names to the parser for their added
+// lines. Lockfiles without a useful line format map to nil: they count as
+// dependency files but contribute no package names.
+var manifests = map[string]lineParser{
+	"go.mod":              goModLine,
+	"go.sum":              goSumLine,
+	"package.json":        jsonDepLine,
+	"composer.json":       jsonDepLine,
+	"package-lock.json":   nil,
+	"npm-shrinkwrap.json": nil,
+	"yarn.lock":           nil,
+	"pnpm-lock.yaml":      nil,
+	"composer.lock":       nil,
+	"pyproject.toml":      tomlDepLine,
+	"poetry.lock":         nil,
+	"Pipfile":             tomlDepLine,
+	"Pipfile.lock":        nil,
+	"Cargo.toml":          tomlDepLine,
+	"Cargo.lock":          nil,
+	"Gemfile":             gemfileLine,
+	"Gemfile.lock":        nil,
+}
+
+// IsDependencyFile reports whether p is a dependency manifest or lockfile.
+func IsDependencyFile(p string) bool {
+	base := path.Base(p)
+	if _, ok := manifests[base]; ok {
+		return true
+	}
+	return isRequirements(base)
+}
+
+// isRequirements matches pip requirement files such as requirements.txt and
+// requirements-dev.txt.
+func isRequirements(base string) bool {
+	return strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt")
+}
+
+// Packages reads unified diff output of dependency files and returns the
+// sorted, distinct names of packages on added lines, i.e. packages that were
+// bumped or added.
+func Packages(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	seen := make(map[string]bool)
+	var parse lineParser
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, "+++ "); ok {
+			parse = parserFor(strings.TrimPrefix(rest, "b/"))
+			continue
+		}
+		if parse == nil || !strings.HasPrefix(line, "+") {
+			continue
+		}
+		if name := parse(line[1:]); name != "" {
+			seen[name] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func parserFor(p string) lineParser {
+	base := path.Base(p)
+	if isRequirements(base) {
+		return requirementsLine
+	}
+	return manifests[base]
+}
+
+// goModLine handles "require mod v1.2.3" and "\tmod v1.2.3 // indirect".
+func goModLine(line string) string {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "require "))
+	if len(fields) < 2 || !strings.HasPrefix(fields[1], "v") || !strings.ContainsAny(fields[0], "./") {
+		return ""
+	}
+	return fields[0]
+}
+
+// goSumLine handles "mod v1.2.3 h1:..." and "mod v1.2.3/go.mod h1:...".
+func goSumLine(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) != 3 || !strings.HasPrefix(fields[2], "h1:") {
+		return ""
+	}
+	return fields[0]
+}
+
+var (
+	jsonDepRe = regexp.MustCompile(`^\s*"([^"]+)"\s*:\s*"([^"]*)"`)
+	tomlDepRe = regexp.MustCompile(`^\s*([A-Za-z0-9_.\-]+)\s*=\s*(?:"([^"]*)"|\{.*version\s*=\s*"([^"]*)")`)
+	quotedReq = regexp.MustCompile(`^\s*"([A-Za-z0-9_.\-\[\]]+)\s*[=<>!~]`)
+	gemRe     = regexp.MustCompile(`^\s*gem\s+["']([^"']+)["']`)
+	versionRe = regexp.MustCompile(`^(?:[\^~<>=!*]|v?\d|workspace:|npm:|git[+:]|https?:|file:|link:)`)
+)
+
+// Keys that hold versions but do not name dependencies.
+var nonPackageKeys = map[string]bool{
+	"version": true, "node": true, "npm": true, "yarn": true, "pnpm": true,
+	"php": true, "python": true, "rust-version": true, "edition": true,
+}
+
+// jsonDepLine handles package.json and composer.json entries such as
+// `"lodash": "^4.17.21",`.
+func jsonDepLine(line string) string {
+	m := jsonDepRe.FindStringSubmatch(line)
+	if m == nil || nonPackageKeys[m[1]] || !versionRe.MatchString(m[2]) {
+		return ""
+	}
+	return m[1]
+}
+
+// tomlDepLine handles `serde = "1.0"`, `serde = { version = "1.0" }`, and
+// PEP 621 list entries such as `"requests>=2.31",`.
+func tomlDepLine(line string) string {
+	if m := quotedReq.FindStringSubmatch(line); m != nil {
+		return requirementName(m[1])
+	}
+	m := tomlDepRe.FindStringSubmatch(line)
+	if m == nil || nonPackageKeys[m[1]] {
+		return ""
+	}
+	version := m[2] + m[3]
+	if !versionRe.MatchString(version) {
+		return ""
+	}
+	return m[1]
+}
+
+// requirementsLine handles pip lines such as "requests==2.31.0".
+func requirementsLine(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+		return ""
+	}
+	return requirementName(line)
+}
+
+func requirementName(spec string) string {
+	if i := strings.IndexAny(spec, "=<>!~[;@ "); i >= 0 {
+		spec = spec[:i]
+	}
+	return strings.TrimSpace(spec)
+}
+
+// gemfileLine handles `gem "rails", "~> 7.0"`.
+func gemfileLine(line string) string {
+	if m := gemRe.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package deps
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsDependencyFile(t *testing.T) {
+	for _, p := range []string{"go.mod", "go.sum", "web/package.json", "web/package-lock.json", "yarn.lock",
+		"requirements.txt", "requirements-dev.txt", "poetry.lock", "pyproject.toml", "Cargo.lock"} {
+		if !IsDependencyFile(p) {
+			t.Errorf("IsDependencyFile(%q) = false, want true", p)
+		}
+	}
+	for _, p := range []string{"main.go", "go.mod.bak", "docs/requirements.md", "package.json5", "mod/go.modx"} {
+		if IsDependencyFile(p) {
+			t.Errorf("IsDependencyFile(%q) = true, want false", p)
+		}
+	}
+}
+
+func TestPackages(t *testing.T) {
+	diff := `diff --git a/go.mod b/go.mod
+--- a/go.mod
++++ b/go.mod
+@@ -3 +3 @@
+-go 1.24
++go 1.25
+@@ -6,2 +6,2 @@
+-	github.com/spf13/cobra v1.9.0
+-	golang.org/x/sys v0.30.0 // indirect
++	github.com/spf13/cobra v1.10.1
++	golang.org/x/sys v0.31.0 // indirect
+diff --git a/go.sum b/go.sum
+--- a/go.sum
++++ b/go.sum
+@@ -1,0 +1,2 @@
++github.com/spf13/cobra v1.10.1 h1:abc=
++github.com/spf13/cobra v1.10.1/go.mod h1:def=
+diff --git a/web/package.json b/web/package.json
+--- a/web/package.json
++++ b/web/package.json
+@@ -3 +3 @@
+-  "version": "1.0.0",
++  "version": "1.1.0",
+@@ -8 +8,2 @@
+-    "lodash": "^4.17.20",
++    "lodash": "^4.17.21",
++    "@types/node": "~20.1.0"
+@@ -12 +13 @@
+-    "test": "jest"
++    "test": "jest --ci"
+diff --git a/requirements.txt b/requirements.txt
+--- a/requirements.txt
++++ b/requirements.txt
+@@ -1,0 +1,3 @@
++# pinned
++requests[socks]==2.31.0
++-r base.txt
+diff --git a/pyproject.toml b/pyproject.toml
+--- a/pyproject.toml
++++ b/pyproject.toml
+@@ -2 +2,3 @@
++python = "^3.11"
++httpx = { version = "^0.27", extras = ["http2"] }
++  "rich>=13",
+diff --git a/Gemfile b/Gemfile
+--- a/Gemfile
++++ b/Gemfile
+@@ -1 +1 @@
++gem "rails", "~> 7.1"
+diff --git a/yarn.lock b/yarn.lock
+--- a/yarn.lock
++++ b/yarn.lock
+@@ -1 +1 @@
++  version "4.17.21"
+`
+	got, err := Packages(strings.NewReader(diff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"@types/node", "github.com/spf13/cobra", "golang.org/x/sys", "httpx", "lodash", "rails", "requests", "rich"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Packages() = %q, want %q", got, want)
+	}
+}
@@ -1,29 +1,64 @@
 package filter
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/jbonatakis/differ/internal/classify"
+	"github.com/jbonatakis/differ/internal/config"
 	"github.com/jbonatakis/differ/internal/parser"
 )
 
 // FilterConfig controls which files to keep or discard.
+//
+// Include and Exclude patterns are evaluated in order, and a pattern
+// prefixed with "!" negates earlier matches, as in .gitignore:
+// Exclude ["vendor/**", "!vendor/patches/**"] keeps vendor/patches.
+// A pattern prefixed with a category name and a colon applies only to
+// files of that category (see SplitCategory): Exclude
+// ["tests:**/fixtures/**"] drops test fixtures but keeps source files
+// under a fixtures directory.
 type FilterConfig struct {
 	Include    []string // glob patterns; if non-empty, only matching files are kept
 	Exclude    []string // glob patterns; matching files are removed
 	Categories []string // category names; if non-empty, only matching categories are kept
+	// Scopes are per-directory configs. For paths under a scope that sets
+	// include (or exclude) patterns, those patterns, relative to the scope
+	// directory, replace Include (or Exclude); the innermost such scope wins.
+	Scopes []config.Scope
 }
 
 // CategoryFunc returns the category string for a given file path.
 type CategoryFunc func(path string) string
 
 // Filter applies include/exclude glob patterns and category restrictions to stats.
-// categoryFn is called to determine each file's category when Categories is non-empty.
+// categoryFn is called to determine each file's category when Categories is
+// non-empty or a pattern is category-scoped; without it, category-scoped
+// patterns are ignored.
 func Filter(stats []parser.FileStat, cfg FilterConfig, categoryFn CategoryFunc) []parser.FileStat {
 	var result []parser.FileStat
 	for _, fs := range stats {
-		if !matchInclude(fs.Path, cfg.Include) {
+		// The category is looked up once, and only if a pattern needs it.
+		category, known := "", false
+		categoryOf := func() string {
+			if !known && categoryFn != nil {
+				category, known = categoryFn(fs.Path), true
+			}
+			return category
+		}
+		include, includePath := cfg.Include, fs.Path
+		if s, rel, ok := config.ScopeFor(cfg.Scopes, fs.Path, func(s config.Scope) bool { return len(s.Include) > 0 }); ok {
+			include, includePath = s.Include, rel
+		}
+		if !matchInclude(includePath, forCategory(include, categoryOf)) {
 			continue
 		}
-		if matchExclude(fs.Path, cfg.Exclude) {
+		exclude, excludePath := cfg.Exclude, fs.Path
+		if s, rel, ok := config.ScopeFor(cfg.Scopes, fs.Path, func(s config.Scope) bool { return len(s.Exclude) > 0 }); ok {
+			exclude, excludePath = s.Exclude, rel
+		}
+		if matchExclude(excludePath, forCategory(exclude, categoryOf)) {
 			continue
 		}
 		if !matchCategory(fs.Path, cfg.Categories, categoryFn) {
@@ -34,28 +69,83 @@ func Filter(stats []parser.FileStat, cfg FilterConfig, categoryFn CategoryFunc)
 	return result
 }
 
-// matchInclude returns true if the path matches at least one include pattern,
-// or if there are no include patterns.
-func matchInclude(path string, patterns []string) bool {
-	if len(patterns) == 0 {
-		return true
+// SplitCategory splits a category-scoped pattern, such as
+// "tests:**/fixtures/**", into the category and the pattern without it. A
+// "!" may come before the category or after the colon; either way it is
+// kept at the front of the returned pattern. category is "" for patterns
+// whose text before the first colon is not a built-in category name, which
+// are returned unchanged.
+func SplitCategory(pattern string) (category, glob string) {
+	negated := strings.HasPrefix(pattern, "!")
+	name, rest, ok := strings.Cut(strings.TrimPrefix(pattern, "!"), ":")
+	if !ok || !slices.Contains(classify.Categories, name) {
+		return "", pattern
 	}
-	for _, p := range patterns {
-		if matched, _ := doublestar.Match(p, path); matched {
-			return true
+	if negated && !strings.HasPrefix(rest, "!") {
+		rest = "!" + rest
+	}
+	return name, rest
+}
+
+// forCategory returns the patterns that apply to a file whose category
+// categoryOf returns: those without a category, and those scoped to its
+// category, without the scope. categoryOf is only called if a pattern is
+// scoped.
+func forCategory(patterns []string, categoryOf func() string) []string {
+	var applied []string
+	for i, p := range patterns {
+		cat, glob := SplitCategory(p)
+		if cat == "" {
+			if applied != nil {
+				applied = append(applied, p)
+			}
+			continue
+		}
+		if applied == nil {
+			applied = append(make([]string, 0, len(patterns)), patterns[:i]...)
+		}
+		if cat == categoryOf() {
+			applied = append(applied, glob)
 		}
 	}
-	return false
+	if applied == nil {
+		return patterns
+	}
+	return applied
 }
 
-// matchExclude returns true if the path matches any exclude pattern.
+// matchInclude returns true if the path matches the include patterns, or if
+// there are no positive include patterns.
+func matchInclude(path string, patterns []string) bool {
+	matched, ok := Match(path, patterns)
+	if !ok {
+		for _, p := range patterns {
+			if !strings.HasPrefix(p, "!") {
+				return false
+			}
+		}
+		return true
+	}
+	return matched
+}
+
+// matchExclude returns true if the path matches the exclude patterns.
 func matchExclude(path string, patterns []string) bool {
+	matched, _ := Match(path, patterns)
+	return matched
+}
+
+// Match evaluates glob patterns against path in order. A pattern prefixed
+// with "!" negates the match, so the last matching pattern wins. ok is false
+// if no pattern matched.
+func Match(path string, patterns []string) (matched, ok bool) {
 	for _, p := range patterns {
-		if matched, _ := doublestar.Match(p, path); matched {
-			return true
+		negated := strings.HasPrefix(p, "!")
+		if m, _ := doublestar.Match(strings.TrimPrefix(p, "!"), path); m {
+			matched, ok = !negated, true
 		}
 	}
-	return false
+	return matched, ok
 }
 
 // matchCategory returns true if the file's category is in the allowed list,
@@ -1,8 +1,10 @@
 package filter
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/jbonatakis/differ/internal/config"
 	"github.com/jbonatakis/differ/internal/parser"
 )
 
@@ -190,3 +192,141 @@ func TestMultipleCategories(t *testing.T) {
 		t.Errorf("multiple categories: got %v, want %v", got, want)
 	}
 }
+
+func TestNegatedExclude(t *testing.T) {
+	input := []parser.FileStat{
+		fs("vendor/lib/a.go"),
+		fs("vendor/patches/fix.patch"),
+		fs("src/main.go"),
+	}
+	cfg := FilterConfig{Exclude: []string{"vendor/**", "!vendor/patches/**"}}
+	got := paths(Filter(input, cfg, nil))
+	want := []string{"vendor/patches/fix.patch", "src/main.go"}
+	if !eq(got, want) {
+		t.Errorf("negated exclude: got %v, want %v", got, want)
+	}
+}
+
+func TestNegatedInclude(t *testing.T) {
+	input := []parser.FileStat{
+		fs("src/main.go"),
+		fs("src/gen/api.go"),
+		fs("docs/readme.md"),
+	}
+	cfg := FilterConfig{Include: []string{"src/**", "!src/gen/**"}}
+	got := paths(Filter(input, cfg, nil))
+	want := []string{"src/main.go"}
+	if !eq(got, want) {
+		t.Errorf("negated include: got %v, want %v", got, want)
+	}
+
+	// Only negations: everything else is included.
+	cfg = FilterConfig{Include: []string{"!docs/**"}}
+	got = paths(Filter(input, cfg, nil))
+	want = []string{"src/main.go", "src/gen/api.go"}
+	if !eq(got, want) {
+		t.Errorf("negation-only include: got %v, want %v", got, want)
+	}
+}
+
+func TestMatchLastPatternWins(t *testing.T) {
+	patterns := []string{"a/**", "!a/b/**", "a/b/keep.go"}
+	for path, want := range map[string]bool{"a/x.go": true, "a/b/y.go": false, "a/b/keep.go": true} {
+		if got, ok := Match(path, patterns); !ok || got != want {
+			t.Errorf("Match(%q) = %v, %v; want %v, true", path, got, ok, want)
+		}
+	}
+	if _, ok := Match("c.go", patterns); ok {
+		t.Error("Match(c.go) should report no match")
+	}
+}
+
+func TestScopedIncludeExclude(t *testing.T) {
+	input := []parser.FileStat{
+		fs("src/main.go"),
+		fs("packages/web/src/app.ts"),
+		fs("packages/web/dist/app.js"),
+		fs("packages/web/README.md"),
+		fs("packages/api/main.go"),
+		fs("packages/api/gen/api.go"),
+	}
+	cfg := FilterConfig{
+		Include: []string{"**/*.go", "**/*.ts", "**/*.js"},
+		Exclude: []string{"**/gen/**"},
+		Scopes: []config.Scope{
+			{Dir: "packages/web", Config: config.Config{Exclude: []string{"dist/**"}}},
+			{Dir: "packages/api", Config: config.Config{Include: []string{"*.go"}}},
+		},
+	}
+	got := paths(Filter(input, cfg, nil))
+	want := []string{"src/main.go", "packages/web/src/app.ts", "packages/api/main.go"}
+	if !eq(got, want) {
+		t.Errorf("scoped filters: got %v, want %v", got, want)
+	}
+}
+
+func TestCategoryScopedPatterns(t *testing.T) {
+	input := []parser.FileStat{
+		fs("tests/fixtures/big.json"),
+		fs("tests/fixtures/keep/small.json"),
+		fs("tests/api_test.go"),
+		fs("src/fixtures/loader.go"),
+		fs("docs/guide.md"),
+	}
+	categoryOf := func(path string) string {
+		switch {
+		case strings.HasPrefix(path, "tests/"):
+			return "tests"
+		case strings.HasPrefix(path, "docs/"):
+			return "docs"
+		}
+		return "source"
+	}
+	calls := 0
+	counted := func(path string) string {
+		calls++
+		return categoryOf(path)
+	}
+
+	cfg := FilterConfig{Exclude: []string{"tests:**/fixtures/**", "!tests:**/fixtures/keep/**"}}
+	got := paths(Filter(input, cfg, counted))
+	want := []string{"tests/fixtures/keep/small.json", "tests/api_test.go", "src/fixtures/loader.go", "docs/guide.md"}
+	if !eq(got, want) {
+		t.Errorf("scoped exclude: got %v, want %v", got, want)
+	}
+	if calls != len(input) {
+		t.Errorf("category looked up %d times, want once per file", calls)
+	}
+
+	// A scoped include narrows only its own category.
+	cfg = FilterConfig{Include: []string{"docs:docs/reference/**", "tests:!**/fixtures/**"}}
+	got = paths(Filter(input, cfg, categoryOf))
+	want = []string{"tests/api_test.go", "src/fixtures/loader.go"}
+	if !eq(got, want) {
+		t.Errorf("scoped include: got %v, want %v", got, want)
+	}
+
+	// Without a category function, scoped patterns do not apply.
+	got = paths(Filter(input, FilterConfig{Exclude: []string{"tests:**/fixtures/**"}}, nil))
+	if len(got) != len(input) {
+		t.Errorf("scoped exclude without categories: got %v, want every file", got)
+	}
+}
+
+func TestSplitCategory(t *testing.T) {
+	tests := []struct {
+		pattern, category, glob string
+	}{
+		{"tests:**/fixtures/**", "tests", "**/fixtures/**"},
+		{"!tests:**/keep/**", "tests", "!**/keep/**"},
+		{"tests:!**/keep/**", "tests", "!**/keep/**"},
+		{"vendor/**", "", "vendor/**"},
+		{"notes:*.md", "", "notes:*.md"},
+		{"!docs/**", "", "!docs/**"},
+	}
+	for _, tt := range tests {
+		if category, glob := SplitCategory(tt.pattern); category != tt.category || glob != tt.glob {
+			t.Errorf("SplitCategory(%q) = %q, %q; want %q, %q", tt.pattern, category, glob, tt.category, tt.glob)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package gate
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// Fields lists the per-metric values a Condition can compare.
+var Fields = []string{"churn", "added", "deleted", "net", "files"}
+
+// operators lists the comparisons a Condition accepts, two-character ones
+// first so that ">=" is not read as ">".
+var operators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// Condition compares one value of a summary with a constant, such as
+// "generated.churn>0", for failing a run when it holds.
+type Condition struct {
+	// Metric is "total" or a category name.
+	Metric string
+	// Field is one of Fields.
+	Field string
+	Op    string
+	Value int
+}
+
+// String returns the condition in the form accepted by ParseCondition.
+func (c Condition) String() string {
+	return c.Metric + "." + c.Field + c.Op + strconv.Itoa(c.Value)
+}
+
+// ParseCondition parses "metric[.field] op value", such as
+// "generated.churn>0" or "total.files >= 50". The field defaults to churn;
+// op is one of >, >=, <, <=, ==, and !=.
+func ParseCondition(s string) (Condition, error) {
+	expr := strings.ReplaceAll(s, " ", "")
+	var c Condition
+	var lhs, rhs string
+	for _, op := range operators {
+		if l, r, ok := strings.Cut(expr, op); ok {
+			lhs, rhs, c.Op = l, r, op
+			break
+		}
+	}
+	if c.Op == "" {
+		return Condition{}, fmt.Errorf("invalid condition %q: expected a comparison such as generated.churn>0", s)
+	}
+	c.Metric, c.Field, _ = strings.Cut(lhs, ".")
+	if c.Field == "" {
+		c.Field = "churn"
+	}
+	if c.Metric == "" {
+		return Condition{}, fmt.Errorf("invalid condition %q: empty metric", s)
+	}
+	if !slices.Contains(Fields, c.Field) {
+		return Condition{}, fmt.Errorf("invalid condition %q: field must be one of %s", s, strings.Join(Fields, ", "))
+	}
+	n, err := strconv.Atoi(rhs)
+	if err != nil {
+		return Condition{}, fmt.Errorf("invalid condition %q: expected a whole number after %s", s, c.Op)
+	}
+	c.Value = n
+	return c, nil
+}
+
+// Actual returns the value c compares in summary.
+func (c Condition) Actual(summary output.Summary) int {
+	t := summary.Totals
+	if c.Metric != TotalMetric {
+		t = summary.CategoryTotals[c.Metric]
+	}
+	switch c.Field {
+	case "added":
+		return t.Added
+	case "deleted":
+		return t.Deleted
+	case "net":
+		return t.Net()
+	case "files":
+		return t.FileCount
+	}
+	return t.Churn
+}
+
+// Holds reports whether c is true of summary.
+func (c Condition) Holds(summary output.Summary) bool {
+	actual := c.Actual(summary)
+	switch c.Op {
+	case ">":
+		return actual > c.Value
+	case ">=":
+		return actual >= c.Value
+	case "<":
+		return actual < c.Value
+	case "<=":
+		return actual <= c.Value
+	case "==":
+		return actual == c.Value
+	}
+	return actual != c.Value
+}
@@ -0,0 +1,127 @@
+// Package gate evaluates churn limits for CI gating. Limits are either fixed
+// line counts or percentiles of the repository's recorded history.
+package gate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+)
+
+// TotalMetric names the overall churn in limits; any other metric is a
+// category name.
+const TotalMetric = "total"
+
+// Limit caps the churn of a metric.
+type Limit struct {
+	Metric string
+	// Value is a fixed maximum churn; used when Percentile is zero.
+	Value int
+	// Percentile (0-100] derives the maximum from recorded history.
+	Percentile float64
+}
+
+// String returns the limit in the form accepted by ParseLimit.
+func (l Limit) String() string {
+	if l.Percentile > 0 {
+		return l.Metric + "=p" + strconv.FormatFloat(l.Percentile, 'f', -1, 64)
+	}
+	return l.Metric + "=" + strconv.Itoa(l.Value)
+}
+
+// ParseLimit parses "[metric=]max", where max is a line count such as "500"
+// or a percentile such as "p95". The metric defaults to "total".
+func ParseLimit(s string) (Limit, error) {
+	metric, max, ok := strings.Cut(s, "=")
+	if !ok {
+		metric, max = TotalMetric, s
+	}
+	if metric == "" {
+		return Limit{}, fmt.Errorf("invalid limit %q: empty metric", s)
+	}
+	if p, ok := strings.CutPrefix(max, "p"); ok {
+		pct, err := strconv.ParseFloat(p, 64)
+		if err != nil || pct <= 0 || pct > 100 {
+			return Limit{}, fmt.Errorf("invalid limit %q: percentile must be p1-p100", s)
+		}
+		return Limit{Metric: metric, Percentile: pct}, nil
+	}
+	n, err := strconv.Atoi(max)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid limit %q: expected a line count or percentile such as p95", s)
+	}
+	return Limit{Metric: metric, Value: n}, nil
+}
+
+// Result is the outcome of checking one limit.
+type Result struct {
+	Limit   Limit
+	Actual  int
+	Max     int    // resolved maximum
+	Samples int    // historical runs the percentile was computed from
+	Skipped string // reason the limit could not be evaluated; empty if evaluated
+}
+
+// Passed reports whether the limit held. Skipped limits pass.
+func (r Result) Passed() bool {
+	return r.Skipped != "" || r.Actual <= r.Max
+}
+
+// Evaluate checks summary against limits. Percentile limits are resolved
+// from history and skipped when fewer than minSamples runs are available.
+func Evaluate(limits []Limit, summary output.Summary, history []snapshot.Snapshot, minSamples int) []Result {
+	results := make([]Result, 0, len(limits))
+	for _, l := range limits {
+		r := Result{Limit: l, Actual: summaryChurn(summary, l.Metric)}
+		if l.Percentile == 0 {
+			r.Max = l.Value
+			results = append(results, r)
+			continue
+		}
+
+		values := make([]int, 0, len(history))
+		for _, snap := range history {
+			values = append(values, snapshotChurn(snap, l.Metric))
+		}
+		r.Samples = len(values)
+		if len(values) == 0 || len(values) < minSamples {
+			r.Skipped = fmt.Sprintf("only %d recorded runs, need %d", len(values), max(minSamples, 1))
+		} else {
+			r.Max = Percentile(values, l.Percentile)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// Percentile returns the nearest-rank pth percentile of values. It returns 0
+// for an empty slice.
+func Percentile(values []int, p float64) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1]
+}
+
+func summaryChurn(s output.Summary, metric string) int {
+	if metric == TotalMetric {
+		return s.Totals.Churn
+	}
+	return s.CategoryTotals[metric].Churn
+}
+
+func snapshotChurn(snap snapshot.Snapshot, metric string) int {
+	if metric == TotalMetric {
+		return snap.Total.Churn
+	}
+	return snap.Categories[metric].Churn
+}
@@ -0,0 +1,151 @@
+package gate
+
+import (
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+)
+
+func TestParseLimit(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Limit
+	}{
+		{"500", Limit{Metric: "total", Value: 500}},
+		{"p95", Limit{Metric: "total", Percentile: 95}},
+		{"generated=0", Limit{Metric: "generated", Value: 0}},
+		{"source=p99.5", Limit{Metric: "source", Percentile: 99.5}},
+	}
+	for _, tc := range cases {
+		got, err := ParseLimit(tc.in)
+		if err != nil {
+			t.Errorf("ParseLimit(%q): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+		if tc.in != "500" && tc.in != "p95" && got.String() != tc.in {
+			t.Errorf("Limit.String() = %q, want %q", got.String(), tc.in)
+		}
+	}
+
+	for _, bad := range []string{"", "=5", "p0", "p101", "pxx", "-3", "lots", "docs="} {
+		if _, err := ParseLimit(bad); err == nil {
+			t.Errorf("ParseLimit(%q) should fail", bad)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []int{50, 10, 40, 20, 30, 60, 70, 80, 90, 100}
+	cases := map[float64]int{50: 50, 90: 90, 95: 100, 100: 100, 1: 10}
+	for p, want := range cases {
+		if got := Percentile(values, p); got != want {
+			t.Errorf("Percentile(p%v) = %d, want %d", p, got, want)
+		}
+	}
+	if got := Percentile(nil, 95); got != 0 {
+		t.Errorf("Percentile(nil) = %d, want 0", got)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	var history []snapshot.Snapshot
+	for i := 1; i <= 10; i++ {
+		history = append(history, snapshot.Snapshot{
+			Total:      snapshot.Totals{Churn: i * 10},
+			Categories: map[string]snapshot.Totals{"source": {Churn: i * 5}},
+		})
+	}
+	summary := output.Summary{
+		Totals:         output.CategoryTotal{Churn: 95},
+		CategoryTotals: map[string]output.CategoryTotal{"source": {Churn: 60}, "generated": {Churn: 3}},
+	}
+	limits := []Limit{
+		{Metric: "total", Percentile: 90},  // p90 = 90, actual 95: fail
+		{Metric: "total", Percentile: 100}, // p100 = 100: pass
+		{Metric: "source", Percentile: 50}, // p50 = 25, actual 60: fail
+		{Metric: "generated", Value: 0},    // fixed 0, actual 3: fail
+		{Metric: "docs", Value: 10},        // absent category: 0 <= 10
+	}
+	results := Evaluate(limits, summary, history, 10)
+	wantPassed := []bool{false, true, false, false, true}
+	wantMax := []int{90, 100, 25, 0, 10}
+	for i, r := range results {
+		if r.Passed() != wantPassed[i] || r.Max != wantMax[i] {
+			t.Errorf("result %d (%s) = passed %v max %d, want %v %d", i, r.Limit, r.Passed(), r.Max, wantPassed[i], wantMax[i])
+		}
+	}
+	if results[0].Samples != 10 {
+		t.Errorf("Samples = %d, want 10", results[0].Samples)
+	}
+
+	// Too little history: percentile limits are skipped, fixed ones still apply.
+	results = Evaluate(limits, summary, history[:3], 10)
+	if results[0].Skipped == "" || !results[0].Passed() {
+		t.Errorf("expected skipped percentile limit, got %+v", results[0])
+	}
+	if results[3].Skipped != "" || results[3].Passed() {
+		t.Errorf("fixed limit should still fail, got %+v", results[3])
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Condition
+	}{
+		{"generated.churn>0", Condition{Metric: "generated", Field: "churn", Op: ">", Value: 0}},
+		{"total.files >= 50", Condition{Metric: "total", Field: "files", Op: ">=", Value: 50}},
+		{"source.net<-100", Condition{Metric: "source", Field: "net", Op: "<", Value: -100}},
+		{"tests==0", Condition{Metric: "tests", Field: "churn", Op: "==", Value: 0}},
+	}
+	for _, tc := range cases {
+		got, err := ParseCondition(tc.in)
+		if err != nil {
+			t.Errorf("ParseCondition(%q): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseCondition(%q) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"", "generated", ">0", "docs.lines>0", "docs.churn>x", "docs.churn>"} {
+		if _, err := ParseCondition(bad); err == nil {
+			t.Errorf("ParseCondition(%q) should fail", bad)
+		}
+	}
+}
+
+func TestConditionHolds(t *testing.T) {
+	summary := output.Summary{
+		Totals: output.CategoryTotal{Added: 30, Deleted: 10, Churn: 40, FileCount: 4},
+		CategoryTotals: map[string]output.CategoryTotal{
+			"generated": {Added: 5, Churn: 5, FileCount: 1},
+		},
+	}
+	cases := map[string]bool{
+		"generated.churn>0":   true,
+		"generated.churn>5":   false,
+		"generated.churn>=5":  true,
+		"docs.churn>0":        false,
+		"docs.files==0":       true,
+		"total.net!=20":       false,
+		"total.deleted<=10":   true,
+		"total.added<30":      false,
+		"total.files>3":       true,
+		"total.churn == 40  ": true,
+	}
+	for in, want := range cases {
+		c, err := ParseCondition(in)
+		if err != nil {
+			t.Fatalf("ParseCondition(%q): %v", in, err)
+		}
+		if got := c.Holds(summary); got != want {
+			t.Errorf("%s holds = %v, want %v (actual %d)", in, got, want, c.Actual(summary))
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package gitdiff
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// checkAttrBatch bounds how many paths are passed as arguments to one git
+// check-attr invocation when the runner cannot supply stdin, keeping the
+// command line well under OS limits.
+const checkAttrBatch = 500
+
+// CheckAttr looks up gitattributes for paths, which are relative to the
+// repository root. The result maps each path to the attributes that are
+// specified for it; values are as reported by git check-attr ("set",
+// "unset", or the assigned value). Paths with no specified attributes are
+// absent.
+//
+// With an InputRunner all paths go through a single `git check-attr
+// --stdin` process; otherwise they are passed as arguments in batches.
+func CheckAttr(runner CommandRunner, paths, attrs []string) (map[string]map[string]string, error) {
+	result := make(map[string]map[string]string)
+	if len(paths) == 0 || len(attrs) == 0 {
+		return result, nil
+	}
+	top, err := TopLevel(runner)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"-C", top, "check-attr", "-z"}, attrs...)
+
+	if in, ok := runner.(InputRunner); ok {
+		var stdin bytes.Buffer
+		for _, p := range paths {
+			stdin.WriteString(p)
+			stdin.WriteByte(0)
+		}
+		out, err := in.RunInput(stdin.Bytes(), "git", append(args, "--stdin")...)
+		if err != nil {
+			return nil, fmt.Errorf("reading gitattributes: %w", err)
+		}
+		return result, parseCheckAttr(out, result)
+	}
+
+	for start := 0; start < len(paths); start += checkAttrBatch {
+		end := min(start+checkAttrBatch, len(paths))
+		batch := append(append(args[:len(args):len(args)], "--"), paths[start:end]...)
+		out, err := runner.Run("git", batch...)
+		if err != nil {
+			return nil, fmt.Errorf("reading gitattributes: %w", err)
+		}
+		if err := parseCheckAttr(out, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// parseCheckAttr parses `git check-attr -z` output, a sequence of
+// NUL-terminated path, attribute, value triples, into result.
+func parseCheckAttr(out []byte, result map[string]map[string]string) error {
+	fields := bytes.Split(out, []byte{0})
+	if n := len(fields); n > 0 && len(fields[n-1]) == 0 {
+		fields = fields[:n-1]
+	}
+	if len(fields)%3 != 0 {
+		return fmt.Errorf("unexpected git check-attr output")
+	}
+	for i := 0; i < len(fields); i += 3 {
+		path, attr, value := string(fields[i]), string(fields[i+1]), string(fields[i+2])
+		if value == "unspecified" {
+			continue
+		}
+		if result[path] == nil {
+			result[path] = make(map[string]string)
+		}
+		result[path][attr] = value
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package gitdiff
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCheckAttr(t *testing.T) {
+	out := []byte("gen/a.pb.go\x00linguist-generated\x00true\x00" +
+		"main.go\x00linguist-generated\x00unspecified\x00" +
+		"dist/app.js\x00linguist-generated\x00unset\x00")
+	got := make(map[string]map[string]string)
+	if err := parseCheckAttr(out, got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]string{
+		"gen/a.pb.go": {"linguist-generated": "true"},
+		"dist/app.js": {"linguist-generated": "unset"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCheckAttr = %v, want %v", got, want)
+	}
+
+	if err := parseCheckAttr([]byte("a\x00b\x00"), got); err == nil {
+		t.Error("expected error for truncated output")
+	}
+}
+
+func TestIntegration_CheckAttr(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := t.TempDir()
+	gitInDir(t, repo, "init")
+	attrs := "*.pb.go linguist-generated=true\ndist/** -linguist-generated\n"
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte(attrs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths are repository-relative even when run from a subdirectory.
+	got, err := CheckAttr(DirRunner{Dir: sub}, []string{"api/v1/api.pb.go", "dist/app.js", "main.go"}, []string{"linguist-generated"})
+	if err != nil {
+		t.Fatalf("CheckAttr: %v", err)
+	}
+	want := map[string]map[string]string{
+		"api/v1/api.pb.go": {"linguist-generated": "true"},
+		"dist/app.js":      {"linguist-generated": "unset"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckAttr = %v, want %v", got, want)
+	}
+
+	// Runners without stdin support pass paths as arguments instead.
+	got, err = CheckAttr(argsOnlyRunner{DirRunner{Dir: sub}}, []string{"api/v1/api.pb.go", "dist/app.js", "main.go"}, []string{"linguist-generated"})
+	if err != nil {
+		t.Fatalf("CheckAttr (args): %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckAttr (args) = %v, want %v", got, want)
+	}
+}
+
+// argsOnlyRunner hides DirRunner's InputRunner implementation.
+type argsOnlyRunner struct{ CommandRunner }
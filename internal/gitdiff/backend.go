@@ -0,0 +1,70 @@
+package gitdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BackendFactory creates the CommandRunner for a diff backend.
+type BackendFactory func() (CommandRunner, error)
+
+var backends = map[string]BackendFactory{
+	"git":   func() (CommandRunner, error) { return DefaultRunner, nil },
+	"gogit": newGoGitBackend,
+}
+
+// RegisterBackend makes a diff backend available under name. Backends that
+// do not shell out to git implement CommandRunner by answering the git
+// invocations differ issues, and Limiter if they cannot do every Feature, so
+// that flags needing one are rejected up front. Registering an existing name
+// replaces it.
+func RegisterBackend(name string, factory BackendFactory) {
+	backends[name] = factory
+}
+
+// Backends returns the names of all registered backends in sorted order.
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A Feature is something differ can ask of a backend beyond diffing two
+// commits, which not every backend can do.
+type Feature int
+
+const (
+	// FeatureWorktree diffs the index or the working tree.
+	FeatureWorktree Feature = iota
+	// FeatureHistory walks commit history, as git log and git rev-list do.
+	FeatureHistory
+	// FeatureIgnoreWhitespace ignores whitespace changes, as git diff -w does.
+	FeatureIgnoreWhitespace
+	// FeatureDiffAlgorithm chooses git's diff algorithm.
+	FeatureDiffAlgorithm
+)
+
+// A Limiter is a backend that cannot do every Feature.
+type Limiter interface {
+	Supports(f Feature) bool
+}
+
+// Supports reports whether runner can do f. Runners that are not a Limiter,
+// such as the git backend's, can do everything.
+func Supports(runner CommandRunner, f Feature) bool {
+	l, ok := runner.(Limiter)
+	return !ok || l.Supports(f)
+}
+
+// NewBackend returns the CommandRunner for the named backend.
+func NewBackend(name string) (CommandRunner, error) {
+	factory, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q (available: %s)", name, strings.Join(Backends(), ", "))
+	}
+	return factory()
+}
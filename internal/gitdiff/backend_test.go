@@ -0,0 +1,43 @@
+package gitdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewBackendDefault(t *testing.T) {
+	runner, err := NewBackend("git")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runner != DefaultRunner {
+		t.Errorf("git backend should return DefaultRunner")
+	}
+}
+
+func TestNewBackendUnknown(t *testing.T) {
+	_, err := NewBackend("libgit2")
+	if err == nil {
+		t.Fatal("expected error for unregistered backend")
+	}
+	if !strings.Contains(err.Error(), "available: git, gogit") {
+		t.Errorf("error should list available backends, got %v", err)
+	}
+}
+
+func TestRegisterBackend(t *testing.T) {
+	mock := &mockRunner{}
+	RegisterBackend("mock", func() (CommandRunner, error) { return mock, nil })
+	defer delete(backends, "mock")
+
+	runner, err := NewBackend("mock")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runner != mock {
+		t.Error("expected registered mock runner")
+	}
+	if got := strings.Join(Backends(), ","); got != "git,gogit,mock" {
+		t.Errorf("Backends() = %q, want git,gogit,mock", got)
+	}
+}
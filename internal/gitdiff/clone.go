@@ -0,0 +1,117 @@
+package gitdiff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// fetchBatchSize caps how many object IDs are passed to a single git fetch.
+const fetchBatchSize = 500
+
+// CloneInfo describes repository setups in which not everything is available
+// locally.
+type CloneInfo struct {
+	// PromisorRemote is the remote missing objects are fetched from in a
+	// partial clone; empty for a full clone.
+	PromisorRemote string
+	// Filter is the partial-clone filter spec, e.g. "blob:none".
+	Filter string
+	// Sparse reports whether a sparse checkout is active.
+	Sparse bool
+}
+
+// Partial reports whether the repository is a partial clone.
+func (c CloneInfo) Partial() bool {
+	return c.PromisorRemote != ""
+}
+
+// DetectClone inspects the repository config for partial-clone promisor
+// remotes and sparse checkout. Lookup failures are treated as "not enabled".
+func DetectClone(runner CommandRunner) CloneInfo {
+	var info CloneInfo
+
+	if out, err := runner.Run("git", "config", "--get-regexp", `^remote\..*\.promisor$`); err == nil {
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			if value != "true" {
+				continue
+			}
+			info.PromisorRemote = strings.TrimSuffix(strings.TrimPrefix(key, "remote."), ".promisor")
+			break
+		}
+	}
+	if info.PromisorRemote != "" {
+		if out, err := runner.Run("git", "config", "--get", "remote."+info.PromisorRemote+".partialclonefilter"); err == nil {
+			info.Filter = strings.TrimSpace(string(out))
+		}
+	}
+
+	if out, err := runner.Run("git", "config", "--bool", "core.sparseCheckout"); err == nil {
+		info.Sparse = strings.TrimSpace(string(out)) == "true"
+	}
+
+	return info
+}
+
+// PrefetchBlobs fetches, in batches, the blobs that a diff of refRange needs
+// from the promisor remote, so a partial clone does not fall back to git's
+// one-object-at-a-time lazy fetching. Only blobs of changed files are
+// requested; git skips the network entirely when all of them are present.
+// It returns the number of blobs requested.
+func PrefetchBlobs(runner CommandRunner, remote, refRange string, pathspecs []string, opts DiffOptions) (int, error) {
+	// --raw with renames disabled lists blob IDs from the trees alone, without
+	// reading (and so lazily fetching) any blob contents.
+	args := []string{"diff", "--raw", "--no-abbrev", "--no-renames", "-z"}
+	if opts.Cached {
+		args = append(args, "--cached")
+	}
+	if refRange != "" {
+		args = append(args, refRange)
+	}
+	if len(pathspecs) > 0 {
+		args = append(args, "--")
+		args = append(args, pathspecs...)
+	}
+	out, err := runner.Run("git", args...)
+	if err != nil {
+		return 0, fmt.Errorf("listing changed blobs: %w", err)
+	}
+
+	oids := parseRawOIDs(out)
+	for start := 0; start < len(oids); start += fetchBatchSize {
+		end := min(start+fetchBatchSize, len(oids))
+		fetchArgs := []string{"fetch", "--quiet", "--no-tags", "--no-write-fetch-head",
+			"--recurse-submodules=no", "--filter=blob:none", remote}
+		fetchArgs = append(fetchArgs, oids[start:end]...)
+		if _, err := runner.Run("git", fetchArgs...); err != nil {
+			return 0, fmt.Errorf("fetching %d blobs from %s: %w", len(oids), remote, err)
+		}
+	}
+	return len(oids), nil
+}
+
+// parseRawOIDs extracts the unique, non-null blob IDs from NUL-terminated
+// `git diff --raw -z` output. Gitlinks (submodules) are skipped.
+func parseRawOIDs(out []byte) []string {
+	var oids []string
+	seen := make(map[string]bool)
+	for _, field := range bytes.Split(out, []byte{0}) {
+		if len(field) == 0 || field[0] != ':' {
+			continue
+		}
+		// :<old mode> <new mode> <old oid> <new oid> <status>
+		parts := strings.Fields(string(field[1:]))
+		if len(parts) < 5 {
+			continue
+		}
+		for i, oid := range parts[2:4] {
+			if parts[i] == "160000" || strings.Trim(oid, "0") == "" || seen[oid] {
+				continue
+			}
+			seen[oid] = true
+			oids = append(oids, oid)
+		}
+	}
+	return oids
+}
@@ -0,0 +1,85 @@
+package gitdiff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRawOIDs(t *testing.T) {
+	a := strings.Repeat("a", 40)
+	b := strings.Repeat("b", 40)
+	c := strings.Repeat("c", 40)
+	zero := strings.Repeat("0", 40)
+	raw := ":100644 100644 " + a + " " + b + " M\x00mod.txt\x00" +
+		":000000 100644 " + zero + " " + c + " A\x00new.txt\x00" +
+		":100644 000000 " + a + " " + zero + " D\x00gone.txt\x00" +
+		":160000 160000 " + strings.Repeat("d", 40) + " " + strings.Repeat("e", 40) + " M\x00sub\x00"
+
+	got := parseRawOIDs([]byte(raw))
+	want := []string{a, b, c}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("parseRawOIDs = %v, want %v", got, want)
+	}
+}
+
+func TestIntegration_PartialClone(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitInDir(t, src, "init")
+	gitInDir(t, src, "config", "user.email", "test@test.com")
+	gitInDir(t, src, "config", "user.name", "Test")
+	gitInDir(t, src, "config", "uploadpack.allowFilter", "true")
+	gitInDir(t, src, "config", "uploadpack.allowAnySHA1InWant", "true")
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		gitInDir(t, src, "add", name)
+		gitInDir(t, src, "commit", "-m", "add "+name)
+	}
+
+	clone := filepath.Join(tmpDir, "clone")
+	gitInDir(t, tmpDir, "clone", "--quiet", "--no-checkout", "--filter=blob:none", "file://"+src, clone)
+	runner := DirRunner{Dir: clone}
+
+	info := DetectClone(runner)
+	if !info.Partial() || info.PromisorRemote != "origin" || info.Filter != "blob:none" {
+		t.Fatalf("DetectClone = %+v, want partial clone from origin with blob:none", info)
+	}
+	if info.Sparse {
+		t.Error("expected sparse checkout to be disabled")
+	}
+
+	missing := func() int {
+		out := gitInDir(t, clone, "rev-list", "--objects", "--missing=print", "HEAD~1..HEAD")
+		return strings.Count(out, "?")
+	}
+	if missing() == 0 {
+		t.Fatal("expected missing blobs in a fresh blobless clone")
+	}
+
+	n, err := PrefetchBlobs(runner, info.PromisorRemote, "HEAD~1..HEAD", nil, DiffOptions{})
+	if err != nil {
+		t.Fatalf("PrefetchBlobs: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("PrefetchBlobs requested %d blobs, want 1", n)
+	}
+	if m := missing(); m != 0 {
+		t.Errorf("%d blobs still missing after prefetch", m)
+	}
+
+	gitInDir(t, clone, "sparse-checkout", "set", "docs")
+	if !DetectClone(runner).Sparse {
+		t.Error("expected sparse checkout to be detected")
+	}
+}
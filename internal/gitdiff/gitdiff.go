@@ -1,36 +1,133 @@
 package gitdiff
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"sort"
 	"strings"
 )
 
 // CommandRunner abstracts command execution for testability.
+//
+// Start returns a nil *exec.Cmd when the output was produced without
+// starting a process, as by the gogit backend.
 type CommandRunner interface {
 	Run(name string, args ...string) ([]byte, error)
 	Start(name string, args ...string) (io.ReadCloser, *exec.Cmd, error)
 }
 
-// defaultRunner executes real git commands.
-type defaultRunner struct{}
+// InputRunner is implemented by CommandRunners that can also feed stdin to a
+// command. Helpers that benefit from it, such as CheckAttr, fall back to
+// plain Run when a runner does not implement it.
+type InputRunner interface {
+	RunInput(stdin []byte, name string, args ...string) ([]byte, error)
+}
 
-func (d defaultRunner) Run(name string, args ...string) ([]byte, error) {
-	cmd := exec.Command(name, args...)
-	return cmd.Output()
+// GitBin and GitArgs control how DefaultRunner and DirRunner invoke git:
+// GitBin replaces the "git" command name, and GitArgs are inserted before
+// the subcommand of every invocation, as in "git -c diff.algorithm=histogram
+// diff ...".
+var (
+	GitBin  = "git"
+	GitArgs []string
+)
+
+// command returns the exec.Cmd for name and args, applying GitBin and
+// GitArgs to git invocations.
+func command(name string, args ...string) *exec.Cmd {
+	if name == "git" {
+		name = GitBin
+		args = append(append([]string(nil), GitArgs...), args...)
+	}
+	return exec.Command(name, args...)
 }
 
-func (d defaultRunner) Start(name string, args ...string) (io.ReadCloser, *exec.Cmd, error) {
-	cmd := exec.Command(name, args...)
+// Trace, if set, is called as each command of DefaultRunner or a DirRunner
+// starts, and returns a function that is called once it has finished with
+// the bytes of output read and the error, if any. The output of a started
+// command counts as finished when it has been read to the end or closed.
+var Trace func(cmd *exec.Cmd) (done func(n int64, err error))
+
+// output runs cmd and returns its standard output.
+func output(cmd *exec.Cmd) ([]byte, error) {
+	if Trace == nil {
+		return cmd.Output()
+	}
+	done := Trace(cmd)
+	out, err := cmd.Output()
+	done(int64(len(out)), err)
+	return out, err
+}
+
+// start starts cmd and returns a pipe reading its standard output.
+func start(cmd *exec.Cmd) (io.ReadCloser, *exec.Cmd, error) {
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, nil, fmt.Errorf("creating stdout pipe: %w", err)
 	}
+	done := func(int64, error) {}
+	if Trace != nil {
+		done = Trace(cmd)
+	}
 	if err := cmd.Start(); err != nil {
+		done(0, err)
 		return nil, nil, fmt.Errorf("starting command: %w", err)
 	}
-	return stdout, cmd, nil
+	if Trace == nil {
+		return stdout, cmd, nil
+	}
+	return &tracedReader{ReadCloser: stdout, done: done}, cmd, nil
+}
+
+// tracedReader counts the output of a traced command and reports it when
+// the output ends.
+type tracedReader struct {
+	io.ReadCloser
+	n    int64
+	done func(n int64, err error)
+}
+
+func (r *tracedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	if err == io.EOF {
+		r.finish(nil)
+	} else if err != nil {
+		r.finish(err)
+	}
+	return n, err
+}
+
+func (r *tracedReader) Close() error {
+	r.finish(nil)
+	return r.ReadCloser.Close()
+}
+
+func (r *tracedReader) finish(err error) {
+	if r.done != nil {
+		r.done(r.n, err)
+		r.done = nil
+	}
+}
+
+// defaultRunner executes real git commands.
+type defaultRunner struct{}
+
+func (d defaultRunner) Run(name string, args ...string) ([]byte, error) {
+	return output(command(name, args...))
+}
+
+func (d defaultRunner) RunInput(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := command(name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	return output(cmd)
+}
+
+func (d defaultRunner) Start(name string, args ...string) (io.ReadCloser, *exec.Cmd, error) {
+	return start(command(name, args...))
 }
 
 // DefaultRunner is the default CommandRunner that executes real commands.
@@ -40,8 +137,10 @@ var DefaultRunner CommandRunner = defaultRunner{}
 //
 // Priority order:
 //  1. --base and --head flags → "base...head"
-//  2. Positional rev-range → returned directly
-//  3. Auto-detect: origin/HEAD...HEAD → main...HEAD → master...HEAD
+//  2. Positional rev-range → returned directly; a single commit-ish without
+//     ".." is expanded to "<rev>^..<rev>" (see SingleCommitRange)
+//  3. Auto-detect: <upstream>...HEAD (see Upstream) → origin/HEAD...HEAD →
+//     main...HEAD → master...HEAD; a detached HEAD has no upstream
 //
 // Returns an error if no ref can be resolved.
 func ResolveRefs(runner CommandRunner, base, head, positionalRange string) (string, error) {
@@ -50,26 +149,165 @@ func ResolveRefs(runner CommandRunner, base, head, positionalRange string) (stri
 	}
 
 	if positionalRange != "" {
+		if !strings.Contains(positionalRange, "..") {
+			return SingleCommitRange(runner, positionalRange)
+		}
 		return positionalRange, nil
 	}
 
-	// Auto-detect fallback chain.
-	fallbacks := []struct {
-		ref      string
-		refRange string
-	}{
-		{"origin/HEAD", "origin/HEAD...HEAD"},
-		{"main", "main...HEAD"},
-		{"master", "master...HEAD"},
+	// Auto-detect fallback chain. In a fork the branch usually tracks the
+	// branch it will be merged into, which origin/HEAD is not.
+	fallbacks := []string{"origin/HEAD", "main", "master"}
+	branch, err := CurrentBranch(runner)
+	if err == nil {
+		if upstream := Upstream(runner, branch); upstream != "" {
+			fallbacks = append([]string{upstream}, fallbacks...)
+		}
 	}
 
-	for _, fb := range fallbacks {
-		if _, err := runner.Run("git", "rev-parse", "--verify", fb.ref); err == nil {
-			return fb.refRange, nil
+	for _, ref := range fallbacks {
+		if _, err := runner.Run("git", "rev-parse", "--verify", ref); err == nil {
+			return ref + "...HEAD", nil
+		}
+	}
+
+	if branch == "" {
+		if _, err := runner.Run("git", "rev-parse", "--verify", "HEAD"); err == nil {
+			return "", fmt.Errorf("cannot resolve base ref: HEAD is detached, so there is no upstream branch, and none of origin/HEAD, main, master exist — pass --base and --head or a rev-range")
+		}
+	}
+	return "", fmt.Errorf("cannot resolve base ref: tried %s — are you in a git repository?", strings.Join(fallbacks, ", "))
+}
+
+// Upstream returns the tracking branch of branch, such as "upstream/main" in
+// a fork or a local branch it is stacked on, or "" if it has none. A remote
+// branch of the same name only records where branch was pushed, not what it
+// is based on, so it counts as none.
+func Upstream(runner CommandRunner, branch string) string {
+	out, err := runner.Run("git", "rev-parse", "--abbrev-ref", branch+"@{upstream}")
+	if err != nil {
+		return ""
+	}
+	upstream := strings.TrimSpace(string(out))
+	if strings.HasSuffix(upstream, "/"+branch) {
+		return ""
+	}
+	return upstream
+}
+
+// ListRefs returns the short names of the repository's local branches,
+// remote-tracking branches, and tags, in that order, for completing ref
+// arguments.
+func ListRefs(runner CommandRunner) ([]string, error) {
+	out, err := runner.Run("git", "for-each-ref", "--format=%(refname:short)", "refs/heads", "refs/remotes", "refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("listing refs: %w", err)
+	}
+	var refs []string
+	for line := range strings.Lines(string(out)) {
+		if ref := strings.TrimSpace(line); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// DefaultBranch returns the branch changes are merged into: the branch
+// origin/HEAD points at (such as "origin/main"), or else a local main or
+// master. It returns "" if there is none.
+func DefaultBranch(runner CommandRunner) string {
+	if out, err := runner.Run("git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		if branch := strings.TrimSpace(string(out)); branch != "" {
+			return branch
+		}
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := runner.Run("git", "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch
+		}
+	}
+	return ""
+}
+
+// SingleCommitRange returns the range covering exactly the changes introduced
+// by rev: "<rev>^..<rev>", or "<empty-tree>..<rev>" when rev is a root commit.
+func SingleCommitRange(runner CommandRunner, rev string) (string, error) {
+	if _, err := runner.Run("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+		return "", fmt.Errorf("cannot resolve commit %q", rev)
+	}
+	if _, err := runner.Run("git", "rev-parse", "--verify", "--quiet", rev+"^"); err == nil {
+		return rev + "^.." + rev, nil
+	}
+	out, err := runner.Run("git", "hash-object", "-t", "tree", os.DevNull)
+	if err != nil {
+		return "", fmt.Errorf("resolving empty tree for root commit %q: %w", rev, err)
+	}
+	return strings.TrimSpace(string(out)) + ".." + rev, nil
+}
+
+// ReleaseRange returns the range covering the changes released in tag:
+// "<previous tag>..<tag>", where the previous tag is the nearest tag
+// reachable from tag's parent, or "<empty-tree>..<tag>" for a first release.
+func ReleaseRange(runner CommandRunner, tag string) (string, error) {
+	if _, err := runner.Run("git", "rev-parse", "--verify", "--quiet", tag+"^{commit}"); err != nil {
+		return "", fmt.Errorf("cannot resolve release %q", tag)
+	}
+	if out, err := runner.Run("git", "describe", "--tags", "--abbrev=0", tag+"^"); err == nil {
+		if prev := strings.TrimSpace(string(out)); prev != "" {
+			return prev + ".." + tag, nil
+		}
+	}
+	out, err := runner.Run("git", "hash-object", "-t", "tree", os.DevNull)
+	if err != nil {
+		return "", fmt.Errorf("resolving empty tree for release %q: %w", tag, err)
+	}
+	return strings.TrimSpace(string(out)) + ".." + tag, nil
+}
+
+// DateRange returns the range covering the changes made on the first-parent
+// history of branch (HEAD if empty) between since and until, dates in any
+// form git log accepts: "<last commit before since>..<last commit before
+// until>". An empty until ends at branch's tip. The range starts from the
+// empty tree when since is empty or predates every commit.
+func DateRange(runner CommandRunner, since, until, branch string) (string, error) {
+	if branch == "" {
+		branch = "HEAD"
+	}
+	head := branch
+	if until != "" {
+		sha, err := lastCommitBefore(runner, until, branch)
+		if err != nil {
+			return "", err
+		}
+		if sha == "" {
+			return "", fmt.Errorf("no commits on %s before %q", branch, until)
 		}
+		head = sha
 	}
+	if since != "" {
+		sha, err := lastCommitBefore(runner, since, head)
+		if err != nil {
+			return "", err
+		}
+		if sha != "" {
+			return sha + ".." + head, nil
+		}
+	}
+	out, err := runner.Run("git", "hash-object", "-t", "tree", os.DevNull)
+	if err != nil {
+		return "", fmt.Errorf("resolving empty tree: %w", err)
+	}
+	return strings.TrimSpace(string(out)) + ".." + head, nil
+}
 
-	return "", fmt.Errorf("cannot resolve base ref: tried origin/HEAD, main, master — are you in a git repository?")
+// lastCommitBefore returns the newest commit on the first-parent history of
+// rev committed before date, or "" if there is none.
+func lastCommitBefore(runner CommandRunner, date, rev string) (string, error) {
+	out, err := runner.Run("git", "rev-list", "-1", "--first-parent", "--before="+date, rev, "--")
+	if err != nil {
+		return "", fmt.Errorf("cannot list commits of %q", rev)
+	}
+	return strings.TrimSpace(string(out)), nil
 }
 
 // WorktreeDirty reports whether the current repository has staged or unstaged changes.
@@ -94,6 +332,16 @@ func MergeBase(runner CommandRunner, base, head string) (string, error) {
 	return mergeBase, nil
 }
 
+// LastMerge returns the latest merge commit in the first-parent history of
+// rev, or "" if there is none.
+func LastMerge(runner CommandRunner, rev string) (string, error) {
+	out, err := runner.Run("git", "rev-list", "-1", "--merges", "--first-parent", rev, "--")
+	if err != nil {
+		return "", fmt.Errorf("finding the last merge into %s: %w", rev, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // DiffResult holds the output of a git diff command.
 type DiffResult struct {
 	Stdout io.ReadCloser
@@ -103,6 +351,9 @@ type DiffResult struct {
 // Wait waits for the diff command to finish and returns any error.
 // The stderr output is included in the error message if the command fails.
 func (d *DiffResult) Wait() error {
+	if d.Cmd == nil {
+		return nil
+	}
 	err := d.Cmd.Wait()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
@@ -113,10 +364,85 @@ func (d *DiffResult) Wait() error {
 	return nil
 }
 
+// DiffOptions adjusts how RunDiffWithOptions invokes git diff.
+type DiffOptions struct {
+	// Cached diffs the index against refRange (or HEAD when refRange is
+	// empty) instead of comparing commits or the working tree.
+	Cached bool
+	// IgnoreWhitespace passes -w so lines differing only in whitespace are
+	// treated as unchanged.
+	IgnoreWhitespace bool
+	// FullContext emits every unchanged line of each changed file as
+	// context instead of none, so both versions of a file can be
+	// reconstructed from the diff.
+	FullContext bool
+	// Algorithm is passed as --diff-algorithm when set; one of
+	// DiffAlgorithms. Empty leaves git's diff.algorithm setting in effect.
+	Algorithm string
+}
+
+// DiffAlgorithms are the diff algorithms git accepts for --diff-algorithm.
+var DiffAlgorithms = []string{"myers", "minimal", "patience", "histogram"}
+
+// EffectiveAlgorithm returns the diff algorithm git uses when asked for
+// algorithm: algorithm itself if set, otherwise the diff.algorithm setting,
+// otherwise git's default, myers.
+func EffectiveAlgorithm(runner CommandRunner, algorithm string) string {
+	if algorithm != "" {
+		return algorithm
+	}
+	out, err := runner.Run("git", "config", "--get", "diff.algorithm")
+	if configured := strings.ToLower(strings.TrimSpace(string(out))); err == nil && configured != "" && configured != "default" {
+		return configured
+	}
+	return "myers"
+}
+
+// fullContextLines is the -U value used for FullContext; git accepts any
+// non-negative int and does not allocate by it.
+const fullContextLines = "2147483647"
+
 // RunDiff executes `git diff --no-color -U0 -M <refRange> -- <pathspecs...>` and
 // returns a DiffResult whose Stdout provides streaming access to the diff output.
 func RunDiff(runner CommandRunner, refRange string, pathspecs []string) (*DiffResult, error) {
-	args := []string{"diff", "--no-color", "-U0", "-M", refRange}
+	return RunDiffWithOptions(runner, refRange, pathspecs, DiffOptions{})
+}
+
+// RunDiffWithOptions is like RunDiff but applies opts to the git diff invocation.
+func RunDiffWithOptions(runner CommandRunner, refRange string, pathspecs []string, opts DiffOptions) (*DiffResult, error) {
+	context := "-U0"
+	if opts.FullContext {
+		context = "-U" + fullContextLines
+	}
+	return startDiff(runner, refRange, pathspecs, opts, context)
+}
+
+// RunNumstat is like RunDiffWithOptions but asks git for per-file line
+// counts and modes (--raw --numstat -z) instead of a patch, which is much
+// faster on large ranges. Object names are not abbreviated, so submodule
+// commits are usable. opts.FullContext does not apply.
+func RunNumstat(runner CommandRunner, refRange string, pathspecs []string, opts DiffOptions) (*DiffResult, error) {
+	return startDiff(runner, refRange, pathspecs, opts, "--raw", "--numstat", "-z", "--no-abbrev")
+}
+
+// startDiff starts git diff with the given output format arguments.
+func startDiff(runner CommandRunner, refRange string, pathspecs []string, opts DiffOptions, format ...string) (*DiffResult, error) {
+	args := append([]string{"diff", "--no-color"}, format...)
+	// --submodule=short keeps a diff.submodule setting from replacing
+	// submodule pointer changes with logs or inline diffs.
+	args = append(args, "-M", "--submodule=short")
+	if opts.Cached {
+		args = append(args, "--cached")
+	}
+	if opts.IgnoreWhitespace {
+		args = append(args, "-w")
+	}
+	if opts.Algorithm != "" {
+		args = append(args, "--diff-algorithm="+opts.Algorithm)
+	}
+	if refRange != "" {
+		args = append(args, refRange)
+	}
 	if len(pathspecs) > 0 {
 		args = append(args, "--")
 		args = append(args, pathspecs...)
@@ -129,3 +455,139 @@ func RunDiff(runner CommandRunner, refRange string, pathspecs []string) (*DiffRe
 
 	return &DiffResult{Stdout: stdout, Cmd: cmd}, nil
 }
+
+// CurrentBranch returns the short name of the checked-out branch. It fails
+// on a detached HEAD.
+func CurrentBranch(runner CommandRunner) (string, error) {
+	out, err := runner.Run("git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("HEAD is not on a branch")
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// UpstreamChain follows upstream tracking branches starting at branch while
+// they point at local branches, and returns the chain ordered from the bottom
+// of the stack to branch. The first element is the first upstream that is not
+// a local branch (e.g. "origin/main"), or the bottom-most local branch when it
+// has no upstream.
+func UpstreamChain(runner CommandRunner, branch string) []string {
+	chain := []string{branch}
+	seen := map[string]bool{branch: true}
+	current := branch
+	for {
+		out, err := runner.Run("git", "rev-parse", "--abbrev-ref", current+"@{upstream}")
+		if err != nil {
+			break
+		}
+		upstream := strings.TrimSpace(string(out))
+		if upstream == "" || seen[upstream] {
+			break
+		}
+		chain = append([]string{upstream}, chain...)
+		if _, err := runner.Run("git", "show-ref", "--verify", "--quiet", "refs/heads/"+upstream); err != nil {
+			break
+		}
+		seen[upstream] = true
+		current = upstream
+	}
+	return chain
+}
+
+// TopLevel returns the absolute path of the working tree's root directory.
+func TopLevel(runner CommandRunner) (string, error) {
+	out, err := runner.Run("git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not inside a git working tree")
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Prefix returns the current directory relative to the top level of the
+// working tree, as a slash-separated path with a trailing slash, or "" at
+// the top level.
+func Prefix(runner CommandRunner) (string, error) {
+	out, err := runner.Run("git", "rev-parse", "--show-prefix")
+	if err != nil {
+		return "", fmt.Errorf("not inside a git working tree")
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ListFiles returns the tracked and untracked, non-ignored files matching a
+// glob pathspec such as "**/.differ.yml", relative to the repository root
+// and sorted.
+func ListFiles(runner CommandRunner, glob string) ([]string, error) {
+	top, err := TopLevel(runner)
+	if err != nil {
+		return nil, err
+	}
+	out, err := runner.Run("git", "-C", top, "ls-files", "-z", "--cached", "--others", "--exclude-standard", "--", ":(glob)"+glob)
+	if err != nil {
+		return nil, fmt.Errorf("listing files: %w", err)
+	}
+	var files []string
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" && !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// maxFirstLine bounds how much of a file FirstLine reads.
+const maxFirstLine = 1024
+
+// FirstLine returns the first line of a blob named as in git cat-file, such
+// as "HEAD:bin/tool" or ":bin/tool" for the index, without reading the rest
+// of it.
+func FirstLine(runner CommandRunner, object string) (string, error) {
+	stdout, cmd, err := runner.Start("git", "cat-file", "-p", object)
+	if err != nil {
+		return "", err
+	}
+	line, readErr := readFirstLine(stdout)
+	stdout.Close()
+	// Closing early can make cat-file fail with a broken pipe; only a
+	// failure before anything was read matters.
+	if cmd == nil {
+		return line, readErr
+	}
+	if err := cmd.Wait(); err != nil && line == "" {
+		return "", fmt.Errorf("reading %s: %w", object, err)
+	}
+	return line, readErr
+}
+
+// readFirstLine reads up to the first newline of r, at most maxFirstLine
+// bytes.
+func readFirstLine(r io.Reader) (string, error) {
+	buf := make([]byte, maxFirstLine)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(buf[:n]), "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// Version returns the version of git, such as "2.45.1".
+func Version(runner CommandRunner) (string, error) {
+	out, err := runner.Run("git", "version")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "git version "), nil
+}
+
+// ResolveCommit returns the full SHA of the commit rev points at.
+func ResolveCommit(runner CommandRunner, rev string) (string, error) {
+	out, err := runner.Run("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%s is not a commit", rev)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
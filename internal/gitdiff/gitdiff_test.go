@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os/exec"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -13,9 +15,19 @@ type mockRunner struct {
 	validRefs map[string]bool
 	// statusOutput is returned by `git status --porcelain`.
 	statusOutput string
+	// upstreams maps "<branch>@{upstream}" lookups to their result.
+	upstreams map[string]string
+	// localBranches is the set of refs/heads/<name> that exist.
+	localBranches map[string]bool
+	// currentBranch is returned by `git symbolic-ref` when set.
+	currentBranch string
 	// mergeBaseOutput is returned by `git merge-base <base> <head>` when set.
 	mergeBaseOutput string
 	mergeBaseSet    bool
+	// tags maps `git describe --tags --abbrev=0 <rev>` lookups to their result.
+	tags map[string]string
+	// refsOutput is returned by `git for-each-ref`.
+	refsOutput string
 }
 
 func (m *mockRunner) Run(name string, args ...string) ([]byte, error) {
@@ -27,6 +39,44 @@ func (m *mockRunner) Run(name string, args ...string) ([]byte, error) {
 		}
 		return nil, fmt.Errorf("fatal: Needed a single revision")
 	}
+	// Expect: git rev-parse --verify --quiet <rev>
+	if len(args) == 4 && args[0] == "rev-parse" && args[1] == "--verify" && args[2] == "--quiet" {
+		if m.validRefs[args[3]] {
+			return []byte("abc123\n"), nil
+		}
+		return nil, fmt.Errorf("exit status 1")
+	}
+	if len(args) == 4 && args[0] == "hash-object" {
+		return []byte("4b825dc642cb6eb9a060e54bf8d69288fbee4904\n"), nil
+	}
+	if len(args) == 3 && args[0] == "rev-parse" && args[1] == "--abbrev-ref" {
+		branch := strings.TrimSuffix(args[2], "@{upstream}")
+		if up, ok := m.upstreams[branch]; ok {
+			return []byte(up + "\n"), nil
+		}
+		return nil, fmt.Errorf("fatal: no upstream configured")
+	}
+	if len(args) == 4 && args[0] == "show-ref" {
+		if m.localBranches[strings.TrimPrefix(args[3], "refs/heads/")] {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("exit status 1")
+	}
+	if len(args) == 4 && args[0] == "symbolic-ref" {
+		if m.currentBranch != "" {
+			return []byte(m.currentBranch + "\n"), nil
+		}
+		return nil, fmt.Errorf("exit status 1")
+	}
+	if len(args) == 4 && args[0] == "describe" {
+		if tag, ok := m.tags[args[3]]; ok {
+			return []byte(tag + "\n"), nil
+		}
+		return nil, fmt.Errorf("fatal: No names found")
+	}
+	if len(args) > 0 && args[0] == "for-each-ref" {
+		return []byte(m.refsOutput), nil
+	}
 	if len(args) == 2 && args[0] == "status" && args[1] == "--porcelain" {
 		return []byte(m.statusOutput), nil
 	}
@@ -65,6 +115,36 @@ func TestResolveRefs_PositionalRange(t *testing.T) {
 	}
 }
 
+func TestResolveRefs_PositionalSingleCommit(t *testing.T) {
+	runner := &mockRunner{validRefs: map[string]bool{"HEAD~1^{commit}": true, "HEAD~1^": true}}
+	got, err := ResolveRefs(runner, "", "", "HEAD~1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "HEAD~1^..HEAD~1" {
+		t.Errorf("got %q, want %q", got, "HEAD~1^..HEAD~1")
+	}
+}
+
+func TestResolveRefs_PositionalRootCommit(t *testing.T) {
+	runner := &mockRunner{validRefs: map[string]bool{"abc123^{commit}": true}}
+	got, err := ResolveRefs(runner, "", "", "abc123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "4b825dc642cb6eb9a060e54bf8d69288fbee4904..abc123"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveRefs_PositionalUnknownCommit(t *testing.T) {
+	runner := &mockRunner{}
+	if _, err := ResolveRefs(runner, "", "", "nope"); err == nil {
+		t.Fatal("expected error for unknown commit")
+	}
+}
+
 func TestResolveRefs_BaseHeadTakesPriorityOverPositional(t *testing.T) {
 	runner := &mockRunner{}
 	got, err := ResolveRefs(runner, "v1.0", "feature", "some..range")
@@ -117,6 +197,50 @@ func TestResolveRefs_NothingResolves(t *testing.T) {
 	}
 }
 
+func TestResolveRefs_Upstream(t *testing.T) {
+	tests := []struct {
+		name     string
+		branch   string
+		upstream string
+		want     string
+	}{
+		{"fork", "feature", "upstream/main", "upstream/main...HEAD"},
+		{"stacked", "part-2", "part-1", "part-1...HEAD"},
+		{"pushed branch", "feature", "origin/feature", "origin/HEAD...HEAD"},
+		{"nested pushed branch", "fix/crash", "origin/fix/crash", "origin/HEAD...HEAD"},
+		{"no upstream", "feature", "", "origin/HEAD...HEAD"},
+		{"detached", "", "", "origin/HEAD...HEAD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &mockRunner{
+				validRefs:     map[string]bool{"origin/HEAD": true, "main": true, tt.upstream: tt.upstream != ""},
+				currentBranch: tt.branch,
+				upstreams:     map[string]string{},
+			}
+			if tt.upstream != "" {
+				runner.upstreams[tt.branch] = tt.upstream
+			}
+			got, err := ResolveRefs(runner, "", "", "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveRefs_DetachedWithoutBase(t *testing.T) {
+	runner := &mockRunner{validRefs: map[string]bool{"HEAD": true}}
+	_, err := ResolveRefs(runner, "", "", "")
+	if err == nil || !strings.Contains(err.Error(), "HEAD is detached") {
+		t.Fatalf("got error %v, want one about the detached HEAD", err)
+	}
+}
+
 func TestResolveRefs_FallbackOrder(t *testing.T) {
 	// When main and master both exist, main should win.
 	runner := &mockRunner{validRefs: map[string]bool{"main": true, "master": true}}
@@ -175,3 +299,97 @@ func TestMergeBase_EmptyOutput(t *testing.T) {
 		t.Fatal("expected error, got nil")
 	}
 }
+
+func TestUpstreamChain(t *testing.T) {
+	runner := &mockRunner{
+		upstreams: map[string]string{
+			"feat-c": "feat-b",
+			"feat-b": "feat-a",
+			"feat-a": "origin/main",
+		},
+		localBranches: map[string]bool{"feat-a": true, "feat-b": true, "feat-c": true},
+	}
+	got := strings.Join(UpstreamChain(runner, "feat-c"), ",")
+	if got != "origin/main,feat-a,feat-b,feat-c" {
+		t.Errorf("UpstreamChain = %s", got)
+	}
+}
+
+func TestUpstreamChainNoUpstream(t *testing.T) {
+	runner := &mockRunner{}
+	got := UpstreamChain(runner, "feature")
+	if len(got) != 1 || got[0] != "feature" {
+		t.Errorf("UpstreamChain = %v, want [feature]", got)
+	}
+}
+
+func TestUpstreamChainCycle(t *testing.T) {
+	runner := &mockRunner{
+		upstreams:     map[string]string{"a": "b", "b": "a"},
+		localBranches: map[string]bool{"a": true, "b": true},
+	}
+	if got := strings.Join(UpstreamChain(runner, "a"), ","); got != "b,a" {
+		t.Errorf("UpstreamChain = %s, want b,a", got)
+	}
+}
+
+func TestCurrentBranch(t *testing.T) {
+	if got, err := CurrentBranch(&mockRunner{currentBranch: "feat"}); err != nil || got != "feat" {
+		t.Errorf("CurrentBranch = %q, %v", got, err)
+	}
+	if _, err := CurrentBranch(&mockRunner{}); err == nil {
+		t.Error("expected error on detached HEAD")
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	if got := DefaultBranch(&mockRunner{currentBranch: "origin/develop"}); got != "origin/develop" {
+		t.Errorf("with origin/HEAD: got %q, want origin/develop", got)
+	}
+	if got := DefaultBranch(&mockRunner{validRefs: map[string]bool{"refs/heads/master": true}}); got != "master" {
+		t.Errorf("local master: got %q, want master", got)
+	}
+	if got := DefaultBranch(&mockRunner{}); got != "" {
+		t.Errorf("no branch: got %q, want empty", got)
+	}
+}
+
+func TestListRefs(t *testing.T) {
+	got, err := ListRefs(&mockRunner{refsOutput: "feature\nmain\norigin/main\nv1.0.0\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"feature", "main", "origin/main", "v1.0.0"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReleaseRange(t *testing.T) {
+	runner := &mockRunner{
+		validRefs: map[string]bool{"v1.1.0^{commit}": true, "v1.0.0^{commit}": true},
+		tags:      map[string]string{"v1.1.0^": "v1.0.0"},
+	}
+	if got, err := ReleaseRange(runner, "v1.1.0"); err != nil || got != "v1.0.0..v1.1.0" {
+		t.Errorf("got %q, %v; want v1.0.0..v1.1.0", got, err)
+	}
+	if got, err := ReleaseRange(runner, "v1.0.0"); err != nil || got != "4b825dc642cb6eb9a060e54bf8d69288fbee4904..v1.0.0" {
+		t.Errorf("first release: got %q, %v", got, err)
+	}
+	if _, err := ReleaseRange(runner, "v9"); err == nil {
+		t.Error("expected an error for an unknown tag")
+	}
+}
+
+func TestCommandGitBinAndArgs(t *testing.T) {
+	defer func(bin string, args []string) { GitBin, GitArgs = bin, args }(GitBin, GitArgs)
+	GitBin, GitArgs = "/opt/git/bin/git", []string{"-c", "diff.algorithm=histogram"}
+
+	got := command("git", "diff", "--numstat").Args
+	want := []string{"/opt/git/bin/git", "-c", "diff.algorithm=histogram", "diff", "--numstat"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("git: got %q, want %q", got, want)
+	}
+	if got := command("sh", "-c", "true").Args; strings.Join(got, " ") != "sh -c true" {
+		t.Errorf("other commands must be left alone, got %q", got)
+	}
+}
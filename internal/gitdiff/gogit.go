@@ -0,0 +1,879 @@
+package gitdiff
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// emptyTreeSHA is the object name of the empty tree, which git hash-object
+// reports for /dev/null and ranges of root commits start from.
+const emptyTreeSHA = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// goGitRunner is the gogit backend: a CommandRunner that answers the git
+// invocations of differ's diff pipeline by reading the repository with
+// go-git, so no git binary is needed. It compares commits only; diffs of the
+// index or working tree, and history commands such as git log, fail with an
+// error naming the unsupported command. Commands other than git are run as
+// DefaultRunner runs them.
+type goGitRunner struct {
+	repo *git.Repository
+	root string // working tree root; empty for a bare repository
+	dir  string // directory the runner works in, as git's current directory
+}
+
+// NewGoGitRunner returns a CommandRunner that reads the repository
+// containing dir, which may be bare, with go-git instead of running git.
+func NewGoGitRunner(dir string) (CommandRunner, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		// A bare repository has no .git directory to look for.
+		repo, err = git.PlainOpen(dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening repository: %w", err)
+	}
+	r := &goGitRunner{repo: repo, dir: dir}
+	if wt, err := repo.Worktree(); err == nil {
+		r.root = wt.Filesystem.Root()
+	}
+	return r, nil
+}
+
+// Supports reports that the gogit backend can do none of the optional
+// features: it diffs commits with go-git's own algorithm and does not walk
+// history.
+func (r *goGitRunner) Supports(Feature) bool { return false }
+
+// newGoGitBackend is the factory of the gogit backend, which reads the
+// repository containing the current directory.
+func newGoGitBackend() (CommandRunner, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return NewGoGitRunner(dir)
+}
+
+// unsupported is the error for a git invocation the gogit backend cannot
+// answer.
+func unsupported(args ...string) error {
+	return fmt.Errorf("git %s is not supported by the gogit backend", strings.Join(args, " "))
+}
+
+func (r *goGitRunner) Run(name string, args ...string) ([]byte, error) {
+	return r.RunInput(nil, name, args...)
+}
+
+func (r *goGitRunner) RunInput(stdin []byte, name string, args ...string) ([]byte, error) {
+	if name != "git" {
+		return DefaultRunner.(InputRunner).RunInput(stdin, name, args...)
+	}
+	at := *r
+	for len(args) >= 2 && args[0] == "-C" {
+		if filepath.IsAbs(args[1]) {
+			at.dir = args[1]
+		} else {
+			at.dir = filepath.Join(at.dir, args[1])
+		}
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		return nil, errors.New("no git command given")
+	}
+	sub, rest := args[0], args[1:]
+	switch sub {
+	case "rev-parse":
+		return at.revParse(rest)
+	case "symbolic-ref":
+		return at.symbolicRef(rest)
+	case "show-ref":
+		return at.showRef(rest)
+	case "merge-base":
+		return at.mergeBase(rest)
+	case "config":
+		return at.config(rest)
+	case "hash-object":
+		if len(rest) == 3 && rest[0] == "-t" && rest[1] == "tree" && rest[2] == os.DevNull {
+			return []byte(emptyTreeSHA + "\n"), nil
+		}
+	case "diff":
+		return at.diff(rest)
+	case "status":
+		return at.status(rest)
+	case "ls-files":
+		return at.lsFiles(rest)
+	case "check-attr":
+		return at.checkAttr(stdin, rest)
+	case "cat-file":
+		if len(rest) == 2 && rest[0] == "-p" {
+			return at.catFile(rest[1])
+		}
+	case "version":
+		return nil, errors.New("the gogit backend does not run git")
+	}
+	return nil, unsupported(args...)
+}
+
+// Start runs the command as Run does and returns its output. The returned
+// *exec.Cmd is nil, as no process is started, except for commands other
+// than git.
+func (r *goGitRunner) Start(name string, args ...string) (io.ReadCloser, *exec.Cmd, error) {
+	if name != "git" {
+		return DefaultRunner.Start(name, args...)
+	}
+	out, err := r.Run(name, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return io.NopCloser(bytes.NewReader(out)), nil, nil
+}
+
+// prefix returns the runner's directory relative to the working tree root,
+// slash-separated, or "" at the root and in a bare repository.
+func (r *goGitRunner) prefix() string {
+	if r.root == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(r.root, r.dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (r *goGitRunner) revParse(args []string) ([]byte, error) {
+	var abbrevRef bool
+	var revs []string
+	for _, a := range args {
+		switch a {
+		case "--verify", "--quiet", "-q":
+		case "--abbrev-ref":
+			abbrevRef = true
+		case "--show-toplevel":
+			if r.root == "" {
+				return nil, errors.New("this operation must be run in a work tree")
+			}
+			return []byte(r.root + "\n"), nil
+		case "--show-prefix":
+			if p := r.prefix(); p != "" {
+				return []byte(p + "/\n"), nil
+			}
+			return []byte("\n"), nil
+		default:
+			if strings.HasPrefix(a, "-") {
+				return nil, unsupported(append([]string{"rev-parse"}, args...)...)
+			}
+			revs = append(revs, a)
+		}
+	}
+	if len(revs) != 1 {
+		return nil, unsupported(append([]string{"rev-parse"}, args...)...)
+	}
+	if abbrevRef {
+		branch, ok := strings.CutSuffix(revs[0], "@{upstream}")
+		if !ok {
+			return nil, unsupported(append([]string{"rev-parse"}, args...)...)
+		}
+		upstream, err := r.upstream(branch)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(upstream + "\n"), nil
+	}
+	hash, err := r.resolve(revs[0])
+	if err != nil {
+		return nil, err
+	}
+	return []byte(hash.String() + "\n"), nil
+}
+
+// resolve returns the commit rev names. The peel suffix ^{commit} is
+// accepted, as commits are all resolve returns.
+func (r *goGitRunner) resolve(rev string) (plumbing.Hash, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(strings.TrimSuffix(rev, "^{commit}")))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("resolving %s: %w", rev, err)
+	}
+	return *hash, nil
+}
+
+// upstream returns the tracking branch of branch, as git rev-parse
+// --abbrev-ref <branch>@{upstream} prints it.
+func (r *goGitRunner) upstream(branch string) (string, error) {
+	cfg, err := r.repo.Config()
+	if err != nil {
+		return "", err
+	}
+	b, ok := cfg.Branches[branch]
+	if !ok || b.Merge == "" {
+		return "", fmt.Errorf("no upstream configured for branch %q", branch)
+	}
+	if b.Remote == "." {
+		return b.Merge.Short(), nil
+	}
+	return b.Remote + "/" + b.Merge.Short(), nil
+}
+
+func (r *goGitRunner) symbolicRef(args []string) ([]byte, error) {
+	var short bool
+	var names []string
+	for _, a := range args {
+		switch a {
+		case "--quiet", "-q":
+		case "--short":
+			short = true
+		default:
+			names = append(names, a)
+		}
+	}
+	if len(names) != 1 {
+		return nil, unsupported(append([]string{"symbolic-ref"}, args...)...)
+	}
+	ref, err := r.repo.Storer.Reference(plumbing.ReferenceName(names[0]))
+	if err != nil {
+		return nil, err
+	}
+	if ref.Type() != plumbing.SymbolicReference {
+		return nil, fmt.Errorf("%s is not a symbolic ref", names[0])
+	}
+	if short {
+		return []byte(ref.Target().Short() + "\n"), nil
+	}
+	return []byte(ref.Target().String() + "\n"), nil
+}
+
+func (r *goGitRunner) showRef(args []string) ([]byte, error) {
+	if len(args) != 3 || args[0] != "--verify" || args[1] != "--quiet" {
+		return nil, unsupported(append([]string{"show-ref"}, args...)...)
+	}
+	if _, err := r.repo.Storer.Reference(plumbing.ReferenceName(args[2])); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (r *goGitRunner) mergeBase(args []string) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, unsupported(append([]string{"merge-base"}, args...)...)
+	}
+	base, err := r.mergeBaseOf(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	return []byte(base.String() + "\n"), nil
+}
+
+// mergeBaseOf returns the best common ancestor of commits a and b.
+func (r *goGitRunner) mergeBaseOf(a, b string) (plumbing.Hash, error) {
+	var commits [2]*object.Commit
+	for i, rev := range []string{a, b} {
+		hash, err := r.resolve(rev)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if commits[i], err = r.repo.CommitObject(hash); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+	bases, err := commits[0].MergeBase(commits[1])
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if len(bases) == 0 {
+		return plumbing.ZeroHash, fmt.Errorf("%s and %s have no common ancestor", a, b)
+	}
+	return bases[0].Hash, nil
+}
+
+// config answers git config --get and --bool lookups from the repository,
+// global, and system config files. --get-regexp finds nothing, as it only
+// looks for settings that need git itself, such as partial clone remotes.
+func (r *goGitRunner) config(args []string) ([]byte, error) {
+	var asBool bool
+	var key string
+	for _, a := range args {
+		switch a {
+		case "--get":
+		case "--bool":
+			asBool = true
+		case "--get-regexp":
+			return nil, errors.New("no matching config settings")
+		default:
+			if strings.HasPrefix(a, "-") || key != "" {
+				return nil, unsupported(append([]string{"config"}, args...)...)
+			}
+			key = a
+		}
+	}
+	cfg, err := r.repo.ConfigScoped(config.SystemScope)
+	if err != nil {
+		return nil, err
+	}
+	dot := strings.Index(key, ".")
+	last := strings.LastIndex(key, ".")
+	if dot < 0 {
+		return nil, fmt.Errorf("invalid config key %q", key)
+	}
+	section := cfg.Raw.Section(key[:dot])
+	var value string
+	var ok bool
+	if dot == last {
+		value, ok = section.Option(key[last+1:]), section.HasOption(key[last+1:])
+	} else if sub := key[dot+1 : last]; section.HasSubsection(sub) {
+		s := section.Subsection(sub)
+		value, ok = s.Option(key[last+1:]), s.HasOption(key[last+1:])
+	}
+	if !ok {
+		return nil, fmt.Errorf("config %s is not set", key)
+	}
+	if asBool {
+		switch strings.ToLower(value) {
+		case "true", "yes", "on", "1", "":
+			value = "true"
+		default:
+			value = "false"
+		}
+	}
+	return []byte(value + "\n"), nil
+}
+
+// status answers git status --porcelain with the two-letter status of each
+// changed file.
+func (r *goGitRunner) status(args []string) ([]byte, error) {
+	if len(args) != 1 || args[0] != "--porcelain" {
+		return nil, unsupported(append([]string{"status"}, args...)...)
+	}
+	wt, err := r.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	st, err := wt.Status()
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for p, fs := range st {
+		if fs.Staging == git.Unmodified && fs.Worktree == git.Unmodified {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%c%c %s\n", fs.Staging, fs.Worktree, p))
+	}
+	sort.Strings(lines)
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// lsFiles answers git ls-files -z with the files in the index, or at HEAD
+// in a bare repository, that match the pathspecs. Untracked files are not
+// listed.
+func (r *goGitRunner) lsFiles(args []string) ([]byte, error) {
+	var specs []string
+	for i, a := range args {
+		if a == "--" {
+			specs = args[i+1:]
+			break
+		}
+		switch a {
+		case "-z", "--cached", "--others", "--exclude-standard":
+		default:
+			return nil, unsupported(append([]string{"ls-files"}, args...)...)
+		}
+	}
+	match, err := r.pathspecMatcher(specs)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	emit := func(name string) {
+		if match(name) {
+			out.WriteString(name)
+			out.WriteByte(0)
+		}
+	}
+	if r.root != "" {
+		idx, err := r.repo.Storer.Index()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range idx.Entries {
+			emit(e.Name)
+		}
+		return out.Bytes(), nil
+	}
+	tree, err := r.tree("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	err = walkFiles(tree, func(name string, _ object.TreeEntry) error {
+		emit(name)
+		return nil
+	})
+	return out.Bytes(), err
+}
+
+// walkFiles calls fn with the path and entry of every file in tree, without
+// reading the files.
+func walkFiles(tree *object.Tree, fn func(name string, entry object.TreeEntry) error) error {
+	w := object.NewTreeWalker(tree, true, nil)
+	defer w.Close()
+	for {
+		name, entry, err := w.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if entry.Mode.IsFile() {
+			if err := fn(name, entry); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// checkAttr answers git check-attr -z with the attributes the
+// .gitattributes files committed at HEAD give each path, read from stdin
+// with --stdin or from the arguments after "--".
+func (r *goGitRunner) checkAttr(stdin []byte, args []string) ([]byte, error) {
+	var attrs, paths []string
+	fromStdin := false
+	for i, a := range args {
+		if a == "--" {
+			paths = args[i+1:]
+			break
+		}
+		switch a {
+		case "-z":
+		case "--stdin":
+			fromStdin = true
+		default:
+			attrs = append(attrs, a)
+		}
+	}
+	if fromStdin {
+		paths = strings.Split(strings.TrimSuffix(string(stdin), "\x00"), "\x00")
+	}
+	matcher, err := r.attrMatcher()
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		for _, attr := range attrs {
+			// Matching one attribute at a time stops at the last
+			// .gitattributes line that sets it, as git does.
+			value := "unspecified"
+			if results, _ := matcher.Match(strings.Split(p, "/"), []string{attr}); results[attr] != nil {
+				switch a := results[attr]; {
+				case a.IsSet():
+					value = "set"
+				case a.IsUnset():
+					value = "unset"
+				case a.IsValueSet():
+					value = a.Value()
+				}
+			}
+			fmt.Fprintf(&out, "%s\x00%s\x00%s\x00", p, attr, value)
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// attrMatcher reads the .gitattributes files committed at HEAD, shallowest
+// first, so deeper files take precedence.
+func (r *goGitRunner) attrMatcher() (gitattributes.Matcher, error) {
+	tree, err := r.tree("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	type attrFile struct {
+		domain []string
+		attrs  []gitattributes.MatchAttribute
+	}
+	var files []attrFile
+	err = walkFiles(tree, func(name string, entry object.TreeEntry) error {
+		if path.Base(name) != ".gitattributes" {
+			return nil
+		}
+		blob, err := r.repo.BlobObject(entry.Hash)
+		if err != nil {
+			return err
+		}
+		rd, err := blob.Reader()
+		if err != nil {
+			return err
+		}
+		defer rd.Close()
+		var domain []string
+		if dir := path.Dir(name); dir != "." {
+			domain = strings.Split(dir, "/")
+		}
+		attrs, err := gitattributes.ReadAttributes(rd, domain, domain == nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		files = append(files, attrFile{domain, attrs})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(files, func(i, j int) bool { return len(files[i].domain) < len(files[j].domain) })
+	var stack []gitattributes.MatchAttribute
+	for _, f := range files {
+		stack = append(stack, f.attrs...)
+	}
+	return gitattributes.NewMatcher(stack), nil
+}
+
+// catFile answers git cat-file -p for a blob named "<rev>:<path>", or
+// ":<path>" for the index.
+func (r *goGitRunner) catFile(object string) ([]byte, error) {
+	rev, name, ok := strings.Cut(object, ":")
+	if !ok {
+		return nil, unsupported("cat-file", "-p", object)
+	}
+	var hash plumbing.Hash
+	if rev == "" {
+		idx, err := r.repo.Storer.Index()
+		if err != nil {
+			return nil, err
+		}
+		e, err := idx.Entry(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", object, err)
+		}
+		hash = e.Hash
+	} else {
+		tree, err := r.tree(rev)
+		if err != nil {
+			return nil, err
+		}
+		e, err := tree.FindEntry(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", object, err)
+		}
+		hash = e.Hash
+	}
+	blob, err := r.repo.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	rd, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer rd.Close()
+	return io.ReadAll(rd)
+}
+
+// tree returns the tree of commit rev, or the empty tree for emptyTreeSHA.
+func (r *goGitRunner) tree(rev string) (*object.Tree, error) {
+	if rev == emptyTreeSHA {
+		return &object.Tree{}, nil
+	}
+	hash, err := r.resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
+
+// diff answers git diff between two commits, "a..b", or "a...b" for the
+// changes since their merge base, as a patch with renames detected. -U sets
+// the lines of context; -w, --diff-algorithm, --cached, and other formats
+// such as --numstat are not supported.
+func (r *goGitRunner) diff(args []string) ([]byte, error) {
+	ctxLines := 3
+	var revs, specs []string
+	for i, a := range args {
+		if a == "--" {
+			specs = args[i+1:]
+			break
+		}
+		switch {
+		case a == "--no-color", a == "-M", a == "--submodule=short":
+		case strings.HasPrefix(a, "-U"):
+			n, err := strconv.Atoi(a[2:])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid context lines %q", a)
+			}
+			ctxLines = n
+		case strings.HasPrefix(a, "-"):
+			return nil, unsupported("diff", a)
+		default:
+			revs = append(revs, a)
+		}
+	}
+	if len(revs) != 1 || !strings.Contains(revs[0], "..") {
+		return nil, errors.New("the gogit backend compares commits only, not the index or working tree; pass a rev-range or --base and --head")
+	}
+	from, to, threeDot := strings.Cut(revs[0], "...")
+	if !threeDot {
+		from, to, _ = strings.Cut(revs[0], "..")
+	}
+	if from == "" {
+		from = "HEAD"
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	if threeDot {
+		base, err := r.mergeBaseOf(from, to)
+		if err != nil {
+			return nil, err
+		}
+		from = base.String()
+	}
+	fromTree, err := r.tree(from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := r.tree(to)
+	if err != nil {
+		return nil, err
+	}
+	match, err := r.pathspecMatcher(specs)
+	if err != nil {
+		return nil, err
+	}
+	// As in git, pathspecs limit the files before renames are paired, so a
+	// file renamed into them from outside shows as added.
+	all, err := object.DiffTreeWithOptions(context.Background(), fromTree, toTree, nil)
+	if err != nil {
+		return nil, err
+	}
+	var changes object.Changes
+	for _, c := range all {
+		if match(c.From.Name) || match(c.To.Name) {
+			changes = append(changes, c)
+		}
+	}
+	if changes, err = object.DetectRenames(changes, object.DefaultDiffTreeOptions); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	enc := fdiff.NewUnifiedEncoder(&out, ctxLines)
+	for _, c := range changes {
+		if c.From.TreeEntry.Mode == filemode.Submodule || c.To.TreeEntry.Mode == filemode.Submodule {
+			writeSubmodulePatch(&out, c)
+			continue
+		}
+		patch, err := c.Patch()
+		if err != nil {
+			return nil, fmt.Errorf("diffing %s: %w", c, err)
+		}
+		if err := enc.Encode(patch); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// writeSubmodulePatch writes a submodule pointer change as git diff
+// --submodule=short does.
+func writeSubmodulePatch(w io.Writer, c *object.Change) {
+	from, to := c.From, c.To
+	name := to.Name
+	if name == "" {
+		name = from.Name
+	}
+	fmt.Fprintf(w, "diff --git a/%s b/%s\n", name, name)
+	switch {
+	case from.Name == "":
+		fmt.Fprintf(w, "new file mode %o\nindex %s..%s\n--- /dev/null\n+++ b/%s\n@@ -0,0 +1 @@\n+Subproject commit %s\n",
+			to.TreeEntry.Mode, plumbing.ZeroHash, to.TreeEntry.Hash, name, to.TreeEntry.Hash)
+	case to.Name == "":
+		fmt.Fprintf(w, "deleted file mode %o\nindex %s..%s\n--- a/%s\n+++ /dev/null\n@@ -1 +0,0 @@\n-Subproject commit %s\n",
+			from.TreeEntry.Mode, from.TreeEntry.Hash, plumbing.ZeroHash, name, from.TreeEntry.Hash)
+	default:
+		fmt.Fprintf(w, "index %s..%s %o\n--- a/%s\n+++ b/%s\n@@ -1 +1 @@\n-Subproject commit %s\n+Subproject commit %s\n",
+			from.TreeEntry.Hash, to.TreeEntry.Hash, to.TreeEntry.Mode, name, name, from.TreeEntry.Hash, to.TreeEntry.Hash)
+	}
+}
+
+// pathspec is one parsed git pathspec, relative to the repository root.
+type pathspec struct {
+	pattern string
+	literal bool // match pattern exactly, wildcards included
+	glob    bool // match with ** and * not crossing /
+	exclude bool
+	re      *regexp.Regexp // for default-magic patterns with wildcards
+}
+
+// pathspecMatcher returns a function reporting whether a path relative to
+// the repository root matches specs, given relative to the runner's
+// directory unless the top magic says otherwise. It supports the top,
+// literal, glob, and exclude magic, in long and short form. An empty path
+// matches nothing; no specs match every other path.
+func (r *goGitRunner) pathspecMatcher(specs []string) (func(string) bool, error) {
+	var include, exclude []pathspec
+	for _, s := range specs {
+		p, err := parsePathspec(s, r.prefix())
+		if err != nil {
+			return nil, err
+		}
+		if p.exclude {
+			exclude = append(exclude, p)
+		} else {
+			include = append(include, p)
+		}
+	}
+	return func(name string) bool {
+		if name == "" {
+			return false
+		}
+		matched := len(include) == 0
+		for _, p := range include {
+			if p.match(name) {
+				matched = true
+				break
+			}
+		}
+		for _, p := range exclude {
+			if matched && p.match(name) {
+				return false
+			}
+		}
+		return matched
+	}, nil
+}
+
+// parsePathspec parses s, a pathspec given in directory prefix.
+func parsePathspec(s, prefix string) (pathspec, error) {
+	var p pathspec
+	top := false
+	switch {
+	case strings.HasPrefix(s, ":("):
+		end := strings.Index(s, ")")
+		if end < 0 {
+			return p, fmt.Errorf("invalid pathspec %q", s)
+		}
+		for _, magic := range strings.Split(s[2:end], ",") {
+			switch magic {
+			case "top":
+				top = true
+			case "literal":
+				p.literal = true
+			case "glob":
+				p.glob = true
+			case "exclude":
+				p.exclude = true
+			default:
+				return p, fmt.Errorf("pathspec magic %q is not supported by the gogit backend", magic)
+			}
+		}
+		s = s[end+1:]
+	case strings.HasPrefix(s, ":"):
+		s = s[1:]
+	short:
+		for s != "" {
+			switch s[0] {
+			case '/':
+				top = true
+			case '!', '^':
+				p.exclude = true
+			case ':':
+				s = s[1:]
+				break short
+			default:
+				break short
+			}
+			s = s[1:]
+		}
+	}
+	if !top {
+		s = path.Join(prefix, s)
+	}
+	if s = path.Clean("/" + s)[1:]; s == "/" {
+		s = ""
+	}
+	p.pattern = s
+	if !p.literal && !p.glob && strings.ContainsAny(s, "*?[") {
+		p.re = wildcardRegexp(s)
+	}
+	return p, nil
+}
+
+func (p pathspec) match(name string) bool {
+	switch {
+	case p.pattern == "":
+		return true
+	case p.glob:
+		ok, _ := doublestar.Match(p.pattern, name)
+		if !ok {
+			ok, _ = doublestar.Match(p.pattern+"/**", name)
+		}
+		return ok
+	case p.re != nil:
+		return p.re.MatchString(name)
+	default:
+		return name == p.pattern || strings.HasPrefix(name, p.pattern+"/")
+	}
+}
+
+// wildcardRegexp translates a pathspec pattern, whose * and ? match /
+// too, into a regexp matching the paths it names and the files under them.
+func wildcardRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			if end := strings.IndexByte(pattern[i+1:], ']'); end >= 0 {
+				class := pattern[i+1 : i+1+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+				i += end + 1
+				continue
+			}
+			b.WriteString(regexp.QuoteMeta("["))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("(?:/.*)?$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return regexp.MustCompile("^" + regexp.QuoteMeta(pattern) + "(?:/.*)?$")
+	}
+	return re
+}
@@ -0,0 +1,174 @@
+package gitdiff
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+// goGitTestRepo creates a repository whose feature branch modifies, adds,
+// deletes, renames, and changes the mode of files, and returns its path.
+func goGitTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name, content string, mode os.FileMode) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitInDir(t, dir, "init", "-q", "-b", "main")
+	gitInDir(t, dir, "config", "user.email", "test@test.com")
+	gitInDir(t, dir, "config", "user.name", "Test")
+	write("keep.go", "package a\n\nfunc A() {}\n", 0o644)
+	write("gone.txt", "bye\n", 0o644)
+	write("old/name.md", strings.Repeat("line\n", 20), 0o644)
+	write("run.sh", "echo hi\n", 0o644)
+	write(".gitattributes", "*.pb.go linguist-generated\n", 0o644)
+	write("sub/.gitattributes", "*.pb.go -linguist-generated\n", 0o644)
+	gitInDir(t, dir, "add", "-A")
+	gitInDir(t, dir, "commit", "-qm", "initial")
+
+	gitInDir(t, dir, "checkout", "-qb", "feature")
+	write("keep.go", "package a\n\nfunc A() { B() }\n\nfunc B() {}\n", 0o644)
+	write("new/file.go", "package b\n", 0o644)
+	os.Remove(filepath.Join(dir, "gone.txt"))
+	gitInDir(t, dir, "mv", "old/name.md", "new/name.md")
+	write("new/name.md", strings.Repeat("line\n", 20)+"more\n", 0o644)
+	write("run.sh", "echo hi\n", 0o755)
+	gitInDir(t, dir, "add", "-A")
+	gitInDir(t, dir, "commit", "-qm", "feature")
+	return dir
+}
+
+func TestGoGitDiffMatchesGit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	dir := goGitTestRepo(t)
+	gogit, err := NewGoGitRunner(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		refRange  string
+		pathspecs []string
+	}{
+		{"main..feature", nil},
+		{"main...feature", nil},
+		{"main..feature", []string{"new"}},
+		{"main..feature", []string{":(exclude)*.md"}},
+	} {
+		var stats [2][]parser.FileStat
+		for i, runner := range []CommandRunner{&dirRunner{dir: dir}, gogit} {
+			result, err := RunDiff(runner, tc.refRange, tc.pathspecs)
+			if err != nil {
+				t.Fatalf("%s %v: RunDiff: %v", tc.refRange, tc.pathspecs, err)
+			}
+			if stats[i], err = parser.Parse(result.Stdout, "exclude"); err != nil {
+				t.Fatalf("%s %v: Parse: %v", tc.refRange, tc.pathspecs, err)
+			}
+			if err := result.Wait(); err != nil {
+				t.Fatalf("%s %v: Wait: %v", tc.refRange, tc.pathspecs, err)
+			}
+		}
+		if len(stats[0]) == 0 || !reflect.DeepEqual(stats[0], stats[1]) {
+			t.Errorf("%s %v:\ngogit: %+v\ngit:   %+v", tc.refRange, tc.pathspecs, stats[1], stats[0])
+		}
+	}
+}
+
+func TestGoGitRefs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	dir := goGitTestRepo(t)
+	gitInDir(t, dir, "config", "branch.feature.remote", ".")
+	gitInDir(t, dir, "config", "branch.feature.merge", "refs/heads/main")
+	runner, err := NewGoGitRunner(filepath.Join(dir, "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if branch, err := CurrentBranch(runner); err != nil || branch != "feature" {
+		t.Errorf("CurrentBranch = %q, %v; want feature", branch, err)
+	}
+	if got, err := ResolveRefs(runner, "", "", ""); err != nil || got != "main...HEAD" {
+		t.Errorf("ResolveRefs = %q, %v; want main...HEAD from the upstream", got, err)
+	}
+	if top, err := TopLevel(runner); err != nil || top != dir {
+		t.Errorf("TopLevel = %q, %v; want %q", top, err, dir)
+	}
+	if prefix, err := Prefix(runner); err != nil || prefix != "new/" {
+		t.Errorf("Prefix = %q, %v; want new/", prefix, err)
+	}
+	base, err := MergeBase(runner, "main", "feature")
+	if want := strings.TrimSpace(gitInDir(t, dir, "rev-parse", "main")); err != nil || base != want {
+		t.Errorf("MergeBase = %q, %v; want %q", base, err, want)
+	}
+	if got, err := SingleCommitRange(runner, "main"); err != nil || got != emptyTreeSHA+"..main" {
+		t.Errorf("SingleCommitRange(root) = %q, %v", got, err)
+	}
+	if line, err := FirstLine(runner, "feature:run.sh"); err != nil || line != "echo hi" {
+		t.Errorf("FirstLine = %q, %v", line, err)
+	}
+	if files, err := ListFiles(runner, "**/*.go"); err != nil || strings.Join(files, ",") != "keep.go,new/file.go" {
+		t.Errorf("ListFiles = %v, %v", files, err)
+	}
+	attrs, err := CheckAttr(runner, []string{"a.pb.go", "sub/b.pb.go", "c.go"}, []string{"linguist-generated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attrs) != 2 || attrs["a.pb.go"]["linguist-generated"] != "set" || attrs["sub/b.pb.go"]["linguist-generated"] != "unset" {
+		t.Errorf("CheckAttr = %v", attrs)
+	}
+	if _, err := runner.Run("git", "log", "--oneline"); err == nil || !strings.Contains(err.Error(), "not supported by the gogit backend") {
+		t.Errorf("git log: err = %v, want unsupported", err)
+	}
+	if _, err := RunDiff(runner, "", nil); err == nil {
+		t.Error("expected an error diffing the working tree")
+	}
+	for _, f := range []Feature{FeatureWorktree, FeatureHistory, FeatureIgnoreWhitespace, FeatureDiffAlgorithm} {
+		if Supports(runner, f) {
+			t.Errorf("Supports(%d) = true, want false", f)
+		}
+		if !Supports(DefaultRunner, f) {
+			t.Errorf("DefaultRunner: Supports(%d) = false, want true", f)
+		}
+	}
+}
+
+func TestGoGitBareRepository(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	bare := filepath.Join(t.TempDir(), "repo.git")
+	gitInDir(t, t.TempDir(), "clone", "-q", "--bare", goGitTestRepo(t), bare)
+	runner, err := NewGoGitRunner(bare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := RunDiff(runner, "main..feature", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := parser.Parse(result.Stdout, "exclude")
+	if err != nil || len(stats) != 5 {
+		t.Errorf("stats = %+v, %v; want 5 files", stats, err)
+	}
+	if _, err := TopLevel(runner); err == nil {
+		t.Error("expected no working tree in a bare repository")
+	}
+}
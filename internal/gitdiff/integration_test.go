@@ -73,6 +73,14 @@ func TestIntegration_RunDiff(t *testing.T) {
 		t.Errorf("ResolveRefs got %q, want %q", refRange, "main...HEAD")
 	}
 
+	// A tracking branch other than the default one is preferred.
+	gitInDir(t, tmpDir, "branch", "release", "main")
+	gitInDir(t, tmpDir, "branch", "--set-upstream-to=release", "feature")
+	if refRange, err := ResolveRefs(runner, "", "", ""); err != nil || refRange != "release...HEAD" {
+		t.Errorf("ResolveRefs with an upstream = %q, %v; want %q", refRange, err, "release...HEAD")
+	}
+	gitInDir(t, tmpDir, "branch", "--unset-upstream", "feature")
+
 	// Test RunDiff.
 	result, err := RunDiff(runner, refRange, nil)
 	if err != nil {
@@ -126,6 +134,25 @@ func TestIntegration_RunDiff(t *testing.T) {
 	if strings.Contains(diffStr2, "new.txt") {
 		t.Errorf("pathspec-filtered diff should not contain new.txt:\n%s", diffStr2)
 	}
+
+	// Test TopLevel and ResolveCommit.
+	top, err := TopLevel(runner)
+	if err != nil {
+		t.Fatalf("TopLevel: %v", err)
+	}
+	if resolved, _ := filepath.EvalSymlinks(tmpDir); top != resolved && top != tmpDir {
+		t.Errorf("TopLevel = %q, want %q", top, tmpDir)
+	}
+	sha, err := ResolveCommit(runner, "main")
+	if err != nil {
+		t.Fatalf("ResolveCommit: %v", err)
+	}
+	if want := strings.TrimSpace(gitInDir(t, tmpDir, "rev-parse", "main")); sha != want {
+		t.Errorf("ResolveCommit(main) = %q, want %q", sha, want)
+	}
+	if _, err := ResolveCommit(runner, "no-such-ref"); err == nil {
+		t.Error("expected error resolving a missing ref")
+	}
 }
 
 // dirRunner runs git commands in a specific directory.
@@ -151,3 +178,180 @@ func (d *dirRunner) Start(name string, args ...string) (io.ReadCloser, *exec.Cmd
 	}
 	return stdout, cmd, nil
 }
+
+// readDiff runs RunDiffWithOptions and returns its full output.
+func readDiff(t *testing.T, runner CommandRunner, refRange string, opts DiffOptions) string {
+	t.Helper()
+	result, err := RunDiffWithOptions(runner, refRange, nil, opts)
+	if err != nil {
+		t.Fatalf("RunDiffWithOptions: %v", err)
+	}
+	out, err := io.ReadAll(result.Stdout)
+	if err != nil {
+		t.Fatalf("reading stdout: %v", err)
+	}
+	if err := result.Wait(); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	return string(out)
+}
+
+func TestIntegration_RunDiffCached(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	gitInDir(t, tmpDir, "init")
+	gitInDir(t, tmpDir, "config", "user.email", "test@test.com")
+	gitInDir(t, tmpDir, "config", "user.name", "Test")
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitInDir(t, tmpDir, "add", "a.txt")
+	gitInDir(t, tmpDir, "commit", "-m", "initial")
+
+	// Stage one change and leave another unstaged.
+	if err := os.WriteFile(filepath.Join(tmpDir, "staged.txt"), []byte("staged\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitInDir(t, tmpDir, "add", "staged.txt")
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.txt"), []byte("a\nunstaged\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	diffStr := readDiff(t, &dirRunner{dir: tmpDir}, "HEAD", DiffOptions{Cached: true})
+	if !strings.Contains(diffStr, "+staged") {
+		t.Errorf("cached diff missing staged change:\n%s", diffStr)
+	}
+	if strings.Contains(diffStr, "+unstaged") {
+		t.Errorf("cached diff should not contain unstaged change:\n%s", diffStr)
+	}
+}
+
+func TestIntegration_ListFiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	gitInDir(t, tmpDir, "init")
+	gitInDir(t, tmpDir, "config", "user.email", "test@test.com")
+	gitInDir(t, tmpDir, "config", "user.name", "Test")
+
+	for _, p := range []string{".differ.yml", "pkg/a/.differ.yml", "pkg/b/.differ.yml", "ignored/.differ.yml", "pkg/a/main.go"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(p)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, p), []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("ignored/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitInDir(t, tmpDir, "add", ".differ.yml", "pkg/a")
+	gitInDir(t, tmpDir, "commit", "-m", "initial")
+
+	// Run from a subdirectory; paths are still relative to the root.
+	files, err := ListFiles(DirRunner{Dir: filepath.Join(tmpDir, "pkg")}, "**/.differ.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ".differ.yml,pkg/a/.differ.yml,pkg/b/.differ.yml"
+	if got := strings.Join(files, ","); got != want {
+		t.Errorf("ListFiles = %s, want %s", got, want)
+	}
+}
+
+func TestIntegration_DiffAlgorithm(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	gitInDir(t, tmpDir, "init")
+	gitInDir(t, tmpDir, "config", "user.email", "test@test.com")
+	gitInDir(t, tmpDir, "config", "user.name", "Test")
+	path := filepath.Join(tmpDir, "f.txt")
+	if err := os.WriteFile(path, []byte("}\na\ny\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitInDir(t, tmpDir, "add", "f.txt")
+	gitInDir(t, tmpDir, "commit", "-m", "initial")
+	if err := os.WriteFile(path, []byte("b\ny\na\n}\nb\ny\ny\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the repository's own diff.algorithm setting should count.
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	runner := &dirRunner{dir: tmpDir}
+	added := func(algorithm string) int {
+		n := 0
+		for _, line := range strings.Split(readDiff(t, runner, "", DiffOptions{Algorithm: algorithm}), "\n") {
+			if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+				n++
+			}
+		}
+		return n
+	}
+	if myers, histogram := added("myers"), added("histogram"); myers != 6 || histogram != 7 {
+		t.Errorf("added lines: myers %d, histogram %d; want 6 and 7", myers, histogram)
+	}
+
+	if got := EffectiveAlgorithm(runner, ""); got != "myers" {
+		t.Errorf("EffectiveAlgorithm without config = %q, want myers", got)
+	}
+	gitInDir(t, tmpDir, "config", "diff.algorithm", "patience")
+	if got := EffectiveAlgorithm(runner, ""); got != "patience" {
+		t.Errorf("EffectiveAlgorithm with diff.algorithm = %q, want patience", got)
+	}
+	if got := EffectiveAlgorithm(runner, "histogram"); got != "histogram" {
+		t.Errorf("EffectiveAlgorithm(histogram) = %q", got)
+	}
+}
+
+func TestIntegration_DateRange(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	gitInDir(t, tmpDir, "init")
+	commits := make(map[string]string)
+	for _, date := range []string{"2024-01-01", "2024-02-01", "2024-03-01"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, "log.txt"), []byte(date+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		gitInDir(t, tmpDir, "add", "log.txt")
+		cmd := exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com", "commit", "-qm", date)
+		cmd.Dir = tmpDir
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date+"T12:00:00Z", "GIT_AUTHOR_DATE="+date+"T12:00:00Z")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("commit failed: %v\n%s", err, out)
+		}
+		commits[date] = strings.TrimSpace(gitInDir(t, tmpDir, "rev-parse", "HEAD"))
+	}
+	runner := &dirRunner{dir: tmpDir}
+	const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+	tests := []struct {
+		since, until, want string
+	}{
+		{"2024-01-15", "", commits["2024-01-01"] + "..HEAD"},
+		{"2024-01-15", "2024-02-15", commits["2024-01-01"] + ".." + commits["2024-02-01"]},
+		{"2023-06-01", "", emptyTree + "..HEAD"},
+		{"", "2024-01-15", emptyTree + ".." + commits["2024-01-01"]},
+	}
+	for _, tt := range tests {
+		got, err := DateRange(runner, tt.since, tt.until, "")
+		if err != nil || got != tt.want {
+			t.Errorf("DateRange(%q, %q) = %q, %v; want %q", tt.since, tt.until, got, err, tt.want)
+		}
+	}
+	if _, err := DateRange(runner, "", "2023-06-01", ""); err == nil || !strings.Contains(err.Error(), "no commits") {
+		t.Errorf("until before the first commit: err = %v", err)
+	}
+}
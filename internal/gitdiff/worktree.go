@@ -0,0 +1,89 @@
+package gitdiff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DirRunner is a CommandRunner that runs commands in Dir with Env appended to
+// the current process environment.
+type DirRunner struct {
+	Dir string
+	Env []string
+}
+
+func (d DirRunner) command(name string, args ...string) *exec.Cmd {
+	cmd := command(name, args...)
+	cmd.Dir = d.Dir
+	if len(d.Env) > 0 {
+		cmd.Env = append(os.Environ(), d.Env...)
+	}
+	return cmd
+}
+
+func (d DirRunner) Run(name string, args ...string) ([]byte, error) {
+	return output(d.command(name, args...))
+}
+
+func (d DirRunner) RunInput(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := d.command(name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	return output(cmd)
+}
+
+func (d DirRunner) Start(name string, args ...string) (io.ReadCloser, *exec.Cmd, error) {
+	return start(d.command(name, args...))
+}
+
+// CommonDir returns the absolute path of the shared git directory for the
+// worktree at dir. Worktrees of the same repository share a common dir.
+func CommonDir(dir string) (string, error) {
+	out, err := DirRunner{Dir: dir}.Run("git", "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("%s is not a git worktree", dir)
+	}
+	return filepath.Clean(strings.TrimSpace(string(out))), nil
+}
+
+// SnapshotWorktree records the complete state of the worktree at dir —
+// committed, staged, unstaged, and untracked non-ignored files — as a tree
+// object and returns its hash. A temporary copy of the index is used, so the
+// worktree's real index is left untouched; blobs for modified files are
+// written to the shared object database.
+func SnapshotWorktree(dir string) (string, error) {
+	out, err := DirRunner{Dir: dir}.Run("git", "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", fmt.Errorf("%s is not a git worktree", dir)
+	}
+	indexPath := strings.TrimSpace(string(out))
+
+	tmpDir, err := os.MkdirTemp("", "differ-index-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpPath := filepath.Join(tmpDir, "index")
+
+	// Seed the temporary index from the real one so unchanged files are not
+	// re-hashed. A missing index (fresh worktree) starts empty.
+	if data, err := os.ReadFile(indexPath); err == nil {
+		if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+			return "", err
+		}
+	}
+
+	runner := DirRunner{Dir: dir, Env: []string{"GIT_INDEX_FILE=" + tmpPath}}
+	if _, err := runner.Run("git", "add", "-A"); err != nil {
+		return "", fmt.Errorf("staging worktree %s: %w", dir, err)
+	}
+	out, err = runner.Run("git", "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("writing tree for %s: %w", dir, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
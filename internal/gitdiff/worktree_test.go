@@ -0,0 +1,126 @@
+package gitdiff
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIntegration_SnapshotWorktree(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	repo := filepath.Join(tmpDir, "repo")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitInDir(t, repo, "init")
+	gitInDir(t, repo, "config", "user.email", "test@test.com")
+	gitInDir(t, repo, "config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitInDir(t, repo, "add", "a.txt")
+	gitInDir(t, repo, "commit", "-m", "initial")
+
+	other := filepath.Join(tmpDir, "other")
+	gitInDir(t, repo, "worktree", "add", "-b", "other", other)
+
+	// Uncommitted edits in each worktree: one unstaged, one untracked.
+	if err := os.WriteFile(filepath.Join(repo, "a.txt"), []byte("a\nmain edit\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(other, "new.txt"), []byte("untracked\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	statusBefore := gitInDir(t, repo, "status", "--porcelain")
+
+	treeA, err := SnapshotWorktree(repo)
+	if err != nil {
+		t.Fatalf("SnapshotWorktree(repo): %v", err)
+	}
+	treeB, err := SnapshotWorktree(other)
+	if err != nil {
+		t.Fatalf("SnapshotWorktree(other): %v", err)
+	}
+
+	if statusAfter := gitInDir(t, repo, "status", "--porcelain"); statusAfter != statusBefore {
+		t.Errorf("snapshot modified the real index:\nbefore: %s\nafter: %s", statusBefore, statusAfter)
+	}
+
+	diffStr := readDiff(t, DirRunner{Dir: repo}, treeA+".."+treeB, DiffOptions{})
+	if !strings.Contains(diffStr, "-main edit") {
+		t.Errorf("diff missing unstaged edit from worktree A:\n%s", diffStr)
+	}
+	if !strings.Contains(diffStr, "+untracked") {
+		t.Errorf("diff missing untracked file from worktree B:\n%s", diffStr)
+	}
+
+	commonA, err := CommonDir(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commonB, err := CommonDir(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commonA != commonB {
+		t.Errorf("worktrees should share a common dir: %s vs %s", commonA, commonB)
+	}
+	if _, err := CommonDir(t.TempDir()); err == nil {
+		t.Error("expected error for a directory outside any repository")
+	}
+}
+
+func TestIntegration_Trace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	repo := t.TempDir()
+	gitInDir(t, repo, "init")
+
+	type call struct {
+		args string
+		n    int64
+		err  error
+	}
+	var calls []call
+	Trace = func(cmd *exec.Cmd) func(int64, error) {
+		return func(n int64, err error) {
+			calls = append(calls, call{strings.Join(cmd.Args[1:], " "), n, err})
+		}
+	}
+	defer func() { Trace = nil }()
+
+	runner := DirRunner{Dir: repo}
+	if _, err := runner.Run("git", "rev-parse", "--git-dir"); err != nil {
+		t.Fatal(err)
+	}
+	runner.Run("git", "rev-parse", "--verify", "--quiet", "nosuchref")
+	out, cmd, err := runner.Start("git", "rev-parse", "--git-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(out)
+	cmd.Wait()
+
+	want := []call{
+		{"rev-parse --git-dir", 5, nil},
+		{"rev-parse --verify --quiet nosuchref", 0, nil},
+		{"rev-parse --git-dir", 5, nil},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("traced %+v, want %+v", calls, want)
+	}
+	for i, c := range calls {
+		if c.args != want[i].args || c.n != want[i].n || (c.err == nil) != (i != 1) {
+			t.Errorf("call %d = %+v, want %s with %d bytes", i, c, want[i].args, want[i].n)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the public GitHub REST API endpoint.
+const DefaultBaseURL = "https://api.github.com"
+
+// Client is a minimal GitHub REST API client.
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client authenticated with token. The base URL honors
+// GITHUB_API_URL (set by GitHub Actions, including on GHES).
+func NewClient(token string) *Client {
+	base := os.Getenv("GITHUB_API_URL")
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL: strings.TrimSuffix(base, "/"),
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// PullRequest holds the pull request fields differ uses.
+type PullRequest struct {
+	Number int `json:"number"`
+	Base   struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"base"`
+	Head struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// PullRequest fetches pull request number in repo ("owner/name").
+func (c *Client) PullRequest(repo string, number int) (PullRequest, error) {
+	var pr PullRequest
+	err := c.do(http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), nil, &pr)
+	return pr, err
+}
+
+// PullRequestCommit holds the commit fields differ uses. Author is the
+// GitHub account the commit email belongs to, and nil if none does.
+type PullRequestCommit struct {
+	SHA    string `json:"sha"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Commit struct {
+		Author struct {
+			Email string `json:"email"`
+		} `json:"author"`
+	} `json:"commit"`
+}
+
+// commitsPerPage is the page size used to list pull request commits, the
+// most GitHub allows.
+const commitsPerPage = 100
+
+// PullRequestCommits lists the commits of pull request number in repo,
+// up to the 250 that GitHub returns.
+func (c *Client) PullRequestCommits(repo string, number int) ([]PullRequestCommit, error) {
+	var commits []PullRequestCommit
+	for page := 1; ; page++ {
+		var batch []PullRequestCommit
+		path := fmt.Sprintf("/repos/%s/pulls/%d/commits?per_page=%d&page=%d", repo, number, commitsPerPage, page)
+		if err := c.do(http.MethodGet, path, nil, &batch); err != nil {
+			return nil, err
+		}
+		commits = append(commits, batch...)
+		if len(batch) < commitsPerPage {
+			return commits, nil
+		}
+	}
+}
+
+// AuthorEmails returns the author emails of the commits in commits made
+// by the GitHub user login.
+func AuthorEmails(commits []PullRequestCommit, login string) []string {
+	var emails []string
+	seen := make(map[string]bool)
+	for _, c := range commits {
+		email := c.Commit.Author.Email
+		if c.Author == nil || !strings.EqualFold(c.Author.Login, login) || email == "" || seen[email] {
+			continue
+		}
+		seen[email] = true
+		emails = append(emails, email)
+	}
+	return emails
+}
+
+// RequestReviewers asks users and teams (team slugs) to review a pull request.
+func (c *Client) RequestReviewers(repo string, number int, users, teams []string) error {
+	body := map[string][]string{
+		"reviewers":      nonNil(users),
+		"team_reviewers": nonNil(teams),
+	}
+	return c.do(http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", repo, number), body, nil)
+}
+
+// CheckAnnotation is a check run annotation. Level is "notice",
+// "warning", or "failure".
+type CheckAnnotation struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Level     string `json:"annotation_level"`
+	Title     string `json:"title,omitempty"`
+	Message   string `json:"message"`
+}
+
+// CheckRunOutput is the report shown on a check run.
+type CheckRunOutput struct {
+	Title       string            `json:"title"`
+	Summary     string            `json:"summary"`
+	Annotations []CheckAnnotation `json:"annotations,omitempty"`
+}
+
+// maxAnnotations is the most annotations GitHub accepts per request.
+const maxAnnotations = 50
+
+// CreateCheckRun creates a completed check run on headSHA in repo. Since
+// GitHub accepts at most 50 annotations per request, any beyond that are
+// added to the run in further requests.
+func (c *Client) CreateCheckRun(repo, name, headSHA, conclusion string, out CheckRunOutput) error {
+	all := out.Annotations
+	out.Annotations = all[:min(len(all), maxAnnotations)]
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	body := map[string]interface{}{
+		"name":       name,
+		"head_sha":   headSHA,
+		"status":     "completed",
+		"conclusion": conclusion,
+		"output":     out,
+	}
+	if err := c.do(http.MethodPost, fmt.Sprintf("/repos/%s/check-runs", repo), body, &created); err != nil {
+		return err
+	}
+	for i := maxAnnotations; i < len(all); i += maxAnnotations {
+		out.Annotations = all[i:min(len(all), i+maxAnnotations)]
+		path := fmt.Sprintf("/repos/%s/check-runs/%d", repo, created.ID)
+		if err := c.do(http.MethodPatch, path, map[string]interface{}{"output": out}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: decoding response: %w", method, path, err)
+	}
+	return nil
+}
+
+var remoteRe = regexp.MustCompile(`github\.com[:/]([^/]+/[^/]+?)(?:\.git)?/?$`)
+
+// RepoFromRemote extracts "owner/name" from a GitHub remote URL in HTTPS or
+// SSH form. It returns "" for non-GitHub remotes.
+func RepoFromRemote(url string) string {
+	m := remoteRe.FindStringSubmatch(strings.TrimSpace(url))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// SplitReviewers separates CODEOWNERS-style handles into user logins and team
+// slugs: "@user" → user, "@org/team" → team. Other entries (such as email
+// addresses) cannot be requested through the API and are returned as skipped.
+func SplitReviewers(handles []string) (users, teams, skipped []string) {
+	for _, h := range handles {
+		if !strings.HasPrefix(h, "@") {
+			skipped = append(skipped, h)
+			continue
+		}
+		name := strings.TrimPrefix(h, "@")
+		if i := strings.Index(name, "/"); i >= 0 {
+			teams = append(teams, name[i+1:])
+			continue
+		}
+		users = append(users, name)
+	}
+	return users, teams, skipped
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
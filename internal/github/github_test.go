@@ -0,0 +1,139 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRepoFromRemote(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/jbonatakis/differ.git", "jbonatakis/differ"},
+		{"https://github.com/jbonatakis/differ", "jbonatakis/differ"},
+		{"git@github.com:jbonatakis/differ.git", "jbonatakis/differ"},
+		{"ssh://git@github.com/jbonatakis/differ.git\n", "jbonatakis/differ"},
+		{"https://gitlab.com/a/b.git", ""},
+	}
+	for _, tt := range tests {
+		if got := RepoFromRemote(tt.url); got != tt.want {
+			t.Errorf("RepoFromRemote(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestSplitReviewers(t *testing.T) {
+	users, teams, skipped := SplitReviewers([]string{"@alice", "@org/core", "bob@example.com"})
+	if strings.Join(users, ",") != "alice" || strings.Join(teams, ",") != "core" || strings.Join(skipped, ",") != "bob@example.com" {
+		t.Errorf("users=%v teams=%v skipped=%v", users, teams, skipped)
+	}
+}
+
+func TestPullRequestAndRequestReviewers(t *testing.T) {
+	var requested map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			t.Errorf("missing auth header")
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/pulls/7":
+			w.Write([]byte(`{"number":7,"base":{"ref":"main","sha":"aaa"},"head":{"ref":"feat","sha":"bbb"},"user":{"login":"me"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/repos/o/r/pulls/7/commits":
+			if r.URL.Query().Get("page") != "1" {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"sha":"c1","author":{"login":"me"},"commit":{"author":{"email":"me@example.com"}}},
+				{"sha":"c2","author":{"login":"alice"},"commit":{"author":{"email":"alice@example.com"}}},
+				{"sha":"c3","author":null,"commit":{"author":{"email":"unknown@example.com"}}},
+				{"sha":"c4","author":{"login":"Me"},"commit":{"author":{"email":"1+me@users.noreply.github.com"}}},
+				{"sha":"c5","author":{"login":"me"},"commit":{"author":{"email":"me@example.com"}}}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/o/r/pulls/7/requested_reviewers":
+			json.NewDecoder(r.Body).Decode(&requested)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Token: "tok", HTTP: srv.Client()}
+	pr, err := c.PullRequest("o/r", 7)
+	if err != nil {
+		t.Fatalf("PullRequest: %v", err)
+	}
+	if pr.Base.SHA != "aaa" || pr.Head.SHA != "bbb" || pr.User.Login != "me" {
+		t.Errorf("pr = %+v", pr)
+	}
+
+	commits, err := c.PullRequestCommits("o/r", 7)
+	if err != nil {
+		t.Fatalf("PullRequestCommits: %v", err)
+	}
+	if got := strings.Join(AuthorEmails(commits, "me"), ","); got != "me@example.com,1+me@users.noreply.github.com" {
+		t.Errorf("AuthorEmails = %q", got)
+	}
+
+	if err := c.RequestReviewers("o/r", 7, []string{"alice"}, nil); err != nil {
+		t.Fatalf("RequestReviewers: %v", err)
+	}
+	if strings.Join(requested["reviewers"], ",") != "alice" || requested["team_reviewers"] == nil {
+		t.Errorf("requested = %v", requested)
+	}
+
+	if _, err := c.PullRequest("o/r", 8); err == nil {
+		t.Error("expected error for missing pull request")
+	}
+}
+
+func TestCreateCheckRun(t *testing.T) {
+	type request struct {
+		HeadSHA    string         `json:"head_sha"`
+		Status     string         `json:"status"`
+		Conclusion string         `json:"conclusion"`
+		Output     CheckRunOutput `json:"output"`
+	}
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/o/r/check-runs":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":42}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/repos/o/r/check-runs/42":
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		requests = append(requests, req)
+	}))
+	defer srv.Close()
+
+	anns := make([]CheckAnnotation, 120)
+	for i := range anns {
+		anns[i] = CheckAnnotation{Path: "f.go", StartLine: 1, EndLine: 1, Level: "warning", Message: "big"}
+	}
+	c := &Client{BaseURL: srv.URL, HTTP: srv.Client()}
+	err := c.CreateCheckRun("o/r", "differ", "abc", "neutral", CheckRunOutput{Title: "t", Summary: "s", Annotations: anns})
+	if err != nil {
+		t.Fatalf("CreateCheckRun: %v", err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, want 3", len(requests))
+	}
+	if r := requests[0]; r.HeadSHA != "abc" || r.Conclusion != "neutral" || r.Status != "completed" {
+		t.Errorf("create request = %+v", r)
+	}
+	for i, want := range []int{50, 50, 20} {
+		if got := len(requests[i].Output.Annotations); got != want {
+			t.Errorf("request %d carried %d annotations, want %d", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package goapi finds changed lines that touch the exported API of Go
+// packages: exported functions, methods, types, constants, and variables.
+package goapi
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+// Change kinds for a Symbol.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Symbol is an exported declaration touched by a diff.
+type Symbol struct {
+	Path   string
+	Kind   string // "func", "method", "type", "const", or "var"
+	Name   string // "Parse", or "Config.Load" for methods
+	Change string // Added, Removed, or Changed
+	Lines  int    // changed lines within the declaration
+}
+
+// Report is the public API churn found in a diff.
+type Report struct {
+	Lines   int // distinct changed lines within exported declarations
+	Symbols []Symbol
+}
+
+// IsAPIFile reports whether path is Go source that can declare package API,
+// i.e. a .go file that is not a test.
+func IsAPIFile(path string) bool {
+	return strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go")
+}
+
+// Analyze reads a unified diff produced with full context (every unchanged
+// line present), reconstructs the before and after version of each Go file,
+// and reports the changed lines that fall within exported declarations.
+// Only a declaration's signature counts: edits inside function bodies or to
+// unexported struct fields do not change the API. Files that do not parse
+// are skipped.
+func Analyze(r io.Reader) (Report, error) {
+	var report Report
+	err := parser.ReadVersions(r, func(f parser.FileVersions) {
+		if IsAPIFile(f.Path) {
+			analyzeFile(f, &report)
+		}
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	sort.Slice(report.Symbols, func(i, j int) bool {
+		a, b := report.Symbols[i], report.Symbols[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+func analyzeFile(f parser.FileVersions, report *Report) {
+	oldDecls, ok := exportedDecls(f.Old)
+	if !ok {
+		return
+	}
+	newDecls, ok := exportedDecls(f.New)
+	if !ok {
+		return
+	}
+
+	oldLines := make(map[int]bool)
+	newLines := make(map[int]bool)
+	keys := make(map[string]bool)
+	for k := range oldDecls {
+		keys[k] = true
+	}
+	for k := range newDecls {
+		keys[k] = true
+	}
+
+	for k := range keys {
+		before, inOld := oldDecls[k]
+		after, inNew := newDecls[k]
+		n := 0
+		if inOld {
+			n += before.count(f.OldChanged, oldLines)
+		}
+		if inNew {
+			n += after.count(f.NewChanged, newLines)
+		}
+
+		d, change := after, Changed
+		switch {
+		case !inOld:
+			change = Added
+		case !inNew:
+			d, change = before, Removed
+		case n == 0:
+			continue
+		}
+		report.Symbols = append(report.Symbols, Symbol{Path: f.Path, Kind: d.kind, Name: d.name, Change: change, Lines: n})
+	}
+	report.Lines += len(oldLines) + len(newLines)
+}
+
+// decl is an exported declaration and the lines that make up its API.
+type decl struct {
+	kind, name string
+	ranges     [][2]int // inclusive 1-based line ranges
+}
+
+// count returns how many changed lines fall within d, recording them in seen.
+func (d decl) count(changed, seen map[int]bool) int {
+	n := 0
+	for _, r := range d.ranges {
+		for line := r[0]; line <= r[1]; line++ {
+			if changed[line] {
+				n++
+				seen[line] = true
+			}
+		}
+	}
+	return n
+}
+
+// exportedDecls parses lines and returns their exported declarations keyed
+// by kind and name; package main has none. It reports false if the source
+// does not parse.
+func exportedDecls(lines []string) (map[string]decl, bool) {
+	if len(lines) == 0 {
+		return nil, true
+	}
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "", strings.Join(lines, "\n")+"\n", goparser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+	if file.Name.Name == "main" {
+		return nil, true
+	}
+	lineRange := func(from, to token.Pos) [2]int {
+		return [2]int{fset.Position(from).Line, fset.Position(to).Line}
+	}
+
+	decls := make(map[string]decl)
+	add := func(d decl) {
+		decls[d.kind+" "+d.name] = d
+	}
+	for _, node := range file.Decls {
+		switch node := node.(type) {
+		case *ast.FuncDecl:
+			if !node.Name.IsExported() {
+				continue
+			}
+			d := decl{kind: "func", name: node.Name.Name, ranges: [][2]int{lineRange(node.Pos(), node.Type.End())}}
+			if node.Recv != nil && len(node.Recv.List) > 0 {
+				recv := receiverName(node.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				d.kind, d.name = "method", recv+"."+node.Name.Name
+			}
+			add(d)
+		case *ast.GenDecl:
+			for _, spec := range node.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.IsExported() {
+						add(decl{kind: "type", name: spec.Name.Name, ranges: typeRanges(spec, lineRange)})
+					}
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if name.IsExported() {
+							add(decl{kind: node.Tok.String(), name: name.Name, ranges: [][2]int{lineRange(spec.Pos(), spec.End())}})
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls, true
+}
+
+// typeRanges returns the API lines of a type: the whole spec, except that a
+// struct contributes only its header line and its exported or embedded
+// fields.
+func typeRanges(spec *ast.TypeSpec, lineRange func(from, to token.Pos) [2]int) [][2]int {
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok || st.Fields == nil {
+		return [][2]int{lineRange(spec.Pos(), spec.End())}
+	}
+	ranges := [][2]int{lineRange(spec.Pos(), st.Fields.Opening)}
+	for _, field := range st.Fields.List {
+		exported := len(field.Names) == 0
+		for _, name := range field.Names {
+			exported = exported || name.IsExported()
+		}
+		if exported {
+			ranges = append(ranges, lineRange(field.Pos(), field.End()))
+		}
+	}
+	return ranges
+}
+
+// receiverName returns the base type name of a method receiver such as
+// T, *T, or *T[K].
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package goapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const apiDiff = `diff --git a/pkg/store.go b/pkg/store.go
+index 1111111..2222222 100644
+--- a/pkg/store.go
++++ b/pkg/store.go
+@@ -1,25 +1,26 @@
+ package pkg
+ 
+ // Store keeps values.
+ type Store struct {
+-	Name string
++	Name  string
++	Limit int
+-	cache map[string]string
++	cache map[string][]byte
+ }
+ 
+-func Open(path string) (*Store, error) {
++func Open(path string, limit int) (*Store, error) {
+ 	return &Store{Name: path}, nil
+ }
+ 
+ func (s *Store) Get(key string) string {
+-	return s.cache[key]
++	return string(s.cache[key])
+ }
+ 
+ func (s *Store) evict() {}
+ 
+-const DefaultName = "store"
++const DefaultName = "kv"
+ 
+-func Legacy() {}
++func Modern() {}
+ 
+ type helper struct{}
+ 
+ func (helper) Exported() {}
+diff --git a/pkg/store_test.go b/pkg/store_test.go
+--- a/pkg/store_test.go
++++ b/pkg/store_test.go
+@@ -1,1 +1,2 @@
+ package pkg
++func TestX() {}
+diff --git a/cmd/tool/main.go b/cmd/tool/main.go
+--- a/cmd/tool/main.go
++++ b/cmd/tool/main.go
+@@ -1,1 +1,2 @@
+ package main
++func Run() {}
+diff --git a/pkg/new.go b/pkg/new.go
+new file mode 100644
+--- /dev/null
++++ b/pkg/new.go
+@@ -0,0 +1,3 @@
++package pkg
++
++var Version = "1"
+`
+
+func TestAnalyze(t *testing.T) {
+	report, err := Analyze(strings.NewReader(apiDiff))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Symbol{
+		{Path: "pkg/new.go", Kind: "var", Name: "Version", Change: Added, Lines: 1},
+		{Path: "pkg/store.go", Kind: "const", Name: "DefaultName", Change: Changed, Lines: 2},
+		{Path: "pkg/store.go", Kind: "func", Name: "Legacy", Change: Removed, Lines: 1},
+		{Path: "pkg/store.go", Kind: "func", Name: "Modern", Change: Added, Lines: 1},
+		{Path: "pkg/store.go", Kind: "func", Name: "Open", Change: Changed, Lines: 2},
+		{Path: "pkg/store.go", Kind: "type", Name: "Store", Change: Changed, Lines: 3},
+	}
+	if !reflect.DeepEqual(report.Symbols, want) {
+		t.Errorf("Symbols =\n%+v\nwant\n%+v", report.Symbols, want)
+	}
+	// The Get body edit, the unexported cache field, and the unexported
+	// helper's method do not count.
+	if report.Lines != 10 {
+		t.Errorf("Lines = %d, want 10", report.Lines)
+	}
+}
+
+func TestAnalyzeUnparsable(t *testing.T) {
+	diff := "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -1 +1 @@\n-package x; func A(\n+package x; func A() {}\n"
+	report, err := Analyze(strings.NewReader(diff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Symbols) != 0 || report.Lines != 0 {
+		t.Errorf("expected unparsable file to be skipped, got %+v", report)
+	}
+}
@@ -0,0 +1,388 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+// Commit holds metadata and per-file churn for a single commit.
+type Commit struct {
+	SHA         string
+	Parents     []string
+	AuthorName  string
+	AuthorEmail string
+	Date        time.Time
+	Subject     string
+	Files       []parser.FileStat
+}
+
+// Added returns the total added lines across all files in the commit.
+func (c Commit) Added() int {
+	n := 0
+	for _, f := range c.Files {
+		n += f.Added
+	}
+	return n
+}
+
+// Deleted returns the total deleted lines across all files in the commit.
+func (c Commit) Deleted() int {
+	n := 0
+	for _, f := range c.Files {
+		n += f.Deleted
+	}
+	return n
+}
+
+// Options controls which commits Log walks and how their diffs are counted.
+type Options struct {
+	// Empty is the empty-line mode passed to parser.Parse ("include"|"exclude").
+	Empty string
+	// NoMerges skips merge commits.
+	NoMerges bool
+	// FirstParent follows only the first parent of merge commits, as
+	// mainline history reads, and diffs each merge against its first
+	// parent: the changes it brought in. Commits made on merged branches
+	// are not walked, so their changes are counted once, in the merge.
+	FirstParent bool
+	// FirstParentMerges walks only the merge commits on the first-parent
+	// chain, each diffed against its first parent. It implies FirstParent
+	// and takes precedence over NoMerges.
+	FirstParentMerges bool
+	// IgnoreRevs holds the full SHAs of commits to leave out, such as bulk
+	// reformats; see ReadIgnoreRevs.
+	IgnoreRevs map[string]bool
+	// Since limits the walk to commits more recent than a date in any form
+	// git log --since accepts; see SinceDate.
+	Since string
+}
+
+// commitMarker prefixes each commit header in the git log output. It starts
+// with a NUL byte so it cannot collide with diff content.
+const commitMarker = "\x00commit "
+
+// logFormat emits SHA, parents, author name, email, date, and subject
+// separated by unit separators. Author names and emails have .mailmap
+// applied, so one person's identities are reported as one.
+const logFormat = "%x00commit %H%x1f%P%x1f%aN%x1f%aE%x1f%aI%x1f%s"
+
+// Log runs `git log -p` over logRange and returns each commit with its
+// per-file churn, newest first. logRange uses git log semantics
+// (e.g. "main..HEAD").
+func Log(runner gitdiff.CommandRunner, logRange string, pathspecs []string, opts Options) ([]Commit, error) {
+	args := []string{"log", "-p", "--no-color", "-U0", "-M", "--format=" + logFormat}
+	if opts.FirstParent || opts.FirstParentMerges {
+		args = append(args, "--first-parent", "-m")
+	}
+	switch {
+	case opts.FirstParentMerges:
+		args = append(args, "--merges")
+	case opts.NoMerges:
+		args = append(args, "--no-merges")
+	}
+	if opts.Since != "" {
+		args = append(args, "--since="+opts.Since)
+	}
+	if logRange != "" {
+		args = append(args, logRange)
+	}
+	if len(pathspecs) > 0 {
+		args = append(args, "--")
+		args = append(args, pathspecs...)
+	}
+
+	out, err := runner.Run("git", args...)
+	if err != nil {
+		return nil, fmt.Errorf("running git log: %w", err)
+	}
+	commits, err := parseLog(out, opts.Empty)
+	if err != nil || len(opts.IgnoreRevs) == 0 {
+		return commits, err
+	}
+	kept := commits[:0]
+	for _, c := range commits {
+		if !opts.IgnoreRevs[c.SHA] {
+			kept = append(kept, c)
+		}
+	}
+	return kept, nil
+}
+
+// TouchCounts returns how many non-merge commits in logRange changed each
+// file, keyed by the file's path at the newest of them. A single git log
+// lists every commit's files; renames are followed back through the range,
+// as git log --follow would for each file, so a file renamed midway counts
+// the commits made under its old name too.
+func TouchCounts(runner gitdiff.CommandRunner, logRange string, pathspecs []string) (map[string]int, error) {
+	args := []string{"log", "--no-merges", "--format=", "--name-status", "-M", "-z"}
+	if logRange != "" {
+		args = append(args, logRange)
+	}
+	if len(pathspecs) > 0 {
+		args = append(args, "--")
+		args = append(args, pathspecs...)
+	}
+	out, err := runner.Run("git", args...)
+	if err != nil {
+		return nil, fmt.Errorf("running git log: %w", err)
+	}
+
+	counts := make(map[string]int)
+	renames := make(renameTracker)
+	err = parseNameStatus(out, nil, func(status byte, oldPath, path string) {
+		path = renames.current(path)
+		counts[path]++
+		if status == 'R' {
+			renames.add(oldPath, path)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// FileAge is when a file was created and last changed.
+type FileAge struct {
+	Created      time.Time // author date of the commit that added the file
+	LastCommit   string    // full SHA of the latest commit to change it
+	LastModified time.Time // author date of LastCommit
+}
+
+// FileAges returns the age of every file that exists at rev, keyed by its
+// path there, from a single git log of rev's history. Renames are followed,
+// so a file's creation is that of the path it was first added under. Merge
+// commits are left out, as their changes are made in the commits merged.
+func FileAges(runner gitdiff.CommandRunner, rev string) (map[string]FileAge, error) {
+	out, err := runner.Run("git", "log", "--no-merges", "--format=%x00%H%x1f%aI", "--name-status", "-M", "-z", rev, "--")
+	if err != nil {
+		return nil, fmt.Errorf("running git log: %w", err)
+	}
+
+	ages := make(map[string]FileAge)
+	// done holds paths whose history is complete: they were added, or
+	// deleted, which leaves older changes to a file of the same name out.
+	done := make(map[string]bool)
+	renames := make(renameTracker)
+	var sha string
+	var date time.Time
+	var dateErr error
+	commit := func(commitSHA, commitDate string) {
+		sha = commitSHA
+		date, dateErr = time.Parse(time.RFC3339, commitDate)
+	}
+	err = parseNameStatus(out, commit, func(status byte, oldPath, path string) {
+		path = renames.current(path)
+		if done[path] || dateErr != nil {
+			return
+		}
+		if status == 'D' {
+			// The path does not exist at rev unless added again later,
+			// in which case it is already done.
+			done[path] = true
+			return
+		}
+		age := ages[path]
+		if age.LastCommit == "" {
+			age.LastCommit, age.LastModified = sha, date
+		}
+		age.Created = date
+		ages[path] = age
+		switch status {
+		case 'A', 'C':
+			done[path] = true
+		case 'R':
+			renames.add(oldPath, path)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dateErr != nil {
+		return nil, fmt.Errorf("parsing commit date: %w", dateErr)
+	}
+	return ages, nil
+}
+
+// renameTracker follows files back through renames while git log output is
+// read newest first.
+type renameTracker map[string]string
+
+// current returns the path that path, as older commits name it, has in the
+// newest commit read.
+func (r renameTracker) current(path string) string {
+	if to, ok := r[path]; ok {
+		return to
+	}
+	return path
+}
+
+// add records that the file now at path was called oldPath before.
+func (r renameTracker) add(oldPath, path string) { r[oldPath] = path }
+
+// parseNameStatus reads git log --name-status -z output, calling commit
+// with each commit's SHA and date when the log format writes them as
+// "%x00%H%x1f%aI", and file with each changed file. Renames and copies
+// pass their old path too; other changes pass an empty one.
+func parseNameStatus(out []byte, commit func(sha, date string), file func(status byte, oldPath, path string)) error {
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	for i := 0; i < len(fields); i++ {
+		field := strings.TrimSpace(fields[i])
+		if field == "" {
+			continue
+		}
+		if sha, date, ok := strings.Cut(field, "\x1f"); ok {
+			if commit != nil {
+				commit(sha, date)
+			}
+			continue
+		}
+		// A status is followed by the path, or by the old and new path
+		// for a rename or copy, each in a field of its own.
+		status, paths := field[0], 1
+		if status == 'R' || status == 'C' {
+			paths = 2
+		}
+		if i+paths >= len(fields) {
+			return fmt.Errorf("unexpected end of git log output after %q", field)
+		}
+		if paths == 2 {
+			file(status, fields[i+1], fields[i+2])
+		} else {
+			file(status, "", fields[i+1])
+		}
+		i += paths
+	}
+	return nil
+}
+
+// fullSHARe matches an unabbreviated SHA-1 or SHA-256 object name.
+var fullSHARe = regexp.MustCompile(`^(?:[0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// ReadIgnoreRevs reads a file of commits to ignore in the format of git
+// blame's --ignore-revs-file: one unabbreviated object name per line, with
+// blank lines and anything after a # ignored.
+func ReadIgnoreRevs(path string) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	revs := make(map[string]bool)
+	for n, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" {
+			continue
+		}
+		if !fullSHARe.MatchString(line) {
+			return nil, fmt.Errorf("%s:%d: invalid object name %q (want a full commit SHA)", path, n+1, line)
+		}
+		revs[line] = true
+	}
+	return revs, nil
+}
+
+// parseLog splits git log output into commits and parses each patch.
+func parseLog(out []byte, emptyMode string) ([]Commit, error) {
+	var commits []Commit
+	var current *Commit
+	var patch bytes.Buffer
+
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		files, err := parser.Parse(&patch, emptyMode)
+		if err != nil {
+			return fmt.Errorf("parsing commit %s: %w", current.SHA, err)
+		}
+		current.Files = files
+		commits = append(commits, *current)
+		current = nil
+		patch.Reset()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, commitMarker) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			c, err := parseHeader(strings.TrimPrefix(line, commitMarker))
+			if err != nil {
+				return nil, err
+			}
+			current = &c
+			continue
+		}
+		if current != nil {
+			patch.WriteString(line)
+			patch.WriteByte('\n')
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// parseHeader parses the fields emitted by logFormat after the marker.
+func parseHeader(header string) (Commit, error) {
+	fields := strings.SplitN(header, "\x1f", 6)
+	if len(fields) != 6 {
+		return Commit{}, fmt.Errorf("malformed commit header %q", header)
+	}
+	date, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return Commit{}, fmt.Errorf("parsing commit date %q: %w", fields[4], err)
+	}
+	return Commit{
+		SHA:         fields[0],
+		Parents:     strings.Fields(fields[1]),
+		AuthorName:  fields[2],
+		AuthorEmail: fields[3],
+		Date:        date,
+		Subject:     fields[5],
+	}, nil
+}
+
+var shortAgeRe = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+var ageUnits = map[string]string{"d": "days", "w": "weeks", "m": "months", "y": "years"}
+
+// SinceDate expands a short age such as "90d", "6w", "3m", or "1y" into the
+// "90 days ago" form git understands. Anything else, such as "2024-01-31",
+// is returned unchanged for git to interpret.
+func SinceDate(s string) string {
+	m := shortAgeRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return s
+	}
+	return m[1] + " " + ageUnits[m[2]] + " ago"
+}
+
+// LogRange converts a diff-style rev-range into the equivalent git log range:
+// "a...b" and "a..b" become "a..b", and a single ref "a" becomes "a..HEAD".
+func LogRange(revRange string) string {
+	if parts := strings.SplitN(revRange, "...", 2); len(parts) == 2 {
+		return parts[0] + ".." + parts[1]
+	}
+	if strings.Contains(revRange, "..") || revRange == "" {
+		return revRange
+	}
+	return revRange + "..HEAD"
+}
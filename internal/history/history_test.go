@@ -0,0 +1,238 @@
+package history
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLog(t *testing.T) {
+	out := "\x00commit aaa\x1fbbb\x1fAlice\x1falice@example.com\x1f2024-01-15T10:30:00Z\x1ffeat: add thing\n" +
+		"\n" +
+		"diff --git a/main.go b/main.go\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -1 +1,2 @@\n" +
+		"-old\n" +
+		"+new\n" +
+		"+more\n" +
+		"\x00commit bbb\x1f\x1fBob\x1fbob@example.com\x1f2024-01-14T09:00:00+02:00\x1f-leading dash subject\n" +
+		"\n" +
+		"diff --git a/README.md b/README.md\n" +
+		"--- /dev/null\n" +
+		"+++ b/README.md\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+# Title\n"
+
+	commits, err := parseLog([]byte(out), "exclude")
+	if err != nil {
+		t.Fatalf("parseLog: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(commits))
+	}
+
+	c := commits[0]
+	if c.SHA != "aaa" || c.AuthorName != "Alice" || c.AuthorEmail != "alice@example.com" || c.Subject != "feat: add thing" {
+		t.Errorf("commit 0 = %+v", c)
+	}
+	if len(c.Parents) != 1 || c.Parents[0] != "bbb" {
+		t.Errorf("parents = %v, want [bbb]", c.Parents)
+	}
+	if c.Added() != 2 || c.Deleted() != 1 {
+		t.Errorf("added/deleted = %d/%d, want 2/1", c.Added(), c.Deleted())
+	}
+
+	c = commits[1]
+	if c.Subject != "-leading dash subject" {
+		t.Errorf("subject = %q", c.Subject)
+	}
+	if len(c.Parents) != 0 {
+		t.Errorf("root commit parents = %v, want none", c.Parents)
+	}
+	if len(c.Files) != 1 || c.Files[0].Path != "README.md" || c.Files[0].Added != 1 {
+		t.Errorf("files = %+v", c.Files)
+	}
+}
+
+func TestParseLogMalformedHeader(t *testing.T) {
+	if _, err := parseLog([]byte("\x00commit onlysha\n"), "exclude"); err == nil {
+		t.Fatal("expected error for malformed header")
+	}
+}
+
+func TestLogRange(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"main...HEAD", "main..HEAD"},
+		{"v1.0..v1.1", "v1.0..v1.1"},
+		{"v1.0", "v1.0..HEAD"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := LogRange(tt.input); got != tt.want {
+			t.Errorf("LogRange(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSinceDate(t *testing.T) {
+	tests := map[string]string{
+		"90d":        "90 days ago",
+		"6w":         "6 weeks ago",
+		"3m":         "3 months ago",
+		"1y":         "1 years ago",
+		"2024-01-31": "2024-01-31",
+		"yesterday":  "yesterday",
+	}
+	for input, want := range tests {
+		if got := SinceDate(input); got != want {
+			t.Errorf("SinceDate(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+// argsRunner records the arguments of the git commands it is asked to run
+// and returns out.
+type argsRunner struct {
+	args []string
+	out  string
+}
+
+func (r *argsRunner) Run(name string, args ...string) ([]byte, error) {
+	r.args = args
+	return []byte(r.out), nil
+}
+
+func (r *argsRunner) Start(name string, args ...string) (io.ReadCloser, *exec.Cmd, error) {
+	return nil, nil, errors.New("not implemented")
+}
+
+func TestLogFirstParentMerges(t *testing.T) {
+	runner := &argsRunner{}
+	if _, err := Log(runner, "v1..v2", nil, Options{FirstParentMerges: true, NoMerges: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(runner.args, " ")
+	if !strings.Contains(got, "--first-parent -m --merges") || strings.Contains(got, "--no-merges") {
+		t.Errorf("git args = %q, want first-parent merges diffed against their first parent", got)
+	}
+}
+
+func TestLogFirstParent(t *testing.T) {
+	runner := &argsRunner{}
+	if _, err := Log(runner, "v1..v2", nil, Options{FirstParent: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(runner.args, " ")
+	if !strings.Contains(got, "--first-parent -m") || strings.Contains(got, "--merges") {
+		t.Errorf("git args = %q, want the first-parent chain with merges diffed against their first parent", got)
+	}
+}
+
+func TestLogIgnoreRevs(t *testing.T) {
+	reformat := strings.Repeat("a", 40)
+	feature := strings.Repeat("b", 40)
+	runner := &argsRunner{out: "\x00commit " + reformat + "\x1f\x1fAlice\x1falice@example.com\x1f2024-01-15T10:30:00Z\x1fstyle: gofmt\n" +
+		"\x00commit " + feature + "\x1f\x1fBob\x1fbob@example.com\x1f2024-01-14T10:30:00Z\x1ffeat: thing\n"}
+	commits, err := Log(runner, "", nil, Options{IgnoreRevs: map[string]bool{reformat: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || commits[0].SHA != feature {
+		t.Errorf("got %d commits, want only %s", len(commits), feature)
+	}
+}
+
+func TestTouchCounts(t *testing.T) {
+	// Newest first: b c.txt edited, a.txt renamed to z.txt, a.txt edited,
+	// both added.
+	runner := &argsRunner{out: "M\x00b c.txt\x00R100\x00a.txt\x00z.txt\x00M\x00a.txt\x00A\x00a.txt\x00A\x00b c.txt\x00"}
+	counts, err := TouchCounts(runner, "v1..v2", []string{"src/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"z.txt": 3, "b c.txt": 2}
+	if len(counts) != len(want) {
+		t.Errorf("got %v, want %v", counts, want)
+	}
+	for path, n := range want {
+		if counts[path] != n {
+			t.Errorf("%s: got %d commits, want %d", path, counts[path], n)
+		}
+	}
+	if got := strings.Join(runner.args, " "); !strings.Contains(got, "--no-merges") || !strings.HasSuffix(got, "v1..v2 -- src/") {
+		t.Errorf("git args = %q", got)
+	}
+
+	runner.out = "M\x00"
+	if _, err := TouchCounts(runner, "", nil); err == nil {
+		t.Error("expected an error for truncated output")
+	}
+}
+
+func TestFileAges(t *testing.T) {
+	sha := func(c string) string { return strings.Repeat(c, 40) }
+	header := func(c, date string) string { return "\x00" + sha(c) + "\x1f" + date + "\x00\n" }
+	// Newest first: b c.txt edited, a.txt renamed to z.txt, a.txt edited
+	// and gone.txt deleted, all three added.
+	runner := &argsRunner{out: header("d", "2024-04-01T00:00:00Z") + "M\x00b c.txt\x00" +
+		header("c", "2024-03-01T00:00:00Z") + "R100\x00a.txt\x00z.txt\x00" +
+		header("b", "2024-02-01T00:00:00Z") + "M\x00a.txt\x00D\x00gone.txt\x00" +
+		header("a", "2024-01-01T00:00:00+02:00") + "A\x00a.txt\x00A\x00b c.txt\x00A\x00gone.txt\x00"}
+	ages, err := FileAges(runner, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ages) != 2 {
+		t.Errorf("expected ages for z.txt and b c.txt only, got %v", ages)
+	}
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.FixedZone("", 2*60*60))
+	for path, want := range map[string]FileAge{
+		"z.txt":   {Created: created, LastCommit: sha("c"), LastModified: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		"b c.txt": {Created: created, LastCommit: sha("d"), LastModified: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		got := ages[path]
+		if !got.Created.Equal(want.Created) || got.LastCommit != want.LastCommit || !got.LastModified.Equal(want.LastModified) {
+			t.Errorf("%s: got %+v, want %+v", path, got, want)
+		}
+	}
+	if got := strings.Join(runner.args, " "); !strings.HasSuffix(got, "-z v1 --") {
+		t.Errorf("git args = %q", got)
+	}
+}
+
+func TestReadIgnoreRevs(t *testing.T) {
+	dir := t.TempDir()
+	sha := strings.Repeat("0123456789", 4)
+	path := filepath.Join(dir, ".git-blame-ignore-revs")
+	content := "# Reformat with gofmt\n" + strings.ToUpper(sha) + "  # trailing comment\n\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	revs, err := ReadIgnoreRevs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 1 || !revs[sha] {
+		t.Errorf("ReadIgnoreRevs = %v, want {%s}", revs, sha)
+	}
+
+	if err := os.WriteFile(path, []byte(sha+"\nabc1234\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadIgnoreRevs(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("abbreviated SHA: got error %v, want one naming line 2", err)
+	}
+
+	if _, err := ReadIgnoreRevs(filepath.Join(dir, "missing")); err == nil {
+		t.Error("missing file: want error")
+	}
+}
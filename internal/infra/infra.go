@@ -0,0 +1,240 @@
+// Package infra summarizes infrastructure-as-code changes by resource:
+// Terraform resources, data sources, and modules, and Kubernetes objects.
+// A resource counts as touched when a changed line falls inside its block or
+// manifest document.
+package infra
+
+import (
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+// KindCount is the number of resources of one kind that a change adds,
+// changes, or removes. Kinds are Terraform resource types (data sources are
+// prefixed "data."; modules are "module") or Kubernetes kinds.
+type KindCount struct {
+	Kind    string
+	Added   int
+	Changed int
+	Removed int
+}
+
+// IsCandidate reports whether p may hold infrastructure definitions: a
+// Terraform file, or YAML that may be a Kubernetes manifest.
+func IsCandidate(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".tf", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// block is one resource and the lines (1-based, inclusive) it spans.
+type block struct {
+	kind, key  string
+	start, end int
+}
+
+// Analyze reads a unified diff produced with full context and returns the
+// touched resources per kind, sorted by kind.
+func Analyze(r io.Reader) ([]KindCount, error) {
+	counts := make(map[string]*KindCount)
+	err := parser.ReadVersions(r, func(f parser.FileVersions) {
+		var extract func([]string) []block
+		switch strings.ToLower(path.Ext(f.Path)) {
+		case ".tf":
+			extract = terraformBlocks
+		case ".yaml", ".yml":
+			extract = manifestBlocks
+		default:
+			return
+		}
+		compare(extract(f.Old), f.OldChanged, extract(f.New), f.NewChanged, counts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]KindCount, 0, len(counts))
+	for _, c := range counts {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Kind < result[j].Kind })
+	return result, nil
+}
+
+func compare(old []block, oldChanged map[int]bool, cur []block, newChanged map[int]bool, counts map[string]*KindCount) {
+	count := func(kind string) *KindCount {
+		if counts[kind] == nil {
+			counts[kind] = &KindCount{Kind: kind}
+		}
+		return counts[kind]
+	}
+	touched := func(b block, changed map[int]bool) bool {
+		for line := b.start; line <= b.end; line++ {
+			if changed[line] {
+				return true
+			}
+		}
+		return false
+	}
+
+	before := make(map[string]block, len(old))
+	for _, b := range old {
+		before[b.key] = b
+	}
+	seen := make(map[string]bool, len(cur))
+	for _, b := range cur {
+		seen[b.key] = true
+		prev, existed := before[b.key]
+		switch {
+		case !existed:
+			count(b.kind).Added++
+		case touched(prev, oldChanged) || touched(b, newChanged):
+			count(b.kind).Changed++
+		}
+	}
+	for _, b := range old {
+		if !seen[b.key] {
+			count(b.kind).Removed++
+		}
+	}
+}
+
+var tfHeader = regexp.MustCompile(`^(resource|data)\s+"([^"]+)"\s+"([^"]+)"|^module\s+"([^"]+)"`)
+
+// terraformBlocks returns the top-level resource, data, and module blocks,
+// finding each block's end by counting braces outside string literals.
+func terraformBlocks(lines []string) []block {
+	var blocks []block
+	for i := 0; i < len(lines); i++ {
+		m := tfHeader.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		b := block{start: i + 1}
+		switch {
+		case m[1] == "resource":
+			b.kind, b.key = m[2], m[2]+"."+m[3]
+		case m[1] == "data":
+			b.kind, b.key = "data."+m[2], "data."+m[2]+"."+m[3]
+		default:
+			b.kind, b.key = "module", "module."+m[4]
+		}
+
+		depth := 0
+		opened := false
+		for j := i; j < len(lines); j++ {
+			d := braceDelta(lines[j])
+			depth += d
+			opened = opened || d > 0 || strings.Contains(lines[j], "{")
+			b.end = j + 1
+			if opened && depth <= 0 {
+				break
+			}
+		}
+		blocks = append(blocks, b)
+		i = b.end - 1
+	}
+	return blocks
+}
+
+// braceDelta returns the opening minus closing braces on an HCL line,
+// ignoring those inside strings and after comments.
+func braceDelta(line string) int {
+	delta := 0
+	inString := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '#' || (c == '/' && i+1 < len(line) && line[i+1] == '/'):
+			return delta
+		case c == '{':
+			delta++
+		case c == '}':
+			delta--
+		}
+	}
+	return delta
+}
+
+var (
+	yamlKind = regexp.MustCompile(`^kind:\s*["']?([A-Za-z0-9]+)`)
+	yamlName = regexp.MustCompile(`^\s+name:\s*["']?([^"'\s#]+)`)
+	yamlNS   = regexp.MustCompile(`^\s+namespace:\s*["']?([^"'\s#]+)`)
+)
+
+// manifestBlocks splits YAML into documents and returns those that are
+// Kubernetes objects (top-level apiVersion and kind), keyed by kind,
+// namespace, and metadata.name.
+func manifestBlocks(lines []string) []block {
+	var blocks []block
+	start := 0
+	for i := 0; i <= len(lines); i++ {
+		if i < len(lines) && !strings.HasPrefix(lines[i], "---") {
+			continue
+		}
+		if b, ok := manifest(lines[start:i], start); ok {
+			blocks = append(blocks, b)
+		}
+		start = i + 1
+	}
+	return blocks
+}
+
+func manifest(doc []string, offset int) (block, bool) {
+	var kind, name, namespace string
+	hasAPIVersion := false
+	inMetadata := false
+	childIndent := -1 // indentation of metadata's direct children
+	for _, line := range doc {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent == 0 {
+			inMetadata = strings.HasPrefix(line, "metadata:")
+			if strings.HasPrefix(line, "apiVersion:") {
+				hasAPIVersion = true
+			}
+			if m := yamlKind.FindStringSubmatch(line); m != nil {
+				kind = m[1]
+			}
+			continue
+		}
+		if !inMetadata {
+			continue
+		}
+		if childIndent < 0 {
+			childIndent = indent
+		}
+		if indent != childIndent {
+			continue // labels, annotations, and other nested keys
+		}
+		if m := yamlName.FindStringSubmatch(line); m != nil {
+			name = m[1]
+		}
+		if m := yamlNS.FindStringSubmatch(line); m != nil {
+			namespace = m[1]
+		}
+	}
+	if !hasAPIVersion || kind == "" {
+		return block{}, false
+	}
+	return block{
+		kind:  kind,
+		key:   kind + "/" + namespace + "/" + name,
+		start: offset + 1,
+		end:   offset + len(doc),
+	}, true
+}
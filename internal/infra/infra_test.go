@@ -0,0 +1,116 @@
+package infra
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTerraformBlocks(t *testing.T) {
+	src := strings.Split(`provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs-${var.env}" # {not a brace}
+  tags = {
+    team = "infra"
+  }
+}
+
+data "aws_iam_policy_document" "read" {
+  statement {}
+}
+
+module "vpc" {
+  source = "./vpc"
+}`, "\n")
+	got := terraformBlocks(src)
+	want := []block{
+		{kind: "aws_s3_bucket", key: "aws_s3_bucket.logs", start: 5, end: 10},
+		{kind: "data.aws_iam_policy_document", key: "data.aws_iam_policy_document.read", start: 12, end: 14},
+		{kind: "module", key: "module.vpc", start: 16, end: 18},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("terraformBlocks =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestManifestBlocks(t *testing.T) {
+	src := strings.Split(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    name: not-this
+  name: web
+  namespace: prod
+spec:
+  replicas: 2
+---
+# just config
+replicas: 3
+---
+apiVersion: v1
+kind: Service
+metadata:
+    name: web`, "\n")
+	got := manifestBlocks(src)
+	want := []block{
+		{kind: "Deployment", key: "Deployment/prod/web", start: 1, end: 9},
+		{kind: "Service", key: "Service//web", start: 14, end: 17},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("manifestBlocks =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := []block{
+		{kind: "aws_s3_bucket", key: "aws_s3_bucket.a", start: 1, end: 3},
+		{kind: "aws_s3_bucket", key: "aws_s3_bucket.b", start: 5, end: 7},
+		{kind: "aws_instance", key: "aws_instance.old", start: 9, end: 11},
+	}
+	cur := []block{
+		{kind: "aws_s3_bucket", key: "aws_s3_bucket.a", start: 1, end: 3},
+		{kind: "aws_s3_bucket", key: "aws_s3_bucket.b", start: 5, end: 8},
+		{kind: "aws_instance", key: "aws_instance.new", start: 10, end: 12},
+	}
+	counts := make(map[string]*KindCount)
+	compare(old, map[int]bool{9: true, 10: true, 11: true}, cur, map[int]bool{6: true, 10: true, 11: true, 12: true}, counts)
+
+	if got := *counts["aws_s3_bucket"]; got != (KindCount{Kind: "aws_s3_bucket", Changed: 1}) {
+		t.Errorf("aws_s3_bucket = %+v", got)
+	}
+	if got := *counts["aws_instance"]; got != (KindCount{Kind: "aws_instance", Added: 1, Removed: 1}) {
+		t.Errorf("aws_instance = %+v", got)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	diff := `diff --git a/main.tf b/main.tf
+--- a/main.tf
++++ b/main.tf
+@@ -1,3 +1,6 @@
+ resource "aws_s3_bucket" "logs" {
+-  bucket = "logs"
++  bucket = "logs-v2"
+ }
++resource "aws_s3_bucket" "assets" {
++  bucket = "assets"
++}
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-resource "x" "y" {}
++hi
+`
+	got, err := Analyze(strings.NewReader(diff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []KindCount{{Kind: "aws_s3_bucket", Added: 1, Changed: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Analyze = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+// Package ledger implements differ's history store: an append-only JSON Lines
+// file in which every recorded run occupies one line, or, for paths starting
+// with SQLiteScheme, a SQLite database with a row per run.
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/snapshot"
+)
+
+// maxLineSize bounds a single ledger line; runs over huge ranges carry many
+// per-file rows.
+const maxLineSize = 64 << 20
+
+// Record is one recorded differ run.
+type Record struct {
+	Repo       string    `json:"repo"`
+	RecordedAt time.Time `json:"recorded_at"`
+	HeadSHA    string    `json:"head_sha,omitempty"`
+	// Release is the tag whose release-level churn the snapshot holds,
+	// such as "v1.3.0"; empty for ordinary runs.
+	Release  string            `json:"release,omitempty"`
+	Snapshot snapshot.Snapshot `json:"snapshot"`
+}
+
+// Append adds rec to the ledger at path, creating the file if needed.
+func Append(path string, rec Record) error {
+	if db, ok := SQLitePath(path); ok {
+		return appendSQLite(db, rec)
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read loads every record from the ledger at path in the order recorded.
+// Blank lines are ignored; malformed lines and snapshots written by a newer
+// format version are errors.
+func Read(path string) ([]Record, error) {
+	if db, ok := SQLitePath(path); ok {
+		return readSQLite(db)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		rec, err := decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// decode parses one JSON-encoded record.
+func decode(data []byte) (Record, error) {
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Record{}, fmt.Errorf("malformed record: %w", err)
+	}
+	if v := rec.Snapshot.Version; v < 1 || v > snapshot.FormatVersion {
+		return Record{}, fmt.Errorf("unsupported snapshot version %d (supported: 1-%d)", v, snapshot.FormatVersion)
+	}
+	return rec, nil
+}
+
+// Runs returns the records of ordinary runs, leaving out release records,
+// whose churn spans many runs.
+func Runs(records []Record) []Record {
+	var runs []Record
+	for _, rec := range records {
+		if rec.Release == "" {
+			runs = append(runs, rec)
+		}
+	}
+	return runs
+}
+
+// FindRelease returns the latest record of release for repo.
+func FindRelease(records []Record, repo, release string) (Record, bool) {
+	for i := len(records) - 1; i >= 0; i-- {
+		if rec := records[i]; rec.Repo == repo && rec.Release == release {
+			return rec, true
+		}
+	}
+	return Record{}, false
+}
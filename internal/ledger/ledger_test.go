@@ -0,0 +1,109 @@
+package ledger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+)
+
+func testRecord(repo string, churn int) Record {
+	return Record{
+		Repo:       repo,
+		RecordedAt: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		HeadSHA:    "abc123",
+		Snapshot: snapshot.FromSummary(output.Summary{
+			Totals: output.CategoryTotal{Added: churn, Churn: churn, FileCount: 1},
+			CategoryTotals: map[string]output.CategoryTotal{
+				"source": {Added: churn, Churn: churn, FileCount: 1},
+			},
+			FileStats: []output.FileStat{
+				{Path: "main.go", Added: churn, Churn: churn, Category: "source", Language: "Go"},
+			},
+		}),
+	}
+}
+
+func TestAppendRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "churn.db")
+
+	if err := Append(path, testRecord("api", 10)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := Append(path, testRecord("web", 20)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	records, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0].Repo != "api" || records[1].Repo != "web" {
+		t.Errorf("records out of order: %q, %q", records[0].Repo, records[1].Repo)
+	}
+	if records[1].Snapshot.Total.Churn != 20 || records[1].Snapshot.Files[0].Path != "main.go" {
+		t.Errorf("snapshot not round-tripped: %+v", records[1].Snapshot)
+	}
+	if !records[0].RecordedAt.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("RecordedAt = %v", records[0].RecordedAt)
+	}
+}
+
+func TestReadMalformedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "churn.db")
+	if err := Append(path, testRecord("api", 10)); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("\n{not json\n")
+	f.Close()
+
+	_, err = Read(path)
+	if err == nil || !strings.Contains(err.Error(), ":3:") {
+		t.Errorf("expected error naming line 3, got %v", err)
+	}
+}
+
+func TestReadRejectsNewerSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "churn.db")
+	rec := testRecord("api", 10)
+	rec.Snapshot.Version = snapshot.FormatVersion + 1
+	if err := Append(path, rec); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("expected error for unsupported snapshot version")
+	}
+}
+
+func TestFindRelease(t *testing.T) {
+	records := []Record{
+		{Repo: "api", Release: "v1.0.0", HeadSHA: "old"},
+		{Repo: "web", Release: "v1.0.0", HeadSHA: "web"},
+		{Repo: "api", HeadSHA: "run"},
+		{Repo: "api", Release: "v1.0.0", HeadSHA: "new"},
+	}
+	if rec, ok := FindRelease(records, "api", "v1.0.0"); !ok || rec.HeadSHA != "new" {
+		t.Errorf("got %+v, %v; want the latest api v1.0.0 record", rec, ok)
+	}
+	if _, ok := FindRelease(records, "api", "v2.0.0"); ok {
+		t.Error("expected no record for an unrecorded release")
+	}
+}
+
+func TestRuns(t *testing.T) {
+	runs := Runs([]Record{{HeadSHA: "a"}, {HeadSHA: "b", Release: "v1"}, {HeadSHA: "c"}})
+	if len(runs) != 2 || runs[0].HeadSHA != "a" || runs[1].HeadSHA != "c" {
+		t.Errorf("Runs = %+v, want the records without a release", runs)
+	}
+}
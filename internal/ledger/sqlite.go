@@ -0,0 +1,161 @@
+package ledger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// SQLiteScheme prefixes ledger paths that name a SQLite database instead
+// of a JSON Lines file, as in "sqlite://churn.sqlite".
+const SQLiteScheme = "sqlite://"
+
+// SQLitePath returns the database file a ledger path names, and whether it
+// names one.
+func SQLitePath(path string) (string, bool) {
+	return strings.CutPrefix(path, SQLiteScheme)
+}
+
+// sqliteSchema creates the tables of a SQLite ledger. Each run is a row of
+// runs, with its totals per category in categories and its files in files;
+// runs.record holds the whole record as JSON, which is what Read returns.
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS runs (
+  id INTEGER PRIMARY KEY,
+  repo TEXT NOT NULL,
+  recorded_at TEXT NOT NULL,
+  head_sha TEXT NOT NULL,
+  release TEXT NOT NULL,
+  base TEXT NOT NULL,
+  head TEXT NOT NULL,
+  added INTEGER NOT NULL,
+  deleted INTEGER NOT NULL,
+  churn INTEGER NOT NULL,
+  files INTEGER NOT NULL,
+  record TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS categories (
+  run_id INTEGER NOT NULL REFERENCES runs(id),
+  category TEXT NOT NULL,
+  added INTEGER NOT NULL,
+  deleted INTEGER NOT NULL,
+  churn INTEGER NOT NULL,
+  files INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS files (
+  run_id INTEGER NOT NULL REFERENCES runs(id),
+  path TEXT NOT NULL,
+  old_path TEXT NOT NULL,
+  category TEXT NOT NULL,
+  language TEXT NOT NULL,
+  status TEXT NOT NULL,
+  added INTEGER NOT NULL,
+  deleted INTEGER NOT NULL,
+  churn INTEGER NOT NULL
+);
+`
+
+// appendSQLite adds rec to the SQLite database at path in one
+// transaction, creating the database and its tables if needed.
+func appendSQLite(path string, rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	snap := rec.Snapshot
+
+	db, err := openSQLite(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("INSERT INTO runs (repo, recorded_at, head_sha, release, base, head, added, deleted, churn, files, record) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		rec.Repo, rec.RecordedAt.UTC().Format(time.RFC3339), rec.HeadSHA, rec.Release, snap.Meta.Base, snap.Meta.Head,
+		snap.Total.Added, snap.Total.Deleted, snap.Total.Churn, snap.Total.Files, string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for cat, t := range snap.Categories {
+		if _, err := tx.Exec("INSERT INTO categories VALUES (?, ?, ?, ?, ?, ?)", runID, cat, t.Added, t.Deleted, t.Churn, t.Files); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, f := range snap.Files {
+		if _, err := tx.Exec("INSERT INTO files VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", runID,
+			f.Path, f.OldPath, f.Category, f.Language, f.Status, f.Added, f.Deleted, f.Churn); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// readSQLite loads every record from the SQLite database at path in the
+// order recorded.
+func readSQLite(path string) ([]Record, error) {
+	// Opening would create a missing database; report it missing instead.
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, record FROM runs ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		var id int64
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		rec, err := decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: run %d: %w", path, id, err)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return records, nil
+}
+
+// sqliteURIEscaper escapes the characters a SQLite file: URI gives meaning.
+var sqliteURIEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+// openSQLite opens the SQLite database at path. Transactions take the
+// write lock up front, and wait out other writers rather than failing
+// while they hold it.
+func openSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+sqliteURIEscaper.Replace(path)+"?_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSQLitePath(t *testing.T) {
+	if db, ok := SQLitePath("sqlite://data/churn.sqlite"); !ok || db != "data/churn.sqlite" {
+		t.Errorf("SQLitePath = %q, %v", db, ok)
+	}
+	if _, ok := SQLitePath("churn.db"); ok {
+		t.Error("expected a plain path not to name a SQLite database")
+	}
+}
+
+func TestAppendReadSQLite(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "churn?v=1#.sqlite")
+	path := SQLiteScheme + db
+
+	if _, err := Read(path); !os.IsNotExist(err) {
+		t.Fatalf("Read of a missing database: got %v, want not-exist", err)
+	}
+
+	rec := testRecord("api", 10)
+	rec.Repo = "o'brien"
+	rec.Snapshot.Files[0].Path = "a|b\nc.go"
+	if err := Append(path, rec); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if err := Append(path, testRecord("web", 20)); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	records, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(records) != 2 || records[0].Repo != "o'brien" || records[1].Repo != "web" {
+		t.Fatalf("got %+v, want o'brien then web", records)
+	}
+	if records[0].Snapshot.Files[0].Path != "a|b\nc.go" {
+		t.Errorf("path not round-tripped: %q", records[0].Snapshot.Files[0].Path)
+	}
+	if records[1].Snapshot.Total.Churn != 20 || records[1].Snapshot.Files[0].Path != "main.go" {
+		t.Errorf("snapshot not round-tripped: %+v", records[1].Snapshot)
+	}
+
+	// The tables answer plain SQL.
+	conn, err := openSQLite(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rows, err := conn.Query("SELECT r.repo, c.churn, f.path FROM runs r JOIN categories c ON c.run_id = r.id JOIN files f ON f.run_id = r.id ORDER BY r.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var repo, path string
+		var churn int
+		if err := rows.Scan(&repo, &churn, &path); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%s %d %q", repo, churn, path))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`o'brien 10 "a|b\nc.go"`, `web 20 "main.go"`}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+}
+
+func TestSQLiteErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-db")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 200)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(SQLiteScheme+path, testRecord("api", 1)); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("expected an error naming a file that is not a database, got %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+// Package merges breaks the churn of a range down by the merge commits on
+// its first-parent chain, so each merged pull request or branch is reported
+// with the changes it brought in.
+package merges
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// Orders merges can be listed in.
+const (
+	SortChurn = "churn"
+	SortDate  = "date"
+)
+
+// SortValues lists the accepted orders.
+var SortValues = []string{SortChurn, SortDate}
+
+// Merge is the churn one merge commit brought in: its diff against its
+// first parent.
+type Merge struct {
+	SHA string `json:"sha"`
+	// Base is the first parent, the target branch before the merge; Head is
+	// the tip of the branch merged in.
+	Base    string    `json:"base"`
+	Head    string    `json:"head"`
+	Subject string    `json:"subject"`
+	PR      int       `json:"pr,omitempty"`     // 0 if the subject names none
+	Branch  string    `json:"branch,omitempty"` // empty if the subject names none
+	Date    time.Time `json:"date"`
+	Files   int       `json:"files"`
+	Added   int       `json:"added"`
+	Deleted int       `json:"deleted"`
+	Churn   int       `json:"churn"`
+	// ByCategory is the churn per category.
+	ByCategory map[string]int `json:"by_category"`
+}
+
+// A Classifier returns the category of a path.
+type Classifier func(path string) string
+
+// subjectRes match the merge subjects written by git and the common forges,
+// capturing the pull request number and the branch merged, if named.
+var subjectRes = []*regexp.Regexp{
+	// GitHub: "Merge pull request #123 from owner/branch"
+	regexp.MustCompile(`^Merge pull request #(?P<pr>\d+) from (?P<branch>\S+)`),
+	// Bitbucket: "Merged in feature/x (pull request #12)"
+	regexp.MustCompile(`^Merged in (?P<branch>\S+) \(pull request #(?P<pr>\d+)\)`),
+	// GitLab: "Merge branch 'feature' into 'main'", with "See merge request
+	// group/project!12" in the body, which the log does not carry.
+	// git: "Merge branch 'feature'", "Merge remote-tracking branch 'origin/x'"
+	regexp.MustCompile(`^Merge (?:remote-tracking )?branch '(?P<branch>[^']+)'`),
+}
+
+// ParseSubject returns the pull request number and branch a merge subject
+// names. Either is zero if the subject does not name it.
+func ParseSubject(subject string) (pr int, branch string) {
+	subject = strings.TrimSpace(subject)
+	for _, re := range subjectRes {
+		m := re.FindStringSubmatch(subject)
+		if m == nil {
+			continue
+		}
+		if i := re.SubexpIndex("pr"); i >= 0 {
+			pr, _ = strconv.Atoi(m[i])
+		}
+		if i := re.SubexpIndex("branch"); i >= 0 {
+			branch = m[i]
+		}
+		return pr, branch
+	}
+	return 0, ""
+}
+
+// Build returns the churn of each merge commit, in the order given by
+// sortBy: by churn, largest first, or by date, newest first. Commits are
+// expected to be merges diffed against their first parent, as
+// history.Options.FirstParentMerges walks them; their files are classified
+// with classify.
+func Build(commits []history.Commit, classify Classifier, sortBy string) ([]Merge, error) {
+	if sortBy != SortChurn && sortBy != SortDate {
+		return nil, fmt.Errorf("unknown sort %q (want %s)", sortBy, strings.Join(SortValues, " or "))
+	}
+	merges := make([]Merge, 0, len(commits))
+	for _, c := range commits {
+		m := Merge{SHA: c.SHA, Subject: c.Subject, Date: c.Date, ByCategory: make(map[string]int)}
+		if len(c.Parents) > 0 {
+			m.Base = c.Parents[0]
+		}
+		if len(c.Parents) > 1 {
+			m.Head = c.Parents[1]
+		}
+		m.PR, m.Branch = ParseSubject(c.Subject)
+		for _, f := range c.Files {
+			m.Files++
+			m.Added += f.Added
+			m.Deleted += f.Deleted
+			m.Churn += f.Added + f.Deleted
+			m.ByCategory[classify(f.Path)] += f.Added + f.Deleted
+		}
+		merges = append(merges, m)
+	}
+	if sortBy == SortChurn {
+		// Log order is newest first, which breaks ties.
+		sort.SliceStable(merges, func(i, j int) bool { return merges[i].Churn > merges[j].Churn })
+	}
+	return merges, nil
+}
+
+// Categories returns the categories any merge has churn in, in report
+// order followed by custom categories by name.
+func Categories(merges []Merge) []string {
+	seen := make(map[string]bool)
+	for _, m := range merges {
+		for cat, churn := range m.ByCategory {
+			if churn > 0 {
+				seen[cat] = true
+			}
+		}
+	}
+	var cats []string
+	for _, key := range output.DisplayOrder() {
+		if seen[key] {
+			cats = append(cats, key)
+			delete(seen, key)
+		}
+	}
+	var custom []string
+	for key := range seen {
+		custom = append(custom, key)
+	}
+	sort.Strings(custom)
+	return append(cats, custom...)
+}
+
+// total sums the churn of merges.
+func total(merges []Merge) Merge {
+	t := Merge{ByCategory: make(map[string]int)}
+	for _, m := range merges {
+		t.Files += m.Files
+		t.Added += m.Added
+		t.Deleted += m.Deleted
+		t.Churn += m.Churn
+		for cat, churn := range m.ByCategory {
+			t.ByCategory[cat] += churn
+		}
+	}
+	return t
+}
+
+// label is how text output names a merge: its pull request number or
+// branch, falling back to the subject.
+func (m Merge) label() string {
+	switch {
+	case m.PR > 0 && m.Branch != "":
+		return fmt.Sprintf("#%d %s", m.PR, m.Branch)
+	case m.PR > 0:
+		return fmt.Sprintf("#%d %s", m.PR, m.Subject)
+	case m.Branch != "":
+		return m.Branch
+	}
+	return m.Subject
+}
+
+// RenderText writes a table with a row per merge: its short SHA, label,
+// churn and share of the range's churn, and churn per category, followed
+// by a total row.
+func RenderText(w io.Writer, merges []Merge) {
+	if len(merges) == 0 {
+		fmt.Fprintln(w, "No merge commits.")
+		return
+	}
+	cats := Categories(merges)
+	t := total(merges)
+
+	header := []string{"SHA", "Merge", "Churn", "Share"}
+	for _, cat := range cats {
+		header = append(header, output.DisplayName(cat))
+	}
+	rows := [][]string{header}
+	for _, m := range append(merges[:len(merges):len(merges)], t) {
+		row := []string{shortSHA(m.SHA), m.label(), fmt.Sprint(m.Churn), share(m.Churn, t.Churn)}
+		for _, cat := range cats {
+			row = append(row, fmt.Sprint(m.ByCategory[cat]))
+		}
+		rows = append(rows, row)
+	}
+	rows[len(rows)-1][1] = fmt.Sprintf("Total (%d %s)", len(merges), mergeWord(len(merges)))
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	for _, row := range rows {
+		cells := []string{fmt.Sprintf("%-*s", widths[0], row[0]), fmt.Sprintf("%-*s", widths[1], row[1])}
+		for i, cell := range row[2:] {
+			cells = append(cells, fmt.Sprintf("%*s", widths[i+2], cell))
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+}
+
+// RenderJSON writes the merges as a JSON object with the range, the
+// categories with churn, a "merges" array, and their totals.
+func RenderJSON(w io.Writer, logRange string, merges []Merge) error {
+	if merges == nil {
+		merges = []Merge{}
+	}
+	categories := Categories(merges)
+	if categories == nil {
+		categories = []string{}
+	}
+	t := total(merges)
+	out := struct {
+		Range      string   `json:"range"`
+		Categories []string `json:"categories"`
+		Merges     []Merge  `json:"merges"`
+		Totals     struct {
+			Merges     int            `json:"merges"`
+			Files      int            `json:"files"`
+			Added      int            `json:"added"`
+			Deleted    int            `json:"deleted"`
+			Churn      int            `json:"churn"`
+			ByCategory map[string]int `json:"by_category"`
+		} `json:"totals"`
+	}{Range: logRange, Categories: categories, Merges: merges}
+	out.Totals.Merges = len(merges)
+	out.Totals.Files = t.Files
+	out.Totals.Added = t.Added
+	out.Totals.Deleted = t.Deleted
+	out.Totals.Churn = t.Churn
+	out.Totals.ByCategory = t.ByCategory
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// share formats part as a whole-number percentage of whole.
+func share(part, whole int) string {
+	if whole == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", (part*100+whole/2)/whole)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func mergeWord(n int) string {
+	if n == 1 {
+		return "merge"
+	}
+	return "merges"
+}
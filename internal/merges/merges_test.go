@@ -0,0 +1,156 @@
+package merges
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/history"
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+func classifyByDir(path string) string {
+	if strings.HasPrefix(path, "docs/") {
+		return "docs"
+	}
+	return "source"
+}
+
+func testCommits() []history.Commit {
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []history.Commit{
+		{
+			SHA: "aaaaaaaaaa", Parents: []string{"p1", "h1"}, Date: day.AddDate(0, 0, 2),
+			Subject: "Merge pull request #12 from octo/docs-refresh",
+			Files:   []parser.FileStat{{Path: "docs/a.md", Added: 5, Deleted: 1}},
+		},
+		{
+			SHA: "bbbbbbbbbb", Parents: []string{"p2", "h2"}, Date: day.AddDate(0, 0, 1),
+			Subject: "Merge branch 'feature/login'",
+			Files: []parser.FileStat{
+				{Path: "login.go", Added: 30, Deleted: 10},
+				{Path: "docs/login.md", Added: 4},
+			},
+		},
+		{
+			SHA: "cccccccccc", Parents: []string{"p3", "h3"}, Date: day,
+			Subject: "Merge tag 'v1.0'",
+		},
+	}
+}
+
+func TestParseSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		pr      int
+		branch  string
+	}{
+		{"Merge pull request #123 from owner/feature-x", 123, "owner/feature-x"},
+		{"Merged in feature/x (pull request #12)", 12, "feature/x"},
+		{"Merge branch 'feature' into 'main'", 0, "feature"},
+		{"Merge branch 'fix-crash'", 0, "fix-crash"},
+		{"Merge remote-tracking branch 'origin/release'", 0, "origin/release"},
+		{"Merge tag 'v1.0'", 0, ""},
+		{"Release 1.2", 0, ""},
+	}
+	for _, tt := range tests {
+		pr, branch := ParseSubject(tt.subject)
+		if pr != tt.pr || branch != tt.branch {
+			t.Errorf("ParseSubject(%q) = %d, %q, want %d, %q", tt.subject, pr, branch, tt.pr, tt.branch)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	merges, err := Build(testCommits(), classifyByDir, SortChurn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merges) != 3 {
+		t.Fatalf("got %d merges, want 3", len(merges))
+	}
+	login := merges[0]
+	if login.SHA != "bbbbbbbbbb" || login.Churn != 44 || login.Files != 2 {
+		t.Errorf("first merge = %+v, want bbbbbbbbbb with churn 44 in 2 files", login)
+	}
+	if login.Base != "p2" || login.Head != "h2" || login.Branch != "feature/login" {
+		t.Errorf("login base/head/branch = %q, %q, %q", login.Base, login.Head, login.Branch)
+	}
+	if login.ByCategory["source"] != 40 || login.ByCategory["docs"] != 4 {
+		t.Errorf("login by category = %v, want source 40, docs 4", login.ByCategory)
+	}
+	if merges[1].PR != 12 || merges[2].Churn != 0 {
+		t.Errorf("order = %s, %s, want the docs merge before the empty one", merges[1].SHA, merges[2].SHA)
+	}
+
+	byDate, err := Build(testCommits(), classifyByDir, SortDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byDate[0].SHA != "aaaaaaaaaa" || byDate[2].SHA != "cccccccccc" {
+		t.Errorf("by date = %s, %s, %s, want newest first", byDate[0].SHA, byDate[1].SHA, byDate[2].SHA)
+	}
+
+	if _, err := Build(nil, classifyByDir, "size"); err == nil {
+		t.Error("Build with an unknown sort: want error")
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	merges, err := Build(testCommits(), classifyByDir, SortChurn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	RenderText(&buf, merges)
+	want := `SHA      Merge                  Churn  Share  Documentation  Source
+bbbbbbb  feature/login             44    88%              4      40
+aaaaaaa  #12 octo/docs-refresh      6    12%              6       0
+ccccccc  Merge tag 'v1.0'           0     0%              0       0
+         Total (3 merges)          50   100%             10      40
+`
+	if got := buf.String(); got != want {
+		t.Errorf("RenderText =\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	RenderText(&buf, nil)
+	if got := buf.String(); got != "No merge commits.\n" {
+		t.Errorf("RenderText(nil) = %q", got)
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	merges, err := Build(testCommits(), classifyByDir, SortChurn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, "v1.0..v1.1", merges); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Range      string   `json:"range"`
+		Categories []string `json:"categories"`
+		Merges     []Merge  `json:"merges"`
+		Totals     struct {
+			Merges     int            `json:"merges"`
+			Churn      int            `json:"churn"`
+			ByCategory map[string]int `json:"by_category"`
+		} `json:"totals"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if out.Range != "v1.0..v1.1" || len(out.Merges) != 3 || out.Merges[0].Head != "h2" {
+		t.Errorf("got range %q with %d merges, first head %q", out.Range, len(out.Merges), out.Merges[0].Head)
+	}
+	if strings.Join(out.Categories, ",") != "docs,source" {
+		t.Errorf("categories = %v, want docs, source", out.Categories)
+	}
+	if out.Totals.Merges != 3 || out.Totals.Churn != 50 || out.Totals.ByCategory["docs"] != 10 {
+		t.Errorf("totals = %+v", out.Totals)
+	}
+}
@@ -0,0 +1,124 @@
+// Package migrate flags risky statements in added lines of SQL and database
+// migration files, such as dropped tables or index builds that lock writes.
+package migrate
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/classify"
+	"github.com/jbonatakis/differ/internal/config"
+)
+
+// Warning is a risky statement found on an added line.
+type Warning struct {
+	Path    string
+	Line    int
+	Rule    string
+	Message string
+}
+
+type rule struct {
+	name    string
+	match   *regexp.Regexp
+	unless  *regexp.Regexp // suppresses the rule when it also matches
+	message string
+}
+
+var rules = []rule{
+	{
+		name:    "drop-table",
+		match:   regexp.MustCompile(`(?i)\bDROP\s+TABLE\b`),
+		message: "drops a table; data is lost and code still using it breaks",
+	},
+	{
+		name:    "drop-column",
+		match:   regexp.MustCompile(`(?i)\bDROP\s+COLUMN\b`),
+		message: "drops a column; deploy code that stops using it first",
+	},
+	{
+		name:    "truncate",
+		match:   regexp.MustCompile(`(?i)^\s*TRUNCATE\b`),
+		message: "truncates a table",
+	},
+	{
+		name:    "set-not-null",
+		match:   regexp.MustCompile(`(?i)\bALTER\s+COLUMN\b.*\bSET\s+NOT\s+NULL\b`),
+		message: "adds NOT NULL to an existing column; fails on existing NULLs and scans the table under lock",
+	},
+	{
+		name:    "add-not-null-without-default",
+		match:   regexp.MustCompile(`(?i)\bADD\s+(COLUMN\s+)?\S+\s+.*\bNOT\s+NULL\b`),
+		unless:  regexp.MustCompile(`(?i)\bDEFAULT\b`),
+		message: "adds a NOT NULL column without a DEFAULT; fails on tables with rows",
+	},
+	{
+		name:    "column-type-change",
+		match:   regexp.MustCompile(`(?i)\bALTER\s+COLUMN\s+\S+\s+(SET\s+DATA\s+)?TYPE\b`),
+		message: "changes a column type; may rewrite the table under lock",
+	},
+	{
+		name:    "rename",
+		match:   regexp.MustCompile(`(?i)\bALTER\s+TABLE\b.*\bRENAME\b`),
+		message: "renames a table or column; code using the old name breaks",
+	},
+	{
+		name:    "index-without-concurrently",
+		match:   regexp.MustCompile(`(?i)\b(CREATE\s+(UNIQUE\s+)?INDEX|DROP\s+INDEX)\b`),
+		unless:  regexp.MustCompile(`(?i)\bCONCURRENTLY\b`),
+		message: "builds or drops an index without CONCURRENTLY; blocks writes on PostgreSQL",
+	},
+}
+
+// migrationDirs are directory names whose files are treated as migrations
+// whatever their extension, since frameworks embed SQL in code.
+var migrationDirs = []string{"migrations", "migration", "migrate", "db/migrate"}
+
+// classifier tells tests, docs, and languages apart with the built-in
+// heuristics.
+var classifier = classify.New(config.Config{})
+
+// IsMigrationFile reports whether p is a SQL file or lives in a migrations
+// directory. Tests and docs are never migrations, and neither are Go files
+// and testdata, so a Go package named migrate is not mistaken for one.
+func IsMigrationFile(p string) bool {
+	category, lang := classifier.Classify(p)
+	switch {
+	case category == classify.Tests || category == classify.Docs:
+		return false
+	case lang == "SQL":
+		return true
+	case lang == "Go":
+		return false
+	}
+	dir := "/" + strings.ToLower(path.Dir(p)) + "/"
+	if strings.Contains(dir, "/testdata/") {
+		return false
+	}
+	for _, d := range migrationDirs {
+		if strings.Contains(dir, "/"+d+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns warnings for one added line of the file at path. Lines of
+// non-migration files and SQL comments are ignored.
+func Check(p string, line int, content string) []Warning {
+	if !IsMigrationFile(p) {
+		return nil
+	}
+	if trimmed := strings.TrimSpace(content); strings.HasPrefix(trimmed, "--") || strings.HasPrefix(trimmed, "#") {
+		return nil
+	}
+	var warnings []Warning
+	for _, r := range rules {
+		if !r.match.MatchString(content) || (r.unless != nil && r.unless.MatchString(content)) {
+			continue
+		}
+		warnings = append(warnings, Warning{Path: p, Line: line, Rule: r.name, Message: r.message})
+	}
+	return warnings
+}
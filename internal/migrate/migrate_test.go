@@ -0,0 +1,69 @@
+package migrate
+
+import "testing"
+
+func TestIsMigrationFile(t *testing.T) {
+	for _, p := range []string{"schema.sql", "db/V2__init.SQL", "db/migrate/20240101_add_users.rb", "app/migrations/0002_auto.py"} {
+		if !IsMigrationFile(p) {
+			t.Errorf("IsMigrationFile(%q) = false, want true", p)
+		}
+	}
+	for _, p := range []string{"main.go", "docs/migrations.md", "migrate.go", "pkg/migrator/run.go"} {
+		if IsMigrationFile(p) {
+			t.Errorf("IsMigrationFile(%q) = true, want false", p)
+		}
+	}
+}
+
+func TestIsMigrationFileSkipsGoPackagesTestsAndDocs(t *testing.T) {
+	for _, p := range []string{"migrations/0002_init.up", "db/migrate/0003_seed", "services/api/db/migrations/0004.ddl", "pkg/migrations/001_init.sql"} {
+		if !IsMigrationFile(p) {
+			t.Errorf("IsMigrationFile(%q) = false, want true", p)
+		}
+	}
+	for _, p := range []string{
+		"internal/migrate/migrate.go", "internal/migrate/migrate_test.go", "internal/migrate/testdata/input",
+		"migrations/README.md", "test/fixtures/schema.sql",
+	} {
+		if IsMigrationFile(p) {
+			t.Errorf("IsMigrationFile(%q) = true, want false", p)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		line string
+		want []string
+	}{
+		{"DROP TABLE users;", []string{"drop-table"}},
+		{"alter table users drop column email;", []string{"drop-column"}},
+		{"TRUNCATE audit_log;", []string{"truncate"}},
+		{"ALTER TABLE users ALTER COLUMN email SET NOT NULL;", []string{"set-not-null"}},
+		{"ALTER TABLE users ADD COLUMN age int NOT NULL;", []string{"add-not-null-without-default"}},
+		{"ALTER TABLE users ADD COLUMN age int NOT NULL DEFAULT 0;", nil},
+		{"ALTER TABLE users ALTER COLUMN age TYPE bigint;", []string{"column-type-change"}},
+		{"ALTER TABLE users RENAME COLUMN name TO full_name;", []string{"rename"}},
+		{"CREATE UNIQUE INDEX users_email ON users (email);", []string{"index-without-concurrently"}},
+		{"CREATE INDEX CONCURRENTLY users_email ON users (email);", nil},
+		{"DROP INDEX users_email;", []string{"index-without-concurrently"}},
+		{"-- DROP TABLE users;", nil},
+		{"SELECT 1;", nil},
+	}
+	for _, tc := range cases {
+		var got []string
+		for _, w := range Check("db/001.sql", 7, tc.line) {
+			if w.Path != "db/001.sql" || w.Line != 7 || w.Message == "" {
+				t.Errorf("Check(%q) returned incomplete warning %+v", tc.line, w)
+			}
+			got = append(got, w.Rule)
+		}
+		if len(got) != len(tc.want) || (len(got) > 0 && got[0] != tc.want[0]) {
+			t.Errorf("Check(%q) rules = %v, want %v", tc.line, got, tc.want)
+		}
+	}
+
+	if w := Check("main.go", 1, "DROP TABLE users;"); w != nil {
+		t.Errorf("expected no warnings outside migration files, got %+v", w)
+	}
+}
@@ -0,0 +1,189 @@
+// Package notify builds webhook payloads from churn summaries, either as
+// compact JSON or as Slack Block Kit messages, and posts them.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+)
+
+// Formats are the accepted payload formats.
+var Formats = []string{"json", "slack"}
+
+// DefaultTemplate is the message used when none is given.
+const DefaultTemplate = `differ: {{.Repo}} {{.Base}}...{{.Head}}: {{.Total.Churn}} {{plural .Total.Churn "line"}} of churn (+{{.Total.Added}} -{{.Total.Deleted}}) in {{.Total.Files}} {{plural .Total.Files "file"}}`
+
+// topFiles is how many of the largest files a Slack message lists.
+const topFiles = 5
+
+// Data is what message templates are executed with.
+type Data struct {
+	Repo       string
+	Base       string
+	Head       string
+	Total      snapshot.Totals
+	Categories map[string]snapshot.Totals
+	// Files are the changed files, largest churn first.
+	Files []snapshot.File
+}
+
+// NewData prepares summary for a message about repo.
+func NewData(repo string, summary output.Summary) Data {
+	snap := snapshot.FromSummary(summary)
+	files := snap.Files
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Churn > files[j].Churn })
+	return Data{
+		Repo:       repo,
+		Base:       snap.Meta.Base,
+		Head:       snap.Meta.Head,
+		Total:      snap.Total,
+		Categories: snap.Categories,
+		Files:      files,
+	}
+}
+
+// ParseTemplate parses a message template in text/template syntax. Besides
+// the built-in functions, templates can call plural, as in
+// {{plural .Total.Files "file"}}.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("message").Funcs(template.FuncMap{"plural": plural}).Option("missingkey=error").Parse(text)
+}
+
+// Payload renders the message for d with tmpl and wraps it in a payload of
+// the given format. Both formats carry the message in a "text" field, so
+// either can be posted to a Slack-compatible incoming webhook.
+func Payload(format string, tmpl *template.Template, d Data) ([]byte, error) {
+	var msg strings.Builder
+	if err := tmpl.Execute(&msg, d); err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(msg.String())
+
+	switch format {
+	case "json":
+		return json.Marshal(map[string]any{
+			"text":       text,
+			"repo":       d.Repo,
+			"base":       d.Base,
+			"head":       d.Head,
+			"total":      d.Total,
+			"categories": d.Categories,
+		})
+	case "slack":
+		return json.Marshal(map[string]any{
+			"text":   text,
+			"blocks": slackBlocks(text, d),
+		})
+	default:
+		return nil, fmt.Errorf("unknown payload format %q", format)
+	}
+}
+
+// slackBlocks lays out the message, then churn per category, then the
+// largest files.
+func slackBlocks(text string, d Data) []any {
+	blocks := []any{section(text)}
+
+	cats := make([]string, 0, len(d.Categories))
+	for cat := range d.Categories {
+		cats = append(cats, cat)
+	}
+	sort.Slice(cats, func(i, j int) bool {
+		ci, cj := d.Categories[cats[i]], d.Categories[cats[j]]
+		if ci.Churn != cj.Churn {
+			return ci.Churn > cj.Churn
+		}
+		return cats[i] < cats[j]
+	})
+	// Slack allows at most 10 fields in a section.
+	if len(cats) > 10 {
+		cats = cats[:10]
+	}
+	if len(cats) > 0 {
+		fields := make([]any, 0, len(cats))
+		for _, cat := range cats {
+			t := d.Categories[cat]
+			fields = append(fields, mrkdwn(fmt.Sprintf("*%s*\n+%d -%d (%d %s)", cat, t.Added, t.Deleted, t.Files, plural(t.Files, "file"))))
+		}
+		blocks = append(blocks, map[string]any{"type": "section", "fields": fields})
+	}
+
+	if len(d.Files) > 0 {
+		var b strings.Builder
+		b.WriteString("Largest changes:")
+		for i, f := range d.Files {
+			if i == topFiles {
+				fmt.Fprintf(&b, "\n...and %d more", len(d.Files)-topFiles)
+				break
+			}
+			fmt.Fprintf(&b, "\n`%s` +%d -%d", f.Path, f.Added, f.Deleted)
+		}
+		blocks = append(blocks, map[string]any{"type": "context", "elements": []any{mrkdwn(b.String())}})
+	}
+	return blocks
+}
+
+func section(text string) map[string]any {
+	return map[string]any{"type": "section", "text": mrkdwn(text)}
+}
+
+func mrkdwn(text string) map[string]any {
+	return map[string]any{"type": "mrkdwn", "text": text}
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// Post sends payload to webhook. A nil client uses one with a 30-second
+// timeout.
+func Post(ctx context.Context, client *http.Client, webhook string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL %s", redact(webhook))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("POST %s: %w", redact(webhook), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("POST %s: %s: %s", redact(webhook), resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// redact drops the path of a webhook URL from error messages, since
+// incoming-webhook URLs embed their secret there.
+func redact(webhook string) string {
+	u, err := url.Parse(webhook)
+	if err != nil || u.Host == "" {
+		return "webhook"
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}
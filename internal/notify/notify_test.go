@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+func testData() Data {
+	return NewData("api", output.Summary{
+		Totals: output.CategoryTotal{Added: 12, Deleted: 3, Churn: 15, FileCount: 2},
+		CategoryTotals: map[string]output.CategoryTotal{
+			"source": {Added: 2, Deleted: 3, Churn: 5, FileCount: 1},
+			"tests":  {Added: 10, Churn: 10, FileCount: 1},
+		},
+		FileStats: []output.FileStat{
+			{Path: "main.go", Added: 2, Deleted: 3, Churn: 5, Category: "source"},
+			{Path: "main_test.go", Added: 10, Churn: 10, Category: "tests"},
+		},
+		Meta: output.Meta{Base: "main", Head: "HEAD"},
+	})
+}
+
+func TestPayloadJSON(t *testing.T) {
+	tmpl, err := ParseTemplate(DefaultTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := Payload("json", tmpl, testData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Text       string         `json:"text"`
+		Repo       string         `json:"repo"`
+		Total      map[string]int `json:"total"`
+		Categories map[string]any `json:"categories"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := "differ: api main...HEAD: 15 lines of churn (+12 -3) in 2 files"; got.Text != want {
+		t.Errorf("text = %q, want %q", got.Text, want)
+	}
+	if got.Repo != "api" || got.Total["churn"] != 15 || len(got.Categories) != 2 {
+		t.Errorf("payload = %s", body)
+	}
+}
+
+func TestPayloadSlack(t *testing.T) {
+	tmpl, err := ParseTemplate(`*{{.Repo}}*: {{.Total.Churn}} {{plural .Total.Churn "line"}}{{range $i, $f := .Files}}{{if eq $i 0}}, most in {{$f.Path}}{{end}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := Payload("slack", tmpl, testData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type     string `json:"type"`
+			Text     struct{ Text string }
+			Fields   []struct{ Text string }
+			Elements []struct{ Text string }
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := "*api*: 15 lines, most in main_test.go"; got.Text != want || len(got.Blocks) != 3 || got.Blocks[0].Text.Text != want {
+		t.Fatalf("payload = %s", body)
+	}
+	if f := got.Blocks[1].Fields; len(f) != 2 || f[0].Text != "*tests*\n+10 -0 (1 file)" {
+		t.Errorf("category fields = %+v", f)
+	}
+	if e := got.Blocks[2].Elements; len(e) != 1 || e[0].Text != "Largest changes:\n`main_test.go` +10 -0\n`main.go` +2 -3" {
+		t.Errorf("file context = %+v", e)
+	}
+}
+
+func TestPayloadTemplateError(t *testing.T) {
+	tmpl, err := ParseTemplate("{{.Nope}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Payload("json", tmpl, testData()); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestPost(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = r.Header.Get("Content-Type") + " " + string(body)
+		if r.URL.Path == "/fail/secret" {
+			http.Error(w, "no_service", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	if err := Post(context.Background(), nil, srv.URL+"/hook", []byte(`{"text":"hi"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got != `application/json {"text":"hi"}` {
+		t.Errorf("request = %q", got)
+	}
+
+	err := Post(context.Background(), nil, srv.URL+"/fail/secret", []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found: no_service") || strings.Contains(err.Error(), "secret") {
+		t.Errorf("error = %v", err)
+	}
+}
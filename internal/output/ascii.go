@@ -0,0 +1,81 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// asciiReplacements maps typographic characters to ASCII look-alikes.
+var asciiReplacements = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '′': "'",
+	'“': `"`, '”': `"`, '„': `"`, '″': `"`,
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…': "...",
+	'•': "*", '·': "*",
+	'\u00a0': " ", '\u2009': " ", '\u202f': " ", // no-break and thin spaces
+	'←': "<-", '→': "->", '↑': "^", '↓': "v",
+	'×': "x",
+	'✓': "ok", '✔': "ok", '✗': "x", '✘': "x",
+	// Box drawing.
+	'─': "-", '━': "-", '│': "|", '┃': "|",
+	'┌': "+", '┐': "+", '└': "+", '┘': "+",
+	'├': "+", '┤': "+", '┬': "+", '┴': "+", '┼': "+",
+	'═': "=", '║': "|",
+	'╔': "+", '╗': "+", '╚': "+", '╝': "+",
+	'╭': "+", '╮': "+", '╯': "+", '╰': "+",
+	'█': "#", '░': ".", '▒': ":", '▓': "#",
+}
+
+// asciiWriter transliterates everything written through it to ASCII.
+type asciiWriter struct {
+	w       io.Writer
+	partial []byte // incomplete UTF-8 sequence from the previous Write
+}
+
+// NewASCIIWriter returns a writer that passes ASCII through unchanged,
+// replaces typographic punctuation and box-drawing characters with ASCII
+// look-alikes, and writes any other character as a \uXXXX escape (a
+// surrogate pair above U+FFFF), which keeps JSON output valid. Invalid
+// UTF-8 is written as "?".
+func NewASCIIWriter(w io.Writer) io.Writer {
+	return &asciiWriter{w: w}
+}
+
+func (a *asciiWriter) Write(p []byte) (int, error) {
+	buf := append(a.partial, p...)
+	a.partial = nil
+
+	out := make([]byte, 0, len(buf))
+	for i := 0; i < len(buf); {
+		c := buf[i]
+		if c < utf8.RuneSelf {
+			out = append(out, c)
+			i++
+			continue
+		}
+		if !utf8.FullRune(buf[i:]) {
+			// Keep the start of a split character for the next Write.
+			a.partial = append([]byte(nil), buf[i:]...)
+			break
+		}
+		r, size := utf8.DecodeRune(buf[i:])
+		i += size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			out = append(out, '?')
+		case asciiReplacements[r] != "":
+			out = append(out, asciiReplacements[r]...)
+		case r > 0xFFFF:
+			r -= 0x10000
+			out = fmt.Appendf(out, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		default:
+			out = fmt.Appendf(out, `\u%04x`, r)
+		}
+	}
+
+	if _, err := a.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
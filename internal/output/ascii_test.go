@@ -0,0 +1,65 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestASCIIWriter(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain +12 -3\n", "plain +12 -3\n"},
+		{"feat: add cache — +10 -2", "feat: add cache - +10 -2"},
+		{"…and 3 more", "...and 3 more"},
+		{"├── src │ 12", "+-- src | 12"},
+		{"“quoted” ‘x’", `"quoted" 'x'`},
+		{"café", `caf\u00e9`},
+		{"ship it 🚀", `ship it \ud83d\ude80`},
+		{"bad \xff byte", "bad ? byte"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		NewASCIIWriter(&buf).Write([]byte(tt.in))
+		if got := buf.String(); got != tt.want {
+			t.Errorf("ASCII(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestASCIIWriterSplitRune(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewASCIIWriter(&buf)
+	s := []byte("a—b")
+	for i := range s {
+		if n, err := w.Write(s[i : i+1]); n != 1 || err != nil {
+			t.Fatalf("Write = %d, %v", n, err)
+		}
+	}
+	if got := buf.String(); got != "a-b" {
+		t.Errorf("got %q, want %q", got, "a-b")
+	}
+}
+
+func TestASCIIWriterKeepsJSONValid(t *testing.T) {
+	s := testSummary()
+	s.FileStats[0].Path = "docs/résumé 📄.md"
+
+	var buf bytes.Buffer
+	RenderJSON(NewASCIIWriter(&buf), s)
+	for _, c := range buf.Bytes() {
+		if c >= 0x80 {
+			t.Fatalf("non-ASCII byte in output:\n%s", buf.String())
+		}
+	}
+	var result struct {
+		ByFile []jsonFile `json:"by_file"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if result.ByFile[0].Path != "docs/résumé 📄.md" {
+		t.Errorf("path = %q, want it round-tripped", result.ByFile[0].Path)
+	}
+}
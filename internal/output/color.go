@@ -0,0 +1,32 @@
+package output
+
+import "os"
+
+// ColorModes are the accepted --color values.
+var ColorModes = []string{"auto", "always", "never"}
+
+// Colorize reports whether text output written to f should be colored in
+// mode, one of ColorModes. In auto mode output is colored when f is a
+// terminal, unless NO_COLOR is set to a non-empty value; CLICOLOR_FORCE set
+// to anything but 0 colors output that is not a terminal.
+func Colorize(mode string, f *os.File) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	return IsTerminal(f)
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
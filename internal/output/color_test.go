@@ -0,0 +1,34 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		mode, noColor, force string
+		want                 bool
+	}{
+		{"auto", "", "", false}, // a file is not a terminal
+		{"auto", "", "1", true},
+		{"auto", "", "0", false},
+		{"auto", "1", "1", false}, // NO_COLOR wins
+		{"always", "1", "", true},
+		{"never", "", "1", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("NO_COLOR", tt.noColor)
+		t.Setenv("CLICOLOR_FORCE", tt.force)
+		if got := Colorize(tt.mode, f); got != tt.want {
+			t.Errorf("Colorize(%q) with NO_COLOR=%q CLICOLOR_FORCE=%q = %v, want %v", tt.mode, tt.noColor, tt.force, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// CategoryDelta holds before/after totals for a single category.
+type CategoryDelta struct {
+	Category string
+	Before   CategoryTotal
+	After    CategoryTotal
+}
+
+// FileDelta holds before/after churn for a single file path.
+type FileDelta struct {
+	Path     string
+	Category string
+	Before   int
+	After    int
+}
+
+// Delta describes the difference between two summaries.
+type Delta struct {
+	BeforeMeta Meta
+	AfterMeta  Meta
+	Total      CategoryDelta
+	Categories []CategoryDelta
+	Files      []FileDelta
+}
+
+// Compare computes the per-category and per-file differences between before
+// and after. Categories are ordered by display order; files whose churn is
+// unchanged are omitted.
+func Compare(before, after Summary) Delta {
+	d := Delta{
+		BeforeMeta: before.Meta,
+		AfterMeta:  after.Meta,
+		Total:      CategoryDelta{Category: "total", Before: before.Totals, After: after.Totals},
+	}
+
+	for _, cat := range categoryOrder {
+		b, inBefore := before.CategoryTotals[cat.key]
+		a, inAfter := after.CategoryTotals[cat.key]
+		if !inBefore && !inAfter {
+			continue
+		}
+		d.Categories = append(d.Categories, CategoryDelta{Category: cat.key, Before: b, After: a})
+	}
+
+	beforeFiles := make(map[string]FileStat, len(before.FileStats))
+	for _, f := range before.FileStats {
+		beforeFiles[f.Path] = f
+	}
+	seen := make(map[string]bool, len(after.FileStats))
+	for _, f := range after.FileStats {
+		seen[f.Path] = true
+		b := beforeFiles[f.Path]
+		if b.Churn == f.Churn {
+			continue
+		}
+		d.Files = append(d.Files, FileDelta{Path: f.Path, Category: f.Category, Before: b.Churn, After: f.Churn})
+	}
+	for _, f := range before.FileStats {
+		if seen[f.Path] {
+			continue
+		}
+		d.Files = append(d.Files, FileDelta{Path: f.Path, Category: f.Category, Before: f.Churn})
+	}
+
+	sort.Slice(d.Files, func(i, j int) bool {
+		di, dj := absInt(d.Files[i].After-d.Files[i].Before), absInt(d.Files[j].After-d.Files[j].Before)
+		if di != dj {
+			return di > dj
+		}
+		return d.Files[i].Path < d.Files[j].Path
+	})
+
+	return d
+}
+
+// RenderDeltaText writes a human-readable comparison of two runs to w.
+// When opts.List or opts.ListOnly is set, changed files are listed as well.
+func RenderDeltaText(w io.Writer, d Delta, opts Options) {
+	fmt.Fprintf(w, "Before: %s\n", describeMeta(d.BeforeMeta))
+	fmt.Fprintf(w, "After:  %s\n\n", describeMeta(d.AfterMeta))
+
+	labelWidth := len("Total")
+	beforeWidth, afterWidth := digitWidth(d.Total.Before.Churn), digitWidth(d.Total.After.Churn)
+	for _, cd := range d.Categories {
+		if l := len(categoryDisplay(cd.Category)); l > labelWidth {
+			labelWidth = l
+		}
+		if w := digitWidth(cd.Before.Churn); w > beforeWidth {
+			beforeWidth = w
+		}
+		if w := digitWidth(cd.After.Churn); w > afterWidth {
+			afterWidth = w
+		}
+	}
+
+	line := func(label string, cd CategoryDelta) {
+		gap := strings.Repeat(" ", labelWidth-len(label)+1)
+		fmt.Fprintf(w, "%s:%s%*d -> %*d (%s) [%d -> %d %s]\n",
+			label, gap, beforeWidth, cd.Before.Churn, afterWidth, cd.After.Churn,
+			formatSigned(cd.After.Churn-cd.Before.Churn, opts.NoColor),
+			cd.Before.FileCount, cd.After.FileCount, fileWord(cd.After.FileCount))
+	}
+	for _, cd := range d.Categories {
+		line(categoryDisplay(cd.Category), cd)
+	}
+	line("Total", d.Total)
+
+	if (opts.List || opts.ListOnly) && len(d.Files) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "[Changed files]")
+		for _, f := range d.Files {
+			fmt.Fprintf(w, "%s %s\n", formatSigned(f.After-f.Before, opts.NoColor), f.Path)
+		}
+	}
+}
+
+type jsonDelta struct {
+	Before     jsonMeta            `json:"before"`
+	After      jsonMeta            `json:"after"`
+	Total      jsonTotalDelta      `json:"total"`
+	ByCategory []jsonCategoryDelta `json:"by_category"`
+	ByFile     []jsonFileDelta     `json:"by_file"`
+}
+
+type jsonTotalDelta struct {
+	Before jsonTotal `json:"before"`
+	After  jsonTotal `json:"after"`
+	Churn  int       `json:"churn_delta"`
+}
+
+type jsonCategoryDelta struct {
+	Category string    `json:"category"`
+	Before   jsonTotal `json:"before"`
+	After    jsonTotal `json:"after"`
+	Churn    int       `json:"churn_delta"`
+}
+
+type jsonFileDelta struct {
+	Path     string `json:"path"`
+	Category string `json:"category"`
+	Before   int    `json:"churn_before"`
+	After    int    `json:"churn_after"`
+	Churn    int    `json:"churn_delta"`
+}
+
+// RenderDeltaJSON writes a comparison of two runs as JSON to w.
+func RenderDeltaJSON(w io.Writer, d Delta) error {
+	out := jsonDelta{
+		Before: toJSONMeta(d.BeforeMeta),
+		After:  toJSONMeta(d.AfterMeta),
+		Total: jsonTotalDelta{
+			Before: toJSONTotal(d.Total.Before),
+			After:  toJSONTotal(d.Total.After),
+			Churn:  d.Total.After.Churn - d.Total.Before.Churn,
+		},
+		ByCategory: make([]jsonCategoryDelta, 0, len(d.Categories)),
+		ByFile:     make([]jsonFileDelta, 0, len(d.Files)),
+	}
+	for _, cd := range d.Categories {
+		out.ByCategory = append(out.ByCategory, jsonCategoryDelta{
+			Category: cd.Category,
+			Before:   toJSONTotal(cd.Before),
+			After:    toJSONTotal(cd.After),
+			Churn:    cd.After.Churn - cd.Before.Churn,
+		})
+	}
+	for _, f := range d.Files {
+		out.ByFile = append(out.ByFile, jsonFileDelta{
+			Path:     f.Path,
+			Category: f.Category,
+			Before:   f.Before,
+			After:    f.After,
+			Churn:    f.After - f.Before,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func describeMeta(m Meta) string {
+	desc := describeRange(m)
+	if m.Timestamp != "" {
+		desc += " (" + m.Timestamp + ")"
+	}
+	return desc
+}
+
+func categoryDisplay(key string) string {
+	for _, cat := range categoryOrder {
+		if cat.key == key {
+			return cat.display
+		}
+	}
+	return key
+}
+
+func formatSigned(n int, noColor bool) string {
+	s := fmt.Sprintf("%+d", n)
+	if noColor || n == 0 {
+		return s
+	}
+	if n > 0 {
+		return addColor + s + resetColor
+	}
+	return delColor + s + resetColor
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
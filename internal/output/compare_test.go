@@ -0,0 +1,120 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func compareSummaries() (Summary, Summary) {
+	before := Summary{
+		Totals: CategoryTotal{Added: 10, Deleted: 5, Churn: 15, FileCount: 2},
+		CategoryTotals: map[string]CategoryTotal{
+			"source": {Added: 8, Deleted: 4, Churn: 12, FileCount: 1},
+			"docs":   {Added: 2, Deleted: 1, Churn: 3, FileCount: 1},
+		},
+		FileStats: []FileStat{
+			{Path: "main.go", Added: 8, Deleted: 4, Churn: 12, Category: "source"},
+			{Path: "README.md", Added: 2, Deleted: 1, Churn: 3, Category: "docs"},
+		},
+		Meta: Meta{Base: "main", Head: "v1"},
+	}
+	after := Summary{
+		Totals: CategoryTotal{Added: 30, Deleted: 5, Churn: 35, FileCount: 2},
+		CategoryTotals: map[string]CategoryTotal{
+			"source": {Added: 20, Deleted: 5, Churn: 25, FileCount: 1},
+			"tests":  {Added: 10, Deleted: 0, Churn: 10, FileCount: 1},
+		},
+		FileStats: []FileStat{
+			{Path: "main.go", Added: 20, Deleted: 5, Churn: 25, Category: "source"},
+			{Path: "main_test.go", Added: 10, Deleted: 0, Churn: 10, Category: "tests"},
+		},
+		Meta: Meta{Base: "main", Head: "v2"},
+	}
+	return before, after
+}
+
+func TestCompareCategoriesInDisplayOrder(t *testing.T) {
+	d := Compare(compareSummaries())
+
+	var got []string
+	for _, cd := range d.Categories {
+		got = append(got, cd.Category)
+	}
+	want := []string{"docs", "tests", "source"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("categories = %v, want %v", got, want)
+	}
+	if d.Total.Before.Churn != 15 || d.Total.After.Churn != 35 {
+		t.Errorf("total = %d -> %d, want 15 -> 35", d.Total.Before.Churn, d.Total.After.Churn)
+	}
+}
+
+func TestCompareFilesSortedByDelta(t *testing.T) {
+	d := Compare(compareSummaries())
+
+	if len(d.Files) != 3 {
+		t.Fatalf("expected 3 changed files, got %d: %+v", len(d.Files), d.Files)
+	}
+	// main.go +13, main_test.go +10, README.md -3.
+	want := []string{"main.go", "main_test.go", "README.md"}
+	for i, f := range d.Files {
+		if f.Path != want[i] {
+			t.Errorf("file %d = %q, want %q", i, f.Path, want[i])
+		}
+	}
+	if d.Files[2].After != 0 || d.Files[2].Before != 3 {
+		t.Errorf("removed file = %+v, want 3 -> 0", d.Files[2])
+	}
+}
+
+func TestCompareOmitsUnchangedFiles(t *testing.T) {
+	s, _ := compareSummaries()
+	d := Compare(s, s)
+	if len(d.Files) != 0 {
+		t.Errorf("expected no changed files, got %+v", d.Files)
+	}
+}
+
+func TestRenderDeltaText(t *testing.T) {
+	var buf bytes.Buffer
+	RenderDeltaText(&buf, Compare(compareSummaries()), Options{List: true, NoColor: true})
+	got := buf.String()
+
+	for _, want := range []string{
+		"Before: main...v1",
+		"After:  main...v2",
+		"Source:        12 -> 25 (+13) [1 -> 1 file]",
+		"Documentation:  3 ->  0 (-3) [1 -> 0 files]",
+		"Total:         15 -> 35 (+20) [2 -> 2 files]",
+		"[Changed files]",
+		"+13 main.go",
+		"-3 README.md",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderDeltaJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderDeltaJSON(&buf, Compare(compareSummaries())); err != nil {
+		t.Fatalf("RenderDeltaJSON: %v", err)
+	}
+
+	var result jsonDelta
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if result.Total.Churn != 20 {
+		t.Errorf("total churn_delta = %d, want 20", result.Total.Churn)
+	}
+	if len(result.ByCategory) != 3 {
+		t.Errorf("expected 3 categories, got %d", len(result.ByCategory))
+	}
+	if result.Before.Pathspecs == nil {
+		t.Error("expected pathspecs to be an empty array, not null")
+	}
+}
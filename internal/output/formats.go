@@ -0,0 +1,250 @@
+package output
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// sortedFiles returns a copy of the summary's files in opts.Sort order.
+func sortedFiles(summary Summary, opts Options) []FileStat {
+	files := make([]FileStat, len(summary.FileStats))
+	copy(files, summary.FileStats)
+	sortFiles(files, opts.Sort)
+	return files
+}
+
+// RenderMarkdown writes the summary as a markdown table of categories and,
+// with opts.List or opts.ListOnly, a table of files, ready to paste into a
+// pull request description or a CI job summary.
+func RenderMarkdown(w io.Writer, summary Summary, opts Options) {
+	if !opts.ListOnly {
+		fmt.Fprintln(w, "| Category | Added | Deleted | Churn | Files |")
+		fmt.Fprintln(w, "|:--|--:|--:|--:|--:|")
+		for _, cat := range categoryOrder {
+			ct, ok := summary.CategoryTotals[cat.key]
+			if !ok || ct.FileCount == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "| %s | +%d | -%d | %d | %d |\n", escapeMarkdown(iconLabel(opts.Icons, cat.key, cat.display)), ct.Added, ct.Deleted, ct.Churn, ct.FileCount)
+		}
+		t := summary.Totals
+		fmt.Fprintf(w, "| **Total** | **+%d** | **-%d** | **%d** | **%d** |\n", t.Added, t.Deleted, t.Churn, t.FileCount)
+	}
+
+	if opts.List || opts.ListOnly {
+		if !opts.ListOnly {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, "| File | Category | Added | Deleted | Churn |")
+		fmt.Fprintln(w, "|:--|:--|--:|--:|--:|")
+		for _, f := range sortedFiles(summary, opts) {
+			name := "`" + strings.ReplaceAll(f.Path, "`", "'") + "`"
+			if f.Link != "" {
+				name = "[" + name + "](" + f.Link + ")"
+			}
+			if n := f.Note(); n != "" {
+				name += " (" + n + ")"
+			}
+			fmt.Fprintf(w, "| %s | %s | +%d | -%d | %d |\n", escapeMarkdown(name), escapeMarkdown(iconLabel(opts.Icons, f.Category, DisplayName(f.Category))), f.Added, f.Deleted, f.Churn)
+		}
+	}
+}
+
+// escapeMarkdown escapes the characters that would break a table cell.
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// csvHeader names the columns RenderCSV writes.
+var csvHeader = []string{"path", "old_path", "category", "language", "added", "deleted", "churn", "net", "moved", "status"}
+
+// RenderPaths writes the path of each file, in opts.Sort order, followed by
+// a NUL byte, so that paths with spaces or newlines reach xargs -0 and the
+// like intact. With opts.Top, only the Top files with the most churn are
+// written.
+func RenderPaths(w io.Writer, summary Summary, opts Options) error {
+	files := sortedFiles(summary, opts)
+	if opts.Top > 0 {
+		files, _ = topFiles(files, opts.Top)
+	}
+	bw := bufio.NewWriter(w)
+	for _, f := range files {
+		bw.WriteString(f.Path)
+		bw.WriteByte(0)
+	}
+	return bw.Flush()
+}
+
+// RenderTable writes one row per file, in opts.Sort order, with its line
+// counts, category, language, and path in columns aligned for reading,
+// under a header row unless opts.NoHeader. Counts are right-aligned. The
+// path comes last and is not padded, so the rest of a row after the
+// language is the path, spaces and all. With opts.Top, only the Top files
+// with the most churn are listed; opts.Net adds a column of net lines.
+func RenderTable(w io.Writer, summary Summary, opts Options) error {
+	files := sortedFiles(summary, opts)
+	if opts.Top > 0 {
+		files, _ = topFiles(files, opts.Top)
+	}
+
+	header := []string{"Added", "Deleted", "Churn"}
+	if opts.Net {
+		header = append(header, "Net")
+	}
+	counts := len(header)
+	header = append(header, "Category", "Language", "Path")
+	var rows [][]string
+	if !opts.NoHeader {
+		rows = append(rows, header)
+	}
+	for _, f := range files {
+		row := []string{strconv.Itoa(f.Added), strconv.Itoa(f.Deleted), strconv.Itoa(f.Churn)}
+		if opts.Net {
+			row = append(row, fmt.Sprintf("%+d", f.Net()))
+		}
+		language := f.Language
+		if language == "" {
+			language = "-"
+		}
+		rows = append(rows, append(row, DisplayName(f.Category), language, f.Path))
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	bw := bufio.NewWriter(w)
+	for _, row := range rows {
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-len(cell))
+			switch {
+			case i < counts:
+				bw.WriteString(pad + cell + "  ")
+			case i < len(row)-1:
+				bw.WriteString(cell + pad + "  ")
+			default:
+				bw.WriteString(cell + "\n")
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// RenderCSV writes one row per file, in opts.Sort order, under a header
+// row unless opts.NoHeader. Summary totals are left for the reader to
+// compute.
+func RenderCSV(w io.Writer, summary Summary, opts Options) error {
+	cw := csv.NewWriter(w)
+	if !opts.NoHeader {
+		if err := cw.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	for _, f := range sortedFiles(summary, opts) {
+		row := []string{
+			f.Path, f.OldPath, f.Category, f.Language,
+			strconv.Itoa(f.Added), strconv.Itoa(f.Deleted), strconv.Itoa(f.Churn), strconv.Itoa(f.Net()), strconv.Itoa(f.Moved), f.Status,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var htmlTmpl = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>differ {{.Range}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5rem; }
+th, td { padding: 0.25rem 0.75rem; border-bottom: 1px solid #ddd; text-align: left; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.add { color: #1a7f37; }
+.del { color: #cf222e; }
+tr.total td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Churn for {{.Range}}</h1>
+{{if .Categories}}<table>
+<tr><th>Category</th><th>Added</th><th>Deleted</th><th>Churn</th><th>Files</th></tr>
+{{range .Categories}}<tr><td>{{.Name}}</td><td class="num add">+{{.Added}}</td><td class="num del">-{{.Deleted}}</td><td class="num">{{.Churn}}</td><td class="num">{{.FileCount}}</td></tr>
+{{end}}<tr class="total"><td>Total</td><td class="num add">+{{.Total.Added}}</td><td class="num del">-{{.Total.Deleted}}</td><td class="num">{{.Total.Churn}}</td><td class="num">{{.Total.FileCount}}</td></tr>
+</table>
+{{end}}{{if .Files}}<table>
+<tr><th>File</th><th>Category</th><th>Added</th><th>Deleted</th><th>Churn</th></tr>
+{{range .Files}}<tr><td>{{if .Link}}<a href="{{.Link}}">{{.Path}}</a>{{else}}{{.Path}}{{end}}{{with .Note}} ({{.}}){{end}}</td><td>{{.Category}}</td><td class="num add">+{{.Added}}</td><td class="num del">-{{.Deleted}}</td><td class="num">{{.Churn}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// RenderHTML writes the summary as a standalone HTML page, with the file
+// table included under the same options as text output.
+func RenderHTML(w io.Writer, summary Summary, opts Options) error {
+	type category struct {
+		Name string
+		CategoryTotal
+	}
+	type file struct {
+		FileStat
+		Category string // display name
+	}
+	data := struct {
+		Range      string
+		Categories []category
+		Total      CategoryTotal
+		Files      []file
+	}{Range: describeRange(summary.Meta), Total: summary.Totals}
+	if !opts.ListOnly {
+		for _, cat := range categoryOrder {
+			if ct, ok := summary.CategoryTotals[cat.key]; ok && ct.FileCount > 0 {
+				data.Categories = append(data.Categories, category{cat.display, ct})
+			}
+		}
+	}
+	if opts.List || opts.ListOnly {
+		for _, f := range sortedFiles(summary, opts) {
+			data.Files = append(data.Files, file{f, DisplayName(f.Category)})
+		}
+	}
+	return htmlTmpl.Execute(w, data)
+}
+
+// RenderPrometheus writes the summary in the Prometheus text exposition
+// format: added, deleted, and churned lines and changed files per category,
+// plus a "total" series, for a textfile collector or a Pushgateway.
+func RenderPrometheus(w io.Writer, summary Summary) error {
+	metrics := []struct {
+		name, help string
+		value      func(CategoryTotal) int
+	}{
+		{"differ_added_lines", "Lines added in the analyzed range.", func(ct CategoryTotal) int { return ct.Added }},
+		{"differ_deleted_lines", "Lines deleted in the analyzed range.", func(ct CategoryTotal) int { return ct.Deleted }},
+		{"differ_churn_lines", "Lines of churn (added plus deleted) in the analyzed range.", func(ct CategoryTotal) int { return ct.Churn }},
+		{"differ_changed_files", "Files changed in the analyzed range.", func(ct CategoryTotal) int { return ct.FileCount }},
+	}
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		fmt.Fprintf(&b, "%s{base=%q,head=%q,category=\"total\"} %d\n", m.name, summary.Meta.Base, summary.Meta.Head, m.value(summary.Totals))
+		for _, cat := range categoryOrder {
+			if ct, ok := summary.CategoryTotals[cat.key]; ok {
+				fmt.Fprintf(&b, "%s{base=%q,head=%q,category=%q} %d\n", m.name, summary.Meta.Base, summary.Meta.Head, cat.key, m.value(ct))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
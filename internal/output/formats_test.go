@@ -0,0 +1,160 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.FileStats[0].Link = "https://example.com/bar.go"
+	RenderMarkdown(&buf, s, Options{List: true, Sort: "churn"})
+	got := buf.String()
+	for _, want := range []string{
+		"| Category | Added | Deleted | Churn | Files |\n|:--|--:|--:|--:|--:|\n| Documentation | +12 | -3 | 15 | 4 |\n",
+		"| **Total** | **+186** | **-104** | **290** | **28** |\n\n",
+		"| File | Category | Added | Deleted | Churn |\n|:--|:--|--:|--:|--:|\n| `internal/baz/baz.go` | Source | +70 | -60 | 130 |\n",
+		"| [`internal/foo/bar.go`](https://example.com/bar.go) | Source |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+
+	buf.Reset()
+	RenderMarkdown(&buf, s, Options{})
+	if strings.Contains(buf.String(), "| File |") {
+		t.Errorf("file table without List:\n%s", buf.String())
+	}
+}
+
+func TestRenderMarkdownIcons(t *testing.T) {
+	var buf bytes.Buffer
+	RenderMarkdown(&buf, testSummary(), Options{List: true, Icons: map[string]string{"docs": "📝", "source": "|>"}})
+	got := buf.String()
+	for _, want := range []string{
+		"| 📝 Documentation | +12 | -3 | 15 | 4 |\n| Tests |",
+		"| **Total** |",
+		"| `internal/baz/baz.go` | \\|> Source | +70 |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.FileStats[5].Path = "dir, with comma/Makefile"
+	if err := RenderCSV(&buf, s, Options{Sort: "path"}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(s.FileStats)+1 || strings.Join(rows[0], ",") != "path,old_path,category,language,added,deleted,churn,net,moved,status" {
+		t.Fatalf("rows = %v", rows)
+	}
+	if got := strings.Join(rows[1], ","); got != "dir, with comma/Makefile,,other,,7,1,8,6,0," {
+		t.Errorf("first row = %q", got)
+	}
+
+	buf.Reset()
+	if err := RenderCSV(&buf, s, Options{Sort: "path", NoHeader: true}); err != nil {
+		t.Fatal(err)
+	}
+	if rows, _ := csv.NewReader(&buf).ReadAll(); len(rows) != len(s.FileStats) || rows[0][0] != "dir, with comma/Makefile" {
+		t.Errorf("without header: rows = %v", rows)
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.FileStats = s.FileStats[:5]
+	s.FileStats[3].Path = "docs/user guide.md"
+	if err := RenderTable(&buf, s, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"Added  Deleted  Churn  Category       Language  Path\n" +
+		"   70       60    130  Source         Go        internal/baz/baz.go\n" +
+		"   50       30     80  Source         Go        internal/foo/bar.go\n" +
+		"   20        2     22  Tests          Go        pkg/a/a_test.go\n" +
+		"   12        3     15  Documentation  -         docs/user guide.md\n" +
+		"    2        2      4  Generated      -         go.sum\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := RenderTable(&buf, s, Options{NoHeader: true, Net: true, Top: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "70  60  130  +10  Source  Go  internal/baz/baz.go\n"; got != want {
+		t.Errorf("without header: got %q, want %q", got, want)
+	}
+}
+
+func TestRenderPaths(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.FileStats[5].Path = "dir with space/new\nline"
+	if err := RenderPaths(&buf, s, Options{Sort: "path"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "dir with space/new\nline\x00docs/README.md\x00go.sum\x00internal/baz/baz.go\x00internal/foo/bar.go\x00internal/foo/specs/bar_spec.rb\x00pkg/a/a_test.go\x00"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// --top keeps the files with the most churn, still in --sort order.
+	buf.Reset()
+	if err := RenderPaths(&buf, s, Options{Sort: "path", Top: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "internal/baz/baz.go\x00internal/foo/bar.go\x00"; got != want {
+		t.Errorf("with Top: got %q, want %q", got, want)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.FileStats[0].Path = "<script>.go"
+	if err := RenderHTML(&buf, s, Options{List: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{"<title>differ main...HEAD</title>", "<td>Source</td>", `<tr class="total"><td>Total</td>`, "&lt;script&gt;.go"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<script>") {
+		t.Error("file path was not escaped")
+	}
+}
+
+func TestRenderPrometheus(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderPrometheus(&buf, testSummary()); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"# TYPE differ_churn_lines gauge\n",
+		`differ_churn_lines{base="main",head="HEAD",category="total"} 290` + "\n",
+		`differ_added_lines{base="main",head="HEAD",category="source"} 120` + "\n",
+		`differ_changed_files{base="main",head="HEAD",category="docs"} 4` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Layer is a named summary that is rendered alongside others, such as one
+// branch in a stack.
+type Layer struct {
+	Name    string
+	Summary Summary
+}
+
+// RenderLayersText writes each layer's heading and text output to w,
+// separated by blank lines.
+func RenderLayersText(w io.Writer, layers []Layer, opts Options) {
+	for i, l := range layers {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "== %s (%s) ==\n", l.Name, describeRange(l.Summary.Meta))
+		RenderText(w, l.Summary, opts)
+	}
+}
+
+type jsonLayer struct {
+	Name string `json:"name"`
+	jsonOutput
+}
+
+// RenderLayersJSON writes the layers as a JSON object with a "layers" array;
+// each element has the same shape as RenderJSON's output plus a "name".
+func RenderLayersJSON(w io.Writer, layers []Layer) error {
+	out := struct {
+		Layers []jsonLayer `json:"layers"`
+	}{Layers: make([]jsonLayer, 0, len(layers))}
+	for _, l := range layers {
+		out.Layers = append(out.Layers, jsonLayer{Name: l.Name, jsonOutput: buildJSON(l.Summary)})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// CombineLayers adds up the totals of layers, overall and per category,
+// into a summary without files, such as the grand total of a
+// multi-repository report.
+func CombineLayers(layers []Layer) Summary {
+	total := Summary{CategoryTotals: make(map[string]CategoryTotal)}
+	for _, l := range layers {
+		total.Totals = addTotals(total.Totals, l.Summary.Totals)
+		for cat, ct := range l.Summary.CategoryTotals {
+			total.CategoryTotals[cat] = addTotals(total.CategoryTotals[cat], ct)
+		}
+	}
+	return total
+}
+
+func addTotals(a, b CategoryTotal) CategoryTotal {
+	return CategoryTotal{
+		Added:      a.Added + b.Added,
+		Deleted:    a.Deleted + b.Deleted,
+		Churn:      a.Churn + b.Churn,
+		Moved:      a.Moved + b.Moved,
+		RawAdded:   a.RawAdded + b.RawAdded,
+		RawDeleted: a.RawDeleted + b.RawDeleted,
+		FileCount:  a.FileCount + b.FileCount,
+	}
+}
+
+// RenderMultiText writes each repository as a layer, followed by the grand
+// total of all of them.
+func RenderMultiText(w io.Writer, repos []Layer, opts Options) {
+	RenderLayersText(w, repos, opts)
+	if len(repos) > 0 {
+		fmt.Fprintln(w)
+	}
+	word := "repositories"
+	if len(repos) == 1 {
+		word = "repository"
+	}
+	fmt.Fprintf(w, "== Total (%d %s) ==\n", len(repos), word)
+	renderSummary(w, CombineLayers(repos), opts)
+}
+
+// RenderMultiJSON writes a JSON object with a "repos" array, each element
+// shaped like RenderJSON's output plus a "name", and the grand "total" and
+// "by_category" of all repositories.
+func RenderMultiJSON(w io.Writer, repos []Layer) error {
+	combined := CombineLayers(repos)
+	out := struct {
+		Repos      []jsonLayer          `json:"repos"`
+		Total      jsonTotal            `json:"total"`
+		ByCategory map[string]jsonTotal `json:"by_category"`
+	}{
+		Repos:      make([]jsonLayer, 0, len(repos)),
+		Total:      toJSONTotal(combined.Totals),
+		ByCategory: make(map[string]jsonTotal, len(combined.CategoryTotals)),
+	}
+	for _, r := range repos {
+		out.Repos = append(out.Repos, jsonLayer{Name: r.Name, jsonOutput: buildJSON(r.Summary)})
+	}
+	for cat, ct := range combined.CategoryTotals {
+		out.ByCategory[cat] = toJSONTotal(ct)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func describeRange(m Meta) string {
+	if m.Head == "" {
+		return m.Base
+	}
+	return m.Base + "..." + m.Head
+}
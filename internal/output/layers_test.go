@@ -0,0 +1,114 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testLayers() []Layer {
+	a := testSummary()
+	a.Meta.Base, a.Meta.Head = "main", "feat-a"
+	b := Summary{
+		Totals:         CategoryTotal{Added: 3, Deleted: 1, Churn: 4, FileCount: 1},
+		CategoryTotals: map[string]CategoryTotal{"source": {Added: 3, Deleted: 1, Churn: 4, FileCount: 1}},
+		FileStats:      []FileStat{{Path: "b.go", Added: 3, Deleted: 1, Churn: 4, Category: "source"}},
+		Meta:           Meta{Base: "feat-a", Head: "feat-b"},
+	}
+	return []Layer{{Name: "feat-a", Summary: a}, {Name: "feat-b", Summary: b}}
+}
+
+func TestRenderLayersText(t *testing.T) {
+	var buf bytes.Buffer
+	RenderLayersText(&buf, testLayers(), Options{NoColor: true})
+	got := buf.String()
+
+	aIdx := strings.Index(got, "== feat-a (main...feat-a) ==")
+	bIdx := strings.Index(got, "== feat-b (feat-a...feat-b) ==")
+	if aIdx < 0 || bIdx < 0 || aIdx > bIdx {
+		t.Fatalf("expected layer headings in order, got:\n%s", got)
+	}
+	if !strings.Contains(got[bIdx:], "Total:         +3 -1 (4) [1 file]") {
+		t.Errorf("expected feat-b total line, got:\n%s", got[bIdx:])
+	}
+}
+
+func TestRenderLayersJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderLayersJSON(&buf, testLayers()); err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Layers []map[string]interface{} `json:"layers"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(result.Layers) != 2 {
+		t.Fatalf("expected 2 layers, got %d", len(result.Layers))
+	}
+	if result.Layers[1]["name"] != "feat-b" {
+		t.Errorf("layer name = %v", result.Layers[1]["name"])
+	}
+	for _, key := range []string{"meta", "total", "by_category", "by_file"} {
+		if _, ok := result.Layers[0][key]; !ok {
+			t.Errorf("missing key %q in layer", key)
+		}
+	}
+}
+
+func TestCombineLayers(t *testing.T) {
+	got := CombineLayers(testLayers())
+	a := testSummary()
+	if got.Totals.Churn != a.Totals.Churn+4 || got.Totals.FileCount != a.Totals.FileCount+1 {
+		t.Errorf("totals = %+v, want the sum of both layers", got.Totals)
+	}
+	if got.CategoryTotals["source"].Added != a.CategoryTotals["source"].Added+3 {
+		t.Errorf("source = %+v, want the sum of both layers", got.CategoryTotals["source"])
+	}
+	if len(got.FileStats) != 0 {
+		t.Errorf("files = %v, want none", got.FileStats)
+	}
+}
+
+func TestRenderMultiText(t *testing.T) {
+	var buf bytes.Buffer
+	RenderMultiText(&buf, testLayers(), Options{NoColor: true, List: true})
+	got := buf.String()
+
+	idx := strings.Index(got, "== Total (2 repositories) ==")
+	if idx < 0 || idx < strings.Index(got, "== feat-b") {
+		t.Fatalf("expected the grand total after the repositories, got:\n%s", got)
+	}
+	if strings.Contains(got[idx:], "b.go") {
+		t.Errorf("grand total lists files:\n%s", got[idx:])
+	}
+}
+
+func TestRenderMultiJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderMultiJSON(&buf, testLayers()); err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Repos []struct {
+			Name  string    `json:"name"`
+			Total jsonTotal `json:"total"`
+		} `json:"repos"`
+		Total      jsonTotal            `json:"total"`
+		ByCategory map[string]jsonTotal `json:"by_category"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(result.Repos) != 2 || result.Repos[1].Name != "feat-b" || result.Repos[1].Total.Churn != 4 {
+		t.Errorf("repos = %+v", result.Repos)
+	}
+	if result.Total.Churn != result.Repos[0].Total.Churn+result.Repos[1].Total.Churn {
+		t.Errorf("total = %+v, want the sum of the repos", result.Total)
+	}
+	if _, ok := result.ByCategory["source"]; !ok {
+		t.Errorf("by_category = %v, want source", result.ByCategory)
+	}
+}
@@ -0,0 +1,37 @@
+package output
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ExpandLink fills a per-file URL template. {path} is replaced with the
+// file path and {base} and {head} with the compared refs, each escaped
+// segment by segment so slashes are kept (as in GitHub's blob/<ref>/<path>).
+func ExpandLink(template, path, base, head string) string {
+	return strings.NewReplacer(
+		"{path}", escapeSegments(path),
+		"{base}", escapeSegments(base),
+		"{head}", escapeSegments(head),
+	).Replace(template)
+}
+
+// escapeSegments path-escapes each slash-separated segment of s.
+func escapeSegments(s string) string {
+	segments := strings.Split(s, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// SetLinks sets the Link of every file in summary from template, using the
+// refs in summary.Meta. An empty template leaves links unset.
+func SetLinks(summary *Summary, template string) {
+	if template == "" {
+		return
+	}
+	for i := range summary.FileStats {
+		summary.FileStats[i].Link = ExpandLink(template, summary.FileStats[i].Path, summary.Meta.Base, summary.Meta.Head)
+	}
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestExpandLink(t *testing.T) {
+	tests := []struct {
+		template, path, base, head string
+		want                       string
+	}{
+		{"https://github.com/o/r/blob/{head}/{path}", "internal/foo/bar.go", "main", "abc123", "https://github.com/o/r/blob/abc123/internal/foo/bar.go"},
+		{"https://example.com/compare/{base}...{head}#{path}", "a.go", "v1.0", "HEAD", "https://example.com/compare/v1.0...HEAD#a.go"},
+		// Path segments are escaped, but slashes are kept.
+		{"https://x/{path}", "docs/my file#1.md", "", "", "https://x/docs/my%20file%231.md"},
+		{"https://x/blob/{head}/{path}", "a.go", "", "feature/x", "https://x/blob/feature/x/a.go"},
+	}
+	for _, tt := range tests {
+		if got := ExpandLink(tt.template, tt.path, tt.base, tt.head); got != tt.want {
+			t.Errorf("ExpandLink(%q, %q) = %q, want %q", tt.template, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRenderJSONLinks(t *testing.T) {
+	s := testSummary()
+	SetLinks(&s, "https://example.com/{head}/{path}")
+
+	var buf bytes.Buffer
+	RenderJSON(&buf, s)
+	var result struct {
+		ByFile []jsonFile `json:"by_file"`
+	}
+	json.Unmarshal(buf.Bytes(), &result)
+	if got := result.ByFile[0].Link; got != "https://example.com/HEAD/internal/foo/bar.go" {
+		t.Errorf("by_file[0].link = %q", got)
+	}
+
+	// Without a template the field is omitted.
+	buf.Reset()
+	RenderJSON(&buf, testSummary())
+	if bytes.Contains(buf.Bytes(), []byte(`"link"`)) {
+		t.Errorf("unexpected link field without a template:\n%s", buf.String())
+	}
+}
@@ -1,11 +1,20 @@
 package output
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"os"
+	"path"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 )
 
 // Category display names and their corresponding internal keys.
@@ -16,10 +25,44 @@ var categoryOrder = []struct {
 	{"docs", "Documentation"},
 	{"tests", "Tests"},
 	{"source", "Source"},
+	{"ci", "CI & Build"},
+	{"i18n", "Localization"},
 	{"generated", "Generated"},
 	{"other", "Uncategorized"},
 }
 
+// DefaultIcons maps category keys to the icons text and Markdown output put
+// before them when Options.Icons is set to it.
+var DefaultIcons = map[string]string{
+	"docs":      "📝",
+	"tests":     "🧪",
+	"source":    "💻",
+	"ci":        "🔧",
+	"i18n":      "🌐",
+	"generated": "🤖",
+	"other":     "📦",
+}
+
+// DisplayOrder returns the category keys in the order reports list them.
+func DisplayOrder() []string {
+	keys := make([]string, len(categoryOrder))
+	for i, c := range categoryOrder {
+		keys[i] = c.key
+	}
+	return keys
+}
+
+// DisplayName returns the label reports use for a category, or the key
+// itself for categories without one.
+func DisplayName(key string) string {
+	for _, c := range categoryOrder {
+		if c.key == key {
+			return c.display
+		}
+	}
+	return key
+}
+
 const (
 	addColor   = "\033[32m"
 	delColor   = "\033[31m"
@@ -28,29 +71,290 @@ const (
 
 // FileStat holds per-file statistics with classification info.
 type FileStat struct {
-	Path     string
-	Added    int
-	Deleted  int
-	Churn    int
-	Category string
-	Language string
+	Path    string
+	Added   int
+	Deleted int
+	Churn   int
+	Moved   int // lines excluded from Added/Deleted as moved between files
+	// RawAdded and RawDeleted count changed lines including comment-only
+	// and blank ones, when only meaningful lines are counted
+	// (Meta.Count); zero otherwise.
+	RawAdded   int
+	RawDeleted int
+	Category   string
+	Language   string
+	Link       string // external URL for the file, if a link template is set
+	OldPath    string // previous path if the file was renamed
+	// OldMode and NewMode are the file's git modes, such as 100644 and
+	// 100755, if the change altered its mode.
+	OldMode string
+	NewMode string
+	// Status is StatusMode, StatusSymlink, or StatusSubmodule for changes
+	// that are not about lines, otherwise empty.
+	Status string
+	// Commits is how many commits in the range changed the file, when
+	// counted; zero otherwise.
+	Commits int
+	// Created and LastModified are the dates the file was added and last
+	// changed before the range, LastCommit the commit of that last change,
+	// when looked up; zero for files the range adds.
+	Created      time.Time
+	LastCommit   string
+	LastModified time.Time
+}
+
+// File statuses.
+const (
+	// StatusMode is a file whose mode, such as the executable bit, changed
+	// while its content did not.
+	StatusMode = "mode"
+	// StatusSymlink is a symbolic link; its lines are its target.
+	StatusSymlink = "symlink"
+	// StatusSubmodule is a submodule whose pointer changed; its commits
+	// are listed in Meta.Submodules and its lines are not counted.
+	StatusSubmodule = "submodule"
+)
+
+// Net returns the lines the file grew by: added minus deleted.
+func (f FileStat) Net() int { return f.Added - f.Deleted }
+
+// Note describes a file's status, mode change, and commit count for the
+// file list, e.g. "mode 100644 -> 100755", "symlink", or "submodule, 3
+// commits"; empty for plain content changes.
+func (f FileStat) Note() string {
+	var parts []string
+	if f.Status == StatusSymlink || f.Status == StatusSubmodule {
+		parts = append(parts, f.Status)
+	}
+	if f.OldMode != "" && f.NewMode != "" {
+		parts = append(parts, "mode "+f.OldMode+" -> "+f.NewMode)
+	}
+	if f.Commits > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", f.Commits, commitWord(f.Commits)))
+	}
+	return strings.Join(parts, ", ")
 }
 
 // CategoryTotal holds aggregate stats for a category.
 type CategoryTotal struct {
-	Added     int
-	Deleted   int
-	Churn     int
-	FileCount int
+	Added      int
+	Deleted    int
+	Churn      int
+	Moved      int
+	RawAdded   int // see FileStat.RawAdded
+	RawDeleted int
+	FileCount  int
+}
+
+// Net returns the lines the category grew by: added minus deleted.
+func (c CategoryTotal) Net() int { return c.Added - c.Deleted }
+
+// Now returns the time reports are stamped with: the current UTC time, or
+// the time in SOURCE_DATE_EPOCH (seconds since the Unix epoch) when it is
+// set, so that reproducible builds and snapshot tests get byte-identical
+// output. An unparsable SOURCE_DATE_EPOCH is ignored.
+func Now() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now().UTC()
 }
 
 // Meta holds metadata about the diff operation.
 type Meta struct {
-	Base      string   `json:"base"`
-	Head      string   `json:"head"`
-	Empty     string   `json:"empty"`
-	Pathspecs []string `json:"pathspecs"`
-	Timestamp string   `json:"timestamp"`
+	Base             string   `json:"base"`
+	Head             string   `json:"head"`
+	Empty            string   `json:"empty"`
+	Pathspecs        []string `json:"pathspecs"`
+	Timestamp        string   `json:"timestamp"`
+	IgnoreWhitespace bool     `json:"ignore_whitespace,omitempty"`
+	DiffAlgorithm    string   `json:"diff_algorithm,omitempty"`
+	DetectMoves      bool     `json:"detect_moves,omitempty"`
+	// Count is CountMeaningful when comment-only and blank changed lines
+	// were left out of the counts, and empty when every line counted.
+	Count    string    `json:"count,omitempty"`
+	Warnings []Warning `json:"warnings,omitempty"`
+
+	// Worktree is the --worktree mode (auto, include, or exclude) of a
+	// comparison of refs; Head is WORKTREE when the working tree was used.
+	Worktree string `json:"worktree,omitempty"`
+	// MergeBase is whether Base and Head were compared from their merge
+	// base (base...head) or directly (base..head); nil unless two refs
+	// were compared.
+	MergeBase *bool `json:"merge_base,omitempty"`
+
+	// WorktreeBreakdown splits a comparison against the working tree into
+	// what is committed on the branch, staged, and unstaged.
+	WorktreeBreakdown *WorktreeBreakdown `json:"worktree_breakdown,omitempty"`
+
+	// Relative is the directory, relative to the repository root, that
+	// file paths are relative to (--relative). Empty means the root.
+	Relative string `json:"relative,omitempty"`
+
+	// DependencyUpdate is set when every changed file is a dependency
+	// manifest or lockfile.
+	DependencyUpdate *DependencyUpdate `json:"dependency_update,omitempty"`
+
+	// APIChurn is set when public API churn was requested (--api-churn).
+	APIChurn *APIChurn `json:"api_churn,omitempty"`
+
+	// SchemaChanges lists structural changes to API schema files when
+	// requested (--schema-changes).
+	SchemaChanges []SchemaChange `json:"schema_changes,omitempty"`
+
+	// Submodules lists the submodules whose pointer moved.
+	Submodules []Submodule `json:"submodules,omitempty"`
+
+	// Infrastructure counts touched Terraform resources and Kubernetes
+	// objects per kind.
+	Infrastructure []InfraKind `json:"infrastructure,omitempty"`
+
+	// Teams is the churn per team from the 'teams:' config, when requested
+	// (--by-team).
+	Teams []Team `json:"teams,omitempty"`
+
+	// Reference is the latest change merged into the base branch, set in
+	// auto mode so the current change can be judged against it.
+	Reference *Reference `json:"reference,omitempty"`
+
+	// Provenance records how the report was produced.
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// Provenance records what produced a report, so that it can be re-run and
+// audited later with `differ --reproduce`.
+type Provenance struct {
+	Version       string   `json:"version"`        // differ version
+	GitVersion    string   `json:"git_version"`    // empty when git was not run
+	DiffAlgorithm string   `json:"diff_algorithm"` // empty when git was not run
+	ConfigHash    string   `json:"config_hash"`    // sha256 of the effective config
+	Args          []string `json:"args"`           // command-line arguments
+	// BaseCommit and HeadCommit are the commits the refs resolved to, when
+	// two commits were compared; a reproduction compares them again even if
+	// the refs have moved since.
+	BaseCommit string `json:"base_commit,omitempty"`
+	HeadCommit string `json:"head_commit,omitempty"`
+}
+
+// Reference is the churn of the latest change merged into a base branch:
+// the diff between the latest merge commit on its first-parent history and
+// that commit's first parent or, when the branch has no merges, as with
+// squash or rebase merges, between its tip and the tip's parent.
+type Reference struct {
+	Base   string `json:"base"`
+	Commit string `json:"commit"`
+	// Kind is ReferenceMerge or ReferenceCommit.
+	Kind       string         `json:"kind"`
+	Churn      int            `json:"churn"`
+	Files      int            `json:"files"`
+	ByCategory map[string]int `json:"by_category"` // churn per category
+}
+
+// Reference kinds.
+const (
+	// ReferenceMerge is a merge commit on the base branch.
+	ReferenceMerge = "merge"
+	// ReferenceCommit is the tip of a base branch without merges.
+	ReferenceCommit = "commit"
+)
+
+// WorktreeBreakdown is the churn of a comparison against the working tree
+// in three separately diffed buckets: base to HEAD, HEAD to the index, and
+// the index to the working tree. A line changed in more than one of them
+// counts in each, so the buckets need not add up to the report's totals.
+type WorktreeBreakdown struct {
+	Committed WorktreeBucket `json:"committed"`
+	Staged    WorktreeBucket `json:"staged"`
+	Unstaged  WorktreeBucket `json:"unstaged"`
+}
+
+// WorktreeBucket is the churn of one part of a WorktreeBreakdown.
+type WorktreeBucket struct {
+	Totals         CategoryTotal            `json:"totals"`
+	CategoryTotals map[string]CategoryTotal `json:"category_totals"`
+}
+
+// Team is the churn of the files a team owns. A file owned by several
+// teams counts for each, so teams need not add up to the report's totals.
+type Team struct {
+	Name           string                   `json:"name"`
+	Totals         CategoryTotal            `json:"totals"`
+	CategoryTotals map[string]CategoryTotal `json:"category_totals"`
+}
+
+// Submodule is a submodule whose pointer a change moved from OldCommit to
+// NewCommit. A commit is empty on the side where the submodule did not
+// exist.
+type Submodule struct {
+	Path      string `json:"path"`
+	OldCommit string `json:"old_commit,omitempty"`
+	NewCommit string `json:"new_commit,omitempty"`
+	// Totals and CategoryTotals are the churn between the two commits
+	// inside the submodule, set when it was analyzed (--submodules
+	// recurse).
+	Totals         *CategoryTotal           `json:"totals,omitempty"`
+	CategoryTotals map[string]CategoryTotal `json:"category_totals,omitempty"`
+}
+
+// InfraKind is how many resources of one kind a change adds, changes, or
+// removes.
+type InfraKind struct {
+	Kind    string `json:"kind"`
+	Added   int    `json:"added"`
+	Changed int    `json:"changed"`
+	Removed int    `json:"removed"`
+}
+
+// SchemaChange is the structural difference in one OpenAPI, GraphQL, or
+// protobuf schema file.
+type SchemaChange struct {
+	Path    string   `json:"path"`
+	Format  string   `json:"format"` // openapi, graphql, or proto
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// APIChurn is the part of a change that touches exported Go declarations.
+type APIChurn struct {
+	Lines   int         `json:"lines"` // changed lines within exported declarations
+	Symbols []APISymbol `json:"symbols"`
+}
+
+// APISymbol is an exported declaration touched by a change.
+type APISymbol struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`   // func, method, type, const, or var
+	Name   string `json:"name"`   // e.g. "Parse" or "Config.Load"
+	Change string `json:"change"` // added, removed, or changed
+	Lines  int    `json:"lines"`
+}
+
+// Warning severities.
+const (
+	// SeverityWarning flags something in the change that needs attention,
+	// such as a migration that drops a table.
+	SeverityWarning = "warning"
+	// SeverityInfo notes a limit on the analysis, such as a sparse
+	// checkout or a summary that could not be computed.
+	SeverityInfo = "info"
+)
+
+// Warning is a non-fatal issue found while analyzing a change. Warnings about
+// a specific added line carry its Path and Line.
+type Warning struct {
+	Severity string `json:"severity"`
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// DependencyUpdate describes a change made up only of dependency manifest and
+// lockfile updates.
+type DependencyUpdate struct {
+	Bumped   int      `json:"bumped"`   // distinct packages bumped or added
+	Packages []string `json:"packages"` // their names, sorted
 }
 
 // Summary holds the complete output data.
@@ -61,16 +365,68 @@ type Summary struct {
 	Meta           Meta
 }
 
-// OutputOpts controls text rendering behavior.
-type OutputOpts struct {
+// Options controls rendering. Formats ignore the fields that do not apply
+// to them: JSON, for example, has no colors or summary-only mode.
+type Options struct {
 	List     bool
 	ListOnly bool
-	Sort     string // "churn" (default) or "path"
+	Sort     string // one of SortModes; "churn" by default
 	NoColor  bool
+	// Net adds each row's net lines (added minus deleted) to text output.
+	Net bool
+	// Top limits the text file list to the Top highest-churn files of each
+	// group, or of the whole list with TopGlobal. Zero lists every file.
+	Top       int
+	TopGlobal bool
+	// GroupBy is how the text file list is grouped, one of GroupModes;
+	// "category" by default.
+	GroupBy string
+	// JSONCategories is how JSON output writes by_category, one of
+	// JSONCategoryModes; JSONCategoriesMap by default.
+	JSONCategories string
+	// Compact writes JSON output on a single line, without indentation.
+	Compact bool
+	// NoHeader leaves the header row out of table and CSV output.
+	NoHeader bool
+	// Icons maps category keys to an icon that text and Markdown output
+	// show before the category's name, such as DefaultIcons. Categories
+	// without one, and all of them when Icons is nil, are shown plainly.
+	Icons map[string]string
 }
 
+// SortModes lists the accepted file list orderings.
+var SortModes = []string{"churn", "path", "net", "added", "deleted", "language", "category"}
+
+// GroupModes lists the accepted file list groupings.
+var GroupModes = []string{"category", "dir", "language", "none"}
+
+// Ways to count changed lines: every line, or only meaningful ones, leaving
+// out comment-only and blank lines.
+const (
+	CountLines      = "lines"
+	CountMeaningful = "meaningful"
+)
+
+// CountModes lists the accepted ways to count changed lines.
+var CountModes = []string{CountLines, CountMeaningful}
+
+// Ways JSON output can write by_category: an object keyed by category, or
+// an array of objects, each naming its category, in report order.
+const (
+	JSONCategoriesMap   = "map"
+	JSONCategoriesArray = "array"
+)
+
+// JSONCategoryModes lists the accepted by_category layouts.
+var JSONCategoryModes = []string{JSONCategoriesMap, JSONCategoriesArray}
+
+// JSONSchemaVersion is the version of the JSON report's layout, written as
+// its schema_version. It is raised when a field is renamed, removed, or
+// changes meaning; adding fields leaves it as is.
+const JSONSchemaVersion = 1
+
 // RenderText writes the human-readable text output to w.
-func RenderText(w io.Writer, summary Summary, opts OutputOpts) {
+func RenderText(w io.Writer, summary Summary, opts Options) {
 	if !opts.ListOnly {
 		renderSummary(w, summary, opts)
 	}
@@ -83,52 +439,293 @@ func RenderText(w io.Writer, summary Summary, opts OutputOpts) {
 	}
 }
 
-func renderSummary(w io.Writer, summary Summary, opts OutputOpts) {
-	labelWidth, addWidth, delWidth, churnWidth := summaryWidths(summary)
+func renderSummary(w io.Writer, summary Summary, opts Options) {
+	labelWidth, addWidth, delWidth, churnWidth := summaryWidths(summary, opts.Icons)
+	netWidth := 0
+	if opts.Net {
+		netWidth = len(fmt.Sprintf("%+d", summary.Totals.Net()))
+		for _, ct := range summary.CategoryTotals {
+			netWidth = max(netWidth, len(fmt.Sprintf("%+d", ct.Net())))
+		}
+	}
 
+	type row struct {
+		key  string // category key, or "" for the total
+		text string
+		ct   CategoryTotal
+	}
+	var rows []row
+	add := func(key, label string, ct CategoryTotal) {
+		gap := strings.Repeat(" ", labelWidth-displayWidth(label)+1)
+		net := ""
+		if opts.Net {
+			net = formatNet(ct.Net(), netWidth, opts.NoColor) + " "
+		}
+		rows = append(rows, row{key, fmt.Sprintf("%s:%s%s (%*d) %s[%d %s]",
+			label, gap, formatAddDel(ct.Added, ct.Deleted, addWidth, delWidth, opts.NoColor), churnWidth, ct.Churn, net, ct.FileCount, fileWord(ct.FileCount)), ct})
+	}
 	for _, cat := range categoryOrder {
 		ct, ok := summary.CategoryTotals[cat.key]
-		if !ok || ct.Churn == 0 {
+		if !ok || ct.FileCount == 0 {
 			continue
 		}
-		gap := strings.Repeat(" ", labelWidth-len(cat.display)+1)
-		fmt.Fprintf(w, "%s:%s%s (%*d) [%d %s]\n",
-			cat.display, gap, formatAddDel(ct.Added, ct.Deleted, addWidth, delWidth, opts.NoColor), churnWidth, ct.Churn, ct.FileCount, fileWord(ct.FileCount))
+		add(cat.key, iconLabel(opts.Icons, cat.key, cat.display), ct)
 	}
-
 	t := summary.Totals
-	gap := strings.Repeat(" ", labelWidth-len("Total")+1)
-	fmt.Fprintf(w, "Total:%s%s (%*d) [%d %s]\n",
-		gap, formatAddDel(t.Added, t.Deleted, addWidth, delWidth, opts.NoColor), churnWidth, t.Churn, t.FileCount, fileWord(t.FileCount))
+	add("", "Total", t)
+
+	// With a reference change, each row ends with its churn in that change
+	// and the difference, aligned after the longest row.
+	ref := summary.Meta.Reference
+	label := "last merge"
+	if ref != nil && ref.Kind == ReferenceCommit {
+		label = "last commit"
+	}
+	rowWidth := 0
+	for _, r := range rows {
+		rowWidth = max(rowWidth, visibleLen(r.text))
+	}
+	for _, r := range rows {
+		if ref == nil {
+			fmt.Fprintln(w, r.text)
+			continue
+		}
+		refChurn := ref.Churn
+		if r.key != "" {
+			refChurn = ref.ByCategory[r.key]
+		}
+		fmt.Fprintf(w, "%s%s  %s %*d (%s)\n", r.text, strings.Repeat(" ", rowWidth-visibleLen(r.text)),
+			label, digitWidth(ref.Churn), refChurn, formatSigned(r.ct.Churn-refChurn, opts.NoColor))
+	}
+	if ref != nil {
+		if ref.Kind == ReferenceCommit {
+			fmt.Fprintf(w, "Last commit: %s on %s, %d %s in %d %s\n", shortSHA(ref.Commit), ref.Base, ref.Churn, lineWord(ref.Churn), ref.Files, fileWord(ref.Files))
+		} else {
+			fmt.Fprintf(w, "Last merge: %s into %s, %d %s in %d %s\n", shortSHA(ref.Commit), ref.Base, ref.Churn, lineWord(ref.Churn), ref.Files, fileWord(ref.Files))
+		}
+	}
+	if wb := summary.Meta.WorktreeBreakdown; wb != nil {
+		renderWorktreeBreakdown(w, wb, opts.NoColor)
+	}
+	if t.Moved > 0 {
+		fmt.Fprintf(w, "Moved: %d %s between files, not counted as churn\n", t.Moved, lineWord(t.Moved))
+	}
+	if summary.Meta.Count == CountMeaningful {
+		skipped := t.RawAdded + t.RawDeleted - t.Added - t.Deleted - t.Moved
+		fmt.Fprintf(w, "Meaningful lines only: %d comment-only or blank %s not counted (raw %s)\n",
+			skipped, lineWord(skipped), formatAddDel(t.RawAdded, t.RawDeleted, 0, 0, opts.NoColor))
+	}
+
+	if locales := localeTotals(summary.FileStats); len(locales) > 0 {
+		parts := make([]string, 0, len(locales))
+		for _, l := range locales {
+			parts = append(parts, fmt.Sprintf("%s %s", l.locale, formatAddDel(l.Added, l.Deleted, 0, 0, opts.NoColor)))
+		}
+		fmt.Fprintf(w, "Locales: %s\n", strings.Join(parts, ", "))
+	}
+
+	if api := summary.Meta.APIChurn; api != nil {
+		renderAPIChurn(w, api)
+	}
+
+	if len(summary.Meta.Infrastructure) > 0 {
+		renderInfrastructure(w, summary.Meta.Infrastructure)
+	}
+
+	if len(summary.Meta.SchemaChanges) > 0 {
+		renderSchemaChanges(w, summary.Meta.SchemaChanges)
+	}
+
+	if len(summary.Meta.Submodules) > 0 {
+		renderSubmodules(w, summary.Meta.Submodules, opts.NoColor)
+	}
+
+	if len(summary.Meta.Teams) > 0 {
+		renderTeams(w, summary.Meta.Teams, opts.NoColor)
+	}
+
+	if dep := summary.Meta.DependencyUpdate; dep != nil {
+		fmt.Fprintf(w, "Dependency update: %s\n", describeDependencyUpdate(dep))
+	}
+
+	if len(summary.Meta.Warnings) > 0 {
+		fmt.Fprintln(w, "Warnings:")
+		for _, warn := range sortedWarnings(summary.Meta.Warnings) {
+			if warn.Path != "" {
+				fmt.Fprintf(w, "  %s: %s:%d: %s (%s)\n", severity(warn), warn.Path, warn.Line, warn.Message, warn.Rule)
+			} else {
+				fmt.Fprintf(w, "  %s: %s (%s)\n", severity(warn), warn.Message, warn.Rule)
+			}
+		}
+	}
+}
+
+// severity returns the severity of w, treating an unset one as a warning.
+func severity(w Warning) string {
+	if w.Severity == "" {
+		return SeverityWarning
+	}
+	return w.Severity
+}
+
+// sortedWarnings orders warnings before infos, keeping the order in which
+// they were found otherwise.
+func sortedWarnings(warnings []Warning) []Warning {
+	sorted := make([]Warning, len(warnings))
+	copy(sorted, warnings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return severity(sorted[i]) == SeverityWarning && severity(sorted[j]) != SeverityWarning
+	})
+	return sorted
 }
 
-func renderFileList(w io.Writer, summary Summary, opts OutputOpts) {
+func renderFileList(w io.Writer, summary Summary, opts Options) {
 	sorted := make([]FileStat, len(summary.FileStats))
 	copy(sorted, summary.FileStats)
 	sortFiles(sorted, opts.Sort)
+	omitted := 0
+	if opts.Top > 0 && opts.TopGlobal {
+		sorted, omitted = topFiles(sorted, opts.Top)
+	}
 	addWidth, delWidth := fileWidths(sorted)
-
-	// Group by category in display order.
-	grouped := make(map[string][]FileStat)
-	for _, f := range sorted {
-		grouped[f.Category] = append(grouped[f.Category], f)
+	netWidth := 0
+	if opts.Net {
+		for _, f := range sorted {
+			netWidth = max(netWidth, len(fmt.Sprintf("%+d", f.Net())))
+		}
 	}
 
-	first := true
-	for _, cat := range categoryOrder {
-		files, ok := grouped[cat.key]
-		if !ok || len(files) == 0 {
-			continue
-		}
-		if !first {
+	for i, g := range groupFiles(sorted, opts.GroupBy, opts.Icons) {
+		if i > 0 {
 			fmt.Fprintln(w)
 		}
-		first = false
+		if g.subtotal {
+			var t CategoryTotal
+			for _, f := range g.files {
+				t.Added += f.Added
+				t.Deleted += f.Deleted
+				t.Churn += f.Churn
+			}
+			fmt.Fprintf(w, "[%s] %s (%d) [%d %s]\n", g.label, formatAddDel(t.Added, t.Deleted, 0, 0, opts.NoColor), t.Churn, len(g.files), fileWord(len(g.files)))
+		} else if g.label != "" {
+			fmt.Fprintf(w, "[%s]\n", g.label)
+		}
+		files, catOmitted := g.files, 0
+		if opts.Top > 0 && !opts.TopGlobal {
+			files, catOmitted = topFiles(files, opts.Top)
+		}
+		for _, f := range files {
+			net := ""
+			if opts.Net {
+				net = formatNet(f.Net(), netWidth, opts.NoColor) + " "
+			}
+			note := ""
+			if n := f.Note(); n != "" {
+				note = " (" + n + ")"
+			}
+			fmt.Fprintf(w, "%s %s%s%s\n", formatAddDel(f.Added, f.Deleted, addWidth, delWidth, opts.NoColor), net, f.Path, note)
+		}
+		renderOmitted(w, catOmitted)
+	}
+	if omitted > 0 {
+		fmt.Fprintln(w)
+		renderOmitted(w, omitted)
+	}
+}
+
+// fileGroup is one headed section of the text file list.
+type fileGroup struct {
+	label    string // heading; empty for an ungrouped list
+	subtotal bool   // whether the heading carries the group's totals
+	files    []FileStat
+}
 
-		fmt.Fprintf(w, "[%s]\n", cat.display)
+// groupFiles splits sorted files into the groups of mode, one of
+// GroupModes, keeping their order within each group. Categories follow
+// display order, labeled with their icons; directories and languages are
+// alphabetical, with files that have no language last. Unknown modes group
+// by category.
+func groupFiles(files []FileStat, mode string, icons map[string]string) []fileGroup {
+	var keyOf func(FileStat) string
+	switch mode {
+	case "none":
+		return []fileGroup{{files: files}}
+	case "dir":
+		keyOf = func(f FileStat) string { return path.Dir(f.Path) }
+	case "language":
+		keyOf = func(f FileStat) string { return f.Language }
+	default:
+		grouped := make(map[string][]FileStat)
 		for _, f := range files {
-			fmt.Fprintf(w, "%s %s\n", formatAddDel(f.Added, f.Deleted, addWidth, delWidth, opts.NoColor), f.Path)
+			grouped[f.Category] = append(grouped[f.Category], f)
+		}
+		var groups []fileGroup
+		for _, cat := range categoryOrder {
+			if len(grouped[cat.key]) > 0 {
+				groups = append(groups, fileGroup{label: iconLabel(icons, cat.key, cat.display), files: grouped[cat.key]})
+			}
 		}
+		return groups
+	}
+
+	grouped := make(map[string][]FileStat)
+	var keys []string
+	for _, f := range files {
+		key := keyOf(f)
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], f)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		// An empty language sorts last.
+		if (keys[i] == "") != (keys[j] == "") {
+			return keys[j] == ""
+		}
+		return keys[i] < keys[j]
+	})
+	groups := make([]fileGroup, 0, len(keys))
+	for _, key := range keys {
+		label := key
+		switch {
+		case mode == "dir" && key == ".":
+			label = "(root)"
+		case mode == "dir":
+			label = key + "/"
+		case key == "":
+			label = "(no language)"
+		}
+		groups = append(groups, fileGroup{label: label, subtotal: true, files: grouped[key]})
+	}
+	return groups
+}
+
+// topFiles keeps the n files of files with the most churn, in their
+// original order, and returns how many it left out.
+func topFiles(files []FileStat, n int) ([]FileStat, int) {
+	if len(files) <= n {
+		return files, 0
+	}
+	ranked := make([]FileStat, len(files))
+	copy(ranked, files)
+	sortFiles(ranked, "churn")
+	keep := make(map[string]bool, n)
+	for _, f := range ranked[:n] {
+		keep[f.Path] = true
+	}
+	kept := make([]FileStat, 0, n)
+	for _, f := range files {
+		if keep[f.Path] {
+			kept = append(kept, f)
+		}
+	}
+	return kept, len(files) - len(kept)
+}
+
+// renderOmitted notes the files a --top limit left out of the list.
+func renderOmitted(w io.Writer, omitted int) {
+	if omitted > 0 {
+		fmt.Fprintf(w, "… and %d more %s\n", omitted, fileWord(omitted))
 	}
 }
 
@@ -139,18 +736,23 @@ func formatAddDel(added, deleted, addWidth, delWidth int, noColor bool) string {
 	return fmt.Sprintf("%s+%*d%s %s-%*d%s", addColor, addWidth, added, resetColor, delColor, delWidth, deleted, resetColor)
 }
 
-func summaryWidths(summary Summary) (labelWidth, addWidth, delWidth, churnWidth int) {
+// formatNet renders net lines as "net +12", right-aligning the number to
+// width.
+func formatNet(net, width int, noColor bool) string {
+	pad := width - len(fmt.Sprintf("%+d", net))
+	return "net " + strings.Repeat(" ", max(pad, 0)) + formatSigned(net, noColor)
+}
+
+func summaryWidths(summary Summary, icons map[string]string) (labelWidth, addWidth, delWidth, churnWidth int) {
 	labelWidth = len("Total")
 	addWidth = digitWidth(summary.Totals.Added)
 	delWidth = digitWidth(summary.Totals.Deleted)
 	churnWidth = digitWidth(summary.Totals.Churn)
 
 	for _, cat := range categoryOrder {
-		if len(cat.display) > labelWidth {
-			labelWidth = len(cat.display)
-		}
+		labelWidth = max(labelWidth, displayWidth(iconLabel(icons, cat.key, cat.display)))
 		ct, ok := summary.CategoryTotals[cat.key]
-		if !ok || ct.Churn == 0 {
+		if !ok || ct.FileCount == 0 {
 			continue
 		}
 		if w := digitWidth(ct.Added); w > addWidth {
@@ -192,6 +794,24 @@ func digitWidth(n int) int {
 	return width
 }
 
+// SummaryLine returns the totals of summary on one uncolored line, as the
+// Total row of the text report shows them.
+func SummaryLine(summary Summary) string {
+	t := summary.Totals
+	return fmt.Sprintf("Total: %s (%d) [%d %s]", formatAddDel(t.Added, t.Deleted, 0, 0, true), t.Churn, t.FileCount, fileWord(t.FileCount))
+}
+
+// DocsRatio returns the lines of docs churn per line of source churn in
+// summary, a measure of whether documentation keeps up with the code it
+// describes. ok is false when no source changed.
+func DocsRatio(summary Summary) (ratio float64, ok bool) {
+	source := summary.CategoryTotals["source"].Churn
+	if source == 0 {
+		return 0, false
+	}
+	return float64(summary.CategoryTotals["docs"].Churn) / float64(source), true
+}
+
 func fileWord(count int) string {
 	if count == 1 {
 		return "file"
@@ -199,65 +819,298 @@ func fileWord(count int) string {
 	return "files"
 }
 
+func commitWord(count int) string {
+	if count == 1 {
+		return "commit"
+	}
+	return "commits"
+}
+
+func lineWord(count int) string {
+	if count == 1 {
+		return "line"
+	}
+	return "lines"
+}
+
+// sortFiles orders files by sortMode, one of SortModes, and then by path.
+// Unknown modes sort by churn.
 func sortFiles(files []FileStat, sortMode string) {
-	switch strings.ToLower(sortMode) {
-	case "path":
-		sort.Slice(files, func(i, j int) bool {
-			return files[i].Path < files[j].Path
-		})
-	default: // "churn" or empty
-		sort.Slice(files, func(i, j int) bool {
-			if files[i].Churn != files[j].Churn {
-				return files[i].Churn > files[j].Churn
-			}
-			return files[i].Path < files[j].Path
-		})
+	compare, ok := fileOrders[strings.ToLower(sortMode)]
+	if !ok {
+		compare = fileOrders["churn"]
 	}
+	sort.Slice(files, func(i, j int) bool {
+		if c := compare(files[i], files[j]); c != 0 {
+			return c < 0
+		}
+		return files[i].Path < files[j].Path
+	})
+}
+
+// fileOrders compares two files for each sort mode. Counts sort largest
+// first; files without a language sort last.
+var fileOrders = map[string]func(a, b FileStat) int{
+	"churn":   func(a, b FileStat) int { return cmp.Compare(b.Churn, a.Churn) },
+	"path":    func(a, b FileStat) int { return 0 },
+	"net":     func(a, b FileStat) int { return cmp.Compare(b.Net(), a.Net()) },
+	"added":   func(a, b FileStat) int { return cmp.Compare(b.Added, a.Added) },
+	"deleted": func(a, b FileStat) int { return cmp.Compare(b.Deleted, a.Deleted) },
+	"language": func(a, b FileStat) int {
+		if (a.Language == "") != (b.Language == "") {
+			return cmp.Compare(b.Language, a.Language) // empty after any name
+		}
+		return cmp.Compare(a.Language, b.Language)
+	},
+	"category": func(a, b FileStat) int { return cmp.Compare(categoryRank(a.Category), categoryRank(b.Category)) },
+}
+
+// categoryRank is the position of category in display order; unknown
+// categories come last.
+func categoryRank(category string) int {
+	for i, c := range categoryOrder {
+		if c.key == category {
+			return i
+		}
+	}
+	return len(categoryOrder)
 }
 
 // jsonOutput is the top-level JSON structure.
 type jsonOutput struct {
-	Meta       jsonMeta                 `json:"meta"`
-	Total      jsonTotal                `json:"total"`
-	ByCategory map[string]jsonCatDetail `json:"by_category"`
-	ByFile     []jsonFile               `json:"by_file"`
+	jsonReport
+	ByFile []jsonFile `json:"by_file"`
+}
+
+// jsonReport is the part of jsonOutput before by_file, which RenderJSON
+// streams after it.
+type jsonReport struct {
+	SchemaVersion int                           `json:"schema_version,omitempty"` // top-level reports only
+	Meta          jsonMeta                      `json:"meta"`
+	Total         jsonTotal                     `json:"total"`
+	DocsRatio     *float64                      `json:"docs_ratio,omitempty"` // to 3 places; see DocsRatio
+	ByCategory    categoryValues[jsonCatDetail] `json:"by_category"`
+}
+
+// categoryValues is a by_category value: an object keyed by category or,
+// with asArray, an array of the values in report order, each with a
+// "category" key first.
+type categoryValues[T any] struct {
+	values  map[string]T
+	asArray bool
+}
+
+func byCategory[T any](values map[string]T) categoryValues[T] {
+	return categoryValues[T]{values: values}
+}
+
+func (c categoryValues[T]) MarshalJSON() ([]byte, error) {
+	if !c.asArray {
+		return json.Marshal(c.values)
+	}
+	cats := make([]string, 0, len(c.values))
+	for cat := range c.values {
+		cats = append(cats, cat)
+	}
+	sort.Slice(cats, func(i, j int) bool {
+		if ri, rj := categoryRank(cats[i]), categoryRank(cats[j]); ri != rj {
+			return ri < rj
+		}
+		return cats[i] < cats[j]
+	})
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, cat := range cats {
+		value, err := json.Marshal(c.values[cat])
+		if err != nil {
+			return nil, err
+		}
+		name, _ := json.Marshal(cat)
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`{"category":`)
+		buf.Write(name)
+		// value is an object; splice its fields in after the name.
+		if len(value) > 2 {
+			buf.WriteByte(',')
+		}
+		buf.Write(value[1:])
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
 }
 
 type jsonMeta struct {
-	Base      string   `json:"base"`
-	Head      string   `json:"head"`
-	Empty     string   `json:"empty"`
-	Pathspecs []string `json:"pathspecs"`
-	Timestamp string   `json:"timestamp"`
+	Base             string    `json:"base"`
+	Head             string    `json:"head"`
+	MergeBase        *bool     `json:"merge_base,omitempty"`
+	Worktree         string    `json:"worktree,omitempty"`
+	Empty            string    `json:"empty"`
+	IgnoreWhitespace bool      `json:"ignore_whitespace"`
+	DiffAlgorithm    string    `json:"diff_algorithm,omitempty"`
+	DetectMoves      bool      `json:"detect_moves,omitempty"`
+	Count            string    `json:"count,omitempty"`
+	Pathspecs        []string  `json:"pathspecs"`
+	Timestamp        string    `json:"timestamp"`
+	Warnings         []Warning `json:"warnings,omitempty"`
+	Relative         string    `json:"relative,omitempty"`
+
+	DependencyUpdate *DependencyUpdate `json:"dependency_update,omitempty"`
+	APIChurn         *APIChurn         `json:"api_churn,omitempty"`
+	SchemaChanges    []SchemaChange    `json:"schema_changes,omitempty"`
+	Submodules       []jsonSubmodule   `json:"submodules,omitempty"`
+	Infrastructure   []InfraKind       `json:"infrastructure,omitempty"`
+	Breakdown        *jsonBreakdown    `json:"worktree_breakdown,omitempty"`
+	Teams            []jsonTeam        `json:"teams,omitempty"`
+	Reference        *Reference        `json:"reference,omitempty"`
+	Provenance       *Provenance       `json:"provenance,omitempty"`
+}
+
+type jsonBreakdown struct {
+	Committed jsonBucket `json:"committed"`
+	Staged    jsonBucket `json:"staged"`
+	Unstaged  jsonBucket `json:"unstaged"`
+}
+
+type jsonBucket struct {
+	Total      jsonTotal                 `json:"total"`
+	ByCategory categoryValues[jsonTotal] `json:"by_category"`
+}
+
+type jsonTeam struct {
+	Name       string                    `json:"name"`
+	Total      jsonTotal                 `json:"total"`
+	ByCategory categoryValues[jsonTotal] `json:"by_category"`
+}
+
+type jsonSubmodule struct {
+	Path       string                     `json:"path"`
+	OldCommit  string                     `json:"old_commit,omitempty"`
+	NewCommit  string                     `json:"new_commit,omitempty"`
+	Total      *jsonTotal                 `json:"total,omitempty"`
+	ByCategory *categoryValues[jsonTotal] `json:"by_category,omitempty"`
 }
 
 type jsonTotal struct {
 	Added   int `json:"added"`
 	Deleted int `json:"deleted"`
 	Churn   int `json:"churn"`
-	Files   int `json:"files"`
+	Net     int `json:"net"`
+	Moved   int `json:"moved,omitempty"`
+	// Raw counts include comment-only and blank lines, when only
+	// meaningful lines were counted.
+	RawAdded   int `json:"raw_added,omitempty"`
+	RawDeleted int `json:"raw_deleted,omitempty"`
+	Files      int `json:"files"`
 }
 
 type jsonCatDetail struct {
-	Added     int      `json:"added"`
-	Deleted   int      `json:"deleted"`
-	Churn     int      `json:"churn"`
-	Files     []string `json:"files"`
-	FileCount int      `json:"file_count"`
+	Added      int      `json:"added"`
+	Deleted    int      `json:"deleted"`
+	Churn      int      `json:"churn"`
+	Net        int      `json:"net"`
+	Moved      int      `json:"moved,omitempty"`
+	RawAdded   int      `json:"raw_added,omitempty"`
+	RawDeleted int      `json:"raw_deleted,omitempty"`
+	Files      []string `json:"files"`
+	FileCount  int      `json:"file_count"`
+
+	Locales map[string]jsonTotal `json:"locales,omitempty"` // i18n only
 }
 
 type jsonFile struct {
-	Path     string `json:"path"`
-	Added    int    `json:"added"`
-	Deleted  int    `json:"deleted"`
-	Churn    int    `json:"churn"`
-	Category string `json:"category"`
-	Language string `json:"language"`
+	Path       string `json:"path"`
+	Added      int    `json:"added"`
+	Deleted    int    `json:"deleted"`
+	Churn      int    `json:"churn"`
+	Net        int    `json:"net"`
+	Moved      int    `json:"moved,omitempty"`
+	RawAdded   int    `json:"raw_added,omitempty"`
+	RawDeleted int    `json:"raw_deleted,omitempty"`
+	Category   string `json:"category"`
+	Language   string `json:"language"`
+	Link       string `json:"link,omitempty"`
+	OldPath    string `json:"old_path,omitempty"`
+	OldMode    string `json:"old_mode,omitempty"`
+	NewMode    string `json:"new_mode,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Commits    int    `json:"commits,omitempty"`
+
+	Created      string `json:"created,omitempty"`
+	LastCommit   string `json:"last_commit,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
 }
 
-// RenderJSON writes JSON output to w.
+// RenderJSON writes JSON output to w. The by_file list is written one file
+// at a time rather than built up in memory first, so the output of a huge
+// range needs no second copy of every file.
 func RenderJSON(w io.Writer, summary Summary) error {
-	byCategory := make(map[string]jsonCatDetail)
+	return renderJSON(w, summary, Options{})
+}
+
+// renderJSON is RenderJSON with the JSON layout opts selects.
+func renderJSON(w io.Writer, summary Summary, opts Options) error {
+	r := buildReport(summary, opts.JSONCategories == JSONCategoriesArray)
+	r.SchemaVersion = JSONSchemaVersion
+	// Each piece written below is indented as pretty-printed output nests
+	// it, or left on the one line of compact output.
+	marshal := func(v any, prefix string) ([]byte, error) {
+		if opts.Compact {
+			return json.Marshal(v)
+		}
+		return json.MarshalIndent(v, prefix, "  ")
+	}
+	newline := func(indent string) string {
+		if opts.Compact {
+			return ""
+		}
+		return "\n" + indent
+	}
+	report, err := marshal(r, "")
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	// Reopen the object, dropping its closing "}" (and the newline before
+	// it), to append by_file.
+	bw.WriteString(strings.TrimSuffix(string(report[:len(report)-1]), "\n"))
+	bw.WriteString("," + newline("  ") + `"by_file":`)
+	if !opts.Compact {
+		bw.WriteByte(' ')
+	}
+	bw.WriteByte('[')
+	for i, f := range summary.FileStats {
+		data, err := marshal(toJSONFile(f), "    ")
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteString(newline("    "))
+		bw.Write(data)
+	}
+	if len(summary.FileStats) > 0 {
+		bw.WriteString(newline("  "))
+	}
+	bw.WriteString("]" + newline("") + "}\n")
+	return bw.Flush()
+}
+
+// buildJSON converts a summary into its JSON document structure.
+func buildJSON(summary Summary) jsonOutput {
+	byFile := make([]jsonFile, 0, len(summary.FileStats))
+	for _, f := range summary.FileStats {
+		byFile = append(byFile, toJSONFile(f))
+	}
+	return jsonOutput{jsonReport: buildReport(summary, false), ByFile: byFile}
+}
+
+// buildReport converts a summary into its JSON document without by_file.
+// With arrays, every by_category in it is written as an array.
+func buildReport(summary Summary, arrays bool) jsonReport {
+	categories := make(map[string]jsonCatDetail)
 
 	// Build file lists per category.
 	catFiles := make(map[string][]string)
@@ -266,51 +1119,397 @@ func RenderJSON(w io.Writer, summary Summary) error {
 	}
 
 	for cat, ct := range summary.CategoryTotals {
-		byCategory[cat] = jsonCatDetail{
-			Added:     ct.Added,
-			Deleted:   ct.Deleted,
-			Churn:     ct.Churn,
-			Files:     catFiles[cat],
-			FileCount: ct.FileCount,
+		categories[cat] = jsonCatDetail{
+			Added:      ct.Added,
+			Deleted:    ct.Deleted,
+			Churn:      ct.Churn,
+			Net:        ct.Net(),
+			Moved:      ct.Moved,
+			RawAdded:   ct.RawAdded,
+			RawDeleted: ct.RawDeleted,
+			Files:      catFiles[cat],
+			FileCount:  ct.FileCount,
+		}
+	}
+	if locales := localeTotals(summary.FileStats); len(locales) > 0 {
+		detail := categories["i18n"]
+		detail.Locales = make(map[string]jsonTotal, len(locales))
+		for _, l := range locales {
+			detail.Locales[l.locale] = toJSONTotal(l.CategoryTotal)
 		}
+		categories["i18n"] = detail
 	}
 
-	byFile := make([]jsonFile, 0, len(summary.FileStats))
-	for _, f := range summary.FileStats {
-		byFile = append(byFile, jsonFile{
-			Path:     f.Path,
-			Added:    f.Added,
-			Deleted:  f.Deleted,
-			Churn:    f.Churn,
-			Category: f.Category,
-			Language: f.Language,
-		})
+	out := jsonReport{
+		Meta:       toJSONMeta(summary.Meta),
+		Total:      toJSONTotal(summary.Totals),
+		ByCategory: byCategory(categories),
 	}
+	if arrays {
+		out.ByCategory.asArray = true
+		out.Meta.categoriesAsArrays()
+	}
+	if ratio, ok := DocsRatio(summary); ok {
+		ratio = math.Round(ratio*1000) / 1000
+		out.DocsRatio = &ratio
+	}
+
+	return out
+}
+
+func toJSONFile(f FileStat) jsonFile {
+	return jsonFile{
+		Path:       f.Path,
+		Added:      f.Added,
+		Deleted:    f.Deleted,
+		Churn:      f.Churn,
+		Net:        f.Net(),
+		Moved:      f.Moved,
+		RawAdded:   f.RawAdded,
+		RawDeleted: f.RawDeleted,
+		Category:   f.Category,
+		Language:   f.Language,
+		Link:       f.Link,
+		OldPath:    f.OldPath,
+		OldMode:    f.OldMode,
+		NewMode:    f.NewMode,
+		Status:     f.Status,
+		Commits:    f.Commits,
+
+		Created:      jsonTime(f.Created),
+		LastCommit:   f.LastCommit,
+		LastModified: jsonTime(f.LastModified),
+	}
+}
+
+// jsonTime formats t as RFC 3339, or as empty for the zero time.
+func jsonTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
 
-	pathspecs := summary.Meta.Pathspecs
+func toJSONMeta(m Meta) jsonMeta {
+	pathspecs := m.Pathspecs
 	if pathspecs == nil {
 		pathspecs = []string{}
 	}
+	return jsonMeta{
+		Base:             m.Base,
+		Head:             m.Head,
+		MergeBase:        m.MergeBase,
+		Worktree:         m.Worktree,
+		Empty:            m.Empty,
+		Pathspecs:        pathspecs,
+		IgnoreWhitespace: m.IgnoreWhitespace,
+		DiffAlgorithm:    m.DiffAlgorithm,
+		DetectMoves:      m.DetectMoves,
+		Count:            m.Count,
+		Timestamp:        m.Timestamp,
+		Warnings:         m.Warnings,
+		Relative:         m.Relative,
+		DependencyUpdate: m.DependencyUpdate,
+		APIChurn:         m.APIChurn,
+		SchemaChanges:    m.SchemaChanges,
+		Submodules:       toJSONSubmodules(m.Submodules),
+		Infrastructure:   m.Infrastructure,
+		Breakdown:        toJSONBreakdown(m.WorktreeBreakdown),
+		Teams:            toJSONTeams(m.Teams),
+		Reference:        m.Reference,
+		Provenance:       m.Provenance,
+	}
+}
+
+// categoriesAsArrays writes the by_category values nested in m as arrays.
+func (m *jsonMeta) categoriesAsArrays() {
+	if b := m.Breakdown; b != nil {
+		b.Committed.ByCategory.asArray = true
+		b.Staged.ByCategory.asArray = true
+		b.Unstaged.ByCategory.asArray = true
+	}
+	for i := range m.Teams {
+		m.Teams[i].ByCategory.asArray = true
+	}
+	for _, sub := range m.Submodules {
+		if sub.ByCategory != nil {
+			sub.ByCategory.asArray = true
+		}
+	}
+}
+
+// toJSONTotals converts category totals to their JSON form.
+func toJSONTotals(totals map[string]CategoryTotal) categoryValues[jsonTotal] {
+	values := make(map[string]jsonTotal, len(totals))
+	for cat, ct := range totals {
+		values[cat] = toJSONTotal(ct)
+	}
+	return byCategory(values)
+}
+
+func toJSONBreakdown(wb *WorktreeBreakdown) *jsonBreakdown {
+	if wb == nil {
+		return nil
+	}
+	bucket := func(b WorktreeBucket) jsonBucket {
+		return jsonBucket{Total: toJSONTotal(b.Totals), ByCategory: toJSONTotals(b.CategoryTotals)}
+	}
+	return &jsonBreakdown{
+		Committed: bucket(wb.Committed),
+		Staged:    bucket(wb.Staged),
+		Unstaged:  bucket(wb.Unstaged),
+	}
+}
+
+func toJSONTeams(teams []Team) []jsonTeam {
+	var out []jsonTeam
+	for _, t := range teams {
+		out = append(out, jsonTeam{Name: t.Name, Total: toJSONTotal(t.Totals), ByCategory: toJSONTotals(t.CategoryTotals)})
+	}
+	return out
+}
+
+func toJSONSubmodules(subs []Submodule) []jsonSubmodule {
+	var out []jsonSubmodule
+	for _, sub := range subs {
+		js := jsonSubmodule{Path: sub.Path, OldCommit: sub.OldCommit, NewCommit: sub.NewCommit}
+		if sub.Totals != nil {
+			total := toJSONTotal(*sub.Totals)
+			js.Total = &total
+			categories := toJSONTotals(sub.CategoryTotals)
+			js.ByCategory = &categories
+		}
+		out = append(out, js)
+	}
+	return out
+}
+
+// renderAPIChurn prints the public API churn line followed by one line per
+// touched symbol.
+func renderAPIChurn(w io.Writer, api *APIChurn) {
+	if len(api.Symbols) == 0 {
+		fmt.Fprintln(w, "Public API churn: none")
+		return
+	}
+	word := "symbols"
+	if len(api.Symbols) == 1 {
+		word = "symbol"
+	}
+	fmt.Fprintf(w, "Public API churn: %d %s in %d exported %s\n", api.Lines, lineWord(api.Lines), len(api.Symbols), word)
+	for _, sym := range api.Symbols {
+		fmt.Fprintf(w, "  %-7s %s %s (%s)\n", sym.Change, sym.Kind, sym.Name, sym.Path)
+	}
+}
+
+// iconLabel returns label, the name of category key, after the key's icon
+// in icons, if it has one.
+func iconLabel(icons map[string]string, key, label string) string {
+	if icon := icons[key]; icon != "" {
+		return icon + " " + label
+	}
+	return label
+}
+
+// displayWidth estimates the number of terminal columns s takes up: wide
+// characters such as emoji and CJK ideographs take two, and zero-width
+// joiners, variation selectors, and combining marks none.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r == '\u200d', r >= 0xfe00 && r <= 0xfe0f, unicode.Is(unicode.Mn, r):
+		case r >= 0x1f000, r >= 0x2e80 && r <= 0xa4cf, r >= 0xac00 && r <= 0xd7a3,
+			r >= 0xf900 && r <= 0xfaff, r >= 0xff00 && r <= 0xff60:
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// visibleLen is the display width of s without ANSI color codes.
+func visibleLen(s string) int {
+	for _, code := range []string{addColor, delColor, resetColor} {
+		s = strings.ReplaceAll(s, code, "")
+	}
+	return displayWidth(s)
+}
+
+// shortSHA returns the abbreviated form of a commit SHA.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// unspecifiedLocale labels localization files without a recognizable
+// locale, such as gettext templates.
+const unspecifiedLocale = "unspecified"
+
+// localeTotal aggregates the i18n files of one locale.
+type localeTotal struct {
+	locale string
+	CategoryTotal
+}
+
+// localeTotals groups i18n files by locale (their Language), ordered by
+// churn and then locale.
+func localeTotals(files []FileStat) []localeTotal {
+	byLocale := make(map[string]*localeTotal)
+	for _, f := range files {
+		if f.Category != "i18n" {
+			continue
+		}
+		locale := f.Language
+		if locale == "" {
+			locale = unspecifiedLocale
+		}
+		lt := byLocale[locale]
+		if lt == nil {
+			lt = &localeTotal{locale: locale}
+			byLocale[locale] = lt
+		}
+		lt.Added += f.Added
+		lt.Deleted += f.Deleted
+		lt.Churn += f.Churn
+		lt.FileCount++
+	}
+	result := make([]localeTotal, 0, len(byLocale))
+	for _, lt := range byLocale {
+		result = append(result, *lt)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Churn != result[j].Churn {
+			return result[i].Churn > result[j].Churn
+		}
+		return result[i].locale < result[j].locale
+	})
+	return result
+}
+
+// renderInfrastructure prints a plan-style resource total followed by the
+// counts for each kind.
+func renderInfrastructure(w io.Writer, kinds []InfraKind) {
+	var added, changed, removed int
+	width := 0
+	for _, k := range kinds {
+		added += k.Added
+		changed += k.Changed
+		removed += k.Removed
+		width = max(width, len(k.Kind))
+	}
+	fmt.Fprintf(w, "Infrastructure: %d to add, %d to change, %d to destroy\n", added, changed, removed)
+	for _, k := range kinds {
+		var parts []string
+		if k.Added > 0 {
+			parts = append(parts, fmt.Sprintf("%d added", k.Added))
+		}
+		if k.Changed > 0 {
+			parts = append(parts, fmt.Sprintf("%d changed", k.Changed))
+		}
+		if k.Removed > 0 {
+			parts = append(parts, fmt.Sprintf("%d removed", k.Removed))
+		}
+		fmt.Fprintf(w, "  %-*s %s\n", width+1, k.Kind+":", strings.Join(parts, ", "))
+	}
+}
+
+// renderSchemaChanges prints each schema file's counts followed by its
+// elements, marked + (added), - (removed), or ~ (changed).
+func renderSchemaChanges(w io.Writer, changes []SchemaChange) {
+	fmt.Fprintln(w, "Schema changes:")
+	for _, c := range changes {
+		fmt.Fprintf(w, "  %s (%s): %d added, %d removed, %d changed\n", c.Path, c.Format, len(c.Added), len(c.Removed), len(c.Changed))
+		for _, e := range c.Added {
+			fmt.Fprintf(w, "    + %s\n", e)
+		}
+		for _, e := range c.Removed {
+			fmt.Fprintf(w, "    - %s\n", e)
+		}
+		for _, e := range c.Changed {
+			fmt.Fprintf(w, "    ~ %s\n", e)
+		}
+	}
+}
+
+// renderWorktreeBreakdown prints the committed, staged, and unstaged churn
+// of a comparison against the working tree on one line.
+func renderWorktreeBreakdown(w io.Writer, wb *WorktreeBreakdown, noColor bool) {
+	buckets := []struct {
+		name string
+		t    CategoryTotal
+	}{
+		{"committed", wb.Committed.Totals},
+		{"staged", wb.Staged.Totals},
+		{"unstaged", wb.Unstaged.Totals},
+	}
+	parts := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		parts = append(parts, fmt.Sprintf("%s %s (%d)", b.name, formatAddDel(b.t.Added, b.t.Deleted, 0, 0, noColor), b.t.Churn))
+	}
+	fmt.Fprintf(w, "Worktree: %s\n", strings.Join(parts, ", "))
+}
+
+// renderTeams prints each team's churn and files on a line of its own.
+func renderTeams(w io.Writer, teams []Team, noColor bool) {
+	width := 0
+	for _, t := range teams {
+		width = max(width, len(t.Name))
+	}
+	fmt.Fprintln(w, "Teams:")
+	for _, t := range teams {
+		fmt.Fprintf(w, "  %-*s %s (%d) [%d %s]\n", width+1, t.Name+":", formatAddDel(t.Totals.Added, t.Totals.Deleted, 0, 0, noColor), t.Totals.Churn, t.Totals.FileCount, fileWord(t.Totals.FileCount))
+	}
+}
+
+// renderSubmodules prints each moved submodule's commit range, followed by
+// its churn when it was analyzed.
+func renderSubmodules(w io.Writer, subs []Submodule, noColor bool) {
+	width := 0
+	for _, sub := range subs {
+		width = max(width, len(sub.Path))
+	}
+	fmt.Fprintln(w, "Submodules:")
+	for _, sub := range subs {
+		var change string
+		switch {
+		case sub.OldCommit == "":
+			change = "added at " + shortSHA(sub.NewCommit)
+		case sub.NewCommit == "":
+			change = "removed, was " + shortSHA(sub.OldCommit)
+		default:
+			change = shortSHA(sub.OldCommit) + ".." + shortSHA(sub.NewCommit)
+		}
+		line := fmt.Sprintf("  %-*s %s", width+1, sub.Path+":", change)
+		if t := sub.Totals; t != nil {
+			line += fmt.Sprintf(" %s (%d) [%d %s]", formatAddDel(t.Added, t.Deleted, 0, 0, noColor), t.Churn, t.FileCount, fileWord(t.FileCount))
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// maxListedPackages caps how many package names the text summary lists.
+const maxListedPackages = 8
+
+func describeDependencyUpdate(dep *DependencyUpdate) string {
+	if dep.Bumped == 0 {
+		return "lockfiles only"
+	}
+	word := "packages"
+	if dep.Bumped == 1 {
+		word = "package"
+	}
+	names := dep.Packages
+	more := ""
+	if len(names) > maxListedPackages {
+		more = fmt.Sprintf(", and %d more", len(names)-maxListedPackages)
+		names = names[:maxListedPackages]
+	}
+	return fmt.Sprintf("%d %s bumped (%s%s)", dep.Bumped, word, strings.Join(names, ", "), more)
+}
 
-	out := jsonOutput{
-		Meta: jsonMeta{
-			Base:      summary.Meta.Base,
-			Head:      summary.Meta.Head,
-			Empty:     summary.Meta.Empty,
-			Pathspecs: pathspecs,
-			Timestamp: summary.Meta.Timestamp,
-		},
-		Total: jsonTotal{
-			Added:   summary.Totals.Added,
-			Deleted: summary.Totals.Deleted,
-			Churn:   summary.Totals.Churn,
-			Files:   summary.Totals.FileCount,
-		},
-		ByCategory: byCategory,
-		ByFile:     byFile,
-	}
-
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-	return enc.Encode(out)
+func toJSONTotal(ct CategoryTotal) jsonTotal {
+	return jsonTotal{Added: ct.Added, Deleted: ct.Deleted, Churn: ct.Churn, Net: ct.Net(), Moved: ct.Moved, RawAdded: ct.RawAdded, RawDeleted: ct.RawDeleted, Files: ct.FileCount}
 }
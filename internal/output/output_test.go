@@ -3,8 +3,10 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 // testSummary returns a representative Summary for tests.
@@ -40,7 +42,7 @@ func testSummary() Summary {
 func TestRenderTextSummaryOnly(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{NoColor: true})
+	RenderText(&buf, s, Options{NoColor: true})
 
 	got := buf.String()
 	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
@@ -68,7 +70,7 @@ func TestRenderTextSummaryOnly(t *testing.T) {
 func TestRenderTextSummaryAlignsDiffColumns(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{NoColor: true})
+	RenderText(&buf, s, Options{NoColor: true})
 
 	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
 	if len(lines) < 2 {
@@ -90,7 +92,7 @@ func TestRenderTextSummaryAlignsDiffColumns(t *testing.T) {
 func TestRenderTextCategoryOrder(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{NoColor: true})
+	RenderText(&buf, s, Options{NoColor: true})
 
 	got := buf.String()
 	docIdx := strings.Index(got, "Documentation:")
@@ -117,7 +119,7 @@ func TestRenderTextOmitsZeroChurn(t *testing.T) {
 		FileStats: []FileStat{},
 	}
 
-	RenderText(&buf, s, OutputOpts{NoColor: true})
+	RenderText(&buf, s, Options{NoColor: true})
 	got := buf.String()
 
 	if strings.Contains(got, "Documentation:") {
@@ -128,6 +130,141 @@ func TestRenderTextOmitsZeroChurn(t *testing.T) {
 	}
 }
 
+func TestRenderTextModeAndSymlinkChanges(t *testing.T) {
+	var buf bytes.Buffer
+	s := Summary{
+		Totals: CategoryTotal{Added: 1, Deleted: 1, Churn: 2, FileCount: 2},
+		CategoryTotals: map[string]CategoryTotal{
+			"source": {FileCount: 1},
+			"other":  {Added: 1, Deleted: 1, Churn: 2, FileCount: 1},
+		},
+		FileStats: []FileStat{
+			{Path: "run.sh", Category: "source", OldMode: "100644", NewMode: "100755", Status: StatusMode},
+			{Path: "link", Category: "other", Added: 1, Deleted: 1, Churn: 2, Status: StatusSymlink, Commits: 2},
+		},
+	}
+
+	RenderText(&buf, s, Options{NoColor: true, List: true})
+	got := buf.String()
+
+	// A category whose only change is a mode change is still listed.
+	for _, want := range []string{"Source:", "+0 -0 run.sh (mode 100644 -> 100755)", "+1 -1 link (symlink, 2 commits)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderTextSubmodules(t *testing.T) {
+	var buf bytes.Buffer
+	s := Summary{
+		Totals:         CategoryTotal{FileCount: 3},
+		CategoryTotals: map[string]CategoryTotal{"other": {FileCount: 3}},
+		FileStats: []FileStat{
+			{Path: "lib", Category: "other", Status: StatusSubmodule},
+			{Path: "vendor/x", Category: "other", Status: StatusSubmodule},
+			{Path: "old", Category: "other", Status: StatusSubmodule},
+		},
+		Meta: Meta{Submodules: []Submodule{
+			{Path: "lib", OldCommit: "fba867ed2731547c87272f3d9a3ddc61b247a22d", NewCommit: "cd7f7a63ac1ad23e8a854586f8090cf065dcfae9",
+				Totals: &CategoryTotal{Added: 12, Deleted: 3, Churn: 15, FileCount: 2}},
+			{Path: "vendor/x", NewCommit: "cd7f7a63ac1ad23e8a854586f8090cf065dcfae9"},
+			{Path: "old", OldCommit: "fba867ed2731547c87272f3d9a3ddc61b247a22d"},
+		}},
+	}
+
+	RenderText(&buf, s, Options{NoColor: true, List: true})
+	got := buf.String()
+
+	for _, want := range []string{
+		"Submodules:\n",
+		"  lib:      fba867e..cd7f7a6 +12 -3 (15) [2 files]\n",
+		"  vendor/x: added at cd7f7a6\n",
+		"  old:      removed, was fba867e\n",
+		"+0 -0 lib (submodule)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderWorktreeBreakdown(t *testing.T) {
+	s := testSummary()
+	s.Meta.WorktreeBreakdown = &WorktreeBreakdown{
+		Committed: WorktreeBucket{
+			Totals:         CategoryTotal{Added: 40, Deleted: 10, Churn: 50, FileCount: 3},
+			CategoryTotals: map[string]CategoryTotal{"source": {Added: 40, Deleted: 10, Churn: 50, FileCount: 3}},
+		},
+		Staged: WorktreeBucket{
+			Totals:         CategoryTotal{Added: 5, Churn: 5, FileCount: 1},
+			CategoryTotals: map[string]CategoryTotal{"tests": {Added: 5, Churn: 5, FileCount: 1}},
+		},
+		Unstaged: WorktreeBucket{CategoryTotals: map[string]CategoryTotal{}},
+	}
+
+	var buf bytes.Buffer
+	RenderText(&buf, s, Options{NoColor: true})
+	if want := "Worktree: committed +40 -10 (50), staged +5 -0 (5), unstaged +0 -0 (0)\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %q in output:\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	RenderJSON(&buf, s)
+	var result struct {
+		Meta struct {
+			Breakdown map[string]struct {
+				Total      jsonTotal            `json:"total"`
+				ByCategory map[string]jsonTotal `json:"by_category"`
+			} `json:"worktree_breakdown"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Meta.Breakdown["staged"]; got.Total.Churn != 5 || got.ByCategory["tests"].Files != 1 {
+		t.Errorf("staged bucket: got %+v", got)
+	}
+	if got := result.Meta.Breakdown["unstaged"]; got.Total.Files != 0 || got.ByCategory == nil {
+		t.Errorf("unstaged bucket: got %+v", got)
+	}
+}
+
+func TestRenderTeams(t *testing.T) {
+	s := testSummary()
+	s.Meta.Teams = []Team{
+		{Name: "payments", Totals: CategoryTotal{Added: 40, Deleted: 10, Churn: 50, FileCount: 3}, CategoryTotals: map[string]CategoryTotal{"source": {Added: 40, Deleted: 10, Churn: 50, FileCount: 3}}},
+		{Name: "unassigned", Totals: CategoryTotal{Added: 2, Churn: 2, FileCount: 1}, CategoryTotals: map[string]CategoryTotal{"docs": {Added: 2, Churn: 2, FileCount: 1}}},
+	}
+
+	var buf bytes.Buffer
+	RenderText(&buf, s, Options{NoColor: true})
+	want := "Teams:\n" +
+		"  payments:   +40 -10 (50) [3 files]\n" +
+		"  unassigned: +2 -0 (2) [1 file]\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %q in output:\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	RenderJSON(&buf, s)
+	var result struct {
+		Meta struct {
+			Teams []struct {
+				Name       string               `json:"name"`
+				Total      jsonTotal            `json:"total"`
+				ByCategory map[string]jsonTotal `json:"by_category"`
+			} `json:"teams"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Meta.Teams; len(got) != 2 || got[0].Name != "payments" || got[0].Total.Churn != 50 || got[1].ByCategory["docs"].Files != 1 {
+		t.Errorf("teams: got %+v", got)
+	}
+}
+
 func TestRenderTextWithColor(t *testing.T) {
 	var buf bytes.Buffer
 	s := Summary{
@@ -138,7 +275,7 @@ func TestRenderTextWithColor(t *testing.T) {
 		FileStats: []FileStat{},
 	}
 
-	RenderText(&buf, s, OutputOpts{NoColor: false})
+	RenderText(&buf, s, Options{NoColor: false})
 	got := buf.String()
 
 	if !strings.Contains(got, "\033[32m+10\033[0m") {
@@ -155,7 +292,7 @@ func TestRenderTextWithColor(t *testing.T) {
 func TestRenderTextNoColor(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{NoColor: true})
+	RenderText(&buf, s, Options{NoColor: true})
 	got := buf.String()
 
 	if strings.Contains(got, "\033[") {
@@ -166,7 +303,7 @@ func TestRenderTextNoColor(t *testing.T) {
 func TestRenderTextListMode(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{List: true, NoColor: true})
+	RenderText(&buf, s, Options{List: true, NoColor: true})
 
 	got := buf.String()
 
@@ -192,7 +329,7 @@ func TestRenderTextListMode(t *testing.T) {
 func TestRenderTextListOnlyMode(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{ListOnly: true, NoColor: true})
+	RenderText(&buf, s, Options{ListOnly: true, NoColor: true})
 
 	got := buf.String()
 
@@ -213,7 +350,7 @@ func TestRenderTextListOnlyMode(t *testing.T) {
 func TestRenderTextSortByChurn(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{ListOnly: true, Sort: "churn", NoColor: true})
+	RenderText(&buf, s, Options{ListOnly: true, Sort: "churn", NoColor: true})
 
 	got := buf.String()
 
@@ -228,7 +365,7 @@ func TestRenderTextSortByChurn(t *testing.T) {
 func TestRenderTextSortByPath(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{ListOnly: true, Sort: "path", NoColor: true})
+	RenderText(&buf, s, Options{ListOnly: true, Sort: "path", NoColor: true})
 
 	got := buf.String()
 
@@ -240,10 +377,100 @@ func TestRenderTextSortByPath(t *testing.T) {
 	}
 }
 
+func TestRenderTextSortByNet(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	RenderText(&buf, s, Options{ListOnly: true, Sort: "net", NoColor: true})
+
+	got := buf.String()
+
+	// bar.go grows by 20 lines and baz.go by 10, though baz.go has more churn.
+	if strings.Index(got, "internal/foo/bar.go") > strings.Index(got, "internal/baz/baz.go") {
+		t.Error("net sort: file with larger net growth should appear first")
+	}
+}
+
+func TestSortFilesModes(t *testing.T) {
+	files := []FileStat{
+		{Path: "b.go", Added: 5, Deleted: 1, Churn: 6, Category: "source", Language: "Go"},
+		{Path: "a.md", Added: 1, Deleted: 5, Churn: 6, Category: "docs"},
+		{Path: "c.py", Added: 9, Deleted: 0, Churn: 9, Category: "source", Language: "Python"},
+		{Path: "a_test.go", Added: 5, Deleted: 2, Churn: 7, Category: "tests", Language: "Go"},
+	}
+	tests := map[string]string{
+		"churn":    "c.py a_test.go a.md b.go",
+		"path":     "a.md a_test.go b.go c.py",
+		"net":      "c.py b.go a_test.go a.md",
+		"added":    "c.py a_test.go b.go a.md",
+		"deleted":  "a.md a_test.go b.go c.py",
+		"language": "a_test.go b.go c.py a.md",
+		"category": "a.md a_test.go b.go c.py",
+		"bogus":    "c.py a_test.go a.md b.go",
+	}
+	for _, mode := range SortModes {
+		if _, ok := tests[mode]; !ok {
+			t.Errorf("sort mode %q is not tested", mode)
+		}
+	}
+	for mode, want := range tests {
+		sorted := append([]FileStat(nil), files...)
+		sortFiles(sorted, mode)
+		var paths []string
+		for _, f := range sorted {
+			paths = append(paths, f.Path)
+		}
+		if got := strings.Join(paths, " "); got != want {
+			t.Errorf("sort %s: got %s, want %s", mode, got, want)
+		}
+	}
+}
+
+func TestRenderTextNet(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.FileStats = append(s.FileStats, FileStat{Path: "old.go", Deleted: 40, Churn: 40, Category: "source"})
+	RenderText(&buf, s, Options{List: true, Net: true, NoColor: true})
+
+	got := buf.String()
+	for _, want := range []string{
+		"Source:        +120 - 90 (210) net +30 [14 files]\n",
+		"Total:         +186 -104 (290) net +82 [28 files]\n",
+		"+50 -30 net +20 internal/foo/bar.go\n",
+		"+ 0 -40 net -40 old.go\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+
+	buf.Reset()
+	RenderText(&buf, testSummary(), Options{List: true, NoColor: true})
+	if strings.Contains(buf.String(), "net") {
+		t.Errorf("net shown without Net:\n%s", buf.String())
+	}
+}
+
+func TestRenderJSONNet(t *testing.T) {
+	var buf bytes.Buffer
+	RenderJSON(&buf, testSummary())
+
+	var result struct {
+		Total      jsonTotal                `json:"total"`
+		ByCategory map[string]jsonCatDetail `json:"by_category"`
+		ByFile     []jsonFile               `json:"by_file"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total.Net != 82 || result.ByCategory["source"].Net != 30 || result.ByFile[1].Net != 10 {
+		t.Errorf("net = total %d, source %d, baz.go %d; want 82, 30, 10", result.Total.Net, result.ByCategory["source"].Net, result.ByFile[1].Net)
+	}
+}
+
 func TestRenderTextFileListGroupOrder(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{ListOnly: true, NoColor: true})
+	RenderText(&buf, s, Options{ListOnly: true, NoColor: true})
 
 	got := buf.String()
 
@@ -267,7 +494,7 @@ func TestRenderTextEmptySummary(t *testing.T) {
 		CategoryTotals: map[string]CategoryTotal{},
 		FileStats:      []FileStat{},
 	}
-	RenderText(&buf, s, OutputOpts{NoColor: true})
+	RenderText(&buf, s, Options{NoColor: true})
 	got := buf.String()
 
 	// Should only have the Total line.
@@ -291,7 +518,7 @@ func TestRenderTextSingularFileWord(t *testing.T) {
 			{Path: "main.go", Added: 2, Deleted: 1, Churn: 3, Category: "source"},
 		},
 	}
-	RenderText(&buf, s, OutputOpts{NoColor: true})
+	RenderText(&buf, s, Options{NoColor: true})
 	got := buf.String()
 	if !strings.Contains(got, "[1 file]") {
 		t.Fatalf("expected singular file word, got:\n%s", got)
@@ -301,6 +528,244 @@ func TestRenderTextSingularFileWord(t *testing.T) {
 	}
 }
 
+func TestRenderTextMovedLines(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.Totals.Moved = 40
+	RenderText(&buf, s, Options{NoColor: true})
+	if !strings.Contains(buf.String(), "\nMoved: 40 lines between files, not counted as churn\n") {
+		t.Errorf("expected moved line after the totals, got:\n%s", buf.String())
+	}
+}
+
+func TestRenderMeaningfulLines(t *testing.T) {
+	s := testSummary()
+	s.Meta.Count = CountMeaningful
+	s.Totals.RawAdded = s.Totals.Added + 12
+	s.Totals.RawDeleted = s.Totals.Deleted + 3
+	s.FileStats[0].RawAdded = s.FileStats[0].Added + 12
+
+	var buf bytes.Buffer
+	RenderText(&buf, s, Options{NoColor: true})
+	want := fmt.Sprintf("\nMeaningful lines only: 15 comment-only or blank lines not counted (raw +%d -%d)\n", s.Totals.RawAdded, s.Totals.RawDeleted)
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %q after the totals, got:\n%s", want, buf.String())
+	}
+
+	out := buildJSON(s)
+	if out.Meta.Count != CountMeaningful || out.Total.RawAdded != s.Totals.RawAdded || out.ByFile[0].RawAdded != s.FileStats[0].RawAdded {
+		t.Errorf("JSON meta count %q, total raw_added %d, first file raw_added %d", out.Meta.Count, out.Total.RawAdded, out.ByFile[0].RawAdded)
+	}
+
+	buf.Reset()
+	RenderText(&buf, testSummary(), Options{NoColor: true})
+	if strings.Contains(buf.String(), "Meaningful") {
+		t.Errorf("counting every line: unexpected meaningful line in:\n%s", buf.String())
+	}
+}
+
+// i18nSummary returns testSummary with localization files added.
+func i18nSummary() Summary {
+	s := testSummary()
+	s.CategoryTotals["i18n"] = CategoryTotal{Added: 13, Deleted: 2, Churn: 15, FileCount: 3}
+	s.FileStats = append(s.FileStats,
+		FileStat{Path: "locales/fr.json", Added: 8, Deleted: 1, Churn: 9, Category: "i18n", Language: "fr"},
+		FileStat{Path: "po/de.po", Added: 3, Deleted: 1, Churn: 4, Category: "i18n", Language: "de"},
+		FileStat{Path: "po/messages.pot", Added: 2, Churn: 2, Category: "i18n"},
+	)
+	return s
+}
+
+func TestRenderTextLocales(t *testing.T) {
+	var buf bytes.Buffer
+	RenderText(&buf, i18nSummary(), Options{NoColor: true})
+	out := buf.String()
+	if !strings.Contains(out, "Localization") {
+		t.Errorf("expected Localization category row, got:\n%s", out)
+	}
+	if !strings.Contains(out, "\nLocales: fr +8 -1, de +3 -1, unspecified +2 -0\n") {
+		t.Errorf("expected locales line ordered by churn, got:\n%s", out)
+	}
+}
+
+func TestRenderTextNoLocales(t *testing.T) {
+	var buf bytes.Buffer
+	RenderText(&buf, testSummary(), Options{NoColor: true})
+	if strings.Contains(buf.String(), "Locales:") {
+		t.Errorf("unexpected locales line without i18n files:\n%s", buf.String())
+	}
+}
+
+func TestRenderTextReference(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.Meta.Reference = &Reference{
+		Base:       "main",
+		Commit:     "0123456789abcdef",
+		Kind:       ReferenceMerge,
+		Churn:      120,
+		Files:      9,
+		ByCategory: map[string]int{"source": 100, "tests": 20},
+	}
+	RenderText(&buf, s, Options{NoColor: true})
+	out := buf.String()
+	for _, want := range []string{
+		"Source:        +120 - 90 (210) [14 files]  last merge 100 (+110)\n",
+		"Documentation: + 12 -  3 ( 15) [4 files]   last merge   0 (+15)\n",
+		"Total:         +186 -104 (290) [28 files]  last merge 120 (+170)\n",
+		"Last merge: 0123456 into main, 120 lines in 9 files\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	// A base branch without merges is compared with its last commit.
+	buf.Reset()
+	s.Meta.Reference.Kind = ReferenceCommit
+	RenderText(&buf, s, Options{NoColor: true})
+	out = buf.String()
+	for _, want := range []string{
+		"Total:         +186 -104 (290) [28 files]  last commit 120 (+170)\n",
+		"Last commit: 0123456 on main, 120 lines in 9 files\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "merge") {
+		t.Errorf("unexpected merge wording for a plain commit:\n%s", out)
+	}
+}
+
+func TestRenderTextDependencyUpdate(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.Meta.DependencyUpdate = &DependencyUpdate{Bumped: 2, Packages: []string{"cobra", "yaml"}}
+	RenderText(&buf, s, Options{NoColor: true})
+	if !strings.Contains(buf.String(), "\nDependency update: 2 packages bumped (cobra, yaml)\n") {
+		t.Errorf("expected dependency update line, got:\n%s", buf.String())
+	}
+
+	names := make([]string, 10)
+	for i := range names {
+		names[i] = fmt.Sprintf("p%d", i)
+	}
+	got := describeDependencyUpdate(&DependencyUpdate{Bumped: 10, Packages: names})
+	if want := "10 packages bumped (p0, p1, p2, p3, p4, p5, p6, p7, and 2 more)"; got != want {
+		t.Errorf("describeDependencyUpdate = %q, want %q", got, want)
+	}
+	if got := describeDependencyUpdate(&DependencyUpdate{Packages: []string{}}); got != "lockfiles only" {
+		t.Errorf("describeDependencyUpdate(lockfiles) = %q", got)
+	}
+}
+
+func TestRenderTextAPIChurn(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.Meta.APIChurn = &APIChurn{Lines: 3, Symbols: []APISymbol{
+		{Path: "pkg/store.go", Kind: "func", Name: "Open", Change: "changed", Lines: 2},
+		{Path: "pkg/store.go", Kind: "method", Name: "Store.Close", Change: "added", Lines: 1},
+	}}
+	RenderText(&buf, s, Options{NoColor: true})
+	want := "Public API churn: 3 lines in 2 exported symbols\n" +
+		"  changed func Open (pkg/store.go)\n" +
+		"  added   method Store.Close (pkg/store.go)\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected API churn block, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	s.Meta.APIChurn = &APIChurn{Symbols: []APISymbol{}}
+	RenderText(&buf, s, Options{NoColor: true})
+	if !strings.Contains(buf.String(), "\nPublic API churn: none\n") {
+		t.Errorf("expected empty API churn line, got:\n%s", buf.String())
+	}
+}
+
+func TestRenderTextSchemaChanges(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.Meta.SchemaChanges = []SchemaChange{{
+		Path: "api/openapi.yaml", Format: "openapi",
+		Added: []string{"GET /pets/{id}"}, Removed: []string{"DELETE /pets"}, Changed: []string{"schema Pet.name"},
+	}}
+	RenderText(&buf, s, Options{NoColor: true})
+	want := "Schema changes:\n" +
+		"  api/openapi.yaml (openapi): 1 added, 1 removed, 1 changed\n" +
+		"    + GET /pets/{id}\n" +
+		"    - DELETE /pets\n" +
+		"    ~ schema Pet.name\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected schema change block, got:\n%s", buf.String())
+	}
+}
+
+func TestRenderTextWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.Meta.Warnings = []Warning{
+		{Severity: SeverityInfo, Rule: "sparse-checkout", Message: "sparse checkout is active"},
+		{Severity: SeverityWarning, Path: "db/002.sql", Line: 3, Rule: "drop-table", Message: "drops a table"},
+	}
+	RenderText(&buf, s, Options{NoColor: true})
+	want := "\nWarnings:\n" +
+		"  warning: db/002.sql:3: drops a table (drop-table)\n" +
+		"  info: sparse checkout is active (sparse-checkout)\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("expected warnings section at the end, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	RenderText(&buf, s, Options{NoColor: true, ListOnly: true})
+	if strings.Contains(buf.String(), "Warnings:") {
+		t.Errorf("list-only output should not include warnings, got:\n%s", buf.String())
+	}
+}
+
+func TestJSONWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.Meta.Warnings = []Warning{{Severity: SeverityInfo, Rule: "sparse-checkout", Message: "sparse checkout is active"}}
+	if err := RenderJSON(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Meta struct {
+			Warnings []map[string]any `json:"warnings"`
+		} `json:"meta"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Meta.Warnings) != 1 {
+		t.Fatalf("warnings = %v, want one", out.Meta.Warnings)
+	}
+	got := out.Meta.Warnings[0]
+	if got["severity"] != "info" || got["rule"] != "sparse-checkout" {
+		t.Errorf("warning = %v", got)
+	}
+	if _, ok := got["path"]; ok {
+		t.Errorf("expected no path for a warning about the analysis, got %v", got)
+	}
+}
+
+func TestRenderTextInfrastructure(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	s.Meta.Infrastructure = []InfraKind{
+		{Kind: "Deployment", Changed: 1},
+		{Kind: "aws_s3_bucket", Added: 2, Removed: 1},
+	}
+	RenderText(&buf, s, Options{NoColor: true})
+	want := "Infrastructure: 2 to add, 1 to change, 1 to destroy\n" +
+		"  Deployment:    1 changed\n" +
+		"  aws_s3_bucket: 2 added, 1 removed\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected infrastructure block, got:\n%s", buf.String())
+	}
+}
+
 // --- JSON tests ---
 
 func TestRenderJSON(t *testing.T) {
@@ -375,6 +840,137 @@ func TestRenderJSONTotal(t *testing.T) {
 	}
 }
 
+func TestRenderJSONStreamsSameDocument(t *testing.T) {
+	for _, s := range []Summary{testSummary(), {}} {
+		doc := buildJSON(s)
+		doc.SchemaVersion = JSONSchemaVersion
+		want, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := RenderJSON(&buf, s); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != string(want)+"\n" {
+			t.Errorf("streamed JSON differs:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+func TestRenderJSONCompact(t *testing.T) {
+	renderer, _ := LookupRenderer("json")
+	for _, s := range []Summary{testSummary(), {}} {
+		var pretty, compact bytes.Buffer
+		if err := renderer.Render(&compact, s, Options{Compact: true, JSONCategories: JSONCategoriesArray}); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Count(compact.String(), "\n") != 1 || !strings.HasSuffix(compact.String(), "}\n") {
+			t.Errorf("expected a single line, got:\n%s", compact.String())
+		}
+		if !json.Valid(compact.Bytes()) {
+			t.Fatalf("invalid JSON: %s", compact.String())
+		}
+
+		if err := renderer.Render(&pretty, s, Options{JSONCategories: JSONCategoriesArray}); err != nil {
+			t.Fatal(err)
+		}
+		var want bytes.Buffer
+		if err := json.Compact(&want, pretty.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSuffix(compact.String(), "\n"); got != want.String() {
+			t.Errorf("compact output differs from the pretty document:\n%s\nwant:\n%s", got, want.String())
+		}
+	}
+}
+
+func TestRenderJSONCategoriesArray(t *testing.T) {
+	s := testSummary()
+	s.CategoryTotals["proto"] = CategoryTotal{Added: 1, Churn: 1, FileCount: 1}
+	s.Meta.Teams = []Team{
+		{Name: "payments", Totals: CategoryTotal{Added: 40, Churn: 40, FileCount: 2}, CategoryTotals: map[string]CategoryTotal{
+			"tests":  {Added: 10, Churn: 10, FileCount: 1},
+			"source": {Added: 30, Churn: 30, FileCount: 1},
+		}},
+	}
+
+	var buf bytes.Buffer
+	renderer, _ := LookupRenderer("json")
+	if err := renderer.Render(&buf, s, Options{JSONCategories: JSONCategoriesArray}); err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		SchemaVersion int `json:"schema_version"`
+		Meta          struct {
+			Teams []struct {
+				ByCategory []struct {
+					Category string `json:"category"`
+					Churn    int    `json:"churn"`
+				} `json:"by_category"`
+			} `json:"teams"`
+		} `json:"meta"`
+		ByCategory []struct {
+			Category  string `json:"category"`
+			Churn     int    `json:"churn"`
+			FileCount int    `json:"file_count"`
+		} `json:"by_category"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if result.SchemaVersion != JSONSchemaVersion {
+		t.Errorf("schema_version: got %d, want %d", result.SchemaVersion, JSONSchemaVersion)
+	}
+
+	var got []string
+	for _, c := range result.ByCategory {
+		got = append(got, c.Category)
+	}
+	// Report order, with custom categories after the built-in ones.
+	want := []string{"docs", "tests", "source", "generated", "other", "proto"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("by_category order: got %v, want %v", got, want)
+	}
+	if c := result.ByCategory[2]; c.Churn != 210 || c.FileCount != 14 {
+		t.Errorf("source: got %+v", c)
+	}
+	if !strings.Contains(buf.String(), "{\n      \"category\": \"docs\",\n      \"added\": 12,") {
+		t.Errorf("expected category as the first key:\n%s", buf.String())
+	}
+
+	if len(result.Meta.Teams) != 1 {
+		t.Fatalf("teams: got %+v", result.Meta.Teams)
+	}
+	team := result.Meta.Teams[0].ByCategory
+	if len(team) != 2 || team[0].Category != "tests" || team[1].Category != "source" || team[1].Churn != 30 {
+		t.Errorf("team by_category: got %+v", team)
+	}
+}
+
+func TestRenderJSONDocsRatio(t *testing.T) {
+	var buf bytes.Buffer
+	s := testSummary()
+	RenderJSON(&buf, s)
+
+	var result struct {
+		DocsRatio *float64 `json:"docs_ratio"`
+	}
+	json.Unmarshal(buf.Bytes(), &result)
+	// 15 lines of docs churn to 210 of source.
+	if result.DocsRatio == nil || *result.DocsRatio != 0.071 {
+		t.Errorf("docs_ratio: expected 0.071, got %v", result.DocsRatio)
+	}
+
+	// Without source churn there is no ratio.
+	delete(s.CategoryTotals, "source")
+	buf.Reset()
+	RenderJSON(&buf, s)
+	if strings.Contains(buf.String(), "docs_ratio") {
+		t.Errorf("expected no docs_ratio without source churn, got:\n%s", buf.String())
+	}
+}
+
 func TestRenderJSONByCategory(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
@@ -405,6 +1001,30 @@ func TestRenderJSONByCategory(t *testing.T) {
 	}
 }
 
+func TestRenderJSONLocales(t *testing.T) {
+	var buf bytes.Buffer
+	RenderJSON(&buf, i18nSummary())
+
+	var result struct {
+		ByCategory map[string]jsonCatDetail `json:"by_category"`
+	}
+	json.Unmarshal(buf.Bytes(), &result)
+
+	locales := result.ByCategory["i18n"].Locales
+	if len(locales) != 3 {
+		t.Fatalf("expected 3 locales, got %+v", locales)
+	}
+	if fr := locales["fr"]; fr.Added != 8 || fr.Deleted != 1 || fr.Files != 1 {
+		t.Errorf("fr: got %+v", fr)
+	}
+	if _, ok := locales["unspecified"]; !ok {
+		t.Error("expected unspecified locale for the gettext template")
+	}
+	if result.ByCategory["source"].Locales != nil {
+		t.Error("locales should only be set on i18n")
+	}
+}
+
 func TestRenderJSONByFile(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
@@ -480,7 +1100,7 @@ func TestRenderJSONByCategoryFiles(t *testing.T) {
 func TestRenderTextListModeColorInHeaders(t *testing.T) {
 	var buf bytes.Buffer
 	s := testSummary()
-	RenderText(&buf, s, OutputOpts{ListOnly: true, NoColor: false})
+	RenderText(&buf, s, Options{ListOnly: true, NoColor: false})
 	got := buf.String()
 
 	// File list category headers should be colored.
@@ -488,3 +1108,122 @@ func TestRenderTextListModeColorInHeaders(t *testing.T) {
 		t.Error("expected color in file list category headers")
 	}
 }
+
+func TestRenderTextTop(t *testing.T) {
+	s := Summary{FileStats: []FileStat{
+		{Path: "a.go", Added: 5, Churn: 5, Category: "source"},
+		{Path: "b.go", Added: 9, Churn: 9, Category: "source"},
+		{Path: "c.go", Added: 1, Churn: 1, Category: "source"},
+		{Path: "d.md", Added: 7, Churn: 7, Category: "docs"},
+		{Path: "e.md", Added: 2, Churn: 2, Category: "docs"},
+	}}
+
+	var buf bytes.Buffer
+	RenderText(&buf, s, Options{ListOnly: true, Sort: "path", Top: 1, NoColor: true})
+	want := "[Documentation]\n" +
+		"+7 -0 d.md\n" +
+		"… and 1 more file\n" +
+		"\n" +
+		"[Source]\n" +
+		"+9 -0 b.go\n" +
+		"… and 2 more files\n"
+	if buf.String() != want {
+		t.Errorf("per category:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	RenderText(&buf, s, Options{ListOnly: true, Sort: "path", Top: 3, TopGlobal: true, NoColor: true})
+	want = "[Documentation]\n" +
+		"+7 -0 d.md\n" +
+		"\n" +
+		"[Source]\n" +
+		"+5 -0 a.go\n" +
+		"+9 -0 b.go\n" +
+		"\n" +
+		"… and 2 more files\n"
+	if buf.String() != want {
+		t.Errorf("global:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	RenderText(&buf, s, Options{ListOnly: true, Top: 5, TopGlobal: true, NoColor: true})
+	if strings.Contains(buf.String(), "more") {
+		t.Errorf("nothing should be omitted when Top covers every file:\n%s", buf.String())
+	}
+}
+
+func TestRenderTextGroupBy(t *testing.T) {
+	s := Summary{FileStats: []FileStat{
+		{Path: "internal/a.go", Added: 5, Churn: 5, Category: "source", Language: "Go"},
+		{Path: "Makefile", Added: 1, Deleted: 1, Churn: 2, Category: "other"},
+		{Path: "internal/b.go", Added: 9, Deleted: 1, Churn: 10, Category: "source", Language: "Go"},
+		{Path: "web/app.ts", Added: 3, Churn: 3, Category: "source", Language: "TypeScript"},
+	}}
+
+	var buf bytes.Buffer
+	RenderText(&buf, s, Options{ListOnly: true, GroupBy: "dir", NoColor: true})
+	want := "[(root)] +1 -1 (2) [1 file]\n" +
+		"+1 -1 Makefile\n" +
+		"\n" +
+		"[internal/] +14 -1 (15) [2 files]\n" +
+		"+9 -1 internal/b.go\n" +
+		"+5 -0 internal/a.go\n" +
+		"\n" +
+		"[web/] +3 -0 (3) [1 file]\n" +
+		"+3 -0 web/app.ts\n"
+	if buf.String() != want {
+		t.Errorf("by dir:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	RenderText(&buf, s, Options{ListOnly: true, GroupBy: "language", NoColor: true})
+	if got := buf.String(); !strings.HasPrefix(got, "[Go] +14 -1 (15) [2 files]\n") || !strings.Contains(got, "\n[(no language)] +1 -1 (2) [1 file]\n+1 -1 Makefile\n") {
+		t.Errorf("by language:\n%s", got)
+	}
+
+	buf.Reset()
+	RenderText(&buf, s, Options{ListOnly: true, GroupBy: "none", Sort: "path", NoColor: true})
+	want = "+1 -1 Makefile\n" +
+		"+5 -0 internal/a.go\n" +
+		"+9 -1 internal/b.go\n" +
+		"+3 -0 web/app.ts\n"
+	if buf.String() != want {
+		t.Errorf("flat:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestRenderTextIcons(t *testing.T) {
+	icons := map[string]string{"docs": "📚", "source": "💻", "generated": "🤖", "other": "📦", "i18n": "🌐"}
+	var buf bytes.Buffer
+	RenderText(&buf, testSummary(), Options{List: true, NoColor: true, Icons: icons})
+	want := "📚 Documentation: + 12 -  3 ( 15) [4 files]\n" +
+		"Tests:            + 45 -  8 ( 53) [6 files]\n" +
+		"💻 Source:        +120 - 90 (210) [14 files]\n" +
+		"🤖 Generated:     +  2 -  2 (  4) [1 file]\n" +
+		"📦 Uncategorized: +  7 -  1 (  8) [3 files]\n" +
+		"Total:            +186 -104 (290) [28 files]\n" +
+		"\n" +
+		"[📚 Documentation]\n"
+	if got := buf.String(); !strings.HasPrefix(got, want) || !strings.Contains(got, "\n[Tests]\n") {
+		t.Errorf("got:\n%s\nwant prefix:\n%s", got, want)
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	for s, want := range map[string]int{"Source": 6, "📝 Docs": 7, "⚙️": 1, "👩‍💻": 4, "テスト": 6, "é": 1} {
+		if got := displayWidth(s); got != want {
+			t.Errorf("displayWidth(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestNow(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	if got := Now().Format(time.RFC3339); got != "2023-11-14T22:13:20Z" {
+		t.Errorf("Now() = %s, want the SOURCE_DATE_EPOCH time", got)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if got := Now(); time.Since(got) > time.Minute {
+		t.Errorf("Now() = %s, want the current time for an invalid SOURCE_DATE_EPOCH", got)
+	}
+}
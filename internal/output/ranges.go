@@ -0,0 +1,139 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// rangeTable lays out the churn profiles of layers side by side: a header
+// row of layer names, one row per category with its churn and share of the
+// layer's total, then the totals. The first cell of each row is its label.
+func rangeTable(layers []Layer) [][]string {
+	header := []string{""}
+	for _, l := range layers {
+		header = append(header, l.Name)
+	}
+	rows := [][]string{header}
+
+	for _, cat := range categoryOrder {
+		present := false
+		for _, l := range layers {
+			present = present || l.Summary.CategoryTotals[cat.key].FileCount > 0
+		}
+		if !present {
+			continue
+		}
+		row := []string{cat.display}
+		for _, l := range layers {
+			churn := l.Summary.CategoryTotals[cat.key].Churn
+			row = append(row, fmt.Sprintf("%d (%s)", churn, share(churn, l.Summary.Totals.Churn)))
+		}
+		rows = append(rows, row)
+	}
+
+	totals := []struct {
+		label string
+		value func(CategoryTotal) string
+	}{
+		{"Total", func(t CategoryTotal) string { return fmt.Sprint(t.Churn) }},
+		{"Added", func(t CategoryTotal) string { return fmt.Sprint(t.Added) }},
+		{"Deleted", func(t CategoryTotal) string { return fmt.Sprint(t.Deleted) }},
+		{"Net", func(t CategoryTotal) string { return fmt.Sprintf("%+d", t.Net()) }},
+		{"Files", func(t CategoryTotal) string { return fmt.Sprint(t.FileCount) }},
+	}
+	for _, total := range totals {
+		row := []string{total.label}
+		for _, l := range layers {
+			row = append(row, total.value(l.Summary.Totals))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// share formats part as a whole-number percentage of whole.
+func share(part, whole int) string {
+	if whole == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", (part*100+whole/2)/whole)
+}
+
+// RenderRangesText writes a table comparing the churn profile of each
+// layer, such as alternative branches, one column per layer.
+func RenderRangesText(w io.Writer, layers []Layer) {
+	rows := rangeTable(layers)
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	for _, row := range rows {
+		cells := []string{fmt.Sprintf("%-*s", widths[0], row[0])}
+		for i, cell := range row[1:] {
+			cells = append(cells, fmt.Sprintf("%*s", widths[i+1], cell))
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+}
+
+// RenderRangesMarkdown writes the table of RenderRangesText as a markdown
+// table.
+func RenderRangesMarkdown(w io.Writer, layers []Layer) {
+	rows := rangeTable(layers)
+	rows[0][0] = "Category"
+	for i, row := range rows {
+		fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+		if i == 0 {
+			align := []string{"---"}
+			for range row[1:] {
+				align = append(align, "---:")
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(align, " | "))
+		}
+	}
+}
+
+type jsonRange struct {
+	Range      string                       `json:"range"`
+	Meta       jsonMeta                     `json:"meta"`
+	Total      jsonTotal                    `json:"total"`
+	ByCategory map[string]jsonCategoryShare `json:"by_category"`
+}
+
+type jsonCategoryShare struct {
+	jsonTotal
+	Share float64 `json:"share"` // fraction of the range's churn, to 3 places
+}
+
+// RenderRangesJSON writes a JSON object with a "ranges" array holding each
+// layer's totals and, per category, its totals and share of the churn.
+func RenderRangesJSON(w io.Writer, layers []Layer) error {
+	out := struct {
+		Ranges []jsonRange `json:"ranges"`
+	}{Ranges: make([]jsonRange, 0, len(layers))}
+	for _, l := range layers {
+		r := jsonRange{
+			Range:      l.Name,
+			Meta:       toJSONMeta(l.Summary.Meta),
+			Total:      toJSONTotal(l.Summary.Totals),
+			ByCategory: make(map[string]jsonCategoryShare, len(l.Summary.CategoryTotals)),
+		}
+		for cat, ct := range l.Summary.CategoryTotals {
+			var s float64
+			if total := l.Summary.Totals.Churn; total > 0 {
+				s = math.Round(float64(ct.Churn)/float64(total)*1000) / 1000
+			}
+			r.ByCategory[cat] = jsonCategoryShare{jsonTotal: toJSONTotal(ct), Share: s}
+		}
+		out.Ranges = append(out.Ranges, r)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testRanges() []Layer {
+	a := Summary{
+		Totals: CategoryTotal{Added: 50, Deleted: 10, Churn: 60, FileCount: 3},
+		CategoryTotals: map[string]CategoryTotal{
+			"source": {Added: 40, Deleted: 5, Churn: 45, FileCount: 2},
+			"tests":  {Added: 10, Deleted: 5, Churn: 15, FileCount: 1},
+		},
+	}
+	b := Summary{
+		Totals:         CategoryTotal{Added: 5, Deleted: 15, Churn: 20, FileCount: 1},
+		CategoryTotals: map[string]CategoryTotal{"source": {Added: 5, Deleted: 15, Churn: 20, FileCount: 1}},
+	}
+	return []Layer{{Name: "main...a", Summary: a}, {Name: "main...b", Summary: b}}
+}
+
+func TestRenderRangesText(t *testing.T) {
+	var buf bytes.Buffer
+	RenderRangesText(&buf, testRanges())
+	want := `         main...a   main...b
+Tests    15 (25%)     0 (0%)
+Source   45 (75%)  20 (100%)
+Total          60         20
+Added          50          5
+Deleted        10         15
+Net           +40        -10
+Files           3          1
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestRenderRangesMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	RenderRangesMarkdown(&buf, testRanges())
+	got := buf.String()
+	for _, want := range []string{
+		"| Category | main...a | main...b |\n| --- | ---: | ---: |\n",
+		"| Source | 45 (75%) | 20 (100%) |\n",
+		"| Net | +40 | -10 |\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderRangesJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderRangesJSON(&buf, testRanges()); err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Ranges []struct {
+			Range      string `json:"range"`
+			Total      jsonTotal
+			ByCategory map[string]struct {
+				Churn int     `json:"churn"`
+				Share float64 `json:"share"`
+			} `json:"by_category"`
+		} `json:"ranges"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(result.Ranges) != 2 || result.Ranges[1].Range != "main...b" {
+		t.Fatalf("ranges = %+v", result.Ranges)
+	}
+	if got := result.Ranges[0].ByCategory["tests"]; got.Churn != 15 || got.Share != 0.25 {
+		t.Errorf("tests = %+v, want churn 15 and share 0.25", got)
+	}
+}
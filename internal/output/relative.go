@@ -0,0 +1,53 @@
+package output
+
+import (
+	"path"
+	"strings"
+)
+
+// Relativize rewrites the paths in summary, which are relative to the
+// repository root, to be relative to dir, a slash-separated directory
+// below the root, as git diff --relative shows them. Files outside dir get
+// "../" paths. Links keep pointing at the files. An empty dir, or ".",
+// leaves summary unchanged.
+func Relativize(summary *Summary, dir string) {
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+	if dir == "" {
+		return
+	}
+	summary.Meta.Relative = dir
+	for i := range summary.FileStats {
+		f := &summary.FileStats[i]
+		f.Path = relativePath(dir, f.Path)
+		if f.OldPath != "" {
+			f.OldPath = relativePath(dir, f.OldPath)
+		}
+	}
+	for i := range summary.Meta.Warnings {
+		if w := &summary.Meta.Warnings[i]; w.Path != "" {
+			w.Path = relativePath(dir, w.Path)
+		}
+	}
+	if api := summary.Meta.APIChurn; api != nil {
+		for i := range api.Symbols {
+			api.Symbols[i].Path = relativePath(dir, api.Symbols[i].Path)
+		}
+	}
+	for i := range summary.Meta.SchemaChanges {
+		summary.Meta.SchemaChanges[i].Path = relativePath(dir, summary.Meta.SchemaChanges[i].Path)
+	}
+	for i := range summary.Meta.Submodules {
+		summary.Meta.Submodules[i].Path = relativePath(dir, summary.Meta.Submodules[i].Path)
+	}
+}
+
+// relativePath returns the root-relative path p relative to dir.
+func relativePath(dir, p string) string {
+	d := strings.Split(dir, "/")
+	f := strings.Split(p, "/")
+	i := 0
+	for i < len(d) && i < len(f)-1 && d[i] == f[i] {
+		i++
+	}
+	return strings.Repeat("../", len(d)-i) + strings.Join(f[i:], "/")
+}
@@ -0,0 +1,48 @@
+package output
+
+import "testing"
+
+func TestRelativize(t *testing.T) {
+	s := Summary{
+		FileStats: []FileStat{
+			{Path: "cmd/differ/main.go"},
+			{Path: "cmd/other/x.go", OldPath: "cmd/differ/x.go"},
+			{Path: "README.md"},
+			{Path: "cmd/differ.go"},
+		},
+		Meta: Meta{Warnings: []Warning{{Path: "db/1.sql"}, {Rule: "unknown-category"}}},
+	}
+	Relativize(&s, "cmd/differ/")
+
+	want := []string{"main.go", "../other/x.go", "../../README.md", "../differ.go"}
+	for i, w := range want {
+		if s.FileStats[i].Path != w {
+			t.Errorf("path %d = %q, want %q", i, s.FileStats[i].Path, w)
+		}
+	}
+	if s.FileStats[1].OldPath != "x.go" {
+		t.Errorf("old path = %q, want x.go", s.FileStats[1].OldPath)
+	}
+	if s.Meta.Warnings[0].Path != "../../db/1.sql" || s.Meta.Warnings[1].Path != "" {
+		t.Errorf("warnings = %+v", s.Meta.Warnings)
+	}
+	if s.Meta.Relative != "cmd/differ" {
+		t.Errorf("meta relative = %q", s.Meta.Relative)
+	}
+
+	// At the root nothing changes.
+	for _, dir := range []string{"", ".", "./"} {
+		s = Summary{FileStats: []FileStat{{Path: "a/b.go"}}}
+		Relativize(&s, dir)
+		if s.FileStats[0].Path != "a/b.go" || s.Meta.Relative != "" {
+			t.Errorf("at the root (%q): %+v", dir, s)
+		}
+	}
+
+	// Directories are cleaned, as --relative-to may give them.
+	s = Summary{FileStats: []FileStat{{Path: "services/api/main.go"}}}
+	Relativize(&s, "./services//api/")
+	if s.FileStats[0].Path != "main.go" || s.Meta.Relative != "services/api" {
+		t.Errorf("cleaned dir: %+v", s)
+	}
+}
@@ -0,0 +1,77 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Renderer writes a summary in one output format. Every format the CLI's
+// --format flag accepts is a registered Renderer; register one with
+// RegisterRenderer to add a format. Render should honor the Options fields
+// that make sense for its format and ignore the rest, and must not modify
+// summary.
+type Renderer interface {
+	Render(w io.Writer, summary Summary, opts Options) error
+}
+
+// RendererFunc adapts a function to the Renderer interface.
+type RendererFunc func(w io.Writer, summary Summary, opts Options) error
+
+// Render calls f(w, summary, opts).
+func (f RendererFunc) Render(w io.Writer, summary Summary, opts Options) error {
+	return f(w, summary, opts)
+}
+
+var renderers = map[string]Renderer{
+	"text": RendererFunc(func(w io.Writer, summary Summary, opts Options) error {
+		RenderText(w, summary, opts)
+		return nil
+	}),
+	"json": RendererFunc(func(w io.Writer, summary Summary, opts Options) error {
+		if opts.Sort != "" {
+			files := make([]FileStat, len(summary.FileStats))
+			copy(files, summary.FileStats)
+			sortFiles(files, opts.Sort)
+			summary.FileStats = files
+		}
+		return renderJSON(w, summary, opts)
+	}),
+	"markdown": RendererFunc(func(w io.Writer, summary Summary, opts Options) error {
+		RenderMarkdown(w, summary, opts)
+		return nil
+	}),
+	"csv":   RendererFunc(RenderCSV),
+	"table": RendererFunc(RenderTable),
+	"html":  RendererFunc(RenderHTML),
+	"prometheus": RendererFunc(func(w io.Writer, summary Summary, _ Options) error {
+		return RenderPrometheus(w, summary)
+	}),
+}
+
+// RegisterRenderer makes a Renderer available as an output format under
+// name, such as "csv". Registering an existing name replaces it.
+func RegisterRenderer(name string, r Renderer) {
+	renderers[name] = r
+}
+
+// Formats returns the names of all registered output formats in sorted
+// order.
+func Formats() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupRenderer returns the Renderer for the named output format.
+func LookupRenderer(name string) (Renderer, error) {
+	r, ok := renderers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (available: %s)", name, strings.Join(Formats(), ", "))
+	}
+	return r, nil
+}
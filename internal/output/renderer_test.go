@@ -0,0 +1,135 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinRenderers(t *testing.T) {
+	for _, name := range []string{"text", "json", "markdown", "csv", "html", "prometheus"} {
+		if !slices.Contains(Formats(), name) {
+			t.Fatalf("Formats() = %v, want %s", Formats(), name)
+		}
+	}
+
+	text, err := LookupRenderer("text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want bytes.Buffer
+	opts := Options{List: true, NoColor: true}
+	if err := text.Render(&got, testSummary(), opts); err != nil {
+		t.Fatal(err)
+	}
+	RenderText(&want, testSummary(), opts)
+	if got.String() != want.String() {
+		t.Errorf("text renderer differs from RenderText:\n%s\nwant:\n%s", got.String(), want.String())
+	}
+}
+
+func TestJSONRendererSortsFiles(t *testing.T) {
+	r, err := LookupRenderer("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := testSummary()
+	for _, tt := range []struct {
+		sort  string
+		first string
+	}{
+		{"", "internal/foo/bar.go"}, // order as given
+		{"churn", "internal/baz/baz.go"},
+		{"path", "Makefile"},
+	} {
+		var buf bytes.Buffer
+		if err := r.Render(&buf, s, Options{Sort: tt.sort}); err != nil {
+			t.Fatal(err)
+		}
+		var result struct {
+			ByFile []jsonFile `json:"by_file"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.ByFile[0].Path != tt.first {
+			t.Errorf("sort %q: by_file[0] = %q, want %q", tt.sort, result.ByFile[0].Path, tt.first)
+		}
+	}
+	if s.FileStats[0].Path != "internal/foo/bar.go" {
+		t.Error("json renderer reordered the caller's summary")
+	}
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	t.Cleanup(func() { delete(renderers, "paths") })
+	RegisterRenderer("paths", RendererFunc(func(w io.Writer, s Summary, _ Options) error {
+		for _, f := range s.FileStats {
+			if _, err := io.WriteString(w, f.Path+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	r, err := LookupRenderer("paths")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := r.Render(&buf, testSummary(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "internal/foo/bar.go\n") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+	if !slices.Contains(Formats(), "paths") {
+		t.Errorf("Formats() = %v, want paths included", Formats())
+	}
+}
+
+func TestLookupUnknownRenderer(t *testing.T) {
+	_, err := LookupRenderer("xml")
+	if err == nil || !strings.Contains(err.Error(), "available: csv, html, json, markdown, prometheus, table, text") {
+		t.Errorf("err = %v, want the available formats", err)
+	}
+}
+
+// TestRenderersDeterministic renders the same summary repeatedly with every
+// built-in format. Map iteration order varies between runs, so any output
+// that depends on it shows up as a difference.
+func TestRenderersDeterministic(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	summary := func() Summary {
+		s := testSummary()
+		s.FileStats = append(s.FileStats,
+			FileStat{Path: "locales/fr.json", Added: 3, Churn: 3, Category: "i18n"},
+			FileStat{Path: "locales/de.json", Added: 3, Churn: 3, Category: "i18n"},
+		)
+		s.CategoryTotals["i18n"] = CategoryTotal{Added: 6, Churn: 6, FileCount: 2}
+		return s
+	}
+	for _, format := range []string{"text", "json", "markdown", "csv", "html", "prometheus"} {
+		r, err := LookupRenderer(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := Options{List: true, Sort: "churn", NoColor: true, GroupBy: "language"}
+		var first bytes.Buffer
+		if err := r.Render(&first, summary(), opts); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 20; i++ {
+			var buf bytes.Buffer
+			if err := r.Render(&buf, summary(), opts); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), first.Bytes()) {
+				t.Fatalf("%s output changed between renders:\n%s\nthen:\n%s", format, first.String(), buf.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package owners
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+)
+
+// Blame returns the number of lines each author email owns in path, which
+// is relative to the repository root, at rev, using `git blame
+// --line-porcelain`.
+func Blame(runner gitdiff.CommandRunner, rev, path string) (map[string]int, error) {
+	top, err := gitdiff.TopLevel(runner)
+	if err != nil {
+		return nil, err
+	}
+	out, err := runner.Run("git", "-C", top, "blame", "--line-porcelain", rev, "--", path)
+	if err != nil {
+		return nil, fmt.Errorf("blaming %s at %s: %w", path, rev, err)
+	}
+	return parseBlame(out), nil
+}
+
+// parseBlame counts author-mail entries in line-porcelain output.
+func parseBlame(out []byte) map[string]int {
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "author-mail ") {
+			continue
+		}
+		mail := strings.Trim(strings.TrimPrefix(line, "author-mail "), "<>")
+		counts[mail]++
+	}
+	return counts
+}
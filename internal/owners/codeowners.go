@@ -0,0 +1,119 @@
+package owners
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// codeownersLocations lists where CODEOWNERS files are searched, in GitHub's
+// lookup order.
+var codeownersLocations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// Rule is a single CODEOWNERS line: a path pattern and its owners.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	globs   []string
+}
+
+// Codeowners is an ordered CODEOWNERS rule list; later rules take precedence.
+type Codeowners struct {
+	Rules []Rule
+}
+
+// LoadCodeowners reads the first CODEOWNERS file found under repoRoot.
+// It returns (nil, nil) when the repository has none.
+func LoadCodeowners(repoRoot string) (*Codeowners, error) {
+	for _, loc := range codeownersLocations {
+		f, err := os.Open(filepath.Join(repoRoot, loc))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		defer f.Close()
+		return ParseCodeowners(f)
+	}
+	return nil, nil
+}
+
+// ParseCodeowners parses CODEOWNERS content. Comments, blank lines, and
+// patterns without owners are skipped.
+func ParseCodeowners(r io.Reader) (*Codeowners, error) {
+	co := &Codeowners{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		co.Rules = append(co.Rules, Rule{
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			globs:   patternGlobs(fields[0]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return co, nil
+}
+
+// OwnersOf returns the owners of path according to the last matching rule,
+// or nil when no rule matches.
+func (co *Codeowners) OwnersOf(path string) []string {
+	if co == nil {
+		return nil
+	}
+	for i := len(co.Rules) - 1; i >= 0; i-- {
+		for _, g := range co.Rules[i].globs {
+			if matched, _ := doublestar.Match(g, path); matched {
+				return co.Rules[i].Owners
+			}
+		}
+	}
+	return nil
+}
+
+// patternGlobs translates a gitignore-style CODEOWNERS pattern into doublestar
+// globs: a leading "/" anchors to the repo root, a pattern without a slash
+// matches at any depth, and a pattern naming a directory covers its contents.
+// A pattern ending in a "*" or "**" segment matches only what it says, so
+// docs/* covers the files directly in docs, not those in its subdirectories.
+func patternGlobs(pattern string) []string {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	p := strings.TrimSuffix(pattern, "/")
+
+	switch {
+	case strings.HasPrefix(p, "/"):
+		p = strings.TrimPrefix(p, "/")
+	case !strings.Contains(p, "/") && p != "*":
+		p = "**/" + p
+	case p == "*":
+		return []string{"**"}
+	}
+
+	if dirOnly {
+		return []string{p + "/**"}
+	}
+	if last := path.Base(p); last == "*" || last == "**" {
+		return []string{p}
+	}
+	return []string{p, p + "/**"}
+}
@@ -0,0 +1,105 @@
+package owners
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleCodeowners = `
+# Default owners
+*               @org/core
+
+/docs/          @org/docs
+*.go            @gopher
+/cmd/differ/    @cli-owner @gopher
+internal/output/**  @renderer # trailing comment
+Makefile
+`
+
+func TestParseCodeownersSkipsOwnerlessLines(t *testing.T) {
+	co, err := ParseCodeowners(strings.NewReader(sampleCodeowners))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(co.Rules) != 5 {
+		t.Fatalf("expected 5 rules, got %d: %+v", len(co.Rules), co.Rules)
+	}
+}
+
+func TestOwnersOfLastMatchWins(t *testing.T) {
+	co, err := ParseCodeowners(strings.NewReader(sampleCodeowners))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"README.md", "@org/core"},
+		{"docs/usage.md", "@org/docs"},
+		{"internal/parser/parser.go", "@gopher"},
+		{"cmd/differ/main.go", "@cli-owner,@gopher"},
+		{"internal/output/output.go", "@renderer"},
+		{"nested/docs/x.md", "@org/core"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(co.OwnersOf(tt.path), ","); got != tt.want {
+			t.Errorf("OwnersOf(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestOwnersOfTrailingWildcard(t *testing.T) {
+	co, err := ParseCodeowners(strings.NewReader("docs/* @docs\nassets/** @design\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"docs/usage.md", "@docs"},
+		{"docs/api/index.md", ""},
+		{"assets/logo.svg", "@design"},
+		{"assets/icons/x.svg", "@design"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(co.OwnersOf(tt.path), ","); got != tt.want {
+			t.Errorf("OwnersOf(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestOwnersOfNil(t *testing.T) {
+	var co *Codeowners
+	if got := co.OwnersOf("main.go"); got != nil {
+		t.Errorf("nil Codeowners returned %v", got)
+	}
+}
+
+func TestLoadCodeownersLocations(t *testing.T) {
+	dir := t.TempDir()
+	co, err := LoadCodeowners(dir)
+	if err != nil || co != nil {
+		t.Fatalf("expected (nil, nil) without CODEOWNERS, got %v, %v", co, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".github", "CODEOWNERS"), []byte("* @a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "CODEOWNERS"), []byte("* @b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	co, err = LoadCodeowners(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := co.OwnersOf("x.go"); len(got) != 1 || got[0] != "@a" {
+		t.Errorf("expected .github/CODEOWNERS to take precedence, got %v", got)
+	}
+}
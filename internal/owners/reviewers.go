@@ -0,0 +1,84 @@
+package owners
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChangedFile is the subset of per-file diff stats reviewer scoring needs.
+type ChangedFile struct {
+	Path  string
+	Churn int
+}
+
+// Suggestion is a candidate reviewer with the churn they own.
+type Suggestion struct {
+	Reviewer string
+	// Score is the number of changed lines attributed to the reviewer.
+	Score float64
+	// Share is Score as a fraction of total churn.
+	Share float64
+	// Sources lists where ownership came from ("codeowners", "blame").
+	Sources []string
+}
+
+// Suggest ranks reviewers by how much of the changed code they own. Every
+// CODEOWNERS owner of a file is credited with the file's full churn; blame
+// authors are credited with the churn weighted by their share of the file's
+// lines. Reviewers in exclude (e.g. the change author) are skipped.
+func Suggest(files []ChangedFile, co *Codeowners, blame map[string]map[string]int, exclude []string) []Suggestion {
+	skip := make(map[string]bool, len(exclude))
+	for _, e := range exclude {
+		skip[strings.ToLower(e)] = true
+	}
+
+	scores := make(map[string]*Suggestion)
+	credit := func(reviewer, source string, amount float64) {
+		if amount <= 0 || skip[strings.ToLower(reviewer)] {
+			return
+		}
+		s, ok := scores[reviewer]
+		if !ok {
+			s = &Suggestion{Reviewer: reviewer}
+			scores[reviewer] = s
+		}
+		s.Score += amount
+		for _, src := range s.Sources {
+			if src == source {
+				return
+			}
+		}
+		s.Sources = append(s.Sources, source)
+	}
+
+	total := 0
+	for _, f := range files {
+		total += f.Churn
+		for _, owner := range co.OwnersOf(f.Path) {
+			credit(owner, "codeowners", float64(f.Churn))
+		}
+		lines := 0
+		for _, n := range blame[f.Path] {
+			lines += n
+		}
+		for author, n := range blame[f.Path] {
+			credit(author, "blame", float64(f.Churn)*float64(n)/float64(lines))
+		}
+	}
+
+	result := make([]Suggestion, 0, len(scores))
+	for _, s := range scores {
+		if total > 0 {
+			s.Share = s.Score / float64(total)
+		}
+		sort.Strings(s.Sources)
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Reviewer < result[j].Reviewer
+	})
+	return result
+}
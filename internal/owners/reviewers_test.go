@@ -0,0 +1,55 @@
+package owners
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseBlame(t *testing.T) {
+	out := []byte(`abc 1 1 2
+author Alice
+author-mail <alice@example.com>
+	line one
+abc 2 2
+author Alice
+author-mail <alice@example.com>
+	line two
+def 3 3 1
+author Bob
+author-mail <bob@example.com>
+	line three
+`)
+	counts := parseBlame(out)
+	if counts["alice@example.com"] != 2 || counts["bob@example.com"] != 1 {
+		t.Errorf("counts = %v", counts)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	co, err := ParseCodeowners(strings.NewReader("*.go @gopher\n/docs/ @docs\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []ChangedFile{
+		{Path: "main.go", Churn: 60},
+		{Path: "docs/a.md", Churn: 40},
+	}
+	blame := map[string]map[string]int{
+		"main.go": {"alice@example.com": 3, "me@example.com": 1},
+	}
+
+	got := Suggest(files, co, blame, []string{"ME@example.com"})
+	if len(got) != 3 {
+		t.Fatalf("expected 3 suggestions, got %+v", got)
+	}
+	if got[0].Reviewer != "@gopher" || got[0].Score != 60 || math.Abs(got[0].Share-0.6) > 1e-9 {
+		t.Errorf("got[0] = %+v", got[0])
+	}
+	if got[1].Reviewer != "alice@example.com" || got[1].Score != 45 || got[1].Sources[0] != "blame" {
+		t.Errorf("got[1] = %+v", got[1])
+	}
+	if got[2].Reviewer != "@docs" || got[2].Score != 40 {
+		t.Errorf("got[2] = %+v", got[2])
+	}
+}
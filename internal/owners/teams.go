@@ -0,0 +1,75 @@
+package owners
+
+import (
+	"sort"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// Unassigned is the team RollupTeams credits with files no team owns.
+const Unassigned = "unassigned"
+
+// TeamsOf returns the teams, sorted by name, with a glob in teams that
+// matches path.
+func TeamsOf(path string, teams map[string][]string) []string {
+	var matched []string
+	for name, globs := range teams {
+		for _, g := range globs {
+			if ok, _ := doublestar.Match(g, path); ok {
+				matched = append(matched, name)
+				break
+			}
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// RollupTeams sums the churn of files per team, overall and per category.
+// A file owned by several teams counts for each of them, and files owned
+// by none count for Unassigned. Teams are ordered by churn and then name,
+// with Unassigned last; teams without changed files are left out.
+func RollupTeams(files []output.FileStat, teams map[string][]string) []output.Team {
+	index := make(map[string]int)
+	var rollup []output.Team
+	for _, f := range files {
+		owners := TeamsOf(f.Path, teams)
+		if len(owners) == 0 {
+			owners = []string{Unassigned}
+		}
+		for _, name := range owners {
+			i, ok := index[name]
+			if !ok {
+				i = len(rollup)
+				index[name] = i
+				rollup = append(rollup, output.Team{Name: name, CategoryTotals: make(map[string]output.CategoryTotal)})
+			}
+			t := &rollup[i]
+			t.Totals = addFile(t.Totals, f)
+			t.CategoryTotals[f.Category] = addFile(t.CategoryTotals[f.Category], f)
+		}
+	}
+	sort.Slice(rollup, func(i, j int) bool {
+		a, b := rollup[i], rollup[j]
+		if (a.Name == Unassigned) != (b.Name == Unassigned) {
+			return b.Name == Unassigned
+		}
+		if a.Totals.Churn != b.Totals.Churn {
+			return a.Totals.Churn > b.Totals.Churn
+		}
+		return a.Name < b.Name
+	})
+	return rollup
+}
+
+func addFile(ct output.CategoryTotal, f output.FileStat) output.CategoryTotal {
+	ct.Added += f.Added
+	ct.Deleted += f.Deleted
+	ct.Churn += f.Churn
+	ct.Moved += f.Moved
+	ct.RawAdded += f.RawAdded
+	ct.RawDeleted += f.RawDeleted
+	ct.FileCount++
+	return ct
+}
@@ -0,0 +1,61 @@
+package owners
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+var testTeams = map[string][]string{
+	"payments": {"services/payments/**", "billing/**"},
+	"platform": {"infra/**", "billing/**"},
+	"web":      {"web/**"},
+}
+
+func TestTeamsOf(t *testing.T) {
+	tests := map[string][]string{
+		"services/payments/api.go": {"payments"},
+		"billing/invoice.go":       {"payments", "platform"},
+		"infra/main.tf":            {"platform"},
+		"README.md":                nil,
+	}
+	for path, want := range tests {
+		if got := TeamsOf(path, testTeams); !reflect.DeepEqual(got, want) {
+			t.Errorf("TeamsOf(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestRollupTeams(t *testing.T) {
+	files := []output.FileStat{
+		{Path: "services/payments/api.go", Added: 10, Deleted: 2, Churn: 12, Category: "source"},
+		{Path: "billing/invoice_test.go", Added: 4, Churn: 4, Category: "tests"},
+		{Path: "infra/main.tf", Added: 1, Deleted: 1, Churn: 2, Category: "source"},
+		{Path: "README.md", Added: 30, Churn: 30, Category: "docs"},
+	}
+	got := RollupTeams(files, testTeams)
+
+	var names []string
+	for _, team := range got {
+		names = append(names, team.Name)
+	}
+	// Unassigned comes last even with the most churn, and web, with no
+	// changed files, is left out.
+	if want := []string{"payments", "platform", "unassigned"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("teams = %v, want %v", names, want)
+	}
+	payments := got[0]
+	if want := (output.CategoryTotal{Added: 14, Deleted: 2, Churn: 16, FileCount: 2}); payments.Totals != want {
+		t.Errorf("payments totals = %+v, want %+v", payments.Totals, want)
+	}
+	if ct := payments.CategoryTotals["tests"]; ct.Churn != 4 || ct.FileCount != 1 {
+		t.Errorf("payments tests = %+v, want churn 4 in 1 file", ct)
+	}
+	if platform := got[1]; platform.Totals.Churn != 6 || platform.Totals.FileCount != 2 {
+		t.Errorf("platform totals = %+v, want churn 6 in 2 files", platform.Totals)
+	}
+	if unassigned := got[2]; unassigned.Totals.Churn != 30 || unassigned.CategoryTotals["docs"].FileCount != 1 {
+		t.Errorf("unassigned = %+v, want the README's 30 lines of docs", unassigned)
+	}
+}
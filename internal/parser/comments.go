@@ -0,0 +1,97 @@
+package parser
+
+import "strings"
+
+// CommentSyntax is how a language writes comments. The zero value has no
+// comments, so only blank lines are left out of meaningful counts.
+type CommentSyntax struct {
+	// Line lists the markers of comments that run to the end of the line,
+	// such as "//" or "#".
+	Line []string
+	// Block lists the opening and closing delimiters of block comments,
+	// such as {"/*", "*/"}.
+	Block [][2]string
+}
+
+// commentTracker follows one side, added or deleted, of a file's changed
+// lines to tell which hold only comments. Block comments are tracked across
+// consecutive changed lines of a hunk; a block opened in an unchanged line
+// is recognized only by a leading "*", as in
+//
+//	/**
+//	 * continuation lines like this one,
+//	 */
+//
+// or by its closing delimiter.
+type commentTracker struct {
+	syntax CommentSyntax
+	open   int // 1 + the index in syntax.Block of the open block comment, or 0
+}
+
+// reset forgets an open block comment, at the start of a hunk.
+func (t *commentTracker) reset() { t.open = 0 }
+
+// commentOnly reports whether content holds nothing but comments and
+// whitespace, updating whether a block comment is left open.
+func (t *commentTracker) commentOnly(content string) bool {
+	s := strings.TrimSpace(content)
+	if t.open == 0 && len(s) > 0 && s[0] == '*' && t.hasBlock("/*") {
+		// The middle of a block comment opened before the hunk.
+		if strings.Trim(s, "*") == "" || strings.HasPrefix(s, "* ") || strings.HasPrefix(s, "*\t") || strings.HasPrefix(s, "*/") {
+			return !strings.Contains(s, "*/") || t.skipClosed(s, "*/")
+		}
+	}
+	for s != "" {
+		if t.open > 0 {
+			end := t.syntax.Block[t.open-1][1]
+			i := strings.Index(s, end)
+			if i < 0 {
+				return true
+			}
+			s = strings.TrimSpace(s[i+len(end):])
+			t.open = 0
+			continue
+		}
+		// Block openers are tried first: some, like Lua's "--[[", start
+		// with the language's line comment marker.
+		opened := false
+		for i, b := range t.syntax.Block {
+			if strings.HasPrefix(s, b[0]) {
+				s = s[len(b[0]):]
+				t.open = i + 1
+				opened = true
+				break
+			}
+		}
+		if !opened {
+			return t.lineComment(s)
+		}
+	}
+	return true
+}
+
+// skipClosed reports whether what follows the first end in s is only
+// comments, as when a line closes a block comment opened before the hunk.
+func (t *commentTracker) skipClosed(s, end string) bool {
+	i := strings.Index(s, end)
+	rest := strings.TrimSpace(s[i+len(end):])
+	return rest == "" || t.commentOnly(rest)
+}
+
+func (t *commentTracker) lineComment(s string) bool {
+	for _, marker := range t.syntax.Line {
+		if strings.HasPrefix(s, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *commentTracker) hasBlock(open string) bool {
+	for _, b := range t.syntax.Block {
+		if b[0] == open {
+			return true
+		}
+	}
+	return false
+}
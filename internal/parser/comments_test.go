@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+var cSyntax = CommentSyntax{Line: []string{"//"}, Block: [][2]string{{"/*", "*/"}}}
+
+func TestCommentOnly(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []bool
+	}{
+		{"line comments", []string{"// note", "x := 1 // trailing", "\t//"}, []bool{true, false, true}},
+		{"block across lines", []string{"/*", "license text", "*/", "x := 1"}, []bool{true, true, true, false}},
+		{"block then code", []string{"/* a */ x := 1", "/* a */ // b"}, []bool{false, true}},
+		{"doc continuation", []string{" * Copyright 2024", " */", "*p = 1"}, []bool{true, true, false}},
+		{"closed then code", []string{" * end */ return"}, []bool{false}},
+		{"banner", []string{"/*****", "*****/"}, []bool{true, true}},
+	}
+	for _, tt := range tests {
+		tracker := commentTracker{syntax: cSyntax}
+		for i, line := range tt.lines {
+			if got := tracker.commentOnly(line); got != tt.want[i] {
+				t.Errorf("%s: commentOnly(%q) = %v, want %v", tt.name, line, got, tt.want[i])
+			}
+		}
+	}
+
+	hash := commentTracker{syntax: CommentSyntax{Line: []string{"#"}}}
+	if !hash.commentOnly("# comment") || hash.commentOnly("* not a comment") {
+		t.Error("# syntax: want only the # line to be a comment")
+	}
+}
+
+func TestParseMeaningfulLines(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1234567..abcdefg 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,0 @@
+-// Copyright 2023 Example
+-// Licensed under MIT.
+-
+-package main
+@@ -0,0 +1,6 @@
++/*
++ * Copyright 2024 Example
++ */
++
++package main // the main package
++
+diff --git a/notes.txt b/notes.txt
+index 1234567..abcdefg 100644
+--- a/notes.txt
++++ b/notes.txt
+@@ -1 +1 @@
+-// old
++// new
+`
+	comments := func(path string) CommentSyntax {
+		if strings.HasSuffix(path, ".go") {
+			return cSyntax
+		}
+		return CommentSyntax{}
+	}
+	stats, err := ParseWithOptions(strings.NewReader(diff), ParseOptions{Empty: "include", Comments: comments})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(stats))
+	}
+	got := stats[0]
+	if got.Added != 1 || got.Deleted != 1 || got.Churn != 2 {
+		t.Errorf("main.go: +%d -%d (%d), want only the package lines: +1 -1 (2)", got.Added, got.Deleted, got.Churn)
+	}
+	if got.RawAdded != 6 || got.RawDeleted != 4 {
+		t.Errorf("main.go raw: +%d -%d, want +6 -4", got.RawAdded, got.RawDeleted)
+	}
+	// Without a comment syntax, only blank lines would be left out.
+	if notes := stats[1]; notes.Added != 1 || notes.Deleted != 1 {
+		t.Errorf("notes.txt: +%d -%d, want +1 -1", notes.Added, notes.Deleted)
+	}
+
+	plain, err := Parse(strings.NewReader(diff), "include")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain[0].RawAdded != 0 || plain[0].Added != 6 {
+		t.Errorf("without Comments: added %d, raw %d, want 6 and no raw count", plain[0].Added, plain[0].RawAdded)
+	}
+}
+
+func TestCommentOnlyBlockBeforeLineMarker(t *testing.T) {
+	lua := commentTracker{syntax: CommentSyntax{Line: []string{"--"}, Block: [][2]string{{"--[[", "]]"}}}}
+	for i, line := range []string{"--[[", "local x = 1", "]]", "-- note", "print(x)"} {
+		want := i != 4
+		if got := lua.commentOnly(line); got != want {
+			t.Errorf("commentOnly(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
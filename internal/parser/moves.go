@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Move detection follows git's --color-moved heuristics: a block of
+// consecutive lines counts as moved only when its lines together contain at
+// least minMovedAlnum alphanumeric characters, so stray braces and blank
+// lines that happen to match elsewhere are not treated as moves.
+const (
+	minMovedAlnum = 20
+	// maxMoveCandidates bounds how many deleted occurrences of a line are
+	// tried as the start of a block, keeping diffs with thousands of
+	// identical lines fast.
+	maxMoveCandidates = 64
+)
+
+// moveLine is one changed line kept for move detection.
+type moveLine struct {
+	key   string // content with whitespace normalized
+	alnum int
+	moved bool
+}
+
+// moveRun is a maximal sequence of consecutive added or deleted lines within
+// one file.
+type moveRun struct {
+	file  int
+	sign  byte
+	lines []moveLine
+}
+
+// moveIndex collects runs while a diff is parsed and, once it is complete,
+// matches added runs against deleted runs in other files.
+type moveIndex struct {
+	runs    []*moveRun
+	current *moveRun
+	files   int
+}
+
+// movedCounts holds the moved lines found in one file.
+type movedCounts struct {
+	added, deleted int
+}
+
+func (m *moveIndex) startFile() {
+	m.files++
+	m.current = nil
+}
+
+func (m *moveIndex) add(sign byte, content string) {
+	if m.current == nil || m.current.sign != sign {
+		m.current = &moveRun{file: m.files - 1, sign: sign}
+		m.runs = append(m.runs, m.current)
+	}
+	key := strings.Join(strings.Fields(content), " ")
+	m.current.lines = append(m.current.lines, moveLine{key: key, alnum: countAlnum(key)})
+}
+
+func (m *moveIndex) endRun() {
+	m.current = nil
+}
+
+// detect greedily pairs each added block with the longest matching deleted
+// block from a different file and returns the moved line counts per file,
+// indexed in diff order.
+func (m *moveIndex) detect() []movedCounts {
+	type pos struct{ run, line int }
+	deleted := make(map[string][]pos)
+	for ri, run := range m.runs {
+		if run.sign != '-' {
+			continue
+		}
+		for li, l := range run.lines {
+			deleted[l.key] = append(deleted[l.key], pos{ri, li})
+		}
+	}
+
+	counts := make([]movedCounts, m.files)
+	for _, run := range m.runs {
+		if run.sign != '+' {
+			continue
+		}
+		for i := 0; i < len(run.lines); {
+			bestLen, best := 0, pos{}
+			tried := 0
+			for _, p := range deleted[run.lines[i].key] {
+				del := m.runs[p.run]
+				if del.file == run.file || del.lines[p.line].moved {
+					continue
+				}
+				if tried == maxMoveCandidates {
+					break
+				}
+				tried++
+				n := 0
+				for i+n < len(run.lines) && p.line+n < len(del.lines) &&
+					!del.lines[p.line+n].moved && del.lines[p.line+n].key == run.lines[i+n].key {
+					n++
+				}
+				if n > bestLen {
+					bestLen, best = n, p
+				}
+			}
+
+			alnum := 0
+			for _, l := range run.lines[i : i+bestLen] {
+				alnum += l.alnum
+			}
+			if bestLen == 0 || alnum < minMovedAlnum {
+				i++
+				continue
+			}
+
+			del := m.runs[best.run]
+			for k := 0; k < bestLen; k++ {
+				run.lines[i+k].moved = true
+				del.lines[best.line+k].moved = true
+			}
+			counts[run.file].added += bestLen
+			counts[del.file].deleted += bestLen
+			i += bestLen
+		}
+	}
+	return counts
+}
+
+func countAlnum(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			n++
+		}
+	}
+	return n
+}
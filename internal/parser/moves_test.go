@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+const movedDiff = `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -1,8 +1,3 @@
+ package a
+-
+-func helper(x int) int {
+-	return x * 2
+-}
+-
+-var unrelated = 1
++var unrelated = 2
+diff --git a/b.go b/b.go
+index 3333333..4444444 100644
+--- a/b.go
++++ b/b.go
+@@ -1,2 +1,6 @@
+ package b
++
++func helper(x int)   int {
++	return x * 2
++}
++}
+`
+
+func TestDetectMovesAcrossFiles(t *testing.T) {
+	stats, err := ParseWithOptions(strings.NewReader(movedDiff), ParseOptions{Empty: "exclude", DetectMoves: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(stats))
+	}
+
+	// a.go: the three helper lines moved out; the var change is real churn.
+	a := stats[0]
+	if a.Added != 1 || a.Deleted != 1 || a.Moved != 3 || a.Churn != 2 {
+		t.Errorf("a.go = %+v, want +1 -1 moved 3 churn 2", a)
+	}
+	// b.go: the helper moved in (whitespace-insensitive); the stray brace
+	// has too little content to count as moved.
+	b := stats[1]
+	if b.Added != 1 || b.Deleted != 0 || b.Moved != 3 || b.Churn != 1 {
+		t.Errorf("b.go = %+v, want +1 -0 moved 3 churn 1", b)
+	}
+}
+
+func TestDetectMovesOffByDefault(t *testing.T) {
+	stats, err := Parse(strings.NewReader(movedDiff), "exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats[0].Deleted != 4 || stats[0].Moved != 0 || stats[1].Added != 4 {
+		t.Errorf("without move detection got %+v", stats)
+	}
+}
+
+func TestDetectMovesIgnoresSameFile(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,3 @@
+-func reorderedWithinFile() {}
+ package a
++func reorderedWithinFile() {}
+`
+	stats, err := ParseWithOptions(strings.NewReader(diff), ParseOptions{Empty: "exclude", DetectMoves: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats[0].Moved != 0 || stats[0].Churn != 2 {
+		t.Errorf("same-file reorder should stay churn, got %+v", stats[0])
+	}
+}
+
+func TestDetectMovesMinimumContent(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,1 @@
+-x := 1
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1,1 +1,2 @@
++x := 1
+`
+	stats, err := ParseWithOptions(strings.NewReader(diff), ParseOptions{Empty: "exclude", DetectMoves: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats[0].Moved != 0 || stats[1].Moved != 0 {
+		t.Errorf("short lines should not count as moved, got %+v", stats)
+	}
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseNumstat reads `git diff --raw --numstat -z` output from r and
+// returns per-file add/delete counts. git counts every changed line, so the
+// result matches Parse with the "include" empty mode; binary files count as
+// zero lines, as they do there. The --raw records, which come first, supply
+// file modes; without them no statuses are set.
+func ParseNumstat(r io.Reader) ([]FileStat, error) {
+	var stats []FileStat
+	err := ParseNumstatFunc(r, func(fs FileStat) error {
+		stats = append(stats, fs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ParseNumstatFunc is ParseNumstat calling emit with each file's stats as it
+// is read instead of collecting them. An error from emit stops parsing and
+// is returned.
+func ParseNumstatFunc(r io.Reader, emit func(FileStat) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	scanner.Split(splitNUL)
+
+	var raws []rawRecord
+	files := 0
+	for scanner.Scan() {
+		record := scanner.Text()
+		if record == "" {
+			continue
+		}
+		if strings.HasPrefix(record, ":") {
+			raw, err := parseRaw(record)
+			if err != nil {
+				return err
+			}
+			// The paths follow: two for renames and copies, else one.
+			paths := 1
+			if raw.status == 'R' || raw.status == 'C' {
+				paths = 2
+			}
+			for range paths {
+				scanner.Scan()
+			}
+			raw.path = scanner.Text()
+			raws = append(raws, raw)
+			continue
+		}
+		fields := strings.SplitN(record, "\t", 3)
+		if len(fields) != 3 {
+			return fmt.Errorf("malformed numstat record %q", record)
+		}
+		added, err := numstatCount(fields[0])
+		if err != nil {
+			return err
+		}
+		deleted, err := numstatCount(fields[1])
+		if err != nil {
+			return err
+		}
+
+		fs := FileStat{Path: fields[2], Added: added, Deleted: deleted}
+		// A rename leaves the path field empty and is followed by the old
+		// and new paths as records of their own.
+		if fs.Path == "" {
+			if !scanner.Scan() {
+				break
+			}
+			fs.OldPath = scanner.Text()
+			if !scanner.Scan() {
+				break
+			}
+			fs.Path = scanner.Text()
+		}
+		fs.Churn = fs.Added + fs.Deleted
+		// git lists the same files in the same order in both formats, all
+		// the --raw records first.
+		if files < len(raws) && raws[files].path == fs.Path {
+			raws[files].apply(&fs)
+		}
+		files++
+		if err := emit(fs); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// apply sets fs's modes and status from raw, the file's --raw record.
+func (raw rawRecord) apply(fs *FileStat) {
+	if raw.oldMode != nullMode && raw.newMode != nullMode && raw.oldMode != raw.newMode {
+		fs.OldMode, fs.NewMode = raw.oldMode, raw.newMode
+	}
+	submodule := raw.oldMode == submoduleMode || raw.newMode == submoduleMode
+	fs.setStatus(raw.oldMode == symlinkMode || raw.newMode == symlinkMode, submodule, raw.oldBlob != raw.newBlob)
+	if raw.oldMode == submoduleMode {
+		fs.OldCommit = raw.oldBlob
+	}
+	if raw.newMode == submoduleMode {
+		fs.NewCommit = raw.newBlob
+	}
+	// A submodule's blobs are commits, which git counts as a line each; a
+	// file replaced by one keeps its lines.
+	if isSubmoduleOrNull(raw.oldMode) && isSubmoduleOrNull(raw.newMode) {
+		fs.Added, fs.Deleted, fs.Churn = 0, 0, 0
+	}
+}
+
+// nullMode is the mode --raw shows for the missing side of an added or
+// deleted file.
+const nullMode = "000000"
+
+// isSubmoduleOrNull reports whether a --raw mode is a submodule or the
+// missing side of an addition or deletion.
+func isSubmoduleOrNull(mode string) bool {
+	return mode == submoduleMode || mode == nullMode
+}
+
+// rawRecord is the metadata part of a `git diff --raw -z` record.
+type rawRecord struct {
+	oldMode, newMode string
+	oldBlob, newBlob string
+	status           byte
+	path             string // the new path, read from the record after it
+}
+
+// parseRaw parses a record such as ":100644 100755 abc1234 abc1234 M".
+func parseRaw(record string) (rawRecord, error) {
+	fields := strings.Fields(strings.TrimPrefix(record, ":"))
+	if len(fields) != 5 || fields[4] == "" {
+		return rawRecord{}, fmt.Errorf("malformed raw diff record %q", record)
+	}
+	return rawRecord{
+		oldMode: fields[0],
+		newMode: fields[1],
+		oldBlob: fields[2],
+		newBlob: fields[3],
+		status:  fields[4][0],
+	}, nil
+}
+
+// numstatCount parses a numstat line count; "-" marks a binary file.
+func numstatCount(s string) (int, error) {
+	if s == "-" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("malformed numstat count %q", s)
+	}
+	return n, nil
+}
+
+// splitNUL is a bufio.SplitFunc for NUL-terminated records.
+func splitNUL(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNumstat(t *testing.T) {
+	out := "3\t1\tmain.go\x00" +
+		"-\t-\tlogo.png\x00" +
+		"2\t0\t\x00old name.go\x00new name.go\x00" +
+		"1\t1\tlink\x00"
+	stats, err := ParseNumstat(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileStat{
+		{Path: "main.go", Added: 3, Deleted: 1, Churn: 4},
+		{Path: "logo.png"},
+		{Path: "new name.go", OldPath: "old name.go", Added: 2, Churn: 2},
+		{Path: "link", Added: 1, Deleted: 1, Churn: 2},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("got %+v\nwant %+v", stats, want)
+	}
+}
+
+func TestParseNumstatModes(t *testing.T) {
+	out := ":100644 100644 587be6b 587be6b R100\x00a.txt\x00b.txt\x00" +
+		":120000 120000 e0e6347 8d14cbf M\x00link\x00" +
+		":100644 100755 8b2fe54 8b2fe54 M\x00run.sh\x00" +
+		":100644 100755 1111111 2222222 M\x00build.sh\x00" +
+		"0\t0\t\x00a.txt\x00b.txt\x00" +
+		"1\t1\tlink\x00" +
+		"0\t0\trun.sh\x00" +
+		"2\t1\tbuild.sh\x00"
+	stats, err := ParseNumstat(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileStat{
+		{Path: "b.txt", OldPath: "a.txt"},
+		{Path: "link", Added: 1, Deleted: 1, Churn: 2, Status: StatusSymlink},
+		{Path: "run.sh", OldMode: "100644", NewMode: "100755", Status: StatusMode},
+		{Path: "build.sh", Added: 2, Deleted: 1, Churn: 3, OldMode: "100644", NewMode: "100755"},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("got %+v\nwant %+v", stats, want)
+	}
+}
+
+func TestParseNumstatSubmodules(t *testing.T) {
+	const a, b = "fba867ed2731547c87272f3d9a3ddc61b247a22d", "cd7f7a63ac1ad23e8a854586f8090cf065dcfae9"
+	const null = "0000000000000000000000000000000000000000"
+	out := ":160000 160000 " + a + " " + b + " M\x00lib\x00" +
+		":000000 160000 " + null + " " + b + " A\x00vendor/x\x00" +
+		":100644 160000 " + a + " " + b + " T\x00tool\x00" +
+		"1\t1\tlib\x00" +
+		"1\t0\tvendor/x\x00" +
+		"1\t4\ttool\x00"
+	stats, err := ParseNumstat(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileStat{
+		{Path: "lib", Status: StatusSubmodule, OldCommit: a, NewCommit: b},
+		{Path: "vendor/x", Status: StatusSubmodule, NewCommit: b},
+		// A file replaced by a submodule keeps its deleted lines.
+		{Path: "tool", Added: 1, Deleted: 4, Churn: 5, OldMode: "100644", NewMode: "160000", Status: StatusSubmodule, NewCommit: b},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("got %+v\nwant %+v", stats, want)
+	}
+}
+
+func TestParseNumstatMalformed(t *testing.T) {
+	for _, out := range []string{"3\tmain.go\x00", "x\t1\tmain.go\x00", ":100644 M\x00main.go\x00"} {
+		if _, err := ParseNumstat(strings.NewReader(out)); err == nil {
+			t.Errorf("no error for %q", out)
+		}
+	}
+}
@@ -12,35 +12,201 @@ type FileStat struct {
 	Added   int
 	Deleted int
 	Churn   int
+	Moved   int    // added and deleted lines that only moved between files
+	OldPath string // previous path if the file was renamed, otherwise empty
+	// OldMode and NewMode are the file's git modes, such as 100644 and
+	// 100755, if the change altered its mode; otherwise empty.
+	OldMode string
+	NewMode string
+	// Status marks changes that are not about lines; see StatusMode,
+	// StatusSymlink, and StatusSubmodule. Empty for other changes.
+	Status string
+	// OldCommit and NewCommit are the commits a submodule pointed at before
+	// and after the change; empty on the side where it did not exist.
+	OldCommit string
+	NewCommit string
+	// RawAdded and RawDeleted count the changed lines before comment-only
+	// and blank lines were left out, when parsed with ParseOptions.Comments.
+	RawAdded   int
+	RawDeleted int
+	// LongestLine is the length in bytes of the longest added or deleted
+	// line, whether or not it was counted.
+	LongestLine int
+}
+
+// File statuses.
+const (
+	// StatusMode is a file whose mode, such as the executable bit, changed
+	// while its content did not.
+	StatusMode = "mode"
+	// StatusSymlink is a symbolic link, whose content is its target, on
+	// either side of the change.
+	StatusSymlink = "symlink"
+	// StatusSubmodule is a submodule whose pointer changed. Its lines are
+	// not counted; OldCommit and NewCommit give the commit range.
+	StatusSubmodule = "submodule"
+)
+
+// Git modes of symbolic links and submodules.
+const (
+	symlinkMode   = "120000"
+	submoduleMode = "160000"
+)
+
+// setStatus derives fs.Status from its modes. symlink and submodule report
+// whether either side is a symbolic link or a submodule, and contentChanged
+// whether the file's content differs.
+func (fs *FileStat) setStatus(symlink, submodule, contentChanged bool) {
+	switch {
+	case submodule:
+		fs.Status = StatusSubmodule
+	case symlink:
+		fs.Status = StatusSymlink
+	case fs.OldMode != fs.NewMode && !contentChanged:
+		fs.Status = StatusMode
+	}
+}
+
+// maxLineSize bounds the diff lines ParseFunc reads. Minified bundles and
+// other compiled assets put whole files on one line, far past bufio's
+// default of 64 KiB.
+const maxLineSize = 256 << 20
+
+// ParseOptions controls diff parsing.
+type ParseOptions struct {
+	// Empty controls whether whitespace-only changed lines are counted:
+	// "exclude" (default) skips them, "include" counts them.
+	Empty string
+	// DetectMoves excludes lines that moved between files from Added and
+	// Deleted and reports them in Moved instead.
+	DetectMoves bool
+	// AddedLine, if set, is called for every counted added line with the
+	// file path, the line's number in the new file, and its content, so
+	// callers can inspect additions without a second pass over the diff.
+	AddedLine func(path string, line int, content string)
+	// Comments, if set, returns the comment syntax of the file at path and
+	// only meaningful lines are counted: changed lines that are blank, even
+	// with Empty set to "include", or hold only comments are left out of
+	// Added and Deleted, and RawAdded and RawDeleted count the lines that
+	// would be counted otherwise.
+	Comments func(path string) CommentSyntax
 }
 
 // Parse reads unified diff output from r and returns per-file add/delete counts.
 // emptyMode controls whether whitespace-only changed lines are counted:
 // "exclude" (default) skips them, "include" counts them.
 func Parse(r io.Reader, emptyMode string) ([]FileStat, error) {
-	scanner := bufio.NewScanner(r)
+	return ParseWithOptions(r, ParseOptions{Empty: emptyMode})
+}
 
+// ParseWithOptions is Parse with additional parsing options.
+func ParseWithOptions(r io.Reader, opts ParseOptions) ([]FileStat, error) {
 	var stats []FileStat
+	err := ParseFunc(r, opts, func(fs FileStat) error {
+		stats = append(stats, fs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ParseFunc is ParseWithOptions calling emit with each file's stats as soon
+// as the file is complete instead of collecting them, so that a huge diff is
+// never held in memory. Files are complete when the next one begins; with
+// DetectMoves, only once the whole diff has been read. An error from emit
+// stops parsing and is returned.
+func ParseFunc(r io.Reader, opts ParseOptions, emit func(FileStat) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	emptyMode := opts.Empty
+
+	// The last finished file is held back until the next header shows it
+	// is not continued.
+	var prev *FileStat
 	var current *FileStat
 	inBinary := false
+	inHeader := false // between "diff --git" and the file's first hunk
+	newLine := 0      // number of the next line in the new file
 
-	flush := func() {
-		if current != nil {
-			current.Churn = current.Added + current.Deleted
-			stats = append(stats, *current)
-			current = nil
+	// With move detection, changed line contents are kept per file as runs
+	// of consecutive added or deleted lines.
+	// Moves are only known at the end, so every file is held until then.
+	var moves *moveIndex
+	var held []FileStat
+	send := emit
+	if opts.DetectMoves {
+		moves = &moveIndex{}
+		send = func(fs FileStat) error {
+			held = append(held, fs)
+			return nil
 		}
 	}
 
+	// Per-file facts from the extended header lines, for the status.
+	symlink, submodule, contentChanged := false, false, false
+	indexed := false // the header had an index line
+
+	// With Comments, each side of the file's hunks is followed for block
+	// comments. The syntax is looked up at the first changed line, once
+	// the path is known to be final after any rename.
+	var addedComments, deletedComments commentTracker
+	syntaxKnown := false
+	meaningful := func(tracker *commentTracker, content string) bool {
+		if !syntaxKnown {
+			syntax := opts.Comments(current.Path)
+			addedComments.syntax, deletedComments.syntax = syntax, syntax
+			syntaxKnown = true
+		}
+		return strings.TrimSpace(content) != "" && !tracker.commentOnly(content)
+	}
+
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		current.setStatus(symlink, submodule, contentChanged)
+		current.Churn = current.Added + current.Deleted
+		if prev != nil {
+			if err := send(*prev); err != nil {
+				return err
+			}
+		}
+		prev, current = current, nil
+		return nil
+	}
+
 	for scanner.Scan() {
 		line := scanner.Text()
 
 		// New file header.
 		if strings.HasPrefix(line, "diff --git ") {
-			flush()
+			if err := flush(); err != nil {
+				return err
+			}
 			inBinary = false
+			inHeader = true
 			path := parseDiffHeader(line)
+			// git diffs a type change, such as a file replaced by a
+			// symlink, as a deletion and an addition of the same path;
+			// count both sections as one file.
+			if prev != nil && prev.Path == path && prev.OldPath == "" {
+				current, prev = prev, nil
+				if moves != nil {
+					moves.endRun()
+				}
+				continue
+			}
+			symlink, submodule, contentChanged = false, false, false
+			indexed = false
+			addedComments.reset()
+			deletedComments.reset()
+			syntaxKnown = false
 			current = &FileStat{Path: path}
+			if moves != nil {
+				moves.startFile()
+			}
 			continue
 		}
 
@@ -49,14 +215,55 @@ func Parse(r io.Reader, emptyMode string) ([]FileStat, error) {
 		}
 
 		// Detect rename.
+		if strings.HasPrefix(line, "rename from ") {
+			current.OldPath = strings.TrimPrefix(line, "rename from ")
+			continue
+		}
 		if strings.HasPrefix(line, "rename to ") {
 			current.Path = strings.TrimPrefix(line, "rename to ")
 			continue
 		}
 
+		if inHeader {
+			if mode, ok := strings.CutPrefix(line, "old mode "); ok {
+				current.OldMode = mode
+				symlink = symlink || mode == symlinkMode
+				submodule = submodule || mode == submoduleMode
+				continue
+			}
+			if mode, ok := strings.CutPrefix(line, "new mode "); ok {
+				current.NewMode = mode
+				symlink = symlink || mode == symlinkMode
+				submodule = submodule || mode == submoduleMode
+				continue
+			}
+			if strings.HasPrefix(line, "new file mode ") || strings.HasPrefix(line, "deleted file mode ") || strings.HasPrefix(line, "index ") {
+				symlink = symlink || strings.HasSuffix(line, " "+symlinkMode)
+				submodule = submodule || strings.HasSuffix(line, " "+submoduleMode)
+				indexed = indexed || strings.HasPrefix(line, "index ")
+				continue
+			}
+		}
+
+		// A submodule's "lines" are the commits it points at. A worktree
+		// diff of a submodule with only local changes has no index line.
+		if submodule || !indexed {
+			if commit, ok := strings.CutPrefix(line, "-Subproject commit "); ok {
+				current.OldCommit = commit
+				submodule = true
+				continue
+			}
+			if commit, ok := strings.CutPrefix(line, "+Subproject commit "); ok {
+				// A worktree diff marks a submodule with local changes.
+				current.NewCommit = strings.TrimSuffix(commit, "-dirty")
+				continue
+			}
+		}
+
 		// Detect binary files — skip the entire file.
 		if strings.HasPrefix(line, "Binary files ") {
 			inBinary = true
+			contentChanged = true
 			continue
 		}
 
@@ -64,39 +271,116 @@ func Parse(r io.Reader, emptyMode string) ([]FileStat, error) {
 			continue
 		}
 
-		// Skip diff metadata lines.
-		if strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---") {
+		// Skip diff metadata lines. Inside a hunk, "+++" and "---" are
+		// changed lines that begin with "++" or "--".
+		if inHeader && (strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---")) {
 			continue
 		}
 
 		// Count additions.
 		if strings.HasPrefix(line, "+") {
 			content := line[1:]
+			newLine++
+			current.LongestLine = max(current.LongestLine, len(content))
 			if emptyMode != "include" && strings.TrimSpace(content) == "" {
 				continue
 			}
+			if opts.Comments != nil {
+				current.RawAdded++
+				if !meaningful(&addedComments, content) {
+					continue
+				}
+			}
 			current.Added++
+			if moves != nil {
+				moves.add('+', content)
+			}
+			if opts.AddedLine != nil {
+				opts.AddedLine(current.Path, newLine-1, content)
+			}
 			continue
 		}
 
 		// Count deletions.
 		if strings.HasPrefix(line, "-") {
 			content := line[1:]
+			current.LongestLine = max(current.LongestLine, len(content))
 			if emptyMode != "include" && strings.TrimSpace(content) == "" {
 				continue
 			}
+			if opts.Comments != nil {
+				current.RawDeleted++
+				if !meaningful(&deletedComments, content) {
+					continue
+				}
+			}
 			current.Deleted++
+			if moves != nil {
+				moves.add('-', content)
+			}
 			continue
 		}
-	}
 
-	flush()
+		if strings.HasPrefix(line, "@@") {
+			inHeader = false
+			contentChanged = true
+			newLine = hunkNewStart(line)
+			addedComments.reset()
+			deletedComments.reset()
+		} else if strings.HasPrefix(line, " ") {
+			newLine++
+		}
 
+		// Any other line (context, hunk header) ends the current run.
+		if moves != nil {
+			moves.endRun()
+		}
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
 	if err := scanner.Err(); err != nil {
-		return nil, err
+		return err
+	}
+	if prev != nil {
+		if err := send(*prev); err != nil {
+			return err
+		}
 	}
 
-	return stats, nil
+	if moves == nil {
+		return nil
+	}
+	for i, moved := range moves.detect() {
+		held[i].Added -= moved.added
+		held[i].Deleted -= moved.deleted
+		held[i].Moved = moved.added + moved.deleted
+		held[i].Churn = held[i].Added + held[i].Deleted
+	}
+	for _, fs := range held {
+		if err := emit(fs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hunkNewStart returns the first new-file line number of a hunk header such
+// as "@@ -10,2 +12,3 @@", or 0 if it cannot be parsed.
+func hunkNewStart(header string) int {
+	_, rest, ok := strings.Cut(header, " +")
+	if !ok {
+		return 0
+	}
+	n := 0
+	for _, c := range rest {
+		if c < '0' || c > '9' {
+			break
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n
 }
 
 // parseDiffHeader extracts the file path from a "diff --git a/... b/..." line.
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -129,11 +131,195 @@ rename to new.go
 	if stats[0].Path != "new.go" {
 		t.Errorf("path = %q, want %q", stats[0].Path, "new.go")
 	}
+	if stats[0].OldPath != "old.go" {
+		t.Errorf("old path = %q, want %q", stats[0].OldPath, "old.go")
+	}
 	if stats[0].Added != 1 || stats[0].Deleted != 1 {
 		t.Errorf("stats = %+v, want Added=1, Deleted=1", stats[0])
 	}
 }
 
+func TestTypeChangeCountedOnce(t *testing.T) {
+	diff := `diff --git a/lib b/lib
+deleted file mode 100644
+index 422c2b7..0000000
+--- a/lib
++++ /dev/null
+@@ -1,2 +0,0 @@
+-one
+-two
+diff --git a/lib b/lib
+new file mode 120000
+index 0000000..32f64f4
+--- /dev/null
++++ b/lib
+@@ -0,0 +1 @@
++vendor/lib
+\ No newline at end of file
+`
+	stats, err := Parse(strings.NewReader(diff), "exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 {
+		t.Fatalf("expected 1 file, got %d: %+v", len(stats), stats)
+	}
+	if stats[0].Added != 1 || stats[0].Deleted != 2 || stats[0].Churn != 3 || stats[0].Status != StatusSymlink {
+		t.Errorf("stats = %+v, want Added=1, Deleted=2, Churn=3, Status=symlink", stats[0])
+	}
+}
+
+func TestParseFuncEmitsEachFile(t *testing.T) {
+	diff := `diff --git a/a.go b/a.go
+@@ -1 +1 @@
+-old
++new
+diff --git a/b.go b/b.go
+@@ -0,0 +1 @@
++added
+diff --git a/c.go b/c.go
+@@ -0,0 +1 @@
++added
+`
+	var paths []string
+	err := ParseFunc(strings.NewReader(diff), ParseOptions{}, func(fs FileStat) error {
+		paths = append(paths, fs.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.go", "b.go", "c.go"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("emitted %v, want %v", paths, want)
+	}
+
+	// An error from emit stops parsing.
+	stop := errors.New("stop")
+	paths = nil
+	err = ParseFunc(strings.NewReader(diff), ParseOptions{}, func(fs FileStat) error {
+		paths = append(paths, fs.Path)
+		return stop
+	})
+	if err != stop || len(paths) != 1 {
+		t.Errorf("got %v after %v, want stop after one file", err, paths)
+	}
+}
+
+func TestModeAndSymlinkStatus(t *testing.T) {
+	diff := `diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/build.sh b/build.sh
+old mode 100644
+new mode 100755
+index 1111111..2222222
+--- a/build.sh
++++ b/build.sh
+@@ -1 +1 @@
+-make
++make all
+diff --git a/link b/link
+index e0e6347..8d14cbf 120000
+--- a/link
++++ b/link
+@@ -1 +1 @@
+-run.sh
+\ No newline at end of file
++a.txt
+\ No newline at end of file
+diff --git a/f.go b/f.go
+index 1234567..abcdefg 100644
+--- a/f.go
++++ b/f.go
+@@ -1 +1 @@
+-a
++b
+`
+	stats, err := Parse(strings.NewReader(diff), "exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileStat{
+		{Path: "run.sh", OldMode: "100644", NewMode: "100755", Status: StatusMode},
+		{Path: "build.sh", Added: 1, Deleted: 1, Churn: 2, OldMode: "100644", NewMode: "100755", LongestLine: 8},
+		{Path: "link", Added: 1, Deleted: 1, Churn: 2, Status: StatusSymlink, LongestLine: 6},
+		{Path: "f.go", Added: 1, Deleted: 1, Churn: 2, LongestLine: 1},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("got %+v\nwant %+v", stats, want)
+	}
+}
+
+func TestSubmoduleStatus(t *testing.T) {
+	diff := `diff --git a/lib b/lib
+index fba867e..cd7f7a6 160000
+--- a/lib
++++ b/lib
+@@ -1 +1 @@
+-Subproject commit fba867ed2731547c87272f3d9a3ddc61b247a22d
++Subproject commit cd7f7a63ac1ad23e8a854586f8090cf065dcfae9
+diff --git a/vendor/x b/vendor/x
+new file mode 160000
+index 0000000..cd7f7a6
+--- /dev/null
++++ b/vendor/x
+@@ -0,0 +1 @@
++Subproject commit cd7f7a63ac1ad23e8a854586f8090cf065dcfae9
+diff --git a/dirty b/dirty
+--- a/dirty
++++ b/dirty
+@@ -1 +1 @@
+-Subproject commit cd7f7a63ac1ad23e8a854586f8090cf065dcfae9
++Subproject commit cd7f7a63ac1ad23e8a854586f8090cf065dcfae9-dirty
+diff --git a/notes.txt b/notes.txt
+index 1111111..2222222 100644
+--- a/notes.txt
++++ b/notes.txt
+@@ -1 +1 @@
+-Subproject commit is how git shows submodules
++Subproject commit lines show submodules
+`
+	stats, err := Parse(strings.NewReader(diff), "exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileStat{
+		{Path: "lib", Status: StatusSubmodule, OldCommit: "fba867ed2731547c87272f3d9a3ddc61b247a22d", NewCommit: "cd7f7a63ac1ad23e8a854586f8090cf065dcfae9"},
+		{Path: "vendor/x", Status: StatusSubmodule, NewCommit: "cd7f7a63ac1ad23e8a854586f8090cf065dcfae9"},
+		{Path: "dirty", Status: StatusSubmodule, OldCommit: "cd7f7a63ac1ad23e8a854586f8090cf065dcfae9", NewCommit: "cd7f7a63ac1ad23e8a854586f8090cf065dcfae9"},
+		{Path: "notes.txt", Added: 1, Deleted: 1, Churn: 2, LongestLine: 45},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("got %+v\nwant %+v", stats, want)
+	}
+}
+
+func TestCaseCollidingPathsKeptSeparate(t *testing.T) {
+	diff := `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-old
++new
+diff --git a/readme.md b/readme.md
+--- a/readme.md
++++ b/readme.md
+@@ -1 +1,2 @@
+ keep
++more
+`
+	stats, err := Parse(strings.NewReader(diff), "exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Path != "README.md" || stats[1].Path != "readme.md" {
+		t.Fatalf("stats = %+v, want README.md and readme.md", stats)
+	}
+	if stats[1].Added != 1 || stats[1].Deleted != 0 {
+		t.Errorf("readme.md = %+v, want Added=1, Deleted=0", stats[1])
+	}
+}
+
 func TestBinaryFileSkipped(t *testing.T) {
 	diff := `diff --git a/image.png b/image.png
 Binary files /dev/null and b/image.png differ
@@ -269,3 +455,86 @@ func TestChurnCalculation(t *testing.T) {
 		t.Errorf("got %+v, want Added=3 Deleted=2 Churn=5", stats[0])
 	}
 }
+
+func TestAddedLineCallback(t *testing.T) {
+	diff := `diff --git a/db/001.sql b/db/001.sql
+--- a/db/001.sql
++++ b/db/001.sql
+@@ -3,0 +4,2 @@
++DROP TABLE users;
++
+@@ -10 +12 @@
+-old
++new
+`
+	type added struct {
+		path    string
+		line    int
+		content string
+	}
+	var got []added
+	_, err := ParseWithOptions(strings.NewReader(diff), ParseOptions{
+		AddedLine: func(path string, line int, content string) {
+			got = append(got, added{path, line, content})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []added{{"db/001.sql", 4, "DROP TABLE users;"}, {"db/001.sql", 12, "new"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AddedLine calls = %+v, want %+v", got, want)
+	}
+}
+
+func TestChangedLinesLookingLikeHeaders(t *testing.T) {
+	diff := `diff --git a/notes.md b/notes.md
+index 1234567..abcdefg 100644
+--- a/notes.md
++++ b/notes.md
+@@ -1 +1,2 @@
+--- old rule
++++ new rule
++++x
+`
+	stats, err := Parse(strings.NewReader(diff), "exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Added != 2 || stats[0].Deleted != 1 {
+		t.Errorf("got %+v, want 2 added and 1 deleted", stats)
+	}
+}
+
+func TestLongestLine(t *testing.T) {
+	bundle := strings.Repeat("var a=1;", 20000) // past bufio's default token size
+	diff := `diff --git a/app.min.js b/app.min.js
+--- a/app.min.js
++++ b/app.min.js
+@@ -1,3 +1 @@
+-short
+-
+-a somewhat longer line
++` + bundle + `
+diff --git a/notes.md b/notes.md
+--- a/notes.md
++++ b/notes.md
+@@ -1 +1,2 @@
+-old note
++
++new
+`
+	stats, err := Parse(strings.NewReader(diff), "exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("got %d files, want 2", len(stats))
+	}
+	if stats[0].LongestLine != len(bundle) || stats[0].Added != 1 || stats[0].Deleted != 2 {
+		t.Errorf("app.min.js = +%d -%d, longest %d; want +1 -2, longest %d", stats[0].Added, stats[0].Deleted, stats[0].LongestLine, len(bundle))
+	}
+	if stats[1].LongestLine != 8 {
+		t.Errorf("notes.md longest line = %d, want 8 from the deleted line", stats[1].LongestLine)
+	}
+}
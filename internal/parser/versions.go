@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// FileVersions is one file of a full-context diff rebuilt into its before
+// and after contents. OldChanged and NewChanged hold the 1-based line
+// numbers the diff deleted from Old and added to New.
+type FileVersions struct {
+	Path       string
+	Old, New   []string
+	OldChanged map[int]bool
+	NewChanged map[int]bool
+}
+
+// ReadVersions reads unified diff output produced with full context (every
+// unchanged line present, e.g. git diff -U<large>) and calls fn with each
+// file's two versions. Path is the new path, or the old path for deletions.
+func ReadVersions(r io.Reader, fn func(FileVersions)) error {
+	var current *FileVersions
+	inHunk := false
+	flush := func() {
+		if current != nil {
+			fn(*current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			current = &FileVersions{OldChanged: map[int]bool{}, NewChanged: map[int]bool{}}
+			inHunk = false
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if !inHunk {
+			switch {
+			case strings.HasPrefix(line, "--- "):
+				if p := strings.TrimPrefix(line, "--- "); p != "/dev/null" {
+					current.Path = strings.TrimPrefix(p, "a/")
+				}
+			case strings.HasPrefix(line, "+++ "):
+				if p := strings.TrimPrefix(line, "+++ "); p != "/dev/null" {
+					current.Path = strings.TrimPrefix(p, "b/")
+				}
+			case strings.HasPrefix(line, "@@"):
+				inHunk = true
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		switch line[0] {
+		case ' ':
+			current.Old = append(current.Old, line[1:])
+			current.New = append(current.New, line[1:])
+		case '-':
+			current.Old = append(current.Old, line[1:])
+			current.OldChanged[len(current.Old)] = true
+		case '+':
+			current.New = append(current.New, line[1:])
+			current.NewChanged[len(current.New)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadVersions(t *testing.T) {
+	diff := `diff --git a/a.txt b/a.txt
+index 1111111..2222222 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++2
+ three
+\ No newline at end of file
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+	var got []FileVersions
+	if err := ReadVersions(strings.NewReader(diff), func(f FileVersions) { got = append(got, f) }); err != nil {
+		t.Fatal(err)
+	}
+	want := []FileVersions{
+		{
+			Path:       "a.txt",
+			Old:        []string{"one", "two", "three"},
+			New:        []string{"one", "2", "three"},
+			OldChanged: map[int]bool{2: true},
+			NewChanged: map[int]bool{2: true},
+		},
+		{
+			Path:       "gone.txt",
+			Old:        []string{"bye"},
+			OldChanged: map[int]bool{1: true},
+			NewChanged: map[int]bool{},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadVersions =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+// Package progress draws a status line on a terminal while a long analysis
+// runs, so that large ranges don't look hung.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Spinner frames; ASCII ones are used when only ASCII may be written.
+var (
+	frames      = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+	asciiFrames = []string{"|", "/", "-", `\`}
+)
+
+// interval is how often the line is redrawn.
+const interval = 100 * time.Millisecond
+
+// Line is a progress line counting parsed files and bytes read. It is only
+// drawn once delay has passed, and erased by Stop. All methods of a nil Line
+// do nothing, so callers need not check whether one was started.
+type Line struct {
+	w      io.Writer
+	frames []string
+	files  atomic.Int64
+	bytes  atomic.Int64
+	done   chan struct{}
+	exited chan struct{}
+}
+
+// Start begins drawing a Line to w, a terminal, after delay. With ascii, the
+// spinner uses ASCII characters only.
+func Start(w io.Writer, delay time.Duration, ascii bool) *Line {
+	l := &Line{w: w, frames: frames, done: make(chan struct{}), exited: make(chan struct{})}
+	if ascii {
+		l.frames = asciiFrames
+	}
+	go l.run(delay)
+	return l
+}
+
+func (l *Line) run(delay time.Duration) {
+	defer close(l.exited)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-l.done:
+		return
+	case <-timer.C:
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for frame := 0; ; frame++ {
+		fmt.Fprintf(l.w, "\r\033[K%s %s", l.frames[frame%len(l.frames)], l.status())
+		select {
+		case <-l.done:
+			fmt.Fprint(l.w, "\r\033[K")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// status describes the progress so far, such as "1200 files parsed,
+// 3.4 MiB read".
+func (l *Line) status() string {
+	files := l.files.Load()
+	word := "files"
+	if files == 1 {
+		word = "file"
+	}
+	return fmt.Sprintf("%d %s parsed, %.1f MiB read", files, word, float64(l.bytes.Load())/(1<<20))
+}
+
+// AddFile counts one parsed file.
+func (l *Line) AddFile() {
+	if l != nil {
+		l.files.Add(1)
+	}
+}
+
+// ReadCloser returns rc counting the bytes read from it.
+func (l *Line) ReadCloser(rc io.ReadCloser) io.ReadCloser {
+	if l == nil {
+		return rc
+	}
+	return countingReader{rc, l}
+}
+
+type countingReader struct {
+	io.ReadCloser
+	l *Line
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.l.bytes.Add(int64(n))
+	return n, err
+}
+
+// Stop erases the line, if it was drawn, and returns once it is gone. It
+// may be called more than once.
+func (l *Line) Stop() {
+	if l == nil {
+		return
+	}
+	select {
+	case <-l.done:
+	default:
+		close(l.done)
+	}
+	<-l.exited
+}
@@ -0,0 +1,75 @@
+package progress
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to write from the drawing goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLineDrawnAfterDelayAndErased(t *testing.T) {
+	var w syncBuffer
+	l := Start(&w, 0, true)
+	l.AddFile()
+	l.AddFile()
+	rc := l.ReadCloser(io.NopCloser(strings.NewReader(strings.Repeat("x", 3<<19))))
+	io.Copy(io.Discard, rc)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(w.String(), "2 files parsed, 1.5 MiB read") {
+		if time.Now().After(deadline) {
+			t.Fatalf("progress line not drawn, got %q", w.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	l.Stop()
+	l.Stop()
+
+	got := w.String()
+	if !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("expected the line to be erased, got %q", got)
+	}
+	if strings.Contains(got, "⠋") {
+		t.Errorf("expected ASCII frames only, got %q", got)
+	}
+}
+
+func TestLineNotDrawnBeforeDelay(t *testing.T) {
+	var w syncBuffer
+	l := Start(&w, time.Hour, false)
+	l.AddFile()
+	l.Stop()
+	if got := w.String(); got != "" {
+		t.Errorf("expected nothing drawn for a quick run, got %q", got)
+	}
+}
+
+func TestNilLine(t *testing.T) {
+	var l *Line
+	l.AddFile()
+	rc := io.NopCloser(strings.NewReader("x"))
+	if l.ReadCloser(rc) != rc {
+		t.Error("expected a nil Line to return the reader unchanged")
+	}
+	l.Stop()
+}
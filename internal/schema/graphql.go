@@ -0,0 +1,135 @@
+package schema
+
+import "strings"
+
+// graphqlElements returns the types ("type User") and their fields or enum
+// values ("type Query.user", "enum Role.ADMIN") defined in GraphQL SDL, each
+// with its signature. Fields of "extend type" blocks belong to the extended
+// type.
+func graphqlElements(src string) (map[string]string, bool) {
+	elems := make(map[string]string)
+	t := &tokens{toks: tokenize(src, true)}
+	for !t.done() {
+		kw := t.next()
+		if kw == "extend" {
+			kw = t.next()
+		}
+		switch kw {
+		case "type", "input", "interface":
+			name := t.next()
+			var header []string
+			for !t.done() && t.peek() != "{" && !isDefinitionKeyword(t.peek()) {
+				header = append(header, t.next())
+			}
+			if _, ok := elems[kw+" "+name]; !ok || len(header) > 0 {
+				elems[kw+" "+name] = strings.Join(header, " ")
+			}
+			if t.peek() != "{" {
+				continue
+			}
+			t.next()
+			for !t.done() && t.peek() != "}" {
+				field := t.next()
+				sig := t.group("(", ")")
+				if t.peek() != ":" {
+					return nil, false
+				}
+				t.next()
+				sig += ":" + graphqlType(t)
+				elems[kw+" "+name+"."+field] = sig
+			}
+			t.next()
+		case "enum":
+			name := t.next()
+			elems["enum "+name] = skipDirectives(t)
+			if t.peek() != "{" {
+				continue
+			}
+			t.next()
+			for !t.done() && t.peek() != "}" {
+				value := t.next()
+				elems["enum "+name+"."+value] = skipDirectives(t)
+			}
+			t.next()
+		case "union":
+			name := t.next()
+			var members []string
+			for !t.done() && !isDefinitionKeyword(t.peek()) {
+				members = append(members, t.next())
+			}
+			elems["union "+name] = strings.Join(members, " ")
+		case "scalar":
+			name := t.next()
+			elems["scalar "+name] = skipDirectives(t)
+		case "schema":
+			elems["schema"] = skipDirectives(t) + t.group("{", "}")
+		case "directive":
+			var parts []string
+			for !t.done() && !isDefinitionKeyword(t.peek()) {
+				parts = append(parts, t.next())
+			}
+			if len(parts) > 1 {
+				elems["directive "+parts[0]+parts[1]] = strings.Join(parts[2:], " ")
+			}
+		default:
+			return nil, false
+		}
+	}
+	return elems, true
+}
+
+// graphqlType consumes a type reference such as [User!]! plus any trailing
+// default value and directives, and returns it compacted.
+func graphqlType(t *tokens) string {
+	var parts []string
+	for !t.done() {
+		switch tok := t.peek(); tok {
+		case "[", "]", "!":
+			parts = append(parts, t.next())
+		default:
+			if len(parts) > 0 && parts[len(parts)-1] != "[" {
+				return strings.Join(parts, "") + skipDirectives(t)
+			}
+			parts = append(parts, t.next())
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// skipDirectives consumes "= default" and "@directive(args)" suffixes and
+// returns them as text, since they are part of an element's contract.
+func skipDirectives(t *tokens) string {
+	var parts []string
+	for !t.done() {
+		switch t.peek() {
+		case "=":
+			t.next()
+			switch t.peek() {
+			case "[":
+				parts = append(parts, "=["+t.group("[", "]")+"]")
+			case "{":
+				parts = append(parts, "={"+t.group("{", "}")+"}")
+			default:
+				parts = append(parts, "="+t.next())
+			}
+		case "@":
+			t.next()
+			name := "@" + t.next()
+			if args := t.group("(", ")"); args != "" {
+				name += "(" + args + ")"
+			}
+			parts = append(parts, name)
+		default:
+			return strings.Join(parts, " ")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func isDefinitionKeyword(tok string) bool {
+	switch tok {
+	case "type", "input", "interface", "enum", "union", "scalar", "schema", "directive", "extend":
+		return true
+	}
+	return false
+}
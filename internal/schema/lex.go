@@ -0,0 +1,120 @@
+package schema
+
+import "strings"
+
+// tokenize splits GraphQL or protobuf source into words and single-character
+// punctuation. Comments and string literals are dropped: they carry
+// descriptions and option values, not structure.
+func tokenize(src string, hashComments bool) []string {
+	var toks []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case hashComments && c == '#', !hashComments && strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case !hashComments && strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return toks
+			}
+			i += end + 4
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			if end < 0 {
+				return toks
+			}
+			i += end + 6
+		case c == '"' || c == '\'':
+			i++
+			for i < len(src) && src[i] != c {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case isWordByte(c):
+			start := i
+			for i < len(src) && isWordByte(src[i]) {
+				i++
+			}
+			toks = append(toks, src[start:i])
+		default:
+			toks = append(toks, string(c))
+			i++
+		}
+	}
+	return toks
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '+' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// tokens is a cursor over a token slice.
+type tokens struct {
+	toks []string
+	pos  int
+}
+
+func (t *tokens) done() bool { return t.pos >= len(t.toks) }
+
+func (t *tokens) peek() string {
+	if t.done() {
+		return ""
+	}
+	return t.toks[t.pos]
+}
+
+func (t *tokens) next() string {
+	tok := t.peek()
+	t.pos++
+	return tok
+}
+
+// group consumes a balanced open...close group starting at the current
+// token, which must be open, and returns its contents joined by spaces.
+func (t *tokens) group(open, close string) string {
+	if t.peek() != open {
+		return ""
+	}
+	t.next()
+	var parts []string
+	depth := 1
+	for !t.done() {
+		tok := t.next()
+		switch tok {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return strings.Join(parts, " ")
+			}
+		}
+		parts = append(parts, tok)
+	}
+	return strings.Join(parts, " ")
+}
+
+// skipStatement consumes tokens through the next ";" outside any braces.
+func (t *tokens) skipStatement() {
+	depth := 0
+	for !t.done() {
+		switch t.next() {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		case ";":
+			if depth <= 0 {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// isOpenAPI reports whether src is an OpenAPI 3 or Swagger 2 document. JSON
+// is valid YAML, so both encodings are handled.
+func isOpenAPI(src string) bool {
+	var doc map[string]any
+	if yaml.Unmarshal([]byte(src), &doc) != nil {
+		return false
+	}
+	_, v3 := doc["openapi"]
+	_, v2 := doc["swagger"]
+	return v3 || v2
+}
+
+// openAPIElements returns the operations ("GET /pets"), schemas
+// ("schema Pet"), and schema properties ("schema Pet.name") of an OpenAPI
+// document, each with a canonical encoding used to detect changes.
+func openAPIElements(src string) (map[string]string, bool) {
+	elems := make(map[string]string)
+	if strings.TrimSpace(src) == "" {
+		return elems, true
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return nil, false
+	}
+
+	paths, _ := doc["paths"].(map[string]any)
+	for p, item := range paths {
+		ops, _ := item.(map[string]any)
+		for _, method := range httpMethods {
+			if op, ok := ops[method]; ok {
+				// Path-level parameters apply to every operation.
+				elems[strings.ToUpper(method)+" "+p] = canonical(op) + canonical(ops["parameters"])
+			}
+		}
+	}
+
+	schemas := doc["definitions"] // Swagger 2
+	if components, ok := doc["components"].(map[string]any); ok {
+		schemas = components["schemas"]
+	}
+	defs, _ := schemas.(map[string]any)
+	for name, def := range defs {
+		fields, _ := def.(map[string]any)
+		rest := make(map[string]any, len(fields))
+		for k, v := range fields {
+			if k != "properties" {
+				rest[k] = v
+			}
+		}
+		elems["schema "+name] = canonical(rest)
+		props, _ := fields["properties"].(map[string]any)
+		for prop, v := range props {
+			elems["schema "+name+"."+prop] = canonical(v)
+		}
+	}
+	return elems, true
+}
+
+// canonical encodes v with sorted keys so equal values compare equal.
+func canonical(v any) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,133 @@
+package schema
+
+import "strings"
+
+// protoElements returns the messages, enums, and services of a .proto file
+// and their members ("message User.email", "enum Role.ADMIN",
+// "rpc Users.Get"), each with a signature. Nested declarations are named
+// with their parent ("message User.Address").
+func protoElements(src string) (map[string]string, bool) {
+	elems := make(map[string]string)
+	t := &tokens{toks: tokenize(src, false)}
+	for !t.done() {
+		if !protoDecl(t, "", elems) {
+			switch t.peek() {
+			case "syntax", "edition", "package", "import", "option":
+				t.skipStatement()
+			case ";":
+				t.next()
+			case "extend":
+				t.next()
+				t.next()
+				t.group("{", "}")
+			default:
+				return nil, false
+			}
+		}
+	}
+	return elems, true
+}
+
+// protoDecl parses a message, enum, or service declaration at the cursor,
+// reporting false if there is none.
+func protoDecl(t *tokens, prefix string, elems map[string]string) bool {
+	kw := t.peek()
+	if kw != "message" && kw != "enum" && kw != "service" {
+		return false
+	}
+	t.next()
+	name := prefix + t.next()
+	elems[kw+" "+name] = ""
+	if t.next() != "{" {
+		return true
+	}
+	switch kw {
+	case "message":
+		protoMessageBody(t, name, elems)
+	case "enum":
+		for !t.done() && t.peek() != "}" {
+			if t.peek() == "option" || t.peek() == "reserved" {
+				t.skipStatement()
+				continue
+			}
+			if t.peek() == ";" {
+				t.next()
+				continue
+			}
+			value := t.next()
+			elems["enum "+name+"."+value] = protoRest(t)
+		}
+		t.next()
+	case "service":
+		for !t.done() && t.peek() != "}" {
+			if t.next() != "rpc" {
+				continue
+			}
+			method := t.next()
+			sig := "(" + t.group("(", ")") + ")"
+			if t.peek() == "returns" {
+				t.next()
+				sig += " returns (" + t.group("(", ")") + ")"
+			}
+			if t.peek() == "{" {
+				t.group("{", "}")
+			} else if t.peek() == ";" {
+				t.next()
+			}
+			elems["rpc "+name+"."+method] = sig
+		}
+		t.next()
+	}
+	return true
+}
+
+// protoMessageBody parses fields, oneofs, and nested declarations up to and
+// including the closing brace.
+func protoMessageBody(t *tokens, name string, elems map[string]string) {
+	for !t.done() && t.peek() != "}" {
+		if protoDecl(t, name+".", elems) {
+			continue
+		}
+		switch t.peek() {
+		case ";":
+			t.next()
+		case "option", "reserved", "extensions":
+			t.skipStatement()
+		case "extend":
+			t.next()
+			t.next()
+			t.group("{", "}")
+		case "oneof":
+			t.next()
+			t.next()
+			if t.next() == "{" {
+				protoMessageBody(t, name, elems)
+			}
+		default:
+			// [label] type name = number [options];
+			var parts []string
+			for !t.done() && t.peek() != "=" && t.peek() != ";" && t.peek() != "}" {
+				parts = append(parts, t.next())
+			}
+			if len(parts) < 2 {
+				t.skipStatement()
+				continue
+			}
+			field := parts[len(parts)-1]
+			elems["message "+name+"."+field] = strings.Join(parts[:len(parts)-1], " ") + " " + protoRest(t)
+		}
+	}
+	t.next()
+}
+
+// protoRest consumes "= number [options];" and returns it compacted.
+func protoRest(t *tokens) string {
+	var parts []string
+	for !t.done() && t.peek() != ";" && t.peek() != "}" {
+		parts = append(parts, t.next())
+	}
+	if t.peek() == ";" {
+		t.next()
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,109 @@
+// Package schema compares API contract files structurally — OpenAPI
+// documents, GraphQL SDL, and Protocol Buffers definitions — reporting the
+// operations, types, and fields that were added, removed, or changed.
+package schema
+
+import (
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+// Schema formats.
+const (
+	OpenAPI = "openapi"
+	GraphQL = "graphql"
+	Proto   = "proto"
+)
+
+// Change is the structural difference between two versions of a schema
+// file. Elements are named like "GET /pets", "schema Pet.name",
+// "type Query.user", or "rpc Greeter.SayHello".
+type Change struct {
+	Path    string
+	Format  string
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// IsCandidate reports whether path may hold a schema. YAML and JSON files
+// are only candidates: they count as schemas when they are OpenAPI or
+// Swagger documents.
+func IsCandidate(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".proto", ".graphql", ".graphqls", ".gql", ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// Analyze reads a unified diff produced with full context, rebuilds both
+// versions of each schema file, and compares their elements. Files that are
+// not schemas, fail to parse, or have no structural change are omitted.
+func Analyze(r io.Reader) ([]Change, error) {
+	var changes []Change
+	err := parser.ReadVersions(r, func(f parser.FileVersions) {
+		if c, ok := compare(f.Path, strings.Join(f.Old, "\n"), strings.Join(f.New, "\n")); ok {
+			changes = append(changes, c)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func compare(p, before, after string) (Change, bool) {
+	var format string
+	var extract func(string) (map[string]string, bool)
+	switch strings.ToLower(path.Ext(p)) {
+	case ".proto":
+		format, extract = Proto, protoElements
+	case ".graphql", ".graphqls", ".gql":
+		format, extract = GraphQL, graphqlElements
+	case ".yaml", ".yml", ".json":
+		if !isOpenAPI(before) && !isOpenAPI(after) {
+			return Change{}, false
+		}
+		format, extract = OpenAPI, openAPIElements
+	default:
+		return Change{}, false
+	}
+
+	old, ok := extract(before)
+	if !ok {
+		return Change{}, false
+	}
+	cur, ok := extract(after)
+	if !ok {
+		return Change{}, false
+	}
+
+	c := Change{Path: p, Format: format}
+	for name, sig := range cur {
+		prev, existed := old[name]
+		switch {
+		case !existed:
+			c.Added = append(c.Added, name)
+		case prev != sig:
+			c.Changed = append(c.Changed, name)
+		}
+	}
+	for name := range old {
+		if _, ok := cur[name]; !ok {
+			c.Removed = append(c.Removed, name)
+		}
+	}
+	if len(c.Added)+len(c.Removed)+len(c.Changed) == 0 {
+		return Change{}, false
+	}
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	sort.Strings(c.Changed)
+	return c, true
+}
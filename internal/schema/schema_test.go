@@ -0,0 +1,204 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fullDiff builds a full-context diff of one file from before to after.
+func fullDiff(path, before, after string) string {
+	var b strings.Builder
+	b.WriteString("diff --git a/" + path + " b/" + path + "\n--- a/" + path + "\n+++ b/" + path + "\n@@ -1 +1 @@\n")
+	for _, l := range strings.Split(before, "\n") {
+		b.WriteString("-" + l + "\n")
+	}
+	for _, l := range strings.Split(after, "\n") {
+		b.WriteString("+" + l + "\n")
+	}
+	return b.String()
+}
+
+func analyzeOne(t *testing.T, path, before, after string) Change {
+	t.Helper()
+	changes, err := Analyze(strings.NewReader(fullDiff(path, before, after)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("expected one change, got %+v", changes)
+	}
+	return changes[0]
+}
+
+func TestOpenAPI(t *testing.T) {
+	before := `openapi: 3.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+    delete:
+      operationId: deletePets
+components:
+  schemas:
+    Pet:
+      required: [id]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+`
+	after := `openapi: 3.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - {name: limit, in: query}
+  /pets/{id}:
+    get:
+      operationId: getPet
+components:
+  schemas:
+    Pet:
+      required: [id]
+      properties:
+        id: {type: integer}
+        tag: {type: string}
+`
+	got := analyzeOne(t, "api/openapi.yaml", before, after)
+	want := Change{
+		Path:    "api/openapi.yaml",
+		Format:  OpenAPI,
+		Added:   []string{"GET /pets/{id}", "schema Pet.tag"},
+		Removed: []string{"DELETE /pets", "schema Pet.name"},
+		Changed: []string{"GET /pets"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestNonOpenAPIYAMLIgnored(t *testing.T) {
+	changes, err := Analyze(strings.NewReader(fullDiff("ci.yml", "on: push", "on: pull_request")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no schema changes, got %+v", changes)
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	before := `"""The root query."""
+type Query {
+  user(id: ID!): User
+  users: [User!]!
+}
+
+type User implements Node {
+  id: ID!
+  name: String # display name
+}
+
+enum Role { ADMIN USER }
+`
+	after := `type Query {
+  user(id: ID!, active: Boolean = true): User
+  users: [User!]!
+}
+
+extend type Query {
+  me: User @auth(requires: USER)
+}
+
+type User implements Node {
+  id: ID!
+  name: String!
+}
+
+enum Role { ADMIN USER GUEST }
+
+union SearchResult = User | Role
+`
+	got := analyzeOne(t, "schema.graphql", before, after)
+	want := Change{
+		Path:    "schema.graphql",
+		Format:  GraphQL,
+		Added:   []string{"enum Role.GUEST", "type Query.me", "union SearchResult"},
+		Changed: []string{"type Query.user", "type User.name"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestProto(t *testing.T) {
+	before := `syntax = "proto3";
+package users.v1;
+import "google/protobuf/timestamp.proto";
+
+// A user.
+message User {
+  string id = 1;
+  string email = 2;
+  message Address { string city = 1; }
+  oneof contact {
+    string phone = 3;
+  }
+}
+
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_ADMIN = 1;
+}
+
+service Users {
+  rpc Get(GetRequest) returns (User);
+  rpc Delete(DeleteRequest) returns (Empty) { option deprecated = true; }
+}
+`
+	after := `syntax = "proto3";
+package users.v1;
+
+message User {
+  string id = 1;
+  repeated string emails = 4;
+  reserved 2;
+  message Address { string city = 1; string zip = 2; }
+  oneof contact {
+    int64 phone = 3;
+  }
+  map<string, string> labels = 5 [deprecated = true];
+}
+
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+  ROLE_ADMIN = 1;
+}
+
+service Users {
+  rpc Get(GetRequest) returns (stream User);
+}
+`
+	got := analyzeOne(t, "proto/users.proto", before, after)
+	want := Change{
+		Path:    "proto/users.proto",
+		Format:  Proto,
+		Added:   []string{"message User.Address.zip", "message User.emails", "message User.labels"},
+		Removed: []string{"message User.email", "rpc Users.Delete"},
+		Changed: []string{"message User.phone", "rpc Users.Get"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestUnparsableSchemaSkipped(t *testing.T) {
+	changes, err := Analyze(strings.NewReader(fullDiff("x.graphql", "type Query { a: Int }", "type Query { a }")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected unparsable file to be skipped, got %+v", changes)
+	}
+}
@@ -0,0 +1,182 @@
+// Package serve exposes the churn analysis over HTTP: a JSON API that runs
+// the pipeline on demand and a minimal HTML dashboard on top of it.
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// Query selects the change to analyze. Fields mirror the CLI flags of the
+// same names.
+type Query struct {
+	Base, Head string
+	RevRange   string
+	Pathspecs  []string
+	Include    []string
+	Exclude    []string
+	Categories []string
+	Empty      string
+}
+
+// AnalyzeFunc computes the churn summary for q.
+type AnalyzeFunc func(q Query) (output.Summary, error)
+
+// Server answers HTTP requests by running Analyze.
+type Server struct {
+	Analyze AnalyzeFunc
+	Log     io.Writer // one line per failed request; defaults to os.Stderr
+
+	logMu sync.Mutex
+}
+
+// Handler returns the server's routes:
+//
+//	GET /                the dashboard: a form that links to /report
+//	GET /summary         the summary as JSON, as with --format json
+//	GET /report          the summary as an HTML page, with the file list
+//
+// /summary and /report take the query parameters base, head, range, path,
+// include, exclude, category (the last four repeatable), and empty.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.dashboard)
+	mux.HandleFunc("GET /summary", s.summary)
+	mux.HandleFunc("GET /report", s.report)
+	return mux
+}
+
+// ParseQuery reads a Query from URL parameters. Refs that look like options
+// are rejected so a request cannot pass flags to git.
+func ParseQuery(v url.Values) (Query, error) {
+	q := Query{
+		Base:       v.Get("base"),
+		Head:       v.Get("head"),
+		RevRange:   v.Get("range"),
+		Pathspecs:  nonEmpty(v["path"]),
+		Include:    nonEmpty(v["include"]),
+		Exclude:    nonEmpty(v["exclude"]),
+		Categories: nonEmpty(v["category"]),
+		Empty:      v.Get("empty"),
+	}
+	for _, p := range []struct{ name, ref string }{{"base", q.Base}, {"head", q.Head}, {"range", q.RevRange}} {
+		if strings.HasPrefix(p.ref, "-") {
+			return Query{}, fmt.Errorf("%s: invalid ref %q", p.name, p.ref)
+		}
+	}
+	if q.RevRange != "" && (q.Base != "" || q.Head != "") {
+		return Query{}, fmt.Errorf("range cannot be combined with base or head")
+	}
+	if q.Empty != "" && q.Empty != "include" && q.Empty != "exclude" {
+		return Query{}, fmt.Errorf("empty must be 'include' or 'exclude', got %q", q.Empty)
+	}
+	return q, nil
+}
+
+// nonEmpty drops the empty values an HTML form submits for blank fields.
+func nonEmpty(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// run parses the request and analyzes it, writing a JSON error response
+// and returning false on failure.
+func (s *Server) run(w http.ResponseWriter, r *http.Request) (output.Summary, bool) {
+	q, err := ParseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return output.Summary{}, false
+	}
+	summary, err := s.Analyze(q)
+	if err != nil {
+		s.logf("%s: %v", r.URL, err)
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return output.Summary{}, false
+	}
+	return summary, true
+}
+
+func (s *Server) summary(w http.ResponseWriter, r *http.Request) {
+	summary, ok := s.run(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := output.RenderJSON(w, summary); err != nil {
+		s.logf("%s: rendering JSON: %v", r.URL, err)
+	}
+}
+
+func (s *Server) report(w http.ResponseWriter, r *http.Request) {
+	summary, ok := s.run(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := output.RenderHTML(w, summary, output.Options{List: true, Sort: "churn"}); err != nil {
+		s.logf("%s: rendering HTML: %v", r.URL, err)
+	}
+}
+
+var dashboardTmpl = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>differ</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 2rem; color: #222; }
+label { display: inline-block; margin-right: 1rem; }
+iframe { width: 100%; height: 70vh; border: 1px solid #ddd; margin-top: 1.5rem; }
+</style>
+</head>
+<body>
+<h1>differ</h1>
+<form action="/report" target="report">
+<label>Base <input name="base" placeholder="main" value="{{.Base}}"></label>
+<label>Head <input name="head" placeholder="HEAD" value="{{.Head}}"></label>
+<label>Path <input name="path" placeholder="all files"></label>
+<button type="submit">Analyze</button>
+</form>
+<p>JSON: <code>/summary?base=main&amp;head=HEAD</code></p>
+<iframe name="report" title="report"></iframe>
+</body>
+</html>
+`))
+
+func (s *Server) dashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := struct{ Base, Head string }{r.URL.Query().Get("base"), r.URL.Query().Get("head")}
+	if err := dashboardTmpl.Execute(w, data); err != nil {
+		s.logf("%s: rendering dashboard: %v", r.URL, err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func (s *Server) logf(format string, args ...any) {
+	log := s.Log
+	if log == nil {
+		log = os.Stderr
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	fmt.Fprintf(log, format+"\n", args...)
+}
@@ -0,0 +1,122 @@
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+func TestParseQuery(t *testing.T) {
+	v := url.Values{
+		"base":     {"main"},
+		"head":     {"HEAD"},
+		"path":     {"docs/", ""},
+		"category": {"docs", "tests"},
+		"empty":    {"include"},
+	}
+	q, err := ParseQuery(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Base != "main" || q.Head != "HEAD" || q.Empty != "include" {
+		t.Errorf("refs = %+v", q)
+	}
+	if len(q.Pathspecs) != 1 || q.Pathspecs[0] != "docs/" {
+		t.Errorf("Pathspecs = %q, want [docs/]", q.Pathspecs)
+	}
+	if len(q.Categories) != 2 {
+		t.Errorf("Categories = %q", q.Categories)
+	}
+
+	for _, bad := range []url.Values{
+		{"base": {"--output=/tmp/x"}},
+		{"range": {"-p"}},
+		{"range": {"main...HEAD"}, "head": {"HEAD"}},
+		{"empty": {"sometimes"}},
+	} {
+		if _, err := ParseQuery(bad); err == nil {
+			t.Errorf("ParseQuery(%v): expected an error", bad)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	var got Query
+	s := &Server{
+		Analyze: func(q Query) (output.Summary, error) {
+			if q.Base == "missing" {
+				return output.Summary{}, errors.New("unknown revision missing")
+			}
+			got = q
+			return output.Summary{
+				Meta:   output.Meta{Base: q.Base, Head: q.Head},
+				Totals: output.CategoryTotal{Added: 3, Churn: 3, FileCount: 1},
+				FileStats: []output.FileStat{
+					{Path: "main.go", Category: "source", Added: 3, Churn: 3},
+				},
+				CategoryTotals: map[string]output.CategoryTotal{"source": {Added: 3, Churn: 3, FileCount: 1}},
+			}, nil
+		},
+		Log: io.Discard,
+	}
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	get := func(path string) (*http.Response, string) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(body)
+	}
+
+	resp, body := get("/summary?base=main&head=feature&path=internal/")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("/summary: %s %s\n%s", resp.Status, resp.Header.Get("Content-Type"), body)
+	}
+	var result struct {
+		Meta  output.Meta `json:"meta"`
+		Total struct {
+			Churn int `json:"churn"`
+		} `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, body)
+	}
+	if result.Meta.Base != "main" || result.Total.Churn != 3 {
+		t.Errorf("unexpected summary: %s", body)
+	}
+	if got.Head != "feature" || len(got.Pathspecs) != 1 {
+		t.Errorf("query = %+v", got)
+	}
+
+	if resp, body := get("/report?base=main"); resp.StatusCode != http.StatusOK || !strings.Contains(body, "main.go") {
+		t.Errorf("/report: %s\n%s", resp.Status, body)
+	}
+	if resp, body := get("/"); resp.StatusCode != http.StatusOK || !strings.Contains(body, `action="/report"`) {
+		t.Errorf("/: %s\n%s", resp.Status, body)
+	}
+
+	if resp, body := get("/summary?base=-p"); resp.StatusCode != http.StatusBadRequest || !strings.Contains(body, `"error"`) {
+		t.Errorf("bad ref: %s\n%s", resp.Status, body)
+	}
+	if resp, body := get("/summary?base=missing"); resp.StatusCode != http.StatusUnprocessableEntity || !strings.Contains(body, "unknown revision") {
+		t.Errorf("failed analysis: %s\n%s", resp.Status, body)
+	}
+	if resp, _ := get("/nope"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("/nope: %s", resp.Status)
+	}
+}
@@ -0,0 +1,244 @@
+// Package setup inspects a repository and proposes a starting .differ.yml,
+// along with a git hook and CI workflow that run differ, for `differ setup`.
+package setup
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/classify"
+	"github.com/jbonatakis/differ/internal/config"
+)
+
+// Directory names that usually hold generated code but that the built-in
+// rules do not recognize.
+var codegenDirNames = map[string]bool{
+	"gen":               true,
+	"generated":         true,
+	"__generated__":     true,
+	"codegen":           true,
+	"autogen":           true,
+	"generated-sources": true,
+}
+
+// Directory names that usually hold tests but that the built-in rules do not
+// recognize.
+var testDirNames = map[string]bool{
+	"e2e":         true,
+	"integration": true,
+	"testdata":    true,
+	"testing":     true,
+	"fixtures":    true,
+	"cypress":     true,
+	"playwright":  true,
+}
+
+// Count is a name with a number of files.
+type Count struct {
+	Name  string
+	Files int
+}
+
+// Suggestion is a category pattern proposed for the config.
+type Suggestion struct {
+	Category string
+	Pattern  string
+	// Files is the number of files the pattern would reclassify.
+	Files int
+}
+
+// Report is what Inspect found in a repository.
+type Report struct {
+	Files int
+	// Languages are the detected source languages, most files first.
+	Languages []Count
+	// TestConventions are the built-in test file conventions in use, such
+	// as "*_test.go", most files first.
+	TestConventions []Count
+	// Suggestions are patterns for directories the built-in rules
+	// misclassify, ordered by path.
+	Suggestions []Suggestion
+}
+
+// Inspect classifies files, the repository's tracked paths, with the
+// built-in rules and looks for languages, test conventions, and directories
+// of generated code or tests that the rules miss.
+func Inspect(files []string) Report {
+	c := classify.New(config.Config{})
+	r := Report{Files: len(files)}
+	languages := make(map[string]int)
+	conventions := make(map[string]int)
+	// Directories matching codegenDirNames or testDirNames, with the number
+	// of their files that are not yet in the suggested category.
+	candidates := make(map[candidate]int)
+
+	for _, f := range files {
+		category, lang := c.Classify(f)
+		if category == classify.Source && lang != "" {
+			languages[lang]++
+		}
+		if category == classify.Tests {
+			if p := testConvention(filepath.Base(f)); p != "" {
+				conventions[p]++
+			}
+		}
+
+		dirs := strings.Split(f, "/")
+		dirs = dirs[:len(dirs)-1]
+		for i, name := range dirs {
+			dir := strings.Join(dirs[:i+1], "/") + "/"
+			switch {
+			case codegenDirNames[name] && category != classify.Generated:
+				candidates[candidate{classify.Generated, dir}]++
+			case testDirNames[name] && category != classify.Tests && category != classify.Generated:
+				candidates[candidate{classify.Tests, dir}]++
+			}
+		}
+	}
+
+	r.Languages = sortCounts(languages)
+	r.TestConventions = sortCounts(conventions)
+	for cand, n := range candidates {
+		if !cand.nested(candidates) {
+			r.Suggestions = append(r.Suggestions, Suggestion{Category: cand.category, Pattern: cand.dir, Files: n})
+		}
+	}
+	sort.Slice(r.Suggestions, func(i, j int) bool {
+		a, b := r.Suggestions[i], r.Suggestions[j]
+		if a.Pattern != b.Pattern {
+			return a.Pattern < b.Pattern
+		}
+		return a.Category < b.Category
+	})
+	return r
+}
+
+// testConvention returns the built-in test file pattern base matches, if
+// it is a well-known naming convention.
+func testConvention(base string) string {
+	for _, p := range []string{"*_test.go", "test_*.py", "*_test.py", "*_spec.rb", "*.test.*", "*.spec.*"} {
+		if matched, _ := filepath.Match(p, strings.ToLower(base)); matched {
+			return p
+		}
+	}
+	for _, p := range []string{"*Test.java", "*Tests.java", "*Test.kt"} {
+		if matched, _ := filepath.Match(p, base); matched {
+			return p
+		}
+	}
+	return ""
+}
+
+// candidate is a directory that may need a category pattern.
+type candidate struct{ category, dir string }
+
+// nested reports whether an enclosing directory of c is a candidate for the
+// same category, so that gen/foo/gen/ is covered by gen/.
+func (c candidate) nested(candidates map[candidate]int) bool {
+	for other := range candidates {
+		if other.category == c.category && other.dir != c.dir && strings.HasPrefix(c.dir, other.dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortCounts(m map[string]int) []Count {
+	counts := make([]Count, 0, len(m))
+	for name, n := range m {
+		counts = append(counts, Count{Name: name, Files: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Files != counts[j].Files {
+			return counts[i].Files > counts[j].Files
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// WriteConfig writes a .differ.yml that adds the accepted suggestions to
+// the built-in rules.
+func WriteConfig(w io.Writer, accepted []Suggestion) error {
+	var b strings.Builder
+	b.WriteString("# Created by differ setup; see the Config Files section of docs/usage.md.\n")
+	fmt.Fprintf(&b, "version: %d\n", config.CurrentVersion)
+
+	byCategory := make(map[string][]Suggestion)
+	for _, s := range accepted {
+		byCategory[s.Category] = append(byCategory[s.Category], s)
+	}
+	if len(byCategory) > 0 {
+		b.WriteString("categories:\n")
+	}
+	for _, category := range classify.Categories {
+		if len(byCategory[category]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s:\n    patterns:\n", category)
+		for _, s := range byCategory[category] {
+			fmt.Fprintf(&b, "      - %s # %d %s\n", strconv.Quote(s.Pattern), s.Files, plural(s.Files, "file"))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// HookScript returns a pre-push hook that prints the churn of the commits
+// being pushed against base. It never blocks the push.
+func HookScript(base string) string {
+	rangeArg := ""
+	if base != "" {
+		rangeArg = " " + base + "...HEAD"
+	}
+	return `#!/bin/sh
+# Installed by differ setup: show the churn of the branch being pushed.
+if command -v differ >/dev/null 2>&1; then
+	differ` + rangeArg + ` || true
+fi
+exit 0
+`
+}
+
+// GitHubWorkflow returns a GitHub Actions workflow that reports the churn
+// of pull requests into branch, such as "main", in the job summary.
+func GitHubWorkflow(branch string) string {
+	if branch == "" {
+		branch = "main"
+	}
+	return `# Installed by differ setup: report the churn of each pull request.
+name: differ
+on:
+  pull_request:
+    branches: [` + branch + `]
+jobs:
+  churn:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          fetch-depth: 0
+      - uses: actions/setup-go@v5
+        with:
+          go-version: stable
+      - run: go install github.com/jbonatakis/differ/cmd/differ@latest
+      - run: |
+          {
+            echo '## Churn'
+            echo '` + "```" + `'
+            differ --color never --base "origin/${{ github.base_ref }}" --head HEAD
+            echo '` + "```" + `'
+          } >> "$GITHUB_STEP_SUMMARY"
+`
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -0,0 +1,104 @@
+package setup
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInspect(t *testing.T) {
+	r := Inspect([]string{
+		"main.go",
+		"main_test.go",
+		"internal/api/api.go",
+		"internal/api/api_test.go",
+		"web/app.ts",
+		"web/app.test.ts",
+		"api/gen/client.go",
+		"api/gen/models/user.go",
+		"api/gen/models/gen/nested.go",
+		"vendor/gen/dep.go", // vendor/ is already generated
+		"e2e/login.ts",
+		"e2e/helpers/session.ts",
+		"tests/e2e/already.py", // tests/ already covers it
+		"README.md",
+	})
+
+	if r.Files != 14 {
+		t.Errorf("Files = %d, want 14", r.Files)
+	}
+	wantLangs := []Count{{"Go", 5}, {"TypeScript", 3}}
+	if !reflect.DeepEqual(r.Languages, wantLangs) {
+		t.Errorf("Languages = %+v, want %+v", r.Languages, wantLangs)
+	}
+	wantConventions := []Count{{"*_test.go", 2}, {"*.test.*", 1}}
+	if !reflect.DeepEqual(r.TestConventions, wantConventions) {
+		t.Errorf("TestConventions = %+v, want %+v", r.TestConventions, wantConventions)
+	}
+	wantSuggestions := []Suggestion{
+		{Category: "generated", Pattern: "api/gen/", Files: 3},
+		{Category: "tests", Pattern: "e2e/", Files: 2},
+	}
+	if !reflect.DeepEqual(r.Suggestions, wantSuggestions) {
+		t.Errorf("Suggestions = %+v, want %+v", r.Suggestions, wantSuggestions)
+	}
+}
+
+func TestWriteConfigIsLoadable(t *testing.T) {
+	var b strings.Builder
+	err := WriteConfig(&b, []Suggestion{
+		{Category: "tests", Pattern: "e2e/", Files: 2},
+		{Category: "generated", Pattern: "api/gen/", Files: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg config.Config
+	if err := yaml.Unmarshal([]byte(b.String()), &cfg); err != nil {
+		t.Fatalf("generated config does not parse: %v\n%s", err, b.String())
+	}
+	if cfg.Version != config.CurrentVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, config.CurrentVersion)
+	}
+	if got := cfg.Categories["generated"].Patterns; !reflect.DeepEqual(got, []string{"api/gen/"}) {
+		t.Errorf("generated patterns = %v", got)
+	}
+	if got := cfg.Categories["tests"].Patterns; !reflect.DeepEqual(got, []string{"e2e/"}) {
+		t.Errorf("tests patterns = %v", got)
+	}
+	// Generated comes before tests, in category priority order.
+	if strings.Index(b.String(), "generated:") > strings.Index(b.String(), "tests:") {
+		t.Errorf("categories out of priority order:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := WriteConfig(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "categories:") {
+		t.Errorf("expected no categories without suggestions:\n%s", b.String())
+	}
+}
+
+func TestHookScript(t *testing.T) {
+	if got := HookScript("origin/main"); !strings.Contains(got, "differ origin/main...HEAD || true") {
+		t.Errorf("hook does not diff against the base:\n%s", got)
+	}
+	if got := HookScript(""); !strings.Contains(got, "\tdiffer || true") {
+		t.Errorf("hook without base should auto-detect:\n%s", got)
+	}
+}
+
+func TestGitHubWorkflowIsYAML(t *testing.T) {
+	var wf map[string]any
+	if err := yaml.Unmarshal([]byte(GitHubWorkflow("develop")), &wf); err != nil {
+		t.Fatalf("workflow does not parse: %v", err)
+	}
+	if !strings.Contains(GitHubWorkflow("develop"), "branches: [develop]") {
+		t.Error("workflow does not target the default branch")
+	}
+}
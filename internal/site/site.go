@@ -0,0 +1,385 @@
+// Package site renders recorded history from the ledger as a static HTML
+// dashboard that can be served from any static host, such as GitHub Pages.
+package site
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// Options controls site generation.
+type Options struct {
+	Title string // page title; defaults to "Churn dashboard"
+	Top   int    // rows in hotspot tables; defaults to 20
+}
+
+// Chart geometry for the per-run trend bars, in SVG user units.
+const (
+	chartHeight = 160
+	barWidth    = 14
+	barGap      = 4
+)
+
+// categories lists the built-in categories in report order with their chart
+// colors. Custom categories are appended in name order using otherColor.
+var categories = []struct {
+	key, display, color string
+}{
+	{"docs", "Documentation", "#4c78a8"},
+	{"tests", "Tests", "#54a24b"},
+	{"source", "Source", "#f58518"},
+	{"ci", "CI & Build", "#eeca3b"},
+	{"i18n", "Localization", "#72b7b2"},
+	{"generated", "Generated", "#b279a2"},
+	{"other", "Uncategorized", "#9d9da1"},
+}
+
+const otherColor = "#bab0ac"
+
+type category struct {
+	Key, Display, Color string
+}
+
+type repoSummary struct {
+	Name     string
+	Slug     string
+	Runs     int
+	Added    int
+	Deleted  int
+	Churn    int
+	First    time.Time
+	Last     time.Time
+	ByCat    []catRow
+	Trend    trend
+	Hotspots []hotspot
+}
+
+type catRow struct {
+	Display, Color        string
+	Added, Deleted, Churn int
+	Share                 string
+}
+
+type hotspot struct {
+	Repo, RepoSlug string
+	Path           string
+	Category       string
+	Churn          int
+	Runs           int
+}
+
+type trend struct {
+	Width  int
+	Height int
+	Bars   []bar
+}
+
+type bar struct {
+	X, Y, Width, Height int
+	Color               string
+	Title               string
+}
+
+type indexPage struct {
+	Title     string
+	Generated string
+	Repos     []*repoSummary
+	Runs      int
+	Churn     int
+	Hotspots  []hotspot
+	Legend    []category
+}
+
+type repoPage struct {
+	Title     string
+	Generated string
+	Repo      *repoSummary
+	Legend    []category
+}
+
+// Build writes index.html, one page per repository under repos/, and a
+// shared style sheet into outDir, creating it if needed.
+func Build(outDir string, records []ledger.Record, opts Options) error {
+	if opts.Title == "" {
+		opts.Title = "Churn dashboard"
+	}
+	if opts.Top <= 0 {
+		opts.Top = 20
+	}
+
+	cats := categoryList(records)
+	repos := summarize(records, cats, opts.Top)
+	generated := output.Now().Format("2006-01-02 15:04 UTC")
+
+	if err := os.MkdirAll(filepath.Join(outDir, "repos"), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outDir, "style.css"), []byte(styleCSS), 0o644); err != nil {
+		return err
+	}
+
+	index := indexPage{Title: opts.Title, Generated: generated, Repos: repos, Legend: cats}
+	var all []hotspot
+	for _, r := range repos {
+		index.Runs += r.Runs
+		index.Churn += r.Churn
+		all = append(all, r.Hotspots...)
+	}
+	index.Hotspots = topHotspots(all, opts.Top)
+	if err := writePage(filepath.Join(outDir, "index.html"), indexTmpl, index); err != nil {
+		return err
+	}
+
+	for _, r := range repos {
+		page := repoPage{Title: opts.Title, Generated: generated, Repo: r, Legend: cats}
+		if err := writePage(filepath.Join(outDir, "repos", r.Slug+".html"), repoTmpl, page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePage(path string, tmpl *template.Template, data any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(f, data); err != nil {
+		f.Close()
+		return fmt.Errorf("rendering %s: %w", filepath.Base(path), err)
+	}
+	return f.Close()
+}
+
+// categoryList returns the built-in categories followed by any custom
+// categories that appear in records.
+func categoryList(records []ledger.Record) []category {
+	known := make(map[string]bool)
+	var cats []category
+	for _, c := range categories {
+		known[c.key] = true
+		cats = append(cats, category{Key: c.key, Display: c.display, Color: c.color})
+	}
+	var custom []string
+	for _, rec := range records {
+		for key := range rec.Snapshot.Categories {
+			if !known[key] {
+				known[key] = true
+				custom = append(custom, key)
+			}
+		}
+	}
+	sort.Strings(custom)
+	for _, key := range custom {
+		cats = append(cats, category{Key: key, Display: key, Color: otherColor})
+	}
+	return cats
+}
+
+// summarize groups records by repository, ordered by total churn descending
+// (the leaderboard order), then by name.
+func summarize(records []ledger.Record, cats []category, top int) []*repoSummary {
+	byName := make(map[string][]ledger.Record)
+	for _, rec := range records {
+		byName[rec.Repo] = append(byName[rec.Repo], rec)
+	}
+
+	slugs := make(map[string]bool)
+	var names []string
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	repos := make([]*repoSummary, 0, len(names))
+	for _, name := range names {
+		recs := byName[name]
+		sort.SliceStable(recs, func(i, j int) bool { return recs[i].RecordedAt.Before(recs[j].RecordedAt) })
+
+		r := &repoSummary{Name: name, Slug: uniqueSlug(name, slugs), Runs: len(recs)}
+		r.First, r.Last = recs[0].RecordedAt, recs[len(recs)-1].RecordedAt
+
+		catTotals := make(map[string]*catRow)
+		files := make(map[string]*hotspot)
+		names := latestNames(recs)
+		for i, rec := range recs {
+			r.Added += rec.Snapshot.Total.Added
+			r.Deleted += rec.Snapshot.Total.Deleted
+			r.Churn += rec.Snapshot.Total.Churn
+			for key, t := range rec.Snapshot.Categories {
+				row := catTotals[key]
+				if row == nil {
+					row = &catRow{}
+					catTotals[key] = row
+				}
+				row.Added += t.Added
+				row.Deleted += t.Deleted
+				row.Churn += t.Churn
+			}
+			for j, f := range rec.Snapshot.Files {
+				// Churn under earlier names counts toward the file's
+				// latest name, so moves do not reset its history.
+				path := names[i][j]
+				h := files[path]
+				if h == nil {
+					h = &hotspot{Repo: name, RepoSlug: r.Slug, Path: path}
+					files[path] = h
+				}
+				h.Category = f.Category
+				h.Churn += f.Churn
+				h.Runs++
+			}
+		}
+
+		for _, c := range cats {
+			row, ok := catTotals[c.Key]
+			if !ok || row.Churn == 0 {
+				continue
+			}
+			row.Display, row.Color = c.Display, c.Color
+			row.Share = percent(row.Churn, r.Churn)
+			r.ByCat = append(r.ByCat, *row)
+		}
+
+		r.Trend = buildTrend(recs, cats)
+
+		spots := make([]hotspot, 0, len(files))
+		for _, h := range files {
+			spots = append(spots, *h)
+		}
+		r.Hotspots = topHotspots(spots, top)
+
+		repos = append(repos, r)
+	}
+
+	sort.SliceStable(repos, func(i, j int) bool { return repos[i].Churn > repos[j].Churn })
+	return repos
+}
+
+// buildTrend lays out one stacked bar per recorded run, scaled to the
+// largest run.
+func buildTrend(recs []ledger.Record, cats []category) trend {
+	maxChurn := 0
+	for _, rec := range recs {
+		maxChurn = max(maxChurn, rec.Snapshot.Total.Churn)
+	}
+	t := trend{Width: len(recs)*(barWidth+barGap) + barGap, Height: chartHeight}
+	if maxChurn == 0 {
+		return t
+	}
+
+	for i, rec := range recs {
+		x := barGap + i*(barWidth+barGap)
+		y := chartHeight
+		for _, c := range cats {
+			churn := rec.Snapshot.Categories[c.Key].Churn
+			if churn == 0 {
+				continue
+			}
+			h := max(1, churn*chartHeight/maxChurn)
+			y -= h
+			t.Bars = append(t.Bars, bar{
+				X: x, Y: y, Width: barWidth, Height: h,
+				Color: c.Color,
+				Title: fmt.Sprintf("%s %s: %s %d", rec.RecordedAt.UTC().Format("2006-01-02"), shortSHA(rec.HeadSHA), c.Display, churn),
+			})
+		}
+	}
+	return t
+}
+
+// latestNames follows the renames recorded in recs, which are in recording
+// order, like git log --follow: names[i][j] is the latest name of the file
+// recs[i].Snapshot.Files[j]. A path that is reused by a different file after
+// a rename keeps the two files apart.
+func latestNames(recs []ledger.Record) [][]string {
+	names := make([][]string, len(recs))
+	// alias maps a path, as named at the point of the walk, to the file's
+	// latest name. Walking from newest to oldest, a rename points the old
+	// path at the file and frees the new path for whichever file held it
+	// before.
+	alias := make(map[string]string)
+	for i := len(recs) - 1; i >= 0; i-- {
+		files := recs[i].Snapshot.Files
+		names[i] = make([]string, len(files))
+		for j, f := range files {
+			names[i][j] = f.Path
+			if latest, ok := alias[f.Path]; ok {
+				names[i][j] = latest
+			}
+		}
+		for _, f := range files {
+			if f.OldPath != "" {
+				delete(alias, f.Path)
+			}
+		}
+		for j, f := range files {
+			if f.OldPath != "" {
+				alias[f.OldPath] = names[i][j]
+			}
+		}
+	}
+	return names
+}
+
+// topHotspots sorts by churn descending, then path, and keeps the first n.
+func topHotspots(spots []hotspot, n int) []hotspot {
+	sort.Slice(spots, func(i, j int) bool {
+		if spots[i].Churn != spots[j].Churn {
+			return spots[i].Churn > spots[j].Churn
+		}
+		if spots[i].Repo != spots[j].Repo {
+			return spots[i].Repo < spots[j].Repo
+		}
+		return spots[i].Path < spots[j].Path
+	})
+	if len(spots) > n {
+		spots = spots[:n]
+	}
+	return spots
+}
+
+// uniqueSlug turns a repository name into a file-name-safe slug, suffixing
+// it when two names collapse to the same slug.
+func uniqueSlug(name string, used map[string]bool) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		} else if !strings.HasSuffix(b.String(), "-") {
+			b.WriteByte('-')
+		}
+	}
+	base := strings.Trim(b.String(), "-.")
+	if base == "" {
+		base = "repo"
+	}
+	slug := base
+	for i := 2; used[slug]; i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	used[slug] = true
+	return slug
+}
+
+func percent(part, whole int) string {
+	if whole == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", float64(part)*100/float64(whole))
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,174 @@
+package site
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/snapshot"
+)
+
+func record(repo string, day int, files ...output.FileStat) ledger.Record {
+	s := output.Summary{CategoryTotals: map[string]output.CategoryTotal{}}
+	for _, f := range files {
+		s.FileStats = append(s.FileStats, f)
+		s.Totals.Added += f.Added
+		s.Totals.Deleted += f.Deleted
+		s.Totals.Churn += f.Churn
+		s.Totals.FileCount++
+		ct := s.CategoryTotals[f.Category]
+		ct.Added += f.Added
+		ct.Deleted += f.Deleted
+		ct.Churn += f.Churn
+		ct.FileCount++
+		s.CategoryTotals[f.Category] = ct
+	}
+	return ledger.Record{
+		Repo:       repo,
+		RecordedAt: time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC),
+		HeadSHA:    "0123456789abcdef",
+		Snapshot:   snapshot.FromSummary(s),
+	}
+}
+
+func file(path, category string, added, deleted int) output.FileStat {
+	return output.FileStat{Path: path, Category: category, Added: added, Deleted: deleted, Churn: added + deleted}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBuild(t *testing.T) {
+	records := []ledger.Record{
+		record("api", 2, file("main.go", "source", 10, 2), file("README.md", "docs", 3, 0)),
+		record("api", 1, file("main.go", "source", 5, 5)),
+		record("web <ui>", 1, file("app.ts", "frontend", 100, 0)),
+	}
+	out := t.TempDir()
+	if err := Build(out, records, Options{Title: "Fleet"}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	index := readFile(t, filepath.Join(out, "index.html"))
+	// Leaderboard is ordered by churn: web (100) before api (25).
+	webIdx := strings.Index(index, `href="repos/web-ui.html"`)
+	apiIdx := strings.Index(index, `href="repos/api.html"`)
+	if webIdx < 0 || apiIdx < 0 || webIdx > apiIdx {
+		t.Errorf("leaderboard order wrong or links missing:\n%s", index)
+	}
+	if !strings.Contains(index, "web &lt;ui&gt;") {
+		t.Error("repository names must be HTML-escaped")
+	}
+	if !strings.Contains(index, "3 recorded runs across 2 repositories, 125 lines of churn") {
+		t.Errorf("missing fleet totals:\n%s", index)
+	}
+
+	api := readFile(t, filepath.Join(out, "repos", "api.html"))
+	if !strings.Contains(api, "2 recorded runs from 2024-01-01 to 2024-01-02") {
+		t.Errorf("missing run range:\n%s", api)
+	}
+	// main.go is the top hotspot with churn 22 across both runs.
+	if !strings.Contains(api, "<code>main.go</code></td><td>source</td><td class=\"num\">2</td><td class=\"num\">22</td>") {
+		t.Errorf("missing main.go hotspot:\n%s", api)
+	}
+	if strings.Count(api, "<rect x=") != 3 {
+		t.Errorf("expected 3 trend bar segments, got %d", strings.Count(api, "<rect x="))
+	}
+
+	web := readFile(t, filepath.Join(out, "repos", "web-ui.html"))
+	if !strings.Contains(web, "frontend") {
+		t.Error("custom categories should appear on repo pages")
+	}
+
+	if _, err := os.Stat(filepath.Join(out, "style.css")); err != nil {
+		t.Errorf("style.css not written: %v", err)
+	}
+}
+
+func renamed(oldPath string, f output.FileStat) output.FileStat {
+	f.OldPath = oldPath
+	return f
+}
+
+func TestLatestNames(t *testing.T) {
+	recs := []ledger.Record{
+		record("api", 1, file("pkg/a.go", "source", 5, 0), file("b.go", "source", 1, 0)),
+		record("api", 2, renamed("pkg/a.go", file("internal/a.go", "source", 0, 0))),
+		record("api", 3, file("internal/a.go", "source", 2, 1), renamed("b.go", file("c.go", "source", 1, 1))),
+		// pkg/a.go is reused by a new file; it must not merge with internal/a.go.
+		record("api", 4, file("pkg/a.go", "source", 4, 0), renamed("internal/a.go", file("lib/a.go", "source", 0, 0))),
+	}
+	names := latestNames(recs)
+	want := [][]string{
+		{"lib/a.go", "c.go"},
+		{"lib/a.go"},
+		{"lib/a.go", "c.go"},
+		{"pkg/a.go", "lib/a.go"},
+	}
+	for i := range want {
+		if strings.Join(names[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("record %d: names = %v, want %v", i, names[i], want[i])
+		}
+	}
+}
+
+func TestBuildFollowsRenames(t *testing.T) {
+	records := []ledger.Record{
+		record("api", 1, file("pkg/store.go", "source", 30, 0)),
+		record("api", 2, renamed("pkg/store.go", file("internal/store/store.go", "source", 5, 5))),
+	}
+	out := t.TempDir()
+	if err := Build(out, records, Options{}); err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	api := readFile(t, filepath.Join(out, "repos", "api.html"))
+	if !strings.Contains(api, "<code>internal/store/store.go</code></td><td>source</td><td class=\"num\">2</td><td class=\"num\">40</td>") {
+		t.Errorf("expected churn from both names under the latest one:\n%s", api)
+	}
+	if strings.Contains(api, "<code>pkg/store.go</code>") {
+		t.Errorf("old name should not be a separate hotspot:\n%s", api)
+	}
+}
+
+func TestBuildTrendScaling(t *testing.T) {
+	recs := []ledger.Record{
+		record("api", 1, file("a.go", "source", 40, 0)),
+		record("api", 2, file("a.go", "source", 80, 0), file("a.md", "docs", 80, 0)),
+	}
+	tr := buildTrend(recs, categoryList(recs))
+	if len(tr.Bars) != 3 {
+		t.Fatalf("got %d bars, want 3", len(tr.Bars))
+	}
+	// The first run is a quarter of the largest run.
+	if tr.Bars[0].Height != chartHeight/4 {
+		t.Errorf("first bar height = %d, want %d", tr.Bars[0].Height, chartHeight/4)
+	}
+	// Segments of the second run stack to the full height.
+	if tr.Bars[1].Height+tr.Bars[2].Height != chartHeight || tr.Bars[2].Y != 0 {
+		t.Errorf("second run segments = %+v, %+v", tr.Bars[1], tr.Bars[2])
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	used := map[string]bool{}
+	for _, tc := range []struct{ name, want string }{
+		{"org/api", "org-api"},
+		{"org:api", "org-api-2"},
+		{"///", "repo"},
+		{"Web.App", "web.app"},
+	} {
+		if got := uniqueSlug(tc.name, used); got != tc.want {
+			t.Errorf("uniqueSlug(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package site
+
+import (
+	"html/template"
+	"time"
+)
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+	"inc":  func(i int) int { return i + 1 },
+	"plural": func(n int, one, many string) string {
+		if n == 1 {
+			return one
+		}
+		return many
+	},
+}
+
+const headerTmpl = `{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.}}</title>
+{{end}}`
+
+const legendTmpl = `{{define "legend"}}<p class="legend">{{range .}}<svg width="10" height="10"><rect width="10" height="10" fill="{{.Color}}"/></svg> {{.Display}} {{end}}</p>{{end}}`
+
+var indexTmpl = template.Must(template.New("index").Funcs(funcs).Parse(headerTmpl + legendTmpl + `{{template "header" .Title}}<link rel="stylesheet" href="style.css">
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="muted">{{.Runs}} recorded {{plural .Runs "run" "runs"}} across {{len .Repos}} {{plural (len .Repos) "repository" "repositories"}}, {{.Churn}} lines of churn. Generated {{.Generated}}.</p>
+
+<h2>Repository leaderboard</h2>
+<table>
+<tr><th>#</th><th>Repository</th><th class="num">Runs</th><th class="num">Added</th><th class="num">Deleted</th><th class="num">Churn</th><th>Last recorded</th></tr>
+{{range $i, $r := .Repos}}<tr><td>{{inc $i}}</td><td><a href="repos/{{$r.Slug}}.html">{{$r.Name}}</a></td><td class="num">{{$r.Runs}}</td><td class="num add">+{{$r.Added}}</td><td class="num del">-{{$r.Deleted}}</td><td class="num">{{$r.Churn}}</td><td>{{date $r.Last}}</td></tr>
+{{end}}</table>
+
+<h2>Hotspots</h2>
+<table>
+<tr><th>Repository</th><th>Path</th><th>Category</th><th class="num">Runs</th><th class="num">Churn</th></tr>
+{{range .Hotspots}}<tr><td><a href="repos/{{.RepoSlug}}.html">{{.Repo}}</a></td><td><code>{{.Path}}</code></td><td>{{.Category}}</td><td class="num">{{.Runs}}</td><td class="num">{{.Churn}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+var repoTmpl = template.Must(template.New("repo").Funcs(funcs).Parse(headerTmpl + legendTmpl + `{{template "header" .Repo.Name}}<link rel="stylesheet" href="../style.css">
+</head>
+<body>
+<p><a href="../index.html">&larr; {{.Title}}</a></p>
+{{with .Repo}}<h1>{{.Name}}</h1>
+<p class="muted">{{.Runs}} recorded {{plural .Runs "run" "runs"}} from {{date .First}} to {{date .Last}}: <span class="add">+{{.Added}}</span> <span class="del">-{{.Deleted}}</span> ({{.Churn}} churn).</p>
+
+<h2>Trend</h2>
+<svg class="trend" width="{{.Trend.Width}}" height="{{.Trend.Height}}" viewBox="0 0 {{.Trend.Width}} {{.Trend.Height}}">
+{{range .Trend.Bars}}<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}" fill="{{.Color}}"><title>{{.Title}}</title></rect>
+{{end}}</svg>
+{{end}}{{template "legend" .Legend}}
+{{with .Repo}}
+<h2>By category</h2>
+<table>
+<tr><th>Category</th><th class="num">Added</th><th class="num">Deleted</th><th class="num">Churn</th><th class="num">Share</th></tr>
+{{range .ByCat}}<tr><td><svg width="10" height="10"><rect width="10" height="10" fill="{{.Color}}"/></svg> {{.Display}}</td><td class="num add">+{{.Added}}</td><td class="num del">-{{.Deleted}}</td><td class="num">{{.Churn}}</td><td class="num">{{.Share}}</td></tr>
+{{end}}</table>
+
+<h2>Hotspots</h2>
+<table>
+<tr><th>Path</th><th>Category</th><th class="num">Runs</th><th class="num">Churn</th></tr>
+{{range .Hotspots}}<tr><td><code>{{.Path}}</code></td><td>{{.Category}}</td><td class="num">{{.Runs}}</td><td class="num">{{.Churn}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+const styleCSS = `body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2rem auto; max-width: 60rem; padding: 0 1rem; color: #1f2328; }
+h1 { margin-bottom: 0.25rem; }
+h2 { margin-top: 2rem; border-bottom: 1px solid #d0d7de; padding-bottom: 0.25rem; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.3rem 0.6rem; border-bottom: 1px solid #eaeef2; }
+.num { text-align: right; font-variant-numeric: tabular-nums; }
+.add { color: #1a7f37; }
+.del { color: #cf222e; }
+.muted { color: #656d76; }
+.legend { font-size: 0.85rem; color: #656d76; }
+svg.trend { display: block; max-width: 100%; height: auto; }
+a { color: #0969da; }
+`
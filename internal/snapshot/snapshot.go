@@ -0,0 +1,139 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// FormatVersion is the current snapshot format version. It is bumped whenever
+// the on-disk layout changes in a way older readers cannot handle.
+const FormatVersion = 1
+
+// Snapshot is the persisted form of a single differ run.
+type Snapshot struct {
+	Version    int               `json:"version"`
+	Meta       output.Meta       `json:"meta"`
+	Total      Totals            `json:"total"`
+	Categories map[string]Totals `json:"categories"`
+	Files      []File            `json:"files"`
+}
+
+// Totals holds aggregate line counts for a category or the whole run.
+type Totals struct {
+	Added   int `json:"added"`
+	Deleted int `json:"deleted"`
+	Churn   int `json:"churn"`
+	Moved   int `json:"moved,omitempty"`
+	Files   int `json:"files"`
+}
+
+// File holds per-file statistics in a snapshot.
+type File struct {
+	Path     string `json:"path"`
+	Added    int    `json:"added"`
+	Deleted  int    `json:"deleted"`
+	Churn    int    `json:"churn"`
+	Moved    int    `json:"moved,omitempty"`
+	Category string `json:"category"`
+	Language string `json:"language"`
+	OldPath  string `json:"old_path,omitempty"` // previous path if renamed
+	OldMode  string `json:"old_mode,omitempty"`
+	NewMode  string `json:"new_mode,omitempty"`
+	Status   string `json:"status,omitempty"`
+}
+
+// FromSummary converts a rendered summary into a snapshot.
+func FromSummary(s output.Summary) Snapshot {
+	snap := Snapshot{
+		Version:    FormatVersion,
+		Meta:       s.Meta,
+		Total:      fromTotal(s.Totals),
+		Categories: make(map[string]Totals, len(s.CategoryTotals)),
+		Files:      make([]File, 0, len(s.FileStats)),
+	}
+	for cat, ct := range s.CategoryTotals {
+		snap.Categories[cat] = fromTotal(ct)
+	}
+	for _, f := range s.FileStats {
+		snap.Files = append(snap.Files, File{
+			Path:     f.Path,
+			Added:    f.Added,
+			Deleted:  f.Deleted,
+			Churn:    f.Churn,
+			Moved:    f.Moved,
+			Category: f.Category,
+			Language: f.Language,
+			OldPath:  f.OldPath,
+			OldMode:  f.OldMode,
+			NewMode:  f.NewMode,
+			Status:   f.Status,
+		})
+	}
+	return snap
+}
+
+// Summary converts the snapshot back into an output summary.
+func (snap Snapshot) Summary() output.Summary {
+	s := output.Summary{
+		Totals:         toTotal(snap.Total),
+		CategoryTotals: make(map[string]output.CategoryTotal, len(snap.Categories)),
+		FileStats:      make([]output.FileStat, 0, len(snap.Files)),
+		Meta:           snap.Meta,
+	}
+	for cat, t := range snap.Categories {
+		s.CategoryTotals[cat] = toTotal(t)
+	}
+	for _, f := range snap.Files {
+		s.FileStats = append(s.FileStats, output.FileStat{
+			Path:     f.Path,
+			Added:    f.Added,
+			Deleted:  f.Deleted,
+			Churn:    f.Churn,
+			Moved:    f.Moved,
+			Category: f.Category,
+			Language: f.Language,
+			OldPath:  f.OldPath,
+			OldMode:  f.OldMode,
+			NewMode:  f.NewMode,
+			Status:   f.Status,
+		})
+	}
+	return s
+}
+
+// Write saves a snapshot of summary to path as indented JSON.
+func Write(path string, s output.Summary) error {
+	data, err := json.MarshalIndent(FromSummary(s), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Read loads a snapshot from path. It rejects snapshots written by a newer
+// format version.
+func Read(path string) (Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return Snapshot{}, fmt.Errorf("malformed snapshot: %w", err)
+	}
+	if snap.Version < 1 || snap.Version > FormatVersion {
+		return Snapshot{}, fmt.Errorf("unsupported snapshot version %d (supported: 1-%d)", snap.Version, FormatVersion)
+	}
+	return snap, nil
+}
+
+func fromTotal(ct output.CategoryTotal) Totals {
+	return Totals{Added: ct.Added, Deleted: ct.Deleted, Churn: ct.Churn, Moved: ct.Moved, Files: ct.FileCount}
+}
+
+func toTotal(t Totals) output.CategoryTotal {
+	return output.CategoryTotal{Added: t.Added, Deleted: t.Deleted, Churn: t.Churn, Moved: t.Moved, FileCount: t.Files}
+}
@@ -0,0 +1,76 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+func testSummary() output.Summary {
+	return output.Summary{
+		Totals: output.CategoryTotal{Added: 15, Deleted: 5, Churn: 20, FileCount: 2},
+		CategoryTotals: map[string]output.CategoryTotal{
+			"source": {Added: 10, Deleted: 5, Churn: 15, FileCount: 1},
+			"docs":   {Added: 5, Deleted: 0, Churn: 5, FileCount: 1},
+		},
+		FileStats: []output.FileStat{
+			{Path: "main.go", Added: 10, Deleted: 5, Churn: 15, Category: "source", Language: "Go"},
+			{Path: "README.md", Added: 5, Deleted: 0, Churn: 5, Category: "docs"},
+		},
+		Meta: output.Meta{
+			Base:      "main",
+			Head:      "HEAD",
+			Empty:     "exclude",
+			Pathspecs: []string{"internal/"},
+			Timestamp: "2024-01-15T10:30:00Z",
+		},
+	}
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	want := testSummary()
+
+	if err := Write(path, want); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	snap, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if snap.Version != FormatVersion {
+		t.Errorf("Version = %d, want %d", snap.Version, FormatVersion)
+	}
+	if got := snap.Summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReadRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Fatal("expected error for unsupported version")
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(path, []byte(`{not json`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Fatal("expected error for malformed snapshot")
+	}
+}
+
+func TestReadMissingFile(t *testing.T) {
+	if _, err := Read(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
@@ -0,0 +1,260 @@
+// Package trend turns the runs recorded in a history ledger into churn per
+// category over time, for text charts and JSON.
+package trend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/jbonatakis/differ/internal/output"
+)
+
+// Periods runs can be grouped by. PeriodRun keeps every run on its own.
+const (
+	PeriodRun   = "run"
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Periods lists the accepted groupings.
+var Periods = []string{PeriodRun, PeriodDay, PeriodWeek, PeriodMonth}
+
+// Point is the churn of the runs recorded in one period, or of one run.
+type Point struct {
+	// Label names the period: the date it starts on, "2024-01" for a
+	// month, or for a run the time recorded and its short head commit.
+	Label      string         `json:"label"`
+	Start      time.Time      `json:"start"`
+	HeadSHA    string         `json:"head_sha,omitempty"` // runs only
+	Runs       int            `json:"runs"`
+	Churn      int            `json:"churn"`
+	ByCategory map[string]int `json:"by_category"`
+}
+
+// Build sums the churn of records per period, oldest first. Periods between
+// the first and last record without runs are included with none, so gaps
+// show in a chart. Records are grouped by the UTC time they were recorded;
+// weeks start on Monday.
+func Build(records []ledger.Record, period string) ([]Point, error) {
+	if !slices.Contains(Periods, period) {
+		return nil, fmt.Errorf("unknown period %q (want %s)", period, strings.Join(Periods, ", "))
+	}
+	sorted := append([]ledger.Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].RecordedAt.Before(sorted[j].RecordedAt) })
+
+	var points []Point
+	for _, rec := range sorted {
+		at := rec.RecordedAt.UTC()
+		if period == PeriodRun {
+			p := Point{Label: at.Format("2006-01-02 15:04"), Start: at, HeadSHA: rec.HeadSHA, ByCategory: make(map[string]int)}
+			if sha := shortSHA(rec.HeadSHA); sha != "" {
+				p.Label += " " + sha
+			}
+			points = append(points, add(p, rec))
+			continue
+		}
+		start := periodStart(at, period)
+		for len(points) > 0 && points[len(points)-1].Start.Before(start) {
+			points = append(points, newPoint(nextStart(points[len(points)-1].Start, period), period))
+		}
+		if len(points) == 0 {
+			points = append(points, newPoint(start, period))
+		}
+		points[len(points)-1] = add(points[len(points)-1], rec)
+	}
+	return points, nil
+}
+
+func newPoint(start time.Time, period string) Point {
+	label := start.Format("2006-01-02")
+	if period == PeriodMonth {
+		label = start.Format("2006-01")
+	}
+	return Point{Label: label, Start: start, ByCategory: make(map[string]int)}
+}
+
+// add counts rec in p.
+func add(p Point, rec ledger.Record) Point {
+	p.Runs++
+	p.Churn += rec.Snapshot.Total.Churn
+	for cat, t := range rec.Snapshot.Categories {
+		p.ByCategory[cat] += t.Churn
+	}
+	return p
+}
+
+// periodStart returns the start of the day, week, or month at falls in.
+func periodStart(at time.Time, period string) time.Time {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case PeriodWeek:
+		// Weekday counts from Sunday; weeks start on Monday.
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case PeriodMonth:
+		return time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+func nextStart(start time.Time, period string) time.Time {
+	switch period {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Categories returns the categories any point has churn in, in report
+// order followed by custom categories by name.
+func Categories(points []Point) []string {
+	seen := make(map[string]bool)
+	for _, p := range points {
+		for cat, churn := range p.ByCategory {
+			if churn > 0 {
+				seen[cat] = true
+			}
+		}
+	}
+	var cats []string
+	for _, key := range output.DisplayOrder() {
+		if seen[key] {
+			cats = append(cats, key)
+			delete(seen, key)
+		}
+	}
+	var custom []string
+	for key := range seen {
+		custom = append(custom, key)
+	}
+	sort.Strings(custom)
+	return append(cats, custom...)
+}
+
+// RenderText writes a table of churn per category for each point, with a
+// bar of width at most width drawn in each category's symbol, scaled to
+// the largest total, and a legend of the symbols.
+func RenderText(w io.Writer, points []Point, width int) {
+	if len(points) == 0 {
+		fmt.Fprintln(w, "No recorded runs.")
+		return
+	}
+	cats := Categories(points)
+	symbols := symbolsFor(cats)
+
+	header := []string{""}
+	for _, cat := range cats {
+		header = append(header, output.DisplayName(cat))
+	}
+	rows := [][]string{append(header, "Total")}
+	peak := 0
+	for _, p := range points {
+		row := []string{p.Label}
+		for _, cat := range cats {
+			row = append(row, fmt.Sprint(p.ByCategory[cat]))
+		}
+		rows = append(rows, append(row, fmt.Sprint(p.Churn)))
+		peak = max(peak, p.Churn)
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	for i, row := range rows {
+		cells := []string{fmt.Sprintf("%-*s", widths[0], row[0])}
+		for j, cell := range row[1:] {
+			cells = append(cells, fmt.Sprintf("%*s", widths[j+1], cell))
+		}
+		if i > 0 {
+			cells = append(cells, bar(points[i-1], cats, symbols, peak, width))
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+
+	legend := make([]string, 0, len(cats))
+	for _, cat := range cats {
+		legend = append(legend, fmt.Sprintf("%c %s", symbols[cat], output.DisplayName(cat)))
+	}
+	if len(legend) > 0 {
+		fmt.Fprintf(w, "\n%s\n", strings.Join(legend, "  "))
+	}
+}
+
+// bar draws p's churn as a bar of symbols, one run of each category's
+// symbol, with the largest total peak as wide as width. Segments are
+// rounded on their running total, so the bar's length matches the total.
+func bar(p Point, cats []string, symbols map[string]rune, peak, width int) string {
+	if peak == 0 {
+		return ""
+	}
+	var b strings.Builder
+	sum, drawn := 0, 0
+	for _, cat := range cats {
+		sum += p.ByCategory[cat]
+		end := (sum*width + peak/2) / peak
+		if end > drawn {
+			b.WriteString(strings.Repeat(string(symbols[cat]), end-drawn))
+			drawn = end
+		}
+	}
+	return b.String()
+}
+
+// symbolsFor assigns each category the upper-case first letter of its name,
+// or of the first letter free among the rest of its name, falling back to a
+// digit.
+func symbolsFor(cats []string) map[string]rune {
+	symbols := make(map[string]rune, len(cats))
+	used := make(map[rune]bool)
+	for i, cat := range cats {
+		symbol := rune('0' + i%10)
+		for _, r := range strings.ToUpper(cat) {
+			if r >= 'A' && r <= 'Z' && !used[r] {
+				symbol = r
+				break
+			}
+		}
+		used[symbol] = true
+		symbols[cat] = symbol
+	}
+	return symbols
+}
+
+// RenderJSON writes the points as a JSON object with the repository, the
+// period, the categories with churn, and a "points" array, oldest first.
+func RenderJSON(w io.Writer, repo, period string, points []Point) error {
+	if points == nil {
+		points = []Point{}
+	}
+	categories := Categories(points)
+	if categories == nil {
+		categories = []string{}
+	}
+	out := struct {
+		Repo       string   `json:"repo,omitempty"`
+		Period     string   `json:"period"`
+		Categories []string `json:"categories"`
+		Points     []Point  `json:"points"`
+	}{repo, period, categories, points}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
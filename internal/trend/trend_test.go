@@ -0,0 +1,138 @@
+package trend
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/ledger"
+	"github.com/jbonatakis/differ/internal/snapshot"
+)
+
+func record(at string, source, docs int) ledger.Record {
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		panic(err)
+	}
+	return ledger.Record{
+		Repo:       "api",
+		RecordedAt: t,
+		HeadSHA:    "0123456789abcdef",
+		Snapshot: snapshot.Snapshot{
+			Version: snapshot.FormatVersion,
+			Total:   snapshot.Totals{Churn: source + docs},
+			Categories: map[string]snapshot.Totals{
+				"source": {Churn: source},
+				"docs":   {Churn: docs},
+			},
+		},
+	}
+}
+
+func testRecords() []ledger.Record {
+	return []ledger.Record{
+		record("2024-01-17T10:00:00Z", 300, 40), // out of order
+		record("2024-01-02T10:00:00Z", 100, 10),
+		record("2024-01-03T09:00:00Z", 50, 0),
+		record("2024-01-22T10:00:00Z", 20, 5),
+	}
+}
+
+func TestBuildWeeks(t *testing.T) {
+	points, err := Build(testRecords(), PeriodWeek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	var churn, runs []int
+	for _, p := range points {
+		labels = append(labels, p.Label)
+		churn = append(churn, p.Churn)
+		runs = append(runs, p.Runs)
+	}
+	// The week of January 8 has no runs but is kept as a gap.
+	if want := []string{"2024-01-01", "2024-01-08", "2024-01-15", "2024-01-22"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("labels = %v, want %v", labels, want)
+	}
+	if want := []int{160, 0, 340, 25}; !reflect.DeepEqual(churn, want) {
+		t.Errorf("churn = %v, want %v", churn, want)
+	}
+	if want := []int{2, 0, 1, 1}; !reflect.DeepEqual(runs, want) {
+		t.Errorf("runs = %v, want %v", runs, want)
+	}
+	if got := points[0].ByCategory; got["source"] != 150 || got["docs"] != 10 {
+		t.Errorf("first week by category = %v", got)
+	}
+}
+
+func TestBuildPeriods(t *testing.T) {
+	months, _ := Build(testRecords(), PeriodMonth)
+	if len(months) != 1 || months[0].Label != "2024-01" || months[0].Churn != 525 {
+		t.Errorf("months = %+v", months)
+	}
+	days, _ := Build(testRecords(), PeriodDay)
+	if len(days) != 21 || days[1].Label != "2024-01-03" {
+		t.Errorf("got %d days starting %+v, want 21 from January 2 to 22", len(days), days[:2])
+	}
+	runs, _ := Build(testRecords(), PeriodRun)
+	if len(runs) != 4 || runs[0].Label != "2024-01-02 10:00 0123456" || runs[3].HeadSHA == "" {
+		t.Errorf("runs = %+v", runs)
+	}
+	if _, err := Build(nil, "year"); err == nil {
+		t.Error("expected an error for an unknown period")
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	points, _ := Build(testRecords(), PeriodWeek)
+	var buf bytes.Buffer
+	RenderText(&buf, points, 20)
+	want := "            Documentation  Source  Total\n" +
+		"2024-01-01             10     150    160  DSSSSSSSS\n" +
+		"2024-01-08              0       0      0\n" +
+		"2024-01-15             40     300    340  DDSSSSSSSSSSSSSSSSSS\n" +
+		"2024-01-22              5      20     25  S\n" +
+		"\n" +
+		"D Documentation  S Source\n"
+	if buf.String() != want {
+		t.Errorf("text:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	RenderText(&buf, nil, 20)
+	if buf.String() != "No recorded runs.\n" {
+		t.Errorf("empty = %q", buf.String())
+	}
+}
+
+func TestSymbolsFor(t *testing.T) {
+	got := symbolsFor([]string{"source", "schemas", "ci"})
+	if want := map[string]rune{"source": 'S', "schemas": 'C', "ci": 'I'}; !reflect.DeepEqual(got, want) {
+		t.Errorf("symbols = %q, want %q", got, want)
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	points, _ := Build(testRecords(), PeriodMonth)
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, "api", PeriodMonth, points); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Repo       string   `json:"repo"`
+		Period     string   `json:"period"`
+		Categories []string `json:"categories"`
+		Points     []Point  `json:"points"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Repo != "api" || out.Period != "month" || !reflect.DeepEqual(out.Categories, []string{"docs", "source"}) {
+		t.Errorf("header = %q %q %v", out.Repo, out.Period, out.Categories)
+	}
+	if len(out.Points) != 1 || out.Points[0].ByCategory["docs"] != 55 {
+		t.Errorf("points = %+v", out.Points)
+	}
+}
@@ -0,0 +1,312 @@
+// Package differ embeds differ's churn analysis in other Go programs.
+//
+// Analyze runs the whole pipeline for a repository: it loads .differ.yml,
+// resolves refs, diffs, classifies and filters files, and aggregates churn
+// per category. The lower-level steps (DiffStats, Summarize, and their
+// streaming forms StreamStats and Summarizer) and renderers are exported
+// too, for callers that supply their own diff or output.
+//
+//	summary, err := differ.Analyze(differ.Options{Dir: "/src/app", Base: "main", Head: "HEAD"})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(summary.CategoryTotals["source"].Churn)
+//
+// Summary has the same shape as differ's JSON output, which RenderJSON
+// writes.
+package differ
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jbonatakis/differ/internal/classify"
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/migrate"
+	"github.com/jbonatakis/differ/internal/output"
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+// Types shared with the rest of differ.
+type (
+	// Config is a merged .differ.yml configuration.
+	Config = config.Config
+	// CategoryConfig holds custom rules for one category.
+	CategoryConfig = config.CategoryConfig
+
+	// Summary is the result of an analysis.
+	Summary = output.Summary
+	// FileStat is one changed file in a Summary.
+	FileStat = output.FileStat
+	// CategoryTotal aggregates the files of one category.
+	CategoryTotal = output.CategoryTotal
+	// Meta describes how a Summary was produced.
+	Meta = output.Meta
+	// Warning is a non-fatal issue found during analysis.
+	Warning = output.Warning
+	// RenderOptions controls rendering.
+	RenderOptions = output.Options
+	// Renderer writes a Summary in one output format; RendererFunc adapts
+	// a function to it.
+	Renderer     = output.Renderer
+	RendererFunc = output.RendererFunc
+
+	// Runner runs git; DiffOptions and ParseOptions tune DiffStats.
+	Runner       = gitdiff.CommandRunner
+	DiffOptions  = gitdiff.DiffOptions
+	ParseOptions = parser.ParseOptions
+	// FileDiff is the line counts of one file parsed from a diff, before
+	// classification.
+	FileDiff = parser.FileStat
+	// RiskyStatement is a risky migration statement found in an added line.
+	RiskyStatement = migrate.Warning
+)
+
+// Options selects what Analyze compares and how.
+type Options struct {
+	// Dir is the repository to analyze; empty means the current directory.
+	Dir string
+	// Runner runs git. If nil, git runs in Dir.
+	Runner Runner
+	// Config replaces the .differ.yml files in Dir (and the global config)
+	// when set. Per-directory configs are still applied.
+	Config *Config
+
+	// Base and Head are compared when both are set; otherwise RevRange
+	// ("main...HEAD", or a single commit for its own change) is used, and
+	// when that is empty too the base is detected as origin/HEAD, main, or
+	// master.
+	Base, Head string
+	RevRange   string
+	// NoMergeBase compares Base and Head directly (base..head) instead of
+	// from their merge base (base...head).
+	NoMergeBase bool
+	// Staged compares the index against Base (default HEAD); Unstaged
+	// compares the working tree against the index.
+	Staged, Unstaged bool
+
+	Pathspecs []string
+	// Include, Exclude, and Categories filter files as the CLI flags of the
+	// same names do, on top of Config, including category-scoped patterns
+	// such as "tests:**/fixtures/**".
+	Include, Exclude []string
+	Categories       []string
+
+	// Empty is "exclude" (default) or "include" for whitespace-only lines.
+	Empty            string
+	IgnoreWhitespace bool
+	DetectMoves      bool
+	// DiffAlgorithm is git's --diff-algorithm (myers, minimal, patience, or
+	// histogram); empty uses Config's, then git's own setting.
+	DiffAlgorithm string
+	// Shebang detects the language of extensionless files from their #!
+	// line.
+	Shebang bool
+}
+
+// Analyze computes the churn of the change selected by opts. Unlike the
+// CLI, it reports every failure as an error.
+func Analyze(opts Options) (Summary, error) {
+	if opts.Staged && opts.Unstaged {
+		return Summary{}, fmt.Errorf("cannot set both Staged and Unstaged")
+	}
+	runner := opts.Runner
+	if runner == nil {
+		runner = gitdiff.DirRunner{Dir: opts.Dir}
+	}
+
+	cfg, err := loadConfig(opts, runner)
+	if err != nil {
+		return Summary{}, fmt.Errorf("loading config: %w", err)
+	}
+	if cfg, err = WithScopes(runner, cfg); err != nil {
+		return Summary{}, fmt.Errorf("loading config: %w", err)
+	}
+
+	var refRange, base, head string
+	switch {
+	case opts.Unstaged:
+		base, head = "INDEX", Worktree
+	case opts.Staged:
+		refRange = opts.Base
+		if refRange == "" {
+			refRange = "HEAD"
+		}
+		base, head = refRange, "INDEX"
+	case opts.NoMergeBase && opts.Base != "" && opts.Head != "":
+		refRange = opts.Base + ".." + opts.Head
+		base, head = opts.Base, opts.Head
+	default:
+		refRange, err = gitdiff.ResolveRefs(runner, opts.Base, opts.Head, opts.RevRange)
+		if err != nil {
+			return Summary{}, err
+		}
+		base, head = ParseRefRange(refRange)
+	}
+
+	if cfg.DiffAlgorithm != "" && !slices.Contains(gitdiff.DiffAlgorithms, cfg.DiffAlgorithm) {
+		return Summary{}, fmt.Errorf("unknown diff algorithm %q (available: %s)", cfg.DiffAlgorithm, strings.Join(gitdiff.DiffAlgorithms, ", "))
+	}
+	diffOpts := DiffOptions{
+		Cached:           opts.Staged,
+		IgnoreWhitespace: cfg.IgnoreWhitespace != nil && *cfg.IgnoreWhitespace,
+		Algorithm:        cfg.DiffAlgorithm,
+	}
+	var firstLine LineReader
+	if opts.Shebang {
+		rev := head
+		switch {
+		case opts.Staged:
+			rev = ""
+		case rev == "":
+			rev = "HEAD"
+		}
+		firstLine = HeadFirstLine(runner, rev)
+	}
+	// Files are summarized as they are parsed, so the whole diff is never
+	// held in memory.
+	var risky []RiskyStatement
+	summarizer := NewSummarizer(runner, cfg, opts.Categories, firstLine)
+	err = StreamStats(runner, refRange, opts.Pathspecs, diffOpts, ParseOptions{
+		Empty:       cfg.Empty,
+		DetectMoves: opts.DetectMoves,
+		AddedLine: func(path string, line int, content string) {
+			risky = append(risky, migrate.Check(path, line, content)...)
+		},
+	}, summarizer.Add)
+	if err != nil {
+		return Summary{}, err
+	}
+	summary := summarizer.Summary()
+	summary.Meta = Meta{
+		Base:             base,
+		Head:             head,
+		Empty:            cfg.Empty,
+		IgnoreWhitespace: diffOpts.IgnoreWhitespace,
+		DiffAlgorithm:    gitdiff.EffectiveAlgorithm(runner, diffOpts.Algorithm),
+		DetectMoves:      opts.DetectMoves,
+		Pathspecs:        opts.Pathspecs,
+		Timestamp:        output.Now().Format(time.RFC3339),
+		Warnings:         append(MigrationWarnings(risky, summary), ConfigWarnings(cfg)...),
+	}
+	if !opts.Staged && !opts.Unstaged {
+		summary.Meta.MergeBase = RangeMergeBase(refRange)
+	}
+	output.SetLinks(&summary, cfg.LinkTemplate)
+	return summary, nil
+}
+
+// loadConfig returns opts.Config, or the configuration of the repository
+// runner works in, with the filter options applied on top.
+func loadConfig(opts Options, runner Runner) (Config, error) {
+	overrides := Config{
+		Include:       opts.Include,
+		Exclude:       opts.Exclude,
+		Empty:         opts.Empty,
+		DiffAlgorithm: opts.DiffAlgorithm,
+	}
+	if opts.IgnoreWhitespace {
+		overrides.IgnoreWhitespace = &opts.IgnoreWhitespace
+	}
+	if opts.Config != nil {
+		cfg := *opts.Config
+		cfg.Include = append(cfg.Include, opts.Include...)
+		cfg.Exclude = append(cfg.Exclude, opts.Exclude...)
+		if opts.Empty != "" {
+			cfg.Empty = opts.Empty
+		}
+		if cfg.Empty == "" {
+			cfg.Empty = "exclude"
+		}
+		if opts.IgnoreWhitespace {
+			cfg.IgnoreWhitespace = overrides.IgnoreWhitespace
+		}
+		if opts.DiffAlgorithm != "" {
+			cfg.DiffAlgorithm = opts.DiffAlgorithm
+		}
+		return cfg, nil
+	}
+	dir := opts.Dir
+	if top, err := gitdiff.TopLevel(runner); err == nil {
+		dir = top
+	} else if dir == "" {
+		if dir, err = os.Getwd(); err != nil {
+			return Config{}, err
+		}
+	}
+	return config.Load(dir, overrides)
+}
+
+// MigrationWarnings converts risky migration statements found in added lines
+// into warnings, keeping those in files that survived filtering.
+func MigrationWarnings(warnings []RiskyStatement, summary Summary) []Warning {
+	kept := make(map[string]bool, len(summary.FileStats))
+	for _, f := range summary.FileStats {
+		kept[f.Path] = true
+	}
+	var result []Warning
+	for _, w := range warnings {
+		if kept[w.Path] {
+			result = append(result, Warning{Severity: output.SeverityWarning, Path: w.Path, Line: w.Line, Rule: w.Rule, Message: w.Message})
+		}
+	}
+	return result
+}
+
+// ConfigWarnings converts the upgrade guidance gathered while loading cfg
+// from config files written for an older schema version into warnings.
+func ConfigWarnings(cfg Config) []Warning {
+	var result []Warning
+	for _, n := range cfg.UpgradeNotices() {
+		result = append(result, Warning{Severity: output.SeverityInfo, Rule: "config-version", Message: n})
+	}
+	return result
+}
+
+// Classify returns the category and language differ assigns to path under
+// cfg, without consulting the repository.
+func Classify(cfg Config, path string) (category, language string) {
+	return classify.New(cfg).Classify(path)
+}
+
+// Categories lists the built-in categories in first-match priority order.
+func Categories() []string {
+	return append([]string(nil), classify.Categories...)
+}
+
+// RenderText writes summary as differ's text report.
+func RenderText(w io.Writer, summary Summary, opts RenderOptions) {
+	output.RenderText(w, summary, opts)
+}
+
+// RenderJSON writes summary as differ's JSON report.
+func RenderJSON(w io.Writer, summary Summary) error {
+	return output.RenderJSON(w, summary)
+}
+
+// RegisterRenderer makes r available to Render as the output format name,
+// replacing any format already registered under it.
+func RegisterRenderer(name string, r Renderer) {
+	output.RegisterRenderer(name, r)
+}
+
+// Formats lists the registered output formats: the built-in text, json,
+// markdown, csv, table, html, and prometheus, plus any added with
+// RegisterRenderer.
+func Formats() []string {
+	return output.Formats()
+}
+
+// Render writes summary in the named format.
+func Render(w io.Writer, format string, summary Summary, opts RenderOptions) error {
+	r, err := output.LookupRenderer(format)
+	if err != nil {
+		return err
+	}
+	return r.Render(w, summary, opts)
+}
@@ -0,0 +1,305 @@
+package differ
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jbonatakis/differ/internal/gitdiff"
+)
+
+// setupRepo creates a repository with a commit on main and a feature commit
+// on top, and returns its directory.
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init")
+	git("checkout", "-b", "main")
+	write("main.go", "package main\n")
+	git("add", "-A")
+	git("commit", "-m", "initial")
+	git("checkout", "-b", "feature")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("README.md", "# App\n")
+	write("main_test.go", "package main\n")
+	git("add", "-A")
+	git("commit", "-m", "feature")
+	return dir
+}
+
+func TestAnalyze(t *testing.T) {
+	dir := setupRepo(t)
+
+	summary, err := Analyze(Options{Dir: dir, Base: "main", Head: "feature"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Totals.FileCount != 3 || summary.Totals.Added != 3 {
+		t.Errorf("totals = %+v, want 3 files and 3 added lines", summary.Totals)
+	}
+	if got := summary.CategoryTotals["source"].Added; got != 1 {
+		t.Errorf("source added = %d, want 1", got)
+	}
+	if summary.Meta.Base != "main" || summary.Meta.Head != "feature" {
+		t.Errorf("meta = %+v, want main...feature", summary.Meta)
+	}
+
+	// Filters apply as on the command line.
+	summary, err = Analyze(Options{Dir: dir, RevRange: "main...feature", Categories: []string{"docs"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.FileStats) != 1 || summary.FileStats[0].Path != "README.md" {
+		t.Errorf("files = %+v, want README.md only", summary.FileStats)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, summary); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"README.md"`) {
+		t.Errorf("JSON missing README.md:\n%s", buf.String())
+	}
+}
+
+func TestAnalyzeReportsErrors(t *testing.T) {
+	dir := setupRepo(t)
+	if _, err := Analyze(Options{Dir: dir, Base: "main", Head: "no-such-branch"}); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+	if _, err := Analyze(Options{Dir: dir, Staged: true, Unstaged: true}); err == nil {
+		t.Error("expected an error for Staged with Unstaged")
+	}
+}
+
+func TestAnalyzeWithConfig(t *testing.T) {
+	dir := setupRepo(t)
+	cfg := Config{Categories: map[string]CategoryConfig{
+		"docs": {Patterns: []string{"main.go"}},
+	}}
+	summary, err := Analyze(Options{Dir: dir, Base: "main", Head: "feature", Config: &cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := summary.CategoryTotals["docs"].FileCount; got != 2 {
+		t.Errorf("docs files = %d, want 2", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	parsed := []FileDiff{
+		{Path: "main.go", Added: 3, Deleted: 1, Churn: 4},
+		{Path: "docs/guide.md", Added: 2, Churn: 2},
+	}
+	summary := Summarize(failingRunner{}, parsed, Config{}, nil, nil)
+	if summary.Totals.Churn != 6 || summary.Totals.FileCount != 2 {
+		t.Errorf("totals = %+v", summary.Totals)
+	}
+	if summary.CategoryTotals["docs"].Churn != 2 {
+		t.Errorf("docs = %+v", summary.CategoryTotals["docs"])
+	}
+}
+
+func TestSummarizeMinified(t *testing.T) {
+	parsed := []FileDiff{
+		{Path: "web/app.js", Added: 3, Churn: 3, LongestLine: 4800},
+		{Path: "web/main.js", Added: 40, Deleted: 2, Churn: 42, LongestLine: 96},
+	}
+	summary := Summarize(failingRunner{}, parsed, Config{}, nil, nil)
+	if got := summary.FileStats[0].Category; got != "generated" {
+		t.Errorf("web/app.js category = %q, want generated", got)
+	}
+	if got := summary.FileStats[1].Category; got != "source" {
+		t.Errorf("web/main.js category = %q, want source", got)
+	}
+
+	off := 0
+	summary = Summarize(failingRunner{}, parsed, Config{MinifiedLineLength: &off}, nil, nil)
+	if got := summary.FileStats[0].Category; got != "source" {
+		t.Errorf("web/app.js category with the check off = %q, want source", got)
+	}
+}
+
+func TestSummarizerBatches(t *testing.T) {
+	// Enough files for several batches, every other one filtered out.
+	s := NewSummarizer(failingRunner{}, Config{Exclude: []string{"docs/**"}}, nil, nil)
+	for i := range 2*summarizeBatch + 10 {
+		path := fmt.Sprintf("pkg/f%d.go", i)
+		if i%2 == 1 {
+			path = fmt.Sprintf("docs/f%d.md", i)
+		}
+		s.Add(FileDiff{Path: path, Added: 1, Churn: 1})
+	}
+	summary := s.Summary()
+	if want := summarizeBatch + 5; summary.Totals.FileCount != want || summary.Totals.Churn != want || len(summary.FileStats) != want {
+		t.Errorf("totals = %+v with %d files, want %d of each", summary.Totals, len(summary.FileStats), want)
+	}
+	if summary.FileStats[0].Path != "pkg/f0.go" || summary.FileStats[1].Path != "pkg/f2.go" {
+		t.Errorf("files out of order: %s, %s", summary.FileStats[0].Path, summary.FileStats[1].Path)
+	}
+	if got := summary.CategoryTotals["source"].FileCount; got != summarizeBatch+5 {
+		t.Errorf("source files = %d, want %d", got, summarizeBatch+5)
+	}
+}
+
+func TestNumstatStatsMatchesDiffStats(t *testing.T) {
+	dir := setupRepo(t)
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	files := map[string]string{
+		"notes.md":  "-- keep\n++ counter\n\n",
+		"logo.png":  "\x89PNG\x00\x01",
+		"script.sh": "echo one\necho two\necho three\necho four\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("add", "-A")
+	run("commit", "-qm", "more")
+	os.WriteFile(filepath.Join(dir, "notes.md"), []byte("--- drop\n+++ add\n\n  \n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "logo.png"), []byte("\x89PNG\x00\x02"), 0o644)
+	run("mv", "script.sh", "run.sh")
+	os.WriteFile(filepath.Join(dir, "run.sh"), []byte("echo one\necho two\necho three\necho 4\n"), 0o644)
+	os.Remove(filepath.Join(dir, "README.md"))
+	if err := os.Symlink("main.go", filepath.Join(dir, "README.md")); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "-A")
+	run("commit", "-qm", "change")
+
+	runner := gitdiff.DirRunner{Dir: dir}
+	full, err := DiffStats(runner, "main..HEAD", nil, DiffOptions{}, ParseOptions{Empty: "include"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, err := NumstatStats(runner, "main..HEAD", nil, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// git's counts carry no line contents, so no line lengths.
+	for i := range full {
+		full[i].LongestLine = 0
+	}
+	if !reflect.DeepEqual(fast, full) {
+		t.Errorf("NumstatStats = %+v\nDiffStats = %+v", fast, full)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	if cat, lang := Classify(Config{}, "cmd/app/main.go"); cat != "source" || lang != "Go" {
+		t.Errorf("Classify = (%q, %q), want (source, Go)", cat, lang)
+	}
+}
+
+func TestParseRefRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantBase string
+		wantHead string
+	}{
+		{"main...HEAD", "main", "HEAD"},
+		{"abc123..def456", "abc123", "def456"},
+		{"single-ref", "single-ref", ""},
+	}
+
+	for _, tt := range tests {
+		base, head := ParseRefRange(tt.input)
+		if base != tt.wantBase || head != tt.wantHead {
+			t.Errorf("ParseRefRange(%q) = (%q, %q), want (%q, %q)",
+				tt.input, base, head, tt.wantBase, tt.wantHead)
+		}
+	}
+}
+
+func TestRangeMergeBase(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"main...HEAD", "true"},
+		{"abc123..def456", "false"},
+		{"single-ref", "nil"},
+	}
+
+	for _, tt := range tests {
+		got := "nil"
+		if mb := RangeMergeBase(tt.input); mb != nil {
+			got = fmt.Sprint(*mb)
+		}
+		if got != tt.want {
+			t.Errorf("RangeMergeBase(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+// failingRunner fails every git call, as outside a repository.
+type failingRunner struct{}
+
+func (failingRunner) Run(name string, args ...string) ([]byte, error) {
+	return nil, errors.New("no repository")
+}
+
+func (failingRunner) Start(name string, args ...string) (io.ReadCloser, *exec.Cmd, error) {
+	return nil, nil, errors.New("no repository")
+}
+
+func TestRender(t *testing.T) {
+	RegisterRenderer("count", RendererFunc(func(w io.Writer, s Summary, _ RenderOptions) error {
+		_, err := io.WriteString(w, strings.Repeat("x", s.Totals.FileCount))
+		return err
+	}))
+
+	var buf bytes.Buffer
+	summary := Summary{Totals: CategoryTotal{FileCount: 3}}
+	if err := Render(&buf, "count", summary, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "xxx" {
+		t.Errorf("custom renderer output = %q, want xxx", buf.String())
+	}
+	if err := Render(&buf, "nope", summary, RenderOptions{}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
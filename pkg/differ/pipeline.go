@@ -0,0 +1,323 @@
+package differ
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jbonatakis/differ/internal/classify"
+	"github.com/jbonatakis/differ/internal/config"
+	"github.com/jbonatakis/differ/internal/filter"
+	"github.com/jbonatakis/differ/internal/gitdiff"
+	"github.com/jbonatakis/differ/internal/parser"
+)
+
+// LineReader returns the first line of a file on the head side of a diff.
+type LineReader func(path string) (string, error)
+
+// Worktree names the working tree where a revision is expected, as in
+// HeadFirstLine.
+const Worktree = "WORKTREE"
+
+// HeadFirstLine returns a LineReader for files at rev: a revision, "" for
+// the index, or Worktree. Worktree files are read from disk under the
+// repository root.
+func HeadFirstLine(runner Runner, rev string) LineReader {
+	if rev != Worktree {
+		return func(path string) (string, error) {
+			return gitdiff.FirstLine(runner, rev+":"+path)
+		}
+	}
+	top, topErr := gitdiff.TopLevel(runner)
+	return func(path string) (string, error) {
+		if topErr != nil {
+			return "", topErr
+		}
+		// git records a symlink's target, not its contents, so a
+		// symlink is never read through to classify it as its target.
+		name := filepath.Join(top, filepath.FromSlash(path))
+		info, err := os.Lstat(name)
+		if err != nil {
+			return "", err
+		}
+		if !info.Mode().IsRegular() {
+			return "", fmt.Errorf("%s is not a regular file", path)
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		buf := make([]byte, 1024)
+		n, err := io.ReadFull(f, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return "", err
+		}
+		line, _, _ := strings.Cut(string(buf[:n]), "\n")
+		return strings.TrimSuffix(line, "\r"), nil
+	}
+}
+
+// shebangs detects the languages of the extensionless files among paths
+// from their first lines. Files that cannot be read, such as deleted ones,
+// are skipped.
+func shebangs(paths []string, firstLine LineReader) map[string]string {
+	langs := make(map[string]string)
+	for _, p := range paths {
+		if filepath.Ext(p) != "" {
+			continue
+		}
+		line, err := firstLine(p)
+		if err != nil {
+			continue
+		}
+		if lang := classify.ShebangLanguage(line); lang != "" {
+			langs[p] = lang
+		}
+	}
+	return langs
+}
+
+// WithScopes adds the repository's per-directory .differ.yml files to cfg
+// as scopes. If they cannot be listed, for example outside a git repository,
+// cfg is returned unchanged.
+func WithScopes(runner Runner, cfg Config) (Config, error) {
+	paths, err := gitdiff.ListFiles(runner, "**/.differ.yml")
+	if err != nil {
+		return cfg, nil
+	}
+	top, err := gitdiff.TopLevel(runner)
+	if err != nil {
+		return cfg, nil
+	}
+	cfg.Scopes, err = config.LoadScopes(top, paths)
+	return cfg, err
+}
+
+// DiffStats runs git diff for refRange and parses it into per-file stats.
+func DiffStats(runner Runner, refRange string, pathspecs []string, diffOpts DiffOptions, parseOpts ParseOptions) ([]FileDiff, error) {
+	var parsed []FileDiff
+	err := StreamStats(runner, refRange, pathspecs, diffOpts, parseOpts, func(fs FileDiff) error {
+		parsed = append(parsed, fs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// StreamStats is DiffStats calling emit with each file as it is parsed
+// instead of collecting them. An error from emit stops the diff and is
+// returned.
+func StreamStats(runner Runner, refRange string, pathspecs []string, diffOpts DiffOptions, parseOpts ParseOptions, emit func(FileDiff) error) error {
+	diffResult, err := gitdiff.RunDiffWithOptions(runner, refRange, pathspecs, diffOpts)
+	if err != nil {
+		return fmt.Errorf("running git diff: %w", err)
+	}
+	return finishDiff(diffResult, parser.ParseFunc(diffResult.Stdout, parseOpts, emit))
+}
+
+// NumstatStats is DiffStats from git's per-file line counts rather than a
+// parsed patch, which is much faster on large ranges. Every changed line is
+// counted, as with the "include" empty mode, and nothing per-line, such as
+// moved-line detection, is available.
+func NumstatStats(runner Runner, refRange string, pathspecs []string, diffOpts DiffOptions) ([]FileDiff, error) {
+	var parsed []FileDiff
+	err := StreamNumstat(runner, refRange, pathspecs, diffOpts, func(fs FileDiff) error {
+		parsed = append(parsed, fs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// StreamNumstat is NumstatStats calling emit with each file as it is read.
+func StreamNumstat(runner Runner, refRange string, pathspecs []string, diffOpts DiffOptions, emit func(FileDiff) error) error {
+	diffResult, err := gitdiff.RunNumstat(runner, refRange, pathspecs, diffOpts)
+	if err != nil {
+		return fmt.Errorf("running git diff: %w", err)
+	}
+	return finishDiff(diffResult, parser.ParseNumstatFunc(diffResult.Stdout, emit))
+}
+
+// finishDiff waits for git after its output was parsed with parseErr. When
+// parsing stopped early, the rest of the output is drained so that git can
+// exit.
+func finishDiff(diffResult *gitdiff.DiffResult, parseErr error) error {
+	if parseErr != nil {
+		io.Copy(io.Discard, diffResult.Stdout)
+		diffResult.Wait()
+		return fmt.Errorf("parsing diff: %w", parseErr)
+	}
+	return diffResult.Wait()
+}
+
+// summarizeBatch is how many parsed files a Summarizer classifies at a time:
+// enough to keep git check-attr calls few, few enough to bound memory.
+const summarizeBatch = 1024
+
+// Summarizer classifies, filters, and aggregates parsed files as they are
+// added, in batches, so that a summary can be built while a diff streams by
+// without holding every parsed file.
+type Summarizer struct {
+	runner    Runner
+	firstLine LineReader
+
+	classifier *classify.Classifier
+	filterCfg  filter.FilterConfig
+	// minifiedLength is the changed-line length past which a file is
+	// classified as minified; 0 turns the check off.
+	minifiedLength int
+	pending        []FileDiff
+	summary        Summary
+}
+
+// NewSummarizer returns a Summarizer applying cfg and the categories
+// filter. If firstLine is set, extensionless files are classified by their
+// shebang line. Files whose changed lines look minified, by
+// cfg.MinifiedLineLength, are classified as generated.
+func NewSummarizer(runner Runner, cfg Config, categories []string, firstLine LineReader) *Summarizer {
+	return &Summarizer{
+		runner:     runner,
+		firstLine:  firstLine,
+		classifier: classify.New(cfg),
+		filterCfg: filter.FilterConfig{
+			Include:    cfg.Include,
+			Exclude:    cfg.Exclude,
+			Categories: categories,
+			Scopes:     cfg.Scopes,
+		},
+		minifiedLength: classify.MinifiedLineLength(cfg),
+		summary:        Summary{CategoryTotals: make(map[string]CategoryTotal)},
+	}
+}
+
+// Add adds a parsed file. It has the signature of StreamStats' emit
+// function and never fails.
+func (s *Summarizer) Add(fs FileDiff) error {
+	s.pending = append(s.pending, fs)
+	if len(s.pending) >= summarizeBatch {
+		s.flush()
+	}
+	return nil
+}
+
+// Summary returns the summary of the files added so far. Meta is left for
+// the caller to fill in.
+func (s *Summarizer) Summary() Summary {
+	s.flush()
+	summary := s.summary
+	summary.Totals.Churn = summary.Totals.Added + summary.Totals.Deleted
+	if summary.FileStats == nil {
+		summary.FileStats = []FileStat{}
+	}
+	return summary
+}
+
+// flush classifies, filters, and aggregates the pending files.
+func (s *Summarizer) flush() {
+	if len(s.pending) == 0 {
+		return
+	}
+	// gitattributes refine classification; if they cannot be read, the
+	// path-based rules alone still apply.
+	paths := make([]string, 0, len(s.pending))
+	minified := make(map[string]bool)
+	for _, fs := range s.pending {
+		paths = append(paths, fs.Path)
+		if classify.Minified(fs.LongestLine, fs.Added, fs.Deleted, s.minifiedLength) {
+			minified[filepath.ToSlash(fs.Path)] = true
+		}
+	}
+	attrs, err := gitdiff.CheckAttr(s.runner, paths, classify.Attributes)
+	if err != nil {
+		attrs = nil
+	}
+	s.classifier.SetAttributes(attrs)
+	s.classifier.SetMinified(minified)
+	if s.firstLine != nil {
+		s.classifier.SetShebangs(shebangs(paths, s.firstLine))
+	}
+
+	filtered := filter.Filter(s.pending, s.filterCfg, func(path string) string {
+		cat, _ := s.classifier.Classify(path)
+		return cat
+	})
+	s.pending = s.pending[:0]
+
+	for _, fs := range filtered {
+		cat, lang := s.classifier.Classify(fs.Path)
+		s.summary.FileStats = append(s.summary.FileStats, FileStat{
+			Path:       fs.Path,
+			Added:      fs.Added,
+			Deleted:    fs.Deleted,
+			Churn:      fs.Churn,
+			Moved:      fs.Moved,
+			RawAdded:   fs.RawAdded,
+			RawDeleted: fs.RawDeleted,
+			Category:   cat,
+			Language:   lang,
+			OldPath:    fs.OldPath,
+			OldMode:    fs.OldMode,
+			NewMode:    fs.NewMode,
+			Status:     fs.Status,
+		})
+
+		ct := s.summary.CategoryTotals[cat]
+		ct.Added += fs.Added
+		ct.Deleted += fs.Deleted
+		ct.Churn += fs.Churn
+		ct.Moved += fs.Moved
+		ct.RawAdded += fs.RawAdded
+		ct.RawDeleted += fs.RawDeleted
+		ct.FileCount++
+		s.summary.CategoryTotals[cat] = ct
+
+		s.summary.Totals.Added += fs.Added
+		s.summary.Totals.Deleted += fs.Deleted
+		s.summary.Totals.Moved += fs.Moved
+		s.summary.Totals.RawAdded += fs.RawAdded
+		s.summary.Totals.RawDeleted += fs.RawDeleted
+		s.summary.Totals.FileCount++
+	}
+}
+
+// Summarize classifies and filters parsed stats and aggregates them into a
+// summary. Meta is left for the caller to fill in. If firstLine is set,
+// extensionless files are classified by their shebang line.
+func Summarize(runner Runner, parsed []FileDiff, cfg Config, categories []string, firstLine LineReader) Summary {
+	s := NewSummarizer(runner, cfg, categories, firstLine)
+	for _, fs := range parsed {
+		s.Add(fs)
+	}
+	return s.Summary()
+}
+
+// RangeMergeBase reports how refRange compares its two refs: true for
+// "base...head", which compares from their merge base, and false for
+// "base..head", which compares them directly. It returns nil for anything
+// else, such as a single ref.
+func RangeMergeBase(refRange string) *bool {
+	base, head := ParseRefRange(refRange)
+	if base == "" || head == "" {
+		return nil
+	}
+	threeDot := strings.Contains(refRange, "...")
+	return &threeDot
+}
+
+// ParseRefRange splits "base...head" into base and head parts.
+func ParseRefRange(refRange string) (string, string) {
+	if parts := strings.SplitN(refRange, "...", 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	if parts := strings.SplitN(refRange, "..", 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return refRange, ""
+}